@@ -0,0 +1,168 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/cmd/provider/common"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+)
+
+// outcomeOrder lists cache outcomes in the order stats are reported
+var outcomeOrder = []provider.CacheOutcome{
+	provider.CacheHit,
+	provider.CacheMiss,
+	provider.CacheStaleRefresh,
+	provider.CacheBypass,
+}
+
+func NewCommand(flags *common.Flags) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Inspect the token cache",
+	}
+
+	cmd.AddCommand(newStatsCommand(flags))
+
+	return cmd
+}
+
+func newStatsCommand(flags *common.Flags) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "stats",
+		Short: "Report token request latency by cache outcome",
+		Long: `Report the token requests served through --token-cache in the cache directory:
+the number of requests, and their mean and longest duration, by provider and cache
+outcome (hit, miss, stale_refresh, bypass). The stats are kept across get-token and
+refresh runs.
+
+Examples:
+  hyperfleet-credential-provider cache stats
+  hyperfleet-credential-provider cache stats --cache-dir=/var/cache/hfcp --output=json
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runStats(flags, cmd.OutOrStdout())
+		},
+	}
+
+	cmd.Flags().String("cache-dir", "", "Cache directory (default: the user cache directory, e.g. ~/.cache/hyperfleet-credential-provider)")
+	cmd.Flags().String("output", "text", "Output format (text, json)")
+
+	return cmd
+}
+
+func runStats(flags *common.Flags, w io.Writer) error {
+	output := flags.Viper.GetString("output")
+	if output != "text" && output != "json" {
+		return errors.New(
+			errors.ErrInvalidArgument,
+			fmt.Sprintf("invalid --output %q (must be one of: text, json)", output),
+		)
+	}
+
+	dir := flags.Viper.GetString("cache-dir")
+	if dir == "" {
+		var err error
+		if dir, err = common.DefaultCacheDir(); err != nil {
+			return errors.Wrap(
+				errors.ErrInvalidArgument,
+				err,
+				"--cache-dir is required when there is no user cache directory",
+			)
+		}
+	}
+
+	stats, err := common.ReadTokenStats(dir)
+	if err != nil {
+		return err
+	}
+
+	if output == "json" {
+		data, err := json.MarshalIndent(stats, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal cache stats: %w", err)
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	}
+	return writeStats(w, stats, dir)
+}
+
+// writeStats writes stats as a table with the hit ratio of each provider
+func writeStats(w io.Writer, stats *common.TokenStats, dir string) error {
+	if len(stats.Providers) == 0 {
+		_, err := fmt.Fprintf(w, "No token requests recorded in %s\n", dir)
+		return err
+	}
+
+	providers := make([]string, 0, len(stats.Providers))
+	for name := range stats.Providers {
+		providers = append(providers, name)
+	}
+	sort.Strings(providers)
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "PROVIDER\tOUTCOME\tREQUESTS\tMEAN\tMAX")
+	for _, name := range providers {
+		for _, outcome := range outcomes(stats.Providers[name]) {
+			s := stats.Providers[name][outcome]
+			fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%s\n", name, outcome, s.Requests,
+				roundDuration(s.Mean()), roundDuration(time.Duration(s.MaxSeconds*float64(time.Second))))
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(w)
+	for _, name := range providers {
+		var total int64
+		for _, s := range stats.Providers[name] {
+			total += s.Requests
+		}
+		hits := stats.Providers[name][string(provider.CacheHit)].Requests
+		fmt.Fprintf(w, "%s: %.1f%% of %d requests served from the cache\n", name, 100*float64(hits)/float64(total), total)
+	}
+	return nil
+}
+
+// outcomes returns the outcomes of byOutcome in report order, unknown ones last
+func outcomes(byOutcome map[string]common.TokenOutcomeStats) []string {
+	var known, unknown []string
+	for _, outcome := range outcomeOrder {
+		if _, ok := byOutcome[string(outcome)]; ok {
+			known = append(known, string(outcome))
+		}
+	}
+	for outcome := range byOutcome {
+		if !isKnownOutcome(outcome) {
+			unknown = append(unknown, outcome)
+		}
+	}
+	sort.Strings(unknown)
+	return append(known, unknown...)
+}
+
+func isKnownOutcome(outcome string) bool {
+	for _, known := range outcomeOrder {
+		if string(known) == outcome {
+			return true
+		}
+	}
+	return false
+}
+
+// roundDuration rounds d for display
+func roundDuration(d time.Duration) time.Duration {
+	if d >= time.Second {
+		return d.Round(time.Millisecond)
+	}
+	return d.Round(time.Microsecond)
+}
@@ -0,0 +1,79 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/cmd/provider/common"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+)
+
+// runCacheStats runs cache stats the way main does and returns its stdout
+func runCacheStats(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+
+	flags := &common.Flags{}
+	root := &cobra.Command{
+		Use:           "hyperfleet-credential-provider",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			flags.Viper = common.NewViper(cmd)
+		},
+	}
+	root.AddCommand(NewCommand(flags))
+	root.SetArgs(append([]string{"cache", "stats"}, args...))
+
+	var out bytes.Buffer
+	root.SetOut(&out)
+	err := root.Execute()
+	return out.String(), err
+}
+
+func TestCacheStats(t *testing.T) {
+	dir := t.TempDir()
+	recorder := common.NewTokenStatsRecorder(dir, logger.Nop())
+	recorder.RecordTokenRequestDuration("aws", "miss", 400*time.Millisecond)
+	recorder.RecordTokenRequestDuration("aws", "hit", 2*time.Millisecond)
+	recorder.RecordTokenRequestDuration("aws", "hit", 4*time.Millisecond)
+	recorder.RecordTokenRequestDuration("aws", "stale_refresh", 300*time.Millisecond)
+	recorder.RecordTokenRequestDuration("gcp", "bypass", 250*time.Millisecond)
+
+	out, err := runCacheStats(t, "--cache-dir="+dir)
+	require.NoError(t, err)
+	assert.Equal(t, `PROVIDER  OUTCOME        REQUESTS  MEAN   MAX
+aws       hit            2         3ms    4ms
+aws       miss           1         400ms  400ms
+aws       stale_refresh  1         300ms  300ms
+gcp       bypass         1         250ms  250ms
+
+aws: 50.0% of 4 requests served from the cache
+gcp: 0.0% of 1 requests served from the cache
+`, out)
+
+	out, err = runCacheStats(t, "--cache-dir="+dir, "--output=json")
+	require.NoError(t, err)
+	var stats common.TokenStats
+	require.NoError(t, json.Unmarshal([]byte(out), &stats))
+	assert.Equal(t, int64(2), stats.Providers["aws"]["hit"].Requests)
+}
+
+func TestCacheStats_Empty(t *testing.T) {
+	dir := t.TempDir()
+	out, err := runCacheStats(t, "--cache-dir="+dir)
+	require.NoError(t, err)
+	assert.Equal(t, "No token requests recorded in "+dir+"\n", out)
+}
+
+func TestCacheStats_InvalidOutput(t *testing.T) {
+	_, err := runCacheStats(t, "--cache-dir="+t.TempDir(), "--output=yaml")
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, errors.ErrInvalidArgument))
+}
@@ -2,43 +2,38 @@ package cluster
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"os"
-	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/cmd/provider/common"
-	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider/aws"
-	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider/azure"
-	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider/gcp"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
 	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
 )
 
-func NewCommand(flags *common.Flags) *cobra.Command {
-	cmd := &cobra.Command{
-		Use:   "get-cluster-info",
-		Short: "Get cluster information (endpoint, CA certificate)",
-		Long: `Get cluster information including API server endpoint and CA certificate.
+var (
+	// outputCAFile also receives the cluster CA as PEM when set
+	outputCAFile string
 
-This command uses cloud provider SDKs (no CLI required) to fetch cluster details.
+	// outputFormat selects the encoder for the cluster info
+	outputFormat string
+)
 
-Examples:
-  # GCP/GKE
+// examples are the per-provider get-cluster-info examples shown in help
+var examples = map[string]string{
+	"gcp": `  # GCP/GKE
   hyperfleet-credential-provider get-cluster-info \
     --provider=gcp \
     --cluster-name=my-cluster \
     --project-id=my-project \
-    --region=us-central1
-
-  # AWS/EKS
+    --region=us-central1`,
+	"aws": `  # AWS/EKS
   hyperfleet-credential-provider get-cluster-info \
     --provider=aws \
     --cluster-name=my-cluster \
-    --region=us-east-1
-
-  # Azure/AKS
+    --region=us-east-1`,
+	"azure": `  # Azure/AKS
   hyperfleet-credential-provider get-cluster-info \
     --provider=azure \
     --cluster-name=my-cluster \
@@ -46,6 +41,46 @@ Examples:
     --tenant-id=xxx \
     --resource-group=my-rg
 
+  # Azure/AKS by resource ID, using the private FQDN of a private cluster
+  hyperfleet-credential-provider get-cluster-info \
+    --provider=azure \
+    --cluster-resource-id=/subscriptions/xxx/resourceGroups/my-rg/providers/Microsoft.ContainerService/managedClusters/my-cluster \
+    --tenant-id=xxx \
+    --endpoint-access=private`,
+	"oci": `  # OCI/OKE, looking the cluster up by name in a compartment
+  hyperfleet-credential-provider get-cluster-info \
+    --provider=oci \
+    --cluster-name=my-cluster \
+    --compartment-id=ocid1.compartment.oc1..aaaa... \
+    --region=us-ashburn-1`,
+	"digitalocean": `  # DigitalOcean/DOKS
+  hyperfleet-credential-provider get-cluster-info \
+    --provider=digitalocean \
+    --cluster-name=my-cluster`,
+}
+
+func NewCommand(flags *common.Flags) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "get-cluster-info",
+		Short: "Get cluster information (endpoint, CA certificate)",
+		Long: `Get cluster information including API server endpoint and CA certificate.
+
+This command uses cloud provider SDKs (no CLI required) to fetch cluster details.
+
+Pass --provider with --help to list only that provider's flags.`,
+		Example: `  # Save the output on a bastion and generate a kubeconfig offline later
+  hyperfleet-credential-provider get-cluster-info ... > cluster-info.json
+  hyperfleet-credential-provider generate-kubeconfig ... --cluster-info-file=cluster-info.json
+
+  # Export CLUSTER_ENDPOINT, CLUSTER_CA, ... into a shell
+  eval "$(hyperfleet-credential-provider get-cluster-info ... --output=env)"
+
+  # Check that the endpoint presents a certificate signed by the returned CA
+  hyperfleet-credential-provider get-cluster-info ... --verify-endpoint
+
+  # Print only the endpoint
+  hyperfleet-credential-provider get-cluster-info ... --output='go-template={{.Endpoint}}'
+
   # Output example:
   {
     "endpoint": "https://34.68.222.124",
@@ -57,17 +92,38 @@ Examples:
 		},
 	}
 
-	cmd.Flags().StringVar(&flags.ProviderName, "provider", "", "Cloud provider (gcp, aws, azure) [required]")
+	cmd.Flags().StringVar(&flags.ProviderName, "provider", "", "Cloud provider (gcp, aws, azure, oci, digitalocean) [required]")
 	cmd.Flags().StringVar(&flags.ClusterName, "cluster-name", "", "Cluster name [required]")
-	cmd.Flags().StringVar(&flags.Region, "region", "", "Cloud region/location [required for GCP/AWS]")
+	cmd.Flags().StringVar(&flags.Region, "region", "", "Cloud region/location [required for GCP/AWS, optional for OCI]")
 	cmd.Flags().StringVar(&flags.ProjectID, "project-id", "", "GCP project ID (required for GCP)")
+	cmd.Flags().StringVar(&flags.GCPUseADC, "gcp-use-adc", "auto", "Use GCP application default credentials: auto (when no credentials file is set), true, or false")
 	cmd.Flags().StringVar(&flags.AccountID, "account-id", "", "AWS account ID (optional)")
+	cmd.Flags().StringVar(&flags.AWSProfile, "profile", "", "AWS shared config profile (default: AWS_PROFILE, then default)")
 	cmd.Flags().StringVar(&flags.SubscriptionID, "subscription-id", "", "Azure subscription ID (required for Azure)")
+	cmd.Flags().StringVar(&flags.AzureCloud, "azure-cloud", "", "Azure cloud (public, usgovernment, china; default: public)")
 	cmd.Flags().StringVar(&flags.TenantID, "tenant-id", "", "Azure tenant ID (required for Azure)")
 	cmd.Flags().StringVar(&flags.ResourceGroup, "resource-group", "", "Azure resource group (required for Azure)")
-
-	// Bind flags to viper for environment variable support
-	common.BindCommandFlags(cmd)
+	cmd.Flags().StringVar(&flags.TenancyID, "tenancy-id", "", "OCI tenancy OCID (default: from the OCI config file)")
+	cmd.Flags().StringVar(&flags.UserID, "user-id", "", "OCI user OCID (default: from the OCI config file)")
+	cmd.Flags().StringVar(&flags.CompartmentID, "compartment-id", "", "OCI compartment OCID (required for OCI when --cluster-name is not a cluster OCID)")
+
+	common.AddEndpointAccessFlag(cmd, flags)
+	common.AddClusterResourceIDFlag(cmd, flags)
+	common.AddVerifyEndpointFlag(cmd, flags)
+	common.AddGKEEndpointFlag(cmd, flags)
+	common.AddSuggestRegionFlag(cmd, flags)
+	cmd.Flags().StringVar(&outputCAFile, "output-ca-file", "", "Also write the cluster CA certificate to this file as PEM")
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "json", "Output format (json, yaml, env, go-template=TEMPLATE)")
+	common.AddClusterInfoCacheFlags(cmd)
+	common.AddPreferSecretFlag(cmd, flags)
+	common.AddCredentialsProfileFlag(cmd, flags)
+
+	common.SetFlagProviders(cmd, []string{"gcp", "aws", "oci"}, "region")
+	common.SetFlagProviders(cmd, []string{"gcp"}, "project-id", "gcp-use-adc")
+	common.SetFlagProviders(cmd, []string{"aws"}, "account-id", "profile")
+	common.SetFlagProviders(cmd, []string{"azure"}, "subscription-id", "tenant-id", "azure-cloud", "resource-group")
+	common.SetFlagProviders(cmd, []string{"oci"}, "tenancy-id", "user-id", "compartment-id")
+	common.SetProviderHelp(cmd, examples)
 
 	// Note: We don't use MarkFlagRequired because Cobra validates before Viper bindings take effect
 	// Instead, we validate in the run function after BindFlagsToViper is called
@@ -79,19 +135,32 @@ func run(flags *common.Flags) error {
 	// Bind Viper values to flags (environment variables take precedence if flags not set)
 	common.BindFlagsToViper(flags)
 
-	if flags.ProviderName == "" {
-		return fmt.Errorf("--provider is required (or set HFCP_PROVIDER)")
+	if err := common.ApplyClusterResourceID(flags); err != nil {
+		return err
+	}
+	if err := common.ValidateInputs(flags, provider.OperationClusterLookup); err != nil {
+		return err
+	}
+	if err := provider.CheckRegistered(flags.ProviderName); err != nil {
+		return err
+	}
+	if _, err := common.ParseEndpointAccess(flags); err != nil {
+		return err
 	}
-	if flags.ClusterName == "" {
-		return fmt.Errorf("--cluster-name is required (or set HFCP_CLUSTER_NAME)")
+	if err := common.ApplyCredentialsMap(flags); err != nil {
+		return err
 	}
 
-	log, err := logger.New(logger.Config{
-		Level:  logger.Level(flags.LogLevel),
-		Format: logger.Format(flags.LogFormat),
-	})
+	encode, err := common.NewClusterInfoEncoder(outputFormat, flags.ProviderName)
 	if err != nil {
-		return fmt.Errorf("failed to initialize logger: %w", err)
+		return err
+	}
+
+	details := clusterInfoDetails(flags)
+
+	log, err := common.CreateLogger(flags)
+	if err != nil {
+		return fmt.Errorf("failed to create logger: %w", err)
 	}
 	defer log.Sync()
 
@@ -102,134 +171,83 @@ func run(flags *common.Flags) error {
 		logger.String("cluster", flags.ClusterName),
 	)
 
-	switch flags.ProviderName {
-	case "gcp":
-		return getGCPClusterInfo(ctx, flags, log)
-	case "aws":
-		return getAWSClusterInfo(ctx, flags, log)
-	case "azure":
-		return getAzureClusterInfo(ctx, flags, log)
-	default:
-		return fmt.Errorf("unsupported provider: %s (must be one of: gcp, aws, azure)", flags.ProviderName)
-	}
-}
-
-func getGCPClusterInfo(ctx context.Context, flags *common.Flags, log logger.Logger) error {
-	if flags.ProjectID == "" {
-		return fmt.Errorf("--project-id is required for GCP")
-	}
-	if flags.Region == "" {
-		return fmt.Errorf("--region is required for GCP (location can be region or zone)")
-	}
-
-	config := &gcp.Config{
-		ProjectID:       flags.ProjectID,
-		CredentialsFile: flags.CredentialsFile,
-		TokenDuration:   1 * time.Hour,
-	}
-	provider, err := gcp.NewProvider(config, log)
+	if flags.DryRun {
+		if outputCAFile != "" {
+			if details == nil {
+				details = map[string]string{}
+			}
+			details["output-ca-file"] = outputCAFile
+		}
+		if flags.EndpointAccess != "" {
+			if details == nil {
+				details = map[string]string{}
+			}
+			details["endpoint-access"] = flags.EndpointAccess
+		}
+		if flags.VerifyEndpoint {
+			if details == nil {
+				details = map[string]string{}
+			}
+			details["verify-endpoint"] = "true"
+		}
+		return common.RunDryRun(ctx, flags, log, os.Stdout, "fetch cluster info", details)
+	}
+
+	cache, err := common.NewClusterInfoCacheFromFlags(flags)
 	if err != nil {
-		return fmt.Errorf("failed to create GCP provider: %w", err)
+		return err
 	}
-
-	info, err := provider.GetClusterInfo(ctx, flags.ClusterName, flags.Region)
-	if err != nil {
-		return fmt.Errorf("failed to get cluster info: %w", err)
-	}
-
-	output := map[string]string{
-		"endpoint":             "https://" + info.Endpoint,
-		"certificateAuthority": info.CertificateAuthority,
-		"version":              info.Version,
-		"location":             info.Location,
-	}
-
-	enc := json.NewEncoder(os.Stdout)
-	enc.SetIndent("", "  ")
-	if err := enc.Encode(output); err != nil {
-		return fmt.Errorf("failed to encode output: %w", err)
-	}
-
-	return nil
-}
-
-func getAWSClusterInfo(ctx context.Context, flags *common.Flags, log logger.Logger) error {
-	if flags.Region == "" {
-		return fmt.Errorf("--region is required for AWS")
-	}
-
-	config := &aws.Config{
-		Region:          flags.Region,
-		CredentialsFile: flags.CredentialsFile,
-		TokenDuration:   15 * time.Minute,
-	}
-	provider, err := aws.NewProvider(config, log)
-	if err != nil {
-		return fmt.Errorf("failed to create AWS provider: %w", err)
+	describe := func() (*common.ClusterInfo, error) {
+		return common.DescribeCluster(ctx, flags, 0, log)
 	}
-
-	info, err := provider.GetClusterInfo(ctx, flags.ClusterName)
+	info, cached, err := common.DescribeClusterCached(cache, flags, log, describe)
 	if err != nil {
 		return fmt.Errorf("failed to get cluster info: %w", err)
 	}
 
-	output := map[string]string{
-		"endpoint":             info.Endpoint,
-		"certificateAuthority": info.CertificateAuthority,
-		"version":              info.Version,
-		"region":               info.Region,
-		"arn":                  info.ARN,
+	if flags.VerifyEndpoint {
+		info, err = common.VerifyClusterInfo(info, cached, cache, flags, log, describe, func(info *common.ClusterInfo) error {
+			return common.VerifyEndpoint(ctx, flags, info, "", common.StatusOutput(flags), log)
+		})
+		if err != nil {
+			return err
+		}
 	}
 
-	enc := json.NewEncoder(os.Stdout)
-	enc.SetIndent("", "  ")
-	if err := enc.Encode(output); err != nil {
-		return fmt.Errorf("failed to encode output: %w", err)
+	if outputCAFile != "" {
+		if err := common.WriteCAFile(outputCAFile, info.CertificateAuthority); err != nil {
+			return err
+		}
 	}
 
-	return nil
+	return encode(os.Stdout, info)
 }
 
-func getAzureClusterInfo(ctx context.Context, flags *common.Flags, log logger.Logger) error {
-	if flags.SubscriptionID == "" {
-		return fmt.Errorf("--subscription-id is required for Azure")
-	}
-	if flags.TenantID == "" {
-		return fmt.Errorf("--tenant-id is required for Azure")
-	}
-	if flags.ResourceGroup == "" {
-		return fmt.Errorf("--resource-group is required for Azure")
-	}
-
-	config := &azure.Config{
-		TenantID:        flags.TenantID,
-		SubscriptionID:  flags.SubscriptionID,
-		CredentialsFile: flags.CredentialsFile,
-		TokenDuration:   1 * time.Hour,
-	}
-	provider, err := azure.NewProvider(config, log)
-	if err != nil {
-		return fmt.Errorf("failed to create Azure provider: %w", err)
-	}
-
-	info, err := provider.GetClusterInfo(ctx, flags.ClusterName, flags.ResourceGroup)
-	if err != nil {
-		return fmt.Errorf("failed to get cluster info: %w", err)
-	}
-
-	output := map[string]string{
-		"endpoint":             info.Endpoint,
-		"certificateAuthority": info.CertificateAuthority,
-		"version":              info.Version,
-		"location":             info.Location,
-		"resourceId":           info.ResourceID,
-	}
-
-	enc := json.NewEncoder(os.Stdout)
-	enc.SetIndent("", "  ")
-	if err := enc.Encode(output); err != nil {
-		return fmt.Errorf("failed to encode output: %w", err)
+// clusterInfoDetails returns the provider-specific flags for the dry-run summary
+func clusterInfoDetails(flags *common.Flags) map[string]string {
+	switch flags.ProviderName {
+	case "gcp":
+		return map[string]string{
+			"project-id": flags.ProjectID,
+			"region":     flags.Region,
+		}
+	case "aws":
+		return map[string]string{
+			"region": flags.Region,
+		}
+	case "azure":
+		return map[string]string{
+			"subscription-id": flags.SubscriptionID,
+			"tenant-id":       flags.TenantID,
+			"resource-group":  flags.ResourceGroup,
+			"azure-cloud":     flags.AzureCloud,
+		}
+	case "oci":
+		return map[string]string{
+			"region":         flags.Region,
+			"compartment-id": flags.CompartmentID,
+		}
+	default:
+		return nil
 	}
-
-	return nil
 }
@@ -0,0 +1,37 @@
+package common
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+)
+
+// CheckClusterAllowed returns an ErrPermissionDenied error when flags.AllowedClusters is
+// set and no entry matches the cluster name, so that a shared runner cannot mint tokens
+// for other clusters whatever cloud IAM allows. Entries are cluster names or path.Match
+// patterns such as "ci-*"; an empty list allows every cluster.
+func CheckClusterAllowed(flags *Flags) error {
+	return ClusterAllowed(flags.AllowedClusters, flags.ClusterName)
+}
+
+// ClusterAllowed is CheckClusterAllowed for a cluster other than the one named by the
+// flags, as requested from serve's token service
+func ClusterAllowed(allowedClusters []string, clusterName string) error {
+	if len(allowedClusters) == 0 {
+		return nil
+	}
+
+	for _, pattern := range allowedClusters {
+		// Patterns were validated when the config file was loaded
+		if matched, _ := path.Match(pattern, clusterName); matched {
+			return nil
+		}
+	}
+	return errors.New(
+		errors.ErrPermissionDenied,
+		fmt.Sprintf("cluster %q is not in the allowed clusters of the configuration", clusterName),
+	).WithField("cluster", clusterName).
+		WithDetail("allowed_clusters: " + strings.Join(allowedClusters, ", "))
+}
@@ -0,0 +1,44 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+)
+
+func TestCheckClusterAllowed(t *testing.T) {
+	allowed := []string{"prod-eu", "ci-*", "stage-[ab]"}
+
+	tests := []struct {
+		name    string
+		allowed []string
+		cluster string
+		denied  bool
+	}{
+		{name: "empty list allows every cluster", cluster: "anything"},
+		{name: "exact match", allowed: allowed, cluster: "prod-eu"},
+		{name: "glob match", allowed: allowed, cluster: "ci-1234"},
+		{name: "character class match", allowed: allowed, cluster: "stage-b"},
+		{name: "no match", allowed: allowed, cluster: "prod-us", denied: true},
+		{name: "exact entries are not prefixes", allowed: allowed, cluster: "prod-eu-2", denied: true},
+		{name: "globs do not cross slashes", allowed: allowed, cluster: "ci-a/b", denied: true},
+		{name: "missing cluster name", allowed: allowed, cluster: "", denied: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckClusterAllowed(&Flags{AllowedClusters: tt.allowed, ClusterName: tt.cluster})
+			if !tt.denied {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, errors.ErrPermissionDenied))
+			assert.Contains(t, err.Error(), `cluster "`+tt.cluster+`" is not in the allowed clusters`)
+			assert.Equal(t, ExitCredential, ExitCode(err))
+		})
+	}
+}
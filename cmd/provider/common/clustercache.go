@@ -0,0 +1,244 @@
+package common
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/clusterverify"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+)
+
+// DefaultClusterInfoCacheTTL is how long cached cluster info is used. Cluster endpoints
+// and CA certificates rarely change, and a stale CA is caught by --verify.
+const DefaultClusterInfoCacheTTL = 24 * time.Hour
+
+// clusterInfoCacheNamespace is the cache directory subdirectory holding cluster info,
+// so that other cached data can share the cache directory
+const clusterInfoCacheNamespace = "cluster-info"
+
+// clusterInfoCacheEntry is the on-disk representation of cached cluster info
+type clusterInfoCacheEntry struct {
+	Key       string      `json:"key"`
+	FetchedAt time.Time   `json:"fetchedAt"`
+	Info      ClusterInfo `json:"info"`
+}
+
+// ClusterInfoCache keeps cluster info looked up through the cloud APIs in one 0600 JSON
+// file per cluster, so that repeated get-cluster-info and generate-kubeconfig runs skip
+// the lookup
+type ClusterInfoCache struct {
+	dir string
+	ttl time.Duration
+	now func() time.Time
+
+	// Refresh bypasses cached entries; looked-up cluster info is still stored
+	Refresh bool
+}
+
+// DefaultCacheDir returns the per-user cache directory of the provider
+func DefaultCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "hyperfleet-credential-provider"), nil
+}
+
+// NewClusterInfoCache returns a cache of cluster info under the cluster-info
+// subdirectory of cacheDir whose entries are used for ttl
+func NewClusterInfoCache(cacheDir string, ttl time.Duration) *ClusterInfoCache {
+	return &ClusterInfoCache{
+		dir: filepath.Join(cacheDir, clusterInfoCacheNamespace),
+		ttl: ttl,
+		now: time.Now,
+	}
+}
+
+// AddClusterInfoCacheFlags adds the --cache-dir, --cluster-info-cache-ttl and --refresh
+// flags read by NewClusterInfoCacheFromFlags to a command that looks up clusters
+func AddClusterInfoCacheFlags(cmd *cobra.Command) {
+	cmd.Flags().String("cache-dir", "", "Cache directory (default: the user cache directory, e.g. ~/.cache/hyperfleet-credential-provider)")
+	cmd.Flags().Duration("cluster-info-cache-ttl", DefaultClusterInfoCacheTTL, "How long a cluster's endpoint and CA are reused from the cache before the cloud API is called again (0 disables the cache)")
+	cmd.Flags().Bool("refresh", false, "Look the cluster up through the cloud API even when it is cached, and update the cache")
+}
+
+// NewClusterInfoCacheFromFlags returns the cluster info cache configured by the
+// --cache-dir, --cluster-info-cache-ttl and --refresh flags of the command being run,
+// or nil when the TTL is zero. Without --cache-dir, caching is also off where there is no
+// user cache directory, such as containers without HOME.
+func NewClusterInfoCacheFromFlags(flags *Flags) (*ClusterInfoCache, error) {
+	ttl := flags.Viper.GetDuration("cluster-info-cache-ttl")
+	if ttl < 0 {
+		return nil, errors.New(errors.ErrInvalidArgument, "--cluster-info-cache-ttl must not be negative")
+	}
+	if ttl == 0 {
+		return nil, nil
+	}
+
+	dir := flags.Viper.GetString("cache-dir")
+	if dir == "" {
+		var err error
+		if dir, err = DefaultCacheDir(); err != nil {
+			return nil, nil
+		}
+	}
+
+	cache := NewClusterInfoCache(dir, ttl)
+	cache.Refresh = flags.Viper.GetBool("refresh")
+	return cache, nil
+}
+
+// ClusterInfoCacheKey identifies the cluster looked up with flags: the provider, the
+// cluster name, the settings that locate it and the endpoint picked for it
+func ClusterInfoCacheKey(flags *Flags) string {
+	parts := []string{
+		flags.ProviderName,
+		flags.ClusterName,
+		flags.Region,
+		flags.ProjectID,
+		flags.AccountID,
+		flags.SubscriptionID,
+		flags.ResourceGroup,
+		flags.AzureCloud,
+		flags.CompartmentID,
+		flags.EndpointAccess,
+	}
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
+
+// Get returns the cached cluster info for key, or nil when there is none. Expired,
+// unreadable and invalid entries, such as ones written by an incompatible version,
+// are reported as missing and replaced by the next Put.
+func (c *ClusterInfoCache) Get(key string) *ClusterInfo {
+	if c.Refresh {
+		return nil
+	}
+
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return nil
+	}
+
+	var entry clusterInfoCacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil
+	}
+	if entry.Key != key || c.now().Sub(entry.FetchedAt) >= c.ttl {
+		return nil
+	}
+	if err := entry.Info.Validate(); err != nil {
+		return nil
+	}
+	return &entry.Info
+}
+
+// Put stores info under key, replacing any previous entry
+func (c *ClusterInfoCache) Put(key string, info *ClusterInfo) error {
+	data, err := json.Marshal(clusterInfoCacheEntry{
+		Key:       key,
+		FetchedAt: c.now(),
+		Info:      *info,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode cluster info: %w", err)
+	}
+
+	if err := os.MkdirAll(c.dir, 0700); err != nil {
+		return fmt.Errorf("failed to create cluster info cache directory: %w", err)
+	}
+
+	// Write to a temp file and rename so concurrent readers never see a partial entry
+	tmp, err := os.CreateTemp(c.dir, ".cluster-info-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary cluster info file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write cluster info cache: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write cluster info cache: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), c.path(key)); err != nil {
+		return fmt.Errorf("failed to store cluster info cache: %w", err)
+	}
+	return nil
+}
+
+// Delete removes the entry stored under key; deleting a missing entry is not an error
+func (c *ClusterInfoCache) Delete(key string) error {
+	if err := os.Remove(c.path(key)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete cached cluster info: %w", err)
+	}
+	return nil
+}
+
+// path returns the file of key, which is a hex digest and so a safe file name
+func (c *ClusterInfoCache) path(key string) string {
+	return filepath.Join(c.dir, key+".json")
+}
+
+// DescribeClusterCached returns the cached cluster info for flags, or looks it up with
+// describe and caches the result. The bool reports whether the info came from the
+// cache. A nil cache always calls describe. Failing to store the result is logged, not
+// returned, since the lookup itself succeeded.
+func DescribeClusterCached(cache *ClusterInfoCache, flags *Flags, log logger.Logger, describe func() (*ClusterInfo, error)) (*ClusterInfo, bool, error) {
+	if cache == nil {
+		info, err := describe()
+		return info, false, err
+	}
+
+	key := ClusterInfoCacheKey(flags)
+	if info := cache.Get(key); info != nil {
+		log.Debug("Using cached cluster info",
+			logger.String("provider", flags.ProviderName),
+			logger.String("cluster", flags.ClusterName),
+		)
+		return info, true, nil
+	}
+
+	info, err := describe()
+	if err != nil {
+		return nil, false, err
+	}
+	if err := cache.Put(key, info); err != nil {
+		log.Warn("Failed to cache cluster info", logger.Error(err))
+	}
+	return info, false, nil
+}
+
+// VerifyClusterInfo checks info with verify. When info came from the cache and the
+// API server certificate does not verify against its CA, the CA has changed since it was
+// cached, so the entry is dropped and the cluster looked up again before a retry.
+func VerifyClusterInfo(info *ClusterInfo, cached bool, cache *ClusterInfoCache, flags *Flags, log logger.Logger, describe func() (*ClusterInfo, error), verify func(*ClusterInfo) error) (*ClusterInfo, error) {
+	err := verify(info)
+	if err == nil || !cached || !clusterverify.IsCertificateError(err) {
+		return info, err
+	}
+
+	log.Warn("Cached cluster CA failed TLS verification, looking the cluster up again",
+		logger.String("cluster", flags.ClusterName),
+		logger.String("endpoint", info.Endpoint),
+	)
+	if err := cache.Delete(ClusterInfoCacheKey(flags)); err != nil {
+		log.Warn("Failed to invalidate cached cluster info", logger.Error(err))
+	}
+
+	info, _, err = DescribeClusterCached(cache, flags, log, describe)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cluster info: %w", err)
+	}
+	return info, verify(info)
+}
@@ -0,0 +1,333 @@
+package common
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+)
+
+func testClusterInfo() *ClusterInfo {
+	return &ClusterInfo{
+		Endpoint:             "https://35.1.2.3",
+		CertificateAuthority: base64.StdEncoding.EncodeToString([]byte(testCAPEM)),
+		Version:              "1.30",
+		Location:             "us-central1",
+	}
+}
+
+func TestClusterInfoCache(t *testing.T) {
+	const key = "0123abcd"
+	now := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		prepare func(t *testing.T, cache *ClusterInfoCache)
+		age     time.Duration
+		refresh bool
+		wantHit bool
+	}{
+		{
+			name:    "miss",
+			prepare: func(t *testing.T, cache *ClusterInfoCache) {},
+		},
+		{
+			name: "hit",
+			prepare: func(t *testing.T, cache *ClusterInfoCache) {
+				require.NoError(t, cache.Put(key, testClusterInfo()))
+			},
+			age:     23 * time.Hour,
+			wantHit: true,
+		},
+		{
+			name: "expired entry",
+			prepare: func(t *testing.T, cache *ClusterInfoCache) {
+				require.NoError(t, cache.Put(key, testClusterInfo()))
+			},
+			age: 24 * time.Hour,
+		},
+		{
+			name: "refresh bypasses a fresh entry",
+			prepare: func(t *testing.T, cache *ClusterInfoCache) {
+				require.NoError(t, cache.Put(key, testClusterInfo()))
+			},
+			refresh: true,
+		},
+		{
+			name: "corrupted entry",
+			prepare: func(t *testing.T, cache *ClusterInfoCache) {
+				require.NoError(t, os.MkdirAll(cache.dir, 0700))
+				require.NoError(t, os.WriteFile(cache.path(key), []byte(`{"key": "0123abcd", "info": [`), 0600))
+			},
+		},
+		{
+			name: "entry from an incompatible schema",
+			prepare: func(t *testing.T, cache *ClusterInfoCache) {
+				require.NoError(t, os.MkdirAll(cache.dir, 0700))
+				entry := fmt.Sprintf(`{"key": "0123abcd", "fetchedAt": %q, "info": "https://35.1.2.3"}`, now.Format(time.RFC3339))
+				require.NoError(t, os.WriteFile(cache.path(key), []byte(entry), 0600))
+			},
+		},
+		{
+			name: "entry with invalid cluster info",
+			prepare: func(t *testing.T, cache *ClusterInfoCache) {
+				require.NoError(t, cache.Put(key, &ClusterInfo{Endpoint: "https://35.1.2.3"}))
+			},
+		},
+		{
+			name: "entry stored under another key",
+			prepare: func(t *testing.T, cache *ClusterInfoCache) {
+				require.NoError(t, cache.Put("other", testClusterInfo()))
+				require.NoError(t, os.Rename(cache.path("other"), cache.path(key)))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := NewClusterInfoCache(t.TempDir(), DefaultClusterInfoCacheTTL)
+			cache.now = func() time.Time { return now }
+			tt.prepare(t, cache)
+
+			cache.now = func() time.Time { return now.Add(tt.age) }
+			cache.Refresh = tt.refresh
+
+			info := cache.Get(key)
+			if !tt.wantHit {
+				assert.Nil(t, info)
+				return
+			}
+			assert.Equal(t, testClusterInfo(), info)
+		})
+	}
+}
+
+func TestClusterInfoCache_PutAndDelete(t *testing.T) {
+	cacheDir := t.TempDir()
+	cache := NewClusterInfoCache(cacheDir, time.Hour)
+
+	require.NoError(t, cache.Put("0123abcd", testClusterInfo()))
+	path := filepath.Join(cacheDir, "cluster-info", "0123abcd.json")
+	assert.FileExists(t, path)
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	}
+
+	require.NoError(t, cache.Delete("0123abcd"))
+	assert.NoFileExists(t, path)
+	assert.Nil(t, cache.Get("0123abcd"))
+	require.NoError(t, cache.Delete("0123abcd"), "deleting a missing entry is not an error")
+}
+
+func TestClusterInfoCacheKey(t *testing.T) {
+	base := Flags{ProviderName: "gcp", ClusterName: "my-cluster", ProjectID: "my-project", Region: "us-central1"}
+	key := ClusterInfoCacheKey(&base)
+	assert.Equal(t, key, ClusterInfoCacheKey(&base))
+
+	otherRegion := base
+	otherRegion.Region = "europe-west1"
+	assert.NotEqual(t, key, ClusterInfoCacheKey(&otherRegion))
+
+	otherProvider := base
+	otherProvider.ProviderName = "aws"
+	assert.NotEqual(t, key, ClusterInfoCacheKey(&otherProvider))
+
+	// The private and public endpoints of one cluster are cached separately
+	privateEndpoint := base
+	privateEndpoint.EndpointAccess = "private"
+	assert.NotEqual(t, key, ClusterInfoCacheKey(&privateEndpoint))
+
+	// Credentials do not locate the cluster, so they share the entry
+	otherCredentials := base
+	otherCredentials.CredentialsFile = "/vault/secrets/other.json"
+	assert.Equal(t, key, ClusterInfoCacheKey(&otherCredentials))
+}
+
+func TestDescribeClusterCached(t *testing.T) {
+	flags := &Flags{ProviderName: "gcp", ClusterName: "my-cluster", ProjectID: "my-project", Region: "us-central1"}
+	lookups := 0
+	describe := func() (*ClusterInfo, error) {
+		lookups++
+		return testClusterInfo(), nil
+	}
+
+	t.Run("nil cache always looks up", func(t *testing.T) {
+		lookups = 0
+		for i := 0; i < 2; i++ {
+			info, cached, err := DescribeClusterCached(nil, flags, logger.Nop(), describe)
+			require.NoError(t, err)
+			assert.False(t, cached)
+			assert.Equal(t, testClusterInfo(), info)
+		}
+		assert.Equal(t, 2, lookups)
+	})
+
+	t.Run("second lookup is served from the cache", func(t *testing.T) {
+		lookups = 0
+		cache := NewClusterInfoCache(t.TempDir(), time.Hour)
+
+		_, cached, err := DescribeClusterCached(cache, flags, logger.Nop(), describe)
+		require.NoError(t, err)
+		assert.False(t, cached)
+
+		info, cached, err := DescribeClusterCached(cache, flags, logger.Nop(), describe)
+		require.NoError(t, err)
+		assert.True(t, cached)
+		assert.Equal(t, testClusterInfo(), info)
+		assert.Equal(t, 1, lookups)
+
+		cache.Refresh = true
+		_, cached, err = DescribeClusterCached(cache, flags, logger.Nop(), describe)
+		require.NoError(t, err)
+		assert.False(t, cached)
+		assert.Equal(t, 2, lookups)
+	})
+
+	t.Run("failed lookup is not cached", func(t *testing.T) {
+		cache := NewClusterInfoCache(t.TempDir(), time.Hour)
+		_, _, err := DescribeClusterCached(cache, flags, logger.Nop(), func() (*ClusterInfo, error) {
+			return nil, fmt.Errorf("cluster not found")
+		})
+		require.Error(t, err)
+		assert.Nil(t, cache.Get(ClusterInfoCacheKey(flags)))
+	})
+}
+
+func TestNewClusterInfoCacheFromFlags(t *testing.T) {
+	cacheDir := t.TempDir()
+
+	tests := []struct {
+		name        string
+		values      map[string]interface{}
+		wantNil     bool
+		wantRefresh bool
+		wantErr     string
+	}{
+		{
+			name:   "cache directory and TTL",
+			values: map[string]interface{}{"cache-dir": cacheDir, "cluster-info-cache-ttl": "1h"},
+		},
+		{
+			name:        "refresh",
+			values:      map[string]interface{}{"cache-dir": cacheDir, "cluster-info-cache-ttl": "1h", "refresh": true},
+			wantRefresh: true,
+		},
+		{
+			name:    "zero TTL disables the cache",
+			values:  map[string]interface{}{"cache-dir": cacheDir, "cluster-info-cache-ttl": "0s"},
+			wantNil: true,
+		},
+		{
+			name:    "negative TTL",
+			values:  map[string]interface{}{"cluster-info-cache-ttl": "-1h"},
+			wantErr: "must not be negative",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := NewViper(nil)
+			for key, value := range tt.values {
+				v.Set(key, value)
+			}
+
+			cache, err := NewClusterInfoCacheFromFlags(&Flags{Viper: v})
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			if tt.wantNil {
+				assert.Nil(t, cache)
+				return
+			}
+			require.NotNil(t, cache)
+			assert.Equal(t, filepath.Join(cacheDir, "cluster-info"), cache.dir)
+			assert.Equal(t, time.Hour, cache.ttl)
+			assert.Equal(t, tt.wantRefresh, cache.Refresh)
+		})
+	}
+}
+
+func TestVerifyClusterInfo(t *testing.T) {
+	flags := &Flags{ProviderName: "gcp", ClusterName: "my-cluster", ProjectID: "my-project", Region: "us-central1"}
+	stale := &ClusterInfo{Endpoint: "https://35.1.2.3", CertificateAuthority: "b2xkLWNh"}
+	fresh := &ClusterInfo{Endpoint: "https://35.1.2.3", CertificateAuthority: "bmV3LWNh"}
+	certErr := errors.Wrap(errors.ErrClusterUnreachable, x509.UnknownAuthorityError{}, "failed to reach the cluster API server")
+
+	// verify rejects the stale CA with a certificate error and accepts the fresh one
+	verify := func(info *ClusterInfo) error {
+		if info.CertificateAuthority == stale.CertificateAuthority {
+			return certErr
+		}
+		return nil
+	}
+
+	tests := []struct {
+		name        string
+		cached      bool
+		verify      func(*ClusterInfo) error
+		wantInfo    *ClusterInfo
+		wantLookups int
+		wantErr     bool
+	}{
+		{
+			name:        "cached CA fails TLS verification",
+			cached:      true,
+			verify:      verify,
+			wantInfo:    fresh,
+			wantLookups: 1,
+		},
+		{
+			name:    "looked-up CA fails TLS verification",
+			verify:  verify,
+			wantErr: true,
+		},
+		{
+			name:   "cached info rejected for another reason",
+			cached: true,
+			verify: func(*ClusterInfo) error {
+				return errors.New(errors.ErrUnauthenticated, "token rejected")
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := NewClusterInfoCache(t.TempDir(), time.Hour)
+			key := ClusterInfoCacheKey(flags)
+			require.NoError(t, cache.Put(key, stale))
+
+			lookups := 0
+			describe := func() (*ClusterInfo, error) {
+				lookups++
+				return fresh, nil
+			}
+
+			info, err := VerifyClusterInfo(stale, tt.cached, cache, flags, logger.Nop(), describe, tt.verify)
+			assert.Equal(t, tt.wantLookups, lookups)
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.Equal(t, stale, cache.Get(key), "the cache is kept")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantInfo, info)
+			assert.Equal(t, fresh, cache.Get(key), "the stale entry is replaced")
+		})
+	}
+}
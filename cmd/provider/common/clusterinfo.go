@@ -0,0 +1,263 @@
+package common
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+)
+
+// ClusterInfo is the JSON document emitted by get-cluster-info.
+// It can be saved to a file and consumed offline by generate-kubeconfig --cluster-info-file.
+type ClusterInfo struct {
+	Endpoint             string `json:"endpoint" yaml:"endpoint"`
+	CertificateAuthority string `json:"certificateAuthority" yaml:"certificateAuthority"`
+	Version              string `json:"version" yaml:"version"`
+	Location             string `json:"location,omitempty" yaml:"location,omitempty"`
+	Region               string `json:"region,omitempty" yaml:"region,omitempty"`
+	ARN                  string `json:"arn,omitempty" yaml:"arn,omitempty"`
+	ResourceID           string `json:"resourceId,omitempty" yaml:"resourceId,omitempty"`
+}
+
+// Validate checks that the cluster info has what a kubeconfig needs
+func (c *ClusterInfo) Validate() error {
+	if c.Endpoint == "" {
+		return errors.New(errors.ErrInvalidArgument, "cluster endpoint is empty")
+	}
+	if !strings.HasPrefix(c.Endpoint, "https://") {
+		return errors.New(errors.ErrInvalidArgument, fmt.Sprintf("cluster endpoint must use https: %s", c.Endpoint))
+	}
+	if c.CertificateAuthority == "" {
+		return errors.New(errors.ErrInvalidArgument, "cluster CA certificate is empty")
+	}
+	if _, err := base64.StdEncoding.DecodeString(c.CertificateAuthority); err != nil {
+		return errors.Wrap(errors.ErrInvalidArgument, err, "cluster CA certificate is not valid base64")
+	}
+	return nil
+}
+
+// clusterInfoJSONFields are the optional fields that get-cluster-info writes for the
+// clusters of a provider, empty or not, as it always has
+var clusterInfoJSONFields = map[string][]string{
+	"gcp":   {"location"},
+	"aws":   {"region", "arn"},
+	"azure": {"location", "resourceId"},
+}
+
+// WriteClusterInfo writes cluster info of a providerName cluster as indented JSON with
+// sorted keys. GCP, AWS and Azure clusters get exactly their clusterInfoJSONFields;
+// clusters of other providers get the optional fields that are set.
+func WriteClusterInfo(w io.Writer, providerName string, info *ClusterInfo) error {
+	output := map[string]string{
+		"endpoint":             info.Endpoint,
+		"certificateAuthority": info.CertificateAuthority,
+		"version":              info.Version,
+	}
+	optional := map[string]string{
+		"location":   info.Location,
+		"region":     info.Region,
+		"arn":        info.ARN,
+		"resourceId": info.ResourceID,
+	}
+	fields, known := clusterInfoJSONFields[providerName]
+	for key, value := range optional {
+		if (known && slices.Contains(fields, key)) || (!known && value != "") {
+			output[key] = value
+		}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(output); err != nil {
+		return fmt.Errorf("failed to encode output: %w", err)
+	}
+	return nil
+}
+
+// LoadClusterInfoFile reads cluster info previously exported by get-cluster-info
+func LoadClusterInfoFile(path string) (*ClusterInfo, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(
+			errors.ErrInvalidArgument,
+			err,
+			"failed to read cluster info file",
+		).WithField("file", path)
+	}
+
+	var info ClusterInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		return nil, errors.Wrap(
+			errors.ErrInvalidArgument,
+			err,
+			fmt.Sprintf("failed to parse cluster info file %s", path),
+		).WithField("file", path)
+	}
+
+	return &info, nil
+}
+
+// LoadOfflineClusterInfo builds cluster info from a file exported by get-cluster-info
+// and/or an explicit endpoint and CA file or inline CA data, without calling any cloud API.
+// Explicit values override the ones read from the file.
+func LoadOfflineClusterInfo(infoFile, endpoint, caFile, caData string) (*ClusterInfo, error) {
+	if caFile != "" && caData != "" {
+		return nil, errors.New(errors.ErrInvalidArgument, "--cluster-ca-file and --cluster-ca-data cannot be combined")
+	}
+	// Without a cluster info file, both halves must be given, since a partial
+	// kubeconfig cannot be completed without the cloud API lookup
+	if infoFile == "" {
+		if endpoint == "" {
+			return nil, errors.New(errors.ErrInvalidArgument, "--cluster-ca-file and --cluster-ca-data require --cluster-endpoint (or --cluster-info-file)")
+		}
+		if caFile == "" && caData == "" {
+			return nil, errors.New(errors.ErrInvalidArgument, "--cluster-endpoint requires --cluster-ca-file or --cluster-ca-data (or --cluster-info-file)")
+		}
+	}
+
+	info := &ClusterInfo{}
+
+	if infoFile != "" {
+		loaded, err := LoadClusterInfoFile(infoFile)
+		if err != nil {
+			return nil, err
+		}
+		info = loaded
+	}
+
+	if endpoint != "" {
+		normalized, err := NormalizeClusterEndpoint(endpoint)
+		if err != nil {
+			return nil, err
+		}
+		info.Endpoint = normalized
+	}
+
+	if caFile != "" {
+		caCert, err := LoadCAFile(caFile)
+		if err != nil {
+			return nil, err
+		}
+		info.CertificateAuthority = caCert
+	}
+
+	if caData != "" {
+		caCert, err := ParseCAData(caData)
+		if err != nil {
+			return nil, err
+		}
+		info.CertificateAuthority = caCert
+	}
+
+	if err := info.Validate(); err != nil {
+		return nil, errors.Wrap(
+			errors.ErrInvalidArgument,
+			err,
+			"incomplete offline cluster info",
+		).WithDetail(err.Error() + " (use --cluster-info-file or --cluster-endpoint with --cluster-ca-file or --cluster-ca-data)")
+	}
+
+	return info, nil
+}
+
+// NormalizeClusterEndpoint adds the https:// scheme to a bare host and rejects other schemes
+func NormalizeClusterEndpoint(endpoint string) (string, error) {
+	if strings.Contains(endpoint, "://") {
+		if !strings.HasPrefix(endpoint, "https://") {
+			return "", errors.New(errors.ErrInvalidArgument, fmt.Sprintf("cluster endpoint must use https: %s", endpoint))
+		}
+		return endpoint, nil
+	}
+	return "https://" + endpoint, nil
+}
+
+// LoadCAFile reads a PEM-encoded CA bundle and returns it base64-encoded for kubeconfig
+func LoadCAFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", errors.Wrap(
+			errors.ErrInvalidArgument,
+			err,
+			"failed to read cluster CA file",
+		).WithField("file", path)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return "", errors.New(errors.ErrInvalidArgument, fmt.Sprintf("cluster CA file %s does not contain a PEM certificate", path))
+	}
+
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+// ParseCAData checks an inline CA certificate, base64-encoded PEM as in kubeconfig
+// certificate-authority-data or PEM, and returns it base64-encoded for kubeconfig
+func ParseCAData(ca string) (string, error) {
+	data := []byte(ca)
+	if !strings.Contains(ca, "-----BEGIN") {
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(ca))
+		if err != nil {
+			return "", errors.Wrap(errors.ErrInvalidArgument, err, "cluster CA data is neither PEM nor base64-encoded PEM")
+		}
+		data = decoded
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return "", errors.New(errors.ErrInvalidArgument, "cluster CA data does not contain a PEM certificate")
+	}
+
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+// AppendCAData appends the PEM certificates of extra to the cluster CA ca, both
+// base64-encoded PEM as in kubeconfig certificate-authority-data, so that kubectl also
+// trusts extra, e.g. the CA of a TLS-intercepting proxy in front of the API server
+func AppendCAData(ca, extra string) (string, error) {
+	clusterCA, err := base64.StdEncoding.DecodeString(strings.TrimSpace(ca))
+	if err != nil {
+		return "", fmt.Errorf("cluster CA certificate is not valid base64: %w", err)
+	}
+	extraCA, err := base64.StdEncoding.DecodeString(strings.TrimSpace(extra))
+	if err != nil {
+		return "", fmt.Errorf("extra CA certificate is not valid base64: %w", err)
+	}
+
+	bundle := clusterCA
+	if len(bundle) > 0 && bundle[len(bundle)-1] != '\n' {
+		bundle = append(bundle, '\n')
+	}
+	bundle = append(bundle, extraCA...)
+	return base64.StdEncoding.EncodeToString(bundle), nil
+}
+
+// WriteCAFile writes the cluster CA as a standalone PEM file with 0644 permissions.
+// ca is the base64-encoded PEM used in kubeconfig; a CA that is already PEM is written as is.
+func WriteCAFile(path, ca string) error {
+	data := []byte(ca)
+	if !strings.Contains(ca, "-----BEGIN") {
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(ca))
+		if err != nil {
+			return fmt.Errorf("cluster CA certificate is not valid base64: %w", err)
+		}
+		data = decoded
+	}
+	if block, _ := pem.Decode(data); block == nil {
+		return fmt.Errorf("cluster CA certificate is not PEM-encoded")
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write cluster CA file: %w", err)
+	}
+	// WriteFile keeps the mode of an existing file and applies the umask to a new one
+	if err := os.Chmod(path, 0644); err != nil {
+		return fmt.Errorf("failed to set cluster CA file permissions: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,208 @@
+package common
+
+import (
+	"bytes"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testCAPEM = `-----BEGIN CERTIFICATE-----
+MIIBszCCAVmgAwIBAgIUTESTTESTTESTTESTTESTTESTTESTMAoGCCqGSM49BAMC
+-----END CERTIFICATE-----
+`
+
+func TestWriteCAFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		ca      string
+		wantErr string
+	}{
+		{name: "base64-encoded PEM", ca: base64.StdEncoding.EncodeToString([]byte(testCAPEM))},
+		{name: "base64 with a trailing newline", ca: base64.StdEncoding.EncodeToString([]byte(testCAPEM)) + "\n"},
+		{name: "raw PEM", ca: testCAPEM},
+		{name: "invalid base64", ca: "not base64!", wantErr: "not valid base64"},
+		{name: "base64 of something other than PEM", ca: base64.StdEncoding.EncodeToString([]byte("plain text")), wantErr: "not PEM-encoded"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "ca.pem")
+
+			err := WriteCAFile(path, tt.ca)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				assert.NoFileExists(t, path)
+				return
+			}
+			require.NoError(t, err)
+
+			data, err := os.ReadFile(path)
+			require.NoError(t, err)
+			assert.Equal(t, testCAPEM, string(data))
+
+			if runtime.GOOS != "windows" {
+				info, err := os.Stat(path)
+				require.NoError(t, err)
+				assert.Equal(t, os.FileMode(0644), info.Mode().Perm())
+			}
+		})
+	}
+}
+
+func TestWriteCAFile_ReplacesModeOfExistingFile(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file modes are not enforced on Windows")
+	}
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(path, []byte("old"), 0600))
+
+	require.NoError(t, WriteCAFile(path, testCAPEM))
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0644), info.Mode().Perm())
+}
+
+func TestWriteCAFile_WriteError(t *testing.T) {
+	dir := t.TempDir()
+
+	err := WriteCAFile(filepath.Join(dir, "missing", "ca.pem"), testCAPEM)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to write cluster CA file")
+
+	if runtime.GOOS == "windows" || os.Geteuid() == 0 {
+		t.Skip("directory permissions do not stop writes on Windows or as root")
+	}
+	readOnly := filepath.Join(dir, "read-only")
+	require.NoError(t, os.Mkdir(readOnly, 0500))
+
+	err = WriteCAFile(filepath.Join(readOnly, "ca.pem"), testCAPEM)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to write cluster CA file")
+}
+
+func TestAppendCAData(t *testing.T) {
+	extraPEM := strings.Replace(testCAPEM, "TESTTEST", "EXTRAEXT", 1)
+	encode := func(pem string) string { return base64.StdEncoding.EncodeToString([]byte(pem)) }
+
+	tests := []struct {
+		name    string
+		ca      string
+		extra   string
+		want    string
+		wantErr string
+	}{
+		{name: "appends the extra CA", ca: encode(testCAPEM), extra: encode(extraPEM), want: testCAPEM + extraPEM},
+		{name: "separates a CA without a trailing newline", ca: encode(strings.TrimSuffix(testCAPEM, "\n")), extra: encode(extraPEM), want: testCAPEM + extraPEM},
+		{name: "invalid cluster CA", ca: "not base64!", extra: encode(extraPEM), wantErr: "cluster CA certificate is not valid base64"},
+		{name: "invalid extra CA", ca: encode(testCAPEM), extra: "not base64!", wantErr: "extra CA certificate is not valid base64"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := AppendCAData(tt.ca, tt.extra)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			decoded, err := base64.StdEncoding.DecodeString(got)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, string(decoded))
+		})
+	}
+}
+
+// TestWriteClusterInfo_Golden checks that the JSON output is byte-for-byte the output of
+// the original get-cluster-info, which encoded a map: sorted keys, and the provider's
+// optional fields even when they are empty
+func TestWriteClusterInfo_Golden(t *testing.T) {
+	tests := []struct {
+		provider string
+		info     *ClusterInfo
+		want     string
+	}{
+		{
+			provider: "gcp",
+			info: &ClusterInfo{
+				Endpoint:             "https://34.68.222.124",
+				CertificateAuthority: "Y2E=",
+				Version:              "1.33.5-gke.2118001",
+			},
+			want: `{
+  "certificateAuthority": "Y2E=",
+  "endpoint": "https://34.68.222.124",
+  "location": "",
+  "version": "1.33.5-gke.2118001"
+}
+`,
+		},
+		{
+			provider: "aws",
+			info: &ClusterInfo{
+				Endpoint:             "https://ABCD.gr7.us-east-1.eks.amazonaws.com",
+				CertificateAuthority: "Y2E=",
+				Version:              "1.31",
+				Region:               "us-east-1",
+			},
+			want: `{
+  "arn": "",
+  "certificateAuthority": "Y2E=",
+  "endpoint": "https://ABCD.gr7.us-east-1.eks.amazonaws.com",
+  "region": "us-east-1",
+  "version": "1.31"
+}
+`,
+		},
+		{
+			provider: "azure",
+			info: &ClusterInfo{
+				Endpoint:             "https://my-aks-dns.hcp.eastus.azmk8s.io:443",
+				CertificateAuthority: "Y2E=",
+				Version:              "1.30.3",
+				Location:             "eastus",
+				ResourceID:           "/subscriptions/sub-123/resourceGroups/my-rg/providers/Microsoft.ContainerService/managedClusters/my-aks",
+			},
+			want: `{
+  "certificateAuthority": "Y2E=",
+  "endpoint": "https://my-aks-dns.hcp.eastus.azmk8s.io:443",
+  "location": "eastus",
+  "resourceId": "/subscriptions/sub-123/resourceGroups/my-rg/providers/Microsoft.ContainerService/managedClusters/my-aks",
+  "version": "1.30.3"
+}
+`,
+		},
+		{
+			provider: "oci",
+			info: &ClusterInfo{
+				Endpoint:             "https://10.0.0.10:6443",
+				CertificateAuthority: "Y2E=",
+				Version:              "v1.30.1",
+				Region:               "us-ashburn-1",
+			},
+			want: `{
+  "certificateAuthority": "Y2E=",
+  "endpoint": "https://10.0.0.10:6443",
+  "region": "us-ashburn-1",
+  "version": "v1.30.1"
+}
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.provider, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, WriteClusterInfo(&buf, tt.provider, tt.info))
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+}
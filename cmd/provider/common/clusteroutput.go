@@ -0,0 +1,104 @@
+package common
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+)
+
+// goTemplatePrefix introduces the template of --output=go-template=TEMPLATE
+const goTemplatePrefix = "go-template="
+
+// ClusterInfoFormats are the --output values get-cluster-info accepts
+var ClusterInfoFormats = []string{"json", "yaml", "env", goTemplatePrefix + "TEMPLATE"}
+
+// shellSafe matches env values that need no quoting in a POSIX shell
+var shellSafe = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]*$`)
+
+// ClusterInfoEncoder writes cluster info in one output format
+type ClusterInfoEncoder func(w io.Writer, info *ClusterInfo) error
+
+// NewClusterInfoEncoder returns the encoder for an --output value for the clusters of
+// providerName. An empty value selects JSON.
+func NewClusterInfoEncoder(output, providerName string) (ClusterInfoEncoder, error) {
+	switch output {
+	case "json", "":
+		return func(w io.Writer, info *ClusterInfo) error {
+			return WriteClusterInfo(w, providerName, info)
+		}, nil
+	case "yaml":
+		return writeClusterInfoYAML, nil
+	case "env":
+		return writeClusterInfoEnv, nil
+	}
+
+	if text, ok := strings.CutPrefix(output, goTemplatePrefix); ok {
+		if text == "" {
+			return nil, fmt.Errorf("go-template output requires a template, e.g. --output='go-template={{.Endpoint}}'")
+		}
+		tmpl, err := template.New("output").Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid go-template: %w", err)
+		}
+		return func(w io.Writer, info *ClusterInfo) error {
+			if err := tmpl.Execute(w, info); err != nil {
+				return fmt.Errorf("failed to execute go-template: %w", err)
+			}
+			return nil
+		}, nil
+	}
+
+	return nil, errors.New(errors.ErrInvalidArgument, fmt.Sprintf("unsupported output format %q (supported: %s)", output, strings.Join(ClusterInfoFormats, ", ")))
+}
+
+// writeClusterInfoYAML writes cluster info as YAML with the JSON field names
+func writeClusterInfoYAML(w io.Writer, info *ClusterInfo) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(info); err != nil {
+		return fmt.Errorf("failed to encode output: %w", err)
+	}
+	return enc.Close()
+}
+
+// writeClusterInfoEnv writes cluster info as CLUSTER_* KEY=VALUE lines that can be
+// sourced by a shell. Optional fields the provider does not report are omitted.
+func writeClusterInfoEnv(w io.Writer, info *ClusterInfo) error {
+	vars := []struct {
+		key      string
+		value    string
+		optional bool
+	}{
+		{"CLUSTER_ENDPOINT", info.Endpoint, false},
+		{"CLUSTER_CA", info.CertificateAuthority, false},
+		{"CLUSTER_VERSION", info.Version, false},
+		{"CLUSTER_LOCATION", info.Location, true},
+		{"CLUSTER_REGION", info.Region, true},
+		{"CLUSTER_ARN", info.ARN, true},
+		{"CLUSTER_RESOURCE_ID", info.ResourceID, true},
+	}
+
+	for _, v := range vars {
+		if v.optional && v.value == "" {
+			continue
+		}
+		if _, err := fmt.Fprintf(w, "%s=%s\n", v.key, ShellQuote(v.value)); err != nil {
+			return fmt.Errorf("failed to write output: %w", err)
+		}
+	}
+	return nil
+}
+
+// ShellQuote single-quotes value when it contains characters a shell would interpret
+func ShellQuote(value string) string {
+	if shellSafe.MatchString(value) {
+		return value
+	}
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
@@ -0,0 +1,142 @@
+package common
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewClusterInfoEncoder(t *testing.T) {
+	gke := &ClusterInfo{
+		Endpoint:             "https://34.68.222.124",
+		CertificateAuthority: "LS0tLS1CRUdJTi==",
+		Version:              "v1.33.5-gke.2118001",
+		Location:             "us-central1",
+	}
+	eks := &ClusterInfo{
+		Endpoint:             "https://ABCD.gr7.us-east-1.eks.amazonaws.com",
+		CertificateAuthority: "LS0tLS1CRUdJTi==",
+		Version:              "1.31",
+		Region:               "us-east-1",
+		ARN:                  "arn:aws:eks:us-east-1:123456789012:cluster/my-cluster",
+	}
+
+	tests := []struct {
+		name     string
+		output   string
+		provider string
+		info     *ClusterInfo
+		want     string
+		wantErr  string
+	}{
+		{
+			name:     "json",
+			output:   "json",
+			provider: "gcp",
+			info:     gke,
+			want: `{
+  "certificateAuthority": "LS0tLS1CRUdJTi==",
+  "endpoint": "https://34.68.222.124",
+  "location": "us-central1",
+  "version": "v1.33.5-gke.2118001"
+}
+`,
+		},
+		{
+			name:     "default is json",
+			output:   "",
+			provider: "aws",
+			info:     eks,
+			want: `{
+  "arn": "arn:aws:eks:us-east-1:123456789012:cluster/my-cluster",
+  "certificateAuthority": "LS0tLS1CRUdJTi==",
+  "endpoint": "https://ABCD.gr7.us-east-1.eks.amazonaws.com",
+  "region": "us-east-1",
+  "version": "1.31"
+}
+`,
+		},
+		{
+			name:   "yaml",
+			output: "yaml",
+			info:   eks,
+			want: `endpoint: https://ABCD.gr7.us-east-1.eks.amazonaws.com
+certificateAuthority: LS0tLS1CRUdJTi==
+version: "1.31"
+region: us-east-1
+arn: arn:aws:eks:us-east-1:123456789012:cluster/my-cluster
+`,
+		},
+		{
+			name:   "env",
+			output: "env",
+			info:   gke,
+			want: `CLUSTER_ENDPOINT=https://34.68.222.124
+CLUSTER_CA=LS0tLS1CRUdJTi==
+CLUSTER_VERSION=v1.33.5-gke.2118001
+CLUSTER_LOCATION=us-central1
+`,
+		},
+		{
+			name:   "env quotes shell metacharacters",
+			output: "env",
+			info:   &ClusterInfo{Endpoint: "https://host", CertificateAuthority: "a b", Version: "it's"},
+			want: `CLUSTER_ENDPOINT=https://host
+CLUSTER_CA='a b'
+CLUSTER_VERSION='it'\''s'
+`,
+		},
+		{
+			name:   "go-template",
+			output: "go-template={{.Endpoint}}",
+			info:   gke,
+			want:   "https://34.68.222.124",
+		},
+		{
+			name:   "go-template with several fields",
+			output: "go-template={{.Region}} {{.ARN}}\n",
+			info:   eks,
+			want:   "us-east-1 arn:aws:eks:us-east-1:123456789012:cluster/my-cluster\n",
+		},
+		{
+			name:    "go-template without a template",
+			output:  "go-template=",
+			wantErr: "requires a template",
+		},
+		{
+			name:    "go-template that does not parse",
+			output:  "go-template={{.Endpoint",
+			wantErr: "invalid go-template",
+		},
+		{
+			name:    "go-template with an unknown field",
+			output:  "go-template={{.Cluster}}",
+			info:    gke,
+			wantErr: "failed to execute go-template",
+		},
+		{
+			name:    "unknown format",
+			output:  "xml",
+			wantErr: `unsupported output format "xml" (supported: json, yaml, env, go-template=TEMPLATE)`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encode, err := NewClusterInfoEncoder(tt.output, tt.provider)
+			if err == nil {
+				var buf bytes.Buffer
+				err = encode(&buf, tt.info)
+				if err == nil {
+					require.Empty(t, tt.wantErr)
+					assert.Equal(t, tt.want, buf.String())
+					return
+				}
+			}
+			require.NotEmpty(t, tt.wantErr, "unexpected error: %v", err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
@@ -3,111 +3,271 @@ package common
 import (
 	"context"
 	"fmt"
+	"io"
+	"net/http"
 	"os"
 	"os/signal"
 	"strings"
 	"syscall"
 	"time"
+	"unicode"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 
 	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
-	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider/aws"
-	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider/azure"
-	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider/gcp"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
 	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/metrics"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/tracing"
+
+	// Providers register their constructors with internal/provider on import
+	_ "github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider/aws"
+	_ "github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider/azure"
+	_ "github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider/digitalocean"
+	_ "github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider/gcp"
+	_ "github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider/oci"
+	_ "github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider/oidc"
 )
 
 type Flags struct {
 	LogLevel        string
 	LogFormat       string
 	CredentialsFile string
+	CredentialsDir  string
+	DryRun          bool
+
+	// CredentialsMap is the file applied by ApplyCredentialsMap
+	CredentialsMap string
+
+	// Quiet only logs errors, overriding LogLevel, and drops decorative stderr output
+	Quiet bool
+
+	// StrictPermissions rejects credentials files readable by group or others
+	StrictPermissions bool
+
+	// CredentialsSHA256 are the comma-separated SHA-256 digests, one of which every GCP,
+	// AWS or Azure credentials file read must have
+	CredentialsSHA256 string
+
+	// CredentialsProfile is the credentials profile of --config-file to apply
+	CredentialsProfile string
+
+	// SkipCredentialCheck skips the pre-flight expiry check of AWS session credentials
+	SkipCredentialCheck bool
+
+	// STSEndpoint overrides the regional AWS STS endpoint, e.g. with a VPC endpoint
+	STSEndpoint string
+
+	// AWSFallbacks are the --aws-fallback credential sources tried in order when the
+	// AWS credentials fail
+	AWSFallbacks []string
+
+	// SuggestRegion looks for an AWS cluster that is not found in its region in a few
+	// likely regions
+	SuggestRegion bool
+
+	// PreferSecret authenticates Azure service principals with the client secret when
+	// they also have a client certificate
+	PreferSecret bool
+
+	// GKEEndpoint overrides the GKE Container API endpoint of cluster lookups
+	GKEEndpoint string
+
+	// Timeout bounds each cloud API operation; zero disables it
+	Timeout time.Duration
+
+	// Tracing is created once by the root command; nil disables tracing
+	Tracing *tracing.Provider
+
+	// HTTPClient is created once by the root command from --https-proxy, --no-proxy and
+	// --ca-bundle; nil keeps the SDK default transports
+	HTTPClient *http.Client
+
+	// RequestID identifies this invocation in every log line and in the reported
+	// error; the root command sets it with NewRequestID
+	RequestID string
+
+	// LogOutput receives the log lines of CreateLogger; nil writes them to LogFile, or
+	// to stderr when LogFile is empty
+	LogOutput io.Writer
+
+	// LogFile is the file log lines are appended to instead of stderr
+	LogFile string
+
+	// Viper resolves flag and environment values for the command being run; the root
+	// command creates it before the command runs
+	Viper *viper.Viper
+
+	// AllowedClusters are the allowed_clusters of --config-file; empty allows every cluster
+	AllowedClusters []string
+
+	// WatchCredentials reloads rotated credentials files (serve and refresh only)
+	WatchCredentials bool
+
+	// Metrics records credential reloads when the command serves /metrics; nil otherwise
+	Metrics *metrics.Metrics
 
 	ProviderName   string
 	ClusterName    string
 	Region         string
 	ProjectID      string
 	AccountID      string
+	AWSProfile     string
 	SubscriptionID string
 	TenantID       string
 	ResourceGroup  string
+	AzureCloud     string
+	TenancyID      string
+	UserID         string
+	CompartmentID  string
 	TokenDuration  string
+	GCPUseADC      string
+
+	// EndpointAccess picks the public or private endpoint of clusters that have both
+	EndpointAccess string
+
+	// ClusterResourceID addresses an AKS cluster by its ARM resource ID instead of
+	// ClusterName, ResourceGroup and SubscriptionID; see ApplyClusterResourceID
+	ClusterResourceID string
+
+	// VerifyEndpoint checks with a TLS handshake that the API server certificate
+	// verifies against the looked-up cluster CA
+	VerifyEndpoint bool
+
+	// OIDC provider flags
+	OIDCIssuerURL        string
+	OIDCClientID         string
+	OIDCPrivateKeyFile   string
+	OIDCScopes           string
+	OIDCSubjectTokenFile string
+	OIDCSubjectTokenType string
+	OIDCUseIDToken       bool
 }
 
-// InitViper initializes Viper for environment variable support
-func InitViper() {
-	viper.SetEnvPrefix("HFCP")
+// NewViper creates the viper instance of the command being run. Keys resolve to the
+// command's flags when set on the command line, then to HFCP_ environment variables,
+// then to the flag defaults. Each run gets its own instance so that commands sharing a
+// flag name, such as --health-address, do not overwrite each other's bindings.
+func NewViper(cmd *cobra.Command) *viper.Viper {
+	v := viper.New()
+	v.SetEnvPrefix("HFCP")
 
 	// Replace hyphens with underscores in environment variables
 	// e.g., --credentials-file -> HFCP_CREDENTIALS_FILE
-	viper.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
+	v.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
 
 	// Automatically bind environment variables
-	viper.AutomaticEnv()
-}
+	v.AutomaticEnv()
 
-// BindPersistentFlags binds persistent flags from root command to Viper
-func BindPersistentFlags(cmd *cobra.Command) {
-	viper.BindPFlags(cmd.PersistentFlags())
-}
+	// The credentials directory currently only applies to GCP keys
+	v.BindEnv("credentials-dir", "HFCP_CREDENTIALS_DIR", "HFCP_GCP_CREDENTIALS_DIR")
 
-// BindCommandFlags binds command-specific flags to Viper
-func BindCommandFlags(cmd *cobra.Command) error {
-	// Bind local flags (specific to this command)
-	return viper.BindPFlags(cmd.Flags())
+	if cmd != nil {
+		// Flags() includes the persistent flags inherited from the root command once
+		// the command line has been parsed
+		v.BindPFlags(cmd.Flags())
+	}
+	return v
 }
 
-// BindFlagsToViper binds command flags to Viper values
-// This ensures environment variables are read if flags are not provided
+// BindFlagsToViper applies the HFCP_ environment variables to flags. A field is only
+// overwritten when its flag was set on the command line or its environment variable is
+// set and non-empty, so flag values take precedence over the environment and flag
+// defaults survive when neither is given. Without flags.Viper, only the environment
+// is read.
 func BindFlagsToViper(flags *Flags) {
-	// Global flags - read from viper if not explicitly set via command line
-	if !isFlagSetExplicitly("log-level") {
-		flags.LogLevel = viper.GetString("log-level")
+	if flags.Viper == nil {
+		flags.Viper = NewViper(nil)
 	}
-	if !isFlagSetExplicitly("log-format") {
-		flags.LogFormat = viper.GetString("log-format")
-	}
-	if !isFlagSetExplicitly("credentials-file") {
-		flags.CredentialsFile = viper.GetString("credentials-file")
+	v := flags.Viper
+
+	// Global flags
+	bindString(v, "log-level", &flags.LogLevel)
+	bindString(v, "log-format", &flags.LogFormat)
+	bindString(v, "log-file", &flags.LogFile)
+	bindString(v, "credentials-file", &flags.CredentialsFile)
+	bindString(v, "credentials-dir", &flags.CredentialsDir)
+	bindString(v, "credentials-map", &flags.CredentialsMap)
+	bindBool(v, "dry-run", &flags.DryRun)
+	bindBool(v, "strict-permissions", &flags.StrictPermissions)
+	bindString(v, "credentials-sha256", &flags.CredentialsSHA256)
+	bindString(v, "credentials-profile", &flags.CredentialsProfile)
+	bindBool(v, "watch-credentials", &flags.WatchCredentials)
+	bindDuration(v, "timeout", &flags.Timeout)
+	bindBool(v, "quiet", &flags.Quiet)
+	if flags.Quiet {
+		// --quiet wins over --log-level and HFCP_LOG_LEVEL
+		flags.LogLevel = "error"
 	}
 
 	// Provider flags
-	if !isFlagSetExplicitly("provider") {
-		flags.ProviderName = viper.GetString("provider")
-	}
-	if !isFlagSetExplicitly("cluster-name") {
-		flags.ClusterName = viper.GetString("cluster-name")
-	}
-	if !isFlagSetExplicitly("region") {
-		flags.Region = viper.GetString("region")
-	}
-	if !isFlagSetExplicitly("project-id") {
-		flags.ProjectID = viper.GetString("project-id")
-	}
-	if !isFlagSetExplicitly("account-id") {
-		flags.AccountID = viper.GetString("account-id")
-	}
-	if !isFlagSetExplicitly("subscription-id") {
-		flags.SubscriptionID = viper.GetString("subscription-id")
+	bindString(v, "provider", &flags.ProviderName)
+	bindString(v, "cluster-name", &flags.ClusterName)
+	bindString(v, "region", &flags.Region)
+	bindString(v, "project-id", &flags.ProjectID)
+	bindString(v, "account-id", &flags.AccountID)
+	bindString(v, "profile", &flags.AWSProfile)
+	bindString(v, "subscription-id", &flags.SubscriptionID)
+	bindString(v, "tenant-id", &flags.TenantID)
+	bindString(v, "resource-group", &flags.ResourceGroup)
+	bindString(v, "azure-cloud", &flags.AzureCloud)
+	bindString(v, "tenancy-id", &flags.TenancyID)
+	bindString(v, "user-id", &flags.UserID)
+	bindString(v, "compartment-id", &flags.CompartmentID)
+	bindString(v, "token-duration", &flags.TokenDuration)
+	bindString(v, "gcp-use-adc", &flags.GCPUseADC)
+	bindString(v, "endpoint-access", &flags.EndpointAccess)
+	bindString(v, "cluster-resource-id", &flags.ClusterResourceID)
+	bindBool(v, "verify-endpoint", &flags.VerifyEndpoint)
+	bindBool(v, "skip-credential-check", &flags.SkipCredentialCheck)
+	bindString(v, "sts-endpoint", &flags.STSEndpoint)
+	bindStringSlice(v, "aws-fallback", &flags.AWSFallbacks)
+	bindBool(v, "suggest-region", &flags.SuggestRegion)
+	bindBool(v, "prefer-secret", &flags.PreferSecret)
+	bindString(v, "gke-endpoint", &flags.GKEEndpoint)
+	bindString(v, "issuer-url", &flags.OIDCIssuerURL)
+	bindString(v, "client-id", &flags.OIDCClientID)
+	bindString(v, "private-key-file", &flags.OIDCPrivateKeyFile)
+	bindString(v, "scopes", &flags.OIDCScopes)
+	bindString(v, "subject-token-file", &flags.OIDCSubjectTokenFile)
+	bindString(v, "subject-token-type", &flags.OIDCSubjectTokenType)
+	bindBool(v, "use-id-token", &flags.OIDCUseIDToken)
+}
+
+func bindString(v *viper.Viper, key string, field *string) {
+	if v.IsSet(key) {
+		*field = v.GetString(key)
 	}
-	if !isFlagSetExplicitly("tenant-id") {
-		flags.TenantID = viper.GetString("tenant-id")
+}
+
+func bindStringSlice(v *viper.Viper, key string, field *[]string) {
+	if v.IsSet(key) {
+		*field = v.GetStringSlice(key)
 	}
-	if !isFlagSetExplicitly("resource-group") {
-		flags.ResourceGroup = viper.GetString("resource-group")
+}
+
+func bindBool(v *viper.Viper, key string, field *bool) {
+	if v.IsSet(key) {
+		*field = v.GetBool(key)
 	}
-	if !isFlagSetExplicitly("token-duration") {
-		flags.TokenDuration = viper.GetString("token-duration")
+}
+
+func bindDuration(v *viper.Viper, key string, field *time.Duration) {
+	if v.IsSet(key) {
+		*field = v.GetDuration(key)
 	}
 }
 
-// isFlagSetExplicitly checks if a flag was set explicitly on the command line
-// If viper.IsSet returns true but the value equals the default, it was from env/config
-func isFlagSetExplicitly(flagName string) bool {
-	// This is a simplification - in practice we'd check the cobra command's flags
-	// For now, we always prefer viper values (env vars take precedence)
-	return false
+// StatusOutput returns where decorative status lines such as "✅ Kubeconfig generated"
+// are written: stderr, as stdout may carry the ExecCredential or kubeconfig, or nowhere
+// with --quiet
+func StatusOutput(flags *Flags) io.Writer {
+	if flags.Quiet {
+		return io.Discard
+	}
+	return os.Stderr
 }
 
 func CreateLogger(flags *Flags) (logger.Logger, error) {
@@ -135,44 +295,210 @@ func CreateLogger(flags *Flags) (logger.Logger, error) {
 		format = logger.JSONFormat
 	}
 
-	return logger.New(logger.Config{
+	output := flags.LogOutput
+	if output == nil && flags.LogFile != "" {
+		// The file stays open for the life of the process
+		file, err := os.OpenFile(flags.LogFile, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+		if err != nil {
+			return nil, errors.Wrap(
+				errors.ErrInvalidArgument,
+				err,
+				"failed to open --log-file",
+			).WithField("path", flags.LogFile)
+		}
+		output = file
+	}
+	if output == nil {
+		output = os.Stderr
+	}
+	log, err := logger.New(logger.Config{
 		Level:  level,
 		Format: format,
-		Output: os.Stderr,
+		Output: output,
 	})
+	if err != nil {
+		return nil, err
+	}
+	if flags.RequestID != "" {
+		log = log.With(logger.String(errors.RequestIDField, flags.RequestID))
+	}
+	return log, nil
+}
+
+// NewProviderConfig builds the shared provider configuration from the command flags.
+// A zero tokenDuration uses the provider default.
+func NewProviderConfig(flags *Flags, tokenDuration time.Duration) *provider.Config {
+	return &provider.Config{
+		Region:               flags.Region,
+		ProjectID:            flags.ProjectID,
+		AccountID:            flags.AccountID,
+		AWSProfile:           flags.AWSProfile,
+		SubscriptionID:       flags.SubscriptionID,
+		TenantID:             flags.TenantID,
+		AzureCloud:           flags.AzureCloud,
+		TenancyID:            flags.TenancyID,
+		UserID:               flags.UserID,
+		CompartmentID:        flags.CompartmentID,
+		CredentialsFile:      flags.CredentialsFile,
+		CredentialsDir:       flags.CredentialsDir,
+		GCPUseADC:            flags.GCPUseADC,
+		OIDCIssuerURL:        flags.OIDCIssuerURL,
+		OIDCClientID:         flags.OIDCClientID,
+		OIDCPrivateKeyFile:   flags.OIDCPrivateKeyFile,
+		OIDCScopes:           splitList(flags.OIDCScopes),
+		OIDCSubjectTokenFile: flags.OIDCSubjectTokenFile,
+		OIDCSubjectTokenType: flags.OIDCSubjectTokenType,
+		OIDCUseIDToken:       flags.OIDCUseIDToken,
+		TokenDuration:        tokenDuration,
+		StrictPermissions:    flags.StrictPermissions,
+		CredentialsSHA256:    flags.CredentialsSHA256,
+		SkipCredentialCheck:  flags.SkipCredentialCheck,
+		STSEndpoint:          flags.STSEndpoint,
+		AWSFallbacks:         flags.AWSFallbacks,
+		AWSSuggestRegion:     flags.SuggestRegion,
+		AzurePreferSecret:    flags.PreferSecret,
+		GKEEndpoint:          flags.GKEEndpoint,
+		Tracing:              flags.Tracing,
+		HTTPClient:           flags.HTTPClient,
+		WatchCredentials:     flags.WatchCredentials,
+		Metrics:              flags.Metrics,
+	}
+}
+
+// splitList splits a comma- or space-separated flag value such as --scopes, returning
+// nil for an empty value
+func splitList(value string) []string {
+	fields := strings.FieldsFunc(value, func(r rune) bool {
+		return r == ',' || unicode.IsSpace(r)
+	})
+	if len(fields) == 0 {
+		return nil
+	}
+	return fields
 }
 
 func CreateProvider(flags *Flags, log logger.Logger) (provider.Provider, error) {
-	switch flags.ProviderName {
-	case "gcp":
-		config := &gcp.Config{
-			ProjectID:       flags.ProjectID,
-			CredentialsFile: flags.CredentialsFile,
-			TokenDuration:   1 * time.Hour,
-			Scopes:          gcp.DefaultScopes(),
-		}
-		return gcp.NewProvider(config, log)
+	return provider.New(flags.ProviderName, NewProviderConfig(flags, 0), log)
+}
 
-	case "aws":
-		config := &aws.Config{
-			Region:          flags.Region,
-			CredentialsFile: flags.CredentialsFile,
-			TokenDuration:   15 * time.Minute,
-		}
-		return aws.NewProvider(config, log)
+// CloseProvider closes prov on shutdown, stopping the credentials file watcher of
+// --watch-credentials. A failure is logged, since the command is done with prov.
+func CloseProvider(prov provider.Provider, log logger.Logger) {
+	if err := provider.Close(prov); err != nil {
+		log.Warn("Failed to close provider",
+			logger.String("provider", prov.Name()),
+			logger.Error(err),
+		)
+	}
+}
 
-	case "azure":
-		config := &azure.Config{
-			TenantID:        flags.TenantID,
-			SubscriptionID:  flags.SubscriptionID,
-			CredentialsFile: flags.CredentialsFile,
-			TokenDuration:   1 * time.Hour,
+// DescribeCluster looks up the cluster endpoint and CA certificate through the selected provider.
+// The lookup is bounded by flags.Timeout.
+func DescribeCluster(ctx context.Context, flags *Flags, tokenDuration time.Duration, log logger.Logger) (*ClusterInfo, error) {
+	describer, err := NewClusterDescriber(flags, tokenDuration, log)
+	if err != nil {
+		return nil, err
+	}
+	return DescribeClusterWith(ctx, describer, flags)
+}
+
+// NewClusterDescriber creates the selected provider and checks that it can look up clusters
+func NewClusterDescriber(flags *Flags, tokenDuration time.Duration, log logger.Logger) (provider.ClusterDescriber, error) {
+	prov, err := provider.New(flags.ProviderName, NewProviderConfig(flags, tokenDuration), log)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s provider: %w", flags.ProviderName, err)
+	}
+
+	describer, ok := prov.(provider.ClusterDescriber)
+	if !ok {
+		return nil, fmt.Errorf("provider %s does not support cluster lookup", flags.ProviderName)
+	}
+	return describer, nil
+}
+
+// DescribeClusterWith looks up the cluster named by flags with an existing provider, so
+// callers describing many clusters can reuse one provider and its SDK clients.
+// The lookup is bounded by flags.Timeout.
+func DescribeClusterWith(ctx context.Context, describer provider.ClusterDescriber, flags *Flags) (*ClusterInfo, error) {
+	if _, err := ParseEndpointAccess(flags); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := WithTimeout(ctx, flags)
+	defer cancel()
+
+	start := time.Now()
+	info, err := describer.DescribeCluster(ctx, provider.ClusterInfoOptions{
+		ClusterName:    flags.ClusterName,
+		Region:         flags.Region,
+		ResourceGroup:  flags.ResourceGroup,
+		EndpointAccess: flags.EndpointAccess,
+	})
+	if err != nil {
+		return nil, TimeoutError(ctx, err, "describe cluster", start)
+	}
+
+	return &ClusterInfo{
+		Endpoint:             info.Endpoint,
+		CertificateAuthority: info.CertificateAuthority,
+		Version:              info.Version,
+		Location:             info.Location,
+		Region:               info.Region,
+		ARN:                  info.ARN,
+		ResourceID:           info.ResourceID,
+	}, nil
+}
+
+// AddEndpointAccessFlag adds the --endpoint-access flag read by ParseEndpointAccess to a
+// command that looks up clusters. --endpoint-type is accepted as its alias.
+func AddEndpointAccessFlag(cmd *cobra.Command, flags *Flags) {
+	cmd.Flags().StringVar(&flags.EndpointAccess, "endpoint-access", "", "API server endpoint to use when the cluster has both a public and a private one: public or private (default: public when the cluster has one; alias --endpoint-type)")
+	SetFlagProviders(cmd, []string{"gcp", "aws", "azure"}, "endpoint-access")
+	cmd.Flags().SetNormalizeFunc(func(_ *pflag.FlagSet, name string) pflag.NormalizedName {
+		if name == "endpoint-type" {
+			name = "endpoint-access"
 		}
-		return azure.NewProvider(config, log)
+		return pflag.NormalizedName(name)
+	})
+}
 
-	default:
-		return nil, fmt.Errorf("unsupported provider: %s (must be one of: gcp, aws, azure)", flags.ProviderName)
+// AddSuggestRegionFlag adds the --suggest-region flag to a command that looks up clusters
+func AddSuggestRegionFlag(cmd *cobra.Command, flags *Flags) {
+	cmd.Flags().BoolVar(&flags.SuggestRegion, "suggest-region", false, "When an AWS cluster is not found, look for it in a few nearby regions and name the region it is in (up to 3 extra DescribeCluster calls)")
+	SetFlagProviders(cmd, []string{"aws"}, "suggest-region")
+}
+
+// AddPreferSecretFlag adds the --prefer-secret flag to a command that authenticates with
+// Azure credentials
+func AddPreferSecretFlag(cmd *cobra.Command, flags *Flags) {
+	cmd.Flags().BoolVar(&flags.PreferSecret, "prefer-secret", false, "Authenticate with the client secret when the Azure credentials have both a secret and a client certificate (default: the certificate)")
+	SetFlagProviders(cmd, []string{"azure"}, "prefer-secret")
+}
+
+// AddVerifyEndpointFlag adds the --verify-endpoint flag to a command that looks up clusters
+func AddVerifyEndpointFlag(cmd *cobra.Command, flags *Flags) {
+	cmd.Flags().BoolVar(&flags.VerifyEndpoint, "verify-endpoint", false, "Check with a TLS handshake that the API server certificate verifies against the cluster CA, without sending an API request")
+}
+
+// AddGKEEndpointFlag adds the --gke-endpoint flag to a command that looks up clusters
+func AddGKEEndpointFlag(cmd *cobra.Command, flags *Flags) {
+	cmd.Flags().StringVar(&flags.GKEEndpoint, "gke-endpoint", "", "GKE Container API endpoint URL for cluster lookups, e.g. in a non-default universe domain (default: from the credentials' universe domain)")
+	SetFlagProviders(cmd, []string{"gcp"}, "gke-endpoint")
+}
+
+// ParseEndpointAccess parses --endpoint-access and checks that the selected provider
+// can pick between public and private endpoints when it is set
+func ParseEndpointAccess(flags *Flags) (provider.EndpointAccess, error) {
+	access, err := provider.ParseEndpointAccess(flags.EndpointAccess)
+	if err != nil {
+		return "", err
 	}
+	if access != provider.EndpointAccessDefault {
+		if err := provider.CheckEndpointAccessSupported(flags.ProviderName); err != nil {
+			return "", err
+		}
+	}
+	return access, nil
 }
 
 func SetupSignalHandler() (context.Context, context.CancelFunc) {
@@ -201,11 +527,35 @@ func GetCredentialsPath(flags *Flags) string {
 		return "/vault/secrets/aws-credentials"
 	case "azure":
 		return "/vault/secrets/azure-credentials.json"
+	case "oci":
+		return "/vault/secrets/oci-config"
+	case "digitalocean":
+		return "/vault/secrets/digitalocean-token"
+	case "oidc":
+		return "/vault/secrets/oidc-client-secret"
 	default:
 		return "/vault/secrets/credentials"
 	}
 }
 
+// CheckTokenDuration validates --token-duration before any cloud call, logging a
+// warning when it is under provider.MinTokenDuration
+func CheckTokenDuration(flags *Flags, log logger.Logger) error {
+	duration, err := ParseTokenDuration(flags)
+	if err != nil {
+		return err
+	}
+	if flags.TokenDuration != "" && duration < provider.MinTokenDuration {
+		log.Warn("Token duration is very short; kubectl will run the exec plugin for almost every request",
+			logger.String("token_duration", duration.String()),
+			logger.String("min_token_duration", provider.MinTokenDuration.String()),
+		)
+	}
+	return nil
+}
+
+// ParseTokenDuration returns --token-duration, or the registered provider default when it
+// is unset. A duration beyond the longest the provider can issue is an ErrInvalidArgument error.
 func ParseTokenDuration(flags *Flags) (time.Duration, error) {
 	if flags.TokenDuration != "" {
 		duration, err := time.ParseDuration(flags.TokenDuration)
@@ -215,17 +565,11 @@ func ParseTokenDuration(flags *Flags) (time.Duration, error) {
 		if duration <= 0 {
 			return 0, fmt.Errorf("token duration must be positive")
 		}
+		if err := provider.CheckTokenDuration(flags.ProviderName, duration); err != nil {
+			return 0, err
+		}
 		return duration, nil
 	}
 
-	switch flags.ProviderName {
-	case "gcp":
-		return 1 * time.Hour, nil
-	case "aws":
-		return 15 * time.Minute, nil
-	case "azure":
-		return 1 * time.Hour, nil
-	default:
-		return 1 * time.Hour, nil
-	}
+	return provider.DefaultsOf(flags.ProviderName).TokenDuration, nil
 }
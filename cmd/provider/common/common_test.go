@@ -0,0 +1,252 @@
+package common
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+)
+
+func TestRegisteredProviders(t *testing.T) {
+	assert.Equal(t, []string{"aws", "azure", "digitalocean", "gcp", "oci", "oidc"}, provider.Registered())
+}
+
+func TestCreateProvider(t *testing.T) {
+	tests := []struct {
+		name        string
+		flags       Flags
+		wantName    string
+		noLookup    bool
+		wantErrCode errors.ErrorCode
+	}{
+		{
+			name:     "gcp",
+			flags:    Flags{ProviderName: "gcp", ProjectID: "my-project", GCPUseADC: "false", CredentialsFile: "/nonexistent/sa.json"},
+			wantName: "gcp",
+		},
+		{
+			name:     "aws",
+			flags:    Flags{ProviderName: "aws", Region: "us-east-1"},
+			wantName: "aws",
+		},
+		{
+			name:     "azure",
+			flags:    Flags{ProviderName: "azure", TenantID: "tenant", SubscriptionID: "subscription"},
+			wantName: "azure",
+		},
+		{
+			name:     "oci",
+			flags:    Flags{ProviderName: "oci", Region: "us-ashburn-1"},
+			wantName: "oci",
+		},
+		{
+			name:     "digitalocean",
+			flags:    Flags{ProviderName: "digitalocean"},
+			wantName: "digitalocean",
+		},
+		{
+			name:     "oidc",
+			flags:    Flags{ProviderName: "oidc", OIDCIssuerURL: "https://issuer.example.com", OIDCClientID: "client"},
+			wantName: "oidc",
+			noLookup: true,
+		},
+		{
+			name:        "oidc without issuer",
+			flags:       Flags{ProviderName: "oidc", OIDCClientID: "client"},
+			wantErrCode: errors.ErrConfigMissingField,
+		},
+		{
+			name:        "invalid gcp ADC mode",
+			flags:       Flags{ProviderName: "gcp", ProjectID: "my-project", GCPUseADC: "sometimes"},
+			wantErrCode: errors.ErrInvalidArgument,
+		},
+		{
+			name:        "unsupported provider",
+			flags:       Flags{ProviderName: "nonexistent"},
+			wantErrCode: errors.ErrProviderNotSupported,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prov, err := CreateProvider(&tt.flags, logger.Nop())
+
+			if tt.wantErrCode != "" {
+				require.Error(t, err)
+				assert.True(t, errors.Is(err, tt.wantErrCode),
+					"expected error code %s, got %v", tt.wantErrCode, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantName, prov.Name())
+			_, ok := prov.(provider.ClusterDescriber)
+			assert.Equal(t, !tt.noLookup, ok, "%s cluster lookup support", tt.wantName)
+		})
+	}
+}
+
+func TestNewProviderConfig(t *testing.T) {
+	flags := &Flags{
+		Region:            "us-east-1",
+		ProjectID:         "project",
+		CredentialsDir:    "/keys",
+		GCPUseADC:         "false",
+		StrictPermissions: true,
+		OIDCScopes:        "openid, kubernetes groups",
+	}
+
+	cfg := NewProviderConfig(flags, 30*time.Minute)
+
+	assert.Equal(t, "us-east-1", cfg.Region)
+	assert.Equal(t, "project", cfg.ProjectID)
+	assert.Equal(t, "/keys", cfg.CredentialsDir)
+	assert.Equal(t, "false", cfg.GCPUseADC)
+	assert.Equal(t, 30*time.Minute, cfg.TokenDuration)
+	assert.True(t, cfg.StrictPermissions)
+	assert.Equal(t, []string{"openid", "kubernetes", "groups"}, cfg.OIDCScopes)
+	assert.Nil(t, NewProviderConfig(&Flags{}, 0).OIDCScopes)
+}
+
+func TestAddEndpointAccessFlag_EndpointTypeAlias(t *testing.T) {
+	for _, args := range [][]string{{"--endpoint-access=private"}, {"--endpoint-type=private"}} {
+		flags := &Flags{}
+		cmd := &cobra.Command{Use: "test", RunE: func(*cobra.Command, []string) error { return nil }}
+		AddEndpointAccessFlag(cmd, flags)
+		cmd.SetArgs(args)
+
+		require.NoError(t, cmd.Execute(), args)
+		assert.Equal(t, "private", flags.EndpointAccess, args)
+	}
+}
+
+func TestParseTokenDuration_ProviderLimits(t *testing.T) {
+	tests := []struct {
+		provider string
+		duration string
+		wantErr  string
+	}{
+		{provider: "aws", duration: "15m"},
+		{provider: "aws", duration: "16m", wantErr: "token duration 16m0s exceeds the aws maximum of 15m0s"},
+		{provider: "aws", duration: "24h", wantErr: "exceeds the aws maximum"},
+		{provider: "aws", duration: "30s"},
+		{provider: "gcp", duration: "1h"},
+		{provider: "gcp", duration: "2h", wantErr: "token duration 2h0m0s exceeds the gcp maximum of 1h0m0s"},
+		{provider: "gcp", duration: "30s"},
+		{provider: "azure", duration: "1h"},
+		{provider: "azure", duration: "90m", wantErr: "token duration 1h30m0s exceeds the azure maximum of 1h0m0s"},
+		{provider: "azure", duration: "30s"},
+		// The DigitalOcean duration only sets how long kubectl caches the API token
+		{provider: "digitalocean", duration: "24h"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.provider+"/"+tt.duration, func(t *testing.T) {
+			duration, err := ParseTokenDuration(&Flags{ProviderName: tt.provider, TokenDuration: tt.duration})
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				assert.True(t, errors.Is(err, errors.ErrInvalidArgument))
+				return
+			}
+			require.NoError(t, err)
+			want, _ := time.ParseDuration(tt.duration)
+			assert.Equal(t, want, duration)
+		})
+	}
+}
+
+func TestCheckTokenDuration_WarnsBelowMinimum(t *testing.T) {
+	for _, name := range []string{"aws", "gcp", "azure"} {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			log, err := logger.New(logger.Config{Level: logger.InfoLevel, Format: logger.JSONFormat, Output: &buf})
+			require.NoError(t, err)
+
+			require.NoError(t, CheckTokenDuration(&Flags{ProviderName: name, TokenDuration: "30s"}, log))
+			assert.Contains(t, buf.String(), "Token duration is very short")
+
+			buf.Reset()
+			require.NoError(t, CheckTokenDuration(&Flags{ProviderName: name, TokenDuration: "1m"}, log))
+			require.NoError(t, CheckTokenDuration(&Flags{ProviderName: name}, log))
+			assert.Empty(t, buf.String())
+
+			require.Error(t, CheckTokenDuration(&Flags{ProviderName: name, TokenDuration: "24h"}, log))
+		})
+	}
+}
+
+// captureOutput replaces os.Stdout and os.Stderr while fn runs and returns what was
+// written to them
+func captureOutput(t *testing.T, fn func()) (string, string) {
+	t.Helper()
+
+	read := func(target **os.File) func() string {
+		r, w, err := os.Pipe()
+		require.NoError(t, err)
+		original := *target
+		*target = w
+		done := make(chan string)
+		go func() {
+			data, _ := io.ReadAll(r)
+			done <- string(data)
+		}()
+		return func() string {
+			*target = original
+			require.NoError(t, w.Close())
+			return <-done
+		}
+	}
+	stdout := read(&os.Stdout)
+	stderr := read(&os.Stderr)
+	fn()
+	return stdout(), stderr()
+}
+
+func TestCreateLogger_LogFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "provider.log")
+
+	stdout, stderr := captureOutput(t, func() {
+		for _, msg := range []string{"first run", "second run"} {
+			log, err := CreateLogger(&Flags{LogLevel: "info", LogFormat: "json", LogFile: path})
+			require.NoError(t, err)
+			log.Info(msg)
+			require.NoError(t, log.Sync())
+		}
+	})
+	assert.Empty(t, stdout)
+	assert.Empty(t, stderr)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 2, "second logger must append")
+	assert.Contains(t, lines[0], "first run")
+	assert.Contains(t, lines[1], "second run")
+
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	}
+}
+
+func TestCreateLogger_LogFileError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing", "provider.log")
+
+	_, err := CreateLogger(&Flags{LogLevel: "info", LogFormat: "json", LogFile: path})
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, errors.ErrInvalidArgument))
+	assert.Contains(t, err.Error(), "failed to open --log-file")
+}
@@ -0,0 +1,58 @@
+package common
+
+import (
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	internalcreds "github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/credentials"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+)
+
+// RegisterCompletions installs dynamic shell completion for the --provider and
+// --profile flags of cmd and all of its subcommands. Static completion of commands
+// and other flags comes from cobra's completion command.
+func RegisterCompletions(cmd *cobra.Command) {
+	if cmd.Flags().Lookup("provider") != nil {
+		cmd.RegisterFlagCompletionFunc("provider", CompleteProviders)
+	}
+	if cmd.Flags().Lookup("profile") != nil {
+		cmd.RegisterFlagCompletionFunc("profile", CompleteAWSProfiles)
+	}
+	for _, sub := range cmd.Commands() {
+		RegisterCompletions(sub)
+	}
+}
+
+// CompleteProviders completes --provider with the registered provider names
+func CompleteProviders(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return withPrefix(provider.Registered(), toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// CompleteAWSProfiles completes --profile with the profiles in the AWS shared files that
+// get-token would read: --credentials-file or AWS_CREDENTIALS_FILE, AWS_CONFIG_FILE, and
+// the files under ~/.aws when neither is set. Unreadable files complete nothing.
+func CompleteAWSProfiles(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	credentialsFile := os.Getenv("AWS_CREDENTIALS_FILE")
+	if flag := cmd.Flag("credentials-file"); flag != nil && flag.Changed {
+		credentialsFile = flag.Value.String()
+	}
+
+	profiles, err := internalcreds.AWSProfiles(credentialsFile, os.Getenv("AWS_CONFIG_FILE"))
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	return withPrefix(profiles, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// withPrefix returns the values that start with prefix
+func withPrefix(values []string, prefix string) []string {
+	var matches []string
+	for _, value := range values {
+		if strings.HasPrefix(value, prefix) {
+			matches = append(matches, value)
+		}
+	}
+	return matches
+}
@@ -0,0 +1,86 @@
+package common
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newCompletionTestRoot builds a root command with a subcommand that has the flags
+// completed dynamically
+func newCompletionTestRoot() *cobra.Command {
+	var s string
+	root := &cobra.Command{Use: "hyperfleet-credential-provider"}
+	root.PersistentFlags().StringVar(&s, "credentials-file", "", "")
+
+	cmd := &cobra.Command{
+		Use:  "get-token",
+		RunE: func(cmd *cobra.Command, args []string) error { return nil },
+	}
+	cmd.Flags().StringVar(&s, "provider", "", "")
+	cmd.Flags().StringVar(&s, "profile", "", "")
+	root.AddCommand(cmd)
+
+	RegisterCompletions(root)
+	return root
+}
+
+// complete runs cobra's hidden completion command and returns the candidates
+func complete(t *testing.T, args ...string) []string {
+	t.Helper()
+	root := newCompletionTestRoot()
+	var out bytes.Buffer
+	root.SetOut(&out)
+	root.SetArgs(append([]string{cobra.ShellCompRequestCmd}, args...))
+	require.NoError(t, root.Execute())
+
+	var candidates []string
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		if !strings.HasPrefix(line, ":") {
+			candidates = append(candidates, line)
+		}
+	}
+	return candidates
+}
+
+func TestCompleteProviders(t *testing.T) {
+	assert.Equal(t, []string{"aws", "azure", "digitalocean", "gcp", "oci", "oidc"}, complete(t, "get-token", "--provider", ""))
+	assert.Equal(t, []string{"aws", "azure"}, complete(t, "get-token", "--provider", "a"))
+}
+
+func TestCompleteAWSProfiles(t *testing.T) {
+	dir := t.TempDir()
+	credsFile := filepath.Join(dir, "credentials")
+	configFile := filepath.Join(dir, "config")
+	otherFile := filepath.Join(dir, "other")
+	require.NoError(t, os.WriteFile(credsFile, []byte("[default]\n[prod]\n[prod-eu]\n"), 0600))
+	require.NoError(t, os.WriteFile(configFile, []byte("[profile staging]\nregion = us-west-2\n"), 0600))
+	require.NoError(t, os.WriteFile(otherFile, []byte("[sandbox]\n"), 0600))
+
+	t.Setenv("AWS_CREDENTIALS_FILE", credsFile)
+	t.Setenv("AWS_CONFIG_FILE", configFile)
+	assert.Equal(t, []string{"default", "prod", "prod-eu", "staging"}, complete(t, "get-token", "--profile", ""))
+	assert.Equal(t, []string{"prod", "prod-eu"}, complete(t, "get-token", "--profile", "pr"))
+
+	// --credentials-file replaces AWS_CREDENTIALS_FILE as it does for get-token
+	assert.Equal(t, []string{"sandbox", "staging"}, complete(t, "--credentials-file", otherFile, "get-token", "--profile", ""))
+}
+
+func TestCompletionScripts(t *testing.T) {
+	for _, shell := range []string{"bash", "zsh", "fish", "powershell"} {
+		t.Run(shell, func(t *testing.T) {
+			root := newCompletionTestRoot()
+			var out bytes.Buffer
+			root.SetOut(&out)
+			root.SetArgs([]string{"completion", shell})
+			require.NoError(t, root.Execute())
+			assert.Contains(t, out.String(), "hyperfleet-credential-provider")
+		})
+	}
+}
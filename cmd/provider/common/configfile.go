@@ -0,0 +1,76 @@
+package common
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	internalconfig "github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/config"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider/aws"
+)
+
+// AddCredentialsProfileFlag adds the --credentials-profile flag read by LoadConfigFile
+func AddCredentialsProfileFlag(cmd *cobra.Command, flags *Flags) {
+	cmd.Flags().StringVar(&flags.CredentialsProfile, "credentials-profile", "", "Credentials profile of --config-file applied to the provider settings (default: the profile key of the file)")
+}
+
+// LoadConfigFile reads the sections of --config-file that apply to every command, with
+// the --credentials-profile of the command applied. It returns an empty configuration
+// when no file is given.
+func LoadConfigFile(v *viper.Viper) (*internalconfig.Config, error) {
+	path := v.GetString("config-file")
+	if path == "" {
+		return &internalconfig.Config{}, nil
+	}
+	return internalconfig.LoadCommandConfig(path, v.GetString("credentials-profile"))
+}
+
+// ApplyProviderConfig makes the provider section of --config-file the default of the
+// matching flags, so that flags and HFCP_ variables win over the file. Of the provider
+// sections, only the one of provider.name applies.
+func ApplyProviderConfig(v *viper.Viper, p internalconfig.ProviderConfig) {
+	setDefault := func(key, value string) {
+		if value != "" {
+			v.SetDefault(key, value)
+		}
+	}
+
+	setDefault("provider", p.Name)
+	setDefault("cluster-name", p.ClusterName)
+	setDefault("region", p.Region)
+	if p.Timeout > 0 {
+		v.SetDefault("timeout", p.Timeout)
+	}
+	if p.StrictPermissions {
+		v.SetDefault("strict-permissions", true)
+	}
+
+	switch {
+	case p.Name == "gcp" && p.GCP != nil:
+		setDefault("project-id", p.GCP.ProjectID)
+		setDefault("credentials-file", p.GCP.CredentialsFile)
+		setDefault("gcp-use-adc", p.GCP.UseADC)
+		if p.GCP.TokenDuration > 0 {
+			setDefault("token-duration", p.GCP.TokenDuration.String())
+		}
+	case p.Name == "aws" && p.AWS != nil:
+		setDefault("account-id", p.AWS.AccountID)
+		if p.AWS.TokenDuration > 0 {
+			setDefault("token-duration", p.AWS.TokenDuration.String())
+		}
+		if len(p.AWS.Fallback) > 0 {
+			fallbacks := make([]string, len(p.AWS.Fallback))
+			for i, entry := range p.AWS.Fallback {
+				fallbacks[i] = aws.Fallback{Profile: entry.Profile, Region: entry.Region, RoleARN: entry.RoleARN}.String()
+			}
+			v.SetDefault("aws-fallback", fallbacks)
+		}
+	case p.Name == "azure" && p.Azure != nil:
+		setDefault("subscription-id", p.Azure.SubscriptionID)
+		setDefault("tenant-id", p.Azure.TenantID)
+		setDefault("resource-group", p.Azure.ResourceGroup)
+		setDefault("azure-cloud", p.Azure.Cloud)
+		if p.Azure.TokenDuration > 0 {
+			setDefault("token-duration", p.Azure.TokenDuration.String())
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package common
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyProviderConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`provider:
+  name: gcp
+  cluster_name: file-cluster
+  region: us-central1
+  timeout: 45s
+  gcp:
+    project_id: file-project
+    credentials_file: /etc/gcp/key.json
+    use_adc: "false"
+  aws:
+    account_id: "123456789012"
+`), 0600))
+
+	flags := &Flags{}
+	cmd := &cobra.Command{Use: "get-token"}
+	cmd.Flags().String("config-file", "", "")
+	cmd.Flags().StringVar(&flags.ProviderName, "provider", "", "")
+	cmd.Flags().StringVar(&flags.ClusterName, "cluster-name", "", "")
+	cmd.Flags().StringVar(&flags.Region, "region", "", "")
+	cmd.Flags().StringVar(&flags.ProjectID, "project-id", "", "")
+	cmd.Flags().StringVar(&flags.AccountID, "account-id", "", "")
+	cmd.Flags().StringVar(&flags.GCPUseADC, "gcp-use-adc", "auto", "")
+	cmd.Flags().DurationVar(&flags.Timeout, "timeout", DefaultTimeout, "")
+	require.NoError(t, cmd.ParseFlags([]string{"--config-file=" + path, "--cluster-name=flag-cluster"}))
+	t.Setenv("HFCP_REGION", "europe-west1")
+
+	flags.Viper = NewViper(cmd)
+	fileConfig, err := LoadConfigFile(flags.Viper)
+	require.NoError(t, err)
+	ApplyProviderConfig(flags.Viper, fileConfig.Provider)
+	BindFlagsToViper(flags)
+
+	assert.Equal(t, "gcp", flags.ProviderName)
+	assert.Equal(t, "flag-cluster", flags.ClusterName, "flags win over the file")
+	assert.Equal(t, "europe-west1", flags.Region, "HFCP_ variables win over the file")
+	assert.Equal(t, "file-project", flags.ProjectID)
+	assert.Equal(t, "/etc/gcp/key.json", flags.CredentialsFile)
+	assert.Equal(t, "false", flags.GCPUseADC, "the file wins over flag defaults")
+	assert.Equal(t, 45*time.Second, flags.Timeout)
+	assert.Empty(t, flags.AccountID, "sections of other providers are ignored")
+}
+
+func TestApplyProviderConfig_CredentialsProfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`provider:
+  name: gcp
+  gcp:
+    project_id: base-project
+    credentials_file: /etc/gcp/base.json
+profile: staging
+credentials:
+  staging:
+    gcp:
+      project_id: staging-project
+      credentials_file: /etc/gcp/staging.json
+  prod:
+    gcp:
+      project_id: prod-project
+      credentials_file: /etc/gcp/prod.json
+`), 0600))
+
+	tests := []struct {
+		name        string
+		args        []string
+		env         string
+		wantProject string
+		wantFile    string
+		wantErr     string
+	}{
+		{
+			name:        "the profile key of the file",
+			wantProject: "staging-project",
+			wantFile:    "/etc/gcp/staging.json",
+		},
+		{
+			name:        "flag",
+			args:        []string{"--credentials-profile=prod"},
+			wantProject: "prod-project",
+			wantFile:    "/etc/gcp/prod.json",
+		},
+		{
+			name:        "environment variable",
+			env:         "prod",
+			wantProject: "prod-project",
+			wantFile:    "/etc/gcp/prod.json",
+		},
+		{
+			name:    "unknown profile",
+			args:    []string{"--credentials-profile=qa"},
+			wantErr: `unknown credentials profile "qa"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("HFCP_CREDENTIALS_PROFILE", tt.env)
+			flags := &Flags{}
+			cmd := &cobra.Command{Use: "get-token"}
+			cmd.Flags().String("config-file", "", "")
+			cmd.Flags().StringVar(&flags.ProviderName, "provider", "", "")
+			cmd.Flags().StringVar(&flags.ProjectID, "project-id", "", "")
+			AddCredentialsProfileFlag(cmd, flags)
+			require.NoError(t, cmd.ParseFlags(append([]string{"--config-file=" + path}, tt.args...)))
+
+			flags.Viper = NewViper(cmd)
+			fileConfig, err := LoadConfigFile(flags.Viper)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			ApplyProviderConfig(flags.Viper, fileConfig.Provider)
+			BindFlagsToViper(flags)
+
+			assert.Equal(t, tt.wantProject, flags.ProjectID)
+			assert.Equal(t, tt.wantFile, flags.CredentialsFile)
+		})
+	}
+}
+
+func TestApplyProviderConfig_AWSFallback(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`provider:
+  name: aws
+  cluster_name: eks-prod
+  aws:
+    fallback:
+      - profile: backup
+        region: us-west-2
+      - role_arn: arn:aws:iam::123456789012:role/eks-admin
+`), 0600))
+
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{
+			name: "from the file",
+			want: []string{"profile=backup,region=us-west-2", "role_arn=arn:aws:iam::123456789012:role/eks-admin"},
+		},
+		{
+			name: "flags win over the file",
+			args: []string{"--aws-fallback=profile=dr,region=eu-west-1"},
+			want: []string{"profile=dr,region=eu-west-1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flags := &Flags{}
+			cmd := &cobra.Command{Use: "get-token"}
+			cmd.Flags().String("config-file", "", "")
+			cmd.Flags().StringVar(&flags.ProviderName, "provider", "", "")
+			cmd.Flags().StringArrayVar(&flags.AWSFallbacks, "aws-fallback", nil, "")
+			require.NoError(t, cmd.ParseFlags(append([]string{"--config-file=" + path}, tt.args...)))
+
+			flags.Viper = NewViper(cmd)
+			fileConfig, err := LoadConfigFile(flags.Viper)
+			require.NoError(t, err)
+			ApplyProviderConfig(flags.Viper, fileConfig.Provider)
+			BindFlagsToViper(flags)
+
+			assert.Equal(t, tt.want, flags.AWSFallbacks)
+			assert.Equal(t, tt.want, NewProviderConfig(flags, 0).AWSFallbacks)
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package common
+
+import (
+	"fmt"
+	"strings"
+
+	internalconfig "github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/config"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+)
+
+// UsesCredentialsMap reports whether ApplyCredentialsMap selects the credentials: a
+// --credentials-map is set and --credentials-file, or HFCP_CREDENTIALS_FILE, is not
+func UsesCredentialsMap(flags *Flags) bool {
+	return flags.CredentialsMap != "" && !flags.Viper.IsSet("credentials-file")
+}
+
+// CredentialsMapKeys returns the keys of --credentials-map that may select the
+// credentials of the cluster named by flags, most specific first
+func CredentialsMapKeys(flags *Flags) []string {
+	keys := []string{flags.ProviderName + "/" + flags.ClusterName}
+
+	var id string
+	switch internalconfig.CredentialsMapScope(flags.ProviderName) {
+	case "project":
+		id = flags.ProjectID
+	case "account":
+		id = flags.AccountID
+	case "subscription":
+		id = flags.SubscriptionID
+	}
+	if id != "" {
+		keys = append(keys, flags.ProviderName+"/"+internalconfig.CredentialsMapScope(flags.ProviderName)+"/"+id)
+	}
+	return keys
+}
+
+// ApplyCredentialsMap sets the credentials file, AWS profile and GCP ADC mode of the
+// cluster named by flags from the first entry of --credentials-map matching one of
+// CredentialsMapKeys. Values given with a flag or HFCP_ variable win over the entry, and
+// the entry wins over the environment the cloud SDKs read, such as
+// GOOGLE_APPLICATION_CREDENTIALS. A cluster without an entry fails with
+// ErrCredentialNotFound rather than falling back to other credentials.
+func ApplyCredentialsMap(flags *Flags) error {
+	if !UsesCredentialsMap(flags) {
+		return nil
+	}
+
+	credentialsMap, err := internalconfig.LoadCredentialsMap(flags.CredentialsMap)
+	if err != nil {
+		return err
+	}
+
+	keys := CredentialsMapKeys(flags)
+	for _, key := range keys {
+		entry, ok := credentialsMap[key]
+		if !ok {
+			continue
+		}
+		flags.CredentialsFile = entry.CredentialsFile
+		if entry.Profile != "" && !flags.Viper.IsSet("profile") {
+			flags.AWSProfile = entry.Profile
+		}
+		if entry.UseADC != "" && !flags.Viper.IsSet("gcp-use-adc") {
+			flags.GCPUseADC = entry.UseADC
+		}
+		return nil
+	}
+
+	return errors.New(
+		errors.ErrCredentialNotFound,
+		fmt.Sprintf("credentials map has no entry for %s", keys[0]),
+	).WithFields(map[string]interface{}{
+		"credentials_map": flags.CredentialsMap,
+		"keys":            strings.Join(keys, ", "),
+	}).WithDetail("add an entry keyed " + strings.Join(keys, " or ") + ", or pass --credentials-file")
+}
@@ -0,0 +1,144 @@
+package common
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+)
+
+// applyCredentialsMapArgs parses args like a command taking the credentials flags, then
+// applies the credentials map
+func applyCredentialsMapArgs(t *testing.T, args ...string) (*Flags, error) {
+	t.Helper()
+
+	flags := &Flags{}
+	var applyErr error
+	cmd := &cobra.Command{
+		Use: "get-token",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			flags.Viper = NewViper(cmd)
+			BindFlagsToViper(flags)
+			applyErr = ApplyCredentialsMap(flags)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&flags.CredentialsFile, "credentials-file", "", "")
+	cmd.Flags().StringVar(&flags.CredentialsMap, "credentials-map", "", "")
+	cmd.Flags().StringVar(&flags.ProviderName, "provider", "", "")
+	cmd.Flags().StringVar(&flags.ClusterName, "cluster-name", "", "")
+	cmd.Flags().StringVar(&flags.ProjectID, "project-id", "", "")
+	cmd.Flags().StringVar(&flags.AccountID, "account-id", "", "")
+	cmd.Flags().StringVar(&flags.AWSProfile, "profile", "", "")
+	cmd.Flags().StringVar(&flags.GCPUseADC, "gcp-use-adc", "auto", "")
+	cmd.SetArgs(args)
+	require.NoError(t, cmd.Execute())
+	return flags, applyErr
+}
+
+func TestApplyCredentialsMap(t *testing.T) {
+	t.Setenv("HFCP_CREDENTIALS_FILE", "")
+	t.Setenv("HFCP_PROFILE", "")
+	t.Setenv("GOOGLE_APPLICATION_CREDENTIALS", "/env/gcp.json")
+	path := filepath.Join(t.TempDir(), "credentials-map.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`aws/prod-east:
+  credentials_file: /secrets/aws-prod-east
+  profile: deploy
+aws/account/123456789012:
+  credentials_file: /secrets/aws-prod
+gcp/project/my-project:
+  credentials_file: /secrets/gcp.json
+  use_adc: "false"
+`), 0600))
+
+	tests := []struct {
+		name        string
+		args        []string
+		env         map[string]string
+		wantFile    string
+		wantProfile string
+		wantUseADC  string
+	}{
+		{
+			name:        "cluster key",
+			args:        []string{"--provider=aws", "--cluster-name=prod-east", "--account-id=123456789012"},
+			wantFile:    "/secrets/aws-prod-east",
+			wantProfile: "deploy",
+			wantUseADC:  "auto",
+		},
+		{
+			name:       "account key",
+			args:       []string{"--provider=aws", "--cluster-name=prod-west", "--account-id=123456789012"},
+			wantFile:   "/secrets/aws-prod",
+			wantUseADC: "auto",
+		},
+		{
+			name:       "map entry over the SDK environment",
+			args:       []string{"--provider=gcp", "--cluster-name=any", "--project-id=my-project"},
+			wantFile:   "/secrets/gcp.json",
+			wantUseADC: "false",
+		},
+		{
+			name:        "flags over the map entry",
+			args:        []string{"--provider=aws", "--cluster-name=prod-east", "--profile=admin"},
+			wantFile:    "/secrets/aws-prod-east",
+			wantProfile: "admin",
+			wantUseADC:  "auto",
+		},
+		{
+			name:       "HFCP_ variables over the map entry",
+			args:       []string{"--provider=gcp", "--cluster-name=any", "--project-id=my-project"},
+			env:        map[string]string{"HFCP_GCP_USE_ADC": "true"},
+			wantFile:   "/secrets/gcp.json",
+			wantUseADC: "true",
+		},
+		{
+			name:       "explicit credentials file skips the map",
+			args:       []string{"--provider=aws", "--cluster-name=unmapped", "--credentials-file=/secrets/other"},
+			wantFile:   "/secrets/other",
+			wantUseADC: "auto",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+			flags, err := applyCredentialsMapArgs(t, append(tt.args, "--credentials-map="+path)...)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantFile, flags.CredentialsFile)
+			assert.Equal(t, tt.wantProfile, flags.AWSProfile)
+			assert.Equal(t, tt.wantUseADC, flags.GCPUseADC)
+		})
+	}
+
+	t.Run("missing entry", func(t *testing.T) {
+		_, err := applyCredentialsMapArgs(t, "--provider=aws", "--cluster-name=staging", "--account-id=210987654321", "--credentials-map="+path)
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, errors.ErrCredentialNotFound), "got %v", err)
+		assert.Equal(t, ExitCredential, ExitCode(err))
+		assert.Contains(t, err.Error(), "credentials map has no entry for aws/staging")
+		var appErr *errors.Error
+		require.True(t, errors.As(err, &appErr))
+		assert.Equal(t, "aws/staging, aws/account/210987654321", appErr.Fields["keys"])
+	})
+
+	t.Run("invalid map", func(t *testing.T) {
+		invalid := filepath.Join(t.TempDir(), "credentials-map.yaml")
+		require.NoError(t, os.WriteFile(invalid, []byte("aws: {}\n"), 0600))
+		_, err := applyCredentialsMapArgs(t, "--provider=aws", "--cluster-name=prod-east", "--credentials-map="+invalid)
+		assert.True(t, errors.Is(err, errors.ErrValidationFailed), "got %v", err)
+	})
+
+	t.Run("no map", func(t *testing.T) {
+		flags, err := applyCredentialsMapArgs(t, "--provider=aws", "--cluster-name=prod-east")
+		require.NoError(t, err)
+		assert.Empty(t, flags.CredentialsFile)
+	})
+}
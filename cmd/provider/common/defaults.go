@@ -0,0 +1,114 @@
+package common
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+
+	internalconfig "github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/config"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+)
+
+// ApplyTokenDefaults overrides the per-provider token duration and refresh threshold
+// from fileDefaults, the defaults section of --config-file, then from
+// HFCP_<PROVIDER>_TOKEN_DURATION and HFCP_<PROVIDER>_REFRESH_THRESHOLD, so the
+// environment wins over the file and --token-duration over both. The result is
+// validated against each provider's maximum, so a bad override fails every command at
+// startup.
+func ApplyTokenDefaults(v *viper.Viper, fileDefaults map[string]internalconfig.ProviderDefaults) error {
+	overrides := make(map[string]internalconfig.ProviderDefaults, len(fileDefaults))
+	for name, d := range fileDefaults {
+		overrides[name] = d
+	}
+
+	for _, name := range provider.Registered() {
+		d := overrides[name]
+		tokenDuration, err := envDuration(v, name+"-token-duration")
+		if err != nil {
+			return err
+		}
+		if tokenDuration > 0 {
+			d.TokenDuration = tokenDuration
+		}
+		refreshThreshold, err := envDuration(v, name+"-refresh-threshold")
+		if err != nil {
+			return err
+		}
+		if refreshThreshold > 0 {
+			d.RefreshThreshold = refreshThreshold
+		}
+		if d != (internalconfig.ProviderDefaults{}) {
+			overrides[name] = d
+		}
+	}
+
+	return provider.ApplyConfigDefaults(overrides)
+}
+
+// ApplyClockSkew sets the clock skew tolerance of token refresh and expiry decisions
+// from --clock-skew or HFCP_CLOCK_SKEW. A tolerance that, added to the refresh
+// threshold of a provider, reaches its token duration is rejected.
+func ApplyClockSkew(v *viper.Viper) error {
+	value := v.GetString("clock-skew")
+	if value == "" {
+		return nil
+	}
+	skew, err := time.ParseDuration(value)
+	if err != nil || skew < 0 {
+		return errors.New(
+			errors.ErrConfigInvalid,
+			fmt.Sprintf("--clock-skew must be a duration of zero or more, got %q", value),
+		).WithField("clock_skew", value)
+	}
+	if err := provider.SetClockSkew(skew); err != nil {
+		return errors.Wrap(
+			errors.ErrConfigInvalid,
+			err,
+			fmt.Sprintf("--clock-skew %s is too large", value),
+		).WithField("clock_skew", value)
+	}
+	return nil
+}
+
+// envDuration parses the HFCP_ environment variable of key; zero when it is unset
+func envDuration(v *viper.Viper, key string) (time.Duration, error) {
+	value := v.GetString(key)
+	if value == "" {
+		return 0, nil
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		env := "HFCP_" + strings.ToUpper(strings.ReplaceAll(key, "-", "_"))
+		return 0, errors.New(
+			errors.ErrConfigInvalid,
+			fmt.Sprintf("%s must be a positive duration, got %q", env, value),
+		).WithField("env", env)
+	}
+	return duration, nil
+}
+
+// TokenDurationHelp is the help text of --token-duration, listing the built-in
+// default of each provider
+func TokenDurationHelp() string {
+	names := provider.Registered()
+	defaults := make([]string, 0, len(names))
+	for _, name := range names {
+		defaults = append(defaults, name+"="+shortDuration(provider.DefaultsOf(name).TokenDuration))
+	}
+	return "Token duration (e.g., 1h, 30m, 900s) (default: " + strings.Join(defaults, ", ") + ")"
+}
+
+// shortDuration formats d without zero trailing units, e.g. 1h instead of 1h0m0s
+func shortDuration(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
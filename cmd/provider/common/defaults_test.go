@@ -0,0 +1,188 @@
+package common
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+)
+
+// defaultsConfig overrides the GCP and AWS defaults
+const defaultsConfig = `
+defaults:
+  gcp:
+    token_duration: 30m
+    refresh_threshold: 3m
+  aws:
+    token_duration: 10m
+`
+
+// newDefaultsViper returns a viper with --config-file set to a file of content, and
+// restores the registered defaults when the test ends
+func newDefaultsViper(t *testing.T, content string) *viper.Viper {
+	t.Helper()
+
+	cmd := &cobra.Command{Use: "test"}
+	cmd.Flags().String("config-file", "", "")
+	if content != "" {
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+		require.NoError(t, cmd.Flags().Set("config-file", path))
+	}
+
+	t.Cleanup(func() { require.NoError(t, provider.ApplyConfigDefaults(nil)) })
+	return NewViper(cmd)
+}
+
+// applyTokenDefaults applies the token defaults of --config-file and the environment
+func applyTokenDefaults(v *viper.Viper) error {
+	fileConfig, err := LoadConfigFile(v)
+	if err != nil {
+		return err
+	}
+	return ApplyTokenDefaults(v, fileConfig.Defaults)
+}
+
+func TestApplyTokenDefaults_Precedence(t *testing.T) {
+	t.Setenv("HFCP_GCP_TOKEN_DURATION", "20m")
+	t.Setenv("HFCP_AZURE_REFRESH_THRESHOLD", "10m")
+	require.NoError(t, applyTokenDefaults(newDefaultsViper(t, defaultsConfig)))
+
+	// The environment wins over the file, which wins over the built-in defaults
+	assert.Equal(t, 20*time.Minute, provider.DefaultsOf("gcp").TokenDuration)
+	assert.Equal(t, 3*time.Minute, provider.RefreshThresholdOf("gcp"))
+	assert.Equal(t, 10*time.Minute, provider.DefaultsOf("aws").TokenDuration)
+	assert.Equal(t, 2*time.Minute, provider.RefreshThresholdOf("aws"))
+	assert.Equal(t, time.Hour, provider.DefaultsOf("azure").TokenDuration)
+	assert.Equal(t, 10*time.Minute, provider.RefreshThresholdOf("azure"))
+
+	// --token-duration wins over every default
+	duration, err := ParseTokenDuration(&Flags{ProviderName: "gcp"})
+	require.NoError(t, err)
+	assert.Equal(t, 20*time.Minute, duration)
+	duration, err = ParseTokenDuration(&Flags{ProviderName: "gcp", TokenDuration: "45m"})
+	require.NoError(t, err)
+	assert.Equal(t, 45*time.Minute, duration)
+}
+
+func TestApplyTokenDefaults_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		env     map[string]string
+		wantMsg string
+	}{
+		{
+			name:    "duration above the AWS maximum",
+			env:     map[string]string{"HFCP_AWS_TOKEN_DURATION": "30m"},
+			wantMsg: "invalid aws defaults: token duration 30m0s exceeds the maximum of 15m0s",
+		},
+		{
+			name:    "duration above the Azure maximum",
+			config:  "defaults:\n  azure:\n    token_duration: 2h\n",
+			wantMsg: "invalid azure defaults: token duration 2h0m0s exceeds the maximum of 1h0m0s",
+		},
+		{
+			name:    "threshold not below the duration",
+			env:     map[string]string{"HFCP_GCP_TOKEN_DURATION": "5m", "HFCP_GCP_REFRESH_THRESHOLD": "5m"},
+			wantMsg: "refresh threshold 5m0s must be shorter than the token duration 5m0s",
+		},
+		{
+			name:    "threshold not below the duration in the file",
+			config:  "defaults:\n  gcp:\n    token_duration: 10m\n    refresh_threshold: 15m\n",
+			wantMsg: "defaults.gcp.refresh_threshold",
+		},
+		{
+			name:    "unknown provider in the file",
+			config:  "defaults:\n  ibm:\n    token_duration: 10m\n",
+			wantMsg: "cannot override defaults of unknown provider ibm",
+		},
+		{
+			name:    "malformed environment variable",
+			env:     map[string]string{"HFCP_GCP_REFRESH_THRESHOLD": "soon"},
+			wantMsg: `HFCP_GCP_REFRESH_THRESHOLD must be a positive duration, got "soon"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			err := applyTokenDefaults(newDefaultsViper(t, tt.config))
+			require.Error(t, err)
+			assert.Contains(t, err.Error()+" "+errorDetail(err), tt.wantMsg)
+			assert.Equal(t, ExitInvalidArgument, ExitCode(err))
+		})
+	}
+}
+
+// errorDetail returns the detail of an application error
+func errorDetail(err error) string {
+	var appErr *errors.Error
+	if errors.As(err, &appErr) {
+		return appErr.Detail
+	}
+	return ""
+}
+
+func TestTokenDurationHelp(t *testing.T) {
+	help := TokenDurationHelp()
+	assert.Contains(t, help, "aws=15m")
+	assert.Contains(t, help, "gcp=1h")
+	assert.Contains(t, help, "azure=1h")
+	assert.Contains(t, help, "oci=4m")
+}
+
+func TestApplyClockSkew(t *testing.T) {
+	tests := []struct {
+		name     string
+		flag     string
+		env      string
+		wantSkew time.Duration
+		wantErr  string
+	}{
+		{name: "flag default", wantSkew: provider.DefaultClockSkew},
+		{name: "flag", flag: "90s", wantSkew: 90 * time.Second},
+		{name: "longer than a default token leaves", flag: "3m", wantErr: "--clock-skew 3m0s is too large: invalid oci defaults: refresh threshold 1m0s plus the clock skew tolerance 3m0s must be shorter than the token duration 4m0s"},
+		{name: "environment", env: "30s", wantSkew: 30 * time.Second},
+		{name: "flag wins over environment", flag: "2m", env: "30s", wantSkew: 2 * time.Minute},
+		{name: "disabled", flag: "0s", wantSkew: 0},
+		{name: "negative", env: "-1m", wantErr: `--clock-skew must be a duration of zero or more, got "-1m"`},
+		{name: "malformed", env: "a while", wantErr: `--clock-skew must be a duration of zero or more, got "a while"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Cleanup(func() { require.NoError(t, provider.SetClockSkew(provider.DefaultClockSkew)) })
+			if tt.env != "" {
+				t.Setenv("HFCP_CLOCK_SKEW", tt.env)
+			}
+
+			cmd := &cobra.Command{Use: "test"}
+			cmd.Flags().Duration("clock-skew", provider.DefaultClockSkew, "")
+			if tt.flag != "" {
+				require.NoError(t, cmd.Flags().Set("clock-skew", tt.flag))
+			}
+
+			err := ApplyClockSkew(NewViper(cmd))
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				assert.Equal(t, ExitInvalidArgument, ExitCode(err))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantSkew, provider.ClockSkew())
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync/atomic"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/credentials"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider/gcp"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+)
+
+// DryRunGuard is the transport of the HTTP client providers are constructed with in
+// dry-run mode. It refuses every request, so a cloud SDK call made by mistake fails
+// instead of spending API quota, and counts the refused requests.
+type DryRunGuard struct {
+	refused atomic.Int64
+}
+
+// RoundTrip implements http.RoundTripper by refusing the request
+func (g *DryRunGuard) RoundTrip(req *http.Request) (*http.Response, error) {
+	g.refused.Add(1)
+	return nil, errors.New(
+		errors.ErrInternal,
+		fmt.Sprintf("dry run refused a network call to %s", req.URL.Host),
+	).WithField("host", req.URL.Host)
+}
+
+// Refused returns the number of requests refused so far
+func (g *DryRunGuard) Refused() int64 {
+	return g.refused.Load()
+}
+
+// Client returns an HTTP client whose requests go through the guard
+func (g *DryRunGuard) Client() *http.Client {
+	return &http.Client{Transport: g}
+}
+
+// ValidateCredentialsOffline loads and validates the local credentials for the selected
+// provider (file readable, JSON/INI parseable, required fields present) without calling any cloud API
+func ValidateCredentialsOffline(ctx context.Context, flags *Flags, log logger.Logger) error {
+	// Credentials in a secret source such as Vault are only fetched when the command runs
+	if credentials.IsSecretURI(flags.CredentialsFile) {
+		return nil
+	}
+
+	loader := credentials.NewOfflineLoader(log,
+		credentials.WithStrictPermissions(flags.StrictPermissions),
+		credentials.WithExpectedSHA256(flags.CredentialsSHA256),
+	)
+
+	var err error
+	switch flags.ProviderName {
+	case "gcp":
+		useADC, parseErr := gcp.ParseADCMode(flags.GCPUseADC)
+		if parseErr != nil {
+			return errors.Wrap(errors.ErrInvalidArgument, parseErr, "invalid --gcp-use-adc")
+		}
+		// Application default credentials come from the metadata server or gcloud
+		// and cannot be validated without calling out
+		if gcp.UsesADC(useADC, flags.CredentialsFile != "" || flags.CredentialsDir != "") {
+			if flags.CredentialsSHA256 != "" {
+				return errors.New(
+					errors.ErrCredentialInvalid,
+					"GCP application default credentials cannot be checked against --credentials-sha256",
+				).WithDetail("use --credentials-file, or drop --credentials-sha256")
+			}
+			return nil
+		}
+		path := flags.CredentialsFile
+		if path == "" && flags.CredentialsDir != "" {
+			if path, err = credentials.NewGCPKeyDir(flags.CredentialsDir).Select(flags.ProjectID); err != nil {
+				return err
+			}
+		}
+		_, err = loader.LoadGCP(ctx, path)
+	case "aws":
+		_, err = loader.LoadAWS(ctx, credentials.AWSCredentialOptions{
+			CredentialsFile: flags.CredentialsFile,
+			Profile:         flags.AWSProfile,
+			Region:          flags.Region,
+			UseEnvironment:  true,
+		})
+	case "azure":
+		_, err = loader.LoadAzure(ctx, credentials.AzureCredentialOptions{
+			CredentialsFile: flags.CredentialsFile,
+			TenantID:        flags.TenantID,
+			UseEnvironment:  true,
+		})
+	case "oci":
+		_, err = loader.LoadOCI(ctx, credentials.OCICredentialOptions{
+			ConfigFile:     flags.CredentialsFile,
+			TenancyID:      flags.TenancyID,
+			UserID:         flags.UserID,
+			Region:         flags.Region,
+			UseEnvironment: true,
+		})
+	case "digitalocean":
+		_, err = loader.LoadDigitalOcean(ctx, credentials.DigitalOceanCredentialOptions{
+			CredentialsFile: flags.CredentialsFile,
+			UseEnvironment:  true,
+		})
+	case "oidc":
+		_, err = loader.LoadOIDC(ctx, credentials.OIDCCredentialOptions{
+			CredentialsFile: flags.CredentialsFile,
+			PrivateKeyFile:  flags.OIDCPrivateKeyFile,
+			UseEnvironment:  true,
+		})
+	default:
+		return fmt.Errorf("unsupported provider: %s (must be one of: gcp, aws, azure, oci, digitalocean, oidc)", flags.ProviderName)
+	}
+
+	return err
+}
+
+// RunDryRun validates local credentials, constructs the provider and prints the action
+// that would be performed. It never calls a cloud API: the provider gets a DryRunGuard
+// client, and a request it refused fails the dry run.
+func RunDryRun(ctx context.Context, flags *Flags, log logger.Logger, w io.Writer, action string, details map[string]string) error {
+	if err := ValidateCredentialsOffline(ctx, flags, log); err != nil {
+		return fmt.Errorf("dry run: credential validation failed: %w", err)
+	}
+	if err := constructDryRunProvider(flags, log); err != nil {
+		return fmt.Errorf("dry run: %w", err)
+	}
+
+	credentialStatus := "valid (environment)"
+	if flags.CredentialsFile != "" {
+		credentialStatus = fmt.Sprintf("valid (%s)", flags.CredentialsFile)
+	}
+	if credentials.IsSecretURI(flags.CredentialsFile) {
+		credentialStatus = fmt.Sprintf("%s (secret source, not checked offline)", flags.CredentialsFile)
+	}
+	if flags.ProviderName == "gcp" {
+		if flags.CredentialsFile == "" && flags.CredentialsDir != "" {
+			credentialStatus = fmt.Sprintf("valid (%s)", flags.CredentialsDir)
+		}
+		if useADC, _ := gcp.ParseADCMode(flags.GCPUseADC); gcp.UsesADC(useADC, flags.CredentialsFile != "" || flags.CredentialsDir != "") {
+			credentialStatus = "application default credentials (not checked offline)"
+		}
+	}
+
+	fmt.Fprintf(w, "Dry run: would %s\n", action)
+	fmt.Fprintf(w, "  provider: %s\n", flags.ProviderName)
+	if flags.ClusterName != "" {
+		fmt.Fprintf(w, "  cluster: %s\n", flags.ClusterName)
+	}
+	fmt.Fprintf(w, "  credentials: %s\n", credentialStatus)
+
+	keys := make([]string, 0, len(details))
+	for key, value := range details {
+		if value != "" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(w, "  %s: %s\n", key, details[key])
+	}
+
+	log.Info("Dry run completed",
+		logger.String("provider", flags.ProviderName),
+		logger.String("action", action),
+	)
+
+	return nil
+}
+
+// constructDryRunProvider creates the provider of flags the way the command would, with
+// the HTTP client replaced by a DryRunGuard, to catch configuration errors that only
+// the provider checks
+func constructDryRunProvider(flags *Flags, log logger.Logger) error {
+	guard := &DryRunGuard{}
+	guarded := *flags
+	guarded.HTTPClient = guard.Client()
+	guarded.WatchCredentials = false
+
+	if _, err := CreateProvider(&guarded, log); err != nil {
+		return err
+	}
+	if refused := guard.Refused(); refused > 0 {
+		return errors.New(
+			errors.ErrInternal,
+			fmt.Sprintf("provider %s attempted %d network calls while being constructed", flags.ProviderName, refused),
+		).WithField("provider", flags.ProviderName)
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+)
+
+// errorOutput is the --error-format=json form of a command error
+type errorOutput struct {
+	Message string                 `json:"message"`
+	Code    errors.ErrorCode       `json:"code"`
+	Detail  string                 `json:"detail,omitempty"`
+	Fields  map[string]interface{} `json:"fields,omitempty"`
+}
+
+// WriteError reports a failed command on w: "Error: <message>" for the text format, or
+// one JSON object with the message, error code, detail and non-sensitive fields for json
+func WriteError(w io.Writer, err error, format string) error {
+	switch format {
+	case "", "text":
+		_, writeErr := fmt.Fprintf(w, "Error: %v\n", err)
+		return writeErr
+	case "json":
+		out := errorOutput{Message: err.Error(), Code: errors.GetCode(err)}
+		var appErr *errors.Error
+		if errors.As(err, &appErr) {
+			out.Detail = appErr.Detail
+			if fields := appErr.Redact().Fields; len(fields) > 0 {
+				out.Fields = fields
+			}
+		}
+		return json.NewEncoder(w).Encode(out)
+	default:
+		return fmt.Errorf("unsupported error format %q (must be text or json)", format)
+	}
+}
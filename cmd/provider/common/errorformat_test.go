@@ -0,0 +1,55 @@
+package common
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+)
+
+func TestWriteError_Text(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteError(&buf, fmt.Errorf("boom"), "text"))
+	assert.Equal(t, "Error: boom\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, WriteError(&buf, fmt.Errorf("boom"), ""))
+	assert.Equal(t, "Error: boom\n", buf.String())
+}
+
+func TestWriteError_JSON(t *testing.T) {
+	err := errors.New(errors.ErrInvalidArgument, "2 missing or invalid flags").
+		WithField("problems", []string{"--tenant-id is required", "--resource-group is required"}).
+		WithField("token", "secret")
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteError(&buf, err, "json"))
+
+	var out struct {
+		Message string                 `json:"message"`
+		Code    string                 `json:"code"`
+		Fields  map[string]interface{} `json:"fields"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &out))
+	assert.Equal(t, err.Error(), out.Message)
+	assert.Equal(t, string(errors.ErrInvalidArgument), out.Code)
+	assert.Len(t, out.Fields["problems"], 2)
+	assert.NotEqual(t, "secret", out.Fields["token"])
+}
+
+func TestWriteError_PlainErrorJSON(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteError(&buf, fmt.Errorf("boom"), "json"))
+	assert.JSONEq(t, `{"message":"boom","code":"ERR_UNKNOWN"}`, buf.String())
+}
+
+func TestWriteError_UnsupportedFormat(t *testing.T) {
+	var buf bytes.Buffer
+	assert.Error(t, WriteError(&buf, fmt.Errorf("boom"), "yaml"))
+	assert.Empty(t, buf.String())
+}
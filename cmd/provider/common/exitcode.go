@@ -0,0 +1,179 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+)
+
+// Exit codes of a failed command by failure class, so that CI pipelines can retry
+// infrastructure failures and page on credential failures. They are stable: a new
+// error code joins one of the existing classes.
+const (
+	ExitSuccess         = 0
+	ExitUnknown         = 1
+	ExitInvalidArgument = 10
+	ExitCredential      = 20
+	ExitToken           = 30
+	ExitCluster         = 40
+	ExitNetwork         = 50
+)
+
+// FailureSummaryFileEnv names the file a failed command writes its JSON failure
+// summary to
+const FailureSummaryFileEnv = "HFCP_FAILURE_SUMMARY_FILE"
+
+// ExitCodeHelp documents the exit codes in --help
+const ExitCodeHelp = `Exit codes:
+  0   success
+  1   unknown or internal error
+  10  invalid argument or configuration
+  20  credential not found, invalid or expired
+  30  token generation failed
+  40  cluster not found, unreachable or misconfigured
+  50  network timeout, unreachable network or rate limit (retryable)
+
+On failure, a JSON summary with the error code, exit code, message, provider and
+cluster is also written to the file named by ` + FailureSummaryFileEnv + ` when it is set.`
+
+// exitCodes maps error codes to their exit code; unlisted codes exit with ExitUnknown
+var exitCodes = map[errors.ErrorCode]int{
+	errors.ErrInvalidArgument:       ExitInvalidArgument,
+	errors.ErrValidationFailed:      ExitInvalidArgument,
+	errors.ErrInvalidFormat:         ExitInvalidArgument,
+	errors.ErrMissingRequired:       ExitInvalidArgument,
+	errors.ErrConfigInvalid:         ExitInvalidArgument,
+	errors.ErrConfigLoadFailed:      ExitInvalidArgument,
+	errors.ErrConfigMissingField:    ExitInvalidArgument,
+	errors.ErrProviderNotSupported:  ExitInvalidArgument,
+	errors.ErrProviderNotRegistered: ExitInvalidArgument,
+
+	errors.ErrCredentialNotFound:         ExitCredential,
+	errors.ErrCredentialInvalid:          ExitCredential,
+	errors.ErrCredentialMalformed:        ExitCredential,
+	errors.ErrCredentialExpired:          ExitCredential,
+	errors.ErrCredentialLoadFailed:       ExitCredential,
+	errors.ErrCredentialValidationFailed: ExitCredential,
+	errors.ErrUnauthenticated:            ExitCredential,
+	errors.ErrPermissionDenied:           ExitCredential,
+
+	errors.ErrTokenGenerationFailed:   ExitToken,
+	errors.ErrTokenExpired:            ExitToken,
+	errors.ErrTokenInvalid:            ExitToken,
+	errors.ErrTokenMalformed:          ExitToken,
+	errors.ErrExecPluginFailed:        ExitToken,
+	errors.ErrExecPluginInvalidOutput: ExitToken,
+
+	errors.ErrClusterNotFound:      ExitCluster,
+	errors.ErrClusterUnreachable:   ExitCluster,
+	errors.ErrClusterInvalidConfig: ExitCluster,
+
+	errors.ErrNetworkTimeout:     ExitNetwork,
+	errors.ErrNetworkUnreachable: ExitNetwork,
+	errors.ErrRateLimitExceeded:  ExitNetwork,
+	errors.ErrFileLocked:         ExitNetwork,
+}
+
+// ExitCode returns the exit code of a command that returned err: ExitSuccess for nil,
+// otherwise the class of its error code
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitSuccess
+	}
+	if code, ok := exitCodes[errors.GetCode(err)]; ok {
+		return code
+	}
+	return ExitUnknown
+}
+
+// failureSummary is the JSON document written to HFCP_FAILURE_SUMMARY_FILE
+type failureSummary struct {
+	Code      errors.ErrorCode `json:"code"`
+	ExitCode  int              `json:"exitCode"`
+	Message   string           `json:"message"`
+	Provider  string           `json:"provider,omitempty"`
+	Cluster   string           `json:"cluster,omitempty"`
+	RequestID string           `json:"requestId,omitempty"`
+}
+
+// WriteFailureSummary writes the JSON failure summary of err to path with 0600
+// permissions, replacing any previous summary. flags may be nil when the command
+// failed before its flags were resolved.
+func WriteFailureSummary(path string, err error, flags *Flags) error {
+	summary := failureSummary{
+		Code:     errors.GetCode(err),
+		ExitCode: ExitCode(err),
+		Message:  err.Error(),
+	}
+	if flags != nil {
+		summary.Provider = flags.ProviderName
+		summary.Cluster = flags.ClusterName
+		summary.RequestID = flags.RequestID
+	}
+
+	data, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode failure summary: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("failed to write failure summary: %w", err)
+	}
+	return nil
+}
+
+// cobraUsagePrefixes start the messages of the usage errors cobra returns without a
+// FlagErrorFunc: unknown commands, wrong argument counts and missing required flags
+var cobraUsagePrefixes = []string{
+	"unknown command ",
+	"accepts ",
+	"requires at least ",
+	"requires at most ",
+	"received ",
+	"required flag(s) ",
+	"if any flags in the group ",
+	"at least one of the flags in the group ",
+}
+
+// FlagError is the FlagErrorFunc of the root command: a flag that fails to parse is an
+// ErrInvalidArgument, so that it exits with ExitInvalidArgument
+func FlagError(cmd *cobra.Command, err error) error {
+	return usageError(err)
+}
+
+// ClassifyUsageError returns err as an ErrInvalidArgument when it is a cobra usage
+// error, and unchanged otherwise
+func ClassifyUsageError(err error) error {
+	if err == nil || errors.As(err, new(*errors.Error)) {
+		return err
+	}
+	for _, prefix := range cobraUsagePrefixes {
+		if strings.HasPrefix(err.Error(), prefix) {
+			return usageError(err)
+		}
+	}
+	return err
+}
+
+// usageError wraps a cobra usage error in an ErrInvalidArgument with the same message
+func usageError(err error) error {
+	usageErr := errors.New(errors.ErrInvalidArgument, err.Error())
+	usageErr.Cause = err
+	return usageErr
+}
+
+// ReportFailure writes the failure summary of err when HFCP_FAILURE_SUMMARY_FILE is set
+// and returns the exit code of err. Failing to write the summary is reported on
+// stderr, not in the exit code, so pipelines still see the original failure class.
+func ReportFailure(err error, flags *Flags) int {
+	if path := os.Getenv(FailureSummaryFileEnv); path != "" {
+		if writeErr := WriteFailureSummary(path, err, flags); writeErr != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", writeErr)
+		}
+	}
+	return ExitCode(err)
+}
@@ -0,0 +1,175 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		code errors.ErrorCode
+		want int
+	}{
+		{errors.ErrUnknown, ExitUnknown},
+		{errors.ErrInternal, ExitUnknown},
+		{errors.ErrNotFound, ExitUnknown},
+		{errors.ErrAlreadyExists, ExitUnknown},
+		{errors.ErrProviderInitFailed, ExitUnknown},
+
+		{errors.ErrInvalidArgument, ExitInvalidArgument},
+		{errors.ErrValidationFailed, ExitInvalidArgument},
+		{errors.ErrInvalidFormat, ExitInvalidArgument},
+		{errors.ErrMissingRequired, ExitInvalidArgument},
+		{errors.ErrConfigInvalid, ExitInvalidArgument},
+		{errors.ErrConfigLoadFailed, ExitInvalidArgument},
+		{errors.ErrConfigMissingField, ExitInvalidArgument},
+		{errors.ErrProviderNotSupported, ExitInvalidArgument},
+		{errors.ErrProviderNotRegistered, ExitInvalidArgument},
+
+		{errors.ErrCredentialNotFound, ExitCredential},
+		{errors.ErrCredentialInvalid, ExitCredential},
+		{errors.ErrCredentialMalformed, ExitCredential},
+		{errors.ErrCredentialExpired, ExitCredential},
+		{errors.ErrCredentialLoadFailed, ExitCredential},
+		{errors.ErrCredentialValidationFailed, ExitCredential},
+		{errors.ErrUnauthenticated, ExitCredential},
+		{errors.ErrPermissionDenied, ExitCredential},
+
+		{errors.ErrTokenGenerationFailed, ExitToken},
+		{errors.ErrTokenExpired, ExitToken},
+		{errors.ErrTokenInvalid, ExitToken},
+		{errors.ErrTokenMalformed, ExitToken},
+		{errors.ErrExecPluginFailed, ExitToken},
+		{errors.ErrExecPluginInvalidOutput, ExitToken},
+
+		{errors.ErrClusterNotFound, ExitCluster},
+		{errors.ErrClusterUnreachable, ExitCluster},
+		{errors.ErrClusterInvalidConfig, ExitCluster},
+
+		{errors.ErrNetworkTimeout, ExitNetwork},
+		{errors.ErrNetworkUnreachable, ExitNetwork},
+		{errors.ErrRateLimitExceeded, ExitNetwork},
+		{errors.ErrFileLocked, ExitNetwork},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.code), func(t *testing.T) {
+			err := errors.New(tt.code, "failed")
+			assert.Equal(t, tt.want, ExitCode(err))
+			assert.Equal(t, tt.want, ExitCode(fmt.Errorf("wrapped: %w", err)), "the code of a wrapped error is used")
+		})
+	}
+}
+
+func TestExitCode_NonApplicationErrors(t *testing.T) {
+	assert.Equal(t, ExitSuccess, ExitCode(nil))
+	assert.Equal(t, ExitUnknown, ExitCode(fmt.Errorf("boom")))
+}
+
+func TestClassifyUsageError(t *testing.T) {
+	newRoot := func() *cobra.Command {
+		root := &cobra.Command{Use: "root", SilenceErrors: true, SilenceUsage: true}
+		root.SetFlagErrorFunc(FlagError)
+		sub := &cobra.Command{Use: "sub", Args: cobra.NoArgs, RunE: func(*cobra.Command, []string) error { return nil }}
+		sub.Flags().Int("count", 0, "")
+		sub.Flags().String("name", "", "")
+		require.NoError(t, sub.MarkFlagRequired("name"))
+		root.AddCommand(sub)
+		return root
+	}
+
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{name: "unknown flag", args: []string{"sub", "--name=x", "--bogus"}},
+		{name: "invalid flag value", args: []string{"sub", "--name=x", "--count=many"}},
+		{name: "unknown command", args: []string{"bogus"}},
+		{name: "unexpected argument", args: []string{"sub", "--name=x", "extra"}},
+		{name: "missing required flag", args: []string{"sub"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := newRoot()
+			root.SetArgs(tt.args)
+			err := ClassifyUsageError(root.Execute())
+			require.Error(t, err)
+			assert.Equal(t, ExitInvalidArgument, ExitCode(err), err.Error())
+		})
+	}
+
+	assert.Equal(t, ExitUnknown, ExitCode(ClassifyUsageError(fmt.Errorf("boom"))))
+	credErr := errors.New(errors.ErrCredentialInvalid, "accepts no keys")
+	assert.Same(t, credErr, ClassifyUsageError(credErr), "application errors are kept")
+}
+
+func TestWriteFailureSummary(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "failure.json")
+	err := fmt.Errorf("failed to get cluster info: %w",
+		errors.New(errors.ErrClusterNotFound, "cluster not found").WithField("token", "secret"))
+	flags := &Flags{ProviderName: "gcp", ClusterName: "my-cluster", RequestID: "0123abcd"}
+
+	require.NoError(t, WriteFailureSummary(path, err, flags))
+
+	data, readErr := os.ReadFile(path)
+	require.NoError(t, readErr)
+	var summary map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &summary))
+	assert.Equal(t, map[string]interface{}{
+		"code":      string(errors.ErrClusterNotFound),
+		"exitCode":  float64(ExitCluster),
+		"message":   err.Error(),
+		"provider":  "gcp",
+		"cluster":   "my-cluster",
+		"requestId": "0123abcd",
+	}, summary)
+	assert.NotContains(t, string(data), "secret")
+
+	if runtime.GOOS != "windows" {
+		info, statErr := os.Stat(path)
+		require.NoError(t, statErr)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	}
+}
+
+func TestWriteFailureSummary_NoFlags(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "failure.json")
+	require.NoError(t, WriteFailureSummary(path, fmt.Errorf("unknown flag: --bogus"), nil))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var summary map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &summary))
+	assert.Equal(t, string(errors.ErrUnknown), summary["code"])
+	assert.Equal(t, float64(ExitUnknown), summary["exitCode"])
+	assert.NotContains(t, summary, "provider")
+	assert.NotContains(t, summary, "cluster")
+}
+
+func TestReportFailure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "failure.json")
+	err := errors.New(errors.ErrCredentialExpired, "credentials expired")
+
+	t.Setenv(FailureSummaryFileEnv, "")
+	assert.Equal(t, ExitCredential, ReportFailure(err, &Flags{}))
+	assert.NoFileExists(t, path, "no summary without HFCP_FAILURE_SUMMARY_FILE")
+
+	t.Setenv(FailureSummaryFileEnv, path)
+	assert.Equal(t, ExitCredential, ReportFailure(err, &Flags{ProviderName: "aws"}))
+	assert.FileExists(t, path)
+
+	// An unwritable summary file does not change the exit code
+	t.Setenv(FailureSummaryFileEnv, filepath.Join(t.TempDir(), "missing", "failure.json"))
+	assert.Equal(t, ExitCredential, ReportFailure(err, &Flags{}))
+}
@@ -0,0 +1,168 @@
+package common
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+)
+
+// providerAnnotation marks a flag as applying only to the listed providers
+const providerAnnotation = "hyperfleet_providers"
+
+// exampleOrder is the order provider examples are listed in when no provider is selected
+var exampleOrder = []string{"gcp", "aws", "azure", "oci", "digitalocean", "oidc"}
+
+// providerTitles are the display names of the provider flag groups
+var providerTitles = map[string]string{
+	"gcp":          "GCP",
+	"aws":          "AWS",
+	"azure":        "Azure",
+	"oci":          "OCI",
+	"digitalocean": "DigitalOcean",
+	"oidc":         "OIDC",
+}
+
+// SetFlagProviders marks flags as specific to the given providers so that help can
+// group them. Flags without a provider annotation are shown for every provider.
+func SetFlagProviders(cmd *cobra.Command, providers []string, names ...string) {
+	for _, name := range names {
+		if err := cmd.Flags().SetAnnotation(name, providerAnnotation, providers); err != nil {
+			panic(fmt.Sprintf("annotating unknown flag %q: %v", name, err))
+		}
+	}
+}
+
+// SetProviderHelp installs a help function that shows only the flags and examples of
+// the selected provider. The provider is resolved from --provider, then HFCP_PROVIDER.
+// Without a provider every flag group and example is shown. examples maps provider
+// names to example text; cmd.Example holds examples shown for every provider.
+func SetProviderHelp(cmd *cobra.Command, examples map[string]string) {
+	cmd.SetHelpFunc(func(c *cobra.Command, args []string) {
+		writeProviderHelp(c.OutOrStdout(), c, helpProvider(c), examples)
+	})
+}
+
+// helpProvider returns the provider help should be narrowed to, or "" when it is
+// unknown. Viper is not initialized when cobra handles --help, so the environment
+// is read directly.
+func helpProvider(cmd *cobra.Command) string {
+	name := os.Getenv("HFCP_PROVIDER")
+	if flag := cmd.Flags().Lookup("provider"); flag != nil && flag.Changed {
+		name = flag.Value.String()
+	}
+	if provider.CheckRegistered(name) != nil {
+		return ""
+	}
+	return name
+}
+
+// flagProviders returns the providers a flag is annotated with (nil for common flags)
+func flagProviders(flag *pflag.Flag) []string {
+	return flag.Annotations[providerAnnotation]
+}
+
+func writeProviderHelp(w io.Writer, cmd *cobra.Command, selected string, examples map[string]string) {
+	description := cmd.Long
+	if description == "" {
+		description = cmd.Short
+	}
+	fmt.Fprintf(w, "%s\n\n", strings.TrimRight(description, "\n"))
+
+	fmt.Fprintf(w, "Usage:\n  %s\n", cmd.UseLine())
+
+	var shown []string
+	for _, name := range exampleOrder {
+		if (selected == "" || name == selected) && examples[name] != "" {
+			shown = append(shown, strings.TrimRight(examples[name], "\n"))
+		}
+	}
+	if cmd.Example != "" {
+		shown = append(shown, strings.TrimRight(cmd.Example, "\n"))
+	}
+	if len(shown) > 0 {
+		fmt.Fprintf(w, "\nExamples:\n%s\n", strings.Join(shown, "\n\n"))
+	}
+
+	common := pflag.NewFlagSet("common", pflag.ContinueOnError)
+	groups := map[string]*pflag.FlagSet{}
+	hidden := map[string]bool{}
+	cmd.LocalFlags().VisitAll(func(flag *pflag.Flag) {
+		providers := flagProviders(flag)
+		if len(providers) == 0 {
+			common.AddFlag(flag)
+			return
+		}
+		for _, name := range providers {
+			if selected != "" && name != selected {
+				continue
+			}
+			if groups[name] == nil {
+				groups[name] = pflag.NewFlagSet(name, pflag.ContinueOnError)
+			}
+			groups[name].AddFlag(flag)
+		}
+		if selected != "" && !contains(providers, selected) {
+			hidden[flag.Name] = true
+		}
+	})
+
+	if common.HasFlags() {
+		fmt.Fprintf(w, "\nFlags:\n%s", common.FlagUsages())
+	}
+
+	names := make([]string, 0, len(groups))
+	for name := range groups {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return exampleIndex(names[i]) < exampleIndex(names[j]) })
+	for _, name := range names {
+		fmt.Fprintf(w, "\n%s Flags:\n%s", providerTitle(name), groups[name].FlagUsages())
+	}
+
+	if len(hidden) > 0 {
+		flags := make([]string, 0, len(hidden))
+		for name := range hidden {
+			flags = append(flags, "--"+name)
+		}
+		sort.Strings(flags)
+		fmt.Fprintf(w, "\nOther providers: %s are hidden; use --provider=<name> --help to see another provider's flags\n",
+			strings.Join(flags, ", "))
+	}
+
+	if cmd.HasAvailableInheritedFlags() {
+		fmt.Fprintf(w, "\nGlobal Flags:\n%s", cmd.InheritedFlags().FlagUsages())
+	}
+}
+
+// exampleIndex orders providers as in exampleOrder, with unknown providers last
+func exampleIndex(name string) int {
+	for i, candidate := range exampleOrder {
+		if candidate == name {
+			return i
+		}
+	}
+	return len(exampleOrder)
+}
+
+func providerTitle(name string) string {
+	if title, ok := providerTitles[name]; ok {
+		return title
+	}
+	return strings.ToUpper(name)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,114 @@
+package common
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newHelpTestCommand builds a command with the provider flag layout used by the real commands
+func newHelpTestCommand() *cobra.Command {
+	var s string
+	cmd := &cobra.Command{
+		Use:   "get-token",
+		Short: "Generate a Kubernetes authentication token",
+		RunE:  func(cmd *cobra.Command, args []string) error { return nil },
+	}
+	for _, name := range []string{"provider", "cluster-name", "region", "project-id", "account-id", "subscription-id", "tenant-id"} {
+		cmd.Flags().StringVar(&s, name, "", name+" usage")
+	}
+	SetFlagProviders(cmd, []string{"gcp", "aws"}, "region")
+	SetFlagProviders(cmd, []string{"gcp"}, "project-id")
+	SetFlagProviders(cmd, []string{"aws"}, "account-id")
+	SetFlagProviders(cmd, []string{"azure"}, "subscription-id", "tenant-id")
+	SetProviderHelp(cmd, map[string]string{
+		"gcp":   "  # GCP example",
+		"aws":   "  # AWS example",
+		"azure": "  # Azure example",
+	})
+	return cmd
+}
+
+func runHelp(t *testing.T, args ...string) string {
+	t.Helper()
+	cmd := newHelpTestCommand()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs(append(args, "--help"))
+	require.NoError(t, cmd.Execute())
+	return out.String()
+}
+
+func TestProviderHelp(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		env        string
+		wantShown  []string
+		wantHidden []string
+	}{
+		{
+			name: "no provider shows every group",
+			wantShown: []string{
+				"Flags:", "--cluster-name", "GCP Flags:", "AWS Flags:", "Azure Flags:",
+				"--project-id", "--account-id", "--tenant-id", "# GCP example", "# AWS example", "# Azure example",
+			},
+			wantHidden: []string{"Other providers"},
+		},
+		{
+			name:       "gcp",
+			args:       []string{"--provider=gcp"},
+			wantShown:  []string{"--cluster-name", "GCP Flags:", "--project-id", "--region", "# GCP example", "Other providers: --account-id, --subscription-id, --tenant-id"},
+			wantHidden: []string{"AWS Flags:", "Azure Flags:", "# AWS example", "# Azure example"},
+		},
+		{
+			name:       "aws",
+			args:       []string{"--provider=aws"},
+			wantShown:  []string{"--cluster-name", "AWS Flags:", "--account-id", "--region", "# AWS example", "Other providers: --project-id, --subscription-id, --tenant-id"},
+			wantHidden: []string{"GCP Flags:", "Azure Flags:", "# GCP example", "# Azure example"},
+		},
+		{
+			name:       "azure",
+			args:       []string{"--provider=azure"},
+			wantShown:  []string{"--cluster-name", "Azure Flags:", "--subscription-id", "--tenant-id", "# Azure example", "Other providers: --account-id, --project-id, --region"},
+			wantHidden: []string{"GCP Flags:", "AWS Flags:", "# GCP example", "# AWS example"},
+		},
+		{
+			name:       "provider from environment",
+			env:        "azure",
+			wantShown:  []string{"Azure Flags:", "# Azure example"},
+			wantHidden: []string{"GCP Flags:", "AWS Flags:"},
+		},
+		{
+			name:       "flag overrides environment",
+			args:       []string{"--provider=aws"},
+			env:        "azure",
+			wantShown:  []string{"AWS Flags:"},
+			wantHidden: []string{"Azure Flags:"},
+		},
+		{
+			name:       "unknown provider shows every group",
+			args:       []string{"--provider=openstack"},
+			wantShown:  []string{"GCP Flags:", "AWS Flags:", "Azure Flags:"},
+			wantHidden: []string{"Other providers"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("HFCP_PROVIDER", tt.env)
+
+			out := runHelp(t, tt.args...)
+
+			for _, want := range tt.wantShown {
+				assert.Contains(t, out, want)
+			}
+			for _, unwanted := range tt.wantHidden {
+				assert.NotContains(t, out, unwanted)
+			}
+		})
+	}
+}
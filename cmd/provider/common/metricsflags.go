@@ -0,0 +1,47 @@
+package common
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/metrics"
+)
+
+// metricNamePattern matches the Prometheus names a namespace or subsystem may use
+var metricNamePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// AddMetricsFlags adds --metrics-enabled, --metrics-namespace, --metrics-subsystem and
+// --metrics-cluster-label to a command that serves /metrics
+func AddMetricsFlags(cmd *cobra.Command) {
+	defaults := metrics.DefaultConfig()
+	cmd.Flags().Bool("metrics-enabled", true, "Collect Prometheus metrics and serve /metrics")
+	cmd.Flags().String("metrics-namespace", defaults.Namespace, "Namespace prefixing every metric name")
+	cmd.Flags().String("metrics-subsystem", defaults.Subsystem, "Subsystem between the namespace and each metric name (default: none)")
+	cmd.Flags().Bool("metrics-cluster-label", true, "Label the token expiry and last success gauges with the cluster; disable for large fleets")
+}
+
+// MetricsConfig returns the metrics configuration of the flags added by AddMetricsFlags,
+// without a registry, and whether metrics are enabled. A namespace or subsystem that is
+// not a valid Prometheus name fails with ErrInvalidArgument.
+func MetricsConfig(v *viper.Viper) (metrics.Config, bool, error) {
+	config := metrics.Config{
+		Namespace: v.GetString("metrics-namespace"),
+		Subsystem: v.GetString("metrics-subsystem"),
+
+		DisableClusterLabel: !v.GetBool("metrics-cluster-label"),
+	}
+	for _, flag := range []string{"metrics-namespace", "metrics-subsystem"} {
+		if value := v.GetString(flag); value != "" && !metricNamePattern.MatchString(value) {
+			return metrics.Config{}, false, errors.New(
+				errors.ErrInvalidArgument,
+				fmt.Sprintf("--%s %q is not a valid Prometheus name", flag, value),
+			).WithField("flag", flag).
+				WithDetail("use letters, digits and underscores, not starting with a digit")
+		}
+	}
+	return config, v.GetBool("metrics-enabled"), nil
+}
@@ -0,0 +1,96 @@
+package common
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/metrics"
+)
+
+func TestMetricsConfig(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		env         map[string]string
+		wantPrefix  string
+		wantEnabled bool
+		wantCluster bool
+		wantErr     string
+	}{
+		{
+			name:        "defaults",
+			wantPrefix:  "hyperfleet_cloud_provider_",
+			wantEnabled: true,
+			wantCluster: true,
+		},
+		{
+			name:        "namespace and subsystem",
+			args:        []string{"--metrics-namespace=platform", "--metrics-subsystem=credentials"},
+			wantPrefix:  "platform_credentials_",
+			wantEnabled: true,
+			wantCluster: true,
+		},
+		{
+			name:        "environment",
+			env:         map[string]string{"HFCP_METRICS_NAMESPACE": "sidecar", "HFCP_METRICS_ENABLED": "false"},
+			wantPrefix:  "sidecar_",
+			wantEnabled: false,
+			wantCluster: true,
+		},
+		{
+			name:        "no cluster label",
+			env:         map[string]string{"HFCP_METRICS_CLUSTER_LABEL": "false"},
+			wantPrefix:  "hyperfleet_cloud_provider_",
+			wantEnabled: true,
+		},
+		{
+			name:    "invalid namespace",
+			args:    []string{"--metrics-namespace=hyperfleet-credential-provider"},
+			wantErr: `--metrics-namespace "hyperfleet-credential-provider" is not a valid Prometheus name`,
+		},
+		{
+			name:    "invalid subsystem",
+			args:    []string{"--metrics-subsystem=1st"},
+			wantErr: `--metrics-subsystem "1st" is not a valid Prometheus name`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+			cmd := &cobra.Command{Use: "serve"}
+			AddMetricsFlags(cmd)
+			require.NoError(t, cmd.ParseFlags(tt.args))
+
+			config, enabled, err := MetricsConfig(NewViper(cmd))
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.True(t, errors.Is(err, errors.ErrInvalidArgument))
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantEnabled, enabled)
+			assert.Equal(t, tt.wantCluster, !config.DisableClusterLabel)
+
+			registry := prometheus.NewRegistry()
+			config.Registry = registry
+			metrics.NewMetrics(config).RecordTokenRequest("aws", "success")
+
+			families, err := registry.Gather()
+			require.NoError(t, err)
+			require.NotEmpty(t, families)
+			for _, family := range families {
+				assert.True(t, strings.HasPrefix(family.GetName(), tt.wantPrefix), family.GetName())
+			}
+		})
+	}
+}
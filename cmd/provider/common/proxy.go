@@ -0,0 +1,20 @@
+package common
+
+import (
+	"net/http"
+
+	"github.com/spf13/viper"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/proxy"
+)
+
+// NewHTTPClient creates the HTTP client of the cloud SDKs from --https-proxy and
+// --no-proxy, which fall back to HTTPS_PROXY and NO_PROXY, and --ca-bundle. It returns
+// nil when none of the flags is set, which keeps the SDK default transports.
+func NewHTTPClient(v *viper.Viper) (*http.Client, error) {
+	return proxy.NewClient(proxy.Config{
+		HTTPSProxy: v.GetString("https-proxy"),
+		NoProxy:    v.GetString("no-proxy"),
+		CABundle:   v.GetString("ca-bundle"),
+	})
+}
@@ -0,0 +1,47 @@
+package common
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewHTTPClient(t *testing.T) {
+	tests := []struct {
+		name       string
+		env        map[string]string
+		wantClient bool
+		wantErr    bool
+	}{
+		{name: "not configured", env: map[string]string{"HTTPS_PROXY": "http://env-proxy:3128"}},
+		{name: "https-proxy", env: map[string]string{"HFCP_HTTPS_PROXY": "http://proxy.example.com:3128"}, wantClient: true},
+		{name: "no-proxy", env: map[string]string{"HFCP_NO_PROXY": ".internal.example.com"}, wantClient: true},
+		{name: "invalid proxy", env: map[string]string{"HFCP_HTTPS_PROXY": "ftp://proxy.example.com"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{"HFCP_HTTPS_PROXY", "HFCP_NO_PROXY", "HTTPS_PROXY", "https_proxy", "NO_PROXY", "no_proxy"} {
+				t.Setenv(name, "")
+			}
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+
+			client, err := NewHTTPClient(NewViper(nil))
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			if !tt.wantClient {
+				assert.Nil(t, client)
+				return
+			}
+			require.NotNil(t, client)
+			assert.IsType(t, &http.Transport{}, client.Transport)
+		})
+	}
+}
@@ -0,0 +1,16 @@
+package common
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// NewRequestID returns a random ID for one invocation of the binary. It is logged on
+// every line and added to the reported error, so that a support ticket quoting either
+// can be matched to the rest of the run.
+func NewRequestID() string {
+	b := make([]byte, 8)
+	// crypto/rand.Read does not fail on supported platforms
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
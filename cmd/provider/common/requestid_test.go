@@ -0,0 +1,58 @@
+package common
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+)
+
+func TestNewRequestID(t *testing.T) {
+	id := NewRequestID()
+	assert.Len(t, id, 16)
+	_, err := hex.DecodeString(id)
+	assert.NoError(t, err)
+	assert.NotEqual(t, id, NewRequestID())
+}
+
+func TestCreateLogger_RequestID(t *testing.T) {
+	var buf bytes.Buffer
+	log, err := CreateLogger(&Flags{LogFormat: "json", RequestID: "0123abcd", LogOutput: &buf})
+	require.NoError(t, err)
+
+	log.Info("Generating token")
+	log.With(logger.String("provider", "gcp")).Warn("Token expires soon")
+	require.NoError(t, log.Sync())
+
+	lines := 0
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var entry map[string]interface{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &entry))
+		assert.Equal(t, "0123abcd", entry[errors.RequestIDField], "line %s", scanner.Text())
+		assert.Equal(t, 1, bytes.Count(scanner.Bytes(), []byte(`"request_id"`)))
+		lines++
+	}
+	assert.Equal(t, 2, lines)
+}
+
+func TestWriteError_RequestID(t *testing.T) {
+	err := errors.WithRequestID(fmt.Errorf("unknown flag: --bogus"), "0123abcd")
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteError(&buf, err, "json"))
+
+	var out errorOutput
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &out))
+	assert.Equal(t, "unknown flag: --bogus", out.Message)
+	assert.Equal(t, errors.ErrUnknown, out.Code)
+	assert.Equal(t, "0123abcd", out.Fields[errors.RequestIDField])
+}
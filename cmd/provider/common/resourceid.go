@@ -0,0 +1,60 @@
+package common
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider/azure"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+)
+
+// AddClusterResourceIDFlag adds the --cluster-resource-id flag read by
+// ApplyClusterResourceID to a command that looks up clusters
+func AddClusterResourceIDFlag(cmd *cobra.Command, flags *Flags) {
+	cmd.Flags().StringVar(&flags.ClusterResourceID, "cluster-resource-id", "", "AKS cluster ARM resource ID, instead of --cluster-name, --resource-group and --subscription-id")
+	SetFlagProviders(cmd, []string{"azure"}, "cluster-resource-id")
+}
+
+// ApplyClusterResourceID fills the cluster name, resource group and subscription of an
+// AKS cluster from --cluster-resource-id. Those flags may also be set, e.g. the
+// subscription from the environment, but must then name the same cluster.
+func ApplyClusterResourceID(flags *Flags) error {
+	if flags.ClusterResourceID == "" {
+		return nil
+	}
+	if flags.ProviderName != "azure" {
+		return errors.New(errors.ErrInvalidArgument, "--cluster-resource-id is only supported for Azure").
+			WithField("provider", flags.ProviderName)
+	}
+
+	id, err := azure.ParseClusterResourceID(flags.ClusterResourceID)
+	if err != nil {
+		return err
+	}
+
+	for _, field := range []struct {
+		flag   string
+		value  *string
+		parsed string
+	}{
+		{"cluster-name", &flags.ClusterName, id.ClusterName},
+		{"resource-group", &flags.ResourceGroup, id.ResourceGroup},
+		{"subscription-id", &flags.SubscriptionID, id.SubscriptionID},
+	} {
+		// ARM names and subscription IDs are case-insensitive
+		if *field.value != "" && !strings.EqualFold(*field.value, field.parsed) {
+			return errors.New(
+				errors.ErrInvalidArgument,
+				fmt.Sprintf("--%s %q does not match --cluster-resource-id", field.flag, *field.value),
+			).WithFields(map[string]interface{}{
+				"provider":    "azure",
+				"resource_id": flags.ClusterResourceID,
+				field.flag:    *field.value,
+			}).WithDetail(fmt.Sprintf("the resource ID has %s %q", field.flag, field.parsed))
+		}
+		*field.value = field.parsed
+	}
+	return nil
+}
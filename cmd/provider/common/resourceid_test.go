@@ -0,0 +1,87 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+)
+
+func TestApplyClusterResourceID(t *testing.T) {
+	const (
+		subscription = "00000000-0000-0000-0000-000000000000"
+		resourceID   = "/subscriptions/" + subscription + "/resourceGroups/my-rg/providers/Microsoft.ContainerService/managedClusters/my-cluster"
+	)
+
+	tests := []struct {
+		name    string
+		flags   Flags
+		want    Flags
+		wantErr string
+	}{
+		{
+			name:  "not set",
+			flags: Flags{ProviderName: "azure", ClusterName: "other", ResourceGroup: "other-rg"},
+			want:  Flags{ProviderName: "azure", ClusterName: "other", ResourceGroup: "other-rg"},
+		},
+		{
+			name:  "fills the cluster flags",
+			flags: Flags{ProviderName: "azure", ClusterResourceID: resourceID},
+			want: Flags{
+				ProviderName:      "azure",
+				ClusterResourceID: resourceID,
+				ClusterName:       "my-cluster",
+				ResourceGroup:     "my-rg",
+				SubscriptionID:    subscription,
+			},
+		},
+		{
+			name:  "matching subscription in another case",
+			flags: Flags{ProviderName: "azure", ClusterResourceID: resourceID, SubscriptionID: "00000000-0000-0000-0000-000000000000", ResourceGroup: "MY-RG"},
+			want: Flags{
+				ProviderName:      "azure",
+				ClusterResourceID: resourceID,
+				ClusterName:       "my-cluster",
+				ResourceGroup:     "my-rg",
+				SubscriptionID:    subscription,
+			},
+		},
+		{
+			name:    "other subscription",
+			flags:   Flags{ProviderName: "azure", ClusterResourceID: resourceID, SubscriptionID: "11111111-1111-1111-1111-111111111111"},
+			wantErr: `--subscription-id "11111111-1111-1111-1111-111111111111" does not match --cluster-resource-id`,
+		},
+		{
+			name:    "other cluster name",
+			flags:   Flags{ProviderName: "azure", ClusterResourceID: resourceID, ClusterName: "other"},
+			wantErr: `--cluster-name "other" does not match --cluster-resource-id`,
+		},
+		{
+			name:    "malformed resource ID",
+			flags:   Flags{ProviderName: "azure", ClusterResourceID: "/subscriptions/" + subscription + "/resourceGroups/my-rg"},
+			wantErr: "invalid AKS cluster resource ID",
+		},
+		{
+			name:    "other provider",
+			flags:   Flags{ProviderName: "gcp", ClusterResourceID: resourceID},
+			wantErr: "--cluster-resource-id is only supported for Azure",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flags := tt.flags
+			err := ApplyClusterResourceID(&flags)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.True(t, errors.Is(err, errors.ErrInvalidArgument))
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, flags)
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package common
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/throttle"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+)
+
+// AddThrottleFlags adds the --token-rate-limit, --token-burst, --breaker-failures and
+// --breaker-timeout flags read by NewThrottleConfig to a long-running command
+func AddThrottleFlags(cmd *cobra.Command) {
+	cmd.Flags().Float64("token-rate-limit", 0, "Most token requests per second sent to the cloud for the cluster (0 for no limit)")
+	cmd.Flags().Int("token-burst", throttle.DefaultBurst, "How many token requests may be sent at once above --token-rate-limit")
+	cmd.Flags().Int("breaker-failures", 0, "Stop requesting tokens after this many failures in a row, until --breaker-timeout has passed (0 disables the circuit breaker)")
+	cmd.Flags().Duration("breaker-timeout", throttle.DefaultOpenTimeout, "How long token requests are rejected once the circuit breaker opens, before one is tried again")
+}
+
+// NewThrottleConfig returns the rate limit and circuit breaker configured by the
+// flags added with AddThrottleFlags. The config limits nothing unless a rate limit or
+// breaker threshold is set.
+func NewThrottleConfig(flags *Flags) (throttle.Config, error) {
+	config := throttle.Config{
+		RequestsPerSecond: flags.Viper.GetFloat64("token-rate-limit"),
+		Burst:             flags.Viper.GetInt("token-burst"),
+		FailureThreshold:  flags.Viper.GetInt("breaker-failures"),
+		OpenTimeout:       flags.Viper.GetDuration("breaker-timeout"),
+	}
+	if config.RequestsPerSecond < 0 {
+		return throttle.Config{}, errors.New(errors.ErrInvalidArgument, "--token-rate-limit must not be negative")
+	}
+	if config.Burst < 1 {
+		return throttle.Config{}, errors.New(errors.ErrInvalidArgument, fmt.Sprintf("--token-burst must be at least 1, got %d", config.Burst))
+	}
+	if config.FailureThreshold < 0 {
+		return throttle.Config{}, errors.New(errors.ErrInvalidArgument, "--breaker-failures must not be negative")
+	}
+	if config.OpenTimeout <= 0 {
+		return throttle.Config{}, errors.New(errors.ErrInvalidArgument, "--breaker-timeout must be positive")
+	}
+	return config, nil
+}
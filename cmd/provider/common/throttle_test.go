@@ -0,0 +1,74 @@
+package common
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/throttle"
+)
+
+func TestNewThrottleConfig(t *testing.T) {
+	tests := []struct {
+		name        string
+		values      map[string]interface{}
+		want        throttle.Config
+		wantEnabled bool
+		wantErr     string
+	}{
+		{
+			name: "defaults limit nothing",
+			want: throttle.Config{Burst: throttle.DefaultBurst, OpenTimeout: throttle.DefaultOpenTimeout},
+		},
+		{
+			name:        "rate limit and breaker",
+			values:      map[string]interface{}{"token-rate-limit": "0.5", "token-burst": "2", "breaker-failures": "3", "breaker-timeout": "1m"},
+			want:        throttle.Config{RequestsPerSecond: 0.5, Burst: 2, FailureThreshold: 3, OpenTimeout: time.Minute},
+			wantEnabled: true,
+		},
+		{
+			name:    "negative rate limit",
+			values:  map[string]interface{}{"token-rate-limit": "-1"},
+			wantErr: "--token-rate-limit must not be negative",
+		},
+		{
+			name:    "zero burst",
+			values:  map[string]interface{}{"token-burst": "0"},
+			wantErr: "--token-burst must be at least 1",
+		},
+		{
+			name:    "negative breaker failures",
+			values:  map[string]interface{}{"breaker-failures": "-1"},
+			wantErr: "--breaker-failures must not be negative",
+		},
+		{
+			name:    "zero breaker timeout",
+			values:  map[string]interface{}{"breaker-timeout": "0s"},
+			wantErr: "--breaker-timeout must be positive",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := &cobra.Command{}
+			AddThrottleFlags(cmd)
+			for key, value := range tt.values {
+				require.NoError(t, cmd.Flags().Set(key, value.(string)))
+			}
+
+			config, err := NewThrottleConfig(&Flags{Viper: NewViper(cmd)})
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				assert.Equal(t, ExitInvalidArgument, ExitCode(err))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, config)
+			assert.Equal(t, tt.wantEnabled, config.Enabled())
+		})
+	}
+}
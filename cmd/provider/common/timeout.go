@@ -0,0 +1,49 @@
+package common
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"time"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+)
+
+// DefaultTimeout bounds each cloud API operation unless --timeout or HFCP_TIMEOUT
+// is set. It matches the ProviderConfig.Timeout default of internal/config.
+const DefaultTimeout = 30 * time.Second
+
+// WithTimeout bounds ctx by flags.Timeout so that a hung cloud endpoint fails the
+// command instead of stalling kubectl. A zero timeout leaves ctx unbounded.
+func WithTimeout(ctx context.Context, flags *Flags) (context.Context, context.CancelFunc) {
+	if flags.Timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, flags.Timeout)
+}
+
+// TimeoutError returns an ErrNetworkTimeout error carrying the elapsed time when err
+// was caused by the deadline of ctx passing, so that callers can classify it
+// without parsing provider-specific messages. Other errors are returned unchanged.
+func TimeoutError(ctx context.Context, err error, operation string, start time.Time) error {
+	if err == nil {
+		return nil
+	}
+	if ctx.Err() != context.DeadlineExceeded && !stderrors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+
+	elapsed := time.Since(start).Round(time.Millisecond)
+	timeoutErr := errors.Wrap(
+		errors.ErrNetworkTimeout,
+		err,
+		fmt.Sprintf("%s timed out after %s", operation, elapsed),
+	).WithFields(map[string]interface{}{
+		"operation":  operation,
+		"elapsed_ms": elapsed.Milliseconds(),
+	})
+	if deadline, ok := ctx.Deadline(); ok {
+		timeoutErr.WithField("timeout_ms", deadline.Sub(start).Round(time.Millisecond).Milliseconds())
+	}
+	return timeoutErr
+}
@@ -0,0 +1,77 @@
+package common
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+)
+
+// tokenCacheNamespace is the cache directory subdirectory holding tokens
+const tokenCacheNamespace = "tokens"
+
+// AddTokenCacheFlags adds the --token-cache and --cache-dir flags read by
+// TokenCacheDir
+func AddTokenCacheFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("token-cache", false, "Reuse tokens from the cache directory while they are valid and store new ones there (mode 0600)")
+	if cmd.Flags().Lookup("cache-dir") == nil {
+		cmd.Flags().String("cache-dir", "", "Cache directory (default: the user cache directory, e.g. ~/.cache/hyperfleet-credential-provider)")
+	}
+}
+
+// TokenCacheDir returns the --cache-dir of --token-cache, or "" when --token-cache is
+// not set
+func TokenCacheDir(flags *Flags) (string, error) {
+	if !flags.Viper.GetBool("token-cache") {
+		return "", nil
+	}
+	return cacheDirFromFlags(flags, "--token-cache")
+}
+
+// cacheDirFromFlags returns --cache-dir, or the user cache directory when it is not set
+func cacheDirFromFlags(flags *Flags, feature string) (string, error) {
+	if dir := flags.Viper.GetString("cache-dir"); dir != "" {
+		return dir, nil
+	}
+	dir, err := DefaultCacheDir()
+	if err != nil {
+		return "", errors.Wrap(
+			errors.ErrInvalidArgument,
+			err,
+			feature+" needs --cache-dir when there is no user cache directory",
+		)
+	}
+	return dir, nil
+}
+
+// NewTokenStore returns the token store under the tokens subdirectory of cacheDir
+func NewTokenStore(cacheDir string) (provider.TokenStore, error) {
+	return provider.NewDiskTokenStore(filepath.Join(cacheDir, tokenCacheNamespace))
+}
+
+// TokenCacheScope identifies the credentials and settings that tokens are minted with,
+// beyond the token options, so that cached tokens are not reused across them
+func TokenCacheScope(flags *Flags) string {
+	return strings.Join([]string{
+		flags.CredentialsFile,
+		flags.CredentialsDir,
+		flags.GCPUseADC,
+		flags.AWSProfile,
+		flags.STSEndpoint,
+		flags.AzureCloud,
+		fmt.Sprint(flags.PreferSecret),
+		flags.TenancyID,
+		flags.UserID,
+		flags.OIDCIssuerURL,
+		flags.OIDCClientID,
+		flags.OIDCPrivateKeyFile,
+		flags.OIDCScopes,
+		flags.OIDCSubjectTokenFile,
+		fmt.Sprint(flags.OIDCUseIDToken),
+		flags.TokenDuration,
+	}, "\x00")
+}
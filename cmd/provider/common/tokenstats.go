@@ -0,0 +1,133 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/filelock"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+)
+
+// tokenStatsFile is the cache directory file holding the token request stats of
+// --token-cache
+const tokenStatsFile = "token-stats.json"
+
+// tokenStatsLockTimeout bounds how long a token request waits to record its stats
+const tokenStatsLockTimeout = time.Second
+
+// TokenStats are the token requests served through --token-cache, persisted across
+// runs, by provider and cache outcome
+type TokenStats struct {
+	Providers map[string]map[string]TokenOutcomeStats `json:"providers"`
+}
+
+// TokenOutcomeStats are the requests of one provider and cache outcome
+type TokenOutcomeStats struct {
+	Requests     int64   `json:"requests"`
+	TotalSeconds float64 `json:"totalSeconds"`
+	MaxSeconds   float64 `json:"maxSeconds"`
+}
+
+// Mean returns the mean request duration
+func (s TokenOutcomeStats) Mean() time.Duration {
+	if s.Requests == 0 {
+		return 0
+	}
+	return time.Duration(s.TotalSeconds / float64(s.Requests) * float64(time.Second))
+}
+
+// add records a request that took duration
+func (s *TokenStats) add(provider, outcome string, duration time.Duration) {
+	if s.Providers == nil {
+		s.Providers = make(map[string]map[string]TokenOutcomeStats)
+	}
+	if s.Providers[provider] == nil {
+		s.Providers[provider] = make(map[string]TokenOutcomeStats)
+	}
+
+	stats := s.Providers[provider][outcome]
+	stats.Requests++
+	stats.TotalSeconds += duration.Seconds()
+	if duration.Seconds() > stats.MaxSeconds {
+		stats.MaxSeconds = duration.Seconds()
+	}
+	s.Providers[provider][outcome] = stats
+}
+
+// TokenStatsRecorder adds token requests to the stats file of a cache directory. It
+// implements provider.RequestObserver.
+type TokenStatsRecorder struct {
+	path   string
+	logger logger.Logger
+}
+
+// NewTokenStatsRecorder creates a recorder of the token request stats of cacheDir
+func NewTokenStatsRecorder(cacheDir string, log logger.Logger) *TokenStatsRecorder {
+	return &TokenStatsRecorder{
+		path:   filepath.Join(cacheDir, tokenStatsFile),
+		logger: log,
+	}
+}
+
+// RecordTokenRequestDuration implements provider.RequestObserver. Failures are logged,
+// since the token request itself succeeded.
+func (r *TokenStatsRecorder) RecordTokenRequestDuration(provider, cacheOutcome string, duration time.Duration) {
+	err := filelock.Update(context.Background(), r.path, tokenStatsLockTimeout, 0600, func(current []byte) ([]byte, error) {
+		stats, err := parseTokenStats(current)
+		if err != nil {
+			// Unreadable stats are started over rather than blocking every request
+			stats = &TokenStats{}
+		}
+		stats.add(provider, cacheOutcome, duration)
+		return json.MarshalIndent(stats, "", "  ")
+	})
+	if err != nil {
+		r.logger.Warn("Failed to record token cache stats",
+			logger.String("file", r.path),
+			logger.Error(err),
+		)
+	}
+}
+
+// ReadTokenStats returns the token request stats of cacheDir; a cache directory
+// without stats has none
+func ReadTokenStats(cacheDir string) (*TokenStats, error) {
+	path := filepath.Join(cacheDir, tokenStatsFile)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &TokenStats{}, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(
+			errors.ErrInternal,
+			err,
+			"failed to read token cache stats",
+		).WithField("file", path)
+	}
+
+	stats, err := parseTokenStats(data)
+	if err != nil {
+		return nil, errors.Wrap(
+			errors.ErrInternal,
+			err,
+			"failed to parse token cache stats",
+		).WithField("file", path)
+	}
+	return stats, nil
+}
+
+// parseTokenStats parses a stats file; empty data has no stats
+func parseTokenStats(data []byte) (*TokenStats, error) {
+	stats := &TokenStats{}
+	if len(data) == 0 {
+		return stats, nil
+	}
+	if err := json.Unmarshal(data, stats); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
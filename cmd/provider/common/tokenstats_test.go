@@ -0,0 +1,62 @@
+package common
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+)
+
+func TestTokenStatsRecorder(t *testing.T) {
+	dir := t.TempDir()
+	recorder := NewTokenStatsRecorder(dir, logger.Nop())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			recorder.RecordTokenRequestDuration("aws", "hit", 2*time.Millisecond)
+		}()
+	}
+	wg.Wait()
+	recorder.RecordTokenRequestDuration("aws", "miss", 400*time.Millisecond)
+	recorder.RecordTokenRequestDuration("aws", "miss", 200*time.Millisecond)
+
+	stats, err := ReadTokenStats(dir)
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), stats.Providers["aws"]["hit"].Requests)
+	miss := stats.Providers["aws"]["miss"]
+	assert.Equal(t, int64(2), miss.Requests)
+	assert.Equal(t, 300*time.Millisecond, miss.Mean().Round(time.Millisecond))
+	assert.InDelta(t, 0.4, miss.MaxSeconds, 1e-9)
+
+	info, err := os.Stat(filepath.Join(dir, tokenStatsFile))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
+
+func TestTokenStatsRecorder_StartsOverCorruptStats(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, tokenStatsFile), []byte("{not json"), 0600))
+
+	_, err := ReadTokenStats(dir)
+	require.Error(t, err)
+
+	NewTokenStatsRecorder(dir, logger.Nop()).RecordTokenRequestDuration("gcp", "bypass", time.Millisecond)
+	stats, err := ReadTokenStats(dir)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), stats.Providers["gcp"]["bypass"].Requests)
+}
+
+func TestReadTokenStats_Missing(t *testing.T) {
+	stats, err := ReadTokenStats(t.TempDir())
+	require.NoError(t, err)
+	assert.Empty(t, stats.Providers)
+}
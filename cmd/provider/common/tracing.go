@@ -0,0 +1,29 @@
+package common
+
+import (
+	"context"
+
+	"github.com/spf13/viper"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/tracing"
+)
+
+// TracingServiceName is the service name reported on exported spans
+const TracingServiceName = "hyperfleet-credential-provider"
+
+// NewTracing creates the tracing provider from --tracing-enabled and --tracing-endpoint.
+// It returns nil when tracing is disabled, which providers treat as a no-op.
+func NewTracing(ctx context.Context, v *viper.Viper, serviceVersion string) (*tracing.Provider, error) {
+	if !v.GetBool("tracing-enabled") {
+		return nil, nil
+	}
+
+	config := tracing.DefaultConfig()
+	config.Enabled = true
+	config.ServiceName = TracingServiceName
+	config.ServiceVersion = serviceVersion
+	if endpoint := v.GetString("tracing-endpoint"); endpoint != "" {
+		config.Endpoint = endpoint
+	}
+	return tracing.NewProvider(ctx, config)
+}
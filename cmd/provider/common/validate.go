@@ -0,0 +1,128 @@
+package common
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/credentials"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+)
+
+// EnvVar returns the environment variable that sets a flag, e.g. HFCP_PROJECT_ID for
+// --project-id
+func EnvVar(flag string) string {
+	return "HFCP_" + strings.ToUpper(strings.ReplaceAll(flag, "-", "_"))
+}
+
+// checksumProviders are the providers whose credentials files --credentials-sha256 checks
+var checksumProviders = map[string]bool{"gcp": true, "aws": true, "azure": true}
+
+// inputValues returns the provider-specific flag values by flag name
+func inputValues(flags *Flags) map[string]string {
+	return map[string]string{
+		"region":           flags.Region,
+		"project-id":       flags.ProjectID,
+		"gcp-use-adc":      flags.GCPUseADC,
+		"account-id":       flags.AccountID,
+		"profile":          flags.AWSProfile,
+		"subscription-id":  flags.SubscriptionID,
+		"tenant-id":        flags.TenantID,
+		"resource-group":   flags.ResourceGroup,
+		"azure-cloud":      flags.AzureCloud,
+		"tenancy-id":       flags.TenancyID,
+		"user-id":          flags.UserID,
+		"compartment-id":   flags.CompartmentID,
+		"issuer-url":       flags.OIDCIssuerURL,
+		"client-id":        flags.OIDCClientID,
+		"credentials-file": flags.CredentialsFile,
+		"credentials-dir":  flags.CredentialsDir,
+	}
+}
+
+// ValidateInputs checks --provider, --cluster-name and the inputs the selected provider
+// registered for op (zero checks only the common flags). Every missing or invalid flag
+// is reported in one ErrInvalidArgument error, with the environment variable that can
+// set it, so users can fix them all before rerunning.
+func ValidateInputs(flags *Flags, op provider.Operation) error {
+	return validateInputs(flags, op, true)
+}
+
+// ValidateProviderInputs is ValidateInputs for commands that do not target a cluster,
+// such as whoami: --cluster-name is not required
+func ValidateProviderInputs(flags *Flags, op provider.Operation) error {
+	return validateInputs(flags, op, false)
+}
+
+// validateInputs implements ValidateInputs, requiring --cluster-name when needCluster is set
+func validateInputs(flags *Flags, op provider.Operation, needCluster bool) error {
+	var problems, envVars []string
+	missing := func(flag, qualifier string) {
+		problems = append(problems, fmt.Sprintf("--%s is required%s (or set %s)", flag, qualifier, EnvVar(flag)))
+		envVars = append(envVars, EnvVar(flag))
+	}
+
+	if flags.ProviderName == "" {
+		missing("provider", "")
+	}
+	if needCluster && flags.ClusterName == "" {
+		missing("cluster-name", "")
+	}
+	if flags.CredentialsProfile != "" && (flags.Viper == nil || flags.Viper.GetString("config-file") == "") {
+		problems = append(problems, "--credentials-profile needs --config-file (or set HFCP_CONFIG_FILE)")
+		envVars = append(envVars, EnvVar("config-file"))
+	}
+	if flags.CredentialsSHA256 != "" {
+		if err := credentials.ValidateSHA256(flags.CredentialsSHA256); err != nil {
+			problems = append(problems, fmt.Sprintf("--credentials-sha256 is invalid: %v", err))
+		} else if !checksumProviders[flags.ProviderName] && flags.ProviderName != "" {
+			problems = append(problems, fmt.Sprintf("--credentials-sha256 is not supported for %s (only gcp, aws and azure)", flags.ProviderName))
+		}
+	}
+
+	if flags.ProviderName != "" {
+		title := providerTitle(flags.ProviderName)
+		values := inputValues(flags)
+		reported := make(map[string]bool)
+		for _, input := range provider.InputsOf(flags.ProviderName) {
+			value := values[input.Name]
+			switch {
+			case value == "":
+				if input.RequiredFor&op == 0 || reported[input.Name] {
+					continue
+				}
+				if input.Satisfied != nil && input.Satisfied(values) {
+					continue
+				}
+				qualifier := " for " + title
+				if input.Note != "" {
+					qualifier += " (" + input.Note + ")"
+				}
+				missing(input.Name, qualifier)
+				reported[input.Name] = true
+			case input.Validate != nil && !reported[input.Name]:
+				if err := input.Validate(value); err != nil {
+					problems = append(problems, fmt.Sprintf("--%s is invalid: %v", input.Name, err))
+					reported[input.Name] = true
+				}
+			}
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+
+	title := problems[0]
+	if len(problems) > 1 {
+		title = fmt.Sprintf("%d missing or invalid flags:\n  %s", len(problems), strings.Join(problems, "\n  "))
+	}
+	err := errors.New(errors.ErrInvalidArgument, title).WithField("problems", problems)
+	if flags.ProviderName != "" {
+		err = err.WithField("provider", flags.ProviderName)
+	}
+	if len(envVars) > 0 {
+		err = err.WithField("env_vars", envVars)
+	}
+	return err
+}
@@ -0,0 +1,173 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+	_ "github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider/aws"
+	_ "github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider/azure"
+	_ "github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider/digitalocean"
+	_ "github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider/gcp"
+	_ "github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider/oci"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+)
+
+func TestEnvVar(t *testing.T) {
+	assert.Equal(t, "HFCP_PROJECT_ID", EnvVar("project-id"))
+	assert.Equal(t, "HFCP_REGION", EnvVar("region"))
+}
+
+func TestValidateInputs(t *testing.T) {
+	tests := []struct {
+		name     string
+		flags    Flags
+		op       provider.Operation
+		expected []string
+	}{
+		{
+			name:     "provider and cluster name missing",
+			expected: []string{"--provider is required (or set HFCP_PROVIDER)", "--cluster-name is required (or set HFCP_CLUSTER_NAME)"},
+		},
+		{
+			name:  "azure reports every missing input",
+			flags: Flags{ProviderName: "azure", ClusterName: "c"},
+			op:    provider.OperationKubeconfig,
+			expected: []string{
+				"--subscription-id is required for Azure (or set HFCP_SUBSCRIPTION_ID)",
+				"--tenant-id is required for Azure (or set HFCP_TENANT_ID)",
+				"--resource-group is required for Azure (or set HFCP_RESOURCE_GROUP)",
+			},
+		},
+		{
+			name:  "azure missing and invalid inputs together",
+			flags: Flags{ProviderName: "azure", ClusterName: "c", SubscriptionID: "s", AzureCloud: "germany"},
+			op:    provider.OperationClusterLookup,
+			expected: []string{
+				"--tenant-id is required for Azure (or set HFCP_TENANT_ID)",
+				"--resource-group is required for Azure (or set HFCP_RESOURCE_GROUP)",
+				"--azure-cloud is invalid",
+			},
+		},
+		{
+			name:  "gcp kubeconfig needs project and region",
+			flags: Flags{ProviderName: "gcp", ClusterName: "c"},
+			op:    provider.OperationKubeconfig,
+			expected: []string{
+				"--project-id is required for GCP (or set HFCP_PROJECT_ID)",
+				"--region is required for GCP (location can be region or zone) (or set HFCP_REGION)",
+			},
+		},
+		{
+			name:     "gcp cluster lookup takes the project from ADC",
+			flags:    Flags{ProviderName: "gcp", ClusterName: "c", GCPUseADC: "true"},
+			op:       provider.OperationClusterLookup,
+			expected: []string{"--region is required for GCP (location can be region or zone) (or set HFCP_REGION)"},
+		},
+		{
+			name:  "gcp cluster lookup without ADC needs project",
+			flags: Flags{ProviderName: "gcp", ClusterName: "c", GCPUseADC: "false", Region: "us-central1"},
+			op:    provider.OperationClusterLookup,
+			expected: []string{
+				"--project-id is required for GCP (or set HFCP_PROJECT_ID)",
+			},
+		},
+		{
+			name:     "gcp invalid ADC mode",
+			flags:    Flags{ProviderName: "gcp", ClusterName: "c", ProjectID: "p", Region: "r", GCPUseADC: "maybe"},
+			op:       provider.OperationKubeconfig,
+			expected: []string{"--gcp-use-adc is invalid"},
+		},
+		{
+			name:     "aws needs region",
+			flags:    Flags{ProviderName: "aws", ClusterName: "c"},
+			op:       provider.OperationClusterLookup,
+			expected: []string{"--region is required for AWS (or set HFCP_REGION)"},
+		},
+		{
+			name:  "token generation needs no provider inputs",
+			flags: Flags{ProviderName: "aws", ClusterName: "c"},
+		},
+		{
+			name:  "oci declares no required inputs",
+			flags: Flags{ProviderName: "oci", ClusterName: "c"},
+			op:    provider.OperationKubeconfig,
+		},
+		{
+			name:  "digitalocean declares no required inputs",
+			flags: Flags{ProviderName: "digitalocean", ClusterName: "c"},
+			op:    provider.OperationKubeconfig,
+		},
+		{
+			name:  "oidc kubeconfig needs issuer and client",
+			flags: Flags{ProviderName: "oidc", ClusterName: "c"},
+			op:    provider.OperationKubeconfig,
+			expected: []string{
+				"--issuer-url is required for OIDC (or set HFCP_ISSUER_URL)",
+				"--client-id is required for OIDC (or set HFCP_CLIENT_ID)",
+			},
+		},
+		{
+			name:     "oidc issuer must use https",
+			flags:    Flags{ProviderName: "oidc", ClusterName: "c", OIDCIssuerURL: "http://issuer.example.com", OIDCClientID: "client"},
+			op:       provider.OperationKubeconfig,
+			expected: []string{"--issuer-url is invalid"},
+		},
+		{
+			name:  "credentials checksum for gcp",
+			flags: Flags{ProviderName: "gcp", ClusterName: "c", CredentialsSHA256: "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"},
+		},
+		{
+			name:     "credentials checksum must be hex",
+			flags:    Flags{ProviderName: "aws", ClusterName: "c", CredentialsSHA256: "not-a-checksum"},
+			expected: []string{"--credentials-sha256 is invalid: must be 64 hex digits"},
+		},
+		{
+			name:     "credentials checksum is not checked for oci",
+			flags:    Flags{ProviderName: "oci", ClusterName: "c", CredentialsSHA256: "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"},
+			expected: []string{"--credentials-sha256 is not supported for oci"},
+		},
+		{
+			name:     "credentials profile needs a config file",
+			flags:    Flags{ProviderName: "gcp", ClusterName: "c", CredentialsProfile: "prod"},
+			expected: []string{"--credentials-profile needs --config-file"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateInputs(&tt.flags, tt.op)
+			if len(tt.expected) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, errors.ErrInvalidArgument))
+			for _, message := range tt.expected {
+				assert.Contains(t, err.Error(), message)
+			}
+
+			var appErr *errors.Error
+			require.True(t, errors.As(err, &appErr))
+			assert.Len(t, appErr.Fields["problems"], len(tt.expected))
+			if len(tt.expected) > 1 {
+				assert.Contains(t, err.Error(), "missing or invalid flags:\n  ")
+			}
+		})
+	}
+}
+
+func TestValidateProviderInputs(t *testing.T) {
+	assert.NoError(t, ValidateProviderInputs(&Flags{ProviderName: "aws"}, 0), "--cluster-name is not required")
+
+	err := ValidateProviderInputs(&Flags{ProviderName: "azure", AzureCloud: "germany"}, 0)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--azure-cloud is invalid")
+	assert.NotContains(t, err.Error(), "--cluster-name")
+
+	err = ValidateProviderInputs(&Flags{}, 0)
+	require.Error(t, err)
+	assert.Equal(t, "--provider is required (or set HFCP_PROVIDER)", err.Error())
+}
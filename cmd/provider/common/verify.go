@@ -0,0 +1,76 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/clusterverify"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/proxy"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+)
+
+// VerifyToken checks that the cluster's API server accepts the token and reports an
+// accepted token on w (stderr, as stdout may carry the ExecCredential or kubeconfig).
+// Each request is bounded by flags.Timeout.
+func VerifyToken(ctx context.Context, flags *Flags, info *ClusterInfo, token string, w io.Writer, log logger.Logger) error {
+	result, err := clusterverify.Verify(ctx, clusterverify.Options{
+		Provider:             flags.ProviderName,
+		Endpoint:             info.Endpoint,
+		CertificateAuthority: info.CertificateAuthority,
+		Token:                token,
+		Timeout:              flags.Timeout,
+	})
+	if err != nil {
+		log.Error("Token verification failed", logger.String("endpoint", info.Endpoint), logger.String("error", err.Error()))
+		return err
+	}
+
+	identity := result.Username
+	if identity == "" {
+		identity = "server version " + result.GitVersion
+	}
+	log.Info("Cluster accepted the token",
+		logger.String("url", result.URL),
+		logger.Int("http_status", result.StatusCode),
+		logger.String("username", result.Username),
+	)
+	fmt.Fprintf(w, "✅ Token accepted by %s (HTTP %d, %s)\n", result.URL, result.StatusCode, identity)
+	return nil
+}
+
+// VerifyEndpoint checks that the API server certificate verifies against the cluster CA,
+// for serverName when set, and reports the certificate on w. The handshake goes through
+// --https-proxy and --no-proxy, and is bounded by flags.Timeout.
+func VerifyEndpoint(ctx context.Context, flags *Flags, info *ClusterInfo, serverName string, w io.Writer, log logger.Logger) error {
+	proxyFunc, err := proxy.Config{
+		HTTPSProxy: flags.Viper.GetString("https-proxy"),
+		NoProxy:    flags.Viper.GetString("no-proxy"),
+	}.ProxyFunc()
+	if err != nil {
+		return err
+	}
+
+	result, err := clusterverify.VerifyEndpoint(ctx, clusterverify.EndpointOptions{
+		Endpoint:             info.Endpoint,
+		CertificateAuthority: info.CertificateAuthority,
+		ServerName:           serverName,
+		Timeout:              flags.Timeout,
+		Proxy:                proxyFunc,
+	})
+	if err != nil {
+		log.Error("Endpoint verification failed", logger.String("endpoint", info.Endpoint), logger.String("error", err.Error()))
+		return err
+	}
+
+	log.Info("API server certificate verified against the cluster CA",
+		logger.String("address", result.Address),
+		logger.String("server_name", result.ServerName),
+		logger.String("tls_version", result.TLSVersion),
+		logger.String("subject", result.Subject),
+		logger.String("not_after", result.NotAfter.Format(time.RFC3339)),
+	)
+	fmt.Fprintf(w, "✅ Endpoint %s presented a certificate for %s that verifies against the cluster CA (%s)\n", result.Address, result.ServerName, result.TLSVersion)
+	return nil
+}
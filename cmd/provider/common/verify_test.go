@@ -0,0 +1,72 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+)
+
+func TestVerifyToken(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer good-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path != "/version" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"gitVersion":"v1.27.9"}`))
+	}))
+	defer server.Close()
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	info := &ClusterInfo{Endpoint: server.URL, CertificateAuthority: base64.StdEncoding.EncodeToString(certPEM)}
+	flags := &Flags{ProviderName: "gcp"}
+
+	var out bytes.Buffer
+	require.NoError(t, VerifyToken(context.Background(), flags, info, "good-token", &out, logger.Nop()))
+	assert.Equal(t, "✅ Token accepted by "+server.URL+"/version (HTTP 200, server version v1.27.9)\n", out.String())
+
+	out.Reset()
+	err := VerifyToken(context.Background(), flags, info, "revoked-token", &out, logger.Nop())
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, errors.ErrUnauthenticated))
+	assert.Contains(t, err.Error(), "roles/container.clusterViewer")
+	assert.Empty(t, out.String())
+}
+
+func TestVerifyEndpoint(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+	}))
+	defer server.Close()
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	info := &ClusterInfo{Endpoint: server.URL, CertificateAuthority: base64.StdEncoding.EncodeToString(certPEM)}
+	flags := &Flags{ProviderName: "gcp", Viper: viper.New()}
+	address := strings.TrimPrefix(server.URL, "https://")
+
+	var out bytes.Buffer
+	require.NoError(t, VerifyEndpoint(context.Background(), flags, info, "", &out, logger.Nop()))
+	assert.Equal(t, "✅ Endpoint "+address+" presented a certificate for 127.0.0.1 that verifies against the cluster CA (TLS 1.3)\n", out.String())
+
+	// The test certificate is not issued for another cluster's name
+	out.Reset()
+	err := VerifyEndpoint(context.Background(), flags, info, "api.other-cluster.example", &out, logger.Nop())
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, errors.ErrClusterInvalidConfig))
+	assert.Empty(t, out.String())
+}
@@ -1,34 +1,22 @@
 package common
 
 import (
-	"os"
 	"testing"
 
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
-func TestInitViper(t *testing.T) {
-	// Reset viper before each test
-	viper.Reset()
-
-	InitViper()
-
-	// Verify environment variable prefix is set
-	os.Setenv("HFCP_TEST_KEY", "test-value")
-	defer os.Unsetenv("HFCP_TEST_KEY")
+func TestNewViper(t *testing.T) {
+	t.Setenv("HFCP_TEST_KEY", "test-value")
 
 	// Viper should automatically read the env var
-	value := viper.GetString("test-key")
+	value := NewViper(nil).GetString("test-key")
 	assert.Equal(t, "test-value", value, "Viper should read environment variable with prefix")
 }
 
 func TestBindFlagsToViper_GlobalFlags(t *testing.T) {
-	viper.Reset()
-	InitViper()
-
 	tests := []struct {
 		name     string
 		envVars  map[string]string
@@ -71,6 +59,20 @@ func TestBindFlagsToViper_GlobalFlags(t *testing.T) {
 				CredentialsFile: "/path/to/creds.json",
 			},
 		},
+		{
+			name: "quiet wins over log-level",
+			envVars: map[string]string{
+				"HFCP_LOG_LEVEL": "debug",
+				"HFCP_QUIET":     "true",
+			},
+			initial: &Flags{
+				LogLevel: "info",
+			},
+			expected: &Flags{
+				LogLevel: "error",
+				Quiet:    true,
+			},
+		},
 		{
 			name: "multiple env vars",
 			envVars: map[string]string{
@@ -95,14 +97,9 @@ func TestBindFlagsToViper_GlobalFlags(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			// Set environment variables
 			for key, value := range tt.envVars {
-				os.Setenv(key, value)
-				defer os.Unsetenv(key)
+				t.Setenv(key, value)
 			}
 
-			// Re-initialize viper to pick up env vars
-			viper.Reset()
-			InitViper()
-
 			// Apply bindings
 			flags := tt.initial
 			BindFlagsToViper(flags)
@@ -111,14 +108,12 @@ func TestBindFlagsToViper_GlobalFlags(t *testing.T) {
 			assert.Equal(t, tt.expected.LogLevel, flags.LogLevel)
 			assert.Equal(t, tt.expected.LogFormat, flags.LogFormat)
 			assert.Equal(t, tt.expected.CredentialsFile, flags.CredentialsFile)
+			assert.Equal(t, tt.expected.Quiet, flags.Quiet)
 		})
 	}
 }
 
 func TestBindFlagsToViper_ProviderFlags(t *testing.T) {
-	viper.Reset()
-	InitViper()
-
 	tests := []struct {
 		name     string
 		envVars  map[string]string
@@ -171,14 +166,9 @@ func TestBindFlagsToViper_ProviderFlags(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			// Set environment variables
 			for key, value := range tt.envVars {
-				os.Setenv(key, value)
-				defer os.Unsetenv(key)
+				t.Setenv(key, value)
 			}
 
-			// Re-initialize viper
-			viper.Reset()
-			InitViper()
-
 			// Apply bindings
 			flags := tt.initial
 			BindFlagsToViper(flags)
@@ -193,14 +183,7 @@ func TestBindFlagsToViper_ProviderFlags(t *testing.T) {
 }
 
 func TestBindFlagsToViper_AWSFlags(t *testing.T) {
-	viper.Reset()
-	InitViper()
-
-	os.Setenv("HFCP_ACCOUNT_ID", "123456789012")
-	defer os.Unsetenv("HFCP_ACCOUNT_ID")
-
-	viper.Reset()
-	InitViper()
+	t.Setenv("HFCP_ACCOUNT_ID", "123456789012")
 
 	flags := &Flags{}
 	BindFlagsToViper(flags)
@@ -209,9 +192,6 @@ func TestBindFlagsToViper_AWSFlags(t *testing.T) {
 }
 
 func TestBindFlagsToViper_AzureFlags(t *testing.T) {
-	viper.Reset()
-	InitViper()
-
 	envVars := map[string]string{
 		"HFCP_SUBSCRIPTION_ID": "sub-123",
 		"HFCP_TENANT_ID":       "tenant-456",
@@ -219,13 +199,9 @@ func TestBindFlagsToViper_AzureFlags(t *testing.T) {
 	}
 
 	for key, value := range envVars {
-		os.Setenv(key, value)
-		defer os.Unsetenv(key)
+		t.Setenv(key, value)
 	}
 
-	viper.Reset()
-	InitViper()
-
 	flags := &Flags{}
 	BindFlagsToViper(flags)
 
@@ -235,35 +211,26 @@ func TestBindFlagsToViper_AzureFlags(t *testing.T) {
 }
 
 func TestBindFlagsToViper_NoEnvVars(t *testing.T) {
-	viper.Reset()
-	InitViper()
-
 	// No environment variables set
 	flags := &Flags{
-		LogLevel:     "info",
-		LogFormat:    "json",
-		ProviderName: "",
+		LogLevel:  "info",
+		LogFormat: "json",
+		GCPUseADC: "auto",
+		Timeout:   DefaultTimeout,
 	}
 
 	BindFlagsToViper(flags)
 
-	// When no env vars are set, viper returns empty strings
-	// Since isFlagSetExplicitly always returns false, flags get overwritten with empty values
-	// This is current behavior - empty env var values override defaults
-	assert.Equal(t, "", flags.LogLevel, "Viper returns empty string when no env var set")
-	assert.Equal(t, "", flags.LogFormat, "Viper returns empty string when no env var set")
+	// Defaults are kept when neither a flag nor an env var is set
+	assert.Equal(t, "info", flags.LogLevel)
+	assert.Equal(t, "json", flags.LogFormat)
+	assert.Equal(t, "auto", flags.GCPUseADC)
+	assert.Equal(t, DefaultTimeout, flags.Timeout)
 	assert.Equal(t, "", flags.ProviderName)
 }
 
 func TestBindFlagsToViper_TokenDuration(t *testing.T) {
-	viper.Reset()
-	InitViper()
-
-	os.Setenv("HFCP_TOKEN_DURATION", "2h")
-	defer os.Unsetenv("HFCP_TOKEN_DURATION")
-
-	viper.Reset()
-	InitViper()
+	t.Setenv("HFCP_TOKEN_DURATION", "2h")
 
 	flags := &Flags{}
 	BindFlagsToViper(flags)
@@ -271,82 +238,37 @@ func TestBindFlagsToViper_TokenDuration(t *testing.T) {
 	assert.Equal(t, "2h", flags.TokenDuration)
 }
 
-func TestBindCommandFlags(t *testing.T) {
-	viper.Reset()
-	InitViper()
-
-	// Create a test command with flags
-	cmd := &cobra.Command{
-		Use: "test",
-	}
-
-	var testFlag string
-	cmd.Flags().StringVar(&testFlag, "test-flag", "", "test flag")
-
-	// Bind flags
-	err := BindCommandFlags(cmd)
-	require.NoError(t, err)
-
-	// Set env var
-	os.Setenv("HFCP_TEST_FLAG", "test-value")
-	defer os.Unsetenv("HFCP_TEST_FLAG")
-
-	// Viper should be able to read it
-	value := viper.GetString("test-flag")
-	assert.Equal(t, "test-value", value)
-}
-
-func TestBindPersistentFlags(t *testing.T) {
-	viper.Reset()
-	InitViper()
-
-	// Create a test command with persistent flags
-	rootCmd := &cobra.Command{
-		Use: "root",
-	}
-
-	var testFlag string
-	rootCmd.PersistentFlags().StringVar(&testFlag, "persistent-flag", "", "persistent test flag")
-
-	// Bind persistent flags
-	BindPersistentFlags(rootCmd)
-
-	// Set env var
-	os.Setenv("HFCP_PERSISTENT_FLAG", "persistent-value")
-	defer os.Unsetenv("HFCP_PERSISTENT_FLAG")
-
-	// Viper should be able to read it
-	value := viper.GetString("persistent-flag")
-	assert.Equal(t, "persistent-value", value)
+func TestNewViper_CommandFlags(t *testing.T) {
+	t.Setenv("HFCP_TEST_FLAG", "test-value")
+	t.Setenv("HFCP_PERSISTENT_FLAG", "persistent-value")
+
+	// Create a test command with a local flag and a flag inherited from the root
+	rootCmd := &cobra.Command{Use: "root"}
+	rootCmd.PersistentFlags().String("persistent-flag", "", "persistent test flag")
+	cmd := &cobra.Command{Use: "test", Run: func(cmd *cobra.Command, args []string) {}}
+	cmd.Flags().String("test-flag", "", "test flag")
+	rootCmd.AddCommand(cmd)
+	rootCmd.SetArgs([]string{"test"})
+	require.NoError(t, rootCmd.Execute())
+
+	// Viper should be able to read both
+	v := NewViper(cmd)
+	assert.Equal(t, "test-value", v.GetString("test-flag"))
+	assert.Equal(t, "persistent-value", v.GetString("persistent-flag"))
 }
 
 func TestBindFlagsToViper_UnderscoreReplacement(t *testing.T) {
 	// Test that hyphens in flag names are converted to underscores in env vars
-	viper.Reset()
-	InitViper()
-
-	// Set env var with underscores
-	os.Setenv("HFCP_CLUSTER_NAME", "test-cluster")
-	defer os.Unsetenv("HFCP_CLUSTER_NAME")
-
-	viper.Reset()
-	InitViper()
+	t.Setenv("HFCP_CLUSTER_NAME", "test-cluster")
 
 	// Viper should read it with hyphen key name
-	value := viper.GetString("cluster-name")
+	value := NewViper(nil).GetString("cluster-name")
 	assert.Equal(t, "test-cluster", value, "Viper should convert underscores to hyphens")
 }
 
 func TestBindFlagsToViper_EmptyEnvVar(t *testing.T) {
-	viper.Reset()
-	InitViper()
-
 	// Set empty env var
-	os.Setenv("HFCP_PROVIDER", "")
-	defer os.Unsetenv("HFCP_PROVIDER")
-
-	viper.Reset()
-	InitViper()
+	t.Setenv("HFCP_PROVIDER", "")
 
 	flags := &Flags{
 		ProviderName: "default-provider",
@@ -355,7 +277,60 @@ func TestBindFlagsToViper_EmptyEnvVar(t *testing.T) {
 	BindFlagsToViper(flags)
 
 	// Empty env var should not override default
-	// Note: This depends on implementation - empty string is still a valid value
-	value := viper.GetString("provider")
-	assert.Equal(t, "", value, "Empty env var is a valid value")
+	assert.Equal(t, "default-provider", flags.ProviderName)
+}
+
+func TestBindFlagsToViper_Precedence(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		env      map[string]string
+		expected string
+	}{
+		{name: "default", expected: "info"},
+		{name: "env overrides default", env: map[string]string{"HFCP_LOG_LEVEL": "debug"}, expected: "debug"},
+		{name: "flag", args: []string{"--log-level=warn"}, expected: "warn"},
+		{name: "flag overrides env", args: []string{"--log-level=warn"}, env: map[string]string{"HFCP_LOG_LEVEL": "debug"}, expected: "warn"},
+		{name: "flag set to its default overrides env", args: []string{"--log-level=info"}, env: map[string]string{"HFCP_LOG_LEVEL": "debug"}, expected: "info"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			flags := &Flags{}
+			cmd := &cobra.Command{
+				Use: "test",
+				Run: func(cmd *cobra.Command, args []string) {
+					flags.Viper = NewViper(cmd)
+					BindFlagsToViper(flags)
+				},
+			}
+			cmd.Flags().StringVar(&flags.LogLevel, "log-level", "info", "")
+			cmd.Flags().StringVar(&flags.LogFormat, "log-format", "json", "")
+			cmd.SetArgs(tt.args)
+			require.NoError(t, cmd.Execute())
+
+			assert.Equal(t, tt.expected, flags.LogLevel)
+			assert.Equal(t, "json", flags.LogFormat, "defaults of other flags are kept")
+		})
+	}
+}
+
+func TestNewViper_SharedFlagNames(t *testing.T) {
+	// Commands with the same flag name resolve it from their own flags
+	newCommand := func(defaultAddress string) *cobra.Command {
+		cmd := &cobra.Command{Use: "test", Run: func(cmd *cobra.Command, args []string) {}}
+		cmd.Flags().String("health-address", defaultAddress, "")
+		return cmd
+	}
+	serve := newCommand(":8080")
+	refresh := newCommand("")
+	refresh.SetArgs([]string{"--health-address=:9090"})
+	require.NoError(t, refresh.Execute())
+
+	assert.Equal(t, ":8080", NewViper(serve).GetString("health-address"))
+	assert.Equal(t, ":9090", NewViper(refresh).GetString("health-address"))
 }
@@ -0,0 +1,130 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/cmd/provider/common"
+	internalconfig "github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/config"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+)
+
+func NewCommand(flags *common.Flags) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Work with provider configuration files",
+		// Replaces the root hook, which applies --config-file and stops at its first
+		// error, since config validate reports every error of that file
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			flags.Viper = common.NewViper(cmd)
+		},
+	}
+
+	cmd.AddCommand(newValidateCommand(flags))
+
+	return cmd
+}
+
+// validateOptions are the flags of config validate
+type validateOptions struct {
+	file    string
+	strict  bool
+	env     bool
+	output  string
+	profile string
+}
+
+func newValidateCommand(flags *common.Flags) *cobra.Command {
+	opts := &validateOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "validate",
+		Short: "Report every problem in a configuration file",
+		Long: `Validate a provider configuration file and report every violation at once,
+with the YAML path and line of each field, instead of stopping at the first one.
+
+Errors make the configuration invalid. Warnings, such as unknown fields or the
+section of a provider that is not selected, are reported but only fail with --strict.
+The command exits non-zero when the configuration is invalid.`,
+		Example: `  # Validate a config file in CI, failing on warnings too
+  hyperfleet-credential-provider config validate --config-file=provider.yaml --strict
+
+  # Include environment variable overrides, as the loader applies them
+  hyperfleet-credential-provider config validate --config-file=provider.yaml --env --output=json
+
+  # Validate the configuration as resolved with one of its credentials profiles
+  hyperfleet-credential-provider config validate --config-file=provider.yaml --credentials-profile=prod`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			file, err := validateFile(opts.file, flags.Viper.GetString("config-file"))
+			if err != nil {
+				return err
+			}
+			opts.file = file
+			return runValidate(opts, cmd.OutOrStdout())
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.file, "config", "", "Configuration file to validate")
+	cmd.Flags().MarkDeprecated("config", "use --config-file instead")
+	cmd.Flags().BoolVar(&opts.strict, "strict", false, "Fail on warnings as well as errors")
+	cmd.Flags().BoolVar(&opts.env, "env", false, "Apply environment variable overrides before validating")
+	cmd.Flags().StringVarP(&opts.output, "output", "o", "text", "Output format (text, json)")
+	cmd.Flags().StringVar(&opts.profile, "credentials-profile", "", "Credentials profile to apply before validating (default: the profile key of the file)")
+
+	return cmd
+}
+
+// validateFile returns the file config validate checks: the global --config-file, or
+// the deprecated --config alias
+func validateFile(alias, configFile string) (string, error) {
+	if alias != "" && configFile != "" && alias != configFile {
+		return "", errors.New(errors.ErrInvalidArgument, "--config and --config-file name different files; use --config-file only")
+	}
+	if alias != "" {
+		return alias, nil
+	}
+	return configFile, nil
+}
+
+func runValidate(opts *validateOptions, w io.Writer) error {
+	if opts.file == "" {
+		return errors.New(errors.ErrInvalidArgument, "--config-file is required")
+	}
+	if opts.output != "text" && opts.output != "json" {
+		return errors.New(errors.ErrInvalidArgument, fmt.Sprintf("unsupported output format %q (must be one of: text, json)", opts.output))
+	}
+
+	loadOpts := []internalconfig.LoadOption{internalconfig.WithConfigFile(opts.file)}
+	if opts.env {
+		loadOpts = append(loadOpts, internalconfig.WithEnv())
+	}
+	if opts.profile != "" {
+		loadOpts = append(loadOpts, internalconfig.WithProfile(opts.profile))
+	}
+	_, report, err := internalconfig.Check(loadOpts...)
+	if err != nil {
+		return err
+	}
+
+	if opts.output == "json" {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			return err
+		}
+	} else {
+		report.WriteText(w)
+	}
+
+	// The report already lists every violation, so the error only summarizes it
+	if !report.Valid(opts.strict) {
+		return errors.New(
+			errors.ErrConfigInvalid,
+			fmt.Sprintf("%s is invalid: %d error(s), %d warning(s)", opts.file, len(report.Errors()), len(report.Warnings())),
+		).WithField("file", opts.file)
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	stderrors "errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/cmd/provider/common"
+	internalconfig "github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/config"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+)
+
+// warningOnlyConfig is valid but has an unknown field
+const warningOnlyConfig = `provider:
+  name: aws
+  cluster_name: my-cluster
+  regoin: us-east-1
+  aws: {}
+`
+
+func TestRunValidate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(warningOnlyConfig), 0600))
+
+	tests := []struct {
+		name     string
+		opts     validateOptions
+		wantErr  bool
+		wantText string
+	}{
+		{
+			name:     "warnings pass by default",
+			opts:     validateOptions{file: path, output: "text"},
+			wantText: "0 error(s), 1 warning(s)",
+		},
+		{
+			name:     "warnings fail with strict",
+			opts:     validateOptions{file: path, strict: true, output: "text"},
+			wantErr:  true,
+			wantText: "provider.regoin (line 4): is not a known configuration field and is ignored [unknown_field]",
+		},
+		{
+			name:    "config is required",
+			opts:    validateOptions{output: "text"},
+			wantErr: true,
+		},
+		{
+			name:    "unsupported output",
+			opts:    validateOptions{file: path, output: "yaml"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			err := runValidate(&tt.opts, &out)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Equal(t, common.ExitInvalidArgument, common.ExitCode(err))
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Contains(t, out.String(), tt.wantText)
+		})
+	}
+}
+
+func TestRunValidate_JSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("provider:\n  name: gcp\n"), 0600))
+
+	var out bytes.Buffer
+	err := runValidate(&validateOptions{file: path, output: "json"}, &out)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "2 error(s)")
+	assert.True(t, errors.Is(err, errors.ErrConfigInvalid))
+	assert.Equal(t, common.ExitInvalidArgument, common.ExitCode(err))
+
+	var report internalconfig.Report
+	require.NoError(t, json.Unmarshal(out.Bytes(), &report))
+	assert.Equal(t, path, report.Source)
+	require.Len(t, report.Violations, 2)
+	assert.Equal(t, "provider.cluster_name", report.Violations[0].Path)
+	assert.Equal(t, "provider.gcp", report.Violations[1].Path)
+	assert.Equal(t, "required_for_provider", report.Violations[1].Rule)
+}
+
+func TestRunValidate_CredentialsProfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`provider:
+  name: azure
+  cluster_name: my-cluster
+credentials:
+  prod:
+    azure:
+      subscription_id: sub
+      tenant_id: tenant
+`), 0600))
+
+	var out bytes.Buffer
+	require.NoError(t, runValidate(&validateOptions{file: path, output: "text", profile: "prod"}, &out))
+	assert.Contains(t, out.String(), "valid")
+
+	out.Reset()
+	err := runValidate(&validateOptions{file: path, output: "text", profile: "dev"}, &out)
+	require.Error(t, err)
+	assert.Contains(t, out.String(), `unknown credentials profile "dev" (available profiles: prod) [unknown_profile]`)
+}
+
+// runConfigCommand runs config with the global --config-file flag of the root command
+func runConfigCommand(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+
+	flags := &common.Flags{}
+	root := &cobra.Command{
+		Use:           "hyperfleet-credential-provider",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		// The root hook applies --config-file, which config validate must not run into
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return stderrors.New("root hook ran")
+		},
+	}
+	root.PersistentFlags().String("config-file", "", "")
+	root.AddCommand(NewCommand(flags))
+	root.SetArgs(append([]string{"config"}, args...))
+
+	var out bytes.Buffer
+	root.SetOut(&out)
+	root.SetErr(&out)
+	err := root.Execute()
+	return out.String(), err
+}
+
+func TestValidateCommand_ConfigFile(t *testing.T) {
+	t.Setenv("HFCP_CONFIG_FILE", "")
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(warningOnlyConfig), 0600))
+	invalid := filepath.Join(dir, "invalid.yaml")
+	require.NoError(t, os.WriteFile(invalid, []byte("provider:\n  name: gcp\n"), 0600))
+
+	out, err := runConfigCommand(t, "validate", "--config-file="+path)
+	require.NoError(t, err)
+	assert.Contains(t, out, "0 error(s), 1 warning(s)")
+
+	out, err = runConfigCommand(t, "validate", "--config-file="+invalid)
+	require.Error(t, err)
+	assert.Equal(t, common.ExitInvalidArgument, common.ExitCode(err))
+	assert.Contains(t, out, "2 error(s)", "every error of the file is reported")
+
+	t.Run("HFCP_CONFIG_FILE", func(t *testing.T) {
+		t.Setenv("HFCP_CONFIG_FILE", path)
+		out, err := runConfigCommand(t, "validate")
+		require.NoError(t, err)
+		assert.Contains(t, out, "0 error(s), 1 warning(s)")
+	})
+
+	t.Run("deprecated --config", func(t *testing.T) {
+		out, err := runConfigCommand(t, "validate", "--config="+path)
+		require.NoError(t, err)
+		assert.Contains(t, out, "--config has been deprecated, use --config-file instead")
+		assert.Contains(t, out, "0 error(s), 1 warning(s)")
+	})
+
+	t.Run("--config and --config-file differ", func(t *testing.T) {
+		_, err := runConfigCommand(t, "validate", "--config="+path, "--config-file="+invalid)
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, errors.ErrInvalidArgument))
+	})
+
+	t.Run("no file", func(t *testing.T) {
+		_, err := runConfigCommand(t, "validate")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "--config-file is required")
+	})
+}
@@ -0,0 +1,67 @@
+package credentials
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/cmd/provider/common"
+	internalcreds "github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/credentials"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+)
+
+func NewCommand(flags *common.Flags) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "credentials",
+		Short: "Inspect configured credentials",
+	}
+
+	cmd.AddCommand(newListCommand(flags))
+
+	return cmd
+}
+
+func newListCommand(flags *common.Flags) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the service account keys in the credentials directory",
+		Long: `List the GCP service account keys in --credentials-dir with the project and
+client email of each key. Files without a .json extension are ignored.
+
+Examples:
+  hyperfleet-credential-provider credentials list --provider=gcp --credentials-dir=~/.config/gcp-keys
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			common.BindFlagsToViper(flags)
+			return runList(flags, cmd.OutOrStdout())
+		},
+	}
+
+	cmd.Flags().StringVar(&flags.ProviderName, "provider", "", "Cloud provider (only gcp is supported) [required]")
+
+	return cmd
+}
+
+func runList(flags *common.Flags, w io.Writer) error {
+	if flags.ProviderName != "gcp" {
+		return errors.New(errors.ErrInvalidArgument, "credentials list only supports --provider=gcp")
+	}
+	if flags.CredentialsDir == "" {
+		return errors.New(errors.ErrInvalidArgument, "--credentials-dir is required (or set HFCP_GCP_CREDENTIALS_DIR)")
+	}
+
+	keys, err := internalcreds.NewGCPKeyDir(flags.CredentialsDir).List()
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "FILE\tPROJECT\tCLIENT EMAIL")
+	for _, key := range keys {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", filepath.Base(key.Path), key.ProjectID, key.ClientEmail)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,41 @@
+package credentials
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/cmd/provider/common"
+)
+
+func TestRunList(t *testing.T) {
+	dir := t.TempDir()
+	key := `{"type":"service_account","project_id":"my-project","client_email":"deploy@my-project.iam.gserviceaccount.com"}`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "deploy.json"), []byte(key), 0600))
+
+	var out bytes.Buffer
+	require.NoError(t, runList(&common.Flags{ProviderName: "gcp", CredentialsDir: dir}, &out))
+	assert.Contains(t, out.String(), "deploy.json")
+	assert.Contains(t, out.String(), "deploy@my-project.iam.gserviceaccount.com")
+
+	tests := []struct {
+		name    string
+		flags   *common.Flags
+		wantErr string
+	}{
+		{name: "unsupported provider", flags: &common.Flags{ProviderName: "aws", CredentialsDir: dir}, wantErr: "only supports --provider=gcp"},
+		{name: "missing credentials directory", flags: &common.Flags{ProviderName: "gcp"}, wantErr: "--credentials-dir is required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := runList(tt.flags, &bytes.Buffer{})
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+			assert.Equal(t, common.ExitInvalidArgument, common.ExitCode(err))
+		})
+	}
+}
@@ -0,0 +1,560 @@
+package doctor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/cmd/provider/common"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/cmd/provider/version"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/credentials"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/headercheck"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+)
+
+// pluginBinaryName is the exec command generate-kubeconfig writes when it is on PATH
+const pluginBinaryName = "hyperfleet-credential-provider"
+
+// Check statuses. Any fail makes the command exit non-zero.
+const (
+	statusPass = "pass"
+	statusWarn = "warn"
+	statusFail = "fail"
+)
+
+const (
+	// defaultClockTimeout bounds the request used to measure clock skew
+	defaultClockTimeout = 5 * time.Second
+
+	// clockSkewWarn is the skew reported as a warning; the Date header has a one second
+	// resolution, so smaller differences are noise
+	clockSkewWarn = 30 * time.Second
+
+	// clockSkewFail is the skew at which signed requests are rejected: AWS refuses
+	// presigned URLs and STS calls signed more than 5 minutes off
+	clockSkewFail = 5 * time.Minute
+
+	// defaultClockURL is asked for the time when no provider is selected
+	defaultClockURL = "https://sts.amazonaws.com"
+
+	// headerProbeTimeout bounds the large Authorization header probe
+	headerProbeTimeout = 10 * time.Second
+)
+
+// providerEnvironment lists the environment a provider reads
+type providerEnvironment struct {
+	// files are variables that name credentials files
+	files []string
+
+	// vars are the other variables that configure credentials
+	vars []string
+
+	// clockURL answers with the time of the provider's token endpoint
+	clockURL string
+}
+
+// providerEnvironments are the environments of the built-in providers
+var providerEnvironments = map[string]providerEnvironment{
+	"gcp": {
+		files:    []string{"GOOGLE_APPLICATION_CREDENTIALS"},
+		clockURL: "https://oauth2.googleapis.com",
+	},
+	"aws": {
+		files: []string{"AWS_CREDENTIALS_FILE", "AWS_SHARED_CREDENTIALS_FILE", "AWS_CONFIG_FILE", "AWS_WEB_IDENTITY_TOKEN_FILE"},
+		vars: []string{
+			"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN", "AWS_CREDENTIAL_EXPIRATION",
+			"AWS_PROFILE", "AWS_REGION", "AWS_DEFAULT_REGION", "AWS_ROLE_ARN", "AWS_ROLE_SESSION_NAME",
+			"AWS_CONTAINER_CREDENTIALS_RELATIVE_URI", "AWS_CONTAINER_CREDENTIALS_FULL_URI", "AWS_EC2_METADATA_DISABLED",
+		},
+		clockURL: "https://sts.amazonaws.com",
+	},
+	"azure": {
+		files: []string{"AZURE_CREDENTIALS_FILE", "AZURE_CLIENT_CERTIFICATE_PATH"},
+		vars: []string{
+			"AZURE_CLIENT_ID", "AZURE_CLIENT_SECRET", "AZURE_TENANT_ID", "AZURE_CLOUD",
+			"AZURE_CLIENT_CERTIFICATE_PASSWORD", "AZURE_CLIENT_SEND_CERTIFICATE_CHAIN",
+		},
+		clockURL: "https://login.microsoftonline.com",
+	},
+	"oci": {
+		files:    []string{"OCI_CLI_CONFIG_FILE", "OCI_CLI_KEY_FILE"},
+		vars:     []string{"OCI_CLI_PROFILE", "OCI_CLI_REGION", "OCI_CLI_TENANCY", "OCI_CLI_USER", "OCI_CLI_FINGERPRINT"},
+		clockURL: "https://iaas.us-ashburn-1.oraclecloud.com",
+	},
+	"digitalocean": {
+		files:    []string{"DIGITALOCEAN_CREDENTIALS_FILE"},
+		vars:     []string{"DIGITALOCEAN_ACCESS_TOKEN", "DIGITALOCEAN_TOKEN"},
+		clockURL: "https://api.digitalocean.com",
+	},
+	// The issuer is configured per deployment, so the clock is checked against the default URL
+	"oidc": {
+		files: []string{"OIDC_CLIENT_SECRET_FILE"},
+		vars:  []string{"OIDC_CLIENT_SECRET"},
+	},
+}
+
+// result is the outcome of one check
+type result struct {
+	Check   string `json:"check"`
+	Subject string `json:"subject"`
+	Status  string `json:"status"`
+	Detail  string `json:"detail,omitempty"`
+}
+
+// report is the output of doctor --output=json
+type report struct {
+	Provider string   `json:"provider,omitempty"`
+	Status   string   `json:"status"`
+	Results  []result `json:"results"`
+}
+
+// doctor checks the environment. Its functions are fields so tests can replace the
+// process environment, PATH and the network.
+type doctor struct {
+	provider        string
+	credentialsFile string
+	clockURL        string
+	clockTimeout    time.Duration
+	skipNetwork     bool
+
+	// probe configures the large Authorization header check; it runs when probe.URL is set
+	probe headercheck.ProbeOptions
+
+	environ     func() []string
+	lookPath    func(file string) (string, error)
+	executable  func() (string, error)
+	clockSkew   func(ctx context.Context, url string, timeout time.Duration) (time.Duration, error)
+	probeHeader func(ctx context.Context, opts headercheck.ProbeOptions) (*headercheck.ProbeResult, error)
+}
+
+func NewCommand(flags *common.Flags) *cobra.Command {
+	var output string
+
+	cmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Diagnose the runtime environment of the credential provider",
+		Long: `Check the environment the credential provider runs in and print a pass/warn/fail report.
+
+The checks cover, for the selected provider or every provider with credentials configured:
+  - credentials files named by --credentials-file and the provider's variables exist and are readable
+  - which HFCP_ and cloud environment variables are set (values are never printed)
+  - whether this binary is on PATH, as kubeconfig exec entries run it by name
+  - the clock skew against the provider's token endpoint, which breaks signed requests
+  - the Go version, OS and architecture of this binary
+  - with --probe-header-url, whether a large Authorization header passes the proxy intact
+
+The clock and header checks call the network; --dry-run skips them. The command exits
+non-zero when a check fails.`,
+		Example: `  # Check the environment for AWS
+  hyperfleet-credential-provider doctor --provider=aws
+
+  # Machine-readable report
+  hyperfleet-credential-provider doctor --output=json
+
+  # Check that the proxy passes a 16KB Authorization header, as large EKS tokens need
+  hyperfleet-credential-provider doctor --https-proxy=http://proxy:3128 \
+    --probe-header-url=https://httpbin.org/headers --probe-reflector`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDoctor(cmd.Context(), flags, output, cmd.OutOrStdout())
+		},
+	}
+
+	cmd.Flags().StringVar(&flags.ProviderName, "provider", "", "Cloud provider to check (default: every provider with credentials configured)")
+	cmd.Flags().StringVarP(&output, "output", "o", "text", "Output format (text, json)")
+	cmd.Flags().String("clock-url", "", "URL whose Date header the clock is compared with (default: the provider's token endpoint)")
+	cmd.Flags().Duration("clock-timeout", defaultClockTimeout, "How long to wait for the clock check")
+	cmd.Flags().String("probe-header-url", "", "URL a large synthetic Authorization header is sent to through the proxy, to detect truncation: a reflector with --probe-reflector, or e.g. the cluster endpoint")
+	cmd.Flags().Bool("probe-reflector", false, "The --probe-header-url echoes the request headers as JSON, like httpbin's /headers, so truncation is detected exactly")
+	cmd.Flags().Int("probe-header-size", headercheck.DefaultProbeHeaderSize, "Size in bytes of the synthetic Authorization header")
+
+	return cmd
+}
+
+func runDoctor(ctx context.Context, flags *common.Flags, output string, stdout io.Writer) error {
+	// Bind Viper values to flags (environment variables take precedence if flags not set)
+	common.BindFlagsToViper(flags)
+
+	if output != "text" && output != "json" {
+		return errors.New(errors.ErrInvalidArgument, fmt.Sprintf("unsupported output format %q (must be one of: text, json)", output))
+	}
+	if flags.ProviderName != "" {
+		if err := provider.CheckRegistered(flags.ProviderName); err != nil {
+			return err
+		}
+	}
+
+	d := &doctor{
+		provider:        flags.ProviderName,
+		credentialsFile: flags.CredentialsFile,
+		clockURL:        flags.Viper.GetString("clock-url"),
+		clockTimeout:    flags.Viper.GetDuration("clock-timeout"),
+		skipNetwork:     flags.DryRun,
+		probe: headercheck.ProbeOptions{
+			URL:        flags.Viper.GetString("probe-header-url"),
+			Reflector:  flags.Viper.GetBool("probe-reflector"),
+			HeaderSize: flags.Viper.GetInt("probe-header-size"),
+			Client:     flags.HTTPClient,
+		},
+		environ:     os.Environ,
+		lookPath:    exec.LookPath,
+		executable:  os.Executable,
+		clockSkew:   httpClockSkew,
+		probeHeader: headercheck.ProbeProxy,
+	}
+
+	rep := d.run(ctx)
+	if err := writeReport(stdout, output, rep); err != nil {
+		return err
+	}
+
+	if rep.Status == statusFail {
+		return errors.New(errors.ErrConfigInvalid, "environment check failed; see the failed checks above")
+	}
+	return nil
+}
+
+// run performs every check and summarizes them with the worst status
+func (d *doctor) run(ctx context.Context) report {
+	env := d.environment()
+
+	var results []result
+	results = append(results, d.checkRuntime())
+	results = append(results, d.checkCredentials(env)...)
+	results = append(results, d.checkEnvironment(env)...)
+	results = append(results, d.checkPath())
+	results = append(results, d.checkClock(ctx))
+	if d.probe.URL != "" {
+		results = append(results, d.checkProxyHeader(ctx))
+	}
+
+	rep := report{Provider: d.provider, Status: statusPass, Results: results}
+	for _, r := range results {
+		if r.Status == statusFail || (r.Status == statusWarn && rep.Status == statusPass) {
+			rep.Status = r.Status
+		}
+	}
+	return rep
+}
+
+// environment returns the set, non-empty environment variables
+func (d *doctor) environment() map[string]string {
+	env := make(map[string]string)
+	for _, entry := range d.environ() {
+		name, value, ok := strings.Cut(entry, "=")
+		if ok && value != "" {
+			env[name] = value
+		}
+	}
+	return env
+}
+
+// providers returns the providers to check: the selected one, or those with any
+// credentials variable set
+func (d *doctor) providers(env map[string]string) []string {
+	if d.provider != "" {
+		return []string{d.provider}
+	}
+
+	var names []string
+	for _, name := range provider.Registered() {
+		penv := providerEnvironments[name]
+		for _, variable := range append(append([]string{}, penv.files...), penv.vars...) {
+			if env[variable] != "" {
+				names = append(names, name)
+				break
+			}
+		}
+	}
+	return names
+}
+
+func (d *doctor) checkRuntime() result {
+	info := version.Current()
+	return result{
+		Check:   "runtime",
+		Subject: fmt.Sprintf("%s %s/%s", info.GoVersion, runtime.GOOS, runtime.GOARCH),
+		Status:  statusPass,
+		Detail:  "version " + info.Version,
+	}
+}
+
+// checkCredentials checks the credentials files of the providers. A provider with no
+// file or variable configured relies on ambient credentials, which is only a warning.
+func (d *doctor) checkCredentials(env map[string]string) []result {
+	var results []result
+	if d.credentialsFile != "" {
+		results = append(results, checkFile("--credentials-file", d.credentialsFile))
+	}
+
+	providers := d.providers(env)
+	if len(providers) == 0 && d.credentialsFile == "" {
+		return append(results, result{
+			Check:   "credentials",
+			Subject: "all providers",
+			Status:  statusWarn,
+			Detail:  "no credentials file or cloud credentials variable is set; only ambient credentials such as instance metadata can be used",
+		})
+	}
+
+	for _, name := range providers {
+		penv := providerEnvironments[name]
+		configured := d.credentialsFile != ""
+		for _, variable := range penv.files {
+			if path := env[variable]; path != "" {
+				results = append(results, checkFile(variable, path))
+				configured = true
+			}
+		}
+		for _, variable := range penv.vars {
+			configured = configured || env[variable] != ""
+		}
+		if !configured {
+			status, detail := statusWarn, "no credentials file or variable is set; ambient credentials must be available"
+			if name == "aws" && credentials.DetectAWSInstanceMetadata() {
+				status, detail = statusPass, "credentials come from the EC2 or ECS metadata endpoint"
+			}
+			results = append(results, result{Check: "credentials", Subject: name, Status: status, Detail: detail})
+		}
+	}
+	return results
+}
+
+// checkFile reports whether a credentials file exists, is readable and is private
+func checkFile(source, path string) result {
+	r := result{Check: "credentials", Subject: source + "=" + path}
+
+	if credentials.IsSecretURI(path) {
+		r.Status, r.Detail = statusPass, "secret source reference, fetched when credentials are loaded"
+		return r
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		r.Status = statusFail
+		if os.IsNotExist(err) {
+			r.Detail = "file does not exist"
+		} else {
+			r.Detail = "file is not readable: " + err.Error()
+		}
+		return r
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	switch {
+	case err != nil:
+		r.Status, r.Detail = statusFail, "file is not readable: "+err.Error()
+	case info.IsDir():
+		r.Status, r.Detail = statusFail, "path is a directory"
+	case runtime.GOOS != "windows" && info.Mode().Perm()&0077 != 0:
+		r.Status, r.Detail = statusWarn, fmt.Sprintf("readable by group or others (mode %04o); --strict-permissions rejects it", info.Mode().Perm())
+	default:
+		r.Status, r.Detail = statusPass, "readable"
+	}
+	return r
+}
+
+// checkEnvironment lists the HFCP_ and cloud variables that are set, without values
+func (d *doctor) checkEnvironment(env map[string]string) []result {
+	known := make(map[string]bool)
+	for _, penv := range providerEnvironments {
+		for _, variable := range append(append([]string{}, penv.files...), penv.vars...) {
+			known[variable] = true
+		}
+	}
+
+	var names []string
+	for name := range env {
+		if strings.HasPrefix(name, "HFCP_") || known[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	if len(names) == 0 {
+		return []result{{Check: "env", Subject: "HFCP_*", Status: statusPass, Detail: "no HFCP_ or cloud variables set"}}
+	}
+	results := make([]result, 0, len(names))
+	for _, name := range names {
+		results = append(results, result{Check: "env", Subject: name, Status: statusPass, Detail: "set (value redacted)"})
+	}
+	return results
+}
+
+// checkPath reports whether kubeconfig exec entries that run the bare binary name find
+// this binary
+func (d *doctor) checkPath() result {
+	r := result{Check: "path", Subject: pluginBinaryName}
+
+	onPath, err := d.lookPath(pluginBinaryName)
+	if err != nil {
+		r.Status = statusWarn
+		r.Detail = "not on PATH; generate-kubeconfig writes the absolute path of this binary instead, which breaks if the binary moves"
+		return r
+	}
+
+	self, err := d.executable()
+	if err != nil {
+		r.Status, r.Detail = statusPass, onPath
+		return r
+	}
+	if resolvedSelf, err := filepath.EvalSymlinks(self); err == nil {
+		self = resolvedSelf
+	}
+	resolved := onPath
+	if resolvedPath, err := filepath.EvalSymlinks(onPath); err == nil {
+		resolved = resolvedPath
+	}
+
+	if resolved != self {
+		r.Status = statusWarn
+		r.Detail = fmt.Sprintf("PATH runs %s, not this binary (%s); run check-kubeconfig to compare versions", onPath, self)
+		return r
+	}
+	r.Status, r.Detail = statusPass, onPath
+	return r
+}
+
+// checkClock compares the local clock with the Date header of the provider's endpoint
+func (d *doctor) checkClock(ctx context.Context) result {
+	url := d.clockURL
+	if url == "" {
+		url = providerEnvironments[d.provider].clockURL
+	}
+	if url == "" {
+		url = defaultClockURL
+	}
+	r := result{Check: "clock", Subject: url}
+
+	if d.skipNetwork {
+		r.Status, r.Detail = statusWarn, "not checked with --dry-run"
+		return r
+	}
+
+	skew, err := d.clockSkew(ctx, url, d.clockTimeout)
+	if err != nil {
+		r.Status, r.Detail = statusWarn, "could not measure clock skew: "+err.Error()
+		return r
+	}
+
+	abs := skew
+	if abs < 0 {
+		abs = -abs
+	}
+	direction := "ahead of"
+	if skew < 0 {
+		direction = "behind"
+	}
+	r.Detail = fmt.Sprintf("local clock is %s %s the server", abs.Round(time.Second), direction)
+
+	switch {
+	case abs >= clockSkewFail:
+		r.Status = statusFail
+		r.Detail += "; signed requests and presigned URLs will be rejected, synchronize the clock with NTP"
+	case skew < -provider.ClockSkew():
+		// A clock behind the server sees tokens as valid for longer than they are
+		r.Status = statusWarn
+		r.Detail += fmt.Sprintf("; exceeds the %s --clock-skew tolerance, cached tokens may be used after they expire", provider.ClockSkew())
+	case abs >= clockSkewWarn:
+		r.Status = statusWarn
+	default:
+		r.Status = statusPass
+	}
+	return r
+}
+
+// checkProxyHeader sends a large synthetic Authorization header to the probe URL through
+// the proxy and fails when it was truncated or rejected on the way
+func (d *doctor) checkProxyHeader(ctx context.Context) result {
+	r := result{Check: "proxy-header", Subject: d.probe.URL}
+
+	if d.skipNetwork {
+		r.Status, r.Detail = statusWarn, "not checked with --dry-run"
+		return r
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, headerProbeTimeout)
+	defer cancel()
+	probe, err := d.probeHeader(ctx, d.probe)
+	if err != nil {
+		r.Status, r.Detail = statusWarn, "could not probe the proxy: "+err.Error()
+		return r
+	}
+
+	r.Detail = probe.Detail
+	if probe.Proxy != "" {
+		r.Detail += "; through proxy " + probe.Proxy
+	}
+	if !probe.OK() {
+		r.Status = statusFail
+		r.Detail += "; large tokens such as EKS tokens will be rejected, raise the proxy header limit or bypass it with --no-proxy"
+		return r
+	}
+	r.Status = statusPass
+	return r
+}
+
+// httpClockSkew returns how far the local clock is ahead of the server's Date header,
+// measured against the midpoint of the request
+func httpClockSkew(ctx context.Context, url string, timeout time.Duration) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	start := time.Now()
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	end := time.Now()
+
+	serverTime, err := http.ParseTime(resp.Header.Get("Date"))
+	if err != nil {
+		return 0, fmt.Errorf("response has no valid Date header")
+	}
+
+	local := start.Add(end.Sub(start) / 2)
+	return local.Sub(serverTime), nil
+}
+
+// writeReport prints the report as a table or as JSON
+func writeReport(w io.Writer, output string, rep report) error {
+	if output == "json" {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(rep)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "CHECK\tSUBJECT\tSTATUS\tDETAIL")
+	for _, r := range rep.Results {
+		detail := r.Detail
+		if detail == "" {
+			detail = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", r.Check, r.Subject, r.Status, detail)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "\nOverall: %s\n", rep.Status)
+	return err
+}
@@ -0,0 +1,482 @@
+package kubeconfig
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/cmd/provider/common"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+)
+
+// defaultBatchConcurrency bounds how many clusters are looked up at once in batch mode
+const defaultBatchConcurrency = 4
+
+// batchFile is the --from-file document listing the clusters of a batch kubeconfig
+type batchFile struct {
+	Clusters []batchCluster `yaml:"clusters"`
+}
+
+// batchCluster is one cluster of a batch kubeconfig. Global flags such as
+// --credentials-file, --exec-env, --exec-command, --bound-audience and
+// --skip-credential-check apply to every cluster; --proxy-url applies to clusters that
+// do not set proxy_url. With --credentials-map, each cluster looks up its own entry.
+type batchCluster struct {
+	Provider       string `yaml:"provider"`
+	Name           string `yaml:"name"`
+	Region         string `yaml:"region"`
+	ProjectID      string `yaml:"project_id"`
+	AccountID      string `yaml:"account_id"`
+	SubscriptionID string `yaml:"subscription_id"`
+	TenantID       string `yaml:"tenant_id"`
+	ResourceGroup  string `yaml:"resource_group"`
+	AzureCloud     string `yaml:"azure_cloud"`
+	TenancyID      string `yaml:"tenancy_id"`
+	UserID         string `yaml:"user_id"`
+	CompartmentID  string `yaml:"compartment_id"`
+	// ContextName defaults to Name; set it when two providers use the same cluster name
+	ContextName string `yaml:"context_name"`
+	// ProxyURL and TLSServerName are written to the kubeconfig cluster entry
+	ProxyURL      string `yaml:"proxy_url"`
+	TLSServerName string `yaml:"tls_server_name"`
+}
+
+// contextName returns the kubeconfig cluster and context name of the cluster
+func (c batchCluster) contextName() string {
+	if c.ContextName != "" {
+		return c.ContextName
+	}
+	return c.Name
+}
+
+// flags returns a copy of the global flags with the cluster's settings applied
+func (c batchCluster) flags(global *common.Flags) *common.Flags {
+	flags := *global
+	flags.ProviderName = c.Provider
+	flags.ClusterName = c.Name
+	flags.Region = c.Region
+	flags.ProjectID = c.ProjectID
+	flags.AccountID = c.AccountID
+	flags.SubscriptionID = c.SubscriptionID
+	flags.TenantID = c.TenantID
+	flags.ResourceGroup = c.ResourceGroup
+	// The --azure-cloud flag applies to clusters that do not set their own
+	if c.AzureCloud != "" {
+		flags.AzureCloud = c.AzureCloud
+	}
+	flags.TenancyID = c.TenancyID
+	flags.UserID = c.UserID
+	flags.CompartmentID = c.CompartmentID
+	return &flags
+}
+
+// batchResult is the outcome of generating one cluster's entry
+type batchResult struct {
+	cluster batchCluster
+	entry   kubeconfigEntry
+	err     error
+}
+
+// loadBatchFile reads and validates a batch file, returning its clusters sorted by context name
+func loadBatchFile(path string) ([]batchCluster, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read clusters file: %w", err)
+	}
+
+	var file batchFile
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&file); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to parse clusters file %s: %w", path, err)
+	}
+	if len(file.Clusters) == 0 {
+		return nil, fmt.Errorf("clusters file %s lists no clusters", path)
+	}
+
+	seen := make(map[string]int, len(file.Clusters))
+	for i, cluster := range file.Clusters {
+		if cluster.Name == "" {
+			return nil, fmt.Errorf("clusters file %s: cluster %d has no name", path, i+1)
+		}
+		if cluster.Provider == "" {
+			return nil, fmt.Errorf("clusters file %s: cluster %s has no provider", path, cluster.Name)
+		}
+		if err := provider.CheckRegistered(cluster.Provider); err != nil {
+			return nil, fmt.Errorf("clusters file %s: cluster %s: %w", path, cluster.Name, err)
+		}
+		if cluster.ProxyURL != "" {
+			if err := validateProxyURL(cluster.ProxyURL); err != nil {
+				return nil, fmt.Errorf("clusters file %s: cluster %s: invalid proxy_url: %w", path, cluster.Name, err)
+			}
+		}
+		if first, ok := seen[cluster.contextName()]; ok {
+			return nil, fmt.Errorf("clusters file %s: clusters %d and %d share the context name %s; set context_name to tell them apart",
+				path, first+1, i+1, cluster.contextName())
+		}
+		seen[cluster.contextName()] = i
+	}
+
+	clusters := file.Clusters
+	sort.Slice(clusters, func(i, j int) bool { return clusters[i].contextName() < clusters[j].contextName() })
+	return clusters, nil
+}
+
+// batchMode returns the flag that selects batch mode, or "" when a single cluster is
+// generated
+func batchMode() string {
+	switch {
+	case fromFile != "":
+		return "--from-file"
+	case clustersFile != "":
+		return "--clusters-file"
+	case len(clusterNames) > 1:
+		return "repeated --cluster-name"
+	}
+	return ""
+}
+
+// loadNamedClusters returns a batch cluster for every --cluster-name and every name in
+// --clusters-file, sorted by name. The clusters share the provider flags, which are
+// validated for each cluster before any is looked up.
+func loadNamedClusters(flags *common.Flags) ([]batchCluster, error) {
+	names := append([]string(nil), clusterNames...)
+	if clustersFile != "" {
+		listed, err := readClusterNames(clustersFile)
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, listed...)
+	}
+	if err := provider.CheckRegistered(flags.ProviderName); err != nil {
+		return nil, err
+	}
+
+	clusters := make([]batchCluster, 0, len(names))
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		if seen[name] {
+			return nil, fmt.Errorf("cluster %s is listed more than once", name)
+		}
+		seen[name] = true
+
+		cluster := batchCluster{
+			Provider:       flags.ProviderName,
+			Name:           name,
+			Region:         flags.Region,
+			ProjectID:      flags.ProjectID,
+			AccountID:      flags.AccountID,
+			SubscriptionID: flags.SubscriptionID,
+			TenantID:       flags.TenantID,
+			ResourceGroup:  flags.ResourceGroup,
+			AzureCloud:     flags.AzureCloud,
+			TenancyID:      flags.TenancyID,
+			UserID:         flags.UserID,
+			CompartmentID:  flags.CompartmentID,
+		}
+		if _, err := kubeconfigProviderInfo(cluster.flags(flags)); err != nil {
+			return nil, fmt.Errorf("cluster %s: %w", name, err)
+		}
+		clusters = append(clusters, cluster)
+	}
+
+	sort.Slice(clusters, func(i, j int) bool { return clusters[i].Name < clusters[j].Name })
+	return clusters, nil
+}
+
+// readClusterNames reads a --clusters-file: one cluster name per line, ignoring blank
+// lines and lines starting with #
+func readClusterNames(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read clusters file: %w", err)
+	}
+
+	var names []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		names = append(names, line)
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("clusters file %s lists no clusters", path)
+	}
+	return names, nil
+}
+
+// runBatch generates one kubeconfig holding every cluster listed in --from-file, or
+// named by --clusters-file and --cluster-name
+func runBatch(flags *common.Flags, describe describeFunc) error {
+	mode := batchMode()
+	if clusterInfoFile != "" || clusterEndpoint != "" || clusterCAFile != "" || clusterCAData != "" {
+		return fmt.Errorf("%s cannot be combined with --cluster-info-file, --cluster-endpoint, --cluster-ca-file or --cluster-ca-data", mode)
+	}
+	if batchConcurrency < 1 {
+		return errors.New(errors.ErrInvalidArgument, "--concurrency must be at least 1")
+	}
+	if batchRateLimit < 0 {
+		return errors.New(errors.ErrInvalidArgument, "--rate-limit must not be negative")
+	}
+	if tlsServerName != "" {
+		if fromFile == "" {
+			return errors.New(errors.ErrInvalidArgument, fmt.Sprintf("--tls-server-name cannot be used with %s", mode))
+		}
+		return errors.New(errors.ErrInvalidArgument, "--tls-server-name cannot be used with --from-file; set tls_server_name per cluster in the clusters file")
+	}
+	if err := validateRenderFlags(); err != nil {
+		return err
+	}
+	tmpl, err := loadKubeconfigTemplate(kubeconfigTemplate)
+	if err != nil {
+		return err
+	}
+
+	var clusters []batchCluster
+	if fromFile != "" {
+		clusters, err = loadBatchFile(fromFile)
+	} else {
+		clusters, err = loadNamedClusters(flags)
+	}
+	if err != nil {
+		return err
+	}
+	if boundAudience != "" {
+		for _, cluster := range clusters {
+			if err := provider.CheckAudienceSupported(cluster.Provider); err != nil {
+				return fmt.Errorf("cluster %s: %w", cluster.contextName(), err)
+			}
+		}
+	}
+
+	extraEnv, err := parseExecEnv(execEnv)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := common.SetupSignalHandler()
+	defer cancel()
+
+	log, err := common.CreateLogger(flags)
+	if err != nil {
+		return fmt.Errorf("failed to create logger: %w", err)
+	}
+	defer log.Sync()
+
+	// --token-duration applies to every cluster, so it must be within each one's limit
+	for _, cluster := range clusters {
+		if _, err := common.ParseTokenDuration(cluster.flags(flags)); err != nil {
+			return fmt.Errorf("cluster %s: %w", cluster.contextName(), err)
+		}
+	}
+	if err := common.CheckTokenDuration(flags, log); err != nil {
+		return err
+	}
+
+	if flags.DryRun {
+		for _, cluster := range clusters {
+			clusterFlags := cluster.flags(flags)
+			if _, err := kubeconfigProviderInfo(clusterFlags); err != nil {
+				return fmt.Errorf("dry run: cluster %s: %w", cluster.contextName(), err)
+			}
+			details := map[string]string{
+				"context":      cluster.contextName(),
+				"output":       outputFile,
+				"cluster-info": "cloud API",
+			}
+			if outputFile == "" {
+				details["output"] = "stdout"
+			}
+			if err := common.RunDryRun(ctx, clusterFlags, log, os.Stdout, "add a cluster to a batch kubeconfig", details); err != nil {
+				return fmt.Errorf("cluster %s: %w", cluster.contextName(), err)
+			}
+		}
+		return nil
+	}
+
+	log.Info("Generating batch kubeconfig",
+		logger.String("mode", mode),
+		logger.Int("clusters", len(clusters)),
+		logger.Int("concurrency", batchConcurrency),
+		logger.Float64("rate_limit", batchRateLimit),
+	)
+
+	results := generateBatch(ctx, flags, clusters, extraEnv, log, describe)
+
+	var entries []kubeconfigEntry
+	var failed []batchResult
+	for _, result := range results {
+		if result.err != nil {
+			failed = append(failed, result)
+			continue
+		}
+		entries = append(entries, result.entry)
+	}
+
+	if len(entries) > 0 {
+		kubeconfig, err := renderKubeconfig(entries, entries[0].Name, tmpl)
+		if err != nil {
+			return fmt.Errorf("failed to generate kubeconfig: %w", err)
+		}
+		if err := writeKubeconfig(ctx, log, common.StatusOutput(flags), kubeconfig, entries); err != nil {
+			return err
+		}
+	}
+
+	// With --quiet the summary is only written when it reports failures
+	if len(failed) > 0 || !flags.Quiet {
+		writeBatchSummary(os.Stderr, len(results), failed)
+	}
+
+	switch {
+	case len(entries) == 0:
+		return fmt.Errorf("all %d clusters failed", len(results))
+	case len(failed) > 0 && failFast:
+		return fmt.Errorf("%d of %d clusters failed (--fail-fast)", len(failed), len(results))
+	}
+	return nil
+}
+
+// describeFunc looks up one cluster's endpoint and CA
+type describeFunc func(ctx context.Context, flags *common.Flags, log logger.Logger) (*common.ClusterInfo, error)
+
+// cachedDescribe returns a describeFunc that serves clusters from the cluster info cache
+// and looks the others up with describe. A nil cache returns describe unchanged.
+func cachedDescribe(cache *common.ClusterInfoCache, describe describeFunc) describeFunc {
+	if cache == nil {
+		return describe
+	}
+	return func(ctx context.Context, flags *common.Flags, log logger.Logger) (*common.ClusterInfo, error) {
+		info, _, err := common.DescribeClusterCached(cache, flags, log, func() (*common.ClusterInfo, error) {
+			return describe(ctx, flags, log)
+		})
+		return info, err
+	}
+}
+
+// generateBatch builds the entries for clusters with a bounded worker pool, admitting
+// lookups at --rate-limit when it is set. Results keep the order of clusters. With
+// --fail-fast, clusters not yet started after the first failure are skipped.
+func generateBatch(ctx context.Context, global *common.Flags, clusters []batchCluster, extraEnv []execEnvVar, log logger.Logger, describe describeFunc) []batchResult {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([]batchResult, len(clusters))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+
+	limiter := newRateLimiter(batchRateLimit)
+	defer limiter.stop()
+
+	for w := 0; w < batchConcurrency && w < len(clusters); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if err := limiter.wait(ctx); err != nil || ctx.Err() != nil {
+					results[i] = batchResult{cluster: clusters[i], err: fmt.Errorf("skipped: %w", ctx.Err())}
+					continue
+				}
+				results[i] = generateBatchEntry(ctx, global, clusters[i], extraEnv, log, describe)
+				if results[i].err != nil && failFast {
+					cancel()
+				}
+			}
+		}()
+	}
+
+	for i := range clusters {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
+// generateBatchEntry builds the kubeconfig entry of a single batch cluster
+func generateBatchEntry(ctx context.Context, global *common.Flags, cluster batchCluster, extraEnv []execEnvVar, log logger.Logger, describe describeFunc) batchResult {
+	result := batchResult{cluster: cluster}
+	name := cluster.contextName()
+	clusterFlags := cluster.flags(global)
+
+	providerInfo, err := kubeconfigProviderInfo(clusterFlags)
+	if err != nil {
+		result.err = errors.Wrap(errors.ErrInvalidArgument, err, "invalid cluster settings")
+		log.Error("Cluster failed", logger.String("context", name), logger.Error(result.err))
+		return result
+	}
+	if err := applyKubeconfigCredentialsMap(clusterFlags, providerInfo); err != nil {
+		result.err = err
+		log.Error("Cluster failed", logger.String("context", name), logger.Error(err))
+		return result
+	}
+	if clusterFlags.StrictPermissions {
+		providerInfo["strict-permissions"] = "true"
+	}
+	if boundAudience != "" {
+		providerInfo["audience"] = boundAudience
+	}
+	if skipCredCheck && cluster.Provider == "aws" {
+		providerInfo["skip-credential-check"] = "true"
+	}
+	if clusterFlags.PreferSecret && cluster.Provider == "azure" {
+		providerInfo["prefer-secret"] = "true"
+	}
+	addRenderOptions(providerInfo)
+	if cluster.ProxyURL != "" {
+		providerInfo["proxy-url"] = cluster.ProxyURL
+	}
+	if cluster.TLSServerName != "" {
+		providerInfo["tls-server-name"] = cluster.TLSServerName
+	}
+
+	log.Info("Looking up cluster",
+		logger.String("context", name),
+		logger.String("provider", cluster.Provider),
+		logger.String("cluster", cluster.Name),
+	)
+
+	info, err := describe(ctx, clusterFlags, log)
+	if err != nil {
+		result.err = err
+		log.Error("Cluster failed", logger.String("context", name), logger.Error(err))
+		return result
+	}
+
+	caCert, err := kubeconfigCA(info)
+	if err != nil {
+		result.err = err
+		log.Error("Cluster failed", logger.String("context", name), logger.Error(err))
+		return result
+	}
+
+	result.entry = newKubeconfigEntry(name, kubeconfigUserName+"-"+name, info.Endpoint, caCert, providerInfo, extraEnv)
+	log.Info("Cluster added",
+		logger.String("context", name),
+		logger.String("endpoint", info.Endpoint),
+	)
+	return result
+}
+
+// writeBatchSummary reports how many clusters were generated and why the others failed
+func writeBatchSummary(w io.Writer, total int, failed []batchResult) {
+	fmt.Fprintf(w, "Clusters: %d generated, %d failed\n", total-len(failed), len(failed))
+	for _, result := range failed {
+		code := ""
+		if c := errors.GetCode(result.err); c != errors.ErrUnknown {
+			code = fmt.Sprintf("[%s] ", c)
+		}
+		fmt.Fprintf(w, "❌ %s (%s): %s%v\n", result.cluster.contextName(), result.cluster.Provider, code, result.err)
+	}
+}
@@ -0,0 +1,676 @@
+package kubeconfig
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/cmd/provider/common"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+)
+
+const testBatchFile = `clusters:
+  - provider: gcp
+    name: gke-prod
+    region: us-central1
+    project_id: my-project
+  - provider: aws
+    name: eks-prod
+    region: us-east-1
+  - provider: azure
+    name: aks-prod
+    subscription_id: sub
+    tenant_id: tenant
+    resource_group: rg
+`
+
+// setBatchFlags sets the package-level batch flags for one test and restores them afterwards
+func setBatchFlags(t *testing.T, file, output string, concurrency int, fast bool) {
+	t.Helper()
+	oldFile, oldOutput, oldConcurrency, oldFast := fromFile, outputFile, batchConcurrency, failFast
+	t.Cleanup(func() {
+		fromFile, outputFile, batchConcurrency, failFast = oldFile, oldOutput, oldConcurrency, oldFast
+	})
+	fromFile, outputFile, batchConcurrency, failFast = file, output, concurrency, fast
+}
+
+func writeBatchFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "clusters.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+// fakeDescribe returns cluster info for every cluster except those listed in failing
+func fakeDescribe(failing ...string) describeFunc {
+	return func(ctx context.Context, flags *common.Flags, log logger.Logger) (*common.ClusterInfo, error) {
+		for _, name := range failing {
+			if flags.ClusterName == name {
+				return nil, errors.New(errors.ErrClusterNotFound, "cluster not found").WithField("cluster", name)
+			}
+		}
+		return &common.ClusterInfo{
+			Endpoint:             "https://" + flags.ClusterName + ".example.com",
+			CertificateAuthority: "Y2E=",
+		}, nil
+	}
+}
+
+func TestCachedDescribe(t *testing.T) {
+	var lookups atomic.Int32
+	counting := func(ctx context.Context, flags *common.Flags, log logger.Logger) (*common.ClusterInfo, error) {
+		lookups.Add(1)
+		return fakeDescribe("missing")(ctx, flags, log)
+	}
+	cache := common.NewClusterInfoCache(t.TempDir(), time.Hour)
+	describe := cachedDescribe(cache, counting)
+
+	flags := &common.Flags{ProviderName: "gcp", ClusterName: "gke-prod", ProjectID: "my-project", Region: "us-central1"}
+	for i := 0; i < 2; i++ {
+		info, err := describe(context.Background(), flags, logger.Nop())
+		require.NoError(t, err)
+		assert.Equal(t, "https://gke-prod.example.com", info.Endpoint)
+	}
+	assert.Equal(t, int32(1), lookups.Load(), "the second lookup is served from the cache")
+
+	// Failed lookups are not cached
+	missing := &common.Flags{ProviderName: "gcp", ClusterName: "missing", ProjectID: "my-project", Region: "us-central1"}
+	for i := 0; i < 2; i++ {
+		_, err := describe(context.Background(), missing, logger.Nop())
+		require.Error(t, err)
+	}
+	assert.Equal(t, int32(3), lookups.Load())
+}
+
+// captureStderr returns what fn writes to the process stderr
+func captureStderr(t *testing.T, fn func()) string {
+	t.Helper()
+
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	original := os.Stderr
+	os.Stderr = w
+	defer func() { os.Stderr = original }()
+
+	fn()
+	require.NoError(t, w.Close())
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestLoadBatchFile(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		wantNames []string
+		wantErr   string
+	}{
+		{
+			name:      "sorted by context name",
+			content:   testBatchFile,
+			wantNames: []string{"aks-prod", "eks-prod", "gke-prod"},
+		},
+		{
+			name: "context name overrides cluster name",
+			content: `clusters:
+  - {provider: aws, name: prod, region: us-east-1, context_name: z-aws-prod}
+  - {provider: gcp, name: prod, region: us-central1, project_id: p, context_name: a-gcp-prod}
+`,
+			wantNames: []string{"a-gcp-prod", "z-aws-prod"},
+		},
+		{
+			name: "duplicate context name",
+			content: `clusters:
+  - {provider: aws, name: prod, region: us-east-1}
+  - {provider: gcp, name: prod, region: us-central1, project_id: p}
+`,
+			wantErr: "share the context name prod",
+		},
+		{
+			name:    "no clusters",
+			content: "clusters: []\n",
+			wantErr: "lists no clusters",
+		},
+		{
+			name:    "empty file",
+			content: "",
+			wantErr: "lists no clusters",
+		},
+		{
+			name:    "missing name",
+			content: "clusters:\n  - {provider: aws}\n",
+			wantErr: "cluster 1 has no name",
+		},
+		{
+			name:    "missing provider",
+			content: "clusters:\n  - {name: prod}\n",
+			wantErr: "cluster prod has no provider",
+		},
+		{
+			name:    "unknown provider",
+			content: "clusters:\n  - {name: prod, provider: openstack}\n",
+			wantErr: "unsupported provider",
+		},
+		{
+			name:    "unknown field",
+			content: "clusters:\n  - {name: prod, provider: aws, projectid: p}\n",
+			wantErr: "field projectid not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clusters, err := loadBatchFile(writeBatchFile(t, tt.content))
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			var names []string
+			for _, cluster := range clusters {
+				names = append(names, cluster.contextName())
+			}
+			assert.Equal(t, tt.wantNames, names)
+		})
+	}
+}
+
+func TestGenerateBatch_BoundedConcurrency(t *testing.T) {
+	setBatchFlags(t, "", "", 2, false)
+
+	clusters := make([]batchCluster, 8)
+	for i := range clusters {
+		clusters[i] = batchCluster{Provider: "aws", Name: fmt.Sprintf("eks-%d", i), Region: "us-east-1"}
+	}
+
+	var inFlight, maxInFlight int32
+	var mu sync.Mutex
+	describe := func(ctx context.Context, flags *common.Flags, log logger.Logger) (*common.ClusterInfo, error) {
+		n := atomic.AddInt32(&inFlight, 1)
+		mu.Lock()
+		if n > maxInFlight {
+			maxInFlight = n
+		}
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		atomic.AddInt32(&inFlight, -1)
+		return fakeDescribe()(ctx, flags, log)
+	}
+
+	results := generateBatch(context.Background(), &common.Flags{}, clusters, nil, logger.Nop(), describe)
+
+	require.Len(t, results, len(clusters))
+	for i, result := range results {
+		require.NoError(t, result.err)
+		assert.Equal(t, clusters[i].Name, result.entry.Name, "results keep the cluster order")
+	}
+	assert.LessOrEqual(t, maxInFlight, int32(2))
+}
+
+func TestGenerateBatch_FailFastSkipsRemaining(t *testing.T) {
+	setBatchFlags(t, "", "", 1, true)
+
+	clusters := []batchCluster{
+		{Provider: "aws", Name: "a", Region: "us-east-1"},
+		{Provider: "aws", Name: "b", Region: "us-east-1"},
+		{Provider: "aws", Name: "c", Region: "us-east-1"},
+	}
+
+	results := generateBatch(context.Background(), &common.Flags{}, clusters, nil, logger.Nop(), fakeDescribe("a"))
+
+	assert.True(t, errors.Is(results[0].err, errors.ErrClusterNotFound))
+	for _, result := range results[1:] {
+		require.Error(t, result.err)
+		assert.Contains(t, result.err.Error(), "skipped")
+	}
+}
+
+func TestGenerateBatch_CredentialsMap(t *testing.T) {
+	setBatchFlags(t, "", "", 1, false)
+	t.Setenv("HFCP_CREDENTIALS_FILE", "")
+	path := filepath.Join(t.TempDir(), "credentials-map.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("aws/eks-a:\n  credentials_file: /secrets/a\naws/eks-b:\n  credentials_file: /secrets/b\n"), 0600))
+
+	clusters := []batchCluster{
+		{Provider: "aws", Name: "eks-a", Region: "us-east-1"},
+		{Provider: "aws", Name: "eks-b", Region: "us-east-1"},
+		{Provider: "aws", Name: "eks-c", Region: "us-east-1"},
+	}
+	var mu sync.Mutex
+	looked := map[string]string{}
+	describe := func(ctx context.Context, flags *common.Flags, log logger.Logger) (*common.ClusterInfo, error) {
+		mu.Lock()
+		looked[flags.ClusterName] = flags.CredentialsFile
+		mu.Unlock()
+		return fakeDescribe()(ctx, flags, log)
+	}
+
+	global := &common.Flags{CredentialsMap: path, Viper: common.NewViper(nil)}
+	results := generateBatch(context.Background(), global, clusters, nil, logger.Nop(), describe)
+
+	assert.Equal(t, map[string]string{"eks-a": "/secrets/a", "eks-b": "/secrets/b"}, looked, "each cluster is looked up with its entry")
+	for _, result := range results[:2] {
+		require.NoError(t, result.err)
+		assert.Equal(t, []execEnvVar{{Name: "HFCP_CREDENTIALS_MAP", Value: path}}, result.entry.Env)
+	}
+	assert.True(t, errors.Is(results[2].err, errors.ErrCredentialNotFound), "got %v", results[2].err)
+	assert.Empty(t, global.CredentialsFile, "the global flags are not changed")
+}
+
+func TestRunBatch(t *testing.T) {
+	tests := []struct {
+		name         string
+		failing      []string
+		failFast     bool
+		wantErr      string
+		wantContexts []string
+	}{
+		{
+			name:         "all clusters succeed",
+			wantContexts: []string{"aks-prod", "eks-prod", "gke-prod"},
+		},
+		{
+			name:         "partial failure still writes successful clusters",
+			failing:      []string{"eks-prod"},
+			wantContexts: []string{"aks-prod", "gke-prod"},
+		},
+		{
+			name:         "partial failure with fail-fast exits non-zero",
+			failing:      []string{"gke-prod"},
+			failFast:     true,
+			wantErr:      "clusters failed (--fail-fast)",
+			wantContexts: []string{"aks-prod", "eks-prod"},
+		},
+		{
+			name:    "all clusters fail",
+			failing: []string{"aks-prod", "eks-prod", "gke-prod"},
+			wantErr: "all 3 clusters failed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := filepath.Join(t.TempDir(), "kubeconfig")
+			// Concurrency 1 makes fail-fast deterministic: gke-prod is looked up last
+			setBatchFlags(t, writeBatchFile(t, testBatchFile), output, 1, tt.failFast)
+
+			err := runBatch(&common.Flags{LogLevel: "error", CredentialsFile: "/creds"}, fakeDescribe(tt.failing...))
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+
+			if len(tt.wantContexts) == 0 {
+				_, statErr := os.Stat(output)
+				assert.True(t, os.IsNotExist(statErr), "nothing is written when every cluster fails")
+				return
+			}
+
+			data, err := os.ReadFile(output)
+			require.NoError(t, err)
+
+			var kubeconfig struct {
+				CurrentContext string `yaml:"current-context"`
+				Contexts       []struct {
+					Name    string `yaml:"name"`
+					Context struct {
+						Cluster string `yaml:"cluster"`
+						User    string `yaml:"user"`
+					} `yaml:"context"`
+				} `yaml:"contexts"`
+				Users []struct {
+					Name string `yaml:"name"`
+					User struct {
+						Exec struct {
+							Args []string `yaml:"args"`
+						} `yaml:"exec"`
+					} `yaml:"user"`
+				} `yaml:"users"`
+			}
+			require.NoError(t, yaml.Unmarshal(data, &kubeconfig))
+
+			var contexts []string
+			for _, c := range kubeconfig.Contexts {
+				contexts = append(contexts, c.Name)
+				assert.Equal(t, c.Name, c.Context.Cluster)
+				assert.Equal(t, "hyperfleet-user-"+c.Name, c.Context.User)
+			}
+			assert.Equal(t, tt.wantContexts, contexts)
+			assert.Equal(t, tt.wantContexts[0], kubeconfig.CurrentContext)
+			for _, u := range kubeconfig.Users {
+				assert.Contains(t, u.User.Exec.Args, "--cluster-name="+u.Name[len("hyperfleet-user-"):])
+			}
+
+			// Regenerating the same clusters produces an identical, diff-friendly file
+			// whatever order the workers finish in
+			if tt.failFast {
+				return
+			}
+			setBatchFlags(t, fromFile, filepath.Join(t.TempDir(), "again"), 3, false)
+			_ = runBatch(&common.Flags{LogLevel: "error", CredentialsFile: "/creds"}, fakeDescribe(tt.failing...))
+			again, err := os.ReadFile(outputFile)
+			require.NoError(t, err)
+			assert.Equal(t, string(data), string(again))
+		})
+	}
+}
+
+func TestRunBatch_BoundAudience(t *testing.T) {
+	const audience = "https://gateway.example.com"
+	oldAudience := boundAudience
+	t.Cleanup(func() { boundAudience = oldAudience })
+	boundAudience = audience
+
+	tests := []struct {
+		provider  string
+		settings  string
+		supported bool
+	}{
+		{provider: "gcp", settings: "region: us-central1\n    project_id: my-project", supported: true},
+		{provider: "aws", settings: "region: us-east-1", supported: true},
+		{provider: "azure", settings: "subscription_id: sub\n    tenant_id: tenant\n    resource_group: rg", supported: true},
+		{provider: "oci", settings: "region: us-ashburn-1"},
+		{provider: "digitalocean", settings: "context_name: doks"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.provider, func(t *testing.T) {
+			content := fmt.Sprintf("clusters:\n  - provider: %s\n    name: my-cluster\n    %s\n", tt.provider, tt.settings)
+			output := filepath.Join(t.TempDir(), "kubeconfig")
+			setBatchFlags(t, writeBatchFile(t, content), output, 1, false)
+
+			err := runBatch(&common.Flags{LogLevel: "error", CredentialsFile: "/creds"}, fakeDescribe())
+
+			if !tt.supported {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), "provider "+tt.provider+" does not support bound audiences")
+				_, statErr := os.Stat(output)
+				assert.True(t, os.IsNotExist(statErr), "nothing is written when the audience is rejected")
+				return
+			}
+
+			require.NoError(t, err)
+			data, err := os.ReadFile(output)
+			require.NoError(t, err)
+			var kubeconfig struct {
+				Users []struct {
+					User struct {
+						Exec struct {
+							Args []string `yaml:"args"`
+						} `yaml:"exec"`
+					} `yaml:"user"`
+				} `yaml:"users"`
+			}
+			require.NoError(t, yaml.Unmarshal(data, &kubeconfig))
+			require.Len(t, kubeconfig.Users, 1)
+			assert.Contains(t, kubeconfig.Users[0].User.Exec.Args, "--audience="+audience)
+		})
+	}
+}
+
+func TestRunBatch_Quiet(t *testing.T) {
+	tests := []struct {
+		name       string
+		failing    []string
+		wantStderr []string
+	}{
+		{
+			name: "success writes nothing",
+		},
+		{
+			name:       "failures are still reported",
+			failing:    []string{"eks-prod"},
+			wantStderr: []string{"1 failed", "eks-prod"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := filepath.Join(t.TempDir(), "kubeconfig")
+			setBatchFlags(t, writeBatchFile(t, testBatchFile), output, 1, false)
+
+			var err error
+			stderr := captureStderr(t, func() {
+				err = runBatch(&common.Flags{LogLevel: "error", Quiet: true, CredentialsFile: "/creds"}, fakeDescribe(tt.failing...))
+			})
+			require.NoError(t, err)
+			if len(tt.wantStderr) == 0 {
+				assert.Empty(t, stderr)
+				return
+			}
+			for _, want := range tt.wantStderr {
+				assert.Contains(t, stderr, want)
+			}
+		})
+	}
+}
+
+func TestRun_OutputCAFileRejectedInBatchMode(t *testing.T) {
+	setBatchFlags(t, writeBatchFile(t, "clusters: []\n"), "", defaultBatchConcurrency, false)
+	old := outputCAFile
+	t.Cleanup(func() { outputCAFile = old })
+	outputCAFile = filepath.Join(t.TempDir(), "ca.pem")
+
+	err := run(&common.Flags{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--output-ca-file cannot be used with --from-file")
+	assert.NoFileExists(t, outputCAFile)
+}
+
+func TestRun_VerifyRejectedInBatchMode(t *testing.T) {
+	setBatchFlags(t, writeBatchFile(t, "clusters: []\n"), "", defaultBatchConcurrency, false)
+	old := verify
+	t.Cleanup(func() { verify = old })
+	verify = true
+
+	err := run(&common.Flags{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--verify cannot be used with --from-file")
+}
+
+func TestRunBatch_ClusterOverrides(t *testing.T) {
+	oldProxy := proxyURL
+	t.Cleanup(func() { proxyURL = oldProxy })
+	proxyURL = "http://proxy.example.com:3128"
+
+	content := testBatchFile + `  - provider: aws
+    name: eks-private
+    region: us-east-1
+    proxy_url: socks5://bastion.example.com:1080
+    tls_server_name: api.eks-private.internal
+`
+	output := filepath.Join(t.TempDir(), "kubeconfig")
+	setBatchFlags(t, writeBatchFile(t, content), output, 2, false)
+
+	require.NoError(t, runBatch(&common.Flags{LogLevel: "error", CredentialsFile: "/creds"}, fakeDescribe()))
+	data, err := os.ReadFile(output)
+	require.NoError(t, err)
+
+	var doc kubeconfigDocument
+	require.NoError(t, yaml.Unmarshal(data, &doc))
+	clusters := make(map[string]clusterConfig)
+	for _, cluster := range doc.Clusters {
+		clusters[cluster.Name] = cluster.Cluster
+	}
+	assert.Equal(t, "http://proxy.example.com:3128", clusters["gke-prod"].ProxyURL, "--proxy-url applies to clusters without proxy_url")
+	assert.Empty(t, clusters["gke-prod"].TLSServerName)
+	assert.Equal(t, "socks5://bastion.example.com:1080", clusters["eks-private"].ProxyURL)
+	assert.Equal(t, "api.eks-private.internal", clusters["eks-private"].TLSServerName)
+}
+
+func TestRunBatch_RenderFlagErrors(t *testing.T) {
+	t.Run("tls-server-name", func(t *testing.T) {
+		old := tlsServerName
+		t.Cleanup(func() { tlsServerName = old })
+		tlsServerName = "api.internal"
+		setBatchFlags(t, writeBatchFile(t, testBatchFile), filepath.Join(t.TempDir(), "kubeconfig"), 1, false)
+
+		err := runBatch(&common.Flags{LogLevel: "error", CredentialsFile: "/creds"}, fakeDescribe())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "set tls_server_name per cluster")
+	})
+
+	t.Run("invalid proxy_url", func(t *testing.T) {
+		content := testBatchFile + "    proxy_url: rg.example.com\n"
+		setBatchFlags(t, writeBatchFile(t, content), filepath.Join(t.TempDir(), "kubeconfig"), 1, false)
+
+		err := runBatch(&common.Flags{LogLevel: "error", CredentialsFile: "/creds"}, fakeDescribe())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "cluster aks-prod: invalid proxy_url")
+	})
+}
+
+// setClusterNames sets --cluster-name and --clusters-file for one test and restores them afterwards
+func setClusterNames(t *testing.T, file string, names ...string) {
+	t.Helper()
+	oldNames, oldFile := clusterNames, clustersFile
+	t.Cleanup(func() { clusterNames, clustersFile = oldNames, oldFile })
+	clusterNames, clustersFile = names, file
+}
+
+func TestRunBatch_ClusterNames(t *testing.T) {
+	tests := []struct {
+		name         string
+		failing      []string
+		failFast     bool
+		wantErr      string
+		wantContexts []string
+		wantStderr   []string
+	}{
+		{
+			name:         "every cluster gets a context",
+			wantContexts: []string{"eks-a", "eks-b", "eks-c"},
+			wantStderr:   []string{"Clusters: 3 generated, 0 failed"},
+		},
+		{
+			name:         "failures are reported without aborting the run",
+			failing:      []string{"eks-b"},
+			wantContexts: []string{"eks-a", "eks-c"},
+			wantStderr:   []string{"Clusters: 2 generated, 1 failed", "❌ eks-b (aws): [ERR_CLUSTER_NOT_FOUND] cluster not found"},
+		},
+		{
+			name:         "failures with fail-fast exit non-zero",
+			failing:      []string{"eks-c"},
+			failFast:     true,
+			wantErr:      "1 of 3 clusters failed (--fail-fast)",
+			wantContexts: []string{"eks-a", "eks-b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := filepath.Join(t.TempDir(), "kubeconfig")
+			// Concurrency 1 makes fail-fast deterministic: eks-c is looked up last
+			setBatchFlags(t, "", output, 1, tt.failFast)
+			namesFile := filepath.Join(t.TempDir(), "clusters.txt")
+			require.NoError(t, os.WriteFile(namesFile, []byte("# production\neks-c\n\n"), 0600))
+			setClusterNames(t, namesFile, "eks-b", "eks-a")
+
+			flags := &common.Flags{LogLevel: "error", CredentialsFile: "/creds", ProviderName: "aws", Region: "us-east-1"}
+			var err error
+			stderr := captureStderr(t, func() {
+				err = runBatch(flags, fakeDescribe(tt.failing...))
+			})
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+			for _, want := range tt.wantStderr {
+				assert.Contains(t, stderr, want)
+			}
+
+			data, err := os.ReadFile(output)
+			require.NoError(t, err)
+			var doc kubeconfigDocument
+			require.NoError(t, yaml.Unmarshal(data, &doc))
+			var contexts []string
+			for _, c := range doc.Contexts {
+				contexts = append(contexts, c.Name)
+			}
+			assert.Equal(t, tt.wantContexts, contexts)
+			for _, cluster := range doc.Clusters {
+				assert.Equal(t, "https://"+cluster.Name+".example.com", cluster.Cluster.Server)
+			}
+		})
+	}
+}
+
+func TestLoadNamedClusters(t *testing.T) {
+	awsFlags := &common.Flags{ProviderName: "aws", Region: "us-east-1", CredentialsFile: "/creds"}
+
+	tests := []struct {
+		name    string
+		flags   *common.Flags
+		names   []string
+		file    string
+		wantErr string
+	}{
+		{
+			name:    "duplicate name",
+			flags:   awsFlags,
+			names:   []string{"eks-a"},
+			file:    "eks-b\neks-a\n",
+			wantErr: "cluster eks-a is listed more than once",
+		},
+		{
+			name:    "empty clusters file",
+			flags:   awsFlags,
+			file:    "# none yet\n",
+			wantErr: "lists no clusters",
+		},
+		{
+			name:    "shared settings are validated per cluster",
+			flags:   &common.Flags{ProviderName: "aws", CredentialsFile: "/creds"},
+			names:   []string{"eks-a", "eks-b"},
+			wantErr: "cluster eks-a:",
+		},
+		{
+			name:    "unknown provider",
+			flags:   &common.Flags{ProviderName: "ibm"},
+			names:   []string{"a", "b"},
+			wantErr: "ibm",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := ""
+			if tt.file != "" {
+				file = filepath.Join(t.TempDir(), "clusters.txt")
+				require.NoError(t, os.WriteFile(file, []byte(tt.file), 0600))
+			}
+			setClusterNames(t, file, tt.names...)
+
+			_, err := loadNamedClusters(tt.flags)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestRun_ClusterNamesRejectedWithFromFile(t *testing.T) {
+	setBatchFlags(t, writeBatchFile(t, testBatchFile), "", defaultBatchConcurrency, false)
+	setClusterNames(t, "", "eks-a", "eks-b")
+
+	err := run(&common.Flags{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--from-file cannot be combined with --clusters-file or repeated --cluster-name")
+}
@@ -0,0 +1,445 @@
+package kubeconfig
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/cmd/provider/common"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/cmd/provider/version"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/filelock"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+)
+
+// pluginBinaryName is the exec command written by generate-kubeconfig. Commands whose
+// base name starts with it, such as hyperfleet-credential-provider-v0.3, are checked.
+const pluginBinaryName = "hyperfleet-credential-provider"
+
+// defaultVersionTimeout bounds each "version" call made against a referenced binary
+const defaultVersionTimeout = 3 * time.Second
+
+// Exec command statuses reported by check-kubeconfig
+const (
+	statusOK              = "ok"
+	statusMissing         = "missing"
+	statusVersionMismatch = "version-mismatch"
+	statusUnknownVersion  = "unknown-version"
+	statusFixed           = "fixed"
+)
+
+// textVersionPattern extracts the version from the text output of older releases
+// that do not support version --output=json
+var textVersionPattern = regexp.MustCompile(`(?m)^\s*Version:\s*(\S+)\s*$`)
+
+// execFinding is the result of checking the exec command of one kubeconfig user
+type execFinding struct {
+	User     string
+	Command  string
+	Resolved string
+	Version  string
+	Status   string
+	Detail   string
+}
+
+// problem reports whether the finding should be fixed by rewriting the command
+func (f execFinding) problem() bool {
+	return f.Status == statusMissing || f.Status == statusVersionMismatch
+}
+
+// execChecker resolves exec commands and compares the versions of the binaries they run
+type execChecker struct {
+	// kubeconfigDir resolves relative commands, as kubectl does
+	kubeconfigDir string
+
+	// currentVersion is the version of this binary
+	currentVersion string
+
+	// fixCommand replaces problem commands when fixing
+	fixCommand string
+
+	// versionTimeout bounds each version call
+	versionTimeout time.Duration
+}
+
+func NewCheckCommand(flags *common.Flags) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "check-kubeconfig",
+		Short: "Find kubeconfig exec commands that point at missing or outdated plugin binaries",
+		Long: `Check the exec plugin commands of a kubeconfig.
+
+Each user whose exec command runs hyperfleet-credential-provider is resolved, either as
+an absolute path or through PATH. The command reports binaries that no longer exist, for
+example an old versioned path left behind by an upgrade, and binaries whose major or minor
+version differs from this one. The version comes from running "version --output=json"
+against the binary. With --fix, those commands are rewritten to run this binary: its bare
+name when PATH resolves the name to this binary, otherwise its absolute path.
+
+The command exits non-zero when a problem remains.`,
+		Example: `  # Check the default kubeconfig
+  hyperfleet-credential-provider check-kubeconfig
+
+  # Rewrite stale commands in a specific kubeconfig
+  hyperfleet-credential-provider check-kubeconfig --kubeconfig=kubeconfig.yaml --fix`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runCheck(flags, os.Stdout)
+		},
+	}
+
+	cmd.Flags().String("kubeconfig", "", "Kubeconfig to check (default: the first file in $KUBECONFIG, then ~/.kube/config)")
+	cmd.Flags().Bool("fix", false, "Rewrite missing or version-mismatched commands to this binary")
+	cmd.Flags().Duration("version-timeout", defaultVersionTimeout, "How long to wait for a referenced binary to print its version")
+
+	return cmd
+}
+
+func runCheck(flags *common.Flags, stdout io.Writer) error {
+	// Bind Viper values to flags (environment variables take precedence if flags not set)
+	common.BindFlagsToViper(flags)
+
+	log, err := common.CreateLogger(flags)
+	if err != nil {
+		return fmt.Errorf("failed to create logger: %w", err)
+	}
+	defer log.Sync()
+
+	path, err := kubeconfigPath(flags.Viper.GetString("kubeconfig"))
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read kubeconfig: %w", err)
+	}
+
+	fixCommand, err := currentCommand()
+	if err != nil {
+		return err
+	}
+	checker := &execChecker{
+		kubeconfigDir:  filepath.Dir(path),
+		currentVersion: version.Version,
+		fixCommand:     fixCommand,
+		versionTimeout: flags.Viper.GetDuration("version-timeout"),
+	}
+
+	ctx, cancel := common.SetupSignalHandler()
+	defer cancel()
+
+	findings, err := checker.check(ctx, data)
+	if err != nil {
+		return fmt.Errorf("failed to check kubeconfig %s: %w", path, err)
+	}
+
+	if flags.Viper.GetBool("fix") {
+		if findings, err = checker.fix(ctx, path, findings); err != nil {
+			return err
+		}
+		log.Info("Kubeconfig exec commands checked",
+			logger.String("file", path),
+			logger.String("fix_command", fixCommand),
+		)
+	}
+
+	writeFindings(stdout, path, findings)
+
+	problems := 0
+	for _, finding := range findings {
+		if finding.problem() {
+			problems++
+		}
+	}
+	if problems > 0 {
+		return fmt.Errorf("%d exec command(s) in %s need fixing; rerun with --fix to point them at %s", problems, path, fixCommand)
+	}
+	return nil
+}
+
+// kubeconfigPath returns the explicit path, or the kubeconfig kubectl would load first
+func kubeconfigPath(explicit string) (string, error) {
+	if explicit != "" {
+		return explicit, nil
+	}
+	for _, path := range filepath.SplitList(os.Getenv("KUBECONFIG")) {
+		if path != "" {
+			return path, nil
+		}
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate the default kubeconfig: %w", err)
+	}
+	return filepath.Join(home, ".kube", "config"), nil
+}
+
+// currentCommand returns the command that runs this binary: its bare name when PATH
+// resolves that name to this binary, otherwise its absolute path
+func currentCommand() (string, error) {
+	self, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate this binary: %w", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(self); err == nil {
+		self = resolved
+	}
+
+	if onPath, err := exec.LookPath(pluginBinaryName); err == nil {
+		if resolved, err := filepath.EvalSymlinks(onPath); err == nil && resolved == self {
+			return pluginBinaryName, nil
+		}
+	}
+	return self, nil
+}
+
+// check returns a finding for every user whose exec command runs the plugin
+func (c *execChecker) check(ctx context.Context, data []byte) ([]execFinding, error) {
+	commands, err := execCommands(data)
+	if err != nil {
+		return nil, err
+	}
+
+	findings := make([]execFinding, 0, len(commands))
+	for _, user := range commands {
+		findings = append(findings, c.checkCommand(ctx, user.name, user.node.Value))
+	}
+	return findings, nil
+}
+
+// checkCommand resolves one exec command and compares the version of the binary it runs
+func (c *execChecker) checkCommand(ctx context.Context, user, command string) execFinding {
+	finding := execFinding{User: user, Command: command}
+
+	resolved, err := c.resolve(command)
+	if err != nil {
+		finding.Status = statusMissing
+		finding.Detail = err.Error()
+		return finding
+	}
+	finding.Resolved = resolved
+
+	found, err := c.binaryVersion(ctx, resolved)
+	if err != nil {
+		finding.Status = statusUnknownVersion
+		finding.Detail = err.Error()
+		return finding
+	}
+	finding.Version = found
+
+	foundMajor, foundMinor, foundOK := version.MajorMinor(found)
+	currentMajor, currentMinor, currentOK := version.MajorMinor(c.currentVersion)
+	switch {
+	case !foundOK || !currentOK:
+		finding.Status = statusOK
+		finding.Detail = fmt.Sprintf("versions %s and %s are not comparable", found, c.currentVersion)
+	case foundMajor != currentMajor || foundMinor != currentMinor:
+		finding.Status = statusVersionMismatch
+		finding.Detail = fmt.Sprintf("runs %s, this binary is %s", found, c.currentVersion)
+	default:
+		finding.Status = statusOK
+	}
+	return finding
+}
+
+// resolve returns the binary a command runs: relative paths are resolved against the
+// kubeconfig directory and bare names through PATH, as kubectl does
+func (c *execChecker) resolve(command string) (string, error) {
+	path := command
+	if strings.ContainsRune(command, filepath.Separator) && !filepath.IsAbs(command) {
+		path = filepath.Join(c.kubeconfigDir, command)
+	}
+
+	resolved, err := exec.LookPath(path)
+	if err != nil {
+		if !strings.ContainsRune(command, filepath.Separator) {
+			return "", fmt.Errorf("%s is not on PATH", command)
+		}
+		return "", fmt.Errorf("%s does not exist or is not executable", path)
+	}
+	return resolved, nil
+}
+
+// binaryVersion asks a binary for its version, falling back to the text output of
+// releases that predate version --output=json
+func (c *execChecker) binaryVersion(ctx context.Context, path string) (string, error) {
+	out, err := c.runVersion(ctx, path, "version", "--output=json")
+	if err == nil {
+		var info version.Info
+		if jsonErr := json.Unmarshal(out, &info); jsonErr == nil && info.Version != "" {
+			return info.Version, nil
+		}
+	}
+	if ctx.Err() != nil {
+		return "", ctx.Err()
+	}
+
+	out, err = c.runVersion(ctx, path, "version")
+	if err != nil {
+		return "", err
+	}
+	if match := textVersionPattern.FindSubmatch(out); match != nil {
+		return string(match[1]), nil
+	}
+	return "", fmt.Errorf("%s version printed no version", path)
+}
+
+func (c *execChecker) runVersion(ctx context.Context, path string, args ...string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.versionTimeout)
+	defer cancel()
+
+	var stdout bytes.Buffer
+	cmd := exec.CommandContext(ctx, path, args...)
+	cmd.Stdout = &stdout
+	// A killed wrapper script may leave children holding stdout open
+	cmd.WaitDelay = time.Second
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, fmt.Errorf("%s version did not answer within %s", path, c.versionTimeout)
+		}
+		return nil, fmt.Errorf("%s version failed: %w", path, err)
+	}
+	return stdout.Bytes(), nil
+}
+
+// fix rewrites the commands of problem findings to c.fixCommand under the kubeconfig lock
+func (c *execChecker) fix(ctx context.Context, path string, findings []execFinding) ([]execFinding, error) {
+	users := make(map[string]bool)
+	for _, finding := range findings {
+		if finding.problem() {
+			users[finding.User] = true
+		}
+	}
+	if len(users) == 0 {
+		return findings, nil
+	}
+
+	err := filelock.Update(ctx, path, filelock.DefaultTimeout, 0600, func(current []byte) ([]byte, error) {
+		return rewriteExecCommands(current, users, c.fixCommand)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fix kubeconfig: %w", err)
+	}
+
+	fixed := make([]execFinding, len(findings))
+	for i, finding := range findings {
+		if finding.problem() {
+			finding.Detail = fmt.Sprintf("was %s: %s", finding.Command, finding.Detail)
+			finding.Status = statusFixed
+			finding.Command = c.fixCommand
+		}
+		fixed[i] = finding
+	}
+	return fixed, nil
+}
+
+// execCommand is the command scalar of a kubeconfig user's exec plugin
+type execCommand struct {
+	name string
+	node *yaml.Node
+}
+
+// execCommands returns the exec commands of users that run the plugin, in file order
+func execCommands(data []byte) ([]execCommand, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return pluginCommands(&doc), nil
+}
+
+// pluginCommands walks users[].user.exec.command of a parsed kubeconfig
+func pluginCommands(doc *yaml.Node) []execCommand {
+	if len(doc.Content) == 0 {
+		return nil
+	}
+
+	var commands []execCommand
+	users := mappingValue(doc.Content[0], "users")
+	if users == nil || users.Kind != yaml.SequenceNode {
+		return nil
+	}
+	for _, user := range users.Content {
+		name := mappingValue(user, "name")
+		command := mappingValue(mappingValue(mappingValue(user, "user"), "exec"), "command")
+		if name == nil || command == nil || command.Kind != yaml.ScalarNode {
+			continue
+		}
+		if !strings.HasPrefix(filepath.Base(command.Value), pluginBinaryName) {
+			continue
+		}
+		commands = append(commands, execCommand{name: name.Value, node: command})
+	}
+	return commands
+}
+
+// mappingValue returns the value node of key in a mapping node, or nil
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// rewriteExecCommands sets the exec command of the given users to command,
+// leaving the rest of the kubeconfig as it is
+func rewriteExecCommands(data []byte, users map[string]bool, command string) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse kubeconfig: %w", err)
+	}
+
+	for _, user := range pluginCommands(&doc) {
+		if users[user.name] {
+			user.node.Value = command
+			user.node.Style = 0
+		}
+	}
+
+	var out bytes.Buffer
+	encoder := yaml.NewEncoder(&out)
+	encoder.SetIndent(4)
+	if err := encoder.Encode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to marshal kubeconfig to YAML: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, fmt.Errorf("failed to marshal kubeconfig to YAML: %w", err)
+	}
+	return out.Bytes(), nil
+}
+
+// writeFindings prints one row per checked exec command
+func writeFindings(w io.Writer, path string, findings []execFinding) {
+	if len(findings) == 0 {
+		fmt.Fprintf(w, "No %s exec commands found in %s\n", pluginBinaryName, path)
+		return
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "USER\tCOMMAND\tVERSION\tSTATUS\tDETAIL")
+	for _, finding := range findings {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", finding.User, finding.Command, valueOrDash(finding.Version), finding.Status, valueOrDash(finding.Detail))
+	}
+	tw.Flush()
+}
+
+func valueOrDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
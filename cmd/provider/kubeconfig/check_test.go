@@ -0,0 +1,291 @@
+package kubeconfig
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/cmd/provider/common"
+)
+
+const (
+	// newPluginScript answers like a release with version --output=json
+	newPluginScript = `#!/bin/sh
+if [ "$2" = "--output=json" ]; then
+  echo '{"version":"v0.5.2","commit":"abc1234"}'
+fi
+`
+
+	// oldPluginScript answers like a release that predates version --output=json
+	oldPluginScript = `#!/bin/sh
+if [ -n "$2" ]; then
+  echo "Error: unknown flag: $2" >&2
+  exit 1
+fi
+printf 'HyperFleet Credential Provider\n  Version:    v0.3.1\n  Commit:     def5678\n'
+`
+
+	// hangingPluginScript never answers
+	hangingPluginScript = `#!/bin/sh
+exec sleep 10
+`
+)
+
+// pluginDirs holds fake plugin binaries; newDir is the only one on PATH
+type pluginDirs struct {
+	newDir, oldDir, hangingDir, kubeconfigDir string
+}
+
+func setupFakePlugins(t *testing.T) pluginDirs {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake plugins are shell scripts")
+	}
+
+	root := t.TempDir()
+	dirs := pluginDirs{
+		newDir:        filepath.Join(root, "new"),
+		oldDir:        filepath.Join(root, "old"),
+		hangingDir:    filepath.Join(root, "hanging"),
+		kubeconfigDir: filepath.Join(root, "kube"),
+	}
+	writePlugin := func(dir, script string) {
+		require.NoError(t, os.MkdirAll(dir, 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, pluginBinaryName), []byte(script), 0755))
+	}
+	writePlugin(dirs.newDir, newPluginScript)
+	writePlugin(dirs.oldDir, oldPluginScript)
+	writePlugin(dirs.hangingDir, hangingPluginScript)
+	writePlugin(filepath.Join(dirs.kubeconfigDir, "bin"), newPluginScript)
+
+	// sh and sleep stay reachable; only the new plugin is found by name
+	t.Setenv("PATH", dirs.newDir+string(os.PathListSeparator)+"/usr/bin"+string(os.PathListSeparator)+"/bin")
+	return dirs
+}
+
+// testKubeconfig returns a kubeconfig with one exec user per command
+func testKubeconfig(t *testing.T, commands map[string]string) []byte {
+	t.Helper()
+
+	users := make([]map[string]interface{}, 0, len(commands))
+	for _, name := range []string{"current", "relative", "stale-path", "not-on-path", "old-binary", "hanging", "other-plugin"} {
+		command, ok := commands[name]
+		if !ok {
+			continue
+		}
+		users = append(users, map[string]interface{}{
+			"name": name,
+			"user": map[string]interface{}{
+				"exec": map[string]interface{}{
+					"apiVersion": "client.authentication.k8s.io/v1",
+					"command":    command,
+					"args":       []string{"get-token", "--provider=aws", "--cluster-name=" + name},
+				},
+			},
+		})
+	}
+	data, err := yaml.Marshal(map[string]interface{}{
+		"apiVersion":      "v1",
+		"kind":            "Config",
+		"users":           users,
+		"current-context": "current",
+	})
+	require.NoError(t, err)
+	return data
+}
+
+func TestExecChecker_Check(t *testing.T) {
+	dirs := setupFakePlugins(t)
+
+	data := testKubeconfig(t, map[string]string{
+		"current":      pluginBinaryName,
+		"relative":     filepath.Join("bin", pluginBinaryName),
+		"stale-path":   "/usr/local/bin/hyperfleet-credential-provider-v0.3",
+		"not-on-path":  "hyperfleet-credential-provider-v0.2",
+		"old-binary":   filepath.Join(dirs.oldDir, pluginBinaryName),
+		"hanging":      filepath.Join(dirs.hangingDir, pluginBinaryName),
+		"other-plugin": "aws",
+	})
+
+	checker := &execChecker{
+		kubeconfigDir:  dirs.kubeconfigDir,
+		currentVersion: "v0.5.0",
+		fixCommand:     pluginBinaryName,
+		versionTimeout: 200 * time.Millisecond,
+	}
+
+	start := time.Now()
+	findings, err := checker.check(context.Background(), data)
+	require.NoError(t, err)
+	assert.Less(t, time.Since(start), 8*time.Second, "the hanging binary should be abandoned at the version timeout")
+
+	got := make(map[string]execFinding)
+	for _, finding := range findings {
+		got[finding.User] = finding
+	}
+	assert.NotContains(t, got, "other-plugin", "other exec plugins are not checked")
+
+	tests := []struct {
+		user        string
+		wantStatus  string
+		wantVersion string
+		wantProblem bool
+	}{
+		{user: "current", wantStatus: statusOK, wantVersion: "v0.5.2"},
+		{user: "relative", wantStatus: statusOK, wantVersion: "v0.5.2"},
+		{user: "stale-path", wantStatus: statusMissing, wantProblem: true},
+		{user: "not-on-path", wantStatus: statusMissing, wantProblem: true},
+		{user: "old-binary", wantStatus: statusVersionMismatch, wantVersion: "v0.3.1", wantProblem: true},
+		{user: "hanging", wantStatus: statusUnknownVersion},
+	}
+	for _, tt := range tests {
+		t.Run(tt.user, func(t *testing.T) {
+			finding, ok := got[tt.user]
+			require.True(t, ok)
+			assert.Equal(t, tt.wantStatus, finding.Status, finding.Detail)
+			assert.Equal(t, tt.wantVersion, finding.Version)
+			assert.Equal(t, tt.wantProblem, finding.problem())
+		})
+	}
+	assert.Equal(t, filepath.Join(dirs.newDir, pluginBinaryName), got["current"].Resolved)
+}
+
+func TestExecChecker_VersionsNotComparable(t *testing.T) {
+	dirs := setupFakePlugins(t)
+
+	checker := &execChecker{
+		kubeconfigDir:  dirs.kubeconfigDir,
+		currentVersion: "dev",
+		versionTimeout: time.Second,
+	}
+	finding := checker.checkCommand(context.Background(), "old", filepath.Join(dirs.oldDir, pluginBinaryName))
+
+	assert.Equal(t, statusOK, finding.Status)
+	assert.Equal(t, "v0.3.1", finding.Version)
+	assert.Contains(t, finding.Detail, "not comparable")
+}
+
+func TestExecChecker_Fix(t *testing.T) {
+	dirs := setupFakePlugins(t)
+
+	path := filepath.Join(dirs.kubeconfigDir, "config")
+	require.NoError(t, os.WriteFile(path, testKubeconfig(t, map[string]string{
+		"current":      pluginBinaryName,
+		"stale-path":   "/usr/local/bin/hyperfleet-credential-provider-v0.3",
+		"old-binary":   filepath.Join(dirs.oldDir, pluginBinaryName),
+		"other-plugin": "aws",
+	}), 0600))
+
+	checker := &execChecker{
+		kubeconfigDir:  dirs.kubeconfigDir,
+		currentVersion: "v0.5.0",
+		fixCommand:     pluginBinaryName,
+		versionTimeout: time.Second,
+	}
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	findings, err := checker.check(context.Background(), data)
+	require.NoError(t, err)
+
+	findings, err = checker.fix(context.Background(), path, findings)
+	require.NoError(t, err)
+	for _, finding := range findings {
+		assert.False(t, finding.problem(), "%s should be fixed", finding.User)
+	}
+
+	var written struct {
+		Users []struct {
+			Name string `yaml:"name"`
+			User struct {
+				Exec struct {
+					Command string   `yaml:"command"`
+					Args    []string `yaml:"args"`
+				} `yaml:"exec"`
+			} `yaml:"user"`
+		} `yaml:"users"`
+		CurrentContext string `yaml:"current-context"`
+	}
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	require.NoError(t, yaml.Unmarshal(data, &written))
+
+	commands := make(map[string]string)
+	for _, user := range written.Users {
+		commands[user.Name] = user.User.Exec.Command
+		assert.Equal(t, "--cluster-name="+user.Name, user.User.Exec.Args[2], "args should be preserved")
+	}
+	assert.Equal(t, map[string]string{
+		"current":      pluginBinaryName,
+		"stale-path":   pluginBinaryName,
+		"old-binary":   pluginBinaryName,
+		"other-plugin": "aws",
+	}, commands)
+	assert.Equal(t, "current", written.CurrentContext)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
+
+func TestRunCheck(t *testing.T) {
+	dirs := setupFakePlugins(t)
+	path := filepath.Join(dirs.kubeconfigDir, "config")
+	require.NoError(t, os.WriteFile(path, testKubeconfig(t, map[string]string{
+		"current":    pluginBinaryName,
+		"stale-path": "/usr/local/bin/hyperfleet-credential-provider-v0.3",
+	}), 0600))
+
+	v := viper.New()
+	v.Set("log-level", "error")
+	v.Set("kubeconfig", path)
+	v.Set("version-timeout", time.Second)
+
+	var out bytes.Buffer
+	err := runCheck(&common.Flags{Viper: v}, &out)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "1 exec command(s)")
+	assert.Contains(t, out.String(), "stale-path")
+	assert.Contains(t, out.String(), statusMissing)
+
+	// The test binary is not on PATH, so --fix writes its absolute path
+	v.Set("fix", true)
+	out.Reset()
+	require.NoError(t, runCheck(&common.Flags{Viper: v}, &out))
+	assert.Contains(t, out.String(), statusFixed)
+
+	self, err := os.Executable()
+	require.NoError(t, err)
+	self, err = filepath.EvalSymlinks(self)
+	require.NoError(t, err)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "command: "+self)
+}
+
+func TestKubeconfigPath(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	t.Setenv("KUBECONFIG", "")
+	path, err := kubeconfigPath("")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(home, ".kube", "config"), path)
+
+	t.Setenv("KUBECONFIG", string(os.PathListSeparator)+"/tmp/a"+string(os.PathListSeparator)+"/tmp/b")
+	path, err = kubeconfigPath("")
+	require.NoError(t, err)
+	assert.Equal(t, "/tmp/a", path)
+
+	path, err = kubeconfigPath("explicit.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, "explicit.yaml", path)
+}
@@ -0,0 +1,135 @@
+package kubeconfig
+
+const (
+	// defaultExecCommand is the exec plugin command of generated kubeconfigs
+	defaultExecCommand = "hyperfleet-credential-provider"
+
+	// execCommandSelf is the --exec-command value that writes the path of the running binary
+	execCommandSelf = "self"
+
+	// execAPIVersion is the ExecCredential version the exec plugin speaks
+	execAPIVersion = "client.authentication.k8s.io/v1"
+)
+
+// kubeconfigDocument is the kubeconfig written by generate-kubeconfig, a local
+// equivalent of the clientcmd/api/v1 Config limited to the fields it sets.
+//
+// yaml.v3 writes struct fields in declaration order, so fields here and in the
+// nested types are declared in the alphabetical order of their keys: the order
+// kubectl config view writes, which keeps the output the same on every run.
+type kubeconfigDocument struct {
+	APIVersion     string         `yaml:"apiVersion"`
+	Clusters       []namedCluster `yaml:"clusters"`
+	Contexts       []namedContext `yaml:"contexts"`
+	CurrentContext string         `yaml:"current-context"`
+	Kind           string         `yaml:"kind"`
+	Users          []namedUser    `yaml:"users"`
+}
+
+// namedCluster is a kubeconfig clusters entry
+type namedCluster struct {
+	Cluster clusterConfig `yaml:"cluster"`
+	Name    string        `yaml:"name"`
+}
+
+// clusterConfig is how to reach a cluster's API server
+type clusterConfig struct {
+	CertificateAuthorityData string `yaml:"certificate-authority-data"`
+	ProxyURL                 string `yaml:"proxy-url,omitempty"`
+	Server                   string `yaml:"server"`
+	TLSServerName            string `yaml:"tls-server-name,omitempty"`
+}
+
+// namedContext is a kubeconfig contexts entry
+type namedContext struct {
+	Context contextConfig `yaml:"context"`
+	Name    string        `yaml:"name"`
+}
+
+// contextConfig pairs a cluster with the user that authenticates to it
+type contextConfig struct {
+	Cluster string `yaml:"cluster"`
+	User    string `yaml:"user"`
+}
+
+// namedUser is a kubeconfig users entry
+type namedUser struct {
+	Name string   `yaml:"name"`
+	User authInfo `yaml:"user"`
+}
+
+// authInfo authenticates a user with the exec plugin
+type authInfo struct {
+	Exec execConfig `yaml:"exec"`
+}
+
+// execConfig runs get-token to obtain the user's token
+type execConfig struct {
+	APIVersion      string       `yaml:"apiVersion" json:"apiVersion"`
+	Args            []string     `yaml:"args" json:"args"`
+	Command         string       `yaml:"command" json:"command"`
+	Env             []execEnvVar `yaml:"env" json:"env"`
+	InteractiveMode string       `yaml:"interactiveMode" json:"interactiveMode"`
+}
+
+// execEnvVar is an environment variable set for the exec plugin
+type execEnvVar struct {
+	Name  string `yaml:"name" json:"name"`
+	Value string `yaml:"value" json:"value"`
+}
+
+// newKubeconfigDocument builds the kubeconfig of entries, in the given order
+func newKubeconfigDocument(entries []kubeconfigEntry, currentContext string) *kubeconfigDocument {
+	doc := &kubeconfigDocument{
+		APIVersion:     "v1",
+		Clusters:       make([]namedCluster, 0, len(entries)),
+		Contexts:       make([]namedContext, 0, len(entries)),
+		CurrentContext: currentContext,
+		Kind:           "Config",
+		Users:          make([]namedUser, 0, len(entries)),
+	}
+
+	for _, entry := range entries {
+		doc.Clusters = append(doc.Clusters, namedCluster{
+			Cluster: entry.cluster(),
+			Name:    entry.Name,
+		})
+		doc.Contexts = append(doc.Contexts, namedContext{
+			Context: contextConfig{
+				Cluster: entry.Name,
+				User:    entry.UserName,
+			},
+			Name: entry.Name,
+		})
+		doc.Users = append(doc.Users, namedUser{
+			Name: entry.UserName,
+			User: authInfo{Exec: entry.exec()},
+		})
+	}
+	return doc
+}
+
+// cluster returns the clusters entry of the entry
+func (e kubeconfigEntry) cluster() clusterConfig {
+	return clusterConfig{
+		CertificateAuthorityData: e.CACert,
+		ProxyURL:                 e.ProxyURL,
+		Server:                   e.Endpoint,
+		TLSServerName:            e.TLSServerName,
+	}
+}
+
+// exec returns the exec plugin configuration of the entry's user
+func (e kubeconfigEntry) exec() execConfig {
+	command := e.Command
+	if command == "" {
+		command = defaultExecCommand
+	}
+	return execConfig{
+		APIVersion:      execAPIVersion,
+		Args:            e.ExecArgs,
+		Command:         command,
+		Env:             e.Env,
+		InteractiveMode: "Never",
+	}
+}
@@ -0,0 +1,192 @@
+package kubeconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/cmd/provider/common"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+)
+
+// Output formats of print-exec-config
+const (
+	execOutputYAML    = "yaml"
+	execOutputJSON    = "json"
+	execOutputKubectl = "kubectl"
+)
+
+var (
+	execOutputFormat string
+	execUserName     string
+)
+
+func NewPrintExecConfigCommand(flags *common.Flags) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "print-exec-config",
+		Short: "Print the exec plugin configuration of a kubeconfig user",
+		Long: `Print the users[].user.exec section that generate-kubeconfig would write, without
+looking the cluster up, for kubeconfigs managed by another tool.
+
+The exec plugin arguments and environment are built from the same flags as
+generate-kubeconfig. With --output=kubectl, the equivalent
+"kubectl config set-credentials" command line is printed instead.`,
+		Example: `  # Exec section of an EKS user, as YAML
+  hyperfleet-credential-provider print-exec-config \
+    --provider=aws \
+    --cluster-name=my-cluster \
+    --region=us-east-1
+
+  # Update the user of an existing kubeconfig
+  eval "$(hyperfleet-credential-provider print-exec-config \
+    --provider=gcp \
+    --cluster-name=my-cluster \
+    --project-id=my-project \
+    --region=us-central1 \
+    --user=gke-user \
+    --output=kubectl)"`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runPrintExecConfig(flags, cmd.OutOrStdout())
+		},
+	}
+
+	cmd.Flags().StringVar(&flags.ProviderName, "provider", "", "Cloud provider (gcp, aws, azure, oci, digitalocean, oidc) [required]")
+	cmd.Flags().StringVar(&flags.ClusterName, "cluster-name", "", "Cluster name [required]")
+	cmd.Flags().StringVar(&flags.Region, "region", "", "Cloud region/location [required for GCP/AWS, optional for OCI]")
+	cmd.Flags().StringVar(&flags.ProjectID, "project-id", "", "GCP project ID (required for GCP)")
+	cmd.Flags().StringVar(&flags.GCPUseADC, "gcp-use-adc", "auto", "Use GCP application default credentials: auto (when no credentials file is set), true, or false")
+	cmd.Flags().StringVar(&flags.AWSProfile, "profile", "", "AWS shared config profile (passed to get-token)")
+	cmd.Flags().BoolVar(&skipCredCheck, "skip-credential-check", false, "Skip the AWS session credential expiry check in get-token (passed to get-token)")
+	cmd.Flags().StringVar(&flags.STSEndpoint, "sts-endpoint", "", "AWS STS endpoint URL that tokens are presigned for (passed to get-token)")
+	cmd.Flags().StringVar(&awsClusterID, "cluster-id", "", "AWS cluster ID for the x-k8s-aws-id header when it differs from the cluster name (passed to get-token)")
+	cmd.Flags().StringVar(&flags.SubscriptionID, "subscription-id", "", "Azure subscription ID (required for Azure)")
+	cmd.Flags().StringVar(&flags.AzureCloud, "azure-cloud", "", "Azure cloud (public, usgovernment, china; default: public)")
+	cmd.Flags().StringVar(&flags.TenantID, "tenant-id", "", "Azure tenant ID (required for Azure)")
+	cmd.Flags().StringVar(&flags.ResourceGroup, "resource-group", "", "Azure resource group (required for Azure)")
+	cmd.Flags().StringVar(&flags.TenancyID, "tenancy-id", "", "OCI tenancy OCID (default: from the OCI config file)")
+	cmd.Flags().StringVar(&flags.UserID, "user-id", "", "OCI user OCID (default: from the OCI config file)")
+	cmd.Flags().StringVar(&flags.CompartmentID, "compartment-id", "", "OCI compartment OCID")
+	cmd.Flags().StringVar(&flags.OIDCIssuerURL, "issuer-url", "", "OIDC issuer URL (required for OIDC)")
+	cmd.Flags().StringVar(&flags.OIDCClientID, "client-id", "", "OIDC client ID (required for OIDC)")
+	cmd.Flags().StringVar(&flags.OIDCPrivateKeyFile, "private-key-file", "", "PEM private key that authenticates the OIDC client with private_key_jwt (passed to get-token)")
+	cmd.Flags().StringVar(&flags.OIDCScopes, "scopes", "", "Comma-separated OIDC scopes to request (passed to get-token)")
+	cmd.Flags().StringVar(&flags.OIDCSubjectTokenFile, "subject-token-file", "", "Token file exchanged with RFC 8693 token exchange (passed to get-token)")
+	cmd.Flags().StringVar(&flags.OIDCSubjectTokenType, "subject-token-type", "", "RFC 8693 type of the --subject-token-file token (passed to get-token)")
+	cmd.Flags().BoolVar(&flags.OIDCUseIDToken, "use-id-token", false, "Make the exec plugin return the OIDC ID token instead of the access token (passed to get-token)")
+	cmd.Flags().StringVar(&boundAudience, "bound-audience", "", "Make the exec plugin request tokens bound to this audience (passed to get-token as --audience; GCP, AWS, Azure and OIDC only)")
+	cmd.Flags().StringArrayVar(&execEnv, "exec-env", nil, "Additional environment variable for the exec plugin in NAME=VALUE format (repeatable)")
+	cmd.Flags().StringVar(&execCommandPath, "exec-command", defaultExecCommand, "Command the exec plugin runs to get tokens, or \"self\" for the absolute path of this binary")
+	cmd.Flags().StringVar(&execOutputFormat, "output", execOutputYAML, "Output format (yaml, json, kubectl)")
+	cmd.Flags().StringVar(&execUserName, "user", kubeconfigUserName, "Kubeconfig user named in the --output=kubectl command")
+
+	common.AddPreferSecretFlag(cmd, flags)
+
+	common.SetFlagProviders(cmd, []string{"gcp", "aws", "oci"}, "region")
+	common.SetFlagProviders(cmd, []string{"gcp"}, "project-id", "gcp-use-adc")
+	common.SetFlagProviders(cmd, []string{"aws"}, "profile", "cluster-id", "skip-credential-check", "sts-endpoint")
+	common.SetFlagProviders(cmd, []string{"azure"}, "subscription-id", "tenant-id", "azure-cloud", "resource-group")
+	common.SetFlagProviders(cmd, []string{"oci"}, "tenancy-id", "user-id", "compartment-id")
+	common.SetFlagProviders(cmd, []string{"oidc"}, "issuer-url", "client-id", "private-key-file", "scopes", "subject-token-file", "subject-token-type", "use-id-token")
+	common.SetFlagProviders(cmd, []string{"gcp", "aws", "azure", "oidc"}, "bound-audience")
+
+	return cmd
+}
+
+func runPrintExecConfig(flags *common.Flags, stdout io.Writer) error {
+	// Bind Viper values to flags (environment variables take precedence if flags not set)
+	common.BindFlagsToViper(flags)
+
+	switch execOutputFormat {
+	case execOutputYAML, execOutputJSON, execOutputKubectl:
+	default:
+		return errors.New(errors.ErrInvalidArgument, fmt.Sprintf("invalid --output %q (must be one of: yaml, json, kubectl)", execOutputFormat))
+	}
+	if strings.TrimSpace(execUserName) == "" {
+		return errors.New(errors.ErrInvalidArgument, "--user must not be empty")
+	}
+
+	providerInfo, err := kubeconfigProviderInfo(flags)
+	if err != nil {
+		return err
+	}
+	if err := provider.CheckRegistered(flags.ProviderName); err != nil {
+		return err
+	}
+	if err := applyKubeconfigCredentialsMap(flags, providerInfo); err != nil {
+		return err
+	}
+	if boundAudience != "" {
+		if err := provider.CheckAudienceSupported(flags.ProviderName); err != nil {
+			return err
+		}
+	}
+	extraEnv, err := parseExecEnv(execEnv)
+	if err != nil {
+		return err
+	}
+	if err := validateRenderFlags(); err != nil {
+		return err
+	}
+	addExecOptions(flags, providerInfo)
+	addRenderOptions(providerInfo)
+
+	entry := newKubeconfigEntry(flags.ClusterName, execUserName, "", "", providerInfo, extraEnv)
+	data, err := renderExecConfig(entry, execOutputFormat)
+	if err != nil {
+		return err
+	}
+	if _, err := stdout.Write(data); err != nil {
+		return fmt.Errorf("failed to write exec config: %w", err)
+	}
+	return nil
+}
+
+// renderExecConfig renders the exec plugin configuration of entry in format
+func renderExecConfig(entry kubeconfigEntry, format string) ([]byte, error) {
+	exec := entry.exec()
+	switch format {
+	case execOutputJSON:
+		data, err := json.MarshalIndent(exec, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal exec config to JSON: %w", err)
+		}
+		return append(data, '\n'), nil
+	case execOutputKubectl:
+		return []byte(kubectlSetCredentials(entry.UserName, exec)), nil
+	default:
+		data, err := yaml.Marshal(exec)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal exec config to YAML: %w", err)
+		}
+		return data, nil
+	}
+}
+
+// kubectlSetCredentials returns the kubectl config set-credentials command line that
+// writes exec as the exec plugin of user, one flag per continued line
+func kubectlSetCredentials(user string, exec execConfig) string {
+	args := []string{
+		"--exec-api-version=" + exec.APIVersion,
+		"--exec-command=" + exec.Command,
+	}
+	for _, arg := range exec.Args {
+		args = append(args, "--exec-arg="+arg)
+	}
+	for _, env := range exec.Env {
+		args = append(args, "--exec-env="+env.Name+"="+env.Value)
+	}
+	args = append(args, "--exec-interactive-mode="+exec.InteractiveMode)
+
+	var b strings.Builder
+	b.WriteString("kubectl config set-credentials " + common.ShellQuote(user))
+	for _, arg := range args {
+		b.WriteString(" \\\n  " + common.ShellQuote(arg))
+	}
+	b.WriteString("\n")
+	return b.String()
+}
@@ -0,0 +1,144 @@
+package kubeconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/cmd/provider/common"
+)
+
+func TestRenderExecConfig_Golden(t *testing.T) {
+	extensions := map[string]string{
+		execOutputYAML:    "yaml",
+		execOutputJSON:    "json",
+		execOutputKubectl: "sh",
+	}
+	for _, providerName := range []string{"gcp", "aws", "azure", "oci", "digitalocean", "oidc"} {
+		t.Run(providerName, func(t *testing.T) {
+			entry := goldenEntries(providerName)[0]
+			for format, extension := range extensions {
+				data, err := renderExecConfig(entry, format)
+				require.NoError(t, err)
+				assertGolden(t, providerName+"-exec.golden."+extension, data)
+			}
+		})
+	}
+}
+
+func TestRenderExecConfig_MatchesKubeconfig(t *testing.T) {
+	entry := goldenEntries("aws")[0]
+	kubeconfig, err := renderKubeconfig([]kubeconfigEntry{entry}, "my-cluster", nil)
+	require.NoError(t, err)
+	var doc kubeconfigDocument
+	require.NoError(t, yaml.Unmarshal(kubeconfig, &doc))
+	require.Len(t, doc.Users, 1)
+
+	data, err := renderExecConfig(entry, execOutputYAML)
+	require.NoError(t, err)
+	var fromYAML execConfig
+	require.NoError(t, yaml.Unmarshal(data, &fromYAML))
+	assert.Equal(t, doc.Users[0].User.Exec, fromYAML, "the fragment is the exec section of the kubeconfig")
+
+	data, err = renderExecConfig(entry, execOutputJSON)
+	require.NoError(t, err)
+	var fromJSON execConfig
+	require.NoError(t, json.Unmarshal(data, &fromJSON))
+	assert.Equal(t, fromYAML, fromJSON)
+}
+
+func TestKubectlSetCredentials_Quoting(t *testing.T) {
+	got := kubectlSetCredentials("ops user", execConfig{
+		APIVersion:      execAPIVersion,
+		Command:         "/opt/hyperfleet bin/hyperfleet-credential-provider",
+		Args:            []string{"get-token", "--scopes=openid groups"},
+		Env:             []execEnvVar{{Name: "TOKEN", Value: "it's"}},
+		InteractiveMode: "Never",
+	})
+	assert.Equal(t, `kubectl config set-credentials 'ops user' \
+  --exec-api-version=client.authentication.k8s.io/v1 \
+  '--exec-command=/opt/hyperfleet bin/hyperfleet-credential-provider' \
+  --exec-arg=get-token \
+  '--exec-arg=--scopes=openid groups' \
+  '--exec-env=TOKEN=it'\''s' \
+  --exec-interactive-mode=Never
+`, got)
+}
+
+// runPrintExecConfigCommand runs print-exec-config the way main does, returning what it printed
+func runPrintExecConfigCommand(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+
+	flags := &common.Flags{}
+	root := &cobra.Command{
+		Use:           "hyperfleet-credential-provider",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			flags.Viper = common.NewViper(cmd)
+		},
+	}
+	root.PersistentFlags().StringVar(&flags.LogLevel, "log-level", "error", "")
+	root.PersistentFlags().StringVar(&flags.CredentialsFile, "credentials-file", "", "")
+	root.AddCommand(NewPrintExecConfigCommand(flags))
+	root.SetArgs(append([]string{"print-exec-config"}, args...))
+
+	var stdout bytes.Buffer
+	root.SetOut(&stdout)
+	err := root.Execute()
+	return stdout.String(), err
+}
+
+func TestPrintExecConfig(t *testing.T) {
+	for _, name := range []string{"AWS_CREDENTIALS_FILE", "HFCP_CREDENTIALS_FILE", "HFCP_PROFILE", "HFCP_REGION"} {
+		t.Setenv(name, "")
+	}
+	awsArgs := []string{"--provider=aws", "--cluster-name=my-cluster", "--region=us-east-1"}
+
+	t.Run("yaml", func(t *testing.T) {
+		stdout, err := runPrintExecConfigCommand(t, append(awsArgs, "--profile=deploy", "--exec-env=HTTPS_PROXY=http://proxy:3128")...)
+		require.NoError(t, err)
+
+		var exec execConfig
+		require.NoError(t, yaml.Unmarshal([]byte(stdout), &exec))
+		assert.Equal(t, execAPIVersion, exec.APIVersion)
+		assert.Equal(t, defaultExecCommand, exec.Command)
+		assert.Equal(t, []string{"get-token", "--provider=aws", "--cluster-name=my-cluster", "--region=us-east-1", "--profile=deploy"}, exec.Args)
+		assert.Contains(t, exec.Env, execEnvVar{Name: "HTTPS_PROXY", Value: "http://proxy:3128"})
+		assert.Equal(t, "Never", exec.InteractiveMode)
+	})
+
+	t.Run("kubectl", func(t *testing.T) {
+		stdout, err := runPrintExecConfigCommand(t, append(awsArgs, "--output=kubectl", "--user=eks-user", "--exec-command=/usr/local/bin/hyperfleet-credential-provider")...)
+		require.NoError(t, err)
+		assert.Contains(t, stdout, "kubectl config set-credentials eks-user \\\n")
+		assert.Contains(t, stdout, "--exec-command=/usr/local/bin/hyperfleet-credential-provider")
+		assert.Contains(t, stdout, "--exec-arg=--cluster-name=my-cluster")
+	})
+
+	t.Run("azure prefer secret", func(t *testing.T) {
+		stdout, err := runPrintExecConfigCommand(t, "--provider=azure", "--cluster-name=my-cluster",
+			"--subscription-id=sub-123", "--tenant-id=tenant-456", "--resource-group=my-rg", "--prefer-secret")
+		require.NoError(t, err)
+
+		var exec execConfig
+		require.NoError(t, yaml.Unmarshal([]byte(stdout), &exec))
+		assert.Contains(t, exec.Args, "--prefer-secret")
+	})
+
+	t.Run("invalid output", func(t *testing.T) {
+		_, err := runPrintExecConfigCommand(t, append(awsArgs, "--output=toml")...)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `invalid --output "toml"`)
+	})
+
+	t.Run("invalid inputs", func(t *testing.T) {
+		_, err := runPrintExecConfigCommand(t, "--provider=aws", "--cluster-name=my-cluster")
+		require.Error(t, err, "the region is required for AWS")
+	})
+}
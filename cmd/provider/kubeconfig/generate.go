@@ -3,19 +3,143 @@ package kubeconfig
 import (
 	"context"
 	"fmt"
+	"io"
+	"net/url"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
 
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v3"
 
 	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/cmd/provider/common"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/filelock"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
 	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider/aws"
-	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider/azure"
-	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider/gcp"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
 	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
 )
 
-var outputFile string
+var (
+	outputFile      string
+	outputCAFile    string
+	execEnv         []string
+	clusterInfoFile string
+	clusterEndpoint string
+	clusterCAFile   string
+	clusterCAData   string
+	lockTimeout     time.Duration
+	boundAudience   string
+	awsClusterID    string
+	skipCredCheck   bool
+	verify          bool
+
+	execCommandPath    = defaultExecCommand
+	proxyURL           string
+	tlsServerName      string
+	kubeconfigTemplate string
+
+	// extraCAFile is appended to the cluster CA of every entry; validateRenderFlags
+	// loads it into extraCA as base64-encoded PEM
+	extraCAFile string
+	extraCA     string
+
+	// clusterNames holds every --cluster-name; more than one, or --clusters-file, adds
+	// several clusters of one provider to the kubeconfig in batch mode
+	clusterNames []string
+	clustersFile string
+
+	fromFile         string
+	failFast         bool
+	batchConcurrency int
+	batchRateLimit   float64
+)
+
+// kubeconfigUserName is the kubeconfig user entry that runs the exec plugin
+const kubeconfigUserName = "hyperfleet-user"
+
+// Placeholders for the cluster endpoint and CA of a --dry-run kubeconfig, which is
+// rendered without looking the cluster up
+const (
+	dryRunEndpoint = "https://DRY-RUN-ENDPOINT"
+	dryRunCA       = "DRY-RUN-CA"
+)
+
+// envNamePattern matches valid environment variable names
+var envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// examples are the per-provider generate-kubeconfig examples shown in help
+var examples = map[string]string{
+	"gcp": `  # GCP/GKE
+  hyperfleet-credential-provider generate-kubeconfig \
+    --provider=gcp \
+    --cluster-name=my-cluster \
+    --project-id=my-project \
+    --region=us-central1 \
+    --output=kubeconfig.yaml`,
+	"aws": `  # AWS/EKS, passing additional environment variables to the exec plugin
+  hyperfleet-credential-provider generate-kubeconfig \
+    --provider=aws \
+    --cluster-name=my-cluster \
+    --region=us-east-1 \
+    --exec-env=HTTPS_PROXY=http://proxy.example.com:3128 \
+    --exec-env=AWS_REGION=us-east-1
+
+  # Offline (air-gapped): reuse cluster info exported by get-cluster-info
+  hyperfleet-credential-provider generate-kubeconfig \
+    --provider=aws \
+    --cluster-name=my-cluster \
+    --region=us-east-1 \
+    --cluster-info-file=cluster-info.json
+
+  # Offline with an explicit endpoint and CA bundle
+  hyperfleet-credential-provider generate-kubeconfig \
+    --provider=aws \
+    --cluster-name=my-cluster \
+    --region=us-east-1 \
+    --cluster-endpoint=https://ABCDEF.gr7.us-east-1.eks.amazonaws.com \
+    --cluster-ca-file=ca.crt
+
+  # Offline with the CA copied from an existing kubeconfig
+  hyperfleet-credential-provider generate-kubeconfig \
+    --provider=aws \
+    --cluster-name=my-cluster \
+    --region=us-east-1 \
+    --cluster-endpoint=https://ABCDEF.gr7.us-east-1.eks.amazonaws.com \
+    --cluster-ca-data=LS0tLS1CRUdJTi...`,
+	"azure": `  # Azure/AKS
+  hyperfleet-credential-provider generate-kubeconfig \
+    --provider=azure \
+    --cluster-name=my-cluster \
+    --subscription-id=xxx \
+    --tenant-id=xxx \
+    --resource-group=my-rg \
+    --output=kubeconfig.yaml`,
+	"oci": `  # OCI/OKE
+  hyperfleet-credential-provider generate-kubeconfig \
+    --provider=oci \
+    --cluster-name=ocid1.cluster.oc1.iad.aaaa... \
+    --region=us-ashburn-1 \
+    --output=kubeconfig.yaml`,
+	"digitalocean": `  # DigitalOcean/DOKS
+  hyperfleet-credential-provider generate-kubeconfig \
+    --provider=digitalocean \
+    --cluster-name=my-cluster \
+    --output=kubeconfig.yaml`,
+	"oidc": `  # Generic OIDC issuer; the cluster endpoint and CA are given explicitly
+  hyperfleet-credential-provider generate-kubeconfig \
+    --provider=oidc \
+    --cluster-name=my-cluster \
+    --issuer-url=https://issuer.example.com \
+    --client-id=hyperfleet \
+    --cluster-endpoint=https://api.my-cluster.example.com:6443 \
+    --cluster-ca-file=ca.pem \
+    --output=kubeconfig.yaml`,
+}
 
 func NewCommand(flags *common.Flags) *cobra.Command {
 	cmd := &cobra.Command{
@@ -26,32 +150,83 @@ func NewCommand(flags *common.Flags) *cobra.Command {
 This command uses cloud provider SDKs (no CLI required) to fetch cluster details
 and generates a kubeconfig that uses hyperfleet-credential-provider for token generation.
 
-Examples:
-  # GCP/GKE
+With --dry-run, inputs and local credentials are validated and the kubeconfig is
+printed to stdout with the placeholders DRY-RUN-ENDPOINT and DRY-RUN-CA in place of
+the cluster endpoint and CA (unless offline cluster info is given), without calling
+a cloud API or writing --output.
+
+Pass --provider with --help to list only that provider's flags.`,
+		Example: `  # Batch mode: one kubeconfig for every cluster listed in a YAML file
   hyperfleet-credential-provider generate-kubeconfig \
-    --provider=gcp \
-    --cluster-name=my-cluster \
-    --project-id=my-project \
-    --region=us-central1 \
+    --from-file=clusters.yaml \
     --output=kubeconfig.yaml`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return run(flags)
 		},
 	}
 
-	cmd.Flags().StringVar(&flags.ProviderName, "provider", "", "Cloud provider (gcp, aws, azure) [required]")
-	cmd.Flags().StringVar(&flags.ClusterName, "cluster-name", "", "Cluster name [required]")
-	cmd.Flags().StringVar(&flags.Region, "region", "", "Cloud region/location [required for GCP/AWS]")
+	cmd.Flags().StringVar(&flags.ProviderName, "provider", "", "Cloud provider (gcp, aws, azure, oci, digitalocean, oidc) [required]")
+	cmd.Flags().StringArrayVar(&clusterNames, "cluster-name", nil, "Cluster name [required]; repeat it to add several clusters of one provider to one kubeconfig")
+	cmd.Flags().StringVar(&flags.Region, "region", "", "Cloud region/location [required for GCP/AWS, optional for OCI]")
 	cmd.Flags().StringVar(&flags.ProjectID, "project-id", "", "GCP project ID (required for GCP)")
+	cmd.Flags().StringVar(&flags.GCPUseADC, "gcp-use-adc", "auto", "Use GCP application default credentials: auto (when no credentials file is set), true, or false")
 	cmd.Flags().StringVar(&flags.AccountID, "account-id", "", "AWS account ID (optional)")
+	cmd.Flags().StringVar(&flags.AWSProfile, "profile", "", "AWS shared config profile (default: AWS_PROFILE, then default; passed to get-token)")
+	cmd.Flags().BoolVar(&skipCredCheck, "skip-credential-check", false, "Skip the AWS session credential expiry check in get-token, for latency-critical clusters (passed to get-token)")
+	cmd.Flags().StringVar(&flags.STSEndpoint, "sts-endpoint", "", "AWS STS endpoint URL that tokens are presigned for, e.g. an interface VPC endpoint (passed to get-token)")
+	cmd.Flags().StringVar(&awsClusterID, "cluster-id", "", "AWS cluster ID for the x-k8s-aws-id header when it differs from the cluster name (passed to get-token)")
 	cmd.Flags().StringVar(&flags.SubscriptionID, "subscription-id", "", "Azure subscription ID (required for Azure)")
+	cmd.Flags().StringVar(&flags.AzureCloud, "azure-cloud", "", "Azure cloud (public, usgovernment, china; default: public)")
 	cmd.Flags().StringVar(&flags.TenantID, "tenant-id", "", "Azure tenant ID (required for Azure)")
 	cmd.Flags().StringVar(&flags.ResourceGroup, "resource-group", "", "Azure resource group (required for Azure)")
+	cmd.Flags().StringVar(&flags.TenancyID, "tenancy-id", "", "OCI tenancy OCID (default: from the OCI config file)")
+	cmd.Flags().StringVar(&flags.UserID, "user-id", "", "OCI user OCID (default: from the OCI config file)")
+	cmd.Flags().StringVar(&flags.CompartmentID, "compartment-id", "", "OCI compartment OCID (required for OCI when --cluster-name is not a cluster OCID)")
+	cmd.Flags().StringVar(&flags.OIDCIssuerURL, "issuer-url", "", "OIDC issuer URL; the token endpoint is read from its discovery document (required for OIDC)")
+	cmd.Flags().StringVar(&flags.OIDCClientID, "client-id", "", "OIDC client ID (required for OIDC)")
+	cmd.Flags().StringVar(&flags.OIDCPrivateKeyFile, "private-key-file", "", "PEM RSA or EC private key that authenticates the OIDC client with private_key_jwt instead of a client secret (passed to get-token)")
+	cmd.Flags().StringVar(&flags.OIDCScopes, "scopes", "", "Comma-separated OIDC scopes to request (default: openid; passed to get-token)")
+	cmd.Flags().StringVar(&flags.OIDCSubjectTokenFile, "subject-token-file", "", "Token file exchanged with RFC 8693 token exchange instead of the client credentials grant (passed to get-token)")
+	cmd.Flags().StringVar(&flags.OIDCSubjectTokenType, "subject-token-type", "", "RFC 8693 type of the --subject-token-file token (default: urn:ietf:params:oauth:token-type:jwt; passed to get-token)")
+	cmd.Flags().BoolVar(&flags.OIDCUseIDToken, "use-id-token", false, "Make the exec plugin return the OIDC ID token instead of the access token (passed to get-token)")
 	cmd.Flags().StringVar(&outputFile, "output", "", "Output file path (default: stdout)")
-	cmd.Flags().StringVar(&flags.TokenDuration, "token-duration", "", "Token duration (e.g., 1h, 30m, 900s) (default: GCP=1h, AWS=15m, Azure=1h)")
-
-	// Bind flags to viper for environment variable support
-	common.BindCommandFlags(cmd)
+	cmd.Flags().StringVar(&outputCAFile, "output-ca-file", "", "Also write the cluster CA certificate to this file as PEM")
+	cmd.Flags().BoolVar(&verify, "verify", false, "Check that the cluster API server accepts a token from these credentials before writing the kubeconfig")
+	cmd.Flags().StringVar(&flags.TokenDuration, "token-duration", "", common.TokenDurationHelp())
+	cmd.Flags().StringVar(&clusterInfoFile, "cluster-info-file", "", "Read cluster endpoint and CA from a JSON file exported by get-cluster-info instead of calling the cloud API")
+	cmd.Flags().StringVar(&clusterEndpoint, "cluster-endpoint", "", "Cluster API server endpoint; skips the cloud API lookup (requires --cluster-ca-file or --cluster-ca-data unless --cluster-info-file is set)")
+	cmd.Flags().StringVar(&clusterCAFile, "cluster-ca-file", "", "PEM-encoded cluster CA certificate file; skips the cloud API lookup")
+	cmd.Flags().StringVar(&clusterCAData, "cluster-ca-data", "", "Cluster CA certificate as base64-encoded PEM, as in kubeconfig certificate-authority-data, or PEM; skips the cloud API lookup")
+	common.AddClusterInfoCacheFlags(cmd)
+	cmd.Flags().StringVar(&fromFile, "from-file", "", "YAML file listing clusters to write into a single kubeconfig (batch mode)")
+	cmd.Flags().StringVar(&clustersFile, "clusters-file", "", "File listing cluster names, one per line, to add to one kubeconfig with the provider flags shared by every cluster (batch mode)")
+	cmd.Flags().BoolVar(&failFast, "fail-fast", false, "In batch mode, stop at the first failed cluster and exit non-zero")
+	cmd.Flags().IntVar(&batchConcurrency, "concurrency", defaultBatchConcurrency, "In batch mode, how many clusters to look up at once")
+	cmd.Flags().Float64Var(&batchRateLimit, "rate-limit", 0, "In batch mode, the most cluster lookups started per second (0 for no limit)")
+	cmd.Flags().DurationVar(&lockTimeout, "lock-timeout", filelock.DefaultTimeout, "How long to wait for another process writing the same --output file")
+	cmd.Flags().StringArrayVar(&execEnv, "exec-env", nil, "Additional environment variable for the exec plugin in NAME=VALUE format (repeatable)")
+	cmd.Flags().StringVar(&execCommandPath, "exec-command", defaultExecCommand, "Command the kubeconfig runs to get tokens, e.g. the absolute path of the installed binary, or \"self\" for the absolute path of this binary")
+	cmd.Flags().StringVar(&proxyURL, "proxy-url", "", "Proxy kubectl uses to reach the API server (http, https or socks5 URL), written as the cluster proxy-url")
+	cmd.Flags().StringVar(&tlsServerName, "tls-server-name", "", "Server name used to verify the API server certificate when it differs from the endpoint host, written as the cluster tls-server-name")
+	cmd.Flags().StringVar(&extraCAFile, "extra-ca-file", "", "PEM file of CA certificates appended to the cluster CA in certificate-authority-data, e.g. the CA of a corporate proxy in front of the API server")
+	common.AddEndpointAccessFlag(cmd, flags)
+	common.AddClusterResourceIDFlag(cmd, flags)
+	common.AddVerifyEndpointFlag(cmd, flags)
+	common.AddGKEEndpointFlag(cmd, flags)
+	common.AddSuggestRegionFlag(cmd, flags)
+	cmd.Flags().StringVar(&kubeconfigTemplate, "kubeconfig-template", "", "Go template file rendered instead of the default kubeconfig; it receives the clusters, users and exec plugin configuration")
+	cmd.Flags().StringVar(&boundAudience, "bound-audience", "", "Make the exec plugin request tokens bound to this audience (passed to get-token as --audience; GCP, AWS, Azure and OIDC only)")
+	common.AddPreferSecretFlag(cmd, flags)
+	common.AddCredentialsProfileFlag(cmd, flags)
+
+	common.SetFlagProviders(cmd, []string{"gcp", "aws", "oci"}, "region")
+	common.SetFlagProviders(cmd, []string{"gcp"}, "project-id", "gcp-use-adc")
+	common.SetFlagProviders(cmd, []string{"aws"}, "account-id", "profile", "cluster-id", "skip-credential-check", "sts-endpoint")
+	common.SetFlagProviders(cmd, []string{"azure"}, "subscription-id", "tenant-id", "azure-cloud", "resource-group")
+	common.SetFlagProviders(cmd, []string{"oci"}, "tenancy-id", "user-id", "compartment-id")
+	common.SetFlagProviders(cmd, []string{"oidc"}, "issuer-url", "client-id", "private-key-file", "scopes", "subject-token-file", "subject-token-type", "use-id-token")
+	common.SetFlagProviders(cmd, []string{"gcp", "aws", "azure", "oidc"}, "bound-audience")
+	common.SetProviderHelp(cmd, examples)
 
 	// Note: We don't use MarkFlagRequired because Cobra validates before Viper bindings take effect
 	// Instead, we validate in the run function after BindFlagsToViper is called
@@ -62,12 +237,70 @@ Examples:
 func run(flags *common.Flags) error {
 	// Bind Viper values to flags (environment variables take precedence if flags not set)
 	common.BindFlagsToViper(flags)
+	// --cluster-name is repeatable, so viper only reads it from HFCP_CLUSTER_NAME
+	if len(clusterNames) > 0 {
+		flags.ClusterName = clusterNames[0]
+	}
+
+	if mode := batchMode(); mode != "" {
+		if fromFile != "" && (clustersFile != "" || len(clusterNames) > 1) {
+			return errors.New(errors.ErrInvalidArgument, "--from-file cannot be combined with --clusters-file or repeated --cluster-name")
+		}
+		if outputCAFile != "" {
+			return errors.New(errors.ErrInvalidArgument, fmt.Sprintf("--output-ca-file cannot be used with %s", mode))
+		}
+		if verify {
+			return errors.New(errors.ErrInvalidArgument, fmt.Sprintf("--verify cannot be used with %s", mode))
+		}
+		if flags.VerifyEndpoint {
+			return errors.New(errors.ErrInvalidArgument, fmt.Sprintf("--verify-endpoint cannot be used with %s", mode))
+		}
+		if flags.ClusterResourceID != "" {
+			return errors.New(errors.ErrInvalidArgument, fmt.Sprintf("--cluster-resource-id cannot be used with %s", mode))
+		}
+		if awsClusterID != "" {
+			return errors.New(errors.ErrInvalidArgument, fmt.Sprintf("--cluster-id cannot be used with %s", mode))
+		}
+		cache, err := common.NewClusterInfoCacheFromFlags(flags)
+		if err != nil {
+			return err
+		}
+		// Clusters sharing a provider and credentials share one provider instance
+		return runBatch(flags, cachedDescribe(cache, newProviderPool(newClusterDescriber).describe))
+	}
+
+	if err := common.ApplyClusterResourceID(flags); err != nil {
+		return err
+	}
+	providerSpecificInfo, err := kubeconfigProviderInfo(flags)
+	if err != nil {
+		return err
+	}
+	if err := provider.CheckRegistered(flags.ProviderName); err != nil {
+		return err
+	}
+	if err := applyKubeconfigCredentialsMap(flags, providerSpecificInfo); err != nil {
+		return err
+	}
+	if boundAudience != "" {
+		if err := provider.CheckAudienceSupported(flags.ProviderName); err != nil {
+			return err
+		}
+	}
+	if _, err := common.ParseEndpointAccess(flags); err != nil {
+		return err
+	}
 
-	if flags.ProviderName == "" {
-		return fmt.Errorf("--provider is required (or set HFCP_PROVIDER)")
+	extraEnv, err := parseExecEnv(execEnv)
+	if err != nil {
+		return err
+	}
+	if err := validateRenderFlags(); err != nil {
+		return err
 	}
-	if flags.ClusterName == "" {
-		return fmt.Errorf("--cluster-name is required (or set HFCP_CLUSTER_NAME)")
+	tmpl, err := loadKubeconfigTemplate(kubeconfigTemplate)
+	if err != nil {
+		return err
 	}
 
 	ctx, cancel := common.SetupSignalHandler()
@@ -79,23 +312,77 @@ func run(flags *common.Flags) error {
 	}
 	defer log.Sync()
 
+	if err := common.CheckTokenDuration(flags, log); err != nil {
+		return err
+	}
+
 	log.Info("Generating kubeconfig",
 		logger.String("provider", flags.ProviderName),
 		logger.String("cluster", flags.ClusterName),
 	)
 
-	var endpoint, caCert, version string
-	var providerSpecificInfo map[string]string
+	addExecOptions(flags, providerSpecificInfo)
+	addRenderOptions(providerSpecificInfo)
+	if tlsServerName != "" {
+		providerSpecificInfo["tls-server-name"] = tlsServerName
+	}
 
-	switch flags.ProviderName {
-	case "gcp":
-		endpoint, caCert, version, providerSpecificInfo, err = getGCPClusterInfoForKubeconfig(ctx, flags, log)
-	case "aws":
-		endpoint, caCert, version, providerSpecificInfo, err = getAWSClusterInfoForKubeconfig(ctx, flags, log)
-	case "azure":
-		endpoint, caCert, version, providerSpecificInfo, err = getAzureClusterInfoForKubeconfig(ctx, flags, log)
-	default:
-		return fmt.Errorf("unsupported provider: %s (must be gcp, aws, or azure)", flags.ProviderName)
+	offline := clusterInfoFile != "" || clusterEndpoint != "" || clusterCAFile != "" || clusterCAData != ""
+	if offline && flags.EndpointAccess != "" {
+		return errors.New(errors.ErrInvalidArgument, "--endpoint-access cannot be used with offline cluster info; pass the endpoint with --cluster-endpoint")
+	}
+
+	if flags.DryRun {
+		details := map[string]string{
+			"output":       outputFile,
+			"cluster-info": "cloud API (placeholders)",
+		}
+		info := &common.ClusterInfo{Endpoint: dryRunEndpoint, CertificateAuthority: dryRunCA}
+		if offline {
+			// Offline cluster info is local, so it can be validated and rendered too
+			if info, err = common.LoadOfflineClusterInfo(clusterInfoFile, clusterEndpoint, clusterCAFile, clusterCAData); err != nil {
+				return fmt.Errorf("dry run: %w", err)
+			}
+			details["cluster-info"] = "offline"
+		}
+		if outputFile == "" {
+			details["output"] = "stdout"
+		}
+		if outputCAFile != "" {
+			details["output-ca-file"] = outputCAFile
+		}
+		if kubeconfigTemplate != "" {
+			details["kubeconfig-template"] = kubeconfigTemplate
+		}
+		if flags.EndpointAccess != "" {
+			details["endpoint-access"] = flags.EndpointAccess
+		}
+		if extraCAFile != "" {
+			details["extra-ca-file"] = extraCAFile
+		}
+		if err := common.RunDryRun(ctx, flags, log, common.StatusOutput(flags), "generate a kubeconfig", details); err != nil {
+			return err
+		}
+		return printDryRunKubeconfig(info, offline, providerSpecificInfo, extraEnv, tmpl)
+	}
+
+	var info *common.ClusterInfo
+	var cache *common.ClusterInfoCache
+	cached := false
+	describe := func() (*common.ClusterInfo, error) {
+		return describeClusterForKubeconfig(ctx, flags, log)
+	}
+	if offline {
+		log.Info("Using offline cluster info, skipping cloud API lookup",
+			logger.String("cluster_info_file", clusterInfoFile),
+		)
+		info, err = common.LoadOfflineClusterInfo(clusterInfoFile, clusterEndpoint, clusterCAFile, clusterCAData)
+	} else {
+		cache, err = common.NewClusterInfoCacheFromFlags(flags)
+		if err != nil {
+			return err
+		}
+		info, cached, err = common.DescribeClusterCached(cache, flags, log, describe)
 	}
 
 	if err != nil {
@@ -103,23 +390,105 @@ func run(flags *common.Flags) error {
 	}
 
 	log.Info("Cluster info retrieved",
-		logger.String("endpoint", endpoint),
-		logger.String("version", version),
+		logger.String("endpoint", info.Endpoint),
+		logger.String("version", info.Version),
 	)
 
-	kubeconfig, err := generateKubeconfigYAML(endpoint, caCert, providerSpecificInfo)
+	if verify || flags.VerifyEndpoint {
+		info, err = common.VerifyClusterInfo(info, cached, cache, flags, log, describe, func(info *common.ClusterInfo) error {
+			if flags.VerifyEndpoint {
+				if err := common.VerifyEndpoint(ctx, flags, info, tlsServerName, common.StatusOutput(flags), log); err != nil {
+					return err
+				}
+			}
+			if verify {
+				return verifyKubeconfigToken(ctx, flags, info, log)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if outputCAFile != "" {
+		if err := common.WriteCAFile(outputCAFile, info.CertificateAuthority); err != nil {
+			return err
+		}
+		log.Info("Cluster CA written to file", logger.String("file", outputCAFile))
+	}
+
+	caCert, err := kubeconfigCA(info)
+	if err != nil {
+		return err
+	}
+
+	clusterName := providerSpecificInfo["cluster-name"]
+	entries := []kubeconfigEntry{
+		newKubeconfigEntry(clusterName, kubeconfigUserName, info.Endpoint, caCert, providerSpecificInfo, extraEnv),
+	}
+	kubeconfig, err := renderKubeconfig(entries, clusterName, tmpl)
 	if err != nil {
 		return fmt.Errorf("failed to generate kubeconfig: %w", err)
 	}
 
+	return writeKubeconfig(ctx, log, common.StatusOutput(flags), kubeconfig, entries)
+}
+
+// printDryRunKubeconfig prints the kubeconfig a --dry-run would generate to stdout.
+// Without offline cluster info, info holds the placeholders, to which --extra-ca-file
+// is not appended.
+func printDryRunKubeconfig(info *common.ClusterInfo, offline bool, providerSpecificInfo map[string]string, extraEnv []execEnvVar, tmpl *template.Template) error {
+	caCert := info.CertificateAuthority
+	if offline {
+		var err error
+		if caCert, err = kubeconfigCA(info); err != nil {
+			return err
+		}
+	}
+
+	clusterName := providerSpecificInfo["cluster-name"]
+	entries := []kubeconfigEntry{
+		newKubeconfigEntry(clusterName, kubeconfigUserName, info.Endpoint, caCert, providerSpecificInfo, extraEnv),
+	}
+	kubeconfig, err := renderKubeconfig(entries, clusterName, tmpl)
+	if err != nil {
+		return fmt.Errorf("dry run: failed to generate kubeconfig: %w", err)
+	}
+	fmt.Print(string(kubeconfig))
+	return nil
+}
+
+// writeKubeconfig writes the kubeconfig to --output, or to stdout when it is not set.
+// An existing file is first copied to backupPath. A written file is reported on status.
+func writeKubeconfig(ctx context.Context, log logger.Logger, status io.Writer, kubeconfig []byte, entries []kubeconfigEntry) error {
 	if outputFile != "" {
-		if err := os.WriteFile(outputFile, kubeconfig, 0600); err != nil {
+		// Other invocations may write the same file; lock and replace it atomically.
+		// The kubeconfig embeds credential paths, so it and its backup are always
+		// written 0600.
+		backedUp := false
+		err := filelock.Update(ctx, outputFile, lockTimeout, 0600, func(current []byte) ([]byte, error) {
+			if current == nil {
+				return kubeconfig, nil
+			}
+			if err := filelock.WriteAtomic(backupPath(outputFile), current, 0600); err != nil {
+				return nil, fmt.Errorf("failed to back up kubeconfig: %w", err)
+			}
+			backedUp = true
+			return kubeconfig, nil
+		})
+		if err != nil {
 			return fmt.Errorf("failed to write kubeconfig to file: %w", err)
 		}
-		log.Info("Kubeconfig written to file",
-			logger.String("file", outputFile),
-		)
-		fmt.Fprintf(os.Stderr, "✅ Kubeconfig generated: %s\n", outputFile)
+		if err := verifyKubeconfigEntries(outputFile, entries); err != nil {
+			return err
+		}
+		fields := []logger.Field{logger.String("file", outputFile)}
+		if backedUp {
+			fields = append(fields, logger.String("backup", backupPath(outputFile)))
+		}
+		log.Info("Kubeconfig written to file", fields...)
+		fmt.Fprintf(status, "✅ Kubeconfig generated: %s\n", outputFile)
 	} else {
 		fmt.Print(string(kubeconfig))
 	}
@@ -127,193 +496,495 @@ func run(flags *common.Flags) error {
 	return nil
 }
 
-func getGCPClusterInfoForKubeconfig(ctx context.Context, flags *common.Flags, log logger.Logger) (string, string, string, map[string]string, error) {
-	if flags.ProjectID == "" {
-		return "", "", "", nil, fmt.Errorf("--project-id is required for GCP")
-	}
-	if flags.Region == "" {
-		return "", "", "", nil, fmt.Errorf("--region is required for GCP (location can be region or zone)")
+// backupPath returns the file an existing kubeconfig at path is copied to before it is
+// replaced
+func backupPath(path string) string {
+	return path + ".bak"
+}
+
+// verifyKubeconfigEntries checks that the written kubeconfig still holds the clusters,
+// contexts and users this invocation added, catching writers that bypass the lock
+func verifyKubeconfigEntries(path string, entries []kubeconfigEntry) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read back kubeconfig: %w", err)
 	}
 
-	duration, err := common.ParseTokenDuration(flags)
+	missing, err := findMissingEntry(data, entries)
 	if err != nil {
-		return "", "", "", nil, err
+		return fmt.Errorf("kubeconfig %s was corrupted after writing: %w", path, err)
+	}
+	if missing != nil {
+		return fmt.Errorf("kubeconfig %s is missing the entries for cluster %s after writing; another process may have overwritten it", path, missing.Name)
 	}
+	return nil
+}
 
-	config := &gcp.Config{
-		ProjectID:       flags.ProjectID,
-		CredentialsFile: flags.CredentialsFile,
-		TokenDuration:   duration,
+// findMissingEntry returns the first of entries whose cluster, context or user the
+// kubeconfig in data lacks, or nil when all are present
+func findMissingEntry(data []byte, entries []kubeconfigEntry) (*kubeconfigEntry, error) {
+	type named struct {
+		Name string `yaml:"name"`
 	}
-	provider, err := gcp.NewProvider(config, log)
-	if err != nil {
-		return "", "", "", nil, fmt.Errorf("failed to create GCP provider: %w", err)
+	var written struct {
+		Clusters []named `yaml:"clusters"`
+		Contexts []named `yaml:"contexts"`
+		Users    []named `yaml:"users"`
+	}
+	if err := yaml.Unmarshal(data, &written); err != nil {
+		return nil, err
 	}
 
-	info, err := provider.GetClusterInfo(ctx, flags.ClusterName, flags.Region)
-	if err != nil {
-		return "", "", "", nil, fmt.Errorf("failed to get cluster info: %w", err)
+	present := func(list []named, want string) bool {
+		for _, item := range list {
+			if item.Name == want {
+				return true
+			}
+		}
+		return false
 	}
+	for i, entry := range entries {
+		if !present(written.Clusters, entry.Name) || !present(written.Contexts, entry.Name) || !present(written.Users, entry.UserName) {
+			return &entries[i], nil
+		}
+	}
+	return nil, nil
+}
 
-	endpoint := "https://" + info.Endpoint
-	providerInfo := map[string]string{
-		"provider":     "gcp",
-		"cluster-name": flags.ClusterName,
-		"project-id":   flags.ProjectID,
-		"region":       flags.Region,
-		"creds-env":    "GOOGLE_APPLICATION_CREDENTIALS",
-		"creds-path":   common.GetCredentialsPath(flags),
+// kubeconfigProviderInfo validates the provider flags and returns the values
+// used to build the exec plugin configuration
+func kubeconfigProviderInfo(flags *common.Flags) (map[string]string, error) {
+	if err := common.ValidateInputs(flags, provider.OperationKubeconfig); err != nil {
+		return nil, err
 	}
 
-	return endpoint, info.CertificateAuthority, info.Version, providerInfo, nil
+	// A malformed name would otherwise only fail when kubectl first runs the plugin
+	opts := provider.GetTokenOptions{
+		ClusterName:   flags.ClusterName,
+		Region:        flags.Region,
+		ProjectID:     flags.ProjectID,
+		ResourceGroup: flags.ResourceGroup,
+	}
+	if err := opts.Validate(flags.ProviderName); err != nil {
+		return nil, err
+	}
+
+	switch flags.ProviderName {
+	case "gcp":
+		return map[string]string{
+			"provider":     "gcp",
+			"cluster-name": flags.ClusterName,
+			"project-id":   flags.ProjectID,
+			"region":       flags.Region,
+			"gcp-use-adc":  flags.GCPUseADC,
+			"creds-dir":    flags.CredentialsDir,
+			"creds-env":    "GOOGLE_APPLICATION_CREDENTIALS",
+			"creds-path":   common.GetCredentialsPath(flags),
+		}, nil
+	case "aws":
+		if flags.STSEndpoint != "" {
+			if err := aws.ValidateSTSEndpoint(flags.STSEndpoint); err != nil {
+				return nil, err
+			}
+		}
+		return map[string]string{
+			"provider":     "aws",
+			"cluster-name": flags.ClusterName,
+			"region":       flags.Region,
+			"profile":      flags.AWSProfile,
+			"sts-endpoint": flags.STSEndpoint,
+			"creds-env":    "AWS_CREDENTIALS_FILE",
+			"creds-path":   common.GetCredentialsPath(flags),
+		}, nil
+	case "azure":
+		return map[string]string{
+			"provider":        "azure",
+			"cluster-name":    flags.ClusterName,
+			"subscription-id": flags.SubscriptionID,
+			"tenant-id":       flags.TenantID,
+			"resource-group":  flags.ResourceGroup,
+			"azure-cloud":     flags.AzureCloud,
+			"creds-env":       "AZURE_CREDENTIALS_FILE",
+			"creds-path":      common.GetCredentialsPath(flags),
+		}, nil
+	case "oci":
+		return map[string]string{
+			"provider":       "oci",
+			"cluster-name":   flags.ClusterName,
+			"region":         flags.Region,
+			"tenancy-id":     flags.TenancyID,
+			"user-id":        flags.UserID,
+			"compartment-id": flags.CompartmentID,
+			"creds-env":      "OCI_CLI_CONFIG_FILE",
+			"creds-path":     common.GetCredentialsPath(flags),
+		}, nil
+	case "digitalocean":
+		return map[string]string{
+			"provider":     "digitalocean",
+			"cluster-name": flags.ClusterName,
+			"creds-env":    "DIGITALOCEAN_CREDENTIALS_FILE",
+			"creds-path":   common.GetCredentialsPath(flags),
+		}, nil
+	case "oidc":
+		info := map[string]string{
+			"provider":           "oidc",
+			"cluster-name":       flags.ClusterName,
+			"issuer-url":         flags.OIDCIssuerURL,
+			"client-id":          flags.OIDCClientID,
+			"private-key-file":   flags.OIDCPrivateKeyFile,
+			"scopes":             flags.OIDCScopes,
+			"subject-token-file": flags.OIDCSubjectTokenFile,
+			"subject-token-type": flags.OIDCSubjectTokenType,
+			"creds-env":          "OIDC_CLIENT_SECRET_FILE",
+			"creds-path":         common.GetCredentialsPath(flags),
+		}
+		if flags.OIDCUseIDToken {
+			info["use-id-token"] = "true"
+		}
+		return info, nil
+	default:
+		return nil, fmt.Errorf("provider %s does not support kubeconfig generation", flags.ProviderName)
+	}
 }
 
-func getAWSClusterInfoForKubeconfig(ctx context.Context, flags *common.Flags, log logger.Logger) (string, string, string, map[string]string, error) {
-	if flags.Region == "" {
-		return "", "", "", nil, fmt.Errorf("--region is required for AWS")
+// applyKubeconfigCredentialsMap selects the credentials of the cluster lookup from
+// --credentials-map. The exec plugin gets the map through HFCP_CREDENTIALS_MAP instead
+// of a credentials file, so one variable serves every cluster; providerInfo must be
+// built first so that the exec arguments leave out the values of the entry.
+func applyKubeconfigCredentialsMap(flags *common.Flags, providerInfo map[string]string) error {
+	if !common.UsesCredentialsMap(flags) {
+		return nil
+	}
+	// kubectl runs the plugin from any directory
+	path, err := filepath.Abs(flags.CredentialsMap)
+	if err != nil {
+		return fmt.Errorf("failed to resolve --credentials-map: %w", err)
 	}
+	if err := common.ApplyCredentialsMap(flags); err != nil {
+		return err
+	}
+	providerInfo["credentials-map"] = path
+	return nil
+}
 
+// describeClusterForKubeconfig looks up the cluster endpoint and CA through the selected provider
+func describeClusterForKubeconfig(ctx context.Context, flags *common.Flags, log logger.Logger) (*common.ClusterInfo, error) {
 	duration, err := common.ParseTokenDuration(flags)
 	if err != nil {
-		return "", "", "", nil, err
+		return nil, err
 	}
+	return common.DescribeCluster(ctx, flags, duration, log)
+}
 
-	config := &aws.Config{
-		Region:          flags.Region,
-		CredentialsFile: flags.CredentialsFile,
-		TokenDuration:   duration,
+// verifyKubeconfigToken gets a token the way the kubeconfig's exec plugin will and checks
+// that the cluster API server accepts it
+func verifyKubeconfigToken(ctx context.Context, flags *common.Flags, info *common.ClusterInfo, log logger.Logger) error {
+	duration, err := common.ParseTokenDuration(flags)
+	if err != nil {
+		return err
 	}
-	provider, err := aws.NewProvider(config, log)
+	config := common.NewProviderConfig(flags, duration)
+	config.SkipCredentialCheck = skipCredCheck
+	prov, err := provider.New(flags.ProviderName, config, log)
 	if err != nil {
-		return "", "", "", nil, fmt.Errorf("failed to create AWS provider: %w", err)
+		return fmt.Errorf("failed to create %s provider: %w", flags.ProviderName, err)
 	}
 
-	info, err := provider.GetClusterInfo(ctx, flags.ClusterName)
-	if err != nil {
-		return "", "", "", nil, fmt.Errorf("failed to get cluster info: %w", err)
+	opts := provider.GetTokenOptions{
+		ClusterName:    flags.ClusterName,
+		Region:         flags.Region,
+		ProjectID:      flags.ProjectID,
+		AccountID:      flags.AccountID,
+		SubscriptionID: flags.SubscriptionID,
+		TenantID:       flags.TenantID,
+		ResourceGroup:  flags.ResourceGroup,
+		CompartmentID:  flags.CompartmentID,
+		Audience:       boundAudience,
+	}
+	if flags.ProviderName == "aws" {
+		opts.ClusterID = awsClusterID
 	}
 
-	providerInfo := map[string]string{
-		"provider":     "aws",
-		"cluster-name": flags.ClusterName,
-		"region":       flags.Region,
-		"creds-env":    "AWS_CREDENTIALS_FILE",
-		"creds-path":   common.GetCredentialsPath(flags),
+	tokenCtx, cancel := common.WithTimeout(ctx, flags)
+	defer cancel()
+	start := time.Now()
+	token, err := prov.GetToken(tokenCtx, opts)
+	if err != nil {
+		return fmt.Errorf("failed to get a token for --verify: %w", common.TimeoutError(tokenCtx, err, "get token", start))
 	}
 
-	return info.Endpoint, info.CertificateAuthority, info.Version, providerInfo, nil
+	return common.VerifyToken(ctx, flags, info, token.AccessToken, common.StatusOutput(flags), log)
 }
 
-func getAzureClusterInfoForKubeconfig(ctx context.Context, flags *common.Flags, log logger.Logger) (string, string, string, map[string]string, error) {
-	if flags.SubscriptionID == "" {
-		return "", "", "", nil, fmt.Errorf("--subscription-id is required for Azure")
+// validateRenderFlags checks the --exec-command and --proxy-url flags, replacing
+// --exec-command=self with the path of this binary
+func validateRenderFlags() error {
+	if strings.TrimSpace(execCommandPath) == "" {
+		return errors.New(errors.ErrInvalidArgument, "--exec-command must not be empty")
 	}
-	if flags.TenantID == "" {
-		return "", "", "", nil, fmt.Errorf("--tenant-id is required for Azure")
+	if execCommandPath == execCommandSelf {
+		self, err := resolveSelfCommand(os.Args[0])
+		if err != nil {
+			return fmt.Errorf("failed to resolve --exec-command=self: %w", err)
+		}
+		execCommandPath = self
 	}
-	if flags.ResourceGroup == "" {
-		return "", "", "", nil, fmt.Errorf("--resource-group is required for Azure")
+	if proxyURL != "" {
+		if err := validateProxyURL(proxyURL); err != nil {
+			return errors.Wrap(errors.ErrInvalidArgument, err, "invalid --proxy-url")
+		}
 	}
+	extraCA = ""
+	if extraCAFile != "" {
+		ca, err := common.LoadCAFile(extraCAFile)
+		if err != nil {
+			return errors.Wrap(errors.ErrInvalidArgument, err, "invalid --extra-ca-file")
+		}
+		extraCA = ca
+	}
+	return nil
+}
 
-	duration, err := common.ParseTokenDuration(flags)
+// kubeconfigCA returns the certificate-authority-data of the kubeconfig entry of info:
+// the cluster CA with --extra-ca-file appended
+func kubeconfigCA(info *common.ClusterInfo) (string, error) {
+	if extraCA == "" {
+		return info.CertificateAuthority, nil
+	}
+	ca, err := common.AppendCAData(info.CertificateAuthority, extraCA)
 	if err != nil {
-		return "", "", "", nil, err
+		return "", fmt.Errorf("failed to append --extra-ca-file: %w", err)
 	}
+	return ca, nil
+}
 
-	config := &azure.Config{
-		SubscriptionID:  flags.SubscriptionID,
-		TenantID:        flags.TenantID,
-		CredentialsFile: flags.CredentialsFile,
-		TokenDuration:   duration,
+// resolveSelfCommand returns the absolute path of the binary started as arg0. A bare
+// name was found through PATH, so it is looked up there the way the shell did.
+func resolveSelfCommand(arg0 string) (string, error) {
+	path := arg0
+	if filepath.Base(arg0) == arg0 {
+		resolved, err := exec.LookPath(arg0)
+		if err != nil {
+			return "", err
+		}
+		path = resolved
 	}
-	provider, err := azure.NewProvider(config, log)
+	return filepath.Abs(path)
+}
+
+// validateProxyURL checks a kubeconfig proxy-url: kubectl accepts http, https and
+// socks5 proxies
+func validateProxyURL(value string) error {
+	u, err := url.Parse(value)
 	if err != nil {
-		return "", "", "", nil, fmt.Errorf("failed to create Azure provider: %w", err)
+		return err
 	}
+	switch u.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return fmt.Errorf("%q must use http, https or socks5", value)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("%q has no host", value)
+	}
+	return nil
+}
 
-	info, err := provider.GetClusterInfo(ctx, flags.ClusterName, flags.ResourceGroup)
-	if err != nil {
-		return "", "", "", nil, fmt.Errorf("failed to get cluster info: %w", err)
+// addExecOptions records the flags passed to get-token by the exec plugin in providerInfo
+// for newKubeconfigEntry
+func addExecOptions(flags *common.Flags, providerInfo map[string]string) {
+	if flags.StrictPermissions {
+		providerInfo["strict-permissions"] = "true"
+	}
+	if boundAudience != "" {
+		providerInfo["audience"] = boundAudience
+	}
+	if awsClusterID != "" && flags.ProviderName == "aws" {
+		providerInfo["cluster-id"] = awsClusterID
 	}
+	if skipCredCheck && flags.ProviderName == "aws" {
+		providerInfo["skip-credential-check"] = "true"
+	}
+	if flags.PreferSecret && flags.ProviderName == "azure" {
+		providerInfo["prefer-secret"] = "true"
+	}
+}
 
-	providerInfo := map[string]string{
-		"provider":        "azure",
-		"cluster-name":    flags.ClusterName,
-		"subscription-id": flags.SubscriptionID,
-		"tenant-id":       flags.TenantID,
-		"resource-group":  flags.ResourceGroup,
-		"creds-env":       "AZURE_CREDENTIALS_FILE",
-		"creds-path":      common.GetCredentialsPath(flags),
+// addRenderOptions records the --exec-command and --proxy-url flags in providerInfo
+// for newKubeconfigEntry
+func addRenderOptions(providerInfo map[string]string) {
+	if execCommandPath != defaultExecCommand {
+		providerInfo["exec-command"] = execCommandPath
 	}
+	if proxyURL != "" {
+		providerInfo["proxy-url"] = proxyURL
+	}
+}
 
-	return info.Endpoint, info.CertificateAuthority, info.Version, providerInfo, nil
+// parseExecEnv parses NAME=VALUE entries into exec plugin env entries
+func parseExecEnv(entries []string) ([]execEnvVar, error) {
+	env := make([]execEnvVar, 0, len(entries))
+	for _, entry := range entries {
+		name, value, found := strings.Cut(entry, "=")
+		if !found {
+			return nil, errors.New(errors.ErrInvalidArgument, fmt.Sprintf("invalid --exec-env value %q: expected NAME=VALUE", entry))
+		}
+		if !envNamePattern.MatchString(name) {
+			return nil, errors.New(errors.ErrInvalidArgument, fmt.Sprintf("invalid --exec-env value %q: %q is not a valid environment variable name", entry, name))
+		}
+		env = append(env, execEnvVar{Name: name, Value: value})
+	}
+	return env, nil
 }
 
-func generateKubeconfigYAML(endpoint, caCert string, providerInfo map[string]string) ([]byte, error) {
-	clusterName := providerInfo["cluster-name"]
-	userName := "hyperfleet-user"
-	contextName := clusterName
+// kubeconfigEntry is one cluster, user and context of a kubeconfig
+type kubeconfigEntry struct {
+	// Name names both the cluster and the context
+	Name          string
+	UserName      string
+	Endpoint      string
+	CACert        string
+	ProxyURL      string
+	TLSServerName string
+	// Command defaults to defaultExecCommand
+	Command  string
+	ExecArgs []string
+	Env      []execEnvVar
+}
 
-	execArgs := []string{"get-token", "--provider=" + providerInfo["provider"], "--cluster-name=" + clusterName}
+// newKubeconfigEntry builds the entry whose exec plugin runs get-token for the cluster in providerInfo
+func newKubeconfigEntry(name, userName, endpoint, caCert string, providerInfo map[string]string, extraEnv []execEnvVar) kubeconfigEntry {
+	return kubeconfigEntry{
+		Name:          name,
+		UserName:      userName,
+		Endpoint:      endpoint,
+		CACert:        caCert,
+		ProxyURL:      providerInfo["proxy-url"],
+		TLSServerName: providerInfo["tls-server-name"],
+		Command:       providerInfo["exec-command"],
+		ExecArgs:      newExecArgs(providerInfo),
+		Env:           newExecEnv(providerInfo, extraEnv),
+	}
+}
+
+// newExecArgs builds the get-token arguments of the exec plugin for the cluster in providerInfo
+func newExecArgs(providerInfo map[string]string) []string {
+	execArgs := []string{"get-token", "--provider=" + providerInfo["provider"], "--cluster-name=" + providerInfo["cluster-name"]}
 
 	switch providerInfo["provider"] {
 	case "gcp":
 		execArgs = append(execArgs, "--project-id="+providerInfo["project-id"])
 		execArgs = append(execArgs, "--region="+providerInfo["region"])
+		if mode := providerInfo["gcp-use-adc"]; mode != "" && mode != "auto" {
+			execArgs = append(execArgs, "--gcp-use-adc="+mode)
+		}
+		if dir := providerInfo["creds-dir"]; dir != "" {
+			execArgs = append(execArgs, "--credentials-dir="+dir)
+		}
 	case "aws":
 		execArgs = append(execArgs, "--region="+providerInfo["region"])
+		if profile := providerInfo["profile"]; profile != "" {
+			execArgs = append(execArgs, "--profile="+profile)
+		}
+		if clusterID := providerInfo["cluster-id"]; clusterID != "" {
+			execArgs = append(execArgs, "--cluster-id="+clusterID)
+		}
+		if providerInfo["skip-credential-check"] == "true" {
+			execArgs = append(execArgs, "--skip-credential-check")
+		}
+		if stsEndpoint := providerInfo["sts-endpoint"]; stsEndpoint != "" {
+			execArgs = append(execArgs, "--sts-endpoint="+stsEndpoint)
+		}
 	case "azure":
 		execArgs = append(execArgs, "--subscription-id="+providerInfo["subscription-id"])
 		execArgs = append(execArgs, "--tenant-id="+providerInfo["tenant-id"])
+		if azureCloud := providerInfo["azure-cloud"]; azureCloud != "" {
+			execArgs = append(execArgs, "--azure-cloud="+azureCloud)
+		}
+		if providerInfo["prefer-secret"] == "true" {
+			execArgs = append(execArgs, "--prefer-secret")
+		}
+	case "oci":
+		// Everything else may come from the OCI config file, so only pass what was set
+		for _, name := range []string{"region", "tenancy-id", "user-id", "compartment-id"} {
+			if value := providerInfo[name]; value != "" {
+				execArgs = append(execArgs, "--"+name+"="+value)
+			}
+		}
+	case "oidc":
+		execArgs = append(execArgs, "--issuer-url="+providerInfo["issuer-url"])
+		execArgs = append(execArgs, "--client-id="+providerInfo["client-id"])
+		for _, name := range []string{"private-key-file", "scopes", "subject-token-file", "subject-token-type"} {
+			if value := providerInfo[name]; value != "" {
+				execArgs = append(execArgs, "--"+name+"="+value)
+			}
+		}
+		if providerInfo["use-id-token"] == "true" {
+			execArgs = append(execArgs, "--use-id-token")
+		}
+	}
+	if audience := providerInfo["audience"]; audience != "" {
+		execArgs = append(execArgs, "--audience="+audience)
+	}
+	if providerInfo["strict-permissions"] == "true" {
+		execArgs = append(execArgs, "--strict-permissions")
 	}
+	return execArgs
+}
 
-	kubeconfig := map[string]interface{}{
-		"apiVersion": "v1",
-		"kind":       "Config",
-		"clusters": []map[string]interface{}{
-			{
-				"name": clusterName,
-				"cluster": map[string]interface{}{
-					"server":                     endpoint,
-					"certificate-authority-data": caCert,
-				},
-			},
-		},
-		"users": []map[string]interface{}{
-			{
-				"name": userName,
-				"user": map[string]interface{}{
-					"exec": map[string]interface{}{
-						"apiVersion": "client.authentication.k8s.io/v1",
-						"command":    "hyperfleet-credential-provider",
-						"args":       execArgs,
-						"env": []map[string]string{
-							{
-								"name":  providerInfo["creds-env"],
-								"value": providerInfo["creds-path"],
-							},
-						},
-						"interactiveMode": "Never",
-					},
-				},
-			},
-		},
-		"contexts": []map[string]interface{}{
-			{
-				"name": contextName,
-				"context": map[string]interface{}{
-					"cluster": clusterName,
-					"user":    userName,
-				},
-			},
+// newExecEnv builds the exec plugin environment: the credentials of providerInfo, the AWS
+// region and profile for AWS, then extraEnv. An --exec-env entry replaces the AWS
+// variable of the same name.
+func newExecEnv(providerInfo map[string]string, extraEnv []execEnvVar) []execEnvVar {
+	if credentialsMap := providerInfo["credentials-map"]; credentialsMap != "" {
+		// get-token selects the credentials and profile of the cluster from the map
+		return append([]execEnvVar{{Name: "HFCP_CREDENTIALS_MAP", Value: credentialsMap}}, extraEnv...)
+	}
+
+	env := []execEnvVar{
+		{
+			Name:  providerInfo["creds-env"],
+			Value: providerInfo["creds-path"],
 		},
-		"current-context": contextName,
 	}
+	if providerInfo["provider"] == "aws" {
+		// The SDK reads these when kubectl runs the plugin with a different environment
+		awsEnv := []execEnvVar{{Name: "AWS_REGION", Value: providerInfo["region"]}}
+		if profile := providerInfo["profile"]; profile != "" {
+			awsEnv = append(awsEnv, execEnvVar{Name: "AWS_PROFILE", Value: profile})
+		}
+		for _, v := range awsEnv {
+			if v.Value != "" && !hasExecEnv(extraEnv, v.Name) {
+				env = append(env, v)
+			}
+		}
+	}
+	return append(env, extraEnv...)
+}
 
-	yamlData, err := yaml.Marshal(kubeconfig)
+// hasExecEnv reports whether env sets name
+func hasExecEnv(env []execEnvVar, name string) bool {
+	for _, v := range env {
+		if v.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// marshalKubeconfig renders entries, in the given order, as a kubeconfig YAML document
+func marshalKubeconfig(entries []kubeconfigEntry, currentContext string) ([]byte, error) {
+	yamlData, err := yaml.Marshal(newKubeconfigDocument(entries, currentContext))
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal kubeconfig to YAML: %w", err)
 	}
 
 	return yamlData, nil
 }
+
+// generateKubeconfigYAML generates a single-cluster kubeconfig
+func generateKubeconfigYAML(endpoint, caCert string, providerInfo map[string]string, extraEnv []execEnvVar) ([]byte, error) {
+	clusterName := providerInfo["cluster-name"]
+	entry := newKubeconfigEntry(clusterName, kubeconfigUserName, endpoint, caCert, providerInfo, extraEnv)
+	return marshalKubeconfig([]kubeconfigEntry{entry}, clusterName)
+}
@@ -0,0 +1,947 @@
+package kubeconfig
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/cmd/provider/common"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+)
+
+const testCAPEM = `-----BEGIN CERTIFICATE-----
+MIIBszCCAVmgAwIBAgIUTESTTESTTESTTESTTESTTESTTESTMAoGCCqGSM49BAMC
+-----END CERTIFICATE-----
+`
+
+func TestParseExecEnv(t *testing.T) {
+	tests := []struct {
+		name     string
+		entries  []string
+		expected []execEnvVar
+		wantErr  bool
+	}{
+		{
+			name:     "no entries",
+			entries:  nil,
+			expected: []execEnvVar{},
+		},
+		{
+			name:    "multiple entries",
+			entries: []string{"HTTPS_PROXY=http://proxy.example.com:3128", "AWS_REGION=us-east-1"},
+			expected: []execEnvVar{
+				{Name: "HTTPS_PROXY", Value: "http://proxy.example.com:3128"},
+				{Name: "AWS_REGION", Value: "us-east-1"},
+			},
+		},
+		{
+			name:    "value containing equals sign",
+			entries: []string{"HFCP_EXTRA=a=b"},
+			expected: []execEnvVar{
+				{Name: "HFCP_EXTRA", Value: "a=b"},
+			},
+		},
+		{
+			name:    "empty value",
+			entries: []string{"NO_PROXY="},
+			expected: []execEnvVar{
+				{Name: "NO_PROXY", Value: ""},
+			},
+		},
+		{
+			name:    "missing equals sign",
+			entries: []string{"HTTPS_PROXY"},
+			wantErr: true,
+		},
+		{
+			name:    "empty name",
+			entries: []string{"=value"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid name",
+			entries: []string{"1BAD-NAME=value"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env, err := parseExecEnv(tt.entries)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, env)
+		})
+	}
+}
+
+func TestGenerateKubeconfigYAML_ExecEnv(t *testing.T) {
+	providerInfo := map[string]string{
+		"provider":     "aws",
+		"cluster-name": "my-cluster",
+		"region":       "us-east-1",
+		"creds-env":    "AWS_CREDENTIALS_FILE",
+		"creds-path":   "/vault/secrets/aws-credentials",
+	}
+
+	extraEnv, err := parseExecEnv([]string{
+		"HTTPS_PROXY=http://proxy.example.com:3128",
+		"AWS_REGION=us-east-1",
+	})
+	require.NoError(t, err)
+
+	data, err := generateKubeconfigYAML("https://example.eks.amazonaws.com", "Y2EtZGF0YQ==", providerInfo, extraEnv)
+	require.NoError(t, err)
+
+	var kubeconfig struct {
+		Users []struct {
+			User struct {
+				Exec struct {
+					Env []execEnvVar `yaml:"env"`
+				} `yaml:"exec"`
+			} `yaml:"user"`
+		} `yaml:"users"`
+	}
+	require.NoError(t, yaml.Unmarshal(data, &kubeconfig))
+	require.Len(t, kubeconfig.Users, 1)
+
+	assert.Equal(t, []execEnvVar{
+		{Name: "AWS_CREDENTIALS_FILE", Value: "/vault/secrets/aws-credentials"},
+		{Name: "HTTPS_PROXY", Value: "http://proxy.example.com:3128"},
+		{Name: "AWS_REGION", Value: "us-east-1"},
+	}, kubeconfig.Users[0].User.Exec.Env)
+}
+
+func TestNewKubeconfigEntry_Audience(t *testing.T) {
+	providerInfo := map[string]string{
+		"provider":     "azure",
+		"cluster-name": "my-cluster",
+		"audience":     "api://aks-gateway",
+	}
+
+	entry := newKubeconfigEntry("my-cluster", kubeconfigUserName, "https://example.com", "Y2E=", providerInfo, nil)
+	assert.Contains(t, entry.ExecArgs, "--audience=api://aks-gateway")
+
+	delete(providerInfo, "audience")
+	entry = newKubeconfigEntry("my-cluster", kubeconfigUserName, "https://example.com", "Y2E=", providerInfo, nil)
+	for _, arg := range entry.ExecArgs {
+		assert.NotContains(t, arg, "--audience")
+	}
+}
+
+func TestNewKubeconfigEntry_AWSClusterID(t *testing.T) {
+	providerInfo := map[string]string{
+		"provider":     "aws",
+		"cluster-name": "my-cluster",
+		"region":       "us-east-1",
+		"cluster-id":   "eksa-cluster-id",
+	}
+
+	entry := newKubeconfigEntry("my-cluster", kubeconfigUserName, "https://example.com", "Y2E=", providerInfo, nil)
+	assert.Contains(t, entry.ExecArgs, "--cluster-name=my-cluster")
+	assert.Contains(t, entry.ExecArgs, "--cluster-id=eksa-cluster-id")
+
+	delete(providerInfo, "cluster-id")
+	entry = newKubeconfigEntry("my-cluster", kubeconfigUserName, "https://example.com", "Y2E=", providerInfo, nil)
+	for _, arg := range entry.ExecArgs {
+		assert.NotContains(t, arg, "--cluster-id")
+	}
+}
+
+func TestNewKubeconfigEntry_AWSSkipCredentialCheck(t *testing.T) {
+	providerInfo := map[string]string{
+		"provider":              "aws",
+		"cluster-name":          "my-cluster",
+		"region":                "us-east-1",
+		"skip-credential-check": "true",
+	}
+
+	entry := newKubeconfigEntry("my-cluster", kubeconfigUserName, "https://example.com", "Y2E=", providerInfo, nil)
+	assert.Contains(t, entry.ExecArgs, "--skip-credential-check")
+
+	delete(providerInfo, "skip-credential-check")
+	entry = newKubeconfigEntry("my-cluster", kubeconfigUserName, "https://example.com", "Y2E=", providerInfo, nil)
+	assert.NotContains(t, entry.ExecArgs, "--skip-credential-check")
+}
+
+func TestNewKubeconfigEntry_AzurePreferSecret(t *testing.T) {
+	flags := &common.Flags{ProviderName: "azure", ClusterName: "my-cluster", PreferSecret: true}
+	providerInfo := map[string]string{
+		"provider":        "azure",
+		"cluster-name":    "my-cluster",
+		"subscription-id": "sub-123",
+		"tenant-id":       "tenant-456",
+	}
+	addExecOptions(flags, providerInfo)
+
+	entry := newKubeconfigEntry("my-cluster", kubeconfigUserName, "https://example.com", "Y2E=", providerInfo, nil)
+	assert.Contains(t, entry.ExecArgs, "--prefer-secret")
+
+	flags.PreferSecret = false
+	delete(providerInfo, "prefer-secret")
+	addExecOptions(flags, providerInfo)
+	entry = newKubeconfigEntry("my-cluster", kubeconfigUserName, "https://example.com", "Y2E=", providerInfo, nil)
+	assert.NotContains(t, entry.ExecArgs, "--prefer-secret")
+}
+
+func TestNewKubeconfigEntry_AWSSTSEndpoint(t *testing.T) {
+	endpoint := "https://vpce-0123-abcd.sts.us-east-1.vpce.amazonaws.com"
+	flags := &common.Flags{ProviderName: "aws", ClusterName: "my-cluster", Region: "us-east-1", STSEndpoint: endpoint}
+	providerInfo, err := kubeconfigProviderInfo(flags)
+	require.NoError(t, err)
+
+	entry := newKubeconfigEntry("my-cluster", kubeconfigUserName, "https://example.com", "Y2E=", providerInfo, nil)
+	assert.Contains(t, entry.ExecArgs, "--sts-endpoint="+endpoint)
+
+	flags.STSEndpoint = ""
+	providerInfo, err = kubeconfigProviderInfo(flags)
+	require.NoError(t, err)
+	entry = newKubeconfigEntry("my-cluster", kubeconfigUserName, "https://example.com", "Y2E=", providerInfo, nil)
+	for _, arg := range entry.ExecArgs {
+		assert.NotContains(t, arg, "--sts-endpoint")
+	}
+
+	flags.STSEndpoint = "http://sts.us-east-1.amazonaws.com"
+	_, err = kubeconfigProviderInfo(flags)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, errors.ErrInvalidArgument))
+}
+
+func TestNewKubeconfigEntry_AWSProfile(t *testing.T) {
+	flags := &common.Flags{ProviderName: "aws", ClusterName: "my-cluster", Region: "us-east-1", AWSProfile: "deploy"}
+	providerInfo, err := kubeconfigProviderInfo(flags)
+	require.NoError(t, err)
+
+	entry := newKubeconfigEntry("my-cluster", kubeconfigUserName, "https://example.com", "Y2E=", providerInfo, nil)
+	assert.Contains(t, entry.ExecArgs, "--profile=deploy")
+
+	flags.AWSProfile = ""
+	providerInfo, err = kubeconfigProviderInfo(flags)
+	require.NoError(t, err)
+	entry = newKubeconfigEntry("my-cluster", kubeconfigUserName, "https://example.com", "Y2E=", providerInfo, nil)
+	for _, arg := range entry.ExecArgs {
+		assert.NotContains(t, arg, "--profile")
+	}
+}
+
+func TestNewKubeconfigEntry_AWSEnv(t *testing.T) {
+	flags := &common.Flags{ProviderName: "aws", ClusterName: "my-cluster", Region: "us-west-2", AWSProfile: "deploy", CredentialsFile: "/vault/secrets/aws-credentials"}
+	providerInfo, err := kubeconfigProviderInfo(flags)
+	require.NoError(t, err)
+
+	entry := newKubeconfigEntry("my-cluster", kubeconfigUserName, "https://example.com", "Y2E=", providerInfo, nil)
+	assert.Equal(t, []execEnvVar{
+		{Name: "AWS_CREDENTIALS_FILE", Value: "/vault/secrets/aws-credentials"},
+		{Name: "AWS_REGION", Value: "us-west-2"},
+		{Name: "AWS_PROFILE", Value: "deploy"},
+	}, entry.Env)
+
+	t.Run("without a profile", func(t *testing.T) {
+		delete(providerInfo, "profile")
+		entry := newKubeconfigEntry("my-cluster", kubeconfigUserName, "https://example.com", "Y2E=", providerInfo, nil)
+		assert.Equal(t, []execEnvVar{
+			{Name: "AWS_CREDENTIALS_FILE", Value: "/vault/secrets/aws-credentials"},
+			{Name: "AWS_REGION", Value: "us-west-2"},
+		}, entry.Env)
+	})
+
+	t.Run("exec-env replaces the injected value", func(t *testing.T) {
+		extraEnv := []execEnvVar{{Name: "AWS_REGION", Value: "eu-west-1"}}
+		entry := newKubeconfigEntry("my-cluster", kubeconfigUserName, "https://example.com", "Y2E=", providerInfo, extraEnv)
+		assert.Equal(t, []execEnvVar{
+			{Name: "AWS_CREDENTIALS_FILE", Value: "/vault/secrets/aws-credentials"},
+			{Name: "AWS_REGION", Value: "eu-west-1"},
+		}, entry.Env)
+	})
+
+	for _, providerName := range []string{"gcp", "azure"} {
+		t.Run(providerName+" is unaffected", func(t *testing.T) {
+			info := goldenProviderInfo(providerName)
+			entry := newKubeconfigEntry("my-cluster", kubeconfigUserName, "https://example.com", "Y2E=", info, nil)
+			assert.Equal(t, []execEnvVar{{Name: info["creds-env"], Value: info["creds-path"]}}, entry.Env)
+		})
+	}
+}
+
+func TestNewKubeconfigEntry_OIDC(t *testing.T) {
+	flags := &common.Flags{
+		ProviderName:         "oidc",
+		ClusterName:          "my-cluster",
+		OIDCIssuerURL:        "https://issuer.example.com",
+		OIDCClientID:         "hyperfleet",
+		OIDCSubjectTokenFile: "/var/run/secrets/tokens/oidc-token",
+		OIDCUseIDToken:       true,
+	}
+	providerInfo, err := kubeconfigProviderInfo(flags)
+	require.NoError(t, err)
+
+	entry := newKubeconfigEntry("my-cluster", kubeconfigUserName, "https://example.com", "Y2E=", providerInfo, nil)
+	assert.Equal(t, []string{
+		"get-token",
+		"--provider=oidc",
+		"--cluster-name=my-cluster",
+		"--issuer-url=https://issuer.example.com",
+		"--client-id=hyperfleet",
+		"--subject-token-file=/var/run/secrets/tokens/oidc-token",
+		"--use-id-token",
+	}, entry.ExecArgs)
+	assert.Equal(t, []execEnvVar{
+		{Name: "OIDC_CLIENT_SECRET_FILE", Value: "/vault/secrets/oidc-client-secret"},
+	}, entry.Env)
+
+	flags.OIDCIssuerURL = ""
+	_, err = kubeconfigProviderInfo(flags)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--issuer-url is required for OIDC")
+}
+
+func TestClusterInfoFile_RoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	infoFile := filepath.Join(dir, "cluster-info.json")
+
+	// Export cluster info the way get-cluster-info does for an EKS cluster
+	exported := &common.ClusterInfo{
+		Endpoint:             "https://ABCDEF.gr7.us-east-1.eks.amazonaws.com",
+		CertificateAuthority: base64.StdEncoding.EncodeToString([]byte(testCAPEM)),
+		Version:              "1.30",
+		Region:               "us-east-1",
+		ARN:                  "arn:aws:eks:us-east-1:123456789012:cluster/my-cluster",
+	}
+	f, err := os.Create(infoFile)
+	require.NoError(t, err)
+	require.NoError(t, common.WriteClusterInfo(f, "aws", exported))
+	require.NoError(t, f.Close())
+
+	// Regenerate a kubeconfig offline from the exported file
+	info, err := common.LoadOfflineClusterInfo(infoFile, "", "", "")
+	require.NoError(t, err)
+	assert.Equal(t, exported, info)
+
+	providerInfo, err := kubeconfigProviderInfo(&common.Flags{
+		ProviderName: "aws",
+		ClusterName:  "my-cluster",
+		Region:       "us-east-1",
+	})
+	require.NoError(t, err)
+
+	data, err := generateKubeconfigYAML(info.Endpoint, info.CertificateAuthority, providerInfo, nil)
+	require.NoError(t, err)
+
+	var kubeconfig struct {
+		Clusters []struct {
+			Cluster struct {
+				Server                   string `yaml:"server"`
+				CertificateAuthorityData string `yaml:"certificate-authority-data"`
+			} `yaml:"cluster"`
+		} `yaml:"clusters"`
+	}
+	require.NoError(t, yaml.Unmarshal(data, &kubeconfig))
+	require.Len(t, kubeconfig.Clusters, 1)
+	assert.Equal(t, exported.Endpoint, kubeconfig.Clusters[0].Cluster.Server)
+	assert.Equal(t, exported.CertificateAuthority, kubeconfig.Clusters[0].Cluster.CertificateAuthorityData)
+}
+
+func TestOfflineOverrides_Kubeconfig(t *testing.T) {
+	encodedCA := base64.StdEncoding.EncodeToString([]byte(testCAPEM))
+
+	// The endpoint and CA data are enough to write a kubeconfig without any lookup
+	info, err := common.LoadOfflineClusterInfo("", "api.my-cluster.example.com:6443", "", encodedCA)
+	require.NoError(t, err)
+
+	providerInfo, err := kubeconfigProviderInfo(&common.Flags{
+		ProviderName: "gcp",
+		ClusterName:  "my-cluster",
+		ProjectID:    "my-project",
+		Region:       "us-central1",
+	})
+	require.NoError(t, err)
+
+	data, err := generateKubeconfigYAML(info.Endpoint, info.CertificateAuthority, providerInfo, nil)
+	require.NoError(t, err)
+	commands, err := execCommands(data)
+	require.NoError(t, err)
+	assert.Len(t, commands, 1, "the exec plugin stanza is written")
+
+	var kubeconfig struct {
+		Clusters []struct {
+			Cluster struct {
+				Server                   string `yaml:"server"`
+				CertificateAuthorityData string `yaml:"certificate-authority-data"`
+			} `yaml:"cluster"`
+		} `yaml:"clusters"`
+	}
+	require.NoError(t, yaml.Unmarshal(data, &kubeconfig))
+	require.Len(t, kubeconfig.Clusters, 1)
+	assert.Equal(t, "https://api.my-cluster.example.com:6443", kubeconfig.Clusters[0].Cluster.Server)
+	assert.Equal(t, encodedCA, kubeconfig.Clusters[0].Cluster.CertificateAuthorityData)
+}
+
+func TestKubeconfigProviderInfo_InvalidClusterName(t *testing.T) {
+	_, err := kubeconfigProviderInfo(&common.Flags{
+		ProviderName: "gcp",
+		ClusterName:  "My_Cluster",
+		ProjectID:    "my-project",
+		Region:       "us-central1",
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid GKE cluster name")
+}
+
+func TestKubeconfigProviderInfo_AzureCloud(t *testing.T) {
+	flags := &common.Flags{
+		ProviderName:   "azure",
+		ClusterName:    "my-cluster",
+		SubscriptionID: "sub",
+		TenantID:       "tenant",
+		ResourceGroup:  "my-rg",
+		AzureCloud:     "usgovernment",
+	}
+
+	providerInfo, err := kubeconfigProviderInfo(flags)
+	require.NoError(t, err)
+	entry := newKubeconfigEntry("my-cluster", kubeconfigUserName, "https://example.com", "Y2E=", providerInfo, nil)
+	assert.Contains(t, entry.ExecArgs, "--azure-cloud=usgovernment")
+
+	flags.AzureCloud = "germany"
+	_, err = kubeconfigProviderInfo(flags)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, errors.ErrInvalidArgument))
+	assert.Contains(t, err.Error(), "--azure-cloud is invalid")
+}
+
+func TestLoadOfflineClusterInfo(t *testing.T) {
+	dir := t.TempDir()
+
+	caFile := filepath.Join(dir, "ca.crt")
+	require.NoError(t, os.WriteFile(caFile, []byte(testCAPEM), 0600))
+
+	notPEMFile := filepath.Join(dir, "not-pem.crt")
+	require.NoError(t, os.WriteFile(notPEMFile, []byte("not a certificate"), 0600))
+
+	infoFile := filepath.Join(dir, "cluster-info.json")
+	require.NoError(t, os.WriteFile(infoFile, []byte(`{
+  "endpoint": "https://from-file.example.com",
+  "certificateAuthority": "Y2EtZGF0YQ==",
+  "version": "1.30"
+}`), 0600))
+
+	httpInfoFile := filepath.Join(dir, "http-cluster-info.json")
+	require.NoError(t, os.WriteFile(httpInfoFile, []byte(`{
+  "endpoint": "http://from-file.example.com",
+  "certificateAuthority": "Y2EtZGF0YQ=="
+}`), 0600))
+
+	encodedCA := base64.StdEncoding.EncodeToString([]byte(testCAPEM))
+
+	tests := []struct {
+		name         string
+		infoFile     string
+		endpoint     string
+		caFile       string
+		caData       string
+		wantEndpoint string
+		wantCA       string
+		wantErr      string
+	}{
+		{
+			name:         "explicit endpoint and CA file",
+			endpoint:     "https://explicit.example.com",
+			caFile:       caFile,
+			wantEndpoint: "https://explicit.example.com",
+			wantCA:       encodedCA,
+		},
+		{
+			name:         "bare host gets https scheme",
+			endpoint:     "explicit.example.com",
+			caFile:       caFile,
+			wantEndpoint: "https://explicit.example.com",
+			wantCA:       encodedCA,
+		},
+		{
+			name:         "explicit endpoint and base64 CA data",
+			endpoint:     "https://explicit.example.com",
+			caData:       encodedCA,
+			wantEndpoint: "https://explicit.example.com",
+			wantCA:       encodedCA,
+		},
+		{
+			name:         "explicit endpoint and PEM CA data",
+			endpoint:     "https://explicit.example.com",
+			caData:       testCAPEM,
+			wantEndpoint: "https://explicit.example.com",
+			wantCA:       encodedCA,
+		},
+		{
+			name:         "CA data overrides file",
+			infoFile:     infoFile,
+			caData:       encodedCA,
+			wantEndpoint: "https://from-file.example.com",
+			wantCA:       encodedCA,
+		},
+		{
+			name:         "explicit endpoint overrides file",
+			infoFile:     infoFile,
+			endpoint:     "https://explicit.example.com",
+			wantEndpoint: "https://explicit.example.com",
+			wantCA:       "Y2EtZGF0YQ==",
+		},
+		{
+			name:     "endpoint without CA",
+			endpoint: "https://explicit.example.com",
+			wantErr:  "--cluster-endpoint requires --cluster-ca-file or --cluster-ca-data",
+		},
+		{
+			name:    "CA data without endpoint",
+			caData:  encodedCA,
+			wantErr: "--cluster-ca-file and --cluster-ca-data require --cluster-endpoint",
+		},
+		{
+			name:     "CA file and CA data",
+			endpoint: "https://explicit.example.com",
+			caFile:   caFile,
+			caData:   encodedCA,
+			wantErr:  "cannot be combined",
+		},
+		{
+			name:     "plain http endpoint",
+			endpoint: "http://explicit.example.com",
+			caFile:   caFile,
+			wantErr:  "must use https",
+		},
+		{
+			name:     "CA file is not PEM",
+			endpoint: "https://explicit.example.com",
+			caFile:   notPEMFile,
+			wantErr:  "does not contain a PEM certificate",
+		},
+		{
+			name:     "CA data is not base64 or PEM",
+			endpoint: "https://explicit.example.com",
+			caData:   "not a certificate",
+			wantErr:  "neither PEM nor base64-encoded PEM",
+		},
+		{
+			name:     "CA data is not a certificate",
+			endpoint: "https://explicit.example.com",
+			caData:   "Y2EtZGF0YQ==",
+			wantErr:  "does not contain a PEM certificate",
+		},
+		{
+			name:     "missing info file",
+			infoFile: filepath.Join(dir, "missing.json"),
+			wantErr:  "failed to read cluster info file",
+		},
+		{
+			name:     "plain http endpoint in info file",
+			infoFile: httpInfoFile,
+			wantErr:  "incomplete offline cluster info: cluster endpoint must use https: http://from-file.example.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := common.LoadOfflineClusterInfo(tt.infoFile, tt.endpoint, tt.caFile, tt.caData)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				assert.Equal(t, common.ExitInvalidArgument, common.ExitCode(err))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantEndpoint, info.Endpoint)
+			assert.Equal(t, tt.wantCA, info.CertificateAuthority)
+		})
+	}
+}
+
+func TestVerifyKubeconfigEntries(t *testing.T) {
+	generated, err := generateKubeconfigYAML("https://1.2.3.4", "Y2E=", map[string]string{
+		"provider":     "aws",
+		"cluster-name": "my-cluster",
+		"region":       "us-east-1",
+	}, nil)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{
+			name:    "entries present",
+			content: string(generated),
+		},
+		{
+			name: "overwritten by another cluster",
+			content: `clusters:
+- name: other-cluster
+contexts:
+- name: other-cluster
+users:
+- name: hyperfleet-user
+`,
+			wantErr: "missing the entries for cluster my-cluster",
+		},
+		{
+			name:    "corrupted",
+			content: "clusters: [\n",
+			wantErr: "corrupted",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "kubeconfig")
+			require.NoError(t, os.WriteFile(path, []byte(tt.content), 0600))
+
+			err := verifyKubeconfigEntries(path, []kubeconfigEntry{{Name: "my-cluster", UserName: kubeconfigUserName}})
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestWriteKubeconfig(t *testing.T) {
+	generated, err := generateKubeconfigYAML("https://1.2.3.4", "Y2E=", map[string]string{
+		"provider":     "aws",
+		"cluster-name": "my-cluster",
+		"region":       "us-east-1",
+	}, nil)
+	require.NoError(t, err)
+	entries := []kubeconfigEntry{{Name: "my-cluster", UserName: kubeconfigUserName}}
+	previous := []byte("apiVersion: v1\nkind: Config\nclusters: []\n")
+
+	setOutput := func(t *testing.T, path string) {
+		old := outputFile
+		t.Cleanup(func() { outputFile = old })
+		outputFile = path
+	}
+
+	t.Run("new file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "kubeconfig")
+		setOutput(t, path)
+
+		require.NoError(t, writeKubeconfig(context.Background(), logger.Nop(), io.Discard, generated, entries))
+		written, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, generated, written, "the file should hold the complete kubeconfig")
+		assert.NoFileExists(t, backupPath(path), "there is nothing to back up")
+		if runtime.GOOS != "windows" {
+			info, err := os.Stat(path)
+			require.NoError(t, err)
+			assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+		}
+	})
+
+	t.Run("overwrite backs up the existing file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "kubeconfig")
+		require.NoError(t, os.WriteFile(path, previous, 0644))
+		setOutput(t, path)
+
+		require.NoError(t, writeKubeconfig(context.Background(), logger.Nop(), io.Discard, generated, entries))
+		written, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, generated, written)
+		backup, err := os.ReadFile(backupPath(path))
+		require.NoError(t, err)
+		assert.Equal(t, previous, backup)
+		if runtime.GOOS != "windows" {
+			for _, file := range []string{path, backupPath(path)} {
+				info, err := os.Stat(file)
+				require.NoError(t, err)
+				assert.Equal(t, os.FileMode(0600), info.Mode().Perm(), file)
+			}
+		}
+		entries, err := os.ReadDir(filepath.Dir(path))
+		require.NoError(t, err)
+		for _, entry := range entries {
+			assert.False(t, strings.Contains(entry.Name(), ".tmp-"), "temp file %s should be removed", entry.Name())
+		}
+	})
+
+	t.Run("failed write leaves the original intact", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "kubeconfig")
+		require.NoError(t, os.WriteFile(path, previous, 0600))
+		// A directory in place of the backup makes the backup, and so the write, fail
+		require.NoError(t, os.MkdirAll(filepath.Join(backupPath(path), "keep"), 0700))
+		setOutput(t, path)
+
+		err := writeKubeconfig(context.Background(), logger.Nop(), io.Discard, generated, entries)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to back up kubeconfig")
+		written, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, previous, written, "the original kubeconfig should be unchanged")
+	})
+}
+
+func TestExecCommand(t *testing.T) {
+	self, err := filepath.Abs(os.Args[0])
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		execCommand string
+		want        string
+		wantErr     string
+	}{
+		{
+			name:        "default",
+			execCommand: defaultExecCommand,
+			want:        "hyperfleet-credential-provider",
+		},
+		{
+			name:        "explicit path",
+			execCommand: "/opt/hyperfleet/bin/hfcp",
+			want:        "/opt/hyperfleet/bin/hfcp",
+		},
+		{
+			name:        "self",
+			execCommand: "self",
+			want:        self,
+		},
+		{
+			name:        "empty",
+			execCommand: " ",
+			wantErr:     "--exec-command must not be empty",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			old := execCommandPath
+			t.Cleanup(func() { execCommandPath = old })
+			execCommandPath = tt.execCommand
+
+			err := validateRenderFlags()
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			providerInfo := map[string]string{"provider": "aws", "cluster-name": "my-cluster", "region": "us-east-1"}
+			addRenderOptions(providerInfo)
+			data, err := generateKubeconfigYAML("https://1.2.3.4", "Y2E=", providerInfo, nil)
+			require.NoError(t, err)
+
+			var doc kubeconfigDocument
+			require.NoError(t, yaml.Unmarshal(data, &doc))
+			require.Len(t, doc.Users, 1)
+			assert.Equal(t, tt.want, doc.Users[0].User.Exec.Command)
+		})
+	}
+}
+
+func TestExtraCAFile(t *testing.T) {
+	extraPEM := strings.Replace(testCAPEM, "TESTTEST", "EXTRAEXT", 1)
+	dir := t.TempDir()
+	extraFile := filepath.Join(dir, "corp-ca.pem")
+	require.NoError(t, os.WriteFile(extraFile, []byte(extraPEM), 0600))
+	notPEM := filepath.Join(dir, "not-pem.txt")
+	require.NoError(t, os.WriteFile(notPEM, []byte("not a certificate"), 0600))
+
+	old := extraCAFile
+	t.Cleanup(func() {
+		extraCAFile = old
+		extraCA = ""
+	})
+
+	info := &common.ClusterInfo{CertificateAuthority: base64.StdEncoding.EncodeToString([]byte(testCAPEM))}
+
+	extraCAFile = ""
+	require.NoError(t, validateRenderFlags())
+	ca, err := kubeconfigCA(info)
+	require.NoError(t, err)
+	assert.Equal(t, info.CertificateAuthority, ca, "without --extra-ca-file the cluster CA is used as is")
+
+	extraCAFile = extraFile
+	require.NoError(t, validateRenderFlags())
+	ca, err = kubeconfigCA(info)
+	require.NoError(t, err)
+	decoded, err := base64.StdEncoding.DecodeString(ca)
+	require.NoError(t, err)
+	assert.Equal(t, testCAPEM+extraPEM, string(decoded))
+
+	extraCAFile = notPEM
+	err = validateRenderFlags()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid --extra-ca-file")
+}
+
+func TestResolveSelfCommand(t *testing.T) {
+	dir := t.TempDir()
+	name := "hfcp-test-binary"
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	binary := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(binary, []byte("#!/bin/sh\n"), 0755))
+
+	t.Run("absolute path", func(t *testing.T) {
+		resolved, err := resolveSelfCommand(binary)
+		require.NoError(t, err)
+		assert.Equal(t, binary, resolved)
+	})
+
+	t.Run("relative path", func(t *testing.T) {
+		t.Chdir(dir)
+		resolved, err := resolveSelfCommand("." + string(filepath.Separator) + name)
+		require.NoError(t, err)
+		assert.Equal(t, binary, resolved)
+	})
+
+	t.Run("bare name is looked up on PATH", func(t *testing.T) {
+		t.Setenv("PATH", dir)
+		resolved, err := resolveSelfCommand("hfcp-test-binary")
+		require.NoError(t, err)
+		assert.Equal(t, binary, resolved)
+	})
+
+	t.Run("bare name not on PATH", func(t *testing.T) {
+		t.Setenv("PATH", t.TempDir())
+		_, err := resolveSelfCommand("hfcp-test-binary")
+		require.Error(t, err)
+	})
+}
+
+// runGenerateKubeconfig runs generate-kubeconfig the way main does, returning what it
+// printed to the process stdout and stderr
+func runGenerateKubeconfig(t *testing.T, args ...string) (stdout, stderr string, err error) {
+	t.Helper()
+
+	flags := &common.Flags{}
+	root := &cobra.Command{
+		Use:           "hyperfleet-credential-provider",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			flags.Viper = common.NewViper(cmd)
+		},
+	}
+	root.PersistentFlags().StringVar(&flags.LogLevel, "log-level", "error", "")
+	root.PersistentFlags().StringVar(&flags.LogFormat, "log-format", "json", "")
+	root.PersistentFlags().StringVar(&flags.CredentialsFile, "credentials-file", "", "")
+	root.PersistentFlags().StringVar(&flags.CredentialsMap, "credentials-map", "", "")
+	root.PersistentFlags().BoolVar(&flags.DryRun, "dry-run", false, "")
+	root.AddCommand(NewCommand(flags))
+	root.SetArgs(append([]string{"generate-kubeconfig"}, args...))
+
+	stdoutFile := filepath.Join(t.TempDir(), "stdout")
+	stderrFile := filepath.Join(t.TempDir(), "stderr")
+	for target, path := range map[**os.File]string{&os.Stdout: stdoutFile, &os.Stderr: stderrFile} {
+		f, createErr := os.Create(path)
+		require.NoError(t, createErr)
+		original := *target
+		*target = f
+		defer func() {
+			*target = original
+			f.Close()
+		}()
+	}
+	err = root.Execute()
+
+	out, readErr := os.ReadFile(stdoutFile)
+	require.NoError(t, readErr)
+	errOut, readErr := os.ReadFile(stderrFile)
+	require.NoError(t, readErr)
+	return string(out), string(errOut), err
+}
+
+func TestGenerateKubeconfig_DryRun(t *testing.T) {
+	for _, name := range []string{"AWS_CREDENTIALS_FILE", "AWS_CONFIG_FILE", "AWS_PROFILE", "AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_WEB_IDENTITY_TOKEN_FILE", "AWS_ROLE_ARN"} {
+		t.Setenv(name, "")
+	}
+	dir := t.TempDir()
+	credentialsFile := filepath.Join(dir, "credentials")
+	require.NoError(t, os.WriteFile(credentialsFile, []byte("[default]\naws_access_key_id = AKIAVALIDVALIDVALID0\naws_secret_access_key = secret\n"), 0600))
+	output := filepath.Join(dir, "kubeconfig.yaml")
+	awsArgs := []string{"--dry-run", "--provider=aws", "--cluster-name=my-cluster", "--region=us-east-1", "--credentials-file=" + credentialsFile, "--output=" + output}
+
+	t.Run("placeholders without a cluster lookup", func(t *testing.T) {
+		stdout, stderr, err := runGenerateKubeconfig(t, awsArgs...)
+		require.NoError(t, err)
+		assert.Contains(t, stderr, "Dry run: would generate a kubeconfig")
+
+		var doc map[string]interface{}
+		require.NoError(t, yaml.Unmarshal([]byte(stdout), &doc), "stdout is the kubeconfig alone")
+		assert.Contains(t, stdout, "server: "+dryRunEndpoint)
+		assert.Contains(t, stdout, "certificate-authority-data: "+dryRunCA)
+		assert.Contains(t, stdout, "--cluster-name=my-cluster")
+		assert.NoFileExists(t, output, "a dry run does not write --output")
+	})
+
+	t.Run("offline cluster info is rendered", func(t *testing.T) {
+		caData := base64.StdEncoding.EncodeToString([]byte(testCAPEM))
+		stdout, _, err := runGenerateKubeconfig(t, append(awsArgs, "--cluster-endpoint=https://api.my-cluster.example.com", "--cluster-ca-data="+caData)...)
+		require.NoError(t, err)
+		assert.Contains(t, stdout, "server: https://api.my-cluster.example.com")
+		assert.Contains(t, stdout, caData)
+		assert.NotContains(t, stdout, "DRY-RUN")
+	})
+
+	t.Run("local validation failures keep their error codes", func(t *testing.T) {
+		_, _, err := runGenerateKubeconfig(t, append(awsArgs, "--token-duration=2h")...)
+		require.Error(t, err)
+		assert.Equal(t, common.ExitInvalidArgument, common.ExitCode(err))
+
+		missing := filepath.Join(dir, "missing")
+		stdout, _, err := runGenerateKubeconfig(t, "--dry-run", "--provider=aws", "--cluster-name=my-cluster", "--region=us-east-1", "--credentials-file="+missing)
+		require.Error(t, err)
+		assert.Equal(t, common.ExitCredential, common.ExitCode(err))
+		assert.Empty(t, stdout)
+	})
+
+	t.Run("repeated cluster names run in batch mode", func(t *testing.T) {
+		stdout, _, err := runGenerateKubeconfig(t, "--dry-run", "--provider=aws", "--cluster-name=eks-b", "--cluster-name=eks-a", "--region=us-east-1", "--credentials-file="+credentialsFile)
+		require.NoError(t, err)
+		assert.Contains(t, stdout, "add a cluster to a batch kubeconfig")
+		assert.Less(t, strings.Index(stdout, "eks-a"), strings.Index(stdout, "eks-b"), "clusters are sorted by name")
+
+		_, _, err = runGenerateKubeconfig(t, "--dry-run", "--provider=aws", "--cluster-name=eks-a", "--cluster-name=eks-b", "--cluster-id=eks", "--region=us-east-1")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "--cluster-id cannot be used with repeated --cluster-name")
+	})
+}
+
+func TestGenerateKubeconfig_CredentialsMap(t *testing.T) {
+	for _, name := range []string{"AWS_CREDENTIALS_FILE", "AWS_CONFIG_FILE", "AWS_PROFILE", "AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_WEB_IDENTITY_TOKEN_FILE", "AWS_ROLE_ARN", "HFCP_CREDENTIALS_FILE", "HFCP_PROFILE"} {
+		t.Setenv(name, "")
+	}
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "aws-prod"), []byte("[deploy]\naws_access_key_id = AKIAVALIDVALIDVALID0\naws_secret_access_key = secret\n"), 0600))
+	mapFile := filepath.Join(dir, "credentials-map.yaml")
+	require.NoError(t, os.WriteFile(mapFile, []byte("aws/my-cluster:\n  credentials_file: aws-prod\n  profile: deploy\n"), 0600))
+	awsArgs := []string{"--dry-run", "--provider=aws", "--region=us-east-1", "--credentials-map=" + mapFile}
+
+	stdout, _, err := runGenerateKubeconfig(t, append(awsArgs, "--cluster-name=my-cluster")...)
+	require.NoError(t, err, "the credentials of the entry are checked")
+	assert.Contains(t, stdout, "name: HFCP_CREDENTIALS_MAP")
+	assert.Contains(t, stdout, "value: "+mapFile)
+	assert.NotContains(t, stdout, "AWS_CREDENTIALS_FILE", "the kubeconfig names the map, not a credentials file")
+	assert.NotContains(t, stdout, "--profile", "get-token reads the profile from the map")
+
+	_, _, err = runGenerateKubeconfig(t, append(awsArgs, "--cluster-name=other-cluster")...)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, errors.ErrCredentialNotFound), "got %v", err)
+	assert.Contains(t, err.Error(), "aws/other-cluster")
+}
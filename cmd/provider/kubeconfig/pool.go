@@ -0,0 +1,127 @@
+package kubeconfig
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/cmd/provider/common"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+)
+
+// describerFactory creates the provider that looks up clusters for flags
+type describerFactory func(flags *common.Flags, log logger.Logger) (provider.ClusterDescriber, error)
+
+// newClusterDescriber is the describerFactory backed by the provider registry
+func newClusterDescriber(flags *common.Flags, log logger.Logger) (provider.ClusterDescriber, error) {
+	duration, err := common.ParseTokenDuration(flags)
+	if err != nil {
+		return nil, err
+	}
+	return common.NewClusterDescriber(flags, duration, log)
+}
+
+// providerPool shares one provider instance, with its SDK clients and cached cloud
+// tokens, between the batch clusters that use the same provider, credentials and
+// region family. It is safe for concurrent use by the batch workers.
+type providerPool struct {
+	factory describerFactory
+
+	mu      sync.Mutex
+	entries map[string]*pooledDescriber
+}
+
+// pooledDescriber is created once by the first worker that needs it; the others wait
+type pooledDescriber struct {
+	once      sync.Once
+	describer provider.ClusterDescriber
+	err       error
+}
+
+func newProviderPool(factory describerFactory) *providerPool {
+	return &providerPool{
+		factory: factory,
+		entries: make(map[string]*pooledDescriber),
+	}
+}
+
+// describe is a describeFunc that looks the cluster up with the pooled provider
+func (p *providerPool) describe(ctx context.Context, flags *common.Flags, log logger.Logger) (*common.ClusterInfo, error) {
+	describer, err := p.get(flags, log)
+	if err != nil {
+		return nil, err
+	}
+	return common.DescribeClusterWith(ctx, describer, flags)
+}
+
+// get returns the provider for flags, creating it on first use. A failed creation is
+// shared too, so clusters with broken settings do not retry it one by one.
+func (p *providerPool) get(flags *common.Flags, log logger.Logger) (provider.ClusterDescriber, error) {
+	key := poolKey(flags)
+
+	p.mu.Lock()
+	entry, ok := p.entries[key]
+	if !ok {
+		entry = &pooledDescriber{}
+		p.entries[key] = entry
+	}
+	p.mu.Unlock()
+
+	entry.once.Do(func() {
+		shared := *flags
+		if provider.CapabilitiesOf(flags.ProviderName).LookupAnyRegion {
+			// The region comes with each lookup, so it must not leak between clusters
+			shared.Region = ""
+		}
+		log.Debug("Creating shared provider for batch clusters", logger.String("provider", flags.ProviderName))
+		entry.describer, entry.err = p.factory(&shared, log)
+	})
+	return entry.describer, entry.err
+}
+
+// poolKey identifies the provider instance that can serve flags: the provider, its
+// configuration and credentials, and the region for providers whose clients are bound
+// to one region
+func poolKey(flags *common.Flags) string {
+	config := *common.NewProviderConfig(flags, 0)
+	config.Tracing = nil
+	config.HTTPClient = nil
+	if provider.CapabilitiesOf(flags.ProviderName).LookupAnyRegion {
+		config.Region = ""
+	}
+	return fmt.Sprintf("%s|%+v", flags.ProviderName, config)
+}
+
+// rateLimiter admits at most one cluster lookup per interval across all workers
+type rateLimiter struct {
+	ticker *time.Ticker
+}
+
+// newRateLimiter returns a limiter for perSecond lookups, or nil for no limit
+func newRateLimiter(perSecond float64) *rateLimiter {
+	if perSecond <= 0 {
+		return nil
+	}
+	return &rateLimiter{ticker: time.NewTicker(time.Duration(float64(time.Second) / perSecond))}
+}
+
+// wait blocks until the next lookup is admitted or ctx is done
+func (l *rateLimiter) wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+	select {
+	case <-l.ticker.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (l *rateLimiter) stop() {
+	if l != nil {
+		l.ticker.Stop()
+	}
+}
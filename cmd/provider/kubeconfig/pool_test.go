@@ -0,0 +1,228 @@
+package kubeconfig
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/cmd/provider/common"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+)
+
+// fakeDescriber answers for any cluster after an optional delay and records its identity
+type fakeDescriber struct {
+	id     int
+	region string
+	delay  time.Duration
+}
+
+func (d *fakeDescriber) DescribeCluster(ctx context.Context, opts provider.ClusterInfoOptions) (*provider.ClusterInfo, error) {
+	if d.delay > 0 {
+		time.Sleep(d.delay)
+	}
+	region := opts.Region
+	if region == "" {
+		region = d.region
+	}
+	return &provider.ClusterInfo{
+		Endpoint:             fmt.Sprintf("https://%s.%s.example.com", opts.ClusterName, region),
+		CertificateAuthority: fmt.Sprintf("describer-%d", d.id),
+	}, nil
+}
+
+// fakeFactory counts the providers it creates. Creation takes setup, standing in for
+// SDK clients, TLS handshakes and cloud token fetches.
+type fakeFactory struct {
+	setup   time.Duration
+	lookup  time.Duration
+	created atomic.Int32
+}
+
+func (f *fakeFactory) create(flags *common.Flags, log logger.Logger) (provider.ClusterDescriber, error) {
+	id := int(f.created.Add(1))
+	if f.setup > 0 {
+		time.Sleep(f.setup)
+	}
+	return &fakeDescriber{id: id, region: flags.Region, delay: f.lookup}, nil
+}
+
+// perClusterDescribe is the describeFunc that builds a new provider for every cluster
+func (f *fakeFactory) perClusterDescribe(ctx context.Context, flags *common.Flags, log logger.Logger) (*common.ClusterInfo, error) {
+	describer, err := f.create(flags, log)
+	if err != nil {
+		return nil, err
+	}
+	return common.DescribeClusterWith(ctx, describer, flags)
+}
+
+func TestProviderPool_SharesProviders(t *testing.T) {
+	setBatchFlags(t, "", "", 4, false)
+
+	clusters := []batchCluster{
+		{Provider: "gcp", Name: "gke-a", Region: "us-central1", ProjectID: "my-project"},
+		{Provider: "gcp", Name: "gke-b", Region: "europe-west1", ProjectID: "my-project"},
+		{Provider: "gcp", Name: "gke-c", Region: "us-central1", ProjectID: "other-project"},
+		{Provider: "aws", Name: "eks-a", Region: "us-east-1"},
+		{Provider: "aws", Name: "eks-b", Region: "us-east-1"},
+		{Provider: "aws", Name: "eks-c", Region: "eu-west-1"},
+		{Provider: "azure", Name: "aks-a", SubscriptionID: "sub", TenantID: "tenant", ResourceGroup: "rg-a"},
+		{Provider: "azure", Name: "aks-b", SubscriptionID: "sub", TenantID: "tenant", ResourceGroup: "rg-b"},
+	}
+
+	factory := &fakeFactory{}
+	pool := newProviderPool(factory.create)
+	results := generateBatch(context.Background(), &common.Flags{}, clusters, nil, logger.Nop(), pool.describe)
+
+	byName := make(map[string]batchResult, len(results))
+	for _, result := range results {
+		require.NoError(t, result.err)
+		byName[result.cluster.Name] = result
+	}
+
+	// gcp regions share a provider, projects do not; aws clients are bound to a region
+	assert.Equal(t, int32(5), factory.created.Load())
+	sameProvider := func(a, b string) bool {
+		return byName[a].entry.CACert == byName[b].entry.CACert
+	}
+	assert.True(t, sameProvider("gke-a", "gke-b"))
+	assert.False(t, sameProvider("gke-a", "gke-c"))
+	assert.True(t, sameProvider("eks-a", "eks-b"))
+	assert.False(t, sameProvider("eks-a", "eks-c"))
+	assert.True(t, sameProvider("aks-a", "aks-b"))
+
+	// The shared gcp provider still looks each cluster up in its own region
+	assert.Equal(t, "https://gke-b.europe-west1.example.com", byName["gke-b"].entry.Endpoint)
+	assert.Equal(t, "https://eks-c.eu-west-1.example.com", byName["eks-c"].entry.Endpoint)
+}
+
+func TestProviderPool_FactoryErrorShared(t *testing.T) {
+	var calls atomic.Int32
+	pool := newProviderPool(func(flags *common.Flags, log logger.Logger) (provider.ClusterDescriber, error) {
+		calls.Add(1)
+		return nil, fmt.Errorf("bad credentials file")
+	})
+
+	flags := &common.Flags{ProviderName: "aws", Region: "us-east-1"}
+	for _, name := range []string{"a", "b", "c"} {
+		flags.ClusterName = name
+		_, err := pool.describe(context.Background(), flags, logger.Nop())
+		require.Error(t, err)
+	}
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestGenerateBatch_ResultsMatchClustersUnderConcurrency(t *testing.T) {
+	setBatchFlags(t, "", "", 16, false)
+
+	regions := []string{"us-central1", "us-east1", "europe-west1"}
+	clusters := make([]batchCluster, 200)
+	for i := range clusters {
+		clusters[i] = batchCluster{
+			Provider:  "gcp",
+			Name:      fmt.Sprintf("gke-%03d", i),
+			Region:    regions[i%len(regions)],
+			ProjectID: fmt.Sprintf("project-%d", i%4),
+		}
+	}
+
+	var mu sync.Mutex
+	random := rand.New(rand.NewSource(1))
+	pool := newProviderPool(func(flags *common.Flags, log logger.Logger) (provider.ClusterDescriber, error) {
+		return describerFunc(func(ctx context.Context, opts provider.ClusterInfoOptions) (*provider.ClusterInfo, error) {
+			mu.Lock()
+			delay := time.Duration(random.Intn(500)) * time.Microsecond
+			mu.Unlock()
+			time.Sleep(delay)
+			return &provider.ClusterInfo{
+				Endpoint:             fmt.Sprintf("https://%s.%s.example.com", opts.ClusterName, opts.Region),
+				CertificateAuthority: flags.ProjectID,
+			}, nil
+		}), nil
+	})
+
+	results := generateBatch(context.Background(), &common.Flags{}, clusters, nil, logger.Nop(), pool.describe)
+
+	require.Len(t, results, len(clusters))
+	for i, result := range results {
+		require.NoError(t, result.err)
+		cluster := clusters[i]
+		assert.Equal(t, cluster.Name, result.entry.Name)
+		assert.Equal(t, fmt.Sprintf("https://%s.%s.example.com", cluster.Name, cluster.Region), result.entry.Endpoint)
+		assert.Equal(t, cluster.ProjectID, result.entry.CACert, "looked up with the provider of its own project")
+	}
+}
+
+func TestGenerateBatch_RateLimit(t *testing.T) {
+	setBatchFlags(t, "", "", 8, false)
+	old := batchRateLimit
+	t.Cleanup(func() { batchRateLimit = old })
+	batchRateLimit = 100
+
+	clusters := make([]batchCluster, 6)
+	for i := range clusters {
+		clusters[i] = batchCluster{Provider: "aws", Name: fmt.Sprintf("eks-%d", i), Region: "us-east-1"}
+	}
+
+	start := time.Now()
+	results := generateBatch(context.Background(), &common.Flags{}, clusters, nil, logger.Nop(), fakeDescribe())
+	for _, result := range results {
+		require.NoError(t, result.err)
+	}
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond, "6 lookups at 100/s take at least 5 intervals")
+}
+
+func TestNewRateLimiter(t *testing.T) {
+	assert.Nil(t, newRateLimiter(0))
+	assert.NoError(t, newRateLimiter(0).wait(context.Background()), "a nil limiter admits everything")
+
+	limiter := newRateLimiter(1)
+	defer limiter.stop()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, limiter.wait(ctx), context.Canceled)
+}
+
+// describerFunc adapts a function to provider.ClusterDescriber
+type describerFunc func(ctx context.Context, opts provider.ClusterInfoOptions) (*provider.ClusterInfo, error)
+
+func (f describerFunc) DescribeCluster(ctx context.Context, opts provider.ClusterInfoOptions) (*provider.ClusterInfo, error) {
+	return f(ctx, opts)
+}
+
+// BenchmarkGenerateBatch compares a provider per cluster with the pooled providers for
+// 100 clusters of one project. Creating a provider costs 10ms and a lookup 0.2ms.
+func BenchmarkGenerateBatch(b *testing.B) {
+	oldConcurrency, oldFast := batchConcurrency, failFast
+	b.Cleanup(func() { batchConcurrency, failFast = oldConcurrency, oldFast })
+	batchConcurrency, failFast = defaultBatchConcurrency, false
+
+	clusters := make([]batchCluster, 100)
+	for i := range clusters {
+		clusters[i] = batchCluster{Provider: "gcp", Name: fmt.Sprintf("gke-%03d", i), Region: "us-central1", ProjectID: "my-project"}
+	}
+
+	b.Run("per-cluster", func(b *testing.B) {
+		factory := &fakeFactory{setup: 10 * time.Millisecond, lookup: 200 * time.Microsecond}
+		for i := 0; i < b.N; i++ {
+			generateBatch(context.Background(), &common.Flags{}, clusters, nil, logger.Nop(), factory.perClusterDescribe)
+		}
+		b.ReportMetric(float64(len(clusters)*b.N)/b.Elapsed().Seconds(), "clusters/s")
+	})
+
+	b.Run("pooled", func(b *testing.B) {
+		factory := &fakeFactory{setup: 10 * time.Millisecond, lookup: 200 * time.Microsecond}
+		for i := 0; i < b.N; i++ {
+			pool := newProviderPool(factory.create)
+			generateBatch(context.Background(), &common.Flags{}, clusters, nil, logger.Nop(), pool.describe)
+		}
+		b.ReportMetric(float64(len(clusters)*b.N)/b.Elapsed().Seconds(), "clusters/s")
+	})
+}
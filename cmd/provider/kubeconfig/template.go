@@ -0,0 +1,122 @@
+package kubeconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// kubeconfigTemplateData is what a --kubeconfig-template is executed with
+type kubeconfigTemplateData struct {
+	// Kubeconfig is the kubeconfig written without a template, for templates that
+	// only add to it
+	Kubeconfig *kubeconfigDocument
+
+	// Entries holds the cluster, user and exec plugin of each context, in output order
+	Entries []kubeconfigTemplateEntry
+
+	// CurrentContext is the context kubectl uses by default
+	CurrentContext string
+}
+
+// kubeconfigTemplateEntry is one context of a templated kubeconfig
+type kubeconfigTemplateEntry struct {
+	// Name names both the cluster and the context
+	Name     string
+	Cluster  clusterConfig
+	UserName string
+	Exec     execConfig
+}
+
+// templateFuncs are the functions available to kubeconfig templates besides the
+// text/template builtins
+var templateFuncs = template.FuncMap{
+	"quote":  quoteYAML,
+	"toYAML": toYAML,
+	"indent": indent,
+}
+
+// loadKubeconfigTemplate parses the --kubeconfig-template file. An empty path
+// returns nil, which renders the default kubeconfig.
+func loadKubeconfigTemplate(path string) (*template.Template, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read kubeconfig template: %w", err)
+	}
+	tmpl, err := template.New(filepath.Base(path)).Funcs(templateFuncs).Parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse kubeconfig template %s: %w", path, err)
+	}
+	return tmpl, nil
+}
+
+// renderKubeconfig renders entries with tmpl, or as the default kubeconfig when tmpl is nil
+func renderKubeconfig(entries []kubeconfigEntry, currentContext string, tmpl *template.Template) ([]byte, error) {
+	if tmpl == nil {
+		return marshalKubeconfig(entries, currentContext)
+	}
+
+	data := kubeconfigTemplateData{
+		Kubeconfig:     newKubeconfigDocument(entries, currentContext),
+		Entries:        make([]kubeconfigTemplateEntry, 0, len(entries)),
+		CurrentContext: currentContext,
+	}
+	for _, entry := range entries {
+		data.Entries = append(data.Entries, kubeconfigTemplateEntry{
+			Name:     entry.Name,
+			Cluster:  entry.cluster(),
+			UserName: entry.UserName,
+			Exec:     entry.exec(),
+		})
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("failed to execute kubeconfig template: %w", err)
+	}
+
+	// Catch templates that lose entries before kubectl does
+	missing, err := findMissingEntry(buf.Bytes(), entries)
+	if err != nil {
+		return nil, fmt.Errorf("kubeconfig template %s did not produce valid YAML: %w", tmpl.Name(), err)
+	}
+	if missing != nil {
+		return nil, fmt.Errorf("kubeconfig template %s did not write the cluster, context and user of %s", tmpl.Name(), missing.Name)
+	}
+	return buf.Bytes(), nil
+}
+
+// quoteYAML returns s as a double-quoted YAML scalar. JSON strings are valid YAML,
+// so every character is escaped the way YAML expects.
+func quoteYAML(s string) (string, error) {
+	quoted, err := json.Marshal(s)
+	if err != nil {
+		return "", err
+	}
+	return string(quoted), nil
+}
+
+// toYAML marshals v without the trailing newline, for use with indent
+func toYAML(v interface{}) (string, error) {
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(string(data), "\n"), nil
+}
+
+// indent prefixes every line of s with n spaces
+func indent(n int, s string) string {
+	pad := strings.Repeat(" ", n)
+	return pad + strings.ReplaceAll(s, "\n", "\n"+pad)
+}
@@ -0,0 +1,242 @@
+package kubeconfig
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// goldenProviderInfo returns the provider info the golden files are generated from
+func goldenProviderInfo(providerName string) map[string]string {
+	switch providerName {
+	case "gcp":
+		return map[string]string{
+			"provider":     "gcp",
+			"cluster-name": "my-cluster",
+			"project-id":   "my-project",
+			"region":       "us-central1",
+			"gcp-use-adc":  "auto",
+			"creds-env":    "GOOGLE_APPLICATION_CREDENTIALS",
+			"creds-path":   "/vault/secrets/gcp-sa.json",
+		}
+	case "aws":
+		return map[string]string{
+			"provider":     "aws",
+			"cluster-name": "my-cluster",
+			"region":       "us-east-1",
+			"creds-env":    "AWS_CREDENTIALS_FILE",
+			"creds-path":   "/vault/secrets/aws-credentials",
+		}
+	case "azure":
+		return map[string]string{
+			"provider":        "azure",
+			"cluster-name":    "my-cluster",
+			"subscription-id": "sub",
+			"tenant-id":       "tenant",
+			"resource-group":  "rg",
+			"creds-env":       "AZURE_CREDENTIALS_FILE",
+			"creds-path":      "/vault/secrets/azure.json",
+		}
+	case "oci":
+		return map[string]string{
+			"provider":     "oci",
+			"cluster-name": "ocid1.cluster.oc1.iad.aaaa",
+			"region":       "us-ashburn-1",
+			"creds-env":    "OCI_CLI_CONFIG_FILE",
+			"creds-path":   "/vault/secrets/oci-config",
+		}
+	case "digitalocean":
+		return map[string]string{
+			"provider":     "digitalocean",
+			"cluster-name": "my-cluster",
+			"creds-env":    "DIGITALOCEAN_CREDENTIALS_FILE",
+			"creds-path":   "/vault/secrets/digitalocean.json",
+		}
+	case "oidc":
+		return map[string]string{
+			"provider":     "oidc",
+			"cluster-name": "my-cluster",
+			"issuer-url":   "https://issuer.example.com",
+			"client-id":    "hyperfleet",
+			"scopes":       "openid groups",
+			"creds-env":    "OIDC_CLIENT_SECRET_FILE",
+			"creds-path":   "/vault/secrets/oidc-client-secret",
+		}
+	}
+	return nil
+}
+
+func goldenEntries(providerName string) []kubeconfigEntry {
+	extraEnv := []execEnvVar{{Name: "HTTPS_PROXY", Value: "http://proxy:3128"}}
+	return []kubeconfigEntry{
+		newKubeconfigEntry("my-cluster", kubeconfigUserName, "https://1.2.3.4", "Y2E=", goldenProviderInfo(providerName), extraEnv),
+	}
+}
+
+// assertGolden compares got with testdata/name, or rewrites it with -update
+func assertGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		require.NoError(t, os.WriteFile(path, got, 0644))
+	}
+	want, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, string(want), string(got))
+}
+
+func TestRenderKubeconfig_Golden(t *testing.T) {
+	tmpl, err := loadKubeconfigTemplate(filepath.Join("testdata", "kubeconfig.tmpl"))
+	require.NoError(t, err)
+
+	for _, providerName := range []string{"gcp", "aws", "azure"} {
+		t.Run(providerName, func(t *testing.T) {
+			entries := goldenEntries(providerName)
+
+			data, err := renderKubeconfig(entries, "my-cluster", nil)
+			require.NoError(t, err)
+			assertGolden(t, providerName+".golden.yaml", data)
+
+			templated, err := renderKubeconfig(entries, "my-cluster", tmpl)
+			require.NoError(t, err)
+			assertGolden(t, providerName+"-template.golden.yaml", templated)
+		})
+	}
+}
+
+func TestRenderKubeconfig_Deterministic(t *testing.T) {
+	entries := append(goldenEntries("gcp"), goldenEntries("aws")...)
+	entries[1].Name = "eks-prod"
+	entries[1].UserName = "eks-prod-user"
+
+	first, err := renderKubeconfig(entries, "my-cluster", nil)
+	require.NoError(t, err)
+	for i := 0; i < 20; i++ {
+		again, err := renderKubeconfig(entries, "my-cluster", nil)
+		require.NoError(t, err)
+		require.Equal(t, string(first), string(again))
+	}
+}
+
+func TestRenderKubeconfig_Overrides(t *testing.T) {
+	providerInfo := goldenProviderInfo("aws")
+	providerInfo["exec-command"] = "/opt/hyperfleet/bin/hyperfleet-credential-provider"
+	providerInfo["proxy-url"] = "socks5://proxy.example.com:1080"
+	providerInfo["tls-server-name"] = "api.my-cluster.internal"
+	entry := newKubeconfigEntry("my-cluster", kubeconfigUserName, "https://1.2.3.4", "Y2E=", providerInfo, nil)
+
+	data, err := renderKubeconfig([]kubeconfigEntry{entry}, "my-cluster", nil)
+	require.NoError(t, err)
+
+	var doc kubeconfigDocument
+	require.NoError(t, yaml.Unmarshal(data, &doc))
+	require.Len(t, doc.Clusters, 1)
+	assert.Equal(t, "socks5://proxy.example.com:1080", doc.Clusters[0].Cluster.ProxyURL)
+	assert.Equal(t, "api.my-cluster.internal", doc.Clusters[0].Cluster.TLSServerName)
+	require.Len(t, doc.Users, 1)
+	assert.Equal(t, "/opt/hyperfleet/bin/hyperfleet-credential-provider", doc.Users[0].User.Exec.Command)
+
+	// Unset overrides leave no empty keys behind
+	plain, err := renderKubeconfig(goldenEntries("aws"), "my-cluster", nil)
+	require.NoError(t, err)
+	assert.NotContains(t, string(plain), "proxy-url")
+	assert.NotContains(t, string(plain), "tls-server-name")
+}
+
+func TestRenderKubeconfig_TemplateErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		wantErr  string
+	}{
+		{
+			name:     "parse error",
+			template: "clusters: {{ range .Entries }}",
+			wantErr:  "failed to parse kubeconfig template",
+		},
+		{
+			name:     "unknown field",
+			template: "{{ .Cluster }}",
+			wantErr:  "failed to execute kubeconfig template",
+		},
+		{
+			name:     "invalid YAML",
+			template: "clusters: [\n",
+			wantErr:  "did not produce valid YAML",
+		},
+		{
+			name:     "entries dropped",
+			template: "apiVersion: v1\nkind: Config\n",
+			wantErr:  "did not write the cluster, context and user of my-cluster",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "kubeconfig.tmpl")
+			require.NoError(t, os.WriteFile(path, []byte(tt.template), 0600))
+
+			tmpl, err := loadKubeconfigTemplate(path)
+			if err == nil {
+				_, err = renderKubeconfig(goldenEntries("gcp"), "my-cluster", tmpl)
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestLoadKubeconfigTemplate(t *testing.T) {
+	tmpl, err := loadKubeconfigTemplate("")
+	require.NoError(t, err)
+	assert.Nil(t, tmpl)
+
+	_, err = loadKubeconfigTemplate(filepath.Join(t.TempDir(), "missing.tmpl"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to read kubeconfig template")
+}
+
+func TestTemplateFuncs(t *testing.T) {
+	quoted, err := quoteYAML("a \"b\"\nc")
+	require.NoError(t, err)
+	assert.Equal(t, `"a \"b\"\nc"`, quoted)
+
+	out, err := toYAML(execEnvVar{Name: "A", Value: "b"})
+	require.NoError(t, err)
+	assert.Equal(t, "name: A\nvalue: b", out)
+	assert.Equal(t, "  name: A\n  value: b", indent(2, out))
+}
+
+func TestValidateProxyURL(t *testing.T) {
+	tests := []struct {
+		value   string
+		wantErr string
+	}{
+		{value: "http://proxy.example.com:3128"},
+		{value: "https://proxy.example.com"},
+		{value: "socks5://127.0.0.1:1080"},
+		{value: "ftp://proxy.example.com", wantErr: "must use http, https or socks5"},
+		{value: "proxy.example.com:3128", wantErr: "must use http, https or socks5"},
+		{value: "http://", wantErr: "has no host"},
+		{value: "http://proxy example.com", wantErr: "invalid character"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			err := validateProxyURL(tt.value)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,11 @@
+kubectl config set-credentials hyperfleet-user \
+  --exec-api-version=client.authentication.k8s.io/v1 \
+  --exec-command=hyperfleet-credential-provider \
+  --exec-arg=get-token \
+  --exec-arg=--provider=aws \
+  --exec-arg=--cluster-name=my-cluster \
+  --exec-arg=--region=us-east-1 \
+  --exec-env=AWS_CREDENTIALS_FILE=/vault/secrets/aws-credentials \
+  --exec-env=AWS_REGION=us-east-1 \
+  --exec-env=HTTPS_PROXY=http://proxy:3128 \
+  --exec-interactive-mode=Never
@@ -0,0 +1,11 @@
+kubectl config set-credentials hyperfleet-user \
+  --exec-api-version=client.authentication.k8s.io/v1 \
+  --exec-command=hyperfleet-credential-provider \
+  --exec-arg=get-token \
+  --exec-arg=--provider=azure \
+  --exec-arg=--cluster-name=my-cluster \
+  --exec-arg=--subscription-id=sub \
+  --exec-arg=--tenant-id=tenant \
+  --exec-env=AZURE_CREDENTIALS_FILE=/vault/secrets/azure.json \
+  --exec-env=HTTPS_PROXY=http://proxy:3128 \
+  --exec-interactive-mode=Never
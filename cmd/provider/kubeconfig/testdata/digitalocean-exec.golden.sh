@@ -0,0 +1,9 @@
+kubectl config set-credentials hyperfleet-user \
+  --exec-api-version=client.authentication.k8s.io/v1 \
+  --exec-command=hyperfleet-credential-provider \
+  --exec-arg=get-token \
+  --exec-arg=--provider=digitalocean \
+  --exec-arg=--cluster-name=my-cluster \
+  --exec-env=DIGITALOCEAN_CREDENTIALS_FILE=/vault/secrets/digitalocean.json \
+  --exec-env=HTTPS_PROXY=http://proxy:3128 \
+  --exec-interactive-mode=Never
@@ -0,0 +1,11 @@
+kubectl config set-credentials hyperfleet-user \
+  --exec-api-version=client.authentication.k8s.io/v1 \
+  --exec-command=hyperfleet-credential-provider \
+  --exec-arg=get-token \
+  --exec-arg=--provider=gcp \
+  --exec-arg=--cluster-name=my-cluster \
+  --exec-arg=--project-id=my-project \
+  --exec-arg=--region=us-central1 \
+  --exec-env=GOOGLE_APPLICATION_CREDENTIALS=/vault/secrets/gcp-sa.json \
+  --exec-env=HTTPS_PROXY=http://proxy:3128 \
+  --exec-interactive-mode=Never
@@ -0,0 +1,10 @@
+kubectl config set-credentials hyperfleet-user \
+  --exec-api-version=client.authentication.k8s.io/v1 \
+  --exec-command=hyperfleet-credential-provider \
+  --exec-arg=get-token \
+  --exec-arg=--provider=oci \
+  --exec-arg=--cluster-name=ocid1.cluster.oc1.iad.aaaa \
+  --exec-arg=--region=us-ashburn-1 \
+  --exec-env=OCI_CLI_CONFIG_FILE=/vault/secrets/oci-config \
+  --exec-env=HTTPS_PROXY=http://proxy:3128 \
+  --exec-interactive-mode=Never
@@ -0,0 +1,12 @@
+kubectl config set-credentials hyperfleet-user \
+  --exec-api-version=client.authentication.k8s.io/v1 \
+  --exec-command=hyperfleet-credential-provider \
+  --exec-arg=get-token \
+  --exec-arg=--provider=oidc \
+  --exec-arg=--cluster-name=my-cluster \
+  --exec-arg=--issuer-url=https://issuer.example.com \
+  --exec-arg=--client-id=hyperfleet \
+  '--exec-arg=--scopes=openid groups' \
+  --exec-env=OIDC_CLIENT_SECRET_FILE=/vault/secrets/oidc-client-secret \
+  --exec-env=HTTPS_PROXY=http://proxy:3128 \
+  --exec-interactive-mode=Never
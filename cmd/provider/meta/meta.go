@@ -0,0 +1,52 @@
+package meta
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/cryptoinventory"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+)
+
+// NewCommand returns the meta command, which reports facts about the binary itself
+func NewCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "meta",
+		Short: "Report information about this binary for audits",
+	}
+
+	cmd.AddCommand(newCryptoInventoryCommand())
+
+	return cmd
+}
+
+func newCryptoInventoryCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "crypto-inventory",
+		Short: "Print the cryptographic operations and libraries this binary uses",
+		Long: `Print a JSON inventory of the cryptography used by this binary, for FIPS and
+compliance reviews: the TLS stack, the signing algorithms each provider uses,
+the hashing used for fingerprints, the Go crypto backend (including whether FIPS
+140-3 mode is enabled), and the versions of security-relevant modules.
+
+The document carries a schemaVersion field that changes only on incompatible changes.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runCryptoInventory(cmd.OutOrStdout())
+		},
+	}
+}
+
+func runCryptoInventory(w io.Writer) error {
+	inventory := cryptoinventory.Build(provider.Registered())
+
+	data, err := json.MarshalIndent(inventory, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode crypto inventory: %w", err)
+	}
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
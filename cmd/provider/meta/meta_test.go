@@ -0,0 +1,35 @@
+package meta
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/cryptoinventory"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+
+	// Register the providers the binary ships with
+	_ "github.com/openshift-hyperfleet/hyperfleet-credential-provider/cmd/provider/common"
+)
+
+// TestProviderTableMatchesRegistry fails when a provider is added or removed without
+// updating the crypto inventory table
+func TestProviderTableMatchesRegistry(t *testing.T) {
+	assert.Equal(t, provider.Registered(), cryptoinventory.Providers())
+}
+
+func TestRunCryptoInventory(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, runCryptoInventory(&out))
+
+	var inv cryptoinventory.Inventory
+	require.NoError(t, json.Unmarshal(out.Bytes(), &inv))
+	assert.Equal(t, cryptoinventory.SchemaVersion, inv.SchemaVersion)
+	assert.NotEmpty(t, inv.GoVersion)
+	for _, use := range inv.Providers {
+		assert.NotEmpty(t, use.Operations, "provider %s has no crypto operations listed", use.Provider)
+	}
+}
@@ -0,0 +1,303 @@
+package serve
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"google.golang.org/grpc"
+	grpccredentials "google.golang.org/grpc/credentials"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/cmd/provider/common"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/execplugin"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/metrics"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/tokenservice"
+)
+
+// tokenService implements the gRPC TokenService with the provider of the serve process.
+// Request fields left empty default to the serve flags.
+type tokenService struct {
+	tokenservice.UnimplementedTokenServiceServer
+
+	prov   provider.Provider
+	tokens *provider.StoreRefresher
+	flags  *common.Flags
+	m      *metrics.Metrics
+	log    logger.Logger
+}
+
+// newTokenService creates the TokenService of prov. Tokens are kept in memory and
+// reused for identical requests until they need a refresh.
+func newTokenService(prov provider.Provider, flags *common.Flags, m *metrics.Metrics, log logger.Logger) *tokenService {
+	tokens := provider.NewStoreRefresher(prov.Name(), prov, provider.NewMemoryTokenStore(), log).
+		WithScope(common.TokenCacheScope(flags)).
+		WithRequestObserver(m)
+	return &tokenService{prov: prov, tokens: tokens, flags: flags, m: m, log: log}
+}
+
+// GetToken implements tokenservice.TokenServiceServer
+func (s *tokenService) GetToken(ctx context.Context, req *tokenservice.GetTokenRequest) (*tokenservice.Token, error) {
+	token, err := s.getToken(ctx, req)
+	if err != nil {
+		return nil, s.statusError("GetToken", err)
+	}
+	return &tokenservice.Token{
+		AccessToken: token.AccessToken,
+		ExpiresAt:   timestamppb.New(token.ExpiresAt),
+		TokenType:   token.TokenType,
+		Provider:    token.Provider,
+		ClusterName: token.ClusterName,
+		Identity:    token.Identity,
+		Audience:    token.Audience,
+		Scopes:      token.Scopes,
+	}, nil
+}
+
+// GetExecCredential implements tokenservice.TokenServiceServer
+func (s *tokenService) GetExecCredential(ctx context.Context, req *tokenservice.GetTokenRequest) (*tokenservice.ExecCredential, error) {
+	token, err := s.getToken(ctx, req)
+	if err != nil {
+		return nil, s.statusError("GetExecCredential", err)
+	}
+	output, err := execplugin.FormatToken(token)
+	if err != nil {
+		return nil, s.statusError("GetExecCredential", err)
+	}
+	return &tokenservice.ExecCredential{
+		Json:      output,
+		ExpiresAt: timestamppb.New(token.ExpiresAt),
+	}, nil
+}
+
+// getToken returns a token for the cluster of req, checking it against the allowed
+// clusters of --config-file like get-token. A cached token is returned while it is valid.
+func (s *tokenService) getToken(ctx context.Context, req *tokenservice.GetTokenRequest) (*provider.Token, error) {
+	opts := provider.GetTokenOptions{
+		ClusterName:    req.GetClusterName(),
+		Region:         orDefault(req.GetRegion(), s.flags.Region),
+		ProjectID:      orDefault(req.GetProjectId(), s.flags.ProjectID),
+		AccountID:      orDefault(req.GetAccountId(), s.flags.AccountID),
+		ClusterID:      req.GetClusterId(),
+		SubscriptionID: orDefault(req.GetSubscriptionId(), s.flags.SubscriptionID),
+		TenantID:       orDefault(req.GetTenantId(), s.flags.TenantID),
+		ResourceGroup:  orDefault(req.GetResourceGroup(), s.flags.ResourceGroup),
+		CompartmentID:  orDefault(req.GetCompartmentId(), s.flags.CompartmentID),
+		Audience:       req.GetAudience(),
+	}
+	if err := opts.Validate(s.prov.Name()); err != nil {
+		return nil, err
+	}
+	if err := common.ClusterAllowed(s.flags.AllowedClusters, opts.ClusterName); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := common.WithTimeout(ctx, s.flags)
+	defer cancel()
+
+	start := time.Now()
+	token, outcome, err := s.tokens.Refresh(ctx, opts)
+	if err != nil {
+		s.m.RecordTokenRequest(s.prov.Name(), "error")
+		s.m.RecordTokenGenerationError(s.prov.Name(), string(errors.GetCode(err)))
+		return nil, common.TimeoutError(ctx, err, "get token", start)
+	}
+	s.m.RecordTokenRequest(s.prov.Name(), "success")
+	if outcome != provider.CacheHit {
+		s.m.RecordTokenGenerationDuration(s.prov.Name(), time.Since(start))
+	}
+	return token, nil
+}
+
+// GetClusterInfo implements tokenservice.TokenServiceServer
+func (s *tokenService) GetClusterInfo(ctx context.Context, req *tokenservice.GetClusterInfoRequest) (*tokenservice.ClusterInfo, error) {
+	info, err := s.describeCluster(ctx, req)
+	if err != nil {
+		return nil, s.statusError("GetClusterInfo", err)
+	}
+	return &tokenservice.ClusterInfo{
+		Endpoint:             info.Endpoint,
+		CertificateAuthority: info.CertificateAuthority,
+		Version:              info.Version,
+		Location:             info.Location,
+		Region:               info.Region,
+		Arn:                  info.ARN,
+		ResourceId:           info.ResourceID,
+	}, nil
+}
+
+// describeCluster looks up the cluster of req with the provider
+func (s *tokenService) describeCluster(ctx context.Context, req *tokenservice.GetClusterInfoRequest) (*provider.ClusterInfo, error) {
+	describer, ok := s.prov.(provider.ClusterDescriber)
+	if !ok {
+		return nil, errors.New(
+			errors.ErrProviderNotSupported,
+			fmt.Sprintf("provider %s does not support cluster lookup", s.prov.Name()),
+		).WithField("provider", s.prov.Name())
+	}
+	if req.GetClusterName() == "" {
+		return nil, errors.New(errors.ErrMissingRequired, "cluster_name is required")
+	}
+	if err := common.ClusterAllowed(s.flags.AllowedClusters, req.GetClusterName()); err != nil {
+		return nil, err
+	}
+
+	access, err := provider.ParseEndpointAccess(req.GetEndpointAccess())
+	if err != nil {
+		return nil, err
+	}
+	if access != provider.EndpointAccessDefault {
+		if err := provider.CheckEndpointAccessSupported(s.prov.Name()); err != nil {
+			return nil, err
+		}
+	}
+
+	ctx, cancel := common.WithTimeout(ctx, s.flags)
+	defer cancel()
+
+	start := time.Now()
+	info, err := describer.DescribeCluster(ctx, provider.ClusterInfoOptions{
+		ClusterName:    req.GetClusterName(),
+		Region:         orDefault(req.GetRegion(), s.flags.Region),
+		ResourceGroup:  orDefault(req.GetResourceGroup(), s.flags.ResourceGroup),
+		EndpointAccess: req.GetEndpointAccess(),
+	})
+	if err != nil {
+		return nil, common.TimeoutError(ctx, err, "describe cluster", start)
+	}
+	return info, nil
+}
+
+// ValidateCredentials implements tokenservice.TokenServiceServer
+func (s *tokenService) ValidateCredentials(ctx context.Context, req *tokenservice.ValidateCredentialsRequest) (*tokenservice.ValidateCredentialsResponse, error) {
+	ctx, cancel := common.WithTimeout(ctx, s.flags)
+	defer cancel()
+
+	start := time.Now()
+	if err := s.prov.ValidateCredentials(ctx); err != nil {
+		s.m.RecordCredentialValidationError(s.prov.Name())
+		return nil, s.statusError("ValidateCredentials", common.TimeoutError(ctx, err, "validate credentials", start))
+	}
+	return &tokenservice.ValidateCredentialsResponse{Provider: s.prov.Name()}, nil
+}
+
+// statusError logs a failed call and converts its error to a gRPC status
+func (s *tokenService) statusError(method string, err error) error {
+	s.log.Warn("Token service call failed",
+		logger.String("method", method),
+		logger.String("code", string(errors.GetCode(err))),
+		logger.String("error", err.Error()),
+	)
+	return tokenservice.Status(err).Err()
+}
+
+// orDefault returns value, or fallback when value is empty
+func orDefault(value, fallback string) string {
+	if value != "" {
+		return value
+	}
+	return fallback
+}
+
+// grpcTLSConfig loads the server certificate of --grpc-cert-file and --grpc-key-file and
+// requires clients to present a certificate signed by --grpc-client-ca-file. It returns
+// nil without a certificate, which is only allowed on a loopback address.
+func grpcTLSConfig(address, certFile, keyFile, clientCAFile string) (*tls.Config, error) {
+	switch {
+	case (certFile == "") != (keyFile == ""):
+		return nil, errors.New(errors.ErrConfigInvalid, "--grpc-cert-file and --grpc-key-file must be set together")
+	case certFile == "" && clientCAFile != "":
+		return nil, errors.New(errors.ErrConfigInvalid, "--grpc-client-ca-file requires --grpc-cert-file and --grpc-key-file")
+	case certFile != "" && clientCAFile == "":
+		return nil, errors.New(
+			errors.ErrConfigInvalid,
+			"--grpc-client-ca-file is required with --grpc-cert-file",
+		).WithDetail("the token service only serves clients that authenticate with a certificate")
+	case certFile == "":
+		if !isLoopback(address) {
+			return nil, errors.New(
+				errors.ErrConfigInvalid,
+				fmt.Sprintf("--grpc-address %s is not a loopback address", address),
+			).WithDetail("serve the token service with mTLS by setting --grpc-cert-file, --grpc-key-file and --grpc-client-ca-file")
+		}
+		return nil, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, errors.Wrap(
+			errors.ErrConfigInvalid,
+			err,
+			"failed to load the gRPC server certificate",
+		).WithFields(map[string]interface{}{
+			"cert_file": certFile,
+			"key_file":  keyFile,
+		})
+	}
+
+	data, err := os.ReadFile(clientCAFile)
+	if err != nil {
+		return nil, errors.Wrap(
+			errors.ErrConfigLoadFailed,
+			err,
+			"failed to read the gRPC client CA",
+		).WithField("path", clientCAFile)
+	}
+	clientCAs := x509.NewCertPool()
+	if !clientCAs.AppendCertsFromPEM(data) {
+		return nil, errors.New(
+			errors.ErrConfigInvalid,
+			"gRPC client CA contains no PEM certificates",
+		).WithField("path", clientCAFile)
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientCAs:    clientCAs,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
+
+// isLoopback reports whether address listens on a loopback interface only
+func isLoopback(address string) bool {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return false
+	}
+	return host == "localhost" || net.ParseIP(host).IsLoopback()
+}
+
+// newGRPCServer creates a gRPC server serving svc, over TLS when tlsConfig is set
+func newGRPCServer(svc tokenservice.TokenServiceServer, tlsConfig *tls.Config) *grpc.Server {
+	var opts []grpc.ServerOption
+	if tlsConfig != nil {
+		opts = append(opts, grpc.Creds(grpccredentials.NewTLS(tlsConfig)))
+	}
+	server := grpc.NewServer(opts...)
+	tokenservice.RegisterTokenServiceServer(server, svc)
+	return server
+}
+
+// stopGRPCServer waits for in-flight calls until ctx is done, then closes the remaining
+// connections
+func stopGRPCServer(ctx context.Context, server *grpc.Server) {
+	stopped := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		server.Stop()
+	}
+}
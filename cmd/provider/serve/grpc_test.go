@@ -0,0 +1,500 @@
+package serve
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	grpccredentials "google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/cmd/provider/common"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider/aws"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/testutil"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/throttle"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/metrics"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/tokenservice"
+)
+
+// newTestClient serves svc over an in-memory listener and returns a client of it
+func newTestClient(t *testing.T, svc tokenservice.TokenServiceServer) tokenservice.TokenServiceClient {
+	t.Helper()
+	ln := bufconn.Listen(1 << 20)
+	server := newGRPCServer(svc, nil)
+	go server.Serve(ln)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return ln.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return tokenservice.NewTokenServiceClient(conn)
+}
+
+// newAWSProvider returns a provider generating real EKS tokens from the mock
+// credential loader, which presigns locally without calling AWS
+func newAWSProvider() *provider.MockProvider {
+	loader := testutil.NewMockCredLoader().WithAWSCreds(testutil.CreateValidAWSCredentials())
+	generator := aws.NewTokenGenerator(&aws.Config{Region: "us-east-1"}, loader, logger.Nop())
+	return &provider.MockProvider{
+		NameValue:    "aws",
+		GetTokenFunc: generator.GenerateToken,
+	}
+}
+
+// describingProvider is a MockProvider that can look up clusters
+type describingProvider struct {
+	provider.MockProvider
+	describe func(ctx context.Context, opts provider.ClusterInfoOptions) (*provider.ClusterInfo, error)
+}
+
+func (p *describingProvider) DescribeCluster(ctx context.Context, opts provider.ClusterInfoOptions) (*provider.ClusterInfo, error) {
+	return p.describe(ctx, opts)
+}
+
+func TestTokenService_GetToken(t *testing.T) {
+	_, m := newMetrics(metrics.DefaultConfig())
+	flags := &common.Flags{Region: "us-east-1"}
+	client := newTestClient(t, newTokenService(newAWSProvider(), flags, m, logger.Nop()))
+
+	token, err := client.GetToken(context.Background(), &tokenservice.GetTokenRequest{ClusterName: "prod-east"})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(token.GetAccessToken(), "k8s-aws-v1."), "got %q", token.GetAccessToken())
+	assert.Equal(t, "Bearer", token.GetTokenType())
+	assert.Equal(t, "aws", token.GetProvider())
+	assert.Equal(t, "prod-east", token.GetClusterName())
+	assert.Equal(t, "prod-east", token.GetAudience())
+	assert.NotEmpty(t, token.GetIdentity())
+	assert.WithinDuration(t, time.Now().Add(15*time.Minute), token.GetExpiresAt().AsTime(), time.Minute)
+
+	cred, err := client.GetExecCredential(context.Background(), &tokenservice.GetTokenRequest{
+		ClusterName: "prod-east",
+		ClusterId:   "eks-anywhere-id",
+	})
+	require.NoError(t, err)
+	var execCred struct {
+		Kind   string `json:"kind"`
+		Status struct {
+			Token string `json:"token"`
+		} `json:"status"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(cred.GetJson()), &execCred))
+	assert.Equal(t, "ExecCredential", execCred.Kind)
+	assert.True(t, strings.HasPrefix(execCred.Status.Token, "k8s-aws-v1."))
+	assert.False(t, cred.GetExpiresAt().AsTime().IsZero())
+}
+
+func TestTokenService_RequestDefaults(t *testing.T) {
+	var got provider.GetTokenOptions
+	prov := &provider.MockProvider{
+		GetTokenFunc: func(ctx context.Context, opts provider.GetTokenOptions) (*provider.Token, error) {
+			got = opts
+			return &provider.Token{AccessToken: "token", ExpiresAt: time.Now().Add(time.Hour)}, nil
+		},
+	}
+	_, m := newMetrics(metrics.DefaultConfig())
+	flags := &common.Flags{Region: "us-east-1", ProjectID: "flag-project", TenantID: "flag-tenant"}
+	client := newTestClient(t, newTokenService(prov, flags, m, logger.Nop()))
+
+	_, err := client.GetToken(context.Background(), &tokenservice.GetTokenRequest{
+		ClusterName: "prod",
+		Region:      "eu-west-1",
+		Audience:    "custom",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, provider.GetTokenOptions{
+		ClusterName: "prod",
+		Region:      "eu-west-1",
+		ProjectID:   "flag-project",
+		TenantID:    "flag-tenant",
+		Audience:    "custom",
+	}, got)
+}
+
+func TestTokenService_CachesTokens(t *testing.T) {
+	calls := 0
+	prov := &provider.MockProvider{
+		NameValue: "aws",
+		GetTokenFunc: func(ctx context.Context, opts provider.GetTokenOptions) (*provider.Token, error) {
+			calls++
+			return &provider.Token{
+				AccessToken: fmt.Sprintf("token-%d", calls),
+				ExpiresAt:   time.Now().Add(time.Hour),
+				TokenType:   "Bearer",
+			}, nil
+		},
+	}
+	_, m := newMetrics(metrics.DefaultConfig())
+	flags := &common.Flags{Region: "us-east-1"}
+	client := newTestClient(t, newTokenService(prov, flags, m, logger.Nop()))
+
+	first, err := client.GetToken(context.Background(), &tokenservice.GetTokenRequest{ClusterName: "prod"})
+	require.NoError(t, err)
+	second, err := client.GetToken(context.Background(), &tokenservice.GetTokenRequest{ClusterName: "prod"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, calls, "identical requests generate one token")
+	assert.Equal(t, first.GetAccessToken(), second.GetAccessToken())
+
+	cred, err := client.GetExecCredential(context.Background(), &tokenservice.GetTokenRequest{ClusterName: "prod"})
+	require.NoError(t, err)
+	assert.Contains(t, cred.GetJson(), first.GetAccessToken())
+	assert.Equal(t, 1, calls, "GetExecCredential shares the cache")
+
+	other, err := client.GetToken(context.Background(), &tokenservice.GetTokenRequest{ClusterName: "prod", Audience: "custom"})
+	require.NoError(t, err)
+	assert.NotEqual(t, first.GetAccessToken(), other.GetAccessToken())
+	assert.Equal(t, 2, calls, "other options generate a new token")
+}
+
+func TestTokenService_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		cluster  string
+		allowed  []string
+		timeout  time.Duration
+		tokenErr error
+		wantCode codes.Code
+		wantErr  errors.ErrorCode
+	}{
+		{
+			name:     "missing cluster name",
+			wantCode: codes.InvalidArgument,
+			wantErr:  errors.ErrInvalidArgument,
+		},
+		{
+			name:     "invalid cluster name",
+			cluster:  "prod east",
+			wantCode: codes.InvalidArgument,
+			wantErr:  errors.ErrInvalidArgument,
+		},
+		{
+			name:     "cluster not allowed",
+			cluster:  "prod",
+			allowed:  []string{"ci-*"},
+			wantCode: codes.PermissionDenied,
+			wantErr:  errors.ErrPermissionDenied,
+		},
+		{
+			name:     "invalid credentials",
+			cluster:  "prod",
+			tokenErr: errors.New(errors.ErrCredentialInvalid, "access key revoked"),
+			wantCode: codes.Unauthenticated,
+			wantErr:  errors.ErrCredentialInvalid,
+		},
+		{
+			name:     "cluster unreachable",
+			cluster:  "prod",
+			tokenErr: errors.New(errors.ErrNetworkUnreachable, "sts unreachable"),
+			wantCode: codes.Unavailable,
+			wantErr:  errors.ErrNetworkUnreachable,
+		},
+		{
+			name:     "timeout",
+			cluster:  "prod",
+			timeout:  10 * time.Millisecond,
+			wantCode: codes.DeadlineExceeded,
+			wantErr:  errors.ErrNetworkTimeout,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prov := &provider.MockProvider{
+				NameValue: "aws",
+				GetTokenFunc: func(ctx context.Context, opts provider.GetTokenOptions) (*provider.Token, error) {
+					if tt.timeout > 0 {
+						<-ctx.Done()
+						return nil, ctx.Err()
+					}
+					if tt.tokenErr != nil {
+						return nil, tt.tokenErr
+					}
+					return &provider.Token{AccessToken: "token", ExpiresAt: time.Now().Add(time.Hour)}, nil
+				},
+			}
+			_, m := newMetrics(metrics.DefaultConfig())
+			flags := &common.Flags{Region: "us-east-1", AllowedClusters: tt.allowed, Timeout: tt.timeout}
+			client := newTestClient(t, newTokenService(prov, flags, m, logger.Nop()))
+
+			_, err := client.GetToken(context.Background(), &tokenservice.GetTokenRequest{ClusterName: tt.cluster})
+			require.Error(t, err)
+			assert.Equal(t, tt.wantCode, status.Code(err), err.Error())
+			assert.Equal(t, tt.wantErr, tokenservice.ErrorCode(err))
+		})
+	}
+}
+
+func TestTokenService_Throttled(t *testing.T) {
+	calls := 0
+	prov := &provider.MockProvider{
+		NameValue: "aws",
+		GetTokenFunc: func(ctx context.Context, opts provider.GetTokenOptions) (*provider.Token, error) {
+			calls++
+			return &provider.Token{AccessToken: "token", ExpiresAt: time.Now().Add(time.Hour)}, nil
+		},
+	}
+	throttled := throttle.Wrap(prov, throttle.Config{RequestsPerSecond: 0.1, Burst: 1})
+	_, m := newMetrics(metrics.DefaultConfig())
+	flags := &common.Flags{Region: "us-east-1"}
+	client := newTestClient(t, newTokenService(throttled, flags, m, logger.Nop()))
+
+	_, err := client.GetToken(context.Background(), &tokenservice.GetTokenRequest{ClusterName: "prod"})
+	require.NoError(t, err)
+
+	// Another audience, since the first token is served from the cache
+	_, err = client.GetToken(context.Background(), &tokenservice.GetTokenRequest{ClusterName: "prod", Audience: "custom"})
+	require.Error(t, err)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Equal(t, errors.ErrRateLimitExceeded, tokenservice.ErrorCode(err))
+	wait, ok := tokenservice.RetryDelay(err)
+	require.True(t, ok, "a RetryInfo detail is attached")
+	assert.Equal(t, 10*time.Second, wait)
+	assert.Equal(t, 1, calls, "the throttled request does not reach the provider")
+}
+
+func TestTokenService_GetClusterInfo(t *testing.T) {
+	var got provider.ClusterInfoOptions
+	prov := &describingProvider{
+		MockProvider: provider.MockProvider{NameValue: "aws"},
+		describe: func(ctx context.Context, opts provider.ClusterInfoOptions) (*provider.ClusterInfo, error) {
+			got = opts
+			if opts.ClusterName == "missing" {
+				return nil, errors.New(errors.ErrClusterNotFound, "cluster not found")
+			}
+			return &provider.ClusterInfo{
+				Endpoint:             "https://ABC.gr7.us-east-1.eks.amazonaws.com",
+				CertificateAuthority: "Y2E=",
+				Version:              "1.31",
+				Region:               opts.Region,
+				ARN:                  "arn:aws:eks:us-east-1:123456789012:cluster/" + opts.ClusterName,
+			}, nil
+		},
+	}
+	_, m := newMetrics(metrics.DefaultConfig())
+	flags := &common.Flags{Region: "us-east-1", AllowedClusters: []string{"prod", "missing"}}
+	client := newTestClient(t, newTokenService(prov, flags, m, logger.Nop()))
+
+	info, err := client.GetClusterInfo(context.Background(), &tokenservice.GetClusterInfoRequest{
+		ClusterName:    "prod",
+		EndpointAccess: "private",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, provider.ClusterInfoOptions{ClusterName: "prod", Region: "us-east-1", EndpointAccess: "private"}, got)
+	assert.Equal(t, "https://ABC.gr7.us-east-1.eks.amazonaws.com", info.GetEndpoint())
+	assert.Equal(t, "Y2E=", info.GetCertificateAuthority())
+	assert.Equal(t, "us-east-1", info.GetRegion())
+	assert.Equal(t, "arn:aws:eks:us-east-1:123456789012:cluster/prod", info.GetArn())
+
+	_, err = client.GetClusterInfo(context.Background(), &tokenservice.GetClusterInfoRequest{ClusterName: "missing"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	assert.Equal(t, errors.ErrClusterNotFound, tokenservice.ErrorCode(err))
+
+	_, err = client.GetClusterInfo(context.Background(), &tokenservice.GetClusterInfoRequest{ClusterName: "staging"})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = client.GetClusterInfo(context.Background(), &tokenservice.GetClusterInfoRequest{
+		ClusterName:    "prod",
+		EndpointAccess: "internal",
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestTokenService_GetClusterInfoUnsupported(t *testing.T) {
+	_, m := newMetrics(metrics.DefaultConfig())
+	client := newTestClient(t, newTokenService(&provider.MockProvider{}, &common.Flags{}, m, logger.Nop()))
+
+	_, err := client.GetClusterInfo(context.Background(), &tokenservice.GetClusterInfoRequest{ClusterName: "prod"})
+	assert.Equal(t, codes.Unimplemented, status.Code(err))
+	assert.Equal(t, errors.ErrProviderNotSupported, tokenservice.ErrorCode(err))
+}
+
+func TestTokenService_ValidateCredentials(t *testing.T) {
+	var validateErr error
+	prov := &provider.MockProvider{
+		NameValue:               "aws",
+		ValidateCredentialsFunc: func(ctx context.Context) error { return validateErr },
+	}
+	_, m := newMetrics(metrics.DefaultConfig())
+	client := newTestClient(t, newTokenService(prov, &common.Flags{}, m, logger.Nop()))
+
+	resp, err := client.ValidateCredentials(context.Background(), &tokenservice.ValidateCredentialsRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, "aws", resp.GetProvider())
+
+	validateErr = errors.New(errors.ErrCredentialExpired, "session token expired")
+	_, err = client.ValidateCredentials(context.Background(), &tokenservice.ValidateCredentialsRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	assert.Equal(t, errors.ErrCredentialExpired, tokenservice.ErrorCode(err))
+	assert.Contains(t, status.Convert(err).Message(), "session token expired")
+}
+
+// testPKI is a test CA with a server certificate written to files and a client
+// certificate, both signed by the CA
+type testPKI struct {
+	caFile, certFile, keyFile string
+	caPool                    *x509.CertPool
+	clientCert                tls.Certificate
+}
+
+// newTestPKI creates a testPKI in a temp dir
+func newTestPKI(t *testing.T) testPKI {
+	t.Helper()
+	dir := t.TempDir()
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	require.NoError(t, err)
+	caCert, err := x509.ParseCertificate(caDER)
+	require.NoError(t, err)
+
+	issue := func(serial int64, usage x509.ExtKeyUsage) ([]byte, []byte) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(serial),
+			Subject:      pkix.Name{CommonName: "localhost"},
+			DNSNames:     []string{"localhost"},
+			IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+		require.NoError(t, err)
+		keyDER, err := x509.MarshalECPrivateKey(key)
+		require.NoError(t, err)
+		return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+			pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	}
+
+	pki := testPKI{
+		caFile:   filepath.Join(dir, "ca.pem"),
+		certFile: filepath.Join(dir, "server.pem"),
+		keyFile:  filepath.Join(dir, "server-key.pem"),
+		caPool:   x509.NewCertPool(),
+	}
+	pki.caPool.AddCert(caCert)
+	require.NoError(t, os.WriteFile(pki.caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER}), 0600))
+
+	serverCert, serverKey := issue(2, x509.ExtKeyUsageServerAuth)
+	require.NoError(t, os.WriteFile(pki.certFile, serverCert, 0600))
+	require.NoError(t, os.WriteFile(pki.keyFile, serverKey, 0600))
+
+	clientCert, clientKey := issue(3, x509.ExtKeyUsageClientAuth)
+	pki.clientCert, err = tls.X509KeyPair(clientCert, clientKey)
+	require.NoError(t, err)
+	return pki
+}
+
+func TestGRPCTLSConfig(t *testing.T) {
+	pki := newTestPKI(t)
+
+	tests := []struct {
+		name      string
+		address   string
+		cert      string
+		key       string
+		clientCA  string
+		wantTLS   bool
+		wantError string
+	}{
+		{name: "plaintext on loopback", address: "127.0.0.1:9443"},
+		{name: "plaintext on localhost", address: "localhost:9443"},
+		{name: "plaintext on all interfaces", address: ":9443", wantError: "--grpc-address :9443 is not a loopback address"},
+		{name: "mtls", address: ":9443", cert: pki.certFile, key: pki.keyFile, clientCA: pki.caFile, wantTLS: true},
+		{name: "cert without key", address: ":9443", cert: pki.certFile, clientCA: pki.caFile, wantError: "must be set together"},
+		{name: "cert without client CA", address: ":9443", cert: pki.certFile, key: pki.keyFile, wantError: "--grpc-client-ca-file is required"},
+		{name: "client CA without cert", address: "127.0.0.1:9443", clientCA: pki.caFile, wantError: "requires --grpc-cert-file"},
+		{name: "client CA without certificates", address: ":9443", cert: pki.certFile, key: pki.keyFile, clientCA: pki.keyFile, wantError: "contains no PEM certificates"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := grpcTLSConfig(tt.address, tt.cert, tt.key, tt.clientCA)
+			if tt.wantError != "" {
+				require.Error(t, err)
+				assert.True(t, errors.Is(err, errors.ErrConfigInvalid), "got %v", err)
+				assert.Contains(t, err.Error(), tt.wantError)
+				return
+			}
+			require.NoError(t, err)
+			if !tt.wantTLS {
+				assert.Nil(t, config)
+				return
+			}
+			require.NotNil(t, config)
+			assert.Equal(t, tls.RequireAndVerifyClientCert, config.ClientAuth)
+		})
+	}
+}
+
+func TestTokenService_MTLS(t *testing.T) {
+	pki := newTestPKI(t)
+	tlsConfig, err := grpcTLSConfig("127.0.0.1:0", pki.certFile, pki.keyFile, pki.caFile)
+	require.NoError(t, err)
+
+	_, m := newMetrics(metrics.DefaultConfig())
+	server := newGRPCServer(newTokenService(newAWSProvider(), &common.Flags{Region: "us-east-1"}, m, logger.Nop()), tlsConfig)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go server.Serve(ln)
+	t.Cleanup(server.Stop)
+
+	dial := func(certificates []tls.Certificate) tokenservice.TokenServiceClient {
+		creds := grpccredentials.NewTLS(&tls.Config{
+			RootCAs:      pki.caPool,
+			Certificates: certificates,
+			MinVersion:   tls.VersionTLS12,
+		})
+		conn, err := grpc.NewClient(ln.Addr().String(), grpc.WithTransportCredentials(creds))
+		require.NoError(t, err)
+		t.Cleanup(func() { conn.Close() })
+		return tokenservice.NewTokenServiceClient(conn)
+	}
+
+	token, err := dial([]tls.Certificate{pki.clientCert}).GetToken(context.Background(), &tokenservice.GetTokenRequest{ClusterName: "prod"})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(token.GetAccessToken(), "k8s-aws-v1."))
+
+	// A client without a certificate fails the handshake
+	_, err = dial(nil).GetToken(context.Background(), &tokenservice.GetTokenRequest{ClusterName: "prod"})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+}
@@ -0,0 +1,303 @@
+package serve
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/cmd/provider/common"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/throttle"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/health"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/metrics"
+)
+
+const (
+	// credentialsCheckName is the readiness check backed by ValidateCredentials
+	credentialsCheckName = "credentials"
+
+	// shutdownTimeout bounds how long in-flight probes may take after SIGTERM
+	shutdownTimeout = 10 * time.Second
+)
+
+var (
+	healthAddress    string
+	metricsAddress   string
+	validateInterval time.Duration
+	grpcAddress      string
+	grpcCertFile     string
+	grpcKeyFile      string
+	grpcClientCAFile string
+)
+
+// examples are the per-provider serve examples shown in help
+var examples = map[string]string{
+	"gcp": `  # GCP
+  hyperfleet-credential-provider serve --provider=gcp --project-id=my-project`,
+	"aws": `  # AWS, with metrics on a separate port
+  hyperfleet-credential-provider serve --provider=aws --region=us-east-1 --metrics-address=:9090`,
+	"azure": `  # Azure
+  hyperfleet-credential-provider serve --provider=azure --tenant-id=... --subscription-id=...`,
+	"oci": `  # OCI
+  hyperfleet-credential-provider serve --provider=oci --region=us-ashburn-1`,
+	"digitalocean": `  # DigitalOcean
+  hyperfleet-credential-provider serve --provider=digitalocean`,
+	"oidc": `  # Generic OIDC issuer
+  hyperfleet-credential-provider serve --provider=oidc --issuer-url=https://issuer.example.com --client-id=hyperfleet`,
+}
+
+func NewCommand(flags *common.Flags) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Run as a long-lived sidecar serving health probes and metrics",
+		Long: `Run as a long-lived process that serves liveness and readiness probes and
+Prometheus metrics until SIGTERM or SIGINT.
+
+The readiness probe validates the configured provider's credentials and reports
+"degraded" with HTTP 503 while they are invalid. Validation results are reused for
+--validate-interval so that frequent probes do not call the cloud API every time.
+
+Metric names start with --metrics-namespace and --metrics-subsystem. With
+--metrics-enabled=false, no metrics are collected and /metrics is not served.
+--metrics-cluster-label=false drops the cluster label of the token gauges for
+large fleets.
+
+With --grpc-address, the TokenService of pkg/tokenservice is served on a separate
+listener so that gRPC clients can request tokens, ExecCredentials and cluster details
+with the serve credentials. Listeners other than loopback require mTLS with
+--grpc-cert-file, --grpc-key-file and --grpc-client-ca-file. --token-rate-limit and
+--breaker-failures throttle its token requests per cluster; rejected calls fail with
+RESOURCE_EXHAUSTED and a RetryInfo detail.
+
+Pass --provider with --help to list only that provider's flags.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return run(flags)
+		},
+	}
+
+	cmd.Flags().StringVar(&flags.ProviderName, "provider", "", "Cloud provider (gcp, aws, azure, oci, digitalocean, oidc) [required]")
+	cmd.Flags().StringVar(&flags.Region, "region", "", "Cloud region (optional for GCP, required for AWS, optional for OCI)")
+	cmd.Flags().StringVar(&flags.ProjectID, "project-id", "", "GCP project ID (required for GCP)")
+	cmd.Flags().StringVar(&flags.GCPUseADC, "gcp-use-adc", "auto", "Use GCP application default credentials: auto (when no credentials file is set), true, or false")
+	cmd.Flags().StringVar(&flags.AccountID, "account-id", "", "AWS account ID (optional)")
+	cmd.Flags().StringArrayVar(&flags.AWSFallbacks, "aws-fallback", nil, "AWS credential source tried when the primary credentials fail, as profile=NAME,region=REGION,role_arn=ARN (repeatable, tried in order)")
+	cmd.Flags().StringVar(&flags.SubscriptionID, "subscription-id", "", "Azure subscription ID (required for Azure)")
+	cmd.Flags().StringVar(&flags.AzureCloud, "azure-cloud", "", "Azure cloud (public, usgovernment, china; default: public)")
+	cmd.Flags().StringVar(&flags.TenantID, "tenant-id", "", "Azure tenant ID (required for Azure)")
+	cmd.Flags().StringVar(&flags.TenancyID, "tenancy-id", "", "OCI tenancy OCID (default: from the OCI config file)")
+	cmd.Flags().StringVar(&flags.UserID, "user-id", "", "OCI user OCID (default: from the OCI config file)")
+	cmd.Flags().StringVar(&flags.OIDCIssuerURL, "issuer-url", "", "OIDC issuer URL; the token endpoint is read from its discovery document (required for OIDC)")
+	cmd.Flags().StringVar(&flags.OIDCClientID, "client-id", "", "OIDC client ID (required for OIDC)")
+	cmd.Flags().StringVar(&flags.OIDCPrivateKeyFile, "private-key-file", "", "PEM RSA or EC private key that authenticates the OIDC client with private_key_jwt instead of a client secret")
+	cmd.Flags().StringVar(&flags.OIDCSubjectTokenFile, "subject-token-file", "", "Token file exchanged with RFC 8693 token exchange instead of the client credentials grant")
+	cmd.Flags().StringVar(&healthAddress, "health-address", health.DefaultConfig().HealthAddress, "Address to serve /healthz, /livez and /readyz on")
+	cmd.Flags().StringVar(&metricsAddress, "metrics-address", "", "Address to serve /metrics on (default: the health address)")
+	cmd.Flags().BoolVar(&flags.WatchCredentials, "watch-credentials", true, "Reload the GCP, AWS or Azure credentials file when it changes on disk, e.g. when Vault Agent rotates it")
+	cmd.Flags().StringVar(&grpcAddress, "grpc-address", "", "Address to serve the gRPC token service on (default: disabled)")
+	cmd.Flags().StringVar(&grpcCertFile, "grpc-cert-file", "", "PEM certificate of the gRPC token service")
+	cmd.Flags().StringVar(&grpcKeyFile, "grpc-key-file", "", "PEM private key of --grpc-cert-file")
+	cmd.Flags().StringVar(&grpcClientCAFile, "grpc-client-ca-file", "", "PEM CA bundle that gRPC client certificates must be signed by")
+	cmd.Flags().DurationVar(&validateInterval, "validate-interval", time.Minute, "How long a credential validation result is reused by the readiness probe")
+	common.AddMetricsFlags(cmd)
+	common.AddThrottleFlags(cmd)
+	common.AddPreferSecretFlag(cmd, flags)
+
+	common.SetFlagProviders(cmd, []string{"gcp", "aws", "oci"}, "region")
+	common.SetFlagProviders(cmd, []string{"gcp"}, "project-id", "gcp-use-adc")
+	common.SetFlagProviders(cmd, []string{"aws"}, "account-id", "aws-fallback")
+	common.SetFlagProviders(cmd, []string{"azure"}, "subscription-id", "tenant-id", "azure-cloud")
+	common.SetFlagProviders(cmd, []string{"oci"}, "tenancy-id", "user-id")
+	common.SetFlagProviders(cmd, []string{"oidc"}, "issuer-url", "client-id", "private-key-file", "subject-token-file")
+	common.SetProviderHelp(cmd, examples)
+
+	return cmd
+}
+
+func run(flags *common.Flags) error {
+	// Bind Viper values to flags (environment variables take precedence if flags not set)
+	common.BindFlagsToViper(flags)
+
+	if flags.ProviderName == "" {
+		return errors.New(errors.ErrInvalidArgument, "--provider is required (or set HFCP_PROVIDER)")
+	}
+	if err := provider.CheckRegistered(flags.ProviderName); err != nil {
+		return err
+	}
+
+	ctx, cancel := common.SetupSignalHandler()
+	defer cancel()
+
+	log, err := common.CreateLogger(flags)
+	if err != nil {
+		return fmt.Errorf("failed to create logger: %w", err)
+	}
+	defer log.Sync()
+
+	metricsConfig, metricsEnabled, err := common.MetricsConfig(flags.Viper)
+	if err != nil {
+		return err
+	}
+
+	config := health.DefaultConfig()
+	config.HealthAddress = flags.Viper.GetString("health-address")
+	config.MetricsAddress = flags.Viper.GetString("metrics-address")
+	config.MetricsDisabled = !metricsEnabled
+	config.Logger = log
+
+	throttleConfig, err := common.NewThrottleConfig(flags)
+	if err != nil {
+		return err
+	}
+
+	grpcAddr := flags.Viper.GetString("grpc-address")
+	var tlsConfig *tls.Config
+	if grpcAddr != "" {
+		tlsConfig, err = grpcTLSConfig(grpcAddr,
+			flags.Viper.GetString("grpc-cert-file"),
+			flags.Viper.GetString("grpc-key-file"),
+			flags.Viper.GetString("grpc-client-ca-file"),
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	if flags.DryRun {
+		return common.RunDryRun(ctx, flags, log, os.Stdout, "serve health probes and metrics", map[string]string{
+			"health-address":  config.HealthAddress,
+			"metrics-address": config.MetricsAddress,
+			"grpc-address":    grpcAddr,
+		})
+	}
+
+	var registry *prometheus.Registry
+	var m *metrics.Metrics
+	if metricsEnabled {
+		registry, m = newMetrics(metricsConfig)
+		flags.Metrics = m
+	}
+
+	prov, err := common.CreateProvider(flags, log)
+	if err != nil {
+		log.Error("Failed to create provider", logger.String("error", err.Error()))
+		return err
+	}
+	defer common.CloseProvider(prov, log)
+
+	if throttleConfig.Enabled() {
+		if m != nil {
+			throttleConfig.Observer = m
+		}
+		prov = throttle.Wrap(prov, throttleConfig)
+	}
+
+	server := newServer(prov, config, registry, m, flags.Viper.GetDuration("validate-interval"), log)
+	if err := server.Start(); err != nil {
+		return err
+	}
+
+	var grpcServer *grpc.Server
+	if grpcAddr != "" {
+		ln, err := net.Listen("tcp", grpcAddr)
+		if err != nil {
+			server.Stop(context.Background())
+			return fmt.Errorf("failed to listen on gRPC address %s: %w", grpcAddr, err)
+		}
+		grpcServer = newGRPCServer(newTokenService(prov, flags, m, log), tlsConfig)
+		log.Info("Starting gRPC token service",
+			logger.String("address", ln.Addr().String()),
+			logger.Bool("mtls", tlsConfig != nil),
+		)
+		go func() {
+			if err := grpcServer.Serve(ln); err != nil {
+				log.Error("Server error",
+					logger.String("server", "grpc"),
+					logger.String("error", err.Error()),
+				)
+			}
+		}()
+	}
+
+	log.Info("Serving until terminated",
+		logger.String("provider", prov.Name()),
+		logger.String("health_address", server.HealthAddr()),
+	)
+	<-ctx.Done()
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer shutdownCancel()
+	if grpcServer != nil {
+		stopGRPCServer(shutdownCtx, grpcServer)
+	}
+	return server.Stop(shutdownCtx)
+}
+
+// newMetrics creates the metrics of config in a registry of the server's own, so the
+// provider can record credential reloads in it before the server starts
+func newMetrics(config metrics.Config) (*prometheus.Registry, *metrics.Metrics) {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	config.Registry = registry
+	return registry, metrics.NewMetrics(config)
+}
+
+// newServer creates the health server serving registry, unless metrics are disabled,
+// and a readiness check backed by prov.ValidateCredentials
+func newServer(prov provider.Provider, config health.Config, registry *prometheus.Registry, m *metrics.Metrics, interval time.Duration, log logger.Logger) *health.Server {
+	if registry != nil {
+		config.MetricsGatherer = registry
+	}
+	server := health.NewServer(config)
+	server.RegisterCheck(credentialsCheckName, credentialsCheck(prov, m, interval, time.Now))
+
+	log.Debug("Health server configured",
+		logger.String("provider", prov.Name()),
+		logger.String("validate_interval", interval.String()),
+	)
+
+	return server
+}
+
+// credentialsCheck returns a readiness check that validates the provider's credentials,
+// reusing the last result for interval so that probes do not call the cloud API every time
+func credentialsCheck(prov provider.Provider, m *metrics.Metrics, interval time.Duration, now func() time.Time) health.Check {
+	var (
+		mu        sync.Mutex
+		checked   time.Time
+		lastError error
+	)
+
+	return func(ctx context.Context) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if !checked.IsZero() && now().Sub(checked) < interval {
+			return lastError
+		}
+
+		timer := metrics.NewTimer()
+		lastError = prov.ValidateCredentials(ctx)
+		checked = now()
+
+		m.RecordHealthCheckDuration(credentialsCheckName, timer.ObserveDuration())
+		if lastError != nil {
+			m.RecordHealthCheckError(credentialsCheckName)
+			m.RecordCredentialValidationError(prov.Name())
+		}
+		return lastError
+	}
+}
@@ -0,0 +1,163 @@
+package serve
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/health"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/metrics"
+)
+
+func TestServer_Readiness(t *testing.T) {
+	tests := []struct {
+		name        string
+		validateErr error
+		wantCode    int
+		wantStatus  string
+		wantCheck   string
+	}{
+		{
+			name:       "valid credentials",
+			wantCode:   http.StatusOK,
+			wantStatus: "ok",
+			wantCheck:  "ok",
+		},
+		{
+			name:        "invalid credentials",
+			validateErr: errors.New(errors.ErrCredentialInvalid, "service account key revoked"),
+			wantCode:    http.StatusServiceUnavailable,
+			wantStatus:  "degraded",
+			wantCheck:   "failed: service account key revoked",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prov := &provider.MockProvider{
+				ValidateCredentialsFunc: func(ctx context.Context) error { return tt.validateErr },
+			}
+			config := health.DefaultConfig()
+			config.HealthAddress = "127.0.0.1:0"
+
+			registry, m := newMetrics(metrics.DefaultConfig())
+			server := newServer(prov, config, registry, m, time.Minute, logger.Nop())
+			require.NoError(t, server.Start())
+			t.Cleanup(func() { server.Stop(context.Background()) })
+
+			resp, err := http.Get("http://" + server.HealthAddr() + "/readyz")
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			var body health.HealthResponse
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+			assert.Equal(t, tt.wantCode, resp.StatusCode)
+			assert.Equal(t, tt.wantStatus, body.Status)
+			assert.Equal(t, tt.wantCheck, body.Checks[credentialsCheckName])
+
+			// Liveness does not depend on the credentials
+			live, err := http.Get("http://" + server.HealthAddr() + "/healthz")
+			require.NoError(t, err)
+			live.Body.Close()
+			assert.Equal(t, http.StatusOK, live.StatusCode)
+
+			// Metrics are served from the same listener
+			metricsResp, err := http.Get("http://" + server.HealthAddr() + "/metrics")
+			require.NoError(t, err)
+			defer metricsResp.Body.Close()
+			data, err := io.ReadAll(metricsResp.Body)
+			require.NoError(t, err)
+			assert.Contains(t, string(data), "hyperfleet_cloud_provider_health_check_duration_seconds")
+			assert.Equal(t, tt.validateErr != nil,
+				strings.Contains(string(data), `hyperfleet_cloud_provider_credential_validation_errors_total{provider="mock"} 1`))
+		})
+	}
+}
+
+func TestCredentialsCheck_ReusesResultWithinInterval(t *testing.T) {
+	calls := 0
+	var validateErr error = errors.New(errors.ErrCredentialExpired, "expired")
+	prov := &provider.MockProvider{
+		ValidateCredentialsFunc: func(ctx context.Context) error {
+			calls++
+			return validateErr
+		},
+	}
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	m := metrics.NewMetrics(metrics.Config{Registry: prometheus.NewRegistry()})
+
+	check := credentialsCheck(prov, m, time.Minute, func() time.Time { return now })
+
+	assert.Error(t, check(context.Background()))
+	assert.Equal(t, 1, calls)
+
+	// Within the interval the cached failure is returned without calling the provider
+	now = now.Add(30 * time.Second)
+	assert.Error(t, check(context.Background()))
+	assert.Equal(t, 1, calls)
+
+	// Once the interval has passed the credentials are validated again
+	validateErr = nil
+	now = now.Add(31 * time.Second)
+	assert.NoError(t, check(context.Background()))
+	assert.Equal(t, 2, calls)
+}
+
+func TestCredentialsCheck_ZeroIntervalAlwaysValidates(t *testing.T) {
+	calls := 0
+	prov := &provider.MockProvider{
+		ValidateCredentialsFunc: func(ctx context.Context) error {
+			calls++
+			return nil
+		},
+	}
+	m := metrics.NewMetrics(metrics.Config{Registry: prometheus.NewRegistry()})
+
+	check := credentialsCheck(prov, m, 0, time.Now)
+	for i := 0; i < 3; i++ {
+		require.NoError(t, check(context.Background()))
+	}
+	assert.Equal(t, 3, calls)
+}
+
+func TestServer_MetricsDisabled(t *testing.T) {
+	config := health.DefaultConfig()
+	config.HealthAddress = "127.0.0.1:0"
+	config.MetricsDisabled = true
+
+	server := newServer(&provider.MockProvider{}, config, nil, nil, time.Minute, logger.Nop())
+	require.NoError(t, server.Start())
+	t.Cleanup(func() { server.Stop(context.Background()) })
+
+	resp, err := http.Get("http://" + server.HealthAddr() + "/")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	var root struct {
+		Endpoints []string `json:"endpoints"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&root))
+	assert.Contains(t, root.Endpoints, "/readyz")
+	assert.NotContains(t, root.Endpoints, "/metrics")
+
+	metricsResp, err := http.Get("http://" + server.HealthAddr() + "/metrics")
+	require.NoError(t, err)
+	metricsResp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, metricsResp.StatusCode)
+
+	// The readiness check records nothing without metrics
+	ready, err := http.Get("http://" + server.HealthAddr() + "/readyz")
+	require.NoError(t, err)
+	ready.Body.Close()
+	assert.Equal(t, http.StatusOK, ready.StatusCode)
+}
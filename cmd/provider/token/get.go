@@ -1,7 +1,10 @@
 package token
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"time"
 
@@ -9,10 +12,32 @@ import (
 
 	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/cmd/provider/common"
 	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/execplugin"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/filelock"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/headercheck"
 	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
 	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
 )
 
+// currentTokenFileMode keeps the --current-token-file token readable by its owner only
+const currentTokenFileMode os.FileMode = 0o600
+
+// examples are the per-provider get-token examples shown in help
+var examples = map[string]string{
+	"gcp": `  # GCP/GKE
+  hyperfleet-credential-provider get-token --provider=gcp --cluster-name=my-cluster --project-id=my-project`,
+	"aws": `  # AWS/EKS
+  hyperfleet-credential-provider get-token --provider=aws --cluster-name=my-cluster --region=us-east-1`,
+	"azure": `  # Azure/AKS
+  hyperfleet-credential-provider get-token --provider=azure --cluster-name=my-cluster --tenant-id=... --subscription-id=...`,
+	"oci": `  # OCI/OKE
+  hyperfleet-credential-provider get-token --provider=oci --cluster-name=ocid1.cluster.oc1.iad.aaaa... --region=us-ashburn-1`,
+	"digitalocean": `  # DigitalOcean/DOKS
+  hyperfleet-credential-provider get-token --provider=digitalocean --cluster-name=my-cluster`,
+	"oidc": `  # Generic OIDC issuer (client credentials, or token exchange with --subject-token-file)
+  hyperfleet-credential-provider get-token --provider=oidc --cluster-name=my-cluster --issuer-url=https://issuer.example.com --client-id=hyperfleet`,
+}
+
 func NewCommand(flags *common.Flags) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "get-token",
@@ -20,37 +45,65 @@ func NewCommand(flags *common.Flags) *cobra.Command {
 		Long: `Generate a short-lived authentication token for a Kubernetes cluster.
 
 Outputs an ExecCredential JSON structure compatible with Kubernetes exec plugin.
+Only the ExecCredential JSON is written to stdout; all logs go to stderr.
+Use --quiet to log errors only, regardless of --log-level or HFCP_LOG_LEVEL.
 
-Examples:
-  # GCP/GKE
-  hyperfleet-credential-provider get-token --provider=gcp --cluster-name=my-cluster --project-id=my-project
-
-  # AWS/EKS
-  hyperfleet-credential-provider get-token --provider=aws --cluster-name=my-cluster --region=us-east-1
-
-  # Azure/AKS
-  hyperfleet-credential-provider get-token --provider=azure --cluster-name=my-cluster --tenant-id=... --subscription-id=...
-`,
-		PreRunE: func(cmd *cobra.Command, args []string) error {
-			// Bind Viper values to flags before validation
-			common.BindFlagsToViper(flags)
-			return nil
-		},
+With --current-token-file, the token in the file is printed without calling the
+cloud while the provider accepts it and it is not within the provider's refresh
+threshold of expiry. Otherwise a new token is generated and replaces the file.
+--token-cache does the same with one file per cluster and credentials under
+--cache-dir.
+Pass --provider with --help to list only that provider's flags.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return run(flags)
+			return run(flags, cmd.OutOrStdout())
 		},
 	}
 
-	cmd.Flags().StringVar(&flags.ProviderName, "provider", "", "Cloud provider (gcp, aws, azure) [required]")
+	cmd.Flags().StringVar(&flags.ProviderName, "provider", "", "Cloud provider (gcp, aws, azure, oci, digitalocean, oidc) [required]")
 	cmd.Flags().StringVar(&flags.ClusterName, "cluster-name", "", "Cluster name [required]")
-	cmd.Flags().StringVar(&flags.Region, "region", "", "Cloud region (optional for GCP, required for AWS, optional for Azure)")
+	cmd.Flags().StringVar(&flags.Region, "region", "", "Cloud region (optional for GCP, required for AWS, optional for Azure, optional for OCI)")
 	cmd.Flags().StringVar(&flags.ProjectID, "project-id", "", "GCP project ID (required for GCP)")
+	cmd.Flags().StringVar(&flags.GCPUseADC, "gcp-use-adc", "auto", "Use GCP application default credentials: auto (when no credentials file is set), true, or false")
 	cmd.Flags().StringVar(&flags.AccountID, "account-id", "", "AWS account ID (optional)")
+	cmd.Flags().StringVar(&flags.AWSProfile, "profile", "", "AWS shared config profile (default: AWS_PROFILE, then default)")
+	cmd.Flags().BoolVar(&flags.SkipCredentialCheck, "skip-credential-check", false, "Skip the check that AWS session credentials have not expired (saves an STS call when their expiration is unknown)")
+	cmd.Flags().StringVar(&flags.STSEndpoint, "sts-endpoint", "", "AWS STS endpoint URL that tokens are presigned for, e.g. an interface VPC endpoint (default: the regional endpoint)")
+	cmd.Flags().StringArrayVar(&flags.AWSFallbacks, "aws-fallback", nil, "AWS credential source tried when the primary credentials fail, as profile=NAME,region=REGION,role_arn=ARN (repeatable, tried in order)")
+	cmd.Flags().String("cluster-id", "", "AWS cluster ID sent in the x-k8s-aws-id header when it differs from the cluster name (default: the cluster name)")
 	cmd.Flags().StringVar(&flags.SubscriptionID, "subscription-id", "", "Azure subscription ID (required for Azure)")
+	cmd.Flags().StringVar(&flags.AzureCloud, "azure-cloud", "", "Azure cloud (public, usgovernment, china; default: public)")
 	cmd.Flags().StringVar(&flags.TenantID, "tenant-id", "", "Azure tenant ID (required for Azure)")
+	cmd.Flags().StringVar(&flags.ResourceGroup, "resource-group", "", "Azure resource group (required for Azure with --verify)")
+	cmd.Flags().StringVar(&flags.TenancyID, "tenancy-id", "", "OCI tenancy OCID (default: from the OCI config file)")
+	cmd.Flags().StringVar(&flags.UserID, "user-id", "", "OCI user OCID (default: from the OCI config file)")
+	cmd.Flags().StringVar(&flags.CompartmentID, "compartment-id", "", "OCI compartment OCID (required for OCI when --cluster-name is not a cluster OCID)")
+	cmd.Flags().StringVar(&flags.OIDCIssuerURL, "issuer-url", "", "OIDC issuer URL; the token endpoint is read from its discovery document (required for OIDC)")
+	cmd.Flags().StringVar(&flags.OIDCClientID, "client-id", "", "OIDC client ID (required for OIDC)")
+	cmd.Flags().StringVar(&flags.OIDCPrivateKeyFile, "private-key-file", "", "PEM RSA or EC private key that authenticates the OIDC client with private_key_jwt instead of a client secret")
+	cmd.Flags().StringVar(&flags.OIDCScopes, "scopes", "", "Comma-separated OIDC scopes to request (default: openid)")
+	cmd.Flags().StringVar(&flags.OIDCSubjectTokenFile, "subject-token-file", "", "Token file, such as a projected service account token, exchanged with RFC 8693 token exchange instead of the client credentials grant")
+	cmd.Flags().StringVar(&flags.OIDCSubjectTokenType, "subject-token-type", "", "RFC 8693 type of the --subject-token-file token (default: urn:ietf:params:oauth:token-type:jwt)")
+	cmd.Flags().BoolVar(&flags.OIDCUseIDToken, "use-id-token", false, "Return the OIDC ID token instead of the access token")
+	cmd.Flags().Int("token-size-warn-threshold", headercheck.DefaultTokenSizeWarnThreshold, "Warn when the Authorization header exceeds this many bytes (proxies may truncate large headers)")
+	cmd.Flags().Bool("verify", false, "Check that the cluster API server accepts the token before writing it (looks up the cluster endpoint and CA unless --cluster-endpoint or --cluster-info-file is set)")
+	cmd.Flags().String("cluster-info-file", "", "With --verify, read the cluster endpoint and CA from a JSON file exported by get-cluster-info instead of looking the cluster up")
+	cmd.Flags().String("cluster-endpoint", "", "With --verify, the cluster API server endpoint; skips the cluster lookup (requires --cluster-ca-file or --cluster-ca-data unless --cluster-info-file is set)")
+	cmd.Flags().String("cluster-ca-file", "", "With --verify, the PEM-encoded cluster CA certificate file; skips the cluster lookup")
+	cmd.Flags().String("cluster-ca-data", "", "With --verify, the cluster CA certificate as base64-encoded PEM, as in kubeconfig certificate-authority-data, or PEM; skips the cluster lookup")
+	cmd.Flags().String("current-token-file", "", "ExecCredential file from an earlier get-token run: reuse its token while it is valid, otherwise generate a token and overwrite the file (one file per cluster)")
+	cmd.Flags().String("audience", "", "Bind the token to this audience instead of the cluster default (GCP: ID token audience, AWS: x-k8s-aws-id cluster ID, Azure: resource application ID URI, OIDC: audience parameter)")
+	common.AddTokenCacheFlags(cmd)
+	common.AddPreferSecretFlag(cmd, flags)
+	common.AddCredentialsProfileFlag(cmd, flags)
 
-	// Bind flags to viper for environment variable support
-	common.BindCommandFlags(cmd)
+	common.SetFlagProviders(cmd, []string{"gcp", "aws", "azure", "oci"}, "region")
+	common.SetFlagProviders(cmd, []string{"gcp"}, "project-id", "gcp-use-adc")
+	common.SetFlagProviders(cmd, []string{"aws"}, "account-id", "profile", "cluster-id", "skip-credential-check", "sts-endpoint", "aws-fallback")
+	common.SetFlagProviders(cmd, []string{"azure"}, "subscription-id", "tenant-id", "azure-cloud", "resource-group")
+	common.SetFlagProviders(cmd, []string{"oci"}, "tenancy-id", "user-id", "compartment-id")
+	common.SetFlagProviders(cmd, []string{"oidc"}, "issuer-url", "client-id", "private-key-file", "scopes", "subject-token-file", "subject-token-type", "use-id-token")
+	common.SetFlagProviders(cmd, []string{"gcp", "aws", "azure", "oidc"}, "audience")
+	common.SetProviderHelp(cmd, examples)
 
 	// Note: We don't use MarkFlagRequired because Cobra validates before Viper bindings take effect
 	// Instead, we validate in the RunE function after BindFlagsToViper is called
@@ -58,15 +111,82 @@ Examples:
 	return cmd
 }
 
-func run(flags *common.Flags) error {
+// run generates the token and writes the ExecCredential to stdout. kubectl parses stdout
+// as a single JSON document, so nothing else may be written to it.
+func run(flags *common.Flags, stdout io.Writer) error {
 	// Bind Viper values to flags (environment variables take precedence if flags not set)
 	common.BindFlagsToViper(flags)
 
-	if flags.ProviderName == "" {
-		return fmt.Errorf("--provider is required (or set HFCP_PROVIDER)")
+	verify := flags.Viper.GetBool("verify")
+	clusterInfoFile := flags.Viper.GetString("cluster-info-file")
+	clusterEndpoint := flags.Viper.GetString("cluster-endpoint")
+	clusterCAFile := flags.Viper.GetString("cluster-ca-file")
+	clusterCAData := flags.Viper.GetString("cluster-ca-data")
+	offline := clusterInfoFile != "" || clusterEndpoint != "" || clusterCAFile != "" || clusterCAData != ""
+	if offline && !verify {
+		return errors.New(
+			errors.ErrInvalidArgument,
+			"--cluster-info-file, --cluster-endpoint, --cluster-ca-file and --cluster-ca-data require --verify",
+		)
+	}
+
+	// The cluster is only looked up when --verify is not given its endpoint and CA
+	var op provider.Operation
+	if verify && !offline {
+		op = provider.OperationClusterLookup
+	}
+	if err := common.ValidateInputs(flags, op); err != nil {
+		return err
+	}
+	if err := provider.CheckRegistered(flags.ProviderName); err != nil {
+		return err
 	}
-	if flags.ClusterName == "" {
-		return fmt.Errorf("--cluster-name is required (or set HFCP_CLUSTER_NAME)")
+	audience := flags.Viper.GetString("audience")
+	if audience != "" {
+		if err := provider.CheckAudienceSupported(flags.ProviderName); err != nil {
+			return err
+		}
+	}
+
+	opts := provider.GetTokenOptions{
+		ClusterName:    flags.ClusterName,
+		Region:         flags.Region,
+		ProjectID:      flags.ProjectID,
+		AccountID:      flags.AccountID,
+		ClusterID:      flags.Viper.GetString("cluster-id"),
+		SubscriptionID: flags.SubscriptionID,
+		TenantID:       flags.TenantID,
+		CompartmentID:  flags.CompartmentID,
+		Audience:       audience,
+	}
+	// Catch malformed cluster names and identifiers before loading credentials
+	if err := opts.Validate(flags.ProviderName); err != nil {
+		return err
+	}
+	if err := common.CheckClusterAllowed(flags); err != nil {
+		return err
+	}
+	if err := common.ApplyCredentialsMap(flags); err != nil {
+		return err
+	}
+	currentTokenFile := flags.Viper.GetString("current-token-file")
+	cacheDir, err := common.TokenCacheDir(flags)
+	if err != nil {
+		return err
+	}
+	if currentTokenFile != "" && cacheDir != "" {
+		return errors.New(
+			errors.ErrInvalidArgument,
+			"--current-token-file and --token-cache cannot be used together",
+		)
+	}
+	// Offline cluster info is checked before a token is generated for it
+	var clusterInfo *common.ClusterInfo
+	if offline {
+		clusterInfo, err = common.LoadOfflineClusterInfo(clusterInfoFile, clusterEndpoint, clusterCAFile, clusterCAData)
+		if err != nil {
+			return err
+		}
 	}
 
 	ctx, cancel := common.SetupSignalHandler()
@@ -83,33 +203,68 @@ func run(flags *common.Flags) error {
 		logger.String("cluster", flags.ClusterName),
 	)
 
+	if flags.DryRun {
+		return common.RunDryRun(ctx, flags, log, stdout, "generate a token", map[string]string{
+			"region":             flags.Region,
+			"project-id":         flags.ProjectID,
+			"subscription-id":    flags.SubscriptionID,
+			"tenant-id":          flags.TenantID,
+			"azure-cloud":        flags.AzureCloud,
+			"compartment-id":     flags.CompartmentID,
+			"issuer-url":         flags.OIDCIssuerURL,
+			"audience":           audience,
+			"cluster-id":         flags.Viper.GetString("cluster-id"),
+			"current-token-file": currentTokenFile,
+			"token-cache":        cacheDir,
+		})
+	}
+
 	prov, err := common.CreateProvider(flags, log)
 	if err != nil {
 		log.Error("Failed to create provider", logger.String("error", err.Error()))
 		return err
 	}
 
-	opts := provider.GetTokenOptions{
-		ClusterName:    flags.ClusterName,
-		Region:         flags.Region,
-		ProjectID:      flags.ProjectID,
-		AccountID:      flags.AccountID,
-		SubscriptionID: flags.SubscriptionID,
-		TenantID:       flags.TenantID,
-	}
+	tokenCtx, tokenCancel := common.WithTimeout(ctx, flags)
+	defer tokenCancel()
 
-	token, err := prov.GetToken(ctx, opts)
+	start := time.Now()
+	var token *provider.Token
+	switch {
+	case currentTokenFile != "":
+		fingerprint := provider.ScopedFingerprint(flags.ProviderName, opts, common.TokenCacheScope(flags))
+		token, err = refreshCurrentToken(tokenCtx, prov, opts, currentTokenFile, fingerprint, log)
+	case cacheDir != "":
+		token, err = refreshCachedToken(tokenCtx, flags, prov, cacheDir, opts, log)
+	default:
+		token, err = prov.GetToken(tokenCtx, opts)
+	}
 	if err != nil {
+		err = common.TimeoutError(tokenCtx, err, "get token", start)
 		log.Error("Failed to generate token", logger.String("error", err.Error()))
 		return err
 	}
 
-	log.Info("Token generated successfully",
-		logger.String("provider", flags.ProviderName),
+	size := headercheck.WarnIfLarge(log, token.AccessToken, flags.Viper.GetInt("token-size-warn-threshold"))
+
+	// The issuing metadata names the identity that minted the token; a token reused
+	// from --current-token-file has none
+	fields := provider.TokenLogFields(token)
+	if token.Provider == "" {
+		fields = append(fields, logger.String("provider", flags.ProviderName))
+	}
+	log.Info("Token generated successfully", append(fields,
 		logger.String("expires_at", token.ExpiresAt.Format(time.RFC3339)),
-	)
+		logger.Int("token_bytes", size.TokenBytes),
+	)...)
+
+	if verify {
+		if err := verifyToken(ctx, flags, prov, clusterInfo, token.AccessToken, log); err != nil {
+			return err
+		}
+	}
 
-	writer := execplugin.NewOutputWriter(os.Stdout)
+	writer := execplugin.NewOutputWriter(stdout)
 	if err := writer.WriteToken(token); err != nil {
 		log.Error("Failed to write token output", logger.String("error", err.Error()))
 		return err
@@ -117,3 +272,122 @@ func run(flags *common.Flags) error {
 
 	return nil
 }
+
+// currentTokenFileContent is the ExecCredential written to --current-token-file, with
+// the fingerprint of the options and credentials its token was generated for
+type currentTokenFileContent struct {
+	*execplugin.ExecCredential
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+// refreshCurrentToken returns the token in path while it was generated for fingerprint,
+// the provider accepts it and it is outside the refresh threshold. Otherwise it
+// generates a token, validates it and replaces path with it. A missing, unreadable,
+// expired or mismatched file only means a new token.
+func refreshCurrentToken(ctx context.Context, prov provider.Provider, opts provider.GetTokenOptions, path, fingerprint string, log logger.Logger) (*provider.Token, error) {
+	current, err := readCurrentToken(path, fingerprint)
+	if err != nil {
+		log.Debug("Not reusing the current token file", logger.String("file", path), logger.Error(err))
+	}
+
+	token, err := prov.RefreshToken(ctx, opts, current)
+	if err != nil {
+		return nil, err
+	}
+	if current != nil && token == current {
+		log.Info("Reusing token from current token file",
+			logger.String("file", path),
+			logger.String("expires_at", token.ExpiresAt.Format(time.RFC3339)),
+		)
+		return token, nil
+	}
+
+	if err := prov.ValidateToken(token); err != nil {
+		return nil, err
+	}
+	credential := execplugin.NewExecCredential(token.AccessToken, token.ExpiresAt)
+	if err := credential.Validate(); err != nil {
+		return nil, err
+	}
+	data, err := json.MarshalIndent(currentTokenFileContent{ExecCredential: credential, Fingerprint: fingerprint}, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode current token file: %w", err)
+	}
+	// The token is still printed when the file cannot be replaced
+	if err := filelock.WriteAtomic(path, append(data, '\n'), currentTokenFileMode); err != nil {
+		log.Warn("Failed to write current token file", logger.String("file", path), logger.Error(err))
+	}
+	return token, nil
+}
+
+// refreshCachedToken returns the token cached in cacheDir for opts while the provider
+// accepts it and it is outside the refresh threshold, and otherwise generates a token
+// and caches it. The request is added to the cache stats of cacheDir.
+func refreshCachedToken(ctx context.Context, flags *common.Flags, prov provider.Provider, cacheDir string, opts provider.GetTokenOptions, log logger.Logger) (*provider.Token, error) {
+	store, err := common.NewTokenStore(cacheDir)
+	if err != nil {
+		return nil, err
+	}
+
+	token, outcome, err := provider.NewStoreRefresher(flags.ProviderName, prov, store, log).
+		WithScope(common.TokenCacheScope(flags)).
+		WithRequestObserver(provider.RequestObservers{flags.Metrics, common.NewTokenStatsRecorder(cacheDir, log)}).
+		Refresh(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	if outcome == provider.CacheHit {
+		log.Info("Reusing token from token cache",
+			logger.String("expires_at", token.ExpiresAt.Format(time.RFC3339)),
+		)
+	}
+	return token, nil
+}
+
+// readCurrentToken reads the ExecCredential written to path by an earlier run for
+// fingerprint. A missing file is reported as no token, and a token generated for other
+// options or credentials, or by a version that did not record them, as an error.
+func readCurrentToken(path, fingerprint string) (*provider.Token, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := execplugin.ParseToken(data)
+	if err != nil {
+		return nil, err
+	}
+	var content currentTokenFileContent
+	if err := json.Unmarshal(data, &content); err != nil {
+		return nil, err
+	}
+	if content.Fingerprint != fingerprint {
+		return nil, errors.New(
+			errors.ErrTokenInvalid,
+			"current token file was written for another cluster, audience or credentials",
+		)
+	}
+	return token, nil
+}
+
+// verifyToken checks that the API server accepts the token. Without offline cluster
+// info, the cluster endpoint and CA are looked up with the provider that issued the token.
+func verifyToken(ctx context.Context, flags *common.Flags, prov provider.Provider, info *common.ClusterInfo, token string, log logger.Logger) error {
+	if info != nil {
+		return common.VerifyToken(ctx, flags, info, token, common.StatusOutput(flags), log)
+	}
+
+	describer, ok := prov.(provider.ClusterDescriber)
+	if !ok {
+		return errors.New(errors.ErrInvalidArgument, fmt.Sprintf("--verify is not supported for provider %s: it does not support cluster lookup", flags.ProviderName))
+	}
+	info, err := common.DescribeClusterWith(ctx, describer, flags)
+	if err != nil {
+		log.Error("Failed to look up the cluster for --verify", logger.String("error", err.Error()))
+		return fmt.Errorf("failed to get cluster info for --verify: %w", err)
+	}
+	return common.VerifyToken(ctx, flags, info, token, common.StatusOutput(flags), log)
+}
@@ -0,0 +1,533 @@
+package token
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/cmd/provider/common"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/execplugin"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+)
+
+// captureFile redirects *target to a pipe and returns a function that restores it
+// and returns everything written
+func captureFile(t *testing.T, target **os.File) func() string {
+	t.Helper()
+
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+
+	original := *target
+	*target = w
+
+	var buf bytes.Buffer
+	done := make(chan struct{})
+	go func() {
+		io.Copy(&buf, r)
+		close(done)
+	}()
+
+	return func() string {
+		*target = original
+		w.Close()
+		<-done
+		r.Close()
+		return buf.String()
+	}
+}
+
+// runGetToken runs get-token the way main does, capturing the process stdout and
+// stderr so that writes bypassing the command's writers are caught as well
+func runGetToken(t *testing.T, args ...string) (stdout, stderr string, err error) {
+	t.Helper()
+
+	flags := &common.Flags{}
+	root := &cobra.Command{
+		Use:           "hyperfleet-credential-provider",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			flags.Viper = common.NewViper(cmd)
+		},
+	}
+	root.PersistentFlags().StringVar(&flags.LogLevel, "log-level", "info", "")
+	root.PersistentFlags().StringVar(&flags.LogFormat, "log-format", "json", "")
+	root.PersistentFlags().StringVar(&flags.CredentialsFile, "credentials-file", "", "")
+	root.PersistentFlags().BoolVar(&flags.Quiet, "quiet", false, "")
+	root.PersistentFlags().BoolVar(&flags.DryRun, "dry-run", false, "")
+	root.AddCommand(NewCommand(flags))
+	root.SetArgs(append([]string{"get-token"}, args...))
+
+	stopStdout := captureFile(t, &os.Stdout)
+	stopStderr := captureFile(t, &os.Stderr)
+	err = root.Execute()
+	stderr = stopStderr()
+	stdout = stopStdout()
+
+	return stdout, stderr, err
+}
+
+// assertPureStdout checks stdout is empty or exactly one JSON document
+func assertPureStdout(t *testing.T, stdout string) {
+	t.Helper()
+
+	if strings.TrimSpace(stdout) == "" {
+		return
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(stdout))
+	var doc map[string]interface{}
+	require.NoError(t, decoder.Decode(&doc), "stdout is not JSON: %q", stdout)
+	_, err := decoder.Token()
+	assert.ErrorIs(t, err, io.EOF, "stdout has content after the JSON document: %q", stdout)
+}
+
+func TestGetToken_StdoutPurityOnFailure(t *testing.T) {
+	dir := t.TempDir()
+	malformed := filepath.Join(dir, "gcp.json")
+	require.NoError(t, os.WriteFile(malformed, []byte("{not json"), 0600))
+
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{
+			name: "malformed GCP credentials file",
+			args: []string{"--provider=gcp", "--cluster-name=c", "--project-id=my-project", "--gcp-use-adc=false", "--credentials-file=" + malformed},
+		},
+		{
+			name: "missing AWS credentials file",
+			args: []string{"--provider=aws", "--cluster-name=c", "--region=us-east-1", "--credentials-file=" + filepath.Join(dir, "missing")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("HFCP_LOG_LEVEL", "debug")
+
+			stdout, stderr, err := runGetToken(t, tt.args...)
+
+			require.Error(t, err)
+			assertPureStdout(t, stdout)
+			assert.Contains(t, stderr, `"level":"debug"`, "debug logs should go to stderr")
+		})
+	}
+}
+
+func TestGetToken_Quiet(t *testing.T) {
+	t.Setenv("HFCP_LOG_LEVEL", "debug")
+	missing := filepath.Join(t.TempDir(), "missing")
+
+	stdout, stderr, err := runGetToken(t, "--quiet", "--provider=aws", "--cluster-name=c", "--region=us-east-1", "--credentials-file="+missing)
+
+	require.Error(t, err)
+	assertPureStdout(t, stdout)
+	assert.NotContains(t, stderr, `"level":"debug"`)
+	assert.NotContains(t, stderr, `"level":"info"`)
+	assert.Contains(t, stderr, `"level":"error"`)
+}
+
+func TestGetToken_QuietSuccess(t *testing.T) {
+	t.Setenv("HFCP_LOG_LEVEL", "debug")
+	tokenFile := filepath.Join(t.TempDir(), "digitalocean-token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("dop_v1_test\n"), 0600))
+
+	// DigitalOcean returns the API token without calling the cloud API
+	stdout, stderr, err := runGetToken(t, "--quiet", "--provider=digitalocean", "--cluster-name=c", "--credentials-file="+tokenFile)
+
+	require.NoError(t, err)
+	assertPureStdout(t, stdout)
+	assert.Contains(t, stdout, "ExecCredential")
+	assert.Empty(t, stderr, "a successful quiet run writes nothing to stderr")
+}
+
+func TestGetToken_DryRun(t *testing.T) {
+	t.Setenv("AZURE_CREDENTIALS_FILE", "")
+	dir := t.TempDir()
+	credentialsFile := filepath.Join(dir, "azure.json")
+	require.NoError(t, os.WriteFile(credentialsFile, []byte(`{"client_id": "client", "client_secret": "secret", "tenant_id": "tenant"}`), 0600))
+	args := []string{"--dry-run", "--provider=azure", "--cluster-name=c", "--tenant-id=44444444-4444-4444-4444-444444444444", "--credentials-file=" + credentialsFile}
+
+	stdout, _, err := runGetToken(t, args...)
+	require.NoError(t, err)
+	assert.Contains(t, stdout, "Dry run: would generate a token")
+	assert.NotContains(t, stdout, "ExecCredential")
+
+	// Invalid provider configuration fails with its usual exit code
+	_, _, err = runGetToken(t, append(args, "--azure-cloud=mars")...)
+	require.Error(t, err)
+	assert.Equal(t, common.ExitInvalidArgument, common.ExitCode(err))
+}
+
+func TestGetToken_AudienceSupport(t *testing.T) {
+	dir := t.TempDir()
+	missing := filepath.Join(dir, "missing")
+	malformed := filepath.Join(dir, "gcp.json")
+	require.NoError(t, os.WriteFile(malformed, []byte("{not json"), 0600))
+
+	// Supported providers accept --audience and fail later on the deliberately
+	// unusable credentials; the others fail early naming the provider
+	tests := []struct {
+		provider  string
+		args      []string
+		supported bool
+	}{
+		{
+			provider:  "gcp",
+			args:      []string{"--project-id=my-project", "--gcp-use-adc=false", "--credentials-file=" + malformed},
+			supported: true,
+		},
+		{
+			provider:  "aws",
+			args:      []string{"--region=us-east-1", "--credentials-file=" + missing},
+			supported: true,
+		},
+		{
+			provider:  "azure",
+			args:      []string{"--tenant-id=tenant", "--subscription-id=subscription", "--credentials-file=" + missing},
+			supported: true,
+		},
+		{
+			provider: "oci",
+			args:     []string{"--region=us-ashburn-1", "--credentials-file=" + missing},
+		},
+		{
+			provider: "digitalocean",
+			args:     []string{"--credentials-file=" + missing},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.provider, func(t *testing.T) {
+			args := append([]string{"--provider=" + tt.provider, "--cluster-name=my-cluster", "--audience=https://gateway.example.com"}, tt.args...)
+
+			stdout, _, err := runGetToken(t, args...)
+
+			require.Error(t, err)
+			assertPureStdout(t, stdout)
+			if tt.supported {
+				assert.NotContains(t, err.Error(), "does not support bound audiences")
+				return
+			}
+			assert.Contains(t, err.Error(), "provider "+tt.provider+" does not support bound audiences")
+		})
+	}
+}
+
+// currentTokenFingerprint is the fingerprint the refreshCurrentToken tests run with
+const currentTokenFingerprint = "fingerprint"
+
+// writeCurrentToken writes token to a current token file the way get-token does for
+// fingerprint
+func writeCurrentToken(t *testing.T, path string, token *provider.Token, fingerprint string) {
+	t.Helper()
+
+	data, err := json.Marshal(currentTokenFileContent{
+		ExecCredential: execplugin.NewExecCredential(token.AccessToken, token.ExpiresAt),
+		Fingerprint:    fingerprint,
+	})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, data, 0600))
+}
+
+// TestFormatToken_IgnoresIssuingMetadata checks that the ExecCredential printed by
+// get-token does not change with the issuing metadata of the token
+func TestFormatToken_IgnoresIssuingMetadata(t *testing.T) {
+	expiresAt := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	plain, err := execplugin.FormatToken(&provider.Token{AccessToken: "ya29.token", ExpiresAt: expiresAt, TokenType: "Bearer"})
+	require.NoError(t, err)
+
+	withMetadata, err := execplugin.FormatToken(&provider.Token{
+		AccessToken: "ya29.token",
+		ExpiresAt:   expiresAt,
+		TokenType:   "Bearer",
+		Provider:    "gcp",
+		ClusterName: "my-cluster",
+		Identity:    "d***@my-project.iam.gserviceaccount.com",
+		Scopes:      []string{"https://www.googleapis.com/auth/cloud-platform"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, plain, withMetadata)
+}
+
+func TestRefreshCurrentToken(t *testing.T) {
+	const eksPrefix = "k8s-aws-v1."
+	fresh := &provider.Token{AccessToken: eksPrefix + "fresh", ExpiresAt: time.Now().Add(15 * time.Minute).Truncate(time.Second), TokenType: "Bearer"}
+
+	tests := []struct {
+		name          string
+		prepare       func(t *testing.T, path string)
+		wantGenerated bool
+	}{
+		{
+			name:          "missing file",
+			prepare:       func(t *testing.T, path string) {},
+			wantGenerated: true,
+		},
+		{
+			name: "valid token is reused",
+			prepare: func(t *testing.T, path string) {
+				writeCurrentToken(t, path, &provider.Token{AccessToken: eksPrefix + "current", ExpiresAt: time.Now().Add(10 * time.Minute)}, currentTokenFingerprint)
+			},
+		},
+		{
+			name: "token for other options or credentials",
+			prepare: func(t *testing.T, path string) {
+				writeCurrentToken(t, path, &provider.Token{AccessToken: eksPrefix + "current", ExpiresAt: time.Now().Add(10 * time.Minute)}, "other")
+			},
+			wantGenerated: true,
+		},
+		{
+			name: "token without a fingerprint",
+			prepare: func(t *testing.T, path string) {
+				data, err := execplugin.FormatToken(&provider.Token{AccessToken: eksPrefix + "current", ExpiresAt: time.Now().Add(10 * time.Minute)})
+				require.NoError(t, err)
+				require.NoError(t, os.WriteFile(path, []byte(data), 0600))
+			},
+			wantGenerated: true,
+		},
+		{
+			name: "token within the refresh threshold",
+			prepare: func(t *testing.T, path string) {
+				writeCurrentToken(t, path, &provider.Token{AccessToken: eksPrefix + "current", ExpiresAt: time.Now().Add(30 * time.Second)}, currentTokenFingerprint)
+			},
+			wantGenerated: true,
+		},
+		{
+			name: "expired token",
+			prepare: func(t *testing.T, path string) {
+				writeCurrentToken(t, path, &provider.Token{AccessToken: eksPrefix + "current", ExpiresAt: time.Now().Add(-time.Hour)}, currentTokenFingerprint)
+			},
+			wantGenerated: true,
+		},
+		{
+			name: "token rejected by the provider",
+			prepare: func(t *testing.T, path string) {
+				writeCurrentToken(t, path, &provider.Token{AccessToken: "not-an-eks-token", ExpiresAt: time.Now().Add(10 * time.Minute)}, currentTokenFingerprint)
+			},
+			wantGenerated: true,
+		},
+		{
+			name: "file that is not an ExecCredential",
+			prepare: func(t *testing.T, path string) {
+				require.NoError(t, os.WriteFile(path, []byte("k8s-aws-v1.raw-token"), 0600))
+			},
+			wantGenerated: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "token.json")
+			tt.prepare(t, path)
+
+			generated := 0
+			prov := &provider.MockProvider{
+				GetTokenFunc: func(ctx context.Context, opts provider.GetTokenOptions) (*provider.Token, error) {
+					generated++
+					return fresh, nil
+				},
+				ValidateTokenFunc: func(token *provider.Token) error {
+					if !strings.HasPrefix(token.AccessToken, eksPrefix) {
+						return errors.New(errors.ErrTokenInvalid, "token does not have expected prefix")
+					}
+					if token.IsExpired() {
+						return errors.New(errors.ErrTokenExpired, "token has expired")
+					}
+					return nil
+				},
+			}
+
+			token, err := refreshCurrentToken(context.Background(), prov, provider.GetTokenOptions{ClusterName: "my-cluster"}, path, currentTokenFingerprint, logger.Nop())
+			require.NoError(t, err)
+
+			data, readErr := os.ReadFile(path)
+			require.NoError(t, readErr)
+			stored, parseErr := execplugin.ParseToken(data)
+			if !tt.wantGenerated {
+				assert.Zero(t, generated, "a valid current token must not call the cloud")
+				assert.Equal(t, eksPrefix+"current", token.AccessToken)
+				return
+			}
+			assert.Equal(t, 1, generated)
+			assert.Same(t, fresh, token)
+			require.NoError(t, parseErr)
+			assert.Equal(t, fresh.AccessToken, stored.AccessToken, "the file is replaced with the new token")
+			assert.True(t, fresh.ExpiresAt.Equal(stored.ExpiresAt))
+		})
+	}
+}
+
+func TestRefreshCurrentToken_InvalidGeneratedToken(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token.json")
+	prov := &provider.MockProvider{
+		GetTokenFunc: func(ctx context.Context, opts provider.GetTokenOptions) (*provider.Token, error) {
+			return &provider.Token{AccessToken: "bad", ExpiresAt: time.Now().Add(time.Hour)}, nil
+		},
+		ValidateTokenFunc: func(token *provider.Token) error {
+			return errors.New(errors.ErrTokenInvalid, "token does not have expected prefix")
+		},
+	}
+
+	_, err := refreshCurrentToken(context.Background(), prov, provider.GetTokenOptions{ClusterName: "my-cluster"}, path, currentTokenFingerprint, logger.Nop())
+
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, errors.ErrTokenInvalid))
+	assert.NoFileExists(t, path, "an invalid token is not written")
+}
+
+func TestGetToken_CurrentTokenFile(t *testing.T) {
+	dir := t.TempDir()
+	credentialsFile := filepath.Join(dir, "digitalocean-token")
+	require.NoError(t, os.WriteFile(credentialsFile, []byte("dop_v1_test\n"), 0600))
+	currentTokenFile := filepath.Join(dir, "token.json")
+	args := []string{"--provider=digitalocean", "--cluster-name=c", "--credentials-file=" + credentialsFile, "--current-token-file=" + currentTokenFile}
+
+	first, _, err := runGetToken(t, args...)
+	require.NoError(t, err)
+	assertPureStdout(t, first)
+	written, err := os.ReadFile(currentTokenFile)
+	require.NoError(t, err)
+	printed, err := execplugin.ParseToken([]byte(first))
+	require.NoError(t, err)
+	stored, err := execplugin.ParseToken(written)
+	require.NoError(t, err)
+	assert.Equal(t, printed.AccessToken, stored.AccessToken, "the file holds the printed token")
+	assert.NotContains(t, first, "fingerprint", "stdout is a plain ExecCredential")
+
+	// The credentials are no longer needed while the current token is valid
+	require.NoError(t, os.Remove(credentialsFile))
+	second, _, err := runGetToken(t, args...)
+	require.NoError(t, err)
+	assert.JSONEq(t, first, second)
+
+	// The token is not reused for another cluster, so the missing credentials are needed
+	otherCluster := append([]string{}, args...)
+	otherCluster[1] = "--cluster-name=other"
+	_, _, err = runGetToken(t, otherCluster...)
+	require.Error(t, err)
+}
+
+func TestGetToken_TokenCache(t *testing.T) {
+	dir := t.TempDir()
+	credentialsFile := filepath.Join(dir, "digitalocean-token")
+	require.NoError(t, os.WriteFile(credentialsFile, []byte("dop_v1_test\n"), 0600))
+	cacheDir := filepath.Join(dir, "cache")
+	args := []string{"--provider=digitalocean", "--credentials-file=" + credentialsFile, "--token-cache", "--cache-dir=" + cacheDir}
+
+	first, _, err := runGetToken(t, append(args, "--cluster-name=c")...)
+	require.NoError(t, err)
+	assertPureStdout(t, first)
+	entries, err := os.ReadDir(filepath.Join(cacheDir, "tokens"))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	// The credentials are no longer needed while the cached token is valid
+	require.NoError(t, os.Remove(credentialsFile))
+	second, stderr, err := runGetToken(t, append(args, "--cluster-name=c")...)
+	require.NoError(t, err)
+	assert.JSONEq(t, first, second)
+	assert.Contains(t, stderr, "Reusing token from token cache")
+
+	// Another cluster has no cached token
+	_, _, err = runGetToken(t, append(args, "--cluster-name=other")...)
+	require.Error(t, err)
+
+	stats, err := common.ReadTokenStats(cacheDir)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), stats.Providers["digitalocean"]["miss"].Requests)
+	assert.Equal(t, int64(1), stats.Providers["digitalocean"]["hit"].Requests)
+}
+
+func TestGetToken_TokenCacheWithCurrentTokenFile(t *testing.T) {
+	dir := t.TempDir()
+	_, _, err := runGetToken(t, "--provider=digitalocean", "--cluster-name=c", "--token-cache", "--cache-dir="+dir, "--current-token-file="+filepath.Join(dir, "token.json"))
+
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, errors.ErrInvalidArgument))
+	assert.Contains(t, err.Error(), "cannot be used together")
+}
+
+// TestGetToken_VerifyOffline checks --verify against an API server given by
+// --cluster-endpoint and --cluster-ca-data, without looking the cluster up
+func TestGetToken_VerifyOffline(t *testing.T) {
+	authorization := make(chan string, 1)
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/apis/authentication.k8s.io/v1/selfsubjectreviews" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		authorization <- r.Header.Get("Authorization")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"kind":"SelfSubjectReview","status":{"userInfo":{"username":"do:cluster-admin"}}}`))
+	}))
+	t.Cleanup(server.Close)
+	ca := base64.StdEncoding.EncodeToString(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))
+
+	dir := t.TempDir()
+	credentialsFile := filepath.Join(dir, "digitalocean-token")
+	require.NoError(t, os.WriteFile(credentialsFile, []byte("dop_v1_test\n"), 0600))
+	args := []string{"--provider=digitalocean", "--cluster-name=c", "--credentials-file=" + credentialsFile}
+
+	stdout, stderr, err := runGetToken(t, append(args, "--verify", "--cluster-endpoint="+server.URL, "--cluster-ca-data="+ca)...)
+	require.NoError(t, err, stderr)
+	assertPureStdout(t, stdout)
+	token, err := execplugin.ParseToken([]byte(stdout))
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer "+token.AccessToken, <-authorization, "the API server got the printed token")
+
+	t.Run("requires --verify", func(t *testing.T) {
+		_, _, err := runGetToken(t, append(args, "--cluster-endpoint="+server.URL, "--cluster-ca-data="+ca)...)
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, errors.ErrInvalidArgument))
+		assert.Contains(t, err.Error(), "require --verify")
+	})
+
+	t.Run("endpoint without a CA", func(t *testing.T) {
+		_, _, err := runGetToken(t, append(args, "--verify", "--cluster-endpoint="+server.URL)...)
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, errors.ErrInvalidArgument))
+		assert.Empty(t, authorization, "no token is sent without the cluster CA")
+	})
+}
+
+// testCAData is a PEM certificate block as --cluster-ca-data; it is not parsed further
+const testCAData = `-----BEGIN CERTIFICATE-----
+MIIBszCCAVmgAwIBAgIUTESTTESTTESTTESTTESTTESTTESTMAoGCCqGSM49BAMC
+-----END CERTIFICATE-----
+`
+
+// TestGetToken_VerifyOfflineSkipsLookupInputs checks that --verify with the cluster
+// endpoint and CA does not ask for the inputs of the cluster lookup
+func TestGetToken_VerifyOfflineSkipsLookupInputs(t *testing.T) {
+	for _, name := range []string{"AZURE_CREDENTIALS_FILE", "AZURE_CLIENT_ID", "AZURE_CLIENT_SECRET", "HFCP_RESOURCE_GROUP"} {
+		t.Setenv(name, "")
+	}
+	azure := []string{"--provider=azure", "--cluster-name=my-cluster", "--subscription-id=sub-123", "--tenant-id=tenant-456",
+		"--credentials-file=" + filepath.Join(t.TempDir(), "missing.json"), "--verify"}
+
+	_, _, err := runGetToken(t, azure...)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "resource-group", "the lookup needs the resource group")
+
+	_, _, err = runGetToken(t, append(azure, "--cluster-endpoint=https://my-aks.hcp.eastus.azmk8s.io:443", "--cluster-ca-data="+testCAData)...)
+	require.Error(t, err, "the credentials file is missing")
+	assert.NotContains(t, err.Error(), "resource-group")
+}
@@ -0,0 +1,257 @@
+package token
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/cmd/provider/common"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/execplugin"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider/aws"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider/azure"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider/gcp"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+)
+
+var (
+	inspectToken     string
+	inspectTokenFile string
+	inspectOutput    string
+	inspectUnsafe    bool
+)
+
+// inspection is the structured result of inspect-token
+type inspection struct {
+	Provider   string      `json:"provider"`
+	TokenBytes int         `json:"tokenBytes"`
+	ExpiresAt  *time.Time  `json:"expiresAt,omitempty"`
+	ExpiresIn  string      `json:"expiresIn,omitempty"`
+	Expired    bool        `json:"expired"`
+	Details    interface{} `json:"details"`
+}
+
+func NewInspectCommand(flags *common.Flags) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "inspect-token",
+		Aliases: []string{"decode-token"},
+		Short:   "Decode a token and show its claims for debugging",
+		Long: `Decode a Kubernetes bearer token and print a structured breakdown to help debug
+"Unauthorized" responses. Tokens are decoded locally and are NOT verified.
+
+The token is read from --token, --token-file, or stdin. ExecCredential JSON (the
+output of get-token) is accepted as input.
+
+  AWS:   decodes the k8s-aws-v1 payload and shows the cluster name, STS URL and
+         presigned URL parameters
+  GCP:   decodes JWT (ID) tokens; OAuth2 access tokens are opaque
+  Azure: decodes the JWT header and claims (iss, aud, exp, ...)
+
+Signatures, session tokens, full access key IDs and the signed part of the STS URL
+are redacted unless --unsafe is set.
+
+Examples:
+  # Inspect the token get-token would send
+  hyperfleet-credential-provider get-token --provider=aws --cluster-name=my-cluster --region=us-east-1 | \
+    hyperfleet-credential-provider inspect-token
+
+  # JSON output from a file
+  hyperfleet-credential-provider inspect-token --token-file=token.txt --output=json
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runInspect(flags, cmd.InOrStdin(), cmd.OutOrStdout())
+		},
+	}
+
+	cmd.Flags().StringVar(&flags.ProviderName, "provider", "", "Cloud provider (gcp, aws, azure); detected from the token if not set")
+	cmd.Flags().StringVar(&inspectToken, "token", "", "Token to inspect")
+	cmd.Flags().StringVar(&inspectTokenFile, "token-file", "", "File containing the token to inspect (- for stdin)")
+	cmd.Flags().StringVar(&inspectOutput, "output", "text", "Output format (text, json)")
+	cmd.Flags().BoolVar(&inspectUnsafe, "unsafe", false, "Print signatures, session tokens, full access key IDs and the full STS URL")
+
+	return cmd
+}
+
+func runInspect(flags *common.Flags, stdin io.Reader, stdout io.Writer) error {
+	if inspectOutput != "text" && inspectOutput != "json" {
+		return errors.New(errors.ErrInvalidArgument, fmt.Sprintf("invalid --output %q (must be one of: text, json)", inspectOutput))
+	}
+
+	raw, err := readInspectInput(inspectToken, inspectTokenFile, stdin)
+	if err != nil {
+		return err
+	}
+
+	result, err := inspect(raw, flags.ProviderName, inspectUnsafe, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if inspectOutput == "json" {
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(result)
+	}
+	return writeInspectText(stdout, result)
+}
+
+// readInspectInput returns the token from the flag, the file, or stdin (in that order).
+// ExecCredential JSON is unwrapped to its status.token.
+func readInspectInput(token, tokenFile string, stdin io.Reader) (string, error) {
+	parsed, err := readTokenInput(token, tokenFile, stdin)
+	if err != nil {
+		return "", err
+	}
+	return parsed.AccessToken, nil
+}
+
+// readTokenInput reads a token like readInspectInput and keeps the expirationTimestamp
+// of ExecCredential input as the token expiry; a bare token has no known expiry
+func readTokenInput(token, tokenFile string, stdin io.Reader) (*provider.Token, error) {
+	if token == "" {
+		var data []byte
+		var err error
+		if tokenFile != "" && tokenFile != "-" {
+			data, err = os.ReadFile(tokenFile)
+		} else {
+			data, err = io.ReadAll(stdin)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read token: %w", err)
+		}
+		token = string(data)
+	}
+
+	result := &provider.Token{TokenType: "Bearer"}
+	token = strings.TrimSpace(token)
+	if strings.HasPrefix(token, "{") {
+		var cred execplugin.ExecCredential
+		if err := json.Unmarshal([]byte(token), &cred); err != nil {
+			return nil, fmt.Errorf("failed to parse ExecCredential input: %w", err)
+		}
+		if cred.Status == nil {
+			return nil, fmt.Errorf("ExecCredential input has no status.token")
+		}
+		token = cred.Status.Token
+		if cred.Status.ExpirationTimestamp != nil {
+			result.ExpiresAt = cred.Status.ExpirationTimestamp.Time
+		}
+	}
+	token = strings.TrimPrefix(token, "Bearer ")
+
+	if token == "" {
+		return nil, fmt.Errorf("no token provided (use --token, --token-file, or stdin)")
+	}
+	result.AccessToken = token
+	return result, nil
+}
+
+// detectProvider guesses the provider from the token format
+func detectProvider(token string) (string, error) {
+	if strings.HasPrefix(token, "k8s-aws-v1.") {
+		return "aws", nil
+	}
+	if provider.IsJWT(token) {
+		jwt, err := provider.DecodeJWT(token)
+		if err != nil {
+			return "", err
+		}
+		issuer := jwt.StringClaim("iss")
+		if strings.Contains(issuer, "sts.windows.net") || strings.Contains(issuer, "login.microsoftonline.com") {
+			return "azure", nil
+		}
+		return "gcp", nil
+	}
+	if strings.HasPrefix(token, "ya29.") {
+		return "gcp", nil
+	}
+	return "", fmt.Errorf("unrecognized token format: expected a k8s-aws-v1. token, a JWT or a GCP access token; set --provider to decode it as a specific provider")
+}
+
+func inspect(token, providerName string, unsafe bool, now time.Time) (*inspection, error) {
+	if providerName == "" {
+		detected, err := detectProvider(token)
+		if err != nil {
+			return nil, err
+		}
+		providerName = detected
+	}
+
+	result := &inspection{
+		Provider:   providerName,
+		TokenBytes: len(token),
+	}
+
+	switch providerName {
+	case "aws":
+		info, err := aws.InspectToken(token, unsafe)
+		if err != nil {
+			return nil, fmt.Errorf("failed to inspect AWS token: %w", err)
+		}
+		result.ExpiresAt = &info.ExpiresAt
+		result.Details = info
+	case "gcp":
+		info, err := gcp.InspectToken(token, unsafe)
+		if err != nil {
+			return nil, fmt.Errorf("failed to inspect GCP token: %w", err)
+		}
+		result.ExpiresAt = info.ExpiresAt
+		result.Details = info
+	case "azure":
+		info, err := azure.InspectToken(token, unsafe)
+		if err != nil {
+			return nil, fmt.Errorf("failed to inspect Azure token: %w", err)
+		}
+		result.ExpiresAt = info.ExpiresAt
+		result.Details = info
+	default:
+		return nil, fmt.Errorf("unsupported provider: %s (must be one of: gcp, aws, azure)", providerName)
+	}
+
+	if result.ExpiresAt != nil {
+		remaining := result.ExpiresAt.Sub(now).Truncate(time.Second)
+		result.Expired = remaining <= 0
+		result.ExpiresIn = remaining.String()
+	}
+
+	return result, nil
+}
+
+// writeInspectText prints the inspection as "key: value" lines,
+// listing the provider details in struct order and skipping empty fields
+func writeInspectText(w io.Writer, result *inspection) error {
+	fmt.Fprintf(w, "provider: %s\n", result.Provider)
+	fmt.Fprintf(w, "tokenBytes: %d\n", result.TokenBytes)
+	if result.ExpiresAt != nil {
+		fmt.Fprintf(w, "expiresAt: %s\n", result.ExpiresAt.Format(time.RFC3339))
+		fmt.Fprintf(w, "expiresIn: %s\n", result.ExpiresIn)
+		fmt.Fprintf(w, "expired: %t\n", result.Expired)
+	} else {
+		fmt.Fprintln(w, "expiresAt: unknown")
+	}
+
+	fmt.Fprintln(w, "details:")
+	value := reflect.Indirect(reflect.ValueOf(result.Details))
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Field(i)
+		if field.IsZero() {
+			continue
+		}
+		name := strings.Split(value.Type().Field(i).Tag.Get("json"), ",")[0]
+
+		switch v := reflect.Indirect(field).Interface().(type) {
+		case time.Time:
+			fmt.Fprintf(w, "  %s: %s\n", name, v.Format(time.RFC3339))
+		default:
+			fmt.Fprintf(w, "  %s: %v\n", name, v)
+		}
+	}
+
+	return nil
+}
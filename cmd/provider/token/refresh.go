@@ -0,0 +1,321 @@
+package token
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/spf13/cobra"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/cmd/provider/common"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/throttle"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/tokenfile"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/health"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/metrics"
+)
+
+const (
+	// tokenFileCheckName is the readiness check reporting whether the token file is current
+	tokenFileCheckName = "token-file"
+
+	// autoInterval refreshes tokens at the provider's refresh threshold
+	autoInterval = "auto"
+
+	// healthShutdownTimeout bounds how long in-flight probes may take after SIGTERM
+	healthShutdownTimeout = 10 * time.Second
+)
+
+// refreshExamples are the per-provider refresh examples shown in help
+var refreshExamples = map[string]string{
+	"gcp": `  # GCP/GKE
+  hyperfleet-credential-provider refresh --provider=gcp --cluster-name=my-cluster --project-id=my-project --token-file=/var/run/secrets/token`,
+	"aws": `  # AWS/EKS, with readiness probes on :8080
+  hyperfleet-credential-provider refresh --provider=aws --cluster-name=my-cluster --region=us-east-1 --token-file=/var/run/secrets/token --health-address=:8080`,
+	"azure": `  # Azure/AKS
+  hyperfleet-credential-provider refresh --provider=azure --cluster-name=my-cluster --tenant-id=... --subscription-id=... --token-file=/var/run/secrets/token`,
+	"oci": `  # OCI/OKE
+  hyperfleet-credential-provider refresh --provider=oci --cluster-name=ocid1.cluster.oc1.iad.aaaa... --region=us-ashburn-1 --token-file=/var/run/secrets/token`,
+	"digitalocean": `  # DigitalOcean/DOKS, rewriting the token every 10 minutes
+  hyperfleet-credential-provider refresh --provider=digitalocean --cluster-name=my-cluster --token-file=/var/run/secrets/token --interval=10m`,
+	"oidc": `  # Generic OIDC issuer, exchanging the pod's service account token
+  hyperfleet-credential-provider refresh --provider=oidc --cluster-name=my-cluster --issuer-url=https://issuer.example.com --client-id=hyperfleet --subject-token-file=/var/run/secrets/tokens/oidc-token --token-file=/var/run/secrets/token`,
+}
+
+// NewRefreshCommand creates the refresh command, which keeps a token file current
+func NewRefreshCommand(flags *common.Flags) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "refresh",
+		Aliases: []string{"refresh-token-file"},
+		Short:   "Keep a bearer token file refreshed for workloads that read tokens from disk",
+		Long: `Write a bearer token to --token-file and rewrite it before it expires, until
+SIGTERM or SIGINT. The file is replaced atomically with mode 0600, so readers never
+see a partial token.
+
+With --interval=auto, tokens are refreshed when they reach the provider's refresh
+threshold (GCP and Azure: 5m, AWS: 2m, others: 1m before expiry). A duration
+refreshes at that interval instead, and never later than the threshold. Refreshes
+are moved earlier by a random jitter so that pods started together do not call the
+cloud API at the same time.
+
+The command exits non-zero after --max-failures refreshes in a row fail. With
+--health-address set, /readyz reports "degraded" until the first token is written
+and whenever the token in the file has expired.
+
+--token-rate-limit and --breaker-failures protect the cloud token endpoint from
+refresh loops: requests over the rate, and requests made while the circuit breaker
+is open after repeated generation failures or timeouts, fail with
+ERR_RATE_LIMIT_EXCEEDED without calling the cloud. With --health-address set, the
+breaker state and throttled requests are served on /metrics.
+
+With --watch-credentials (the default), a GCP, AWS or Azure credentials file that
+changes on disk, e.g. when Vault Agent rotates it, is loaded again and used for the
+next tokens. A file that fails to load keeps the previous credentials in use; with
+--health-address set, reloads are counted on /metrics.
+
+With --token-cache, tokens are also stored under --cache-dir, and a restarted
+command writes the cached token while it is valid instead of generating one.
+
+Pass --provider with --help to list only that provider's flags.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRefresh(flags)
+		},
+	}
+
+	cmd.Flags().StringVar(&flags.ProviderName, "provider", "", "Cloud provider (gcp, aws, azure, oci, digitalocean, oidc) [required]")
+	cmd.Flags().StringVar(&flags.ClusterName, "cluster-name", "", "Cluster name [required]")
+	cmd.Flags().StringVar(&flags.Region, "region", "", "Cloud region (optional for GCP, required for AWS, optional for Azure, optional for OCI)")
+	cmd.Flags().StringVar(&flags.ProjectID, "project-id", "", "GCP project ID (required for GCP)")
+	cmd.Flags().StringVar(&flags.GCPUseADC, "gcp-use-adc", "auto", "Use GCP application default credentials: auto (when no credentials file is set), true, or false")
+	cmd.Flags().StringVar(&flags.AccountID, "account-id", "", "AWS account ID (optional)")
+	cmd.Flags().StringVar(&flags.AWSProfile, "profile", "", "AWS shared config profile (default: AWS_PROFILE, then default)")
+	cmd.Flags().StringVar(&flags.STSEndpoint, "sts-endpoint", "", "AWS STS endpoint URL that tokens are presigned for, e.g. an interface VPC endpoint (default: the regional endpoint)")
+	cmd.Flags().StringArrayVar(&flags.AWSFallbacks, "aws-fallback", nil, "AWS credential source tried when the primary credentials fail, as profile=NAME,region=REGION,role_arn=ARN (repeatable, tried in order)")
+	cmd.Flags().StringVar(&flags.SubscriptionID, "subscription-id", "", "Azure subscription ID (required for Azure)")
+	cmd.Flags().StringVar(&flags.AzureCloud, "azure-cloud", "", "Azure cloud (public, usgovernment, china; default: public)")
+	cmd.Flags().StringVar(&flags.TenantID, "tenant-id", "", "Azure tenant ID (required for Azure)")
+	cmd.Flags().StringVar(&flags.TenancyID, "tenancy-id", "", "OCI tenancy OCID (default: from the OCI config file)")
+	cmd.Flags().StringVar(&flags.UserID, "user-id", "", "OCI user OCID (default: from the OCI config file)")
+	cmd.Flags().StringVar(&flags.CompartmentID, "compartment-id", "", "OCI compartment OCID (required for OCI when --cluster-name is not a cluster OCID)")
+	cmd.Flags().StringVar(&flags.OIDCIssuerURL, "issuer-url", "", "OIDC issuer URL; the token endpoint is read from its discovery document (required for OIDC)")
+	cmd.Flags().StringVar(&flags.OIDCClientID, "client-id", "", "OIDC client ID (required for OIDC)")
+	cmd.Flags().StringVar(&flags.OIDCPrivateKeyFile, "private-key-file", "", "PEM RSA or EC private key that authenticates the OIDC client with private_key_jwt instead of a client secret")
+	cmd.Flags().StringVar(&flags.OIDCScopes, "scopes", "", "Comma-separated OIDC scopes to request (default: openid)")
+	cmd.Flags().StringVar(&flags.OIDCSubjectTokenFile, "subject-token-file", "", "Token file, such as a projected service account token, exchanged with RFC 8693 token exchange instead of the client credentials grant")
+	cmd.Flags().StringVar(&flags.OIDCSubjectTokenType, "subject-token-type", "", "RFC 8693 type of the --subject-token-file token (default: urn:ietf:params:oauth:token-type:jwt)")
+	cmd.Flags().BoolVar(&flags.OIDCUseIDToken, "use-id-token", false, "Return the OIDC ID token instead of the access token")
+	cmd.Flags().StringVar(&flags.TokenDuration, "token-duration", "", common.TokenDurationHelp())
+	cmd.Flags().String("token-file", "", "File the token is written to [required]")
+	cmd.Flags().String("interval", autoInterval, "How often to rewrite the token: auto (at the provider's refresh threshold) or a duration such as 10m")
+	cmd.Flags().Int("max-failures", tokenfile.DefaultMaxFailures, "Exit non-zero after this many refreshes in a row fail")
+	cmd.Flags().String("health-address", "", "Address to serve /healthz, /livez and /readyz on (default: no health server)")
+	common.AddMetricsFlags(cmd)
+	cmd.Flags().BoolVar(&flags.WatchCredentials, "watch-credentials", true, "Reload the GCP, AWS or Azure credentials file when it changes on disk, e.g. when Vault Agent rotates it")
+	common.AddThrottleFlags(cmd)
+	common.AddTokenCacheFlags(cmd)
+	common.AddPreferSecretFlag(cmd, flags)
+
+	common.SetFlagProviders(cmd, []string{"gcp", "aws", "azure", "oci"}, "region")
+	common.SetFlagProviders(cmd, []string{"gcp"}, "project-id", "gcp-use-adc")
+	common.SetFlagProviders(cmd, []string{"aws"}, "account-id", "profile", "sts-endpoint", "aws-fallback")
+	common.SetFlagProviders(cmd, []string{"azure"}, "subscription-id", "tenant-id", "azure-cloud")
+	common.SetFlagProviders(cmd, []string{"oci"}, "tenancy-id", "user-id", "compartment-id")
+	common.SetFlagProviders(cmd, []string{"oidc"}, "issuer-url", "client-id", "private-key-file", "scopes", "subject-token-file", "subject-token-type", "use-id-token")
+	common.SetProviderHelp(cmd, refreshExamples)
+
+	return cmd
+}
+
+func runRefresh(flags *common.Flags) error {
+	// Bind Viper values to flags (environment variables take precedence if flags not set)
+	common.BindFlagsToViper(flags)
+
+	if err := common.ValidateInputs(flags, 0); err != nil {
+		return err
+	}
+	if err := provider.CheckRegistered(flags.ProviderName); err != nil {
+		return err
+	}
+	if err := common.CheckClusterAllowed(flags); err != nil {
+		return err
+	}
+	if err := common.ApplyCredentialsMap(flags); err != nil {
+		return err
+	}
+
+	tokenFile := flags.Viper.GetString("token-file")
+	if tokenFile == "" {
+		return errors.New(errors.ErrInvalidArgument, "--token-file is required (or set HFCP_TOKEN_FILE)")
+	}
+	interval, err := parseInterval(flags.Viper.GetString("interval"))
+	if err != nil {
+		return err
+	}
+	maxFailures := flags.Viper.GetInt("max-failures")
+	if maxFailures < 1 {
+		return errors.New(errors.ErrInvalidArgument, fmt.Sprintf("--max-failures must be at least 1, got %d", maxFailures))
+	}
+	throttleConfig, err := common.NewThrottleConfig(flags)
+	if err != nil {
+		return err
+	}
+	cacheDir, err := common.TokenCacheDir(flags)
+	if err != nil {
+		return err
+	}
+
+	opts := provider.GetTokenOptions{
+		ClusterName:    flags.ClusterName,
+		Region:         flags.Region,
+		ProjectID:      flags.ProjectID,
+		AccountID:      flags.AccountID,
+		SubscriptionID: flags.SubscriptionID,
+		TenantID:       flags.TenantID,
+		CompartmentID:  flags.CompartmentID,
+	}
+	// Catch malformed cluster names and identifiers before loading credentials
+	if err := opts.Validate(flags.ProviderName); err != nil {
+		return err
+	}
+
+	ctx, cancel := common.SetupSignalHandler()
+	defer cancel()
+
+	log, err := common.CreateLogger(flags)
+	if err != nil {
+		return fmt.Errorf("failed to create logger: %w", err)
+	}
+	defer log.Sync()
+
+	if err := common.CheckTokenDuration(flags, log); err != nil {
+		return err
+	}
+	tokenDuration, err := common.ParseTokenDuration(flags)
+	if err != nil {
+		return err
+	}
+
+	healthAddress := flags.Viper.GetString("health-address")
+	metricsConfig, metricsEnabled, err := common.MetricsConfig(flags.Viper)
+	if err != nil {
+		return err
+	}
+	if flags.DryRun {
+		return common.RunDryRun(ctx, flags, log, os.Stdout, "keep a token file refreshed", map[string]string{
+			"token-file":     tokenFile,
+			"interval":       flags.Viper.GetString("interval"),
+			"health-address": healthAddress,
+		})
+	}
+
+	// Metrics are only collected when they can be served
+	var registry *prometheus.Registry
+	if healthAddress != "" && metricsEnabled {
+		registry = prometheus.NewRegistry()
+		metricsConfig.Registry = registry
+		flags.Metrics = metrics.NewMetrics(metricsConfig)
+	}
+
+	var prov provider.Provider
+	prov, err = provider.New(flags.ProviderName, common.NewProviderConfig(flags, tokenDuration), log)
+	if err != nil {
+		log.Error("Failed to create provider", logger.String("error", err.Error()))
+		return err
+	}
+	defer common.CloseProvider(prov, log)
+
+	if throttleConfig.Enabled() {
+		if flags.Metrics != nil {
+			throttleConfig.Observer = flags.Metrics
+		}
+		prov = throttle.Wrap(prov, throttleConfig)
+	}
+
+	// Tokens are kept in memory unless --token-cache keeps them for the next run
+	var store provider.TokenStore = provider.NewMemoryTokenStore()
+	observer := provider.RequestObservers{flags.Metrics}
+	if cacheDir != "" {
+		if store, err = common.NewTokenStore(cacheDir); err != nil {
+			return err
+		}
+		observer = append(observer, common.NewTokenStatsRecorder(cacheDir, log))
+	}
+	tokens := provider.NewStoreRefresher(flags.ProviderName, &dueRefresher{prov: prov}, store, log).
+		WithScope(common.TokenCacheScope(flags)).
+		WithRequestObserver(observer)
+
+	refresher := tokenfile.New(tokenfile.Config{
+		Path:        tokenFile,
+		Interval:    interval,
+		Threshold:   provider.RefreshThresholdOf(flags.ProviderName),
+		MaxFailures: maxFailures,
+		Logger:      log,
+	}, func(ctx context.Context) (*provider.Token, error) {
+		tokenCtx, tokenCancel := common.WithTimeout(ctx, flags)
+		defer tokenCancel()
+
+		start := time.Now()
+		token, err := tokens.RefreshToken(tokenCtx, opts)
+		return token, common.TimeoutError(tokenCtx, err, "get token", start)
+	})
+
+	if healthAddress != "" {
+		config := health.DefaultConfig()
+		config.HealthAddress = healthAddress
+		config.MetricsDisabled = !metricsEnabled
+		if registry != nil {
+			config.MetricsGatherer = registry
+		}
+		config.Logger = log
+
+		server := health.NewServer(config)
+		server.RegisterCheck(tokenFileCheckName, refresher.Check)
+		if err := server.Start(); err != nil {
+			return err
+		}
+		defer func() {
+			shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), healthShutdownTimeout)
+			defer shutdownCancel()
+			server.Stop(shutdownCtx)
+		}()
+	}
+
+	log.Info("Refreshing token file until terminated",
+		logger.String("provider", prov.Name()),
+		logger.String("cluster", flags.ClusterName),
+		logger.String("file", tokenFile),
+	)
+	return refresher.Run(ctx)
+}
+
+// dueRefresher refreshes the tokens of the refresh loop. Only the first token may come
+// from the store, e.g. after a restart; the loop asks again when a new token is due.
+type dueRefresher struct {
+	prov    provider.Provider
+	started bool
+}
+
+// RefreshToken implements provider.TokenRefresher
+func (r *dueRefresher) RefreshToken(ctx context.Context, opts provider.GetTokenOptions, currentToken *provider.Token) (*provider.Token, error) {
+	if !r.started {
+		r.started = true
+		return r.prov.RefreshToken(ctx, opts, currentToken)
+	}
+	return r.prov.GetToken(ctx, opts)
+}
+
+// parseInterval parses --interval: auto (zero) or a positive duration
+func parseInterval(value string) (time.Duration, error) {
+	if value == "" || value == autoInterval {
+		return 0, nil
+	}
+	interval, err := time.ParseDuration(value)
+	if err != nil || interval <= 0 {
+		return 0, errors.New(errors.ErrInvalidArgument, fmt.Sprintf("--interval must be auto or a positive duration such as 10m, got %q", value))
+	}
+	return interval, nil
+}
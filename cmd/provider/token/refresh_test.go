@@ -0,0 +1,171 @@
+package token
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/cmd/provider/common"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+)
+
+// runRefreshCommand runs refresh the way main does and returns its error
+func runRefreshCommand(t *testing.T, args ...string) (stdout string, err error) {
+	t.Helper()
+
+	flags := &common.Flags{}
+	root := &cobra.Command{
+		Use:           "hyperfleet-credential-provider",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			flags.Viper = common.NewViper(cmd)
+		},
+	}
+	root.PersistentFlags().StringVar(&flags.LogLevel, "log-level", "info", "")
+	root.PersistentFlags().StringVar(&flags.LogFormat, "log-format", "json", "")
+	root.PersistentFlags().BoolVar(&flags.DryRun, "dry-run", false, "")
+	root.AddCommand(NewRefreshCommand(flags))
+	root.SetArgs(append([]string{"refresh"}, args...))
+
+	stopStdout := captureFile(t, &os.Stdout)
+	stopStderr := captureFile(t, &os.Stderr)
+	err = root.Execute()
+	stopStderr()
+	stdout = stopStdout()
+
+	return stdout, err
+}
+
+func TestParseInterval(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected time.Duration
+		wantErr  bool
+	}{
+		{value: "", expected: 0},
+		{value: "auto", expected: 0},
+		{value: "10m", expected: 10 * time.Minute},
+		{value: "0s", wantErr: true},
+		{value: "-1m", wantErr: true},
+		{value: "sometimes", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			interval, err := parseInterval(tt.value)
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), "--interval")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, interval)
+		})
+	}
+}
+
+func TestRefresh_InvalidFlags(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{
+			name:    "missing token file",
+			args:    []string{"--provider=digitalocean", "--cluster-name=c"},
+			wantErr: "--token-file is required (or set HFCP_TOKEN_FILE)",
+		},
+		{
+			name:    "invalid interval",
+			args:    []string{"--provider=digitalocean", "--cluster-name=c", "--token-file=" + tokenFile, "--interval=often"},
+			wantErr: "--interval must be auto or a positive duration",
+		},
+		{
+			name:    "no failures allowed",
+			args:    []string{"--provider=digitalocean", "--cluster-name=c", "--token-file=" + tokenFile, "--max-failures=0"},
+			wantErr: "--max-failures must be at least 1",
+		},
+		{
+			name:    "zero token burst",
+			args:    []string{"--provider=digitalocean", "--cluster-name=c", "--token-file=" + tokenFile, "--token-burst=0"},
+			wantErr: "--token-burst must be at least 1",
+		},
+		{
+			name:    "missing cluster name",
+			args:    []string{"--provider=digitalocean", "--token-file=" + tokenFile},
+			wantErr: "--cluster-name is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := runRefreshCommand(t, tt.args...)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestRefresh_DryRun(t *testing.T) {
+	t.Setenv("DIGITALOCEAN_TOKEN", "dop_v1_test")
+	tokenFile := filepath.Join(t.TempDir(), "token")
+
+	stdout, err := runRefreshCommand(t, "--dry-run", "--provider=digitalocean", "--cluster-name=c", "--token-file="+tokenFile, "--interval=10m")
+
+	require.NoError(t, err)
+	assert.Contains(t, stdout, tokenFile)
+	assert.Contains(t, stdout, "10m")
+	assert.NoFileExists(t, tokenFile)
+}
+
+func TestRefresh_TokenFileFromEnv(t *testing.T) {
+	t.Setenv("DIGITALOCEAN_TOKEN", "dop_v1_test")
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	t.Setenv("HFCP_TOKEN_FILE", tokenFile)
+
+	stdout, err := runRefreshCommand(t, "--dry-run", "--provider=digitalocean", "--cluster-name=c")
+
+	require.NoError(t, err)
+	assert.Contains(t, stdout, tokenFile)
+}
+
+func TestDueRefresher(t *testing.T) {
+	ctx := context.Background()
+	opts := provider.GetTokenOptions{ClusterName: "c"}
+	store := provider.NewMemoryTokenStore()
+	cached := &provider.Token{AccessToken: "cached", ExpiresAt: time.Now().Add(time.Hour), TokenType: "Bearer"}
+	require.NoError(t, store.Put(ctx, provider.Fingerprint("mock", opts), cached))
+
+	generated := 0
+	prov := &provider.MockProvider{
+		GetTokenFunc: func(ctx context.Context, opts provider.GetTokenOptions) (*provider.Token, error) {
+			generated++
+			return &provider.Token{AccessToken: "fresh", ExpiresAt: time.Now().Add(time.Hour), TokenType: "Bearer"}, nil
+		},
+	}
+	tokens := provider.NewStoreRefresher("mock", &dueRefresher{prov: prov}, store, logger.Nop())
+
+	// The first token comes from the store, e.g. after a restart
+	token, outcome, err := tokens.Refresh(ctx, opts)
+	require.NoError(t, err)
+	assert.Equal(t, "cached", token.AccessToken)
+	assert.Equal(t, provider.CacheHit, outcome)
+	assert.Zero(t, generated)
+
+	// Later refreshes are due, so the stored token is replaced even though it is valid
+	token, outcome, err = tokens.Refresh(ctx, opts)
+	require.NoError(t, err)
+	assert.Equal(t, "fresh", token.AccessToken)
+	assert.Equal(t, provider.CacheStaleRefresh, outcome)
+	assert.Equal(t, 1, generated)
+}
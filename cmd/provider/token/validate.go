@@ -0,0 +1,191 @@
+package token
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/cmd/provider/common"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+)
+
+// validation is the structured verdict of validate-token
+type validation struct {
+	Provider    string           `json:"provider"`
+	Valid       bool             `json:"valid"`
+	ExpiresAt   *time.Time       `json:"expiresAt,omitempty"`
+	ExpiresIn   string           `json:"expiresIn,omitempty"`
+	ExpiresSoon bool             `json:"expiresSoon"`
+	Code        errors.ErrorCode `json:"code,omitempty"`
+	Reason      string           `json:"reason,omitempty"`
+}
+
+func NewValidateCommand(flags *common.Flags) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "validate-token",
+		Short: "Check a token with the provider's offline token validation",
+		Long: `Check a Kubernetes bearer token the way the provider checks the tokens it issues,
+without calling the cloud: the token must be set, well-formed for the provider (AWS
+tokens must start with k8s-aws-v1.) and unexpired. A token that expires within the
+provider's refresh threshold plus the --clock-skew tolerance is valid but reported as
+expiring soon.
+
+The token is read from --token, --token-file, or stdin. ExecCredential JSON (the
+output of get-token) is accepted and its expirationTimestamp is used as the expiry.
+Otherwise the expiry is decoded from the token: the presigned URL of AWS tokens and
+the exp claim of JWTs. Opaque tokens, such as GCP access tokens, must be passed as
+ExecCredential JSON.
+
+The verdict is printed on stdout, and the command exits non-zero when the token is
+invalid. Signatures are not verified; a valid verdict does not mean the API server
+will accept the token.`,
+		Example: `  # Check the token get-token would send
+  hyperfleet-credential-provider get-token --provider=aws --cluster-name=my-cluster --region=us-east-1 | \
+    hyperfleet-credential-provider validate-token --provider=aws
+
+  # JSON verdict for a token someone handed you
+  hyperfleet-credential-provider validate-token --provider=azure --token-file=token.txt --output=json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runValidate(flags, cmd.InOrStdin(), cmd.OutOrStdout())
+		},
+	}
+
+	cmd.Flags().StringVar(&flags.ProviderName, "provider", "", "Cloud provider (gcp, aws, azure, oci, digitalocean, oidc); detected from the token if not set")
+	cmd.Flags().String("token", "", "Token to validate")
+	cmd.Flags().String("token-file", "", "File containing the token to validate (- for stdin)")
+	cmd.Flags().String("output", "text", "Output format (text, json)")
+	cmd.Flags().StringVar(&flags.OIDCIssuerURL, "issuer-url", "", "OIDC issuer URL (required for OIDC)")
+	cmd.Flags().StringVar(&flags.OIDCClientID, "client-id", "", "OIDC client ID (required for OIDC)")
+	common.SetFlagProviders(cmd, []string{"oidc"}, "issuer-url", "client-id")
+
+	return cmd
+}
+
+func runValidate(flags *common.Flags, stdin io.Reader, stdout io.Writer) error {
+	// Bind Viper values to flags (environment variables take precedence if flags not set)
+	common.BindFlagsToViper(flags)
+
+	output := flags.Viper.GetString("output")
+	if output != "text" && output != "json" {
+		return errors.New(errors.ErrInvalidArgument, fmt.Sprintf("invalid --output %q (must be one of: text, json)", output))
+	}
+
+	token, err := readTokenInput(flags.Viper.GetString("token"), flags.Viper.GetString("token-file"), stdin)
+	if err != nil {
+		return err
+	}
+
+	if flags.ProviderName == "" {
+		if flags.ProviderName, err = detectProvider(token.AccessToken); err != nil {
+			return err
+		}
+	}
+
+	log, err := common.CreateLogger(flags)
+	if err != nil {
+		return fmt.Errorf("failed to create logger: %w", err)
+	}
+	defer log.Sync()
+
+	prov, err := common.CreateProvider(flags, log)
+	if err != nil {
+		return err
+	}
+
+	result, validateErr := validate(prov, token, time.Now())
+	if result == nil {
+		return validateErr
+	}
+
+	if output == "json" {
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(result); err != nil {
+			return err
+		}
+	} else {
+		writeValidationText(stdout, result)
+	}
+	return validateErr
+}
+
+// validate checks token with the ValidateToken of prov, decoding its expiry first when
+// the input did not carry one. It returns the verdict and, for an invalid token, the
+// validation error. A token whose expiry cannot be known returns no verdict.
+func validate(prov provider.Provider, token *provider.Token, now time.Time) (*validation, error) {
+	result := &validation{Provider: prov.Name()}
+
+	if token.ExpiresAt.IsZero() {
+		expiresAt, err := decodeExpiry(token.AccessToken, prov.Name(), now)
+		if err != nil {
+			err = errors.Wrap(errors.ErrTokenMalformed, err, "failed to decode the token").
+				WithField("provider", prov.Name())
+			result.Code, result.Reason = errors.GetCode(err), err.Error()
+			return result, err
+		}
+		if expiresAt == nil {
+			return nil, errors.New(
+				errors.ErrInvalidArgument,
+				"the expiry of the token is unknown",
+			).WithField("provider", prov.Name()).
+				WithDetail("opaque tokens carry no expiry; pass the ExecCredential printed by get-token, whose expirationTimestamp holds it")
+		}
+		token.ExpiresAt = *expiresAt
+	}
+
+	expiresAt := token.ExpiresAt.UTC()
+	result.ExpiresAt = &expiresAt
+	result.ExpiresIn = expiresAt.Sub(now).Truncate(time.Second).String()
+
+	if err := prov.ValidateToken(token); err != nil {
+		result.Code, result.Reason = errors.GetCode(err), err.Error()
+		return result, err
+	}
+
+	result.Valid = true
+	result.ExpiresSoon = expiresAt.Sub(now) < provider.RefreshThresholdOf(prov.Name())+provider.ClockSkew()
+	return result, nil
+}
+
+// decodeExpiry returns the expiry encoded in token, or nil when the token is opaque
+func decodeExpiry(token, providerName string, now time.Time) (*time.Time, error) {
+	switch providerName {
+	case "gcp", "aws", "azure":
+		result, err := inspect(token, providerName, false, now)
+		if err != nil {
+			return nil, err
+		}
+		return result.ExpiresAt, nil
+	}
+
+	if !provider.IsJWT(token) {
+		return nil, nil
+	}
+	jwt, err := provider.DecodeJWT(token)
+	if err != nil {
+		return nil, err
+	}
+	return jwt.TimeClaim("exp"), nil
+}
+
+// writeValidationText prints the verdict as "key: value" lines
+func writeValidationText(w io.Writer, result *validation) {
+	fmt.Fprintf(w, "provider: %s\n", result.Provider)
+	fmt.Fprintf(w, "valid: %t\n", result.Valid)
+	if result.ExpiresAt != nil {
+		fmt.Fprintf(w, "expiresAt: %s\n", result.ExpiresAt.Format(time.RFC3339))
+		fmt.Fprintf(w, "expiresIn: %s\n", result.ExpiresIn)
+	}
+	if result.ExpiresSoon {
+		fmt.Fprintf(w, "warning: the token expires within the %s refresh threshold and %s clock skew tolerance\n",
+			provider.RefreshThresholdOf(result.Provider), provider.ClockSkew())
+	}
+	if result.Code != "" {
+		fmt.Fprintf(w, "code: %s\n", result.Code)
+		fmt.Fprintf(w, "reason: %s\n", result.Reason)
+	}
+}
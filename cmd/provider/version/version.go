@@ -1,9 +1,17 @@
 package version
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"runtime"
+	"strconv"
 
 	"github.com/spf13/cobra"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
 )
 
 var (
@@ -12,19 +20,74 @@ var (
 	BuildTime = "unknown"
 )
 
+// Info is the version information printed by version --output=json
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildTime string `json:"buildTime"`
+	GoVersion string `json:"goVersion"`
+
+	// Platform is the GOOS/GOARCH the binary was built for, e.g. linux/amd64
+	Platform string `json:"platform"`
+}
+
+// semverPattern matches the major and minor components of versions such as v1.2.3 or 1.2.0-rc.1
+var semverPattern = regexp.MustCompile(`^v?(\d+)\.(\d+)(\.\d+)?([-+].*)?$`)
+
 func NewCommand() *cobra.Command {
-	return &cobra.Command{
+	var output string
+
+	cmd := &cobra.Command{
 		Use:   "version",
 		Short: "Print version information",
 		Long:  "Print detailed version information including build metadata",
-		Run:   runVersion,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runVersion(os.Stdout, output)
+		},
 	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", "text", "Output format (text, json)")
+
+	return cmd
 }
 
-func runVersion(cmd *cobra.Command, args []string) {
-	fmt.Printf("HyperFleet Credential Provider\n")
-	fmt.Printf("  Version:    %s\n", Version)
-	fmt.Printf("  Commit:     %s\n", Commit)
-	fmt.Printf("  Build Time: %s\n", BuildTime)
-	fmt.Printf("  Go Version: %s\n", "go1.24+")
+// Current returns the version information of the running binary
+func Current() Info {
+	return Info{
+		Version:   Version,
+		Commit:    Commit,
+		BuildTime: BuildTime,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+}
+
+// MajorMinor returns the major and minor components of a semantic version.
+// ok is false for versions that are not semantic, such as dev builds or commit SHAs.
+func MajorMinor(v string) (major, minor int, ok bool) {
+	match := semverPattern.FindStringSubmatch(v)
+	if match == nil {
+		return 0, 0, false
+	}
+	major, _ = strconv.Atoi(match[1])
+	minor, _ = strconv.Atoi(match[2])
+	return major, minor, true
+}
+
+func runVersion(w io.Writer, output string) error {
+	switch output {
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(Current())
+	case "text", "":
+		fmt.Fprintf(w, "HyperFleet Credential Provider\n")
+		fmt.Fprintf(w, "  Version:    %s\n", Version)
+		fmt.Fprintf(w, "  Commit:     %s\n", Commit)
+		fmt.Fprintf(w, "  Build Time: %s\n", BuildTime)
+		fmt.Fprintf(w, "  Go Version: %s\n", "go1.24+")
+		return nil
+	default:
+		return errors.New(errors.ErrInvalidArgument, fmt.Sprintf("unsupported output format %q (must be one of: text, json)", output))
+	}
 }
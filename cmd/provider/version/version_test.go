@@ -0,0 +1,71 @@
+package version
+
+import (
+	"bytes"
+	"encoding/json"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunVersion_JSON(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, runVersion(&out, "json"))
+
+	var info Info
+	require.NoError(t, json.Unmarshal(out.Bytes(), &info))
+	assert.Equal(t, Version, info.Version)
+	assert.Equal(t, Commit, info.Commit)
+	assert.Equal(t, BuildTime, info.BuildTime)
+	assert.Equal(t, runtime.Version(), info.GoVersion)
+	assert.Equal(t, runtime.GOOS+"/"+runtime.GOARCH, info.Platform)
+
+	// Every field is populated, also in dev builds
+	var fields map[string]string
+	require.NoError(t, json.Unmarshal(out.Bytes(), &fields))
+	for _, key := range []string{"version", "commit", "buildTime", "goVersion", "platform"} {
+		assert.NotEmpty(t, fields[key], key)
+	}
+
+	assert.Error(t, runVersion(&out, "yaml"))
+}
+
+func TestRunVersion_Text(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, runVersion(&out, "text"))
+
+	expected := "HyperFleet Credential Provider\n" +
+		"  Version:    " + Version + "\n" +
+		"  Commit:     " + Commit + "\n" +
+		"  Build Time: " + BuildTime + "\n" +
+		"  Go Version: go1.24+\n"
+	assert.Equal(t, expected, out.String())
+}
+
+func TestMajorMinor(t *testing.T) {
+	tests := []struct {
+		version   string
+		wantMajor int
+		wantMinor int
+		wantOK    bool
+	}{
+		{version: "v1.2.3", wantMajor: 1, wantMinor: 2, wantOK: true},
+		{version: "0.3.0", wantMajor: 0, wantMinor: 3, wantOK: true},
+		{version: "v2.10", wantMajor: 2, wantMinor: 10, wantOK: true},
+		{version: "v1.4.0-rc.1", wantMajor: 1, wantMinor: 4, wantOK: true},
+		{version: "dev"},
+		{version: "5773910"},
+		{version: "5773910-dirty"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			major, minor, ok := MajorMinor(tt.version)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.wantMajor, major)
+			assert.Equal(t, tt.wantMinor, minor)
+		})
+	}
+}
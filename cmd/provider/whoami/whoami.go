@@ -0,0 +1,162 @@
+package whoami
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/cmd/provider/common"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+)
+
+// examples are the per-provider whoami examples shown in help
+var examples = map[string]string{
+	"gcp": `  # GCP: the service account of the credentials file or application default credentials
+  hyperfleet-credential-provider whoami --provider=gcp`,
+	"aws": `  # AWS: the account, ARN and user ID reported by STS
+  hyperfleet-credential-provider whoami --provider=aws --profile=deployer`,
+	"azure": `  # Azure: the service principal's application, object and tenant IDs
+  hyperfleet-credential-provider whoami --provider=azure --tenant-id=xxx --subscription-id=xxx`,
+}
+
+// identityOutput is the JSON printed by whoami. Fields a provider does not report are omitted.
+type identityOutput struct {
+	Provider string `json:"provider"`
+	Type     string `json:"type,omitempty"`
+	Account  string `json:"account,omitempty"`
+	ARN      string `json:"arn,omitempty"`
+	UserID   string `json:"userId,omitempty"`
+	Email    string `json:"email,omitempty"`
+	AppID    string `json:"appId,omitempty"`
+	ObjectID string `json:"objectId,omitempty"`
+	TenantID string `json:"tenantId,omitempty"`
+	Source   string `json:"source"`
+}
+
+// NewCommand creates the whoami command
+func NewCommand(flags *common.Flags) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "whoami",
+		Short: "Show the cloud identity the credentials authenticate as",
+		Long: `Show the identity the provider's credentials authenticate as, to confirm which
+account, service account or service principal tokens will be issued for.
+
+AWS calls STS GetCallerIdentity. GCP reads the service account from the credentials
+file, or introspects an access token when application default credentials have no
+key file. Azure requests a token and reads the service principal from its claims.
+OCI and DigitalOcean are not supported.
+
+Pass --provider with --help to list only that provider's flags.`,
+		Example: `  # Output example:
+  {
+    "provider": "aws",
+    "type": "assumed-role",
+    "account": "123456789012",
+    "arn": "arn:aws:sts::123456789012:assumed-role/deployer/session",
+    "userId": "AROAEXAMPLE:session",
+    "source": "sts:GetCallerIdentity"
+  }`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return run(flags, cmd.OutOrStdout())
+		},
+	}
+
+	cmd.Flags().StringVar(&flags.ProviderName, "provider", "", "Cloud provider (gcp, aws, azure) [required]")
+	cmd.Flags().StringVar(&flags.Region, "region", "", "AWS region for the STS call (default: us-east-1)")
+	cmd.Flags().StringVar(&flags.ProjectID, "project-id", "", "GCP project ID (selects the key in --credentials-dir)")
+	cmd.Flags().StringVar(&flags.GCPUseADC, "gcp-use-adc", "auto", "Use GCP application default credentials: auto (when no credentials file is set), true, or false")
+	cmd.Flags().StringVar(&flags.AWSProfile, "profile", "", "AWS shared config profile (default: AWS_PROFILE, then default)")
+	cmd.Flags().StringVar(&flags.STSEndpoint, "sts-endpoint", "", "AWS STS endpoint URL to call, e.g. an interface VPC endpoint (default: the regional endpoint)")
+	cmd.Flags().StringVar(&flags.SubscriptionID, "subscription-id", "", "Azure subscription ID (optional)")
+	cmd.Flags().StringVar(&flags.AzureCloud, "azure-cloud", "", "Azure cloud (public, usgovernment, china; default: public)")
+	cmd.Flags().StringVar(&flags.TenantID, "tenant-id", "", "Azure tenant ID (default: from the credentials)")
+	common.AddPreferSecretFlag(cmd, flags)
+
+	common.SetFlagProviders(cmd, []string{"aws"}, "region", "profile", "sts-endpoint")
+	common.SetFlagProviders(cmd, []string{"gcp"}, "project-id", "gcp-use-adc")
+	common.SetFlagProviders(cmd, []string{"azure"}, "subscription-id", "tenant-id", "azure-cloud")
+	common.SetProviderHelp(cmd, examples)
+
+	return cmd
+}
+
+func run(flags *common.Flags, w io.Writer) error {
+	// Bind Viper values to flags (environment variables take precedence if flags not set)
+	common.BindFlagsToViper(flags)
+
+	if err := common.ValidateProviderInputs(flags, 0); err != nil {
+		return err
+	}
+	if err := provider.CheckRegistered(flags.ProviderName); err != nil {
+		return err
+	}
+
+	log, err := common.CreateLogger(flags)
+	if err != nil {
+		return fmt.Errorf("failed to create logger: %w", err)
+	}
+	defer log.Sync()
+
+	ctx := context.Background()
+
+	if flags.DryRun {
+		return common.RunDryRun(ctx, flags, log, w, "report the authenticated identity", nil)
+	}
+
+	prov, err := common.CreateProvider(flags, log)
+	if err != nil {
+		return fmt.Errorf("failed to create %s provider: %w", flags.ProviderName, err)
+	}
+	reporter, ok := prov.(provider.IdentityReporter)
+	if !ok {
+		return fmt.Errorf("whoami is not supported for provider %s", flags.ProviderName)
+	}
+
+	ctx, cancel := common.WithTimeout(ctx, flags)
+	defer cancel()
+
+	start := time.Now()
+	identity, err := reporter.WhoAmI(ctx)
+	if err != nil {
+		return common.TimeoutError(ctx, err, "get identity", start)
+	}
+
+	log.Debug("Authenticated identity retrieved",
+		logger.String("provider", flags.ProviderName),
+		logger.String("type", identity.Type),
+		logger.String("source", identity.Source),
+	)
+
+	return writeIdentity(w, newIdentityOutput(flags.ProviderName, identity))
+}
+
+// newIdentityOutput shapes a provider identity for output
+func newIdentityOutput(providerName string, identity *provider.Identity) identityOutput {
+	return identityOutput{
+		Provider: providerName,
+		Type:     identity.Type,
+		Account:  identity.Account,
+		ARN:      identity.ARN,
+		UserID:   identity.UserID,
+		Email:    identity.Email,
+		AppID:    identity.AppID,
+		ObjectID: identity.ObjectID,
+		TenantID: identity.TenantID,
+		Source:   identity.Source,
+	}
+}
+
+// writeIdentity writes the identity as indented JSON
+func writeIdentity(w io.Writer, out identityOutput) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(out); err != nil {
+		return fmt.Errorf("failed to encode output: %w", err)
+	}
+	return nil
+}
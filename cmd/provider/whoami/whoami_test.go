@@ -0,0 +1,142 @@
+package whoami
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/cmd/provider/common"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+	_ "github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider/aws"
+	_ "github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider/azure"
+	_ "github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider/digitalocean"
+	_ "github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider/gcp"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+)
+
+func TestWriteIdentity(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider string
+		identity provider.Identity
+		expected string
+	}{
+		{
+			name:     "aws",
+			provider: "aws",
+			identity: provider.Identity{
+				Type:    "assumed-role",
+				Account: "123456789012",
+				ARN:     "arn:aws:sts::123456789012:assumed-role/deployer/session",
+				UserID:  "AROAEXAMPLE:session",
+				Source:  "sts:GetCallerIdentity",
+			},
+			expected: `{
+  "provider": "aws",
+  "type": "assumed-role",
+  "account": "123456789012",
+  "arn": "arn:aws:sts::123456789012:assumed-role/deployer/session",
+  "userId": "AROAEXAMPLE:session",
+  "source": "sts:GetCallerIdentity"
+}
+`,
+		},
+		{
+			name:     "gcp",
+			provider: "gcp",
+			identity: provider.Identity{
+				Type:    "service_account",
+				Account: "my-project",
+				UserID:  "1234",
+				Email:   "sa@my-project.iam.gserviceaccount.com",
+				Source:  "credentials file",
+			},
+			expected: `{
+  "provider": "gcp",
+  "type": "service_account",
+  "account": "my-project",
+  "userId": "1234",
+  "email": "sa@my-project.iam.gserviceaccount.com",
+  "source": "credentials file"
+}
+`,
+		},
+		{
+			name:     "azure",
+			provider: "azure",
+			identity: provider.Identity{
+				Type:     "service_principal",
+				AppID:    "app-1",
+				ObjectID: "oid-1",
+				TenantID: "tenant-1",
+				Source:   "access token claims",
+			},
+			expected: `{
+  "provider": "azure",
+  "type": "service_principal",
+  "appId": "app-1",
+  "objectId": "oid-1",
+  "tenantId": "tenant-1",
+  "source": "access token claims"
+}
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, writeIdentity(&buf, newIdentityOutput(tt.provider, &tt.identity)))
+			assert.Equal(t, tt.expected, buf.String())
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name        string
+		env         map[string]string
+		wantOutput  string
+		wantErr     string
+		wantErrCode errors.ErrorCode
+	}{
+		{
+			name:        "provider required",
+			wantErrCode: errors.ErrInvalidArgument,
+			wantErr:     "--provider is required",
+		},
+		{
+			name:    "unsupported provider",
+			env:     map[string]string{"HFCP_PROVIDER": "digitalocean", "DIGITALOCEAN_TOKEN": "dop_v1_test"},
+			wantErr: "whoami is not supported for provider digitalocean",
+		},
+		{
+			name:       "dry run needs no cluster",
+			env:        map[string]string{"HFCP_PROVIDER": "digitalocean", "HFCP_DRY_RUN": "true", "DIGITALOCEAN_TOKEN": "dop_v1_test"},
+			wantOutput: "Dry run: would report the authenticated identity\n  provider: digitalocean\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			var buf bytes.Buffer
+			err := run(&common.Flags{LogLevel: "error", LogFormat: "json"}, &buf)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				if tt.wantErrCode != "" {
+					assert.True(t, errors.Is(err, tt.wantErrCode))
+				}
+				return
+			}
+			require.NoError(t, err)
+			assert.Contains(t, buf.String(), tt.wantOutput)
+		})
+	}
+}
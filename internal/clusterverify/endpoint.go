@@ -0,0 +1,245 @@
+package clusterverify
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	xproxy "golang.org/x/net/proxy"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+)
+
+// EndpointOptions configures an endpoint check
+type EndpointOptions struct {
+	// Endpoint is the API server URL
+	Endpoint string
+
+	// CertificateAuthority is the cluster CA, base64-encoded PEM as in kubeconfig or PEM
+	CertificateAuthority string
+
+	// ServerName verifies the server certificate for this name instead of the
+	// endpoint host, as kubeconfig tls-server-name does
+	ServerName string
+
+	// Timeout bounds the dial and handshake (default: DefaultTimeout)
+	Timeout time.Duration
+
+	// Proxy selects the proxy of the endpoint as http.Transport.Proxy does; nil uses
+	// HTTPS_PROXY and NO_PROXY like Verify. http and https proxies are tunneled
+	// through with CONNECT, and socks5 proxies are supported too.
+	Proxy func(*http.Request) (*url.URL, error)
+}
+
+// EndpointResult describes a server certificate that verified against the cluster CA
+type EndpointResult struct {
+	// Address is the host:port that was dialed
+	Address string
+
+	// ServerName is the name the certificate was verified for
+	ServerName string
+
+	// TLSVersion is the negotiated TLS version, e.g. "TLS 1.3"
+	TLSVersion string
+
+	// Subject is the subject of the server certificate
+	Subject string
+
+	// NotAfter is when the server certificate expires
+	NotAfter time.Time
+}
+
+// VerifyEndpoint completes a TLS handshake with the API server, trusting only the
+// cluster CA, and closes the connection without sending a request. Unlike Verify it
+// needs no token. Like Verify it goes through the proxy of the endpoint, opening a
+// tunnel to the API server. A failed dial or handshake returns ErrClusterUnreachable,
+// and a server certificate that does not verify against the CA or for the server name
+// ErrClusterInvalidConfig.
+func VerifyEndpoint(ctx context.Context, opts EndpointOptions) (*EndpointResult, error) {
+	pool, err := certPool(opts.CertificateAuthority)
+	if err != nil {
+		return nil, err
+	}
+	address, host, err := endpointAddress(opts.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	proxyURL, err := endpointProxy(opts.Proxy, address)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrClusterUnreachable, err, "failed to select the proxy of the cluster API server").
+			WithField("address", address)
+	}
+
+	serverName := host
+	if opts.ServerName != "" {
+		serverName = opts.ServerName
+	}
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	conn, err := dialTLS(ctx, address, proxyURL, &tls.Config{
+		RootCAs:    pool,
+		ServerName: serverName,
+		MinVersion: tls.VersionTLS12,
+	})
+	if err != nil {
+		if IsCertificateError(err) {
+			return nil, errors.Wrap(errors.ErrClusterInvalidConfig, err, "API server certificate does not verify against the cluster CA").
+				WithField("address", address).
+				WithField("server_name", serverName).
+				WithDetail("the cluster CA may be stale or belong to another cluster; look the cluster up again, or set the TLS server name when the certificate is issued for another host")
+		}
+		unreachable := errors.Wrap(errors.ErrClusterUnreachable, err, "failed to complete a TLS handshake with the cluster API server").
+			WithField("address", address)
+		if proxyURL != nil {
+			unreachable = unreachable.WithField("proxy", proxyURL.Redacted())
+		}
+		return nil, unreachable
+	}
+	defer conn.Close()
+
+	state := conn.ConnectionState()
+	result := &EndpointResult{
+		Address:    address,
+		ServerName: serverName,
+		TLSVersion: tls.VersionName(state.Version),
+	}
+	if len(state.PeerCertificates) > 0 {
+		leaf := state.PeerCertificates[0]
+		result.Subject = leaf.Subject.String()
+		result.NotAfter = leaf.NotAfter
+	}
+	return result, nil
+}
+
+// endpointAddress returns the host:port to dial for an https API server URL and its host
+func endpointAddress(endpoint string) (string, string, error) {
+	parsed, err := url.Parse(endpoint)
+	if err != nil || parsed.Host == "" {
+		return "", "", errors.New(errors.ErrClusterInvalidConfig, "invalid cluster endpoint").
+			WithField("endpoint", endpoint)
+	}
+	if !strings.EqualFold(parsed.Scheme, "https") {
+		return "", "", errors.New(errors.ErrClusterInvalidConfig, "cluster endpoint must use https").
+			WithField("endpoint", endpoint)
+	}
+
+	port := parsed.Port()
+	if port == "" {
+		port = "443"
+	}
+	return net.JoinHostPort(parsed.Hostname(), port), parsed.Hostname(), nil
+}
+
+// endpointProxy returns the proxy of address selected by proxyFunc, or nil to dial it
+// directly
+func endpointProxy(proxyFunc func(*http.Request) (*url.URL, error), address string) (*url.URL, error) {
+	if proxyFunc == nil {
+		proxyFunc = http.ProxyFromEnvironment
+	}
+	return proxyFunc(&http.Request{URL: &url.URL{Scheme: "https", Host: address}})
+}
+
+// dialTLS completes a TLS handshake with address, through a tunnel of proxyURL when it
+// is not nil
+func dialTLS(ctx context.Context, address string, proxyURL *url.URL, config *tls.Config) (*tls.Conn, error) {
+	if proxyURL == nil {
+		conn, err := (&tls.Dialer{Config: config}).DialContext(ctx, "tcp", address)
+		if err != nil {
+			return nil, err
+		}
+		return conn.(*tls.Conn), nil
+	}
+
+	tunnel, err := dialProxy(ctx, address, proxyURL)
+	if err != nil {
+		return nil, err
+	}
+	conn := tls.Client(tunnel, config)
+	if err := conn.HandshakeContext(ctx); err != nil {
+		tunnel.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// dialProxy opens a tunnel to address through proxyURL: a CONNECT request to an http
+// or https proxy, or a socks5 connection
+func dialProxy(ctx context.Context, address string, proxyURL *url.URL) (net.Conn, error) {
+	var dialer net.Dialer
+	if proxyURL.Scheme == "socks5" {
+		socks, err := xproxy.FromURL(proxyURL, &dialer)
+		if err != nil {
+			return nil, err
+		}
+		return socks.(xproxy.ContextDialer).DialContext(ctx, "tcp", address)
+	}
+
+	proxyAddress := proxyURL.Host
+	switch {
+	case proxyURL.Scheme != "http" && proxyURL.Scheme != "https":
+		return nil, fmt.Errorf("unsupported proxy scheme %q", proxyURL.Scheme)
+	case proxyURL.Port() == "" && proxyURL.Scheme == "https":
+		proxyAddress = net.JoinHostPort(proxyURL.Hostname(), "443")
+	case proxyURL.Port() == "":
+		proxyAddress = net.JoinHostPort(proxyURL.Hostname(), "80")
+	}
+
+	conn, err := dialer.DialContext(ctx, "tcp", proxyAddress)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to proxy %s: %w", proxyURL.Redacted(), err)
+	}
+	if proxyURL.Scheme == "https" {
+		proxyConn := tls.Client(conn, &tls.Config{ServerName: proxyURL.Hostname(), MinVersion: tls.VersionTLS12})
+		if err := proxyConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to connect to proxy %s: %w", proxyURL.Redacted(), err)
+		}
+		conn = proxyConn
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+		defer conn.SetDeadline(time.Time{})
+	}
+
+	connect := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: address},
+		Host:   address,
+		Header: make(http.Header),
+	}
+	if proxyURL.User != nil {
+		password, _ := proxyURL.User.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(proxyURL.User.Username() + ":" + password))
+		connect.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+	if err := connect.Write(conn); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to send CONNECT to proxy %s: %w", proxyURL.Redacted(), err)
+	}
+
+	// The API server sends nothing before the client hello, so the reader buffers
+	// only the proxy response
+	resp, err := http.ReadResponse(bufio.NewReader(conn), connect)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to read the CONNECT response of proxy %s: %w", proxyURL.Redacted(), err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("proxy %s refused to CONNECT to %s: %s", proxyURL.Redacted(), address, resp.Status)
+	}
+	return conn, nil
+}
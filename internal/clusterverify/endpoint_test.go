@@ -0,0 +1,190 @@
+package clusterverify
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+)
+
+func TestVerifyEndpoint(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+	}))
+	defer server.Close()
+	address := strings.TrimPrefix(server.URL, "https://")
+
+	tests := []struct {
+		name       string
+		endpoint   string
+		ca         string
+		serverName string
+		wantCode   errors.ErrorCode
+	}{
+		{
+			name:     "certificate verifies against the cluster CA",
+			endpoint: server.URL,
+			ca:       serverCA(server),
+		},
+		{
+			name:       "certificate verifies for the server name",
+			endpoint:   server.URL,
+			ca:         serverCA(server),
+			serverName: "example.com",
+		},
+		{
+			name:     "CA of another cluster",
+			endpoint: server.URL,
+			ca:       otherCA(t),
+			wantCode: errors.ErrClusterInvalidConfig,
+		},
+		{
+			name:       "certificate not issued for the server name",
+			endpoint:   server.URL,
+			ca:         serverCA(server),
+			serverName: "api.other-cluster.example",
+			wantCode:   errors.ErrClusterInvalidConfig,
+		},
+		{
+			name:     "http endpoint",
+			endpoint: "http://" + address,
+			ca:       serverCA(server),
+			wantCode: errors.ErrClusterInvalidConfig,
+		},
+		{
+			name:     "invalid CA",
+			endpoint: server.URL,
+			ca:       "not base64!",
+			wantCode: errors.ErrClusterInvalidConfig,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := VerifyEndpoint(context.Background(), EndpointOptions{
+				Endpoint:             tt.endpoint,
+				CertificateAuthority: tt.ca,
+				ServerName:           tt.serverName,
+			})
+
+			if tt.wantCode != "" {
+				require.Error(t, err)
+				assert.True(t, errors.Is(err, tt.wantCode), "got %v", err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, address, result.Address)
+			assert.NotEmpty(t, result.TLSVersion)
+			assert.Equal(t, server.Certificate().NotAfter, result.NotAfter)
+		})
+	}
+
+	assert.Zero(t, requests.Load(), "no request is sent to the API server")
+}
+
+func TestVerifyEndpoint_Unreachable(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	ca := serverCA(server)
+	server.Close()
+
+	_, err := VerifyEndpoint(context.Background(), EndpointOptions{Endpoint: server.URL, CertificateAuthority: ca})
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, errors.ErrClusterUnreachable), "got %v", err)
+
+	// A plain TCP server fails the handshake without a certificate to blame
+	plain := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer plain.Close()
+	_, err = VerifyEndpoint(context.Background(), EndpointOptions{
+		Endpoint:             strings.Replace(plain.URL, "http://", "https://", 1),
+		CertificateAuthority: ca,
+	})
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, errors.ErrClusterUnreachable), "got %v", err)
+}
+
+func TestVerifyEndpoint_Proxy(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	address := strings.TrimPrefix(server.URL, "https://")
+
+	// The CONNECT target and Proxy-Authorization header the proxy received
+	connects := make(chan [2]string, 1)
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodConnect {
+			http.Error(w, "CONNECT only", http.StatusMethodNotAllowed)
+			return
+		}
+		connects <- [2]string{r.Host, r.Header.Get("Proxy-Authorization")}
+
+		target, err := net.Dial("tcp", r.Host)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		defer target.Close()
+		client, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			return
+		}
+		defer client.Close()
+		io.WriteString(client, "HTTP/1.1 200 Connection established\r\n\r\n")
+		go io.Copy(target, client)
+		io.Copy(client, target)
+	}))
+	defer proxy.Close()
+	proxyURL, err := url.Parse(proxy.URL)
+	require.NoError(t, err)
+	proxyURL.User = url.UserPassword("user", "secret")
+
+	result, err := VerifyEndpoint(context.Background(), EndpointOptions{
+		Endpoint:             server.URL,
+		CertificateAuthority: serverCA(server),
+		Proxy:                http.ProxyURL(proxyURL),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, address, result.Address)
+	assert.Equal(t, [2]string{address, "Basic dXNlcjpzZWNyZXQ="}, <-connects, "the handshake is tunneled through the proxy")
+
+	// A proxy that refuses the tunnel leaves the server unreachable
+	refusing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "denied", http.StatusForbidden)
+	}))
+	defer refusing.Close()
+	refusingURL, err := url.Parse(refusing.URL)
+	require.NoError(t, err)
+
+	_, err = VerifyEndpoint(context.Background(), EndpointOptions{
+		Endpoint:             server.URL,
+		CertificateAuthority: serverCA(server),
+		Proxy:                http.ProxyURL(refusingURL),
+	})
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, errors.ErrClusterUnreachable), "got %v", err)
+	assert.Contains(t, err.Error(), "403 Forbidden")
+}
+
+func TestEndpointAddress(t *testing.T) {
+	address, host, err := endpointAddress("https://34.68.222.124")
+	require.NoError(t, err)
+	assert.Equal(t, "34.68.222.124:443", address)
+	assert.Equal(t, "34.68.222.124", host)
+
+	address, host, err = endpointAddress("https://[fd00::1]:6443/")
+	require.NoError(t, err)
+	assert.Equal(t, "[fd00::1]:6443", address)
+	assert.Equal(t, "fd00::1", host)
+
+	_, _, err = endpointAddress("34.68.222.124")
+	assert.True(t, errors.Is(err, errors.ErrClusterInvalidConfig))
+}
@@ -0,0 +1,263 @@
+// Package clusterverify checks that a generated token is accepted by the cluster's
+// API server, catching credentials that are valid for the cloud but not mapped to a
+// Kubernetes identity.
+package clusterverify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+)
+
+// DefaultTimeout bounds the verification requests when no timeout is set
+const DefaultTimeout = 10 * time.Second
+
+// maxResponseBody caps how much of an API server response is read
+const maxResponseBody = 1 << 20
+
+const (
+	// selfSubjectReviewPath answers with the identity of the token (Kubernetes 1.28+)
+	selfSubjectReviewPath = "/apis/authentication.k8s.io/v1/selfsubjectreviews"
+
+	// versionPath is used on clusters that do not serve SelfSubjectReview
+	versionPath = "/version"
+)
+
+// selfSubjectReviewBody is the request body of a SelfSubjectReview
+const selfSubjectReviewBody = `{"apiVersion":"authentication.k8s.io/v1","kind":"SelfSubjectReview"}`
+
+// unauthenticatedHints explain the usual reason a cloud token is rejected, by provider
+var unauthenticatedHints = map[string]string{
+	"aws":          "check the aws-auth ConfigMap mapping or EKS access entry for this IAM principal, and that the token's cluster ID matches the cluster",
+	"gcp":          "check that the identity has a GKE role such as roles/container.clusterViewer and that --audience is not set for a cluster that expects access tokens",
+	"azure":        "check that the service principal or its Azure AD groups are synced to the cluster's Azure AD integration and that the token audience is the AKS server application",
+	"oci":          "check the IAM policy that grants this user access to the OKE cluster",
+	"digitalocean": "check that the API token belongs to the team that owns the cluster",
+	"oidc":         "check that the cluster trusts the issuer, that the token audience matches the cluster's client ID and that the username claim is mapped",
+}
+
+// Options configures a verification
+type Options struct {
+	// Provider selects the hint reported when the token is rejected
+	Provider string
+
+	// Endpoint is the API server URL
+	Endpoint string
+
+	// CertificateAuthority is the cluster CA, base64-encoded PEM as in kubeconfig or PEM
+	CertificateAuthority string
+
+	// Token is sent as the bearer token
+	Token string
+
+	// Timeout bounds each request (default: DefaultTimeout)
+	Timeout time.Duration
+}
+
+// Result describes an accepted token
+type Result struct {
+	// URL is the API server URL that accepted the token
+	URL string
+
+	// StatusCode is the HTTP status of the accepted request
+	StatusCode int
+
+	// Username is the Kubernetes user of the token; empty when the cluster does not
+	// serve SelfSubjectReview
+	Username string
+
+	// GitVersion is the API server version, set when /version was used
+	GitVersion string
+}
+
+// NewClient returns an HTTP client that trusts only the given cluster CA. It honors
+// HTTPS_PROXY and NO_PROXY like kubectl does.
+func NewClient(certificateAuthority string, timeout time.Duration) (*http.Client, error) {
+	pool, err := certPool(certificateAuthority)
+	if err != nil {
+		return nil, err
+	}
+
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+
+	return &http.Client{Transport: transport, Timeout: timeout}, nil
+}
+
+// certPool returns a pool holding only the cluster CA
+func certPool(certificateAuthority string) (*x509.CertPool, error) {
+	pemData, err := decodeCA(certificateAuthority)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pemData) {
+		return nil, errors.New(errors.ErrClusterInvalidConfig, "cluster CA certificate is not a PEM certificate")
+	}
+	return pool, nil
+}
+
+// decodeCA returns the PEM of a base64-encoded or PEM cluster CA
+func decodeCA(ca string) ([]byte, error) {
+	if strings.Contains(ca, "-----BEGIN") {
+		return []byte(ca), nil
+	}
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(ca))
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrClusterInvalidConfig, err, "cluster CA certificate is not valid base64")
+	}
+	return data, nil
+}
+
+// IsCertificateError reports whether err is a failure to verify the API server
+// certificate against the cluster CA, which means the CA is stale or wrong rather than
+// the server unreachable
+func IsCertificateError(err error) bool {
+	var verificationErr *tls.CertificateVerificationError
+	var unknownAuthorityErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var invalidErr x509.CertificateInvalidError
+	return stderrors.As(err, &verificationErr) ||
+		stderrors.As(err, &unknownAuthorityErr) ||
+		stderrors.As(err, &hostnameErr) ||
+		stderrors.As(err, &invalidErr)
+}
+
+// Verify sends an authenticated request to the API server: a SelfSubjectReview, or
+// GET /version when the cluster does not serve it. Connection and TLS failures return
+// ErrClusterUnreachable, HTTP 401 ErrUnauthenticated with a provider hint, and HTTP 403
+// ErrPermissionDenied.
+func Verify(ctx context.Context, opts Options) (*Result, error) {
+	client, err := NewClient(opts.CertificateAuthority, opts.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	base := strings.TrimSuffix(opts.Endpoint, "/")
+
+	status, body, err := do(ctx, client, http.MethodPost, base+selfSubjectReviewPath, opts.Token, selfSubjectReviewBody)
+	if err != nil {
+		return nil, err
+	}
+	if status == http.StatusNotFound {
+		// SelfSubjectReview v1 is served from Kubernetes 1.28
+		return verifyVersion(ctx, client, base, opts)
+	}
+	if err := statusError(opts.Provider, base+selfSubjectReviewPath, status, body); err != nil {
+		return nil, err
+	}
+
+	result := &Result{URL: base + selfSubjectReviewPath, StatusCode: status}
+	var review struct {
+		Status struct {
+			UserInfo struct {
+				Username string `json:"username"`
+			} `json:"userInfo"`
+		} `json:"status"`
+	}
+	if json.Unmarshal(body, &review) == nil {
+		result.Username = review.Status.UserInfo.Username
+	}
+	return result, nil
+}
+
+// verifyVersion checks the token with GET /version. An invalid bearer token is
+// rejected there even when anonymous requests are allowed.
+func verifyVersion(ctx context.Context, client *http.Client, base string, opts Options) (*Result, error) {
+	url := base + versionPath
+	status, body, err := do(ctx, client, http.MethodGet, url, opts.Token, "")
+	if err != nil {
+		return nil, err
+	}
+	if err := statusError(opts.Provider, url, status, body); err != nil {
+		return nil, err
+	}
+
+	result := &Result{URL: url, StatusCode: status}
+	var version struct {
+		GitVersion string `json:"gitVersion"`
+	}
+	if json.Unmarshal(body, &version) == nil {
+		result.GitVersion = version.GitVersion
+	}
+	return result, nil
+}
+
+// do sends one request and returns the status and body
+func do(ctx context.Context, client *http.Client, method, url, token, body string) (int, []byte, error) {
+	var reader io.Reader
+	if body != "" {
+		reader = bytes.NewBufferString(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return 0, nil, errors.Wrap(errors.ErrClusterInvalidConfig, err, "invalid cluster endpoint").
+			WithField("url", url)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/json")
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, nil, errors.Wrap(errors.ErrClusterUnreachable, err, "failed to reach the cluster API server").
+			WithField("url", url)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	if err != nil {
+		return 0, nil, errors.Wrap(errors.ErrClusterUnreachable, err, "failed to read the cluster API server response").
+			WithField("url", url)
+	}
+	return resp.StatusCode, data, nil
+}
+
+// statusError maps a non-success HTTP status to an error
+func statusError(provider, url string, status int, body []byte) error {
+	if status >= 200 && status < 300 {
+		return nil
+	}
+
+	fields := map[string]interface{}{
+		"url":         url,
+		"http_status": status,
+	}
+	switch status {
+	case http.StatusUnauthorized:
+		err := errors.New(errors.ErrUnauthenticated, "cluster rejected the token (HTTP 401)").WithFields(fields)
+		if hint, ok := unauthenticatedHints[provider]; ok {
+			err = err.WithDetail(hint)
+		}
+		return err
+	case http.StatusForbidden:
+		detail := "check the RBAC bindings of this identity"
+		var apiStatus struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(body, &apiStatus) == nil && apiStatus.Message != "" {
+			detail = apiStatus.Message
+		}
+		return errors.New(errors.ErrPermissionDenied, "cluster accepted the token but denied the request (HTTP 403)").
+			WithFields(fields).
+			WithDetail(detail)
+	default:
+		return errors.New(errors.ErrClusterUnreachable, fmt.Sprintf("cluster API server returned HTTP %d", status)).
+			WithFields(fields)
+	}
+}
@@ -0,0 +1,222 @@
+package clusterverify
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+)
+
+const testToken = "k8s-aws-v1.test-token"
+
+// serverCA returns the base64-encoded PEM CA of a TLS test server, as in kubeconfig
+func serverCA(server *httptest.Server) string {
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	return base64.StdEncoding.EncodeToString(certPEM)
+}
+
+// otherCA returns a base64-encoded self-signed CA that did not sign the test server
+func otherCA(t *testing.T) string {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "other-cluster-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	return base64.StdEncoding.EncodeToString(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+// newAPIServer answers SelfSubjectReview and /version with the given statuses; a zero
+// status means the path is not served
+func newAPIServer(t *testing.T, reviewStatus, versionStatus int) *httptest.Server {
+	t.Helper()
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+testToken {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case r.URL.Path == selfSubjectReviewPath && r.Method == http.MethodPost && reviewStatus != 0:
+			w.WriteHeader(reviewStatus)
+			w.Write([]byte(`{"kind":"SelfSubjectReview","status":{"userInfo":{"username":"arn:aws:sts::123456789012:assumed-role/deploy/session"}}}`))
+		case r.URL.Path == versionPath && r.Method == http.MethodGet && versionStatus != 0:
+			w.WriteHeader(versionStatus)
+			w.Write([]byte(`{"gitVersion":"v1.27.9","kind":"Status","message":"forbidden: User cannot get path /version"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestVerify(t *testing.T) {
+	tests := []struct {
+		name          string
+		reviewStatus  int
+		versionStatus int
+		token         string
+		provider      string
+		wantURLPath   string
+		wantUsername  string
+		wantVersion   string
+		wantCode      errors.ErrorCode
+		wantDetail    string
+	}{
+		{
+			name:         "SelfSubjectReview names the user",
+			reviewStatus: http.StatusCreated,
+			wantURLPath:  selfSubjectReviewPath,
+			wantUsername: "arn:aws:sts::123456789012:assumed-role/deploy/session",
+		},
+		{
+			name:          "falls back to /version on older clusters",
+			versionStatus: http.StatusOK,
+			wantURLPath:   versionPath,
+			wantVersion:   "v1.27.9",
+		},
+		{
+			name:         "rejected token with the AWS hint",
+			reviewStatus: http.StatusCreated,
+			token:        "stale-token",
+			provider:     "aws",
+			wantCode:     errors.ErrUnauthenticated,
+			wantDetail:   unauthenticatedHints["aws"],
+		},
+		{
+			name:          "rejected token on /version with the Azure hint",
+			versionStatus: http.StatusOK,
+			token:         "stale-token",
+			provider:      "azure",
+			wantCode:      errors.ErrUnauthenticated,
+			wantDetail:    unauthenticatedHints["azure"],
+		},
+		{
+			name:          "forbidden",
+			versionStatus: http.StatusForbidden,
+			wantCode:      errors.ErrPermissionDenied,
+			wantDetail:    "forbidden: User cannot get path /version",
+		},
+		{
+			name:         "server error",
+			reviewStatus: http.StatusServiceUnavailable,
+			wantCode:     errors.ErrClusterUnreachable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newAPIServer(t, tt.reviewStatus, tt.versionStatus)
+			token := tt.token
+			if token == "" {
+				token = testToken
+			}
+
+			result, err := Verify(context.Background(), Options{
+				Provider:             tt.provider,
+				Endpoint:             server.URL + "/",
+				CertificateAuthority: serverCA(server),
+				Token:                token,
+			})
+
+			if tt.wantCode != "" {
+				require.Error(t, err)
+				assert.True(t, errors.Is(err, tt.wantCode), "got %v", err)
+				var appErr *errors.Error
+				require.True(t, errors.As(err, &appErr))
+				assert.NotZero(t, appErr.Fields["http_status"])
+				if tt.wantDetail != "" {
+					assert.Equal(t, tt.wantDetail, appErr.Detail)
+				}
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, server.URL+tt.wantURLPath, result.URL)
+			assert.Equal(t, tt.wantUsername, result.Username)
+			assert.Equal(t, tt.wantVersion, result.GitVersion)
+		})
+	}
+}
+
+func TestVerify_Unreachable(t *testing.T) {
+	t.Run("connection refused", func(t *testing.T) {
+		server := newAPIServer(t, http.StatusCreated, 0)
+		ca := serverCA(server)
+		server.Close()
+
+		_, err := Verify(context.Background(), Options{Endpoint: server.URL, CertificateAuthority: ca, Token: testToken})
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, errors.ErrClusterUnreachable), "got %v", err)
+		assert.False(t, IsCertificateError(err))
+	})
+
+	t.Run("CA does not match the server", func(t *testing.T) {
+		server := newAPIServer(t, http.StatusCreated, 0)
+
+		_, err := Verify(context.Background(), Options{Endpoint: server.URL, CertificateAuthority: otherCA(t), Token: testToken})
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, errors.ErrClusterUnreachable), "got %v", err)
+		assert.Contains(t, err.Error(), "certificate")
+		assert.True(t, IsCertificateError(err), "a CA mismatch is a certificate error")
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(500 * time.Millisecond)
+		}))
+		defer server.Close()
+
+		start := time.Now()
+		_, err := Verify(context.Background(), Options{
+			Endpoint:             server.URL,
+			CertificateAuthority: serverCA(server),
+			Token:                testToken,
+			Timeout:              50 * time.Millisecond,
+		})
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, errors.ErrClusterUnreachable), "got %v", err)
+		assert.Less(t, time.Since(start), 400*time.Millisecond)
+	})
+}
+
+func TestNewClient_CA(t *testing.T) {
+	server := newAPIServer(t, http.StatusCreated, 0)
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+
+	// A PEM CA is accepted as well as the base64 kubeconfig form
+	result, err := Verify(context.Background(), Options{Endpoint: server.URL, CertificateAuthority: string(certPEM), Token: testToken})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, result.StatusCode)
+
+	_, err = NewClient("not base64!", 0)
+	assert.True(t, errors.Is(err, errors.ErrClusterInvalidConfig))
+
+	_, err = NewClient(base64.StdEncoding.EncodeToString([]byte("plain text")), 0)
+	assert.True(t, errors.Is(err, errors.ErrClusterInvalidConfig))
+
+	client, err := NewClient(serverCA(server), 0)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultTimeout, client.Timeout)
+}
@@ -1,7 +1,15 @@
 package config
 
 import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/health"
 )
 
 // Config represents the complete provider configuration
@@ -17,6 +25,42 @@ type Config struct {
 
 	// Metrics configuration
 	Metrics MetricsConfig `yaml:"metrics"`
+
+	// Credentials are named credential sets, one of which can be selected by Profile
+	Credentials map[string]CredentialProfile `yaml:"credentials,omitempty"`
+
+	// Profile names the credential set applied to the provider sections (optional)
+	Profile string `yaml:"profile,omitempty"`
+
+	// Defaults override the built-in token lifetime settings, keyed by provider name
+	Defaults map[string]ProviderDefaults `yaml:"defaults,omitempty"`
+
+	// AllowedClusters are the names or path.Match patterns of the clusters tokens may be
+	// generated for, independent of cloud IAM. Empty allows every cluster.
+	AllowedClusters []string `yaml:"allowed_clusters,omitempty"`
+}
+
+// ProviderDefaults override the built-in token lifetime settings of one provider for
+// every command and cluster. Zero keeps the built-in value.
+type ProviderDefaults struct {
+	// TokenDuration is the token lifetime used when --token-duration is not given
+	TokenDuration time.Duration `yaml:"token_duration"`
+
+	// RefreshThreshold is the remaining lifetime at which tokens are refreshed
+	RefreshThreshold time.Duration `yaml:"refresh_threshold"`
+}
+
+// CredentialProfile is a named set of provider-specific credential references. The
+// sections of the selected profile override the provider sections of the same name.
+type CredentialProfile struct {
+	// GCP credentials
+	GCP *GCPConfig `yaml:"gcp,omitempty"`
+
+	// AWS credentials
+	AWS *AWSConfig `yaml:"aws,omitempty"`
+
+	// Azure credentials
+	Azure *AzureConfig `yaml:"azure,omitempty"`
 }
 
 // LogConfig holds logging configuration
@@ -50,18 +94,25 @@ type ProviderConfig struct {
 
 	// Timeout for provider operations
 	Timeout time.Duration `yaml:"timeout" validate:"min=0"`
+
+	// StrictPermissions rejects credentials files readable by group or others
+	StrictPermissions bool `yaml:"strict_permissions"`
 }
 
 // GCPConfig holds GCP-specific configuration
 type GCPConfig struct {
-	// ProjectID is the GCP project ID
-	ProjectID string `yaml:"project_id" validate:"required"`
+	// ProjectID is the GCP project ID (optional when application default
+	// credentials supply one)
+	ProjectID string `yaml:"project_id"`
 
 	// CredentialsFile is the path to the service account JSON file
 	CredentialsFile string `yaml:"credentials_file"`
 
 	// TokenDuration is the token expiration duration
 	TokenDuration time.Duration `yaml:"token_duration"`
+
+	// UseADC controls the application default credentials fallback (auto, true, false)
+	UseADC string `yaml:"use_adc" validate:"omitempty,oneof=auto true false"`
 }
 
 // AWSConfig holds AWS-specific configuration
@@ -74,6 +125,23 @@ type AWSConfig struct {
 
 	// TokenDuration is the token expiration duration
 	TokenDuration time.Duration `yaml:"token_duration"`
+
+	// Fallback lists the credential sources tried in order when the configured
+	// credentials fail to produce a token (optional)
+	Fallback []AWSFallbackConfig `yaml:"fallback"`
+}
+
+// AWSFallbackConfig is an AWS credential source of the fallback chain. Empty fields keep
+// the provider configuration.
+type AWSFallbackConfig struct {
+	// Profile is the profile of the AWS shared files
+	Profile string `yaml:"profile"`
+
+	// Region is the region of the STS endpoint the token is presigned for
+	Region string `yaml:"region"`
+
+	// RoleARN is the IAM role assumed with the profile credentials
+	RoleARN string `yaml:"role_arn"`
 }
 
 // AzureConfig holds Azure-specific configuration
@@ -87,6 +155,9 @@ type AzureConfig struct {
 	// ResourceGroup is the cluster resource group (optional)
 	ResourceGroup string `yaml:"resource_group"`
 
+	// Cloud is the Azure cloud of the cluster (public, usgovernment, china; default: public)
+	Cloud string `yaml:"cloud" validate:"omitempty,oneof=public usgovernment china"`
+
 	// TokenDuration is the token expiration duration
 	TokenDuration time.Duration `yaml:"token_duration"`
 }
@@ -96,6 +167,9 @@ type HealthConfig struct {
 	// Enabled determines if health server is enabled
 	Enabled bool `yaml:"enabled"`
 
+	// Host is the health server bind host (default: all interfaces)
+	Host string `yaml:"host"`
+
 	// Port is the health server port
 	Port int `yaml:"port" validate:"min=0,max=65535"`
 
@@ -111,7 +185,11 @@ type MetricsConfig struct {
 	// Enabled determines if metrics are enabled
 	Enabled bool `yaml:"enabled"`
 
-	// Port is the metrics server port
+	// Host is the metrics server bind host (default: all interfaces)
+	Host string `yaml:"host"`
+
+	// Port is the metrics server port.
+	// Metrics share the health listener when host and port match.
 	Port int `yaml:"port" validate:"min=0,max=65535"`
 
 	// Path is the metrics endpoint path
@@ -170,58 +248,49 @@ func (c *Config) Merge(other *Config) {
 	if other.Provider.Timeout > 0 {
 		c.Provider.Timeout = other.Provider.Timeout
 	}
+	if other.Provider.StrictPermissions {
+		c.Provider.StrictPermissions = true
+	}
 
 	// Merge provider-specific configs
-	if other.Provider.GCP != nil {
-		if c.Provider.GCP == nil {
-			c.Provider.GCP = &GCPConfig{}
-		}
-		if other.Provider.GCP.ProjectID != "" {
-			c.Provider.GCP.ProjectID = other.Provider.GCP.ProjectID
-		}
-		if other.Provider.GCP.CredentialsFile != "" {
-			c.Provider.GCP.CredentialsFile = other.Provider.GCP.CredentialsFile
-		}
-		if other.Provider.GCP.TokenDuration > 0 {
-			c.Provider.GCP.TokenDuration = other.Provider.GCP.TokenDuration
+	c.Provider.mergeSections(other.Provider.GCP, other.Provider.AWS, other.Provider.Azure)
+
+	// Merge credential profiles; a profile of the same name is replaced as a whole
+	if other.Profile != "" {
+		c.Profile = other.Profile
+	}
+	for name, profile := range other.Credentials {
+		if c.Credentials == nil {
+			c.Credentials = make(map[string]CredentialProfile)
 		}
+		c.Credentials[name] = profile
 	}
 
-	if other.Provider.AWS != nil {
-		if c.Provider.AWS == nil {
-			c.Provider.AWS = &AWSConfig{}
-		}
-		if other.Provider.AWS.AccountID != "" {
-			c.Provider.AWS.AccountID = other.Provider.AWS.AccountID
+	// Merge token defaults field by field
+	for name, defaults := range other.Defaults {
+		if c.Defaults == nil {
+			c.Defaults = make(map[string]ProviderDefaults)
 		}
-		if other.Provider.AWS.RoleARN != "" {
-			c.Provider.AWS.RoleARN = other.Provider.AWS.RoleARN
+		merged := c.Defaults[name]
+		if defaults.TokenDuration != 0 {
+			merged.TokenDuration = defaults.TokenDuration
 		}
-		if other.Provider.AWS.TokenDuration > 0 {
-			c.Provider.AWS.TokenDuration = other.Provider.AWS.TokenDuration
+		if defaults.RefreshThreshold != 0 {
+			merged.RefreshThreshold = defaults.RefreshThreshold
 		}
+		c.Defaults[name] = merged
 	}
 
-	if other.Provider.Azure != nil {
-		if c.Provider.Azure == nil {
-			c.Provider.Azure = &AzureConfig{}
-		}
-		if other.Provider.Azure.SubscriptionID != "" {
-			c.Provider.Azure.SubscriptionID = other.Provider.Azure.SubscriptionID
-		}
-		if other.Provider.Azure.TenantID != "" {
-			c.Provider.Azure.TenantID = other.Provider.Azure.TenantID
-		}
-		if other.Provider.Azure.ResourceGroup != "" {
-			c.Provider.Azure.ResourceGroup = other.Provider.Azure.ResourceGroup
-		}
-		if other.Provider.Azure.TokenDuration > 0 {
-			c.Provider.Azure.TokenDuration = other.Provider.Azure.TokenDuration
-		}
+	// A non-empty allow-list replaces the previous one as a whole
+	if len(other.AllowedClusters) > 0 {
+		c.AllowedClusters = other.AllowedClusters
 	}
 
 	// Merge health config
 	c.Health.Enabled = other.Health.Enabled
+	if other.Health.Host != "" {
+		c.Health.Host = other.Health.Host
+	}
 	if other.Health.Port > 0 {
 		c.Health.Port = other.Health.Port
 	}
@@ -234,6 +303,9 @@ func (c *Config) Merge(other *Config) {
 
 	// Merge metrics config
 	c.Metrics.Enabled = other.Metrics.Enabled
+	if other.Metrics.Host != "" {
+		c.Metrics.Host = other.Metrics.Host
+	}
 	if other.Metrics.Port > 0 {
 		c.Metrics.Port = other.Metrics.Port
 	}
@@ -241,3 +313,125 @@ func (c *Config) Merge(other *Config) {
 		c.Metrics.Path = other.Metrics.Path
 	}
 }
+
+// mergeSections merges the non-zero values of the given provider sections into p
+func (p *ProviderConfig) mergeSections(gcp *GCPConfig, aws *AWSConfig, azure *AzureConfig) {
+	if gcp != nil {
+		if p.GCP == nil {
+			p.GCP = &GCPConfig{}
+		}
+		if gcp.ProjectID != "" {
+			p.GCP.ProjectID = gcp.ProjectID
+		}
+		if gcp.CredentialsFile != "" {
+			p.GCP.CredentialsFile = gcp.CredentialsFile
+		}
+		if gcp.TokenDuration > 0 {
+			p.GCP.TokenDuration = gcp.TokenDuration
+		}
+		if gcp.UseADC != "" {
+			p.GCP.UseADC = gcp.UseADC
+		}
+	}
+
+	if aws != nil {
+		if p.AWS == nil {
+			p.AWS = &AWSConfig{}
+		}
+		if aws.AccountID != "" {
+			p.AWS.AccountID = aws.AccountID
+		}
+		if aws.RoleARN != "" {
+			p.AWS.RoleARN = aws.RoleARN
+		}
+		if aws.TokenDuration > 0 {
+			p.AWS.TokenDuration = aws.TokenDuration
+		}
+		if len(aws.Fallback) > 0 {
+			p.AWS.Fallback = aws.Fallback
+		}
+	}
+
+	if azure != nil {
+		if p.Azure == nil {
+			p.Azure = &AzureConfig{}
+		}
+		if azure.SubscriptionID != "" {
+			p.Azure.SubscriptionID = azure.SubscriptionID
+		}
+		if azure.TenantID != "" {
+			p.Azure.TenantID = azure.TenantID
+		}
+		if azure.ResourceGroup != "" {
+			p.Azure.ResourceGroup = azure.ResourceGroup
+		}
+		if azure.Cloud != "" {
+			p.Azure.Cloud = azure.Cloud
+		}
+		if azure.TokenDuration > 0 {
+			p.Azure.TokenDuration = azure.TokenDuration
+		}
+	}
+}
+
+// ApplyProfile merges the named credential profile into the provider sections. An
+// empty name applies Profile, and no profile leaves the configuration unchanged.
+func (c *Config) ApplyProfile(name string) error {
+	if name == "" {
+		name = c.Profile
+	}
+	if name == "" {
+		return nil
+	}
+
+	profile, ok := c.Credentials[name]
+	if !ok {
+		available := c.ProfileNames()
+		detail := "no credentials profiles are defined"
+		if len(available) > 0 {
+			detail = "available profiles: " + strings.Join(available, ", ")
+		}
+		return errors.New(
+			errors.ErrConfigInvalid,
+			fmt.Sprintf("unknown credentials profile %q", name),
+		).WithDetail(detail).
+			WithField("profile", name).
+			WithField("available", available)
+	}
+
+	c.Provider.mergeSections(profile.GCP, profile.AWS, profile.Azure)
+	c.Profile = name
+	return nil
+}
+
+// ProfileNames returns the names of the credential profiles in sorted order
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Credentials))
+	for name := range c.Credentials {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// HealthServerConfig converts the health and metrics settings into a health server configuration
+func (c *Config) HealthServerConfig() health.Config {
+	cfg := health.DefaultConfig()
+
+	cfg.HealthDisabled = !c.Health.Enabled
+	cfg.HealthAddress = net.JoinHostPort(c.Health.Host, strconv.Itoa(c.Health.Port))
+	if c.Health.LivenessPath != "" {
+		cfg.LivenessPath = c.Health.LivenessPath
+	}
+	if c.Health.ReadinessPath != "" {
+		cfg.ReadinessPath = c.Health.ReadinessPath
+	}
+
+	cfg.MetricsDisabled = !c.Metrics.Enabled
+	cfg.MetricsAddress = net.JoinHostPort(c.Metrics.Host, strconv.Itoa(c.Metrics.Port))
+	if c.Metrics.Path != "" {
+		cfg.MetricsPath = c.Metrics.Path
+	}
+
+	return cfg
+}
@@ -5,6 +5,9 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
 )
 
 func TestDefaultConfig(t *testing.T) {
@@ -52,3 +55,245 @@ func TestFromFlags(t *testing.T) {
 	assert.Equal(t, "debug", config.Log.Level)
 	assert.Equal(t, "console", config.Log.Format)
 }
+
+func TestHealthServerConfig(t *testing.T) {
+	t.Run("defaults share one listener", func(t *testing.T) {
+		cfg := DefaultConfig().HealthServerConfig()
+
+		assert.Equal(t, ":8080", cfg.HealthAddress)
+		assert.Equal(t, ":8080", cfg.MetricsAddress)
+		assert.False(t, cfg.HealthDisabled)
+		assert.False(t, cfg.MetricsDisabled)
+		assert.Equal(t, "/healthz", cfg.LivenessPath)
+		assert.Equal(t, "/readyz", cfg.ReadinessPath)
+		assert.Equal(t, "/metrics", cfg.MetricsPath)
+	})
+
+	t.Run("split listeners", func(t *testing.T) {
+		config := DefaultConfig()
+		config.Health.Host = "127.0.0.1"
+		config.Health.Port = 8081
+		config.Health.LivenessPath = "/live"
+		config.Metrics.Port = 9090
+		config.Metrics.Path = "/custom-metrics"
+
+		cfg := config.HealthServerConfig()
+
+		assert.Equal(t, "127.0.0.1:8081", cfg.HealthAddress)
+		assert.Equal(t, ":9090", cfg.MetricsAddress)
+		assert.Equal(t, "/live", cfg.LivenessPath)
+		assert.Equal(t, "/custom-metrics", cfg.MetricsPath)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		config := DefaultConfig()
+		config.Health.Enabled = false
+		config.Metrics.Enabled = false
+
+		cfg := config.HealthServerConfig()
+
+		assert.True(t, cfg.HealthDisabled)
+		assert.True(t, cfg.MetricsDisabled)
+	})
+}
+
+// profilesConfig defines two credential profiles and selects staging by default
+const profilesConfig = `provider:
+  name: gcp
+  cluster_name: my-cluster
+  gcp:
+    project_id: base-project
+    use_adc: "false"
+    credentials_file: /etc/gcp/base.json
+profile: staging
+credentials:
+  staging:
+    gcp:
+      project_id: staging-project
+      credentials_file: /etc/gcp/staging.json
+  prod:
+    gcp:
+      project_id: prod-project
+      credentials_file: /etc/gcp/prod.json
+      token_duration: 30m
+`
+
+func TestLoad_CredentialsProfile(t *testing.T) {
+	path := writeConfig(t, profilesConfig)
+
+	tests := []struct {
+		name        string
+		profile     string
+		wantProject string
+		wantFile    string
+	}{
+		{name: "profile key of the file", wantProject: "staging-project", wantFile: "/etc/gcp/staging.json"},
+		{name: "selected profile", profile: "prod", wantProject: "prod-project", wantFile: "/etc/gcp/prod.json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := Load(WithConfigFile(path), WithProfile(tt.profile))
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.wantProject, config.Provider.GCP.ProjectID)
+			assert.Equal(t, tt.wantFile, config.Provider.GCP.CredentialsFile)
+			assert.Equal(t, "false", config.Provider.GCP.UseADC, "fields the profile does not set are kept")
+			assert.ElementsMatch(t, []string{"prod", "staging"}, config.ProfileNames())
+		})
+	}
+}
+
+func TestLoad_UnknownProfile(t *testing.T) {
+	path := writeConfig(t, profilesConfig)
+
+	_, report, err := Check(WithConfigFile(path), WithProfile("qa"))
+	require.NoError(t, err)
+	require.Len(t, report.Errors(), 1)
+	assert.Equal(t, "profile", report.Errors()[0].Path)
+	assert.Equal(t, "unknown_profile", report.Errors()[0].Rule)
+	assert.Contains(t, report.Errors()[0].Message, `unknown credentials profile "qa"`)
+	assert.Contains(t, report.Errors()[0].Message, "available profiles: prod, staging")
+
+	_, err = Load(WithConfigFile(path), WithProfile("qa"))
+	require.Error(t, err)
+}
+
+func TestApplyProfile(t *testing.T) {
+	config := DefaultConfig()
+	assert.NoError(t, config.ApplyProfile(""), "no profile leaves the configuration unchanged")
+	assert.Nil(t, config.Provider.Azure)
+
+	err := config.ApplyProfile("prod")
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, errors.ErrConfigInvalid))
+	assert.Contains(t, err.Error(), "no credentials profiles are defined")
+
+	config.Credentials = map[string]CredentialProfile{
+		"prod": {Azure: &AzureConfig{SubscriptionID: "sub", TenantID: "tenant"}},
+	}
+	require.NoError(t, config.ApplyProfile("prod"))
+	assert.Equal(t, "prod", config.Profile)
+	require.NotNil(t, config.Provider.Azure)
+	assert.Equal(t, "sub", config.Provider.Azure.SubscriptionID)
+}
+
+func TestConfigMerge_ProfileAndFlags(t *testing.T) {
+	path := writeConfig(t, profilesConfig)
+	config, err := Load(WithConfigFile(path), WithProfile("prod"))
+	require.NoError(t, err)
+
+	// Explicit flags are merged last and take precedence over the profile
+	flags := FromFlags("gcp", "other-cluster", "", "", "")
+	flags.Provider.GCP = &GCPConfig{ProjectID: "flag-project"}
+	config.Merge(flags)
+
+	assert.Equal(t, "other-cluster", config.Provider.ClusterName)
+	assert.Equal(t, "flag-project", config.Provider.GCP.ProjectID)
+	assert.Equal(t, "/etc/gcp/prod.json", config.Provider.GCP.CredentialsFile, "profile values not given as flags are kept")
+	assert.Equal(t, 30*time.Minute, config.Provider.GCP.TokenDuration)
+}
+
+func TestConfigMerge_Credentials(t *testing.T) {
+	base := DefaultConfig()
+	base.Credentials = map[string]CredentialProfile{
+		"staging": {AWS: &AWSConfig{AccountID: "111111111111"}},
+		"prod":    {AWS: &AWSConfig{AccountID: "222222222222", RoleARN: "arn:aws:iam::222222222222:role/deploy"}},
+	}
+
+	base.Merge(&Config{
+		Profile: "prod",
+		Credentials: map[string]CredentialProfile{
+			"prod": {AWS: &AWSConfig{AccountID: "333333333333"}},
+		},
+	})
+
+	assert.Equal(t, "prod", base.Profile)
+	assert.Equal(t, "111111111111", base.Credentials["staging"].AWS.AccountID)
+	assert.Equal(t, "333333333333", base.Credentials["prod"].AWS.AccountID)
+	assert.Empty(t, base.Credentials["prod"].AWS.RoleARN, "a profile is replaced as a whole")
+}
+
+func TestLoadCommandConfig(t *testing.T) {
+	path := writeConfig(t, `defaults:
+  gcp:
+    token_duration: 30m
+  aws:
+    refresh_threshold: 1m
+`)
+
+	config, err := LoadCommandConfig(path, "")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]ProviderDefaults{
+		"gcp": {TokenDuration: 30 * time.Minute},
+		"aws": {RefreshThreshold: time.Minute},
+	}, config.Defaults, "the other sections of the file are not required")
+
+	_, err = LoadCommandConfig(writeConfig(t, "defaults:\n  gcp:\n    token_duration: 5m\n    refresh_threshold: 5m\n"), "")
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, errors.ErrConfigInvalid))
+
+	_, err = LoadCommandConfig(writeConfig(t, "defaults:\n  gcp:\n    token_duration: soon\n"), "")
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, errors.ErrConfigInvalid))
+}
+
+func TestConfigMerge_Defaults(t *testing.T) {
+	config := DefaultConfig()
+	config.Defaults = map[string]ProviderDefaults{"gcp": {TokenDuration: 30 * time.Minute, RefreshThreshold: 3 * time.Minute}}
+
+	config.Merge(&Config{Defaults: map[string]ProviderDefaults{
+		"gcp": {TokenDuration: 20 * time.Minute},
+		"aws": {TokenDuration: 10 * time.Minute},
+	}})
+
+	assert.Equal(t, map[string]ProviderDefaults{
+		"gcp": {TokenDuration: 20 * time.Minute, RefreshThreshold: 3 * time.Minute},
+		"aws": {TokenDuration: 10 * time.Minute},
+	}, config.Defaults)
+}
+
+func TestLoadCommandConfig_AllowedClusters(t *testing.T) {
+	config, err := LoadCommandConfig(writeConfig(t, "allowed_clusters:\n  - prod-eu\n  - ci-*\n"), "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"prod-eu", "ci-*"}, config.AllowedClusters)
+
+	_, err = LoadCommandConfig(writeConfig(t, "allowed_clusters:\n  - ci-*\n  - \"ci-[\"\n"), "")
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, errors.ErrConfigInvalid))
+	var appErr *errors.Error
+	require.True(t, errors.As(err, &appErr))
+	assert.Contains(t, appErr.Detail, `entry 1 must be a cluster name or glob pattern, got "ci-["`)
+}
+
+func TestLoadCommandConfig_Provider(t *testing.T) {
+	config, err := LoadCommandConfig(writeConfig(t, profilesConfig), "")
+	require.NoError(t, err)
+	assert.Equal(t, "gcp", config.Provider.Name)
+	assert.Equal(t, "my-cluster", config.Provider.ClusterName)
+	assert.Equal(t, "staging-project", config.Provider.GCP.ProjectID, "the profile key of the file is applied")
+
+	config, err = LoadCommandConfig(writeConfig(t, profilesConfig), "prod")
+	require.NoError(t, err)
+	assert.Equal(t, "prod-project", config.Provider.GCP.ProjectID, "the selected profile wins over the profile key")
+	assert.Equal(t, "/etc/gcp/prod.json", config.Provider.GCP.CredentialsFile)
+
+	_, err = LoadCommandConfig(writeConfig(t, profilesConfig), "qa")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown credentials profile "qa"`)
+
+	config, err = LoadCommandConfig(writeConfig(t, "provider:\n  region: us-east-1\n  azure:\n    cloud: china\n"), "")
+	require.NoError(t, err, "provider settings are optional")
+	assert.Equal(t, "us-east-1", config.Provider.Region)
+
+	_, err = LoadCommandConfig(writeConfig(t, "provider:\n  name: gce\n  gcp:\n    use_adc: maybe\n"), "")
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, errors.ErrConfigInvalid))
+	var appErr *errors.Error
+	require.True(t, errors.As(err, &appErr))
+	assert.Equal(t, "provider.name, provider.gcp.use_adc", appErr.Fields["paths"])
+
+	_, err = LoadCommandConfig(writeConfig(t, "profile: qa\n"), "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown credentials profile "qa"`)
+}
@@ -0,0 +1,141 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+)
+
+// CredentialsMap selects the credentials of each cluster. Keys are "<provider>/<cluster>",
+// e.g. aws/prod-east, or "<provider>/<scope>/<id>" for every cluster of a GCP project,
+// AWS account or Azure subscription, e.g. gcp/project/my-project.
+type CredentialsMap map[string]CredentialsMapEntry
+
+// CredentialsMapEntry is the credentials selection of one key of a CredentialsMap
+type CredentialsMapEntry struct {
+	// CredentialsFile is resolved against the directory of the map file when relative
+	CredentialsFile string `yaml:"credentials_file"`
+
+	// Profile is the AWS profile of the credentials file
+	Profile string `yaml:"profile"`
+
+	// UseADC is the GCP --gcp-use-adc mode: auto, true or false
+	UseADC string `yaml:"use_adc"`
+}
+
+// credentialsMapScopes is the scope of the "<provider>/<scope>/<id>" keys of each provider
+var credentialsMapScopes = map[string]string{
+	"gcp":   "project",
+	"aws":   "account",
+	"azure": "subscription",
+}
+
+// CredentialsMapScope returns the scope of providerName's "<provider>/<scope>/<id>" keys,
+// or "" when the provider only has per-cluster keys
+func CredentialsMapScope(providerName string) string {
+	return credentialsMapScopes[providerName]
+}
+
+// LoadCredentialsMap reads and validates a credentials map file, failing with one error
+// listing every invalid entry
+func LoadCredentialsMap(path string) (CredentialsMap, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(
+			errors.ErrConfigLoadFailed,
+			err,
+			"failed to read credentials map",
+		).WithField("path", path)
+	}
+
+	var credentialsMap CredentialsMap
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&credentialsMap); err != nil && err != io.EOF {
+		return nil, errors.Wrap(
+			errors.ErrConfigInvalid,
+			err,
+			"failed to parse credentials map",
+		).WithField("path", path)
+	}
+
+	report := &Report{Source: path}
+	validateCredentialsMap(report, credentialsMap)
+	if err := report.Err(false); err != nil {
+		return nil, err
+	}
+
+	dir := filepath.Dir(path)
+	for key, entry := range credentialsMap {
+		if entry.CredentialsFile != "" && !filepath.IsAbs(entry.CredentialsFile) {
+			entry.CredentialsFile = filepath.Join(dir, entry.CredentialsFile)
+			credentialsMap[key] = entry
+		}
+	}
+	return credentialsMap, nil
+}
+
+// validateCredentialsMap checks the keys of credentialsMap and the fields each entry sets
+func validateCredentialsMap(report *Report, credentialsMap CredentialsMap) {
+	keys := make([]string, 0, len(credentialsMap))
+	for key := range credentialsMap {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		entry := credentialsMap[key]
+		parts := strings.Split(key, "/")
+		providerName := parts[0]
+
+		switch {
+		case len(parts) < 2 || len(parts) > 3 || containsEmpty(parts):
+			report.add(SeverityError, key, "key",
+				"must be <provider>/<cluster> or <provider>/<scope>/<id>")
+			continue
+		case len(parts) == 3 && parts[1] != CredentialsMapScope(providerName):
+			scope := CredentialsMapScope(providerName)
+			if scope == "" {
+				report.add(SeverityError, key, "key",
+					fmt.Sprintf("%s credentials are only mapped per cluster", providerName))
+			} else {
+				report.add(SeverityError, key, "key",
+					fmt.Sprintf("the scope of %s keys is %s, got %q", providerName, scope, parts[1]))
+			}
+			continue
+		}
+
+		if entry == (CredentialsMapEntry{}) {
+			report.add(SeverityError, key, "required",
+				"must set credentials_file, profile or use_adc")
+		}
+		if entry.Profile != "" && providerName != "aws" {
+			report.add(SeverityError, key+".profile", "provider", "only applies to aws")
+		}
+		if entry.UseADC != "" {
+			if providerName != "gcp" {
+				report.add(SeverityError, key+".use_adc", "provider", "only applies to gcp")
+			} else if entry.UseADC != "auto" && entry.UseADC != "true" && entry.UseADC != "false" {
+				report.add(SeverityError, key+".use_adc", "oneof",
+					fmt.Sprintf("must be one of: auto, true, false, got %q", entry.UseADC))
+			}
+		}
+	}
+}
+
+func containsEmpty(parts []string) bool {
+	for _, part := range parts {
+		if part == "" {
+			return true
+		}
+	}
+	return false
+}
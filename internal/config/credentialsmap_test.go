@@ -0,0 +1,100 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+)
+
+func TestLoadCredentialsMap(t *testing.T) {
+	path := writeConfig(t, `aws/prod-east:
+  credentials_file: /secrets/aws-prod
+  profile: deploy
+aws/account/123456789012:
+  credentials_file: aws-shared
+gcp/project/my-project:
+  use_adc: "false"
+  credentials_file: gcp/my-project.json
+`)
+
+	credentialsMap, err := LoadCredentialsMap(path)
+	require.NoError(t, err)
+	assert.Equal(t, CredentialsMap{
+		"aws/prod-east":            {CredentialsFile: "/secrets/aws-prod", Profile: "deploy"},
+		"aws/account/123456789012": {CredentialsFile: filepath.Join(filepath.Dir(path), "aws-shared")},
+		"gcp/project/my-project":   {CredentialsFile: filepath.Join(filepath.Dir(path), "gcp/my-project.json"), UseADC: "false"},
+	}, credentialsMap, "relative files are resolved against the map")
+
+	empty, err := LoadCredentialsMap(writeConfig(t, ""))
+	require.NoError(t, err)
+	assert.Empty(t, empty)
+}
+
+func TestLoadCredentialsMap_Invalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		wantCode errors.ErrorCode
+		wantMsg  string
+	}{
+		{
+			name:     "unknown field",
+			content:  "aws/prod:\n  credentials: /secrets/aws\n",
+			wantCode: errors.ErrConfigInvalid,
+		},
+		{
+			name:     "key without a cluster",
+			content:  "aws:\n  profile: deploy\n",
+			wantCode: errors.ErrValidationFailed,
+			wantMsg:  "must be <provider>/<cluster> or <provider>/<scope>/<id>",
+		},
+		{
+			name:     "scope of another provider",
+			content:  "gcp/account/123456789012:\n  credentials_file: /secrets/gcp.json\n",
+			wantCode: errors.ErrValidationFailed,
+			wantMsg:  `the scope of gcp keys is project, got "account"`,
+		},
+		{
+			name:     "scope of a per-cluster provider",
+			content:  "oci/tenancy/abc:\n  credentials_file: /secrets/oci\n",
+			wantCode: errors.ErrValidationFailed,
+			wantMsg:  "oci credentials are only mapped per cluster",
+		},
+		{
+			name:     "empty entry",
+			content:  "aws/prod: {}\n",
+			wantCode: errors.ErrValidationFailed,
+			wantMsg:  "must set credentials_file, profile or use_adc",
+		},
+		{
+			name:     "profile of another provider",
+			content:  "azure/prod:\n  profile: deploy\n",
+			wantCode: errors.ErrValidationFailed,
+			wantMsg:  "azure/prod.profile: only applies to aws",
+		},
+		{
+			name:     "invalid ADC mode",
+			content:  "gcp/prod:\n  use_adc: maybe\n",
+			wantCode: errors.ErrValidationFailed,
+			wantMsg:  `must be one of: auto, true, false, got "maybe"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadCredentialsMap(writeConfig(t, tt.content))
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, tt.wantCode), "got %v", err)
+			var appErr *errors.Error
+			require.True(t, errors.As(err, &appErr))
+			assert.Contains(t, appErr.Detail, tt.wantMsg)
+		})
+	}
+
+	_, err := LoadCredentialsMap(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.True(t, errors.Is(err, errors.ErrConfigLoadFailed))
+}
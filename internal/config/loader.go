@@ -1,7 +1,10 @@
 package config
 
 import (
+	stderrors "errors"
+	"fmt"
 	"os"
+	"reflect"
 	"strconv"
 	"time"
 
@@ -16,6 +19,7 @@ type LoadOption func(*loadOptions)
 type loadOptions struct {
 	configFile string
 	fromEnv    bool
+	profile    string
 }
 
 // WithConfigFile specifies the config file path
@@ -32,8 +36,33 @@ func WithEnv() LoadOption {
 	}
 }
 
-// Load loads configuration with the given options
+// WithProfile selects the credentials profile, overriding the profile key of the file
+func WithProfile(name string) LoadOption {
+	return func(o *loadOptions) {
+		o.profile = name
+	}
+}
+
+// Load loads configuration with the given options: DefaultConfig, merged with the
+// config file, the selected profile and the environment, then validated against the
+// validate tags of Config. It fails with one error listing every violation when the
+// configuration is invalid; see Report.ConfigErr for its code.
 func Load(opts ...LoadOption) (*Config, error) {
+	config, report, err := Check(opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := report.ConfigErr(); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// Check loads configuration like Load, but returns the validation report instead of
+// failing on violations. Violations carry the line of the field when a config file is
+// used. The error is only set when the config file cannot be read or is not YAML.
+func Check(opts ...LoadOption) (*Config, *Report, error) {
 	options := &loadOptions{}
 	for _, opt := range opts {
 		opt(options)
@@ -43,48 +72,120 @@ func Load(opts ...LoadOption) (*Config, error) {
 	config := DefaultConfig()
 
 	// Load from file if specified
+	var root *yaml.Node
+	var fileViolations []Violation
 	if options.configFile != "" {
-		fileConfig, err := loadFromFile(options.configFile)
-		if err != nil {
-			return nil, err
+		fileConfig, node, err := loadFromFile(options.configFile)
+		var typeErr *yaml.TypeError
+		if stderrors.As(err, &typeErr) {
+			// The other fields were still decoded, so keep validating them
+			fileViolations = typeErrorViolations(node, typeErr)
+		} else if err != nil {
+			return nil, nil, err
 		}
+		root = node
+		fileViolations = append(fileViolations, unknownFields(documentMapping(node), reflect.TypeOf(Config{}), "")...)
 		config.Merge(fileConfig)
 	}
 
+	// The selected profile overrides the provider sections of the file, and is in turn
+	// overridden by environment variables and by flags merged in by the caller
+	var profileErr *errors.Error
+	if err := config.ApplyProfile(options.profile); errors.As(err, &profileErr) {
+		fileViolations = append(fileViolations, Violation{
+			Path:     "profile",
+			Rule:     "unknown_profile",
+			Message:  fmt.Sprintf("%s (%s)", profileErr.Title, profileErr.Detail),
+			Severity: SeverityError,
+		})
+	}
+
 	// Override with environment variables if enabled
 	if options.fromEnv {
 		envConfig := loadFromEnv()
 		config.Merge(envConfig)
 	}
 
-	if err := Validate(config); err != nil {
-		return nil, err
-	}
+	report := ValidateReport(config)
+	report.Source = options.configFile
+	report.Violations = append(fileViolations, report.Violations...)
+	report.locate(root)
 
-	return config, nil
+	return config, report, nil
 }
 
-// loadFromFile loads configuration from a YAML file
-func loadFromFile(path string) (*Config, error) {
+// loadFromFile loads configuration from a YAML file and returns it with the parsed
+// document. When some fields have the wrong type, the rest of the configuration is
+// returned together with a *yaml.TypeError.
+func loadFromFile(path string) (*Config, *yaml.Node, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
-		return nil, errors.Wrap(
+		return nil, nil, errors.Wrap(
 			errors.ErrConfigLoadFailed,
 			err,
 			"failed to read config file",
 		).WithField("path", path)
 	}
 
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, nil, errors.Wrap(
+			errors.ErrConfigInvalid,
+			err,
+			"failed to parse config file",
+		).WithField("path", path)
+	}
+
 	var config Config
-	if err := yaml.Unmarshal(data, &config); err != nil {
+	if len(root.Content) == 0 {
+		// An empty file leaves every setting at its default
+		return &config, &root, nil
+	}
+	if err := root.Decode(&config); err != nil {
+		var typeErr *yaml.TypeError
+		if stderrors.As(err, &typeErr) {
+			return &config, &root, typeErr
+		}
+		return nil, nil, errors.Wrap(
+			errors.ErrConfigInvalid,
+			err,
+			"failed to parse config file",
+		).WithField("path", path)
+	}
+
+	return &config, &root, nil
+}
+
+// LoadCommandConfig reads the sections of a config file that every command applies:
+// defaults, allowed_clusters and the provider settings, with the credentials profile
+// applied: profile, or the profile key of the file when profile is empty. Nothing is
+// required, since flags and HFCP_ variables may supply what the file leaves out, but
+// the values that are set are validated.
+func LoadCommandConfig(path, profile string) (*Config, error) {
+	config, _, err := loadFromFile(path)
+	var typeErr *yaml.TypeError
+	if stderrors.As(err, &typeErr) {
 		return nil, errors.Wrap(
 			errors.ErrConfigInvalid,
 			err,
 			"failed to parse config file",
 		).WithField("path", path)
 	}
+	if err != nil {
+		return nil, err
+	}
 
-	return &config, nil
+	report := &Report{Source: path}
+	validateDefaults(report, config.Defaults)
+	validateAllowedClusters(report, config.AllowedClusters)
+	validateCommandProvider(report, &config.Provider)
+	if err := report.ConfigErr(); err != nil {
+		return nil, err
+	}
+	if err := config.ApplyProfile(profile); err != nil {
+		return nil, err
+	}
+	return config, nil
 }
 
 // loadFromEnv loads configuration from environment variables
@@ -99,26 +200,33 @@ func loadFromEnv() *Config {
 			Region:      getEnv("PROVIDER_REGION", ""),
 			ClusterName: getEnv("CLUSTER_NAME", ""),
 			Timeout:     getDurationEnv("PROVIDER_TIMEOUT", 0),
+
+			StrictPermissions: getBoolEnv("STRICT_PERMISSIONS", false),
 		},
 		Health: HealthConfig{
 			Enabled:       getBoolEnv("HEALTH_ENABLED", true),
+			Host:          getEnv("HEALTH_HOST", ""),
 			Port:          getIntEnv("HEALTH_PORT", 0),
 			ReadinessPath: getEnv("HEALTH_READINESS_PATH", ""),
 			LivenessPath:  getEnv("HEALTH_LIVENESS_PATH", ""),
 		},
 		Metrics: MetricsConfig{
 			Enabled: getBoolEnv("METRICS_ENABLED", true),
+			Host:    getEnv("METRICS_HOST", ""),
 			Port:    getIntEnv("METRICS_PORT", 0),
 			Path:    getEnv("METRICS_PATH", ""),
 		},
 	}
 
 	// Load GCP config
-	if gcpProjectID := getEnv("GCP_PROJECT_ID", ""); gcpProjectID != "" {
+	gcpProjectID := getEnv("GCP_PROJECT_ID", "")
+	gcpUseADC := getEnv("GCP_USE_ADC", "")
+	if gcpProjectID != "" || gcpUseADC != "" {
 		config.Provider.GCP = &GCPConfig{
 			ProjectID:       gcpProjectID,
 			CredentialsFile: getEnv("GOOGLE_APPLICATION_CREDENTIALS", ""),
 			TokenDuration:   getDurationEnv("GCP_TOKEN_DURATION", 0),
+			UseADC:          gcpUseADC,
 		}
 	}
 
@@ -137,6 +245,7 @@ func loadFromEnv() *Config {
 			SubscriptionID: azureSubscriptionID,
 			TenantID:       getEnv("AZURE_TENANT_ID", ""),
 			ResourceGroup:  getEnv("AZURE_RESOURCE_GROUP", ""),
+			Cloud:          getEnv("AZURE_CLOUD", ""),
 			TokenDuration:  getDurationEnv("AZURE_TOKEN_DURATION", 0),
 		}
 	}
@@ -0,0 +1,324 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+)
+
+// Severity is how serious a violation is
+type Severity string
+
+const (
+	// SeverityError makes the configuration invalid
+	SeverityError Severity = "error"
+
+	// SeverityWarning is reported but only fails strict validation
+	SeverityWarning Severity = "warning"
+)
+
+// Violation is one problem found in a configuration
+type Violation struct {
+	// Path is the YAML path of the offending field, e.g. provider.azure.tenant_id
+	Path string `json:"path"`
+
+	// Line is the line of the field in the config file, or 0 when it is not in the file
+	Line int `json:"line,omitempty"`
+
+	// Rule is the validator tag or custom rule that failed
+	Rule string `json:"rule"`
+
+	// Message describes the problem
+	Message string `json:"message"`
+
+	// Severity is error or warning
+	Severity Severity `json:"severity"`
+}
+
+// Report collects every violation found in a configuration
+type Report struct {
+	// Source is the config file that was validated, if any
+	Source string `json:"source,omitempty"`
+
+	// Violations are ordered by line, with violations outside the file last
+	Violations []Violation `json:"violations"`
+}
+
+// typeErrorLinePattern matches the line prefix of yaml.TypeError messages
+var typeErrorLinePattern = regexp.MustCompile(`^line (\d+): (.*)$`)
+
+func (r *Report) add(severity Severity, path, rule, message string) {
+	r.Violations = append(r.Violations, Violation{
+		Path:     path,
+		Rule:     rule,
+		Message:  message,
+		Severity: severity,
+	})
+}
+
+// Errors returns the violations with error severity
+func (r *Report) Errors() []Violation {
+	return r.filter(SeverityError)
+}
+
+// Warnings returns the violations with warning severity
+func (r *Report) Warnings() []Violation {
+	return r.filter(SeverityWarning)
+}
+
+func (r *Report) filter(severity Severity) []Violation {
+	var out []Violation
+	for _, v := range r.Violations {
+		if v.Severity == severity {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// Valid reports whether the configuration has no errors, and in strict mode no warnings either
+func (r *Report) Valid(strict bool) bool {
+	if strict {
+		return len(r.Violations) == 0
+	}
+	return len(r.Errors()) == 0
+}
+
+// Err returns nil when the configuration is valid, and otherwise one error listing every
+// violation that makes it invalid
+func (r *Report) Err(strict bool) error {
+	if r.Valid(strict) {
+		return nil
+	}
+
+	failing := r.Errors()
+	if strict {
+		failing = r.Violations
+	}
+	return r.newError(errors.ErrValidationFailed, failing)
+}
+
+// ConfigErr returns nil when the configuration is valid, like Err(false), and otherwise
+// an error whose code tells a missing setting from a wrong one: ErrConfigMissingField
+// when every error is a required field left empty, ErrConfigInvalid otherwise. Its
+// paths field lists the offending fields.
+func (r *Report) ConfigErr() error {
+	failing := r.Errors()
+	if len(failing) == 0 {
+		return nil
+	}
+
+	code := errors.ErrConfigMissingField
+	paths := make([]string, 0, len(failing))
+	for _, v := range failing {
+		if !strings.HasPrefix(v.Rule, "required") {
+			code = errors.ErrConfigInvalid
+		}
+		paths = append(paths, v.Path)
+	}
+	return r.newError(code, failing).WithField("paths", strings.Join(paths, ", "))
+}
+
+// newError creates an error of code listing the failing violations in its detail
+func (r *Report) newError(code errors.ErrorCode, failing []Violation) *errors.Error {
+	lines := make([]string, 0, len(failing))
+	for _, v := range failing {
+		lines = append(lines, v.String())
+	}
+
+	return errors.New(
+		code,
+		fmt.Sprintf("configuration has %d problem(s)", len(failing)),
+	).WithFields(map[string]interface{}{
+		"source":   r.Source,
+		"errors":   len(r.Errors()),
+		"warnings": len(r.Warnings()),
+	}).WithDetail(strings.Join(lines, "; "))
+}
+
+// String formats the violation as "path (line N): message [rule]"
+func (v Violation) String() string {
+	location := v.Path
+	if location == "" {
+		location = "<document>"
+	}
+	if v.Line > 0 {
+		location += fmt.Sprintf(" (line %d)", v.Line)
+	}
+	return fmt.Sprintf("%s: %s [%s]", location, v.Message, v.Rule)
+}
+
+// WriteText prints a summary line followed by one line per violation
+func (r *Report) WriteText(w io.Writer) {
+	source := r.Source
+	if source == "" {
+		source = "configuration"
+	}
+	if len(r.Violations) == 0 {
+		fmt.Fprintf(w, "%s: valid\n", source)
+		return
+	}
+
+	fmt.Fprintf(w, "%s: %d error(s), %d warning(s)\n", source, len(r.Errors()), len(r.Warnings()))
+	for _, v := range r.Violations {
+		fmt.Fprintf(w, "  %-8s %s\n", v.Severity, v)
+	}
+}
+
+// locate fills in the line of each violation from the parsed config file and orders
+// the violations by line
+func (r *Report) locate(root *yaml.Node) {
+	if root == nil {
+		return
+	}
+	for i := range r.Violations {
+		if r.Violations[i].Line == 0 {
+			r.Violations[i].Line = lineOf(root, r.Violations[i].Path)
+		}
+	}
+	sortViolations(r.Violations)
+}
+
+// sortViolations orders violations by line, keeping violations without a line last
+func sortViolations(violations []Violation) {
+	sort.SliceStable(violations, func(i, j int) bool {
+		a, b := violations[i].Line, violations[j].Line
+		if a == 0 || b == 0 {
+			return a != 0 && b == 0
+		}
+		return a < b
+	})
+}
+
+// documentMapping returns the top-level mapping of a parsed YAML document
+func documentMapping(root *yaml.Node) *yaml.Node {
+	if root != nil && root.Kind == yaml.DocumentNode && len(root.Content) > 0 {
+		root = root.Content[0]
+	}
+	if root == nil || root.Kind != yaml.MappingNode {
+		return nil
+	}
+	return root
+}
+
+// lineOf returns the line of the key at path. A missing key is located at its closest
+// parent in the document, and a path entirely outside the document returns 0.
+func lineOf(root *yaml.Node, path string) int {
+	node := documentMapping(root)
+	if node == nil || path == "" {
+		return 0
+	}
+
+	line := 0
+	for _, segment := range strings.Split(path, ".") {
+		if node == nil || node.Kind != yaml.MappingNode {
+			return 0
+		}
+		var next *yaml.Node
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == segment {
+				line = node.Content[i].Line
+				next = node.Content[i+1]
+				break
+			}
+		}
+		if next == nil {
+			return line
+		}
+		node = next
+	}
+	return line
+}
+
+// pathAtLine returns the path of the deepest key on line, or "" when there is none
+func pathAtLine(node *yaml.Node, line int, prefix string) string {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return ""
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		path := joinPath(prefix, key.Value)
+		if nested := pathAtLine(value, line, path); nested != "" {
+			return nested
+		}
+		if key.Line == line || value.Line == line {
+			return path
+		}
+	}
+	return ""
+}
+
+// typeErrorViolations converts the messages of a yaml.TypeError into violations
+func typeErrorViolations(root *yaml.Node, typeErr *yaml.TypeError) []Violation {
+	violations := make([]Violation, 0, len(typeErr.Errors))
+	for _, message := range typeErr.Errors {
+		v := Violation{Rule: "type", Message: message, Severity: SeverityError}
+		if match := typeErrorLinePattern.FindStringSubmatch(message); match != nil {
+			v.Line, _ = strconv.Atoi(match[1])
+			v.Message = match[2]
+			v.Path = pathAtLine(documentMapping(root), v.Line, "")
+		}
+		violations = append(violations, v)
+	}
+	return violations
+}
+
+// unknownFields reports keys of the document that do not map to a field of t
+func unknownFields(node *yaml.Node, t reflect.Type, prefix string) []Violation {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if node != nil && node.Kind == yaml.MappingNode && t.Kind() == reflect.Map {
+		// Keys are names, such as credential profiles; check the fields of each value
+		var violations []Violation
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			violations = append(violations, unknownFields(node.Content[i+1], t.Elem(), joinPath(prefix, node.Content[i].Value))...)
+		}
+		return violations
+	}
+	if node == nil || node.Kind != yaml.MappingNode || t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	fields := make(map[string]reflect.Type, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0]
+		if name != "" && name != "-" {
+			fields[name] = t.Field(i).Type
+		}
+	}
+
+	var violations []Violation
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := node.Content[i]
+		path := joinPath(prefix, key.Value)
+		fieldType, ok := fields[key.Value]
+		if !ok {
+			violations = append(violations, Violation{
+				Path:     path,
+				Line:     key.Line,
+				Rule:     "unknown_field",
+				Message:  "is not a known configuration field and is ignored",
+				Severity: SeverityWarning,
+			})
+			continue
+		}
+		violations = append(violations, unknownFields(node.Content[i+1], fieldType, path)...)
+	}
+	return violations
+}
+
+func joinPath(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
@@ -2,6 +2,11 @@ package config
 
 import (
 	"fmt"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 
@@ -12,125 +17,224 @@ var validate *validator.Validate
 
 func init() {
 	validate = validator.New()
+
+	// Name fields by their YAML keys so violations point into the config file
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
 }
 
+// Validate checks the whole configuration and returns one error listing every
+// violation, or nil when it is valid. Warnings do not make it invalid.
 func Validate(config *Config) error {
 	if config == nil {
 		return errors.New(errors.ErrConfigInvalid, "configuration is nil")
 	}
 
+	return ValidateReport(config).Err(false)
+}
+
+// ValidateReport walks the whole configuration and collects every violation of the
+// validator tags and of the cross-field rules, instead of stopping at the first one
+func ValidateReport(config *Config) *Report {
+	report := &Report{}
+	if config == nil {
+		report.add(SeverityError, "", "required", "configuration is nil")
+		return report
+	}
+
 	if err := validate.Struct(config); err != nil {
-		return formatValidationError(err)
+		addValidationErrors(report, err)
 	}
 
-	// Provider-specific validation
-	switch config.Provider.Name {
-	case "gcp":
-		if err := validateGCPConfig(config.Provider.GCP); err != nil {
-			return err
-		}
-	case "aws":
-		if err := validateAWSConfig(config.Provider.AWS); err != nil {
-			return err
-		}
-	case "azure":
-		if err := validateAzureConfig(config.Provider.Azure); err != nil {
-			return err
-		}
-	default:
-		return errors.New(
-			errors.ErrProviderNotSupported,
-			fmt.Sprintf("unsupported provider: %s", config.Provider.Name),
-		).WithField("provider", config.Provider.Name)
+	validateProviderSections(report, &config.Provider)
+	validateDefaults(report, config.Defaults)
+	validateAllowedClusters(report, config.AllowedClusters)
+	validateServers(report, config)
+
+	return report
+}
+
+// addValidationErrors adds one violation per failed validator tag
+func addValidationErrors(report *Report, err error) {
+	validationErrs, ok := err.(validator.ValidationErrors)
+	if !ok {
+		report.add(SeverityError, "", "validation", err.Error())
+		return
 	}
 
-	return nil
+	for _, fieldErr := range validationErrs {
+		report.add(SeverityError, fieldPath(fieldErr), fieldErr.Tag(), tagMessage(fieldErr))
+	}
 }
 
-// validateGCPConfig validates GCP-specific configuration
-func validateGCPConfig(config *GCPConfig) error {
-	if config == nil {
-		return errors.New(
-			errors.ErrConfigMissingField,
-			"GCP configuration is required",
-		).WithField("provider", "gcp")
+// fieldPath returns the YAML path of a validator field error, without the root struct
+func fieldPath(fieldErr validator.FieldError) string {
+	_, path, _ := strings.Cut(fieldErr.Namespace(), ".")
+	return path
+}
+
+// tagMessage describes a failed validator tag
+func tagMessage(fieldErr validator.FieldError) string {
+	switch fieldErr.Tag() {
+	case "required":
+		return "is required"
+	case "oneof":
+		return fmt.Sprintf("must be one of %s, got %q", strings.ReplaceAll(fieldErr.Param(), " ", ", "), fmt.Sprint(fieldErr.Value()))
+	case "min":
+		return fmt.Sprintf("must be at least %s, got %v", fieldErr.Param(), fieldErr.Value())
+	case "max":
+		return fmt.Sprintf("must be at most %s, got %v", fieldErr.Param(), fieldErr.Value())
+	default:
+		return fmt.Sprintf("failed the %q rule", fieldErr.Tag())
+	}
+}
+
+// validateProviderSections applies the rules that depend on provider.name: the section of
+// the selected provider must be present and complete, and other sections are ignored
+func validateProviderSections(report *Report, provider *ProviderConfig) {
+	sections := map[string]bool{
+		"gcp":   provider.GCP != nil,
+		"aws":   provider.AWS != nil,
+		"azure": provider.Azure != nil,
 	}
 
-	if config.ProjectID == "" {
-		return errors.New(
-			errors.ErrConfigMissingField,
-			"GCP project_id is required",
-		).WithField("provider", "gcp")
+	if _, supported := sections[provider.Name]; supported && !sections[provider.Name] {
+		report.add(SeverityError, "provider."+provider.Name, "required_for_provider",
+			fmt.Sprintf("is required when provider.name is %s", provider.Name))
+	}
+	for _, name := range []string{"gcp", "aws", "azure"} {
+		if sections[name] && name != provider.Name {
+			report.add(SeverityWarning, "provider."+name, "unused_section",
+				fmt.Sprintf("is ignored because provider.name is %q", provider.Name))
+		}
 	}
 
-	return nil
+	// With application default credentials the project can come from the ADC lookup
+	if provider.Name == "gcp" && provider.GCP != nil && provider.GCP.ProjectID == "" && !gcpUsesADC(provider.GCP) {
+		report.add(SeverityError, "provider.gcp.project_id", "required_without_adc",
+			"is required when application default credentials are not used (use_adc false, or a credentials file is set)")
+	}
+
+	if provider.GCP != nil {
+		checkTokenDuration(report, "gcp", provider.GCP.TokenDuration)
+	}
+	if provider.AWS != nil {
+		checkTokenDuration(report, "aws", provider.AWS.TokenDuration)
+	}
+	if provider.Azure != nil {
+		checkTokenDuration(report, "azure", provider.Azure.TokenDuration)
+	}
 }
 
-// validateAWSConfig validates AWS-specific configuration
-func validateAWSConfig(config *AWSConfig) error {
-	if config == nil {
-		return errors.New(
-			errors.ErrConfigMissingField,
-			"AWS configuration is required",
-		).WithField("provider", "aws")
+// validateCommandProvider checks the values set in the provider section, which commands
+// apply as flag defaults. Unlike ValidateReport it requires no field.
+func validateCommandProvider(report *Report, provider *ProviderConfig) {
+	if err := validate.Struct(provider); err != nil {
+		validationErrs, ok := err.(validator.ValidationErrors)
+		if !ok {
+			report.add(SeverityError, "provider", "validation", err.Error())
+			return
+		}
+		for _, fieldErr := range validationErrs {
+			if strings.HasPrefix(fieldErr.Tag(), "required") {
+				continue
+			}
+			report.add(SeverityError, "provider."+fieldPath(fieldErr), fieldErr.Tag(), tagMessage(fieldErr))
+		}
 	}
 
-	// AWS config is mostly optional as credentials can come from environment
-	// and SDK chain
+	if provider.GCP != nil {
+		checkTokenDuration(report, "gcp", provider.GCP.TokenDuration)
+	}
+	if provider.AWS != nil {
+		checkTokenDuration(report, "aws", provider.AWS.TokenDuration)
+	}
+	if provider.Azure != nil {
+		checkTokenDuration(report, "azure", provider.Azure.TokenDuration)
+	}
+}
 
-	return nil
+// checkTokenDuration rejects a negative token duration; zero selects the provider default
+func checkTokenDuration(report *Report, provider string, duration time.Duration) {
+	if duration < 0 {
+		report.add(SeverityError, "provider."+provider+".token_duration", "min",
+			fmt.Sprintf("must not be negative, got %s", duration))
+	}
 }
 
-// validateAzureConfig validates Azure-specific configuration
-func validateAzureConfig(config *AzureConfig) error {
-	if config == nil {
-		return errors.New(
-			errors.ErrConfigMissingField,
-			"Azure configuration is required",
-		).WithField("provider", "azure")
+// validateDefaults checks the token defaults that can be checked without the provider
+// registry; the provider maximums are enforced when the defaults are applied
+func validateDefaults(report *Report, defaults map[string]ProviderDefaults) {
+	for name, d := range defaults {
+		prefix := "defaults." + name
+		if d.TokenDuration < 0 {
+			report.add(SeverityError, prefix+".token_duration", "min",
+				fmt.Sprintf("must not be negative, got %s", d.TokenDuration))
+		}
+		if d.RefreshThreshold < 0 {
+			report.add(SeverityError, prefix+".refresh_threshold", "min",
+				fmt.Sprintf("must not be negative, got %s", d.RefreshThreshold))
+		}
+		if d.TokenDuration > 0 && d.RefreshThreshold >= d.TokenDuration {
+			report.add(SeverityError, prefix+".refresh_threshold", "ltfield",
+				fmt.Sprintf("must be shorter than token_duration %s, got %s", d.TokenDuration, d.RefreshThreshold))
+		}
 	}
+}
 
-	if config.SubscriptionID == "" {
-		return errors.New(
-			errors.ErrConfigMissingField,
-			"Azure subscription_id is required",
-		).WithField("provider", "azure")
+// validateAllowedClusters rejects malformed cluster patterns, which would otherwise
+// deny every cluster
+func validateAllowedClusters(report *Report, patterns []string) {
+	for i, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil || pattern == "" {
+			report.add(SeverityError, "allowed_clusters", "glob",
+				fmt.Sprintf("entry %d must be a cluster name or glob pattern, got %q", i, pattern))
+		}
 	}
+}
 
-	if config.TenantID == "" {
-		return errors.New(
-			errors.ErrConfigMissingField,
-			"Azure tenant_id is required",
-		).WithField("provider", "azure")
+// validateServers checks the health and metrics listeners against each other
+func validateServers(report *Report, config *Config) {
+	if config.Health.Enabled {
+		for _, check := range []struct{ path, value string }{
+			{"health.readiness_path", config.Health.ReadinessPath},
+			{"health.liveness_path", config.Health.LivenessPath},
+		} {
+			if check.value != "" && !strings.HasPrefix(check.value, "/") {
+				report.add(SeverityError, check.path, "absolute_path", fmt.Sprintf("must start with /, got %q", check.value))
+			}
+		}
+	}
+	if config.Metrics.Enabled && config.Metrics.Path != "" && !strings.HasPrefix(config.Metrics.Path, "/") {
+		report.add(SeverityError, "metrics.path", "absolute_path", fmt.Sprintf("must start with /, got %q", config.Metrics.Path))
 	}
 
-	return nil
+	// Metrics share the health listener only when host and port both match;
+	// the same port on a different host cannot be bound twice
+	if config.Health.Enabled && config.Metrics.Enabled &&
+		config.Health.Port != 0 && config.Health.Port == config.Metrics.Port &&
+		config.Health.Host != config.Metrics.Host {
+		report.add(SeverityError, "metrics.host", "port_conflict",
+			fmt.Sprintf("must match health.host (%q) when metrics.port equals health.port (%d)", config.Health.Host, config.Health.Port))
+	}
 }
 
-// formatValidationError formats validator errors into application errors
-func formatValidationError(err error) error {
-	validationErrs, ok := err.(validator.ValidationErrors)
-	if !ok {
-		return errors.Wrap(
-			errors.ErrValidationFailed,
-			err,
-			"validation failed",
-		)
-	}
-
-	if len(validationErrs) > 0 {
-		fieldErr := validationErrs[0]
-		return errors.New(
-			errors.ErrValidationFailed,
-			fmt.Sprintf("validation failed for field '%s'", fieldErr.Field()),
-		).WithFields(map[string]interface{}{
-			"field": fieldErr.Field(),
-			"tag":   fieldErr.Tag(),
-			"value": fieldErr.Value(),
-		})
-	}
-
-	return errors.New(errors.ErrValidationFailed, "validation failed")
+// gcpUsesADC mirrors gcp.UsesADC: ADC applies when forced, or in auto mode when
+// no credentials file is configured
+func gcpUsesADC(config *GCPConfig) bool {
+	switch config.UseADC {
+	case "true":
+		return true
+	case "false":
+		return false
+	default:
+		return config.CredentialsFile == "" && os.Getenv("GOOGLE_APPLICATION_CREDENTIALS") == ""
+	}
 }
 
 // ValidateProvider validates that a provider name is supported
@@ -0,0 +1,447 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+)
+
+// violationKey identifies a violation without its message
+type violationKey struct {
+	Path     string
+	Line     int
+	Rule     string
+	Severity Severity
+}
+
+func keys(violations []Violation) []violationKey {
+	out := make([]violationKey, 0, len(violations))
+	for _, v := range violations {
+		out = append(out, violationKey{Path: v.Path, Line: v.Line, Rule: v.Rule, Severity: v.Severity})
+	}
+	return out
+}
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func TestCheck_Violations(t *testing.T) {
+	t.Setenv("GOOGLE_APPLICATION_CREDENTIALS", "")
+
+	tests := []struct {
+		name   string
+		config string
+		want   []violationKey
+	}{
+		{
+			name: "valid",
+			config: `provider:
+  name: aws
+  cluster_name: my-cluster
+  aws: {}
+`,
+		},
+		{
+			name: "empty file reports the required fields",
+			config: `
+`,
+			want: []violationKey{
+				{Path: "provider.name", Rule: "required", Severity: SeverityError},
+				{Path: "provider.cluster_name", Rule: "required", Severity: SeverityError},
+			},
+		},
+		{
+			name: "every violation is reported, not just the first",
+			config: `log:
+  level: verbose
+  format: xml
+provider:
+  name: azure
+  azure:
+    subscription_id: sub
+`,
+			want: []violationKey{
+				{Path: "log.level", Line: 2, Rule: "oneof", Severity: SeverityError},
+				{Path: "log.format", Line: 3, Rule: "oneof", Severity: SeverityError},
+				{Path: "provider.cluster_name", Line: 4, Rule: "required", Severity: SeverityError},
+				{Path: "provider.azure.tenant_id", Line: 6, Rule: "required", Severity: SeverityError},
+			},
+		},
+		{
+			name: "unsupported provider",
+			config: `provider:
+  name: vsphere
+  cluster_name: my-cluster
+`,
+			want: []violationKey{
+				{Path: "provider.name", Line: 2, Rule: "oneof", Severity: SeverityError},
+			},
+		},
+		{
+			name: "selected provider section missing",
+			config: `provider:
+  name: gcp
+  cluster_name: my-cluster
+`,
+			want: []violationKey{
+				{Path: "provider.gcp", Line: 1, Rule: "required_for_provider", Severity: SeverityError},
+			},
+		},
+		{
+			name: "GCP project required without ADC",
+			config: `provider:
+  name: gcp
+  cluster_name: my-cluster
+  gcp:
+    use_adc: "false"
+`,
+			want: []violationKey{
+				{Path: "provider.gcp.project_id", Line: 4, Rule: "required_without_adc", Severity: SeverityError},
+			},
+		},
+		{
+			name: "GCP project optional with ADC",
+			config: `provider:
+  name: gcp
+  cluster_name: my-cluster
+  gcp:
+    use_adc: "true"
+`,
+		},
+		{
+			name: "invalid ADC mode",
+			config: `provider:
+  name: gcp
+  cluster_name: my-cluster
+  gcp:
+    project_id: my-project
+    use_adc: maybe
+`,
+			want: []violationKey{
+				{Path: "provider.gcp.use_adc", Line: 6, Rule: "oneof", Severity: SeverityError},
+			},
+		},
+		{
+			name: "unknown Azure cloud",
+			config: `provider:
+  name: azure
+  cluster_name: my-cluster
+  azure:
+    subscription_id: sub
+    tenant_id: tenant
+    cloud: germany
+`,
+			want: []violationKey{
+				{Path: "provider.azure.cloud", Line: 7, Rule: "oneof", Severity: SeverityError},
+			},
+		},
+		{
+			name: "sections of other providers are ignored",
+			config: `provider:
+  name: aws
+  cluster_name: my-cluster
+  aws: {}
+  azure:
+    subscription_id: sub
+    tenant_id: tenant
+`,
+			want: []violationKey{
+				{Path: "provider.azure", Line: 5, Rule: "unused_section", Severity: SeverityWarning},
+			},
+		},
+		{
+			name: "unknown fields",
+			config: `provider:
+  name: aws
+  cluster_name: my-cluster
+  regoin: us-east-1
+  aws:
+    role: admin
+tracing: {}
+`,
+			want: []violationKey{
+				{Path: "provider.regoin", Line: 4, Rule: "unknown_field", Severity: SeverityWarning},
+				{Path: "provider.aws.role", Line: 6, Rule: "unknown_field", Severity: SeverityWarning},
+				{Path: "tracing", Line: 7, Rule: "unknown_field", Severity: SeverityWarning},
+			},
+		},
+		{
+			name: "wrong types do not hide other violations",
+			config: `log:
+  level: loud
+provider:
+  name: aws
+  cluster_name: [a, b]
+  timeout: soon
+  aws: {}
+health:
+  port: eighty
+`,
+			want: []violationKey{
+				{Path: "log.level", Line: 2, Rule: "oneof", Severity: SeverityError},
+				{Path: "provider.cluster_name", Line: 5, Rule: "type", Severity: SeverityError},
+				{Path: "provider.cluster_name", Line: 5, Rule: "required", Severity: SeverityError},
+				{Path: "provider.timeout", Line: 6, Rule: "type", Severity: SeverityError},
+				{Path: "health.port", Line: 9, Rule: "type", Severity: SeverityError},
+			},
+		},
+		{
+			name: "server ports and paths",
+			config: `provider:
+  name: aws
+  cluster_name: my-cluster
+  aws: {}
+health:
+  enabled: true
+  port: 70000
+  liveness_path: healthz
+metrics:
+  enabled: true
+  host: 127.0.0.1
+  path: metrics
+`,
+			want: []violationKey{
+				{Path: "health.port", Line: 7, Rule: "max", Severity: SeverityError},
+				{Path: "health.liveness_path", Line: 8, Rule: "absolute_path", Severity: SeverityError},
+				{Path: "metrics.path", Line: 12, Rule: "absolute_path", Severity: SeverityError},
+			},
+		},
+		{
+			name: "metrics on the health port of another host",
+			config: `provider:
+  name: aws
+  cluster_name: my-cluster
+  aws: {}
+health:
+  enabled: true
+  port: 9000
+metrics:
+  enabled: true
+  host: 127.0.0.1
+  port: 9000
+`,
+			want: []violationKey{
+				{Path: "metrics.host", Line: 10, Rule: "port_conflict", Severity: SeverityError},
+			},
+		},
+		{
+			name: "disabled servers are not checked",
+			config: `provider:
+  name: aws
+  cluster_name: my-cluster
+  aws: {}
+health:
+  enabled: false
+  readiness_path: ready
+metrics:
+  enabled: false
+  path: metrics
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeConfig(t, tt.config)
+
+			config, report, err := Check(WithConfigFile(path))
+			require.NoError(t, err)
+			require.NotNil(t, config)
+			assert.Equal(t, path, report.Source)
+
+			if len(tt.want) == 0 {
+				assert.Empty(t, report.Violations)
+				return
+			}
+			assert.ElementsMatch(t, tt.want, keys(report.Violations))
+		})
+	}
+}
+
+func TestCheck_OrdersViolationsByLine(t *testing.T) {
+	path := writeConfig(t, `provider:
+  name: azure
+  cluster_name: my-cluster
+  azure: {}
+log:
+  level: loud
+`)
+
+	_, report, err := Check(WithConfigFile(path))
+	require.NoError(t, err)
+
+	lines := make([]int, 0, len(report.Violations))
+	for _, v := range report.Violations {
+		lines = append(lines, v.Line)
+	}
+	assert.IsNonDecreasing(t, lines)
+}
+
+func TestCheck_UnreadableFiles(t *testing.T) {
+	_, _, err := Check(WithConfigFile(filepath.Join(t.TempDir(), "missing.yaml")))
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, errors.ErrConfigLoadFailed))
+
+	_, _, err = Check(WithConfigFile(writeConfig(t, "provider: [unclosed\n")))
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, errors.ErrConfigInvalid))
+}
+
+func TestLoad_ReportsAllErrors(t *testing.T) {
+	path := writeConfig(t, `log:
+  level: loud
+provider:
+  name: azure
+  azure: {}
+  regoin: us-east-1
+`)
+
+	_, err := Load(WithConfigFile(path))
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, errors.ErrConfigInvalid))
+	assert.Contains(t, err.Error(), "log.level (line 2)")
+	assert.Contains(t, err.Error(), "provider.cluster_name")
+	assert.Contains(t, err.Error(), "provider.azure.subscription_id")
+	assert.Contains(t, err.Error(), "provider.azure.tenant_id")
+	assert.NotContains(t, err.Error(), "regoin", "warnings do not fail Load")
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		wantCode  errors.ErrorCode
+		wantPaths string
+	}{
+		{
+			name: "valid file",
+			content: `provider:
+  name: aws
+  cluster_name: prod-east
+  region: us-east-1
+  aws:
+    account_id: "123456789012"
+`,
+		},
+		{
+			name: "missing required field",
+			content: `provider:
+  name: azure
+  cluster_name: prod-aks
+  azure:
+    subscription_id: 00000000-0000-0000-0000-000000000000
+`,
+			wantCode:  errors.ErrConfigMissingField,
+			wantPaths: "provider.azure.tenant_id",
+		},
+		{
+			name: "invalid enum value",
+			content: `log:
+  format: yaml
+provider:
+  name: aws
+  cluster_name: prod-east
+  aws: {}
+`,
+			wantCode:  errors.ErrConfigInvalid,
+			wantPaths: "log.format",
+		},
+		{
+			name: "missing and invalid fields",
+			content: `log:
+  level: loud
+provider:
+  name: aws
+  aws: {}
+`,
+			wantCode:  errors.ErrConfigInvalid,
+			wantPaths: "log.level, provider.cluster_name",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := Load(WithConfigFile(writeConfig(t, tt.content)))
+			if tt.wantCode == "" {
+				require.NoError(t, err)
+				assert.Equal(t, "prod-east", config.Provider.ClusterName)
+				assert.Equal(t, "123456789012", config.Provider.AWS.AccountID)
+				assert.Equal(t, DefaultConfig().Log, config.Log, "unset sections keep DefaultConfig")
+				return
+			}
+
+			require.Error(t, err)
+			var appErr *errors.Error
+			require.True(t, errors.As(err, &appErr))
+			assert.Equal(t, tt.wantCode, appErr.Code)
+			assert.Equal(t, tt.wantPaths, appErr.Fields["paths"])
+		})
+	}
+}
+
+func TestValidateReport_NegativeDurations(t *testing.T) {
+	config := FromFlags("aws", "my-cluster", "us-east-1", "", "")
+	config.Provider.Timeout = -time.Second
+	config.Provider.AWS = &AWSConfig{TokenDuration: -15 * time.Minute}
+
+	report := ValidateReport(config)
+	assert.ElementsMatch(t, []violationKey{
+		{Path: "provider.timeout", Rule: "min", Severity: SeverityError},
+		{Path: "provider.aws.token_duration", Rule: "min", Severity: SeverityError},
+	}, keys(report.Violations))
+}
+
+func TestReport_Strict(t *testing.T) {
+	report := &Report{}
+	report.add(SeverityWarning, "provider.gcp", "unused_section", "is ignored")
+
+	assert.True(t, report.Valid(false))
+	assert.NoError(t, report.Err(false))
+	assert.False(t, report.Valid(true))
+	require.Error(t, report.Err(true))
+	assert.Contains(t, report.Err(true).Error(), "provider.gcp: is ignored [unused_section]")
+}
+
+func TestValidate(t *testing.T) {
+	assert.True(t, errors.Is(Validate(nil), errors.ErrConfigInvalid))
+
+	config := FromFlags("aws", "my-cluster", "us-east-1", "", "")
+	config.Provider.AWS = &AWSConfig{}
+	assert.NoError(t, Validate(config))
+
+	config.Provider.ClusterName = ""
+	config.Log.Level = "loud"
+	err := Validate(config)
+	require.Error(t, err)
+	var appErr *errors.Error
+	require.True(t, errors.As(err, &appErr))
+	assert.Equal(t, 2, appErr.Fields["errors"])
+}
+
+func TestCheck_UnknownFieldsInProfiles(t *testing.T) {
+	path := writeConfig(t, `provider:
+  name: aws
+  cluster_name: my-cluster
+  aws: {}
+credentials:
+  prod:
+    aws:
+      acount_id: "123456789012"
+`)
+
+	_, report, err := Check(WithConfigFile(path))
+	require.NoError(t, err)
+	assert.Equal(t, []violationKey{
+		{Path: "credentials.prod.aws.acount_id", Line: 8, Rule: "unknown_field", Severity: SeverityWarning},
+	}, keys(report.Violations))
+}
@@ -0,0 +1,88 @@
+package credentials
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+)
+
+// ec2SysVendorFile reports the hypervisor vendor; it reads "Amazon EC2" on EC2 instances
+var ec2SysVendorFile = "/sys/devices/virtual/dmi/id/sys_vendor"
+
+// instanceMetadataFunc fetches credentials from the EC2 instance metadata service or the
+// ECS container credentials endpoint. It is a field on DefaultLoader so tests can replace
+// the SDK call.
+type instanceMetadataFunc func(ctx context.Context, region string) (*AWSCredentials, error)
+
+// DetectAWSInstanceMetadata reports whether the process runs where AWS serves credentials
+// from a metadata endpoint: an ECS task with a container credentials URI, or an EC2
+// instance whose metadata service has not been disabled
+func DetectAWSInstanceMetadata() bool {
+	if os.Getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI") != "" || os.Getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI") != "" {
+		return true
+	}
+	if strings.EqualFold(os.Getenv("AWS_EC2_METADATA_DISABLED"), "true") {
+		return false
+	}
+	data, err := os.ReadFile(ec2SysVendorFile)
+	return err == nil && strings.TrimSpace(string(data)) == "Amazon EC2"
+}
+
+// loadAWSInstanceMetadata builds credentials from the instance or task metadata endpoint,
+// as the last source after files and environment variables
+func (l *DefaultLoader) loadAWSInstanceMetadata(ctx context.Context, region string) (*AWSCredentials, error) {
+	l.logger.Debug("Loading AWS credentials from the instance metadata endpoint",
+		logger.String("region", region),
+	)
+
+	creds, err := l.instanceMetadata(ctx, region)
+	if err != nil {
+		return nil, errors.Wrap(
+			errors.ErrCredentialNotFound,
+			err,
+			"no AWS credentials configured and the instance metadata endpoint returned none",
+		).WithDetail("set AWS credentials in a file or the environment, or run with an instance profile or ECS task role")
+	}
+
+	if region != "" {
+		creds.Region = region
+	}
+	return creds, nil
+}
+
+// sdkInstanceMetadata resolves credentials with the SDK default chain. It is only called
+// when no file or environment credentials exist, so the chain ends at the ECS container
+// endpoint or EC2 IMDS.
+func sdkInstanceMetadata(ctx context.Context, region string) (*AWSCredentials, error) {
+	loadOpts := []func(*awsconfig.LoadOptions) error{awsconfig.WithEC2IMDSRegion()}
+	if region != "" {
+		loadOpts = append(loadOpts, awsconfig.WithRegion(region))
+	}
+
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, loadOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := cfg.Credentials.Retrieve(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	creds := &AWSCredentials{
+		AccessKeyID:     value.AccessKeyID,
+		SecretAccessKey: value.SecretAccessKey,
+		SessionToken:    value.SessionToken,
+		Region:          cfg.Region,
+	}
+	if value.CanExpire {
+		creds.Expiration = value.Expires
+	}
+
+	return creds, nil
+}
@@ -0,0 +1,168 @@
+package credentials
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+)
+
+// fakeInstanceMetadata returns fixed role credentials and records the requested regions
+func fakeInstanceMetadata(calls *[]string) instanceMetadataFunc {
+	return func(ctx context.Context, region string) (*AWSCredentials, error) {
+		*calls = append(*calls, region)
+		return &AWSCredentials{
+			AccessKeyID:     "ASIAINSTANCEPROFILE0",
+			SecretAccessKey: "instanceProfileSecret",
+			SessionToken:    "instanceProfileSessionToken",
+			Region:          "eu-west-1",
+			Expiration:      time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC),
+		}, nil
+	}
+}
+
+func TestLoadAWS_InstanceMetadataFallback(t *testing.T) {
+	credsFile := filepath.Join(t.TempDir(), "credentials")
+	require.NoError(t, os.WriteFile(credsFile, []byte(`[default]
+aws_access_key_id = AKIAFILFILFILFILFIL
+aws_secret_access_key = fileSecretKeyForTestingPurposesOnly
+`), 0600))
+
+	tests := []struct {
+		name          string
+		env           map[string]string
+		opts          AWSCredentialOptions
+		wantAccessKey string
+		wantRegion    string
+		wantCalls     []string
+	}{
+		{
+			name:          "used when nothing else is configured",
+			opts:          AWSCredentialOptions{UseEnvironment: true, UseInstanceMetadata: true},
+			wantAccessKey: "ASIAINSTANCEPROFILE0",
+			wantRegion:    "eu-west-1",
+			wantCalls:     []string{""},
+		},
+		{
+			name:          "configured region wins over the metadata region",
+			opts:          AWSCredentialOptions{UseEnvironment: true, UseInstanceMetadata: true, Region: "us-east-2"},
+			wantAccessKey: "ASIAINSTANCEPROFILE0",
+			wantRegion:    "us-east-2",
+			wantCalls:     []string{"us-east-2"},
+		},
+		{
+			name: "environment credentials win",
+			env: map[string]string{
+				"AWS_ACCESS_KEY_ID":     "AKIAENVENVENVENVENV",
+				"AWS_SECRET_ACCESS_KEY": "envSecretKeyForTestingPurposesOnly",
+			},
+			opts:          AWSCredentialOptions{UseEnvironment: true, UseInstanceMetadata: true},
+			wantAccessKey: "AKIAENVENVENVENVENV",
+		},
+		{
+			name: "credentials file wins over environment and metadata",
+			env: map[string]string{
+				"AWS_ACCESS_KEY_ID":     "AKIAENVENVENVENVENV",
+				"AWS_SECRET_ACCESS_KEY": "envSecretKeyForTestingPurposesOnly",
+			},
+			opts:          AWSCredentialOptions{UseEnvironment: true, UseInstanceMetadata: true, CredentialsFile: credsFile},
+			wantAccessKey: "AKIAFILFILFILFILFIL",
+		},
+		{
+			name:          "explicit keys win",
+			opts:          AWSCredentialOptions{UseInstanceMetadata: true, AccessKeyID: "AKIAEXPLICIT0000000", SecretAccessKey: "explicitSecret"},
+			wantAccessKey: "AKIAEXPLICIT0000000",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{
+				"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN", "AWS_CREDENTIAL_EXPIRATION",
+				"AWS_REGION", "AWS_DEFAULT_REGION", "AWS_CREDENTIALS_FILE", "AWS_CONFIG_FILE", "AWS_PROFILE",
+				"AWS_WEB_IDENTITY_TOKEN_FILE", "AWS_ROLE_ARN",
+			} {
+				t.Setenv(name, "")
+			}
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+
+			var calls []string
+			loader := &DefaultLoader{
+				logger:           logger.Nop(),
+				instanceMetadata: fakeInstanceMetadata(&calls),
+			}
+
+			creds, err := loader.LoadAWS(context.Background(), tt.opts)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantAccessKey, creds.AccessKeyID)
+			assert.Equal(t, tt.wantCalls, calls)
+			if tt.wantRegion != "" {
+				assert.Equal(t, tt.wantRegion, creds.Region)
+			}
+		})
+	}
+}
+
+func TestLoadAWS_InstanceMetadataDisabled(t *testing.T) {
+	for _, name := range []string{"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_CREDENTIALS_FILE", "AWS_CONFIG_FILE"} {
+		t.Setenv(name, "")
+	}
+
+	var calls []string
+	loader := &DefaultLoader{
+		logger:           logger.Nop(),
+		instanceMetadata: fakeInstanceMetadata(&calls),
+	}
+
+	_, err := loader.LoadAWS(context.Background(), AWSCredentialOptions{UseEnvironment: true})
+	require.Error(t, err)
+	assert.Empty(t, calls)
+}
+
+func TestLoadAWS_InstanceMetadataError(t *testing.T) {
+	for _, name := range []string{"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_CREDENTIALS_FILE", "AWS_CONFIG_FILE"} {
+		t.Setenv(name, "")
+	}
+
+	loader := &DefaultLoader{
+		logger: logger.Nop(),
+		instanceMetadata: func(ctx context.Context, region string) (*AWSCredentials, error) {
+			return nil, fmt.Errorf("no EC2 IMDS role found")
+		},
+	}
+
+	_, err := loader.LoadAWS(context.Background(), AWSCredentialOptions{UseEnvironment: true, UseInstanceMetadata: true})
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, errors.ErrCredentialNotFound))
+}
+
+func TestDetectAWSInstanceMetadata(t *testing.T) {
+	vendorFile := filepath.Join(t.TempDir(), "sys_vendor")
+	original := ec2SysVendorFile
+	ec2SysVendorFile = vendorFile
+	t.Cleanup(func() { ec2SysVendorFile = original })
+
+	t.Setenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI", "")
+	t.Setenv("AWS_CONTAINER_CREDENTIALS_FULL_URI", "")
+	t.Setenv("AWS_EC2_METADATA_DISABLED", "")
+	assert.False(t, DetectAWSInstanceMetadata(), "no vendor file and no ECS variables")
+
+	require.NoError(t, os.WriteFile(vendorFile, []byte("Amazon EC2\n"), 0600))
+	assert.True(t, DetectAWSInstanceMetadata())
+
+	t.Setenv("AWS_EC2_METADATA_DISABLED", "true")
+	assert.False(t, DetectAWSInstanceMetadata())
+
+	t.Setenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI", "/v2/credentials/task-role")
+	assert.True(t, DetectAWSInstanceMetadata(), "ECS task credentials do not use IMDS")
+}
@@ -0,0 +1,506 @@
+package credentials
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+)
+
+const (
+	// defaultCredentialProcessTimeout bounds how long a credential_process helper may run
+	defaultCredentialProcessTimeout = 30 * time.Second
+
+	// defaultAssumeRoleRegion is used for STS AssumeRole when no region is configured
+	defaultAssumeRoleRegion = "us-east-1"
+
+	// maxSourceProfileDepth limits source_profile chains
+	maxSourceProfileDepth = 8
+)
+
+// assumeRoleInput holds parameters for an STS AssumeRole call
+type assumeRoleInput struct {
+	Source          *AWSCredentials
+	RoleARN         string
+	RoleSessionName string
+	ExternalID      string
+	Duration        time.Duration
+	Region          string
+}
+
+// assumeRoleFunc exchanges source credentials for temporary role credentials
+type assumeRoleFunc func(ctx context.Context, input assumeRoleInput) (*AWSCredentials, error)
+
+// credentialProcessFunc runs a credential_process command and returns its credentials
+type credentialProcessFunc func(ctx context.Context, command string, timeout time.Duration) (*AWSCredentials, error)
+
+// awsProfileResolver resolves a named profile from AWS shared credentials and config files
+type awsProfileResolver struct {
+	// credentials holds sections from the shared credentials file
+	credentials map[string]map[string]string
+
+	// config holds sections from the shared config file ("[profile foo]" normalized to "foo")
+	config map[string]map[string]string
+
+	assumeRole     assumeRoleFunc
+	runProcess     credentialProcessFunc
+	processTimeout time.Duration
+}
+
+// resolve returns credentials for the given profile
+func (r *awsProfileResolver) resolve(ctx context.Context, profile string) (*AWSCredentials, error) {
+	return r.resolveProfile(ctx, profile, nil)
+}
+
+func (r *awsProfileResolver) resolveProfile(ctx context.Context, profile string, chain []string) (*AWSCredentials, error) {
+	for _, visited := range chain {
+		if visited == profile {
+			return nil, errors.New(
+				errors.ErrCredentialMalformed,
+				"circular source_profile reference in AWS config",
+			).WithField("profile_chain", append(chain, profile))
+		}
+	}
+	if len(chain) >= maxSourceProfileDepth {
+		return nil, errors.New(
+			errors.ErrCredentialMalformed,
+			"AWS source_profile chain is too deep",
+		).WithField("profile_chain", append(chain, profile))
+	}
+	chain = append(chain, profile)
+
+	section, found := r.section(profile)
+	if !found {
+		return nil, errors.New(
+			errors.ErrCredentialMalformed,
+			fmt.Sprintf("profile '%s' not found in AWS credentials or config file", profile),
+		).WithField("profile", profile)
+	}
+
+	region := section["region"]
+
+	if roleARN := section["role_arn"]; roleARN != "" {
+		sourceProfile := section["source_profile"]
+		if sourceProfile == "" {
+			return nil, errors.New(
+				errors.ErrCredentialMalformed,
+				fmt.Sprintf("profile '%s' sets role_arn without source_profile", profile),
+			).WithField("profile", profile)
+		}
+
+		var source *AWSCredentials
+		var err error
+		if sourceProfile == profile {
+			// A profile may use its own static keys as the source for role assumption
+			source, err = staticAWSCredentials(profile, section)
+		} else {
+			source, err = r.resolveProfile(ctx, sourceProfile, chain)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if region == "" {
+			region = source.Region
+		}
+
+		return r.assumeProfileRole(ctx, profile, section, source, region)
+	}
+
+	if command := section["credential_process"]; command != "" {
+		creds, err := r.runProcess(ctx, command, r.processTimeout)
+		if err != nil {
+			return nil, err
+		}
+		creds.Region = region
+		return creds, nil
+	}
+
+	creds, err := staticAWSCredentials(profile, section)
+	if err != nil {
+		return nil, err
+	}
+	return creds, nil
+}
+
+// section merges a profile from the config and credentials files.
+// Keys from the credentials file take precedence.
+func (r *awsProfileResolver) section(profile string) (map[string]string, bool) {
+	configSection, inConfig := awsINISection(r.config, profile)
+	credsSection, inCreds := awsINISection(r.credentials, profile)
+	if !inConfig && !inCreds {
+		return nil, false
+	}
+
+	merged := make(map[string]string, len(configSection)+len(credsSection))
+	for k, v := range configSection {
+		merged[k] = v
+	}
+	for k, v := range credsSection {
+		merged[k] = v
+	}
+	return merged, true
+}
+
+// assumeProfileRole assumes the role configured on a profile using the source credentials
+func (r *awsProfileResolver) assumeProfileRole(ctx context.Context, profile string, section map[string]string, source *AWSCredentials, region string) (*AWSCredentials, error) {
+	input := assumeRoleInput{
+		Source:          source,
+		RoleARN:         section["role_arn"],
+		RoleSessionName: section["role_session_name"],
+		ExternalID:      section["external_id"],
+		Region:          region,
+	}
+	if input.RoleSessionName == "" {
+		input.RoleSessionName = fmt.Sprintf("hyperfleet-credential-provider-%d", time.Now().Unix())
+	}
+	if raw := section["duration_seconds"]; raw != "" {
+		seconds, err := strconv.Atoi(raw)
+		if err != nil || seconds <= 0 {
+			return nil, errors.New(
+				errors.ErrCredentialMalformed,
+				fmt.Sprintf("profile '%s' has invalid duration_seconds", profile),
+			).WithField("duration_seconds", raw)
+		}
+		input.Duration = time.Duration(seconds) * time.Second
+	}
+
+	creds, err := r.assumeRole(ctx, input)
+	if err != nil {
+		return nil, errors.Wrap(
+			errors.ErrCredentialLoadFailed,
+			err,
+			"failed to assume role for AWS profile",
+		).WithFields(map[string]interface{}{
+			"profile":  profile,
+			"role_arn": input.RoleARN,
+		})
+	}
+	creds.Region = region
+	return creds, nil
+}
+
+// staticAWSCredentials extracts static access keys from a profile section
+func staticAWSCredentials(profile string, section map[string]string) (*AWSCredentials, error) {
+	creds := &AWSCredentials{
+		AccessKeyID:     section["aws_access_key_id"],
+		SecretAccessKey: section["aws_secret_access_key"],
+		SessionToken:    section["aws_session_token"],
+		Region:          section["region"],
+	}
+
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		return nil, errors.New(
+			errors.ErrCredentialMalformed,
+			fmt.Sprintf("profile '%s' missing required credentials (aws_access_key_id and aws_secret_access_key)", profile),
+		).WithField("profile", profile)
+	}
+	// Written next to exported session credentials so expiry is known without calling STS
+	if raw := section["aws_session_expiration"]; raw != "" {
+		expiration, err := parseAWSExpiration(raw, fmt.Sprintf("aws_session_expiration in profile '%s'", profile))
+		if err != nil {
+			return nil, err
+		}
+		creds.Expiration = expiration
+	}
+
+	return creds, nil
+}
+
+// credentialProcessOutput is the JSON document printed by a credential_process helper
+type credentialProcessOutput struct {
+	Version         int    `json:"Version"`
+	AccessKeyID     string `json:"AccessKeyId"`
+	SecretAccessKey string `json:"SecretAccessKey"`
+	SessionToken    string `json:"SessionToken"`
+	Expiration      string `json:"Expiration"`
+}
+
+// runCredentialProcess executes a credential_process command and parses its output
+func runCredentialProcess(ctx context.Context, command string, timeout time.Duration) (*AWSCredentials, error) {
+	if timeout <= 0 {
+		timeout = defaultCredentialProcessTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd.exe", "/C", command) // #nosec G204 -- command comes from the user's AWS config
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command) // #nosec G204 -- command comes from the user's AWS config
+	}
+
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+	// Don't wait on grandchildren holding stdout open once the helper is killed
+	cmd.WaitDelay = time.Second
+
+	if err := cmd.Run(); err != nil {
+		exitCode := -1
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			exitCode = exitErr.ExitCode()
+		}
+
+		appErr := errors.Wrap(
+			errors.ErrCredentialLoadFailed,
+			err,
+			"credential_process failed",
+		).WithField("exit_code", exitCode)
+		if ctx.Err() == context.DeadlineExceeded {
+			appErr = appErr.WithDetail(fmt.Sprintf("credential_process timed out after %s", timeout))
+		}
+		return nil, appErr
+	}
+
+	var output credentialProcessOutput
+	if err := json.Unmarshal(stdout.Bytes(), &output); err != nil {
+		return nil, errors.Wrap(
+			errors.ErrCredentialMalformed,
+			err,
+			"failed to parse credential_process output",
+		)
+	}
+
+	if output.Version != 1 {
+		return nil, errors.New(
+			errors.ErrCredentialMalformed,
+			"unsupported credential_process output version",
+		).WithField("version", output.Version).
+			WithDetail("expected Version 1")
+	}
+
+	if output.AccessKeyID == "" || output.SecretAccessKey == "" {
+		return nil, errors.New(
+			errors.ErrCredentialMalformed,
+			"credential_process output missing AccessKeyId or SecretAccessKey",
+		)
+	}
+
+	creds := &AWSCredentials{
+		AccessKeyID:     output.AccessKeyID,
+		SecretAccessKey: output.SecretAccessKey,
+		SessionToken:    output.SessionToken,
+	}
+
+	if output.Expiration != "" {
+		expiration, err := time.Parse(time.RFC3339, output.Expiration)
+		if err != nil {
+			return nil, errors.Wrap(
+				errors.ErrCredentialMalformed,
+				err,
+				"invalid Expiration in credential_process output",
+			)
+		}
+		creds.Expiration = expiration
+	}
+
+	return creds, nil
+}
+
+// stsAssumeRole assumes an IAM role using the AWS STS API
+func stsAssumeRole(ctx context.Context, input assumeRoleInput) (*AWSCredentials, error) {
+	region := input.Region
+	if region == "" {
+		region = defaultAssumeRoleRegion
+	}
+
+	source := input.Source
+	client := sts.New(sts.Options{
+		Region: region,
+		Credentials: aws.CredentialsProviderFunc(func(ctx context.Context) (aws.Credentials, error) {
+			return aws.Credentials{
+				AccessKeyID:     source.AccessKeyID,
+				SecretAccessKey: source.SecretAccessKey,
+				SessionToken:    source.SessionToken,
+			}, nil
+		}),
+	})
+
+	stsInput := &sts.AssumeRoleInput{
+		RoleArn:         aws.String(input.RoleARN),
+		RoleSessionName: aws.String(input.RoleSessionName),
+	}
+	if input.ExternalID != "" {
+		stsInput.ExternalId = aws.String(input.ExternalID)
+	}
+	if input.Duration > 0 {
+		stsInput.DurationSeconds = aws.Int32(int32(input.Duration.Seconds()))
+	}
+
+	output, err := client.AssumeRole(ctx, stsInput)
+	if err != nil {
+		return nil, err
+	}
+	if output.Credentials == nil {
+		return nil, fmt.Errorf("AssumeRole returned no credentials")
+	}
+
+	creds := &AWSCredentials{
+		AccessKeyID:     aws.ToString(output.Credentials.AccessKeyId),
+		SecretAccessKey: aws.ToString(output.Credentials.SecretAccessKey),
+		SessionToken:    aws.ToString(output.Credentials.SessionToken),
+	}
+	if output.Credentials.Expiration != nil {
+		creds.Expiration = *output.Credentials.Expiration
+	}
+
+	return creds, nil
+}
+
+// parseAWSINI parses an AWS shared credentials or config file into sections keyed by profile name.
+// In config files, "[profile foo]" headers are normalized to "foo"; other non-default sections
+// (such as sso-session) are ignored. A repeated section, matched without regard to case,
+// replaces the earlier one under the name it is last written with.
+func parseAWSINI(content string, isConfig bool) map[string]map[string]string {
+	sections := make(map[string]map[string]string)
+	// names maps the lower-case name of each section to the name it is stored under
+	names := make(map[string]string)
+	var current map[string]string
+
+	for _, rawLine := range splitLines(content) {
+		line := trimSpace(rawLine)
+		if line == "" || hasPrefix(line, "#") || hasPrefix(line, ";") {
+			continue
+		}
+
+		if hasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			name := trimSpace(line[1 : len(line)-1])
+			if isConfig && name != "default" {
+				if !hasPrefix(name, "profile ") {
+					current = nil
+					continue
+				}
+				name = trimSpace(strings.TrimPrefix(name, "profile "))
+			}
+			if previous, exists := names[strings.ToLower(name)]; exists {
+				delete(sections, previous)
+			}
+			names[strings.ToLower(name)] = name
+			current = make(map[string]string)
+			sections[name] = current
+			continue
+		}
+
+		// Indented lines are nested values (e.g. "s3 =" sub-sections) which we don't use
+		if current == nil || hasPrefix(rawLine, " ") || hasPrefix(rawLine, "\t") {
+			continue
+		}
+
+		parts := splitKeyValue(line)
+		if len(parts) != 2 {
+			continue
+		}
+
+		key := strings.ToLower(trimSpace(parts[0]))
+		current[key] = awsINIValue(parts[1])
+	}
+
+	return sections
+}
+
+// awsINIValue returns the value of an INI key without an inline comment or the quotes
+// around the whole value. A # or ; only starts a comment after whitespace and outside a
+// leading quoted string, so secrets containing them are kept whole, and a command such
+// as "/opt/helper" --flag keeps its quotes.
+func awsINIValue(raw string) string {
+	value := trimSpace(raw)
+	start := 1
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') {
+		if end := strings.IndexByte(value[1:], value[0]); end >= 0 {
+			start = end + 2
+		}
+	}
+	for i := start; i < len(value); i++ {
+		if (value[i] == '#' || value[i] == ';') && (value[i-1] == ' ' || value[i-1] == '\t') {
+			value = trimSpace(value[:i])
+			break
+		}
+	}
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
+// awsINISection returns the section of profile, matching its name without regard to case
+// when no section has the exact name
+func awsINISection(sections map[string]map[string]string, profile string) (map[string]string, bool) {
+	if section, found := sections[profile]; found {
+		return section, true
+	}
+	for name, section := range sections {
+		if strings.EqualFold(name, profile) {
+			return section, true
+		}
+	}
+	return nil, false
+}
+
+// defaultSharedAWSFiles returns the default ~/.aws credentials and config file paths
+func defaultSharedAWSFiles() (string, string) {
+	credentialsFile := os.Getenv("AWS_SHARED_CREDENTIALS_FILE")
+	configFile := os.Getenv("AWS_CONFIG_FILE")
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return credentialsFile, configFile
+	}
+	if credentialsFile == "" {
+		credentialsFile = filepath.Join(home, ".aws", "credentials")
+	}
+	if configFile == "" {
+		configFile = filepath.Join(home, ".aws", "config")
+	}
+	return credentialsFile, configFile
+}
+
+// AWSProfiles lists the profile names defined in the AWS shared credentials and config
+// files, sorted. When both paths are empty the shared files under ~/.aws are read.
+// Missing files are skipped.
+func AWSProfiles(credentialsFile, configFile string) ([]string, error) {
+	if credentialsFile == "" && configFile == "" {
+		credentialsFile, configFile = defaultSharedAWSFiles()
+	}
+
+	seen := make(map[string]bool)
+	for _, file := range []struct {
+		path     string
+		isConfig bool
+	}{{credentialsFile, false}, {configFile, true}} {
+		if file.path == "" {
+			continue
+		}
+		data, err := os.ReadFile(file.path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read AWS shared file %s: %w", file.path, err)
+		}
+		for name := range parseAWSINI(string(data), file.isConfig) {
+			seen[name] = true
+		}
+	}
+
+	profiles := make([]string, 0, len(seen))
+	for name := range seen {
+		profiles = append(profiles, name)
+	}
+	sort.Strings(profiles)
+	return profiles, nil
+}
@@ -0,0 +1,107 @@
+package credentials
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+)
+
+// webIdentityInput describes an AssumeRoleWithWebIdentity call
+type webIdentityInput struct {
+	RoleARN         string
+	TokenFile       string
+	RoleSessionName string
+	Region          string
+}
+
+// webIdentityFunc exchanges a web identity token for role credentials.
+// It is a field on DefaultLoader so tests can replace the STS call.
+type webIdentityFunc func(ctx context.Context, input webIdentityInput) (*AWSCredentials, error)
+
+// loadAWSWebIdentity builds credentials from an OIDC web identity token,
+// as issued to GitHub Actions or EKS service accounts
+func (l *DefaultLoader) loadAWSWebIdentity(ctx context.Context, tokenFile, roleARN, region string) (*AWSCredentials, error) {
+	if roleARN == "" {
+		return nil, errors.New(
+			errors.ErrCredentialMalformed,
+			"web identity token file is set but no role ARN was provided",
+		).WithDetail("set AWS_ROLE_ARN or AWSCredentialOptions.RoleARN")
+	}
+
+	if !fileExists(tokenFile) {
+		return nil, errors.New(
+			errors.ErrCredentialNotFound,
+			"web identity token file not found",
+		).WithField("path", redactPath(tokenFile))
+	}
+
+	sessionName := os.Getenv("AWS_ROLE_SESSION_NAME")
+	if sessionName == "" {
+		sessionName = fmt.Sprintf("hyperfleet-credential-provider-%d", time.Now().Unix())
+	}
+
+	l.logger.Debug("Assuming AWS role with web identity",
+		logger.String("role_arn", roleARN),
+		logger.String("token_file", redactPath(tokenFile)),
+	)
+
+	creds, err := l.webIdentity(ctx, webIdentityInput{
+		RoleARN:         roleARN,
+		TokenFile:       tokenFile,
+		RoleSessionName: sessionName,
+		Region:          region,
+	})
+	if err != nil {
+		return nil, errors.Wrap(
+			errors.ErrCredentialLoadFailed,
+			err,
+			"failed to assume role with web identity",
+		).WithField("role_arn", roleARN)
+	}
+
+	creds.Region = region
+	return creds, nil
+}
+
+// stsWebIdentity exchanges the token via stscreds.NewWebIdentityRoleProvider
+func stsWebIdentity(ctx context.Context, input webIdentityInput) (*AWSCredentials, error) {
+	region := input.Region
+	if region == "" {
+		region = defaultAssumeRoleRegion
+	}
+
+	// AssumeRoleWithWebIdentity is authenticated by the token itself, not by AWS credentials
+	client := sts.New(sts.Options{Region: region})
+
+	provider := stscreds.NewWebIdentityRoleProvider(
+		client,
+		input.RoleARN,
+		stscreds.IdentityTokenFile(input.TokenFile),
+		func(o *stscreds.WebIdentityRoleOptions) {
+			o.RoleSessionName = input.RoleSessionName
+		},
+	)
+
+	value, err := provider.Retrieve(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	creds := &AWSCredentials{
+		AccessKeyID:     value.AccessKeyID,
+		SecretAccessKey: value.SecretAccessKey,
+		SessionToken:    value.SessionToken,
+	}
+	if value.CanExpire {
+		creds.Expiration = value.Expires
+	}
+
+	return creds, nil
+}
@@ -0,0 +1,179 @@
+package credentials
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+)
+
+// fakeWebIdentity returns fixed role credentials and records the inputs
+func fakeWebIdentity(calls *[]webIdentityInput) webIdentityFunc {
+	return func(ctx context.Context, input webIdentityInput) (*AWSCredentials, error) {
+		*calls = append(*calls, input)
+		return &AWSCredentials{
+			AccessKeyID:     "ASIAWEBIDENTITY00000",
+			SecretAccessKey: "webIdentitySecret",
+			SessionToken:    "webIdentitySessionToken",
+			Expiration:      time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC),
+		}, nil
+	}
+}
+
+func TestLoadAWS_WebIdentity(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("oidc-token"), 0600))
+
+	tests := []struct {
+		name          string
+		env           map[string]string
+		opts          AWSCredentialOptions
+		wantAccessKey string
+		wantRegion    string
+		wantCall      *webIdentityInput
+		wantErrCode   errors.ErrorCode
+	}{
+		{
+			name: "web identity selected from environment",
+			env: map[string]string{
+				"AWS_WEB_IDENTITY_TOKEN_FILE": tokenFile,
+				"AWS_ROLE_ARN":                "arn:aws:iam::123456789012:role/github-actions",
+				"AWS_ROLE_SESSION_NAME":       "gha-run-42",
+				"AWS_REGION":                  "us-west-2",
+				"AWS_ACCESS_KEY_ID":           "AKIASTATICSTATIC0000",
+				"AWS_SECRET_ACCESS_KEY":       "staticSecret",
+			},
+			opts:          AWSCredentialOptions{UseEnvironment: true},
+			wantAccessKey: "ASIAWEBIDENTITY00000",
+			wantRegion:    "us-west-2",
+			wantCall: &webIdentityInput{
+				RoleARN:         "arn:aws:iam::123456789012:role/github-actions",
+				TokenFile:       tokenFile,
+				RoleSessionName: "gha-run-42",
+				Region:          "us-west-2",
+			},
+		},
+		{
+			name: "static environment used without role ARN",
+			env: map[string]string{
+				"AWS_WEB_IDENTITY_TOKEN_FILE": tokenFile,
+				"AWS_ACCESS_KEY_ID":           "AKIASTATICSTATIC0000",
+				"AWS_SECRET_ACCESS_KEY":       "staticSecret",
+			},
+			opts:          AWSCredentialOptions{UseEnvironment: true},
+			wantAccessKey: "AKIASTATICSTATIC0000",
+		},
+		{
+			name: "static environment used without token file",
+			env: map[string]string{
+				"AWS_ROLE_ARN":          "arn:aws:iam::123456789012:role/github-actions",
+				"AWS_ACCESS_KEY_ID":     "AKIASTATICSTATIC0000",
+				"AWS_SECRET_ACCESS_KEY": "staticSecret",
+			},
+			opts:          AWSCredentialOptions{UseEnvironment: true},
+			wantAccessKey: "AKIASTATICSTATIC0000",
+		},
+		{
+			name: "environment web identity ignored when UseEnvironment is false",
+			env: map[string]string{
+				"AWS_WEB_IDENTITY_TOKEN_FILE": tokenFile,
+				"AWS_ROLE_ARN":                "arn:aws:iam::123456789012:role/github-actions",
+			},
+			opts: AWSCredentialOptions{
+				AccessKeyID:     "AKIAEXPLICIT00000000",
+				SecretAccessKey: "explicitSecret",
+			},
+			wantAccessKey: "AKIAEXPLICIT00000000",
+		},
+		{
+			name: "explicit token file and role ARN",
+			opts: AWSCredentialOptions{
+				WebIdentityTokenFile: tokenFile,
+				RoleARN:              "arn:aws:iam::123456789012:role/explicit",
+				Region:               "eu-west-1",
+			},
+			wantAccessKey: "ASIAWEBIDENTITY00000",
+			wantRegion:    "eu-west-1",
+			wantCall: &webIdentityInput{
+				RoleARN:   "arn:aws:iam::123456789012:role/explicit",
+				TokenFile: tokenFile,
+				Region:    "eu-west-1",
+			},
+		},
+		{
+			name: "explicit token file without role ARN",
+			opts: AWSCredentialOptions{
+				WebIdentityTokenFile: tokenFile,
+			},
+			wantErrCode: errors.ErrCredentialMalformed,
+		},
+		{
+			name: "explicit token file that does not exist",
+			opts: AWSCredentialOptions{
+				WebIdentityTokenFile: filepath.Join(t.TempDir(), "missing"),
+				RoleARN:              "arn:aws:iam::123456789012:role/explicit",
+			},
+			wantErrCode: errors.ErrCredentialNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{
+				"AWS_WEB_IDENTITY_TOKEN_FILE", "AWS_ROLE_ARN", "AWS_ROLE_SESSION_NAME",
+				"AWS_REGION", "AWS_DEFAULT_REGION", "AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY",
+				"AWS_SESSION_TOKEN", "AWS_CREDENTIALS_FILE", "AWS_CONFIG_FILE", "AWS_PROFILE",
+			} {
+				t.Setenv(name, "")
+			}
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+
+			var calls []webIdentityInput
+			loader := &DefaultLoader{
+				logger:      logger.Nop(),
+				webIdentity: fakeWebIdentity(&calls),
+			}
+
+			creds, err := loader.LoadAWS(context.Background(), tt.opts)
+			if tt.wantErrCode != "" {
+				require.Error(t, err)
+				assert.True(t, errors.Is(err, tt.wantErrCode), "expected error code %s, got %v", tt.wantErrCode, err)
+				assert.Empty(t, calls)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantAccessKey, creds.AccessKeyID)
+			if tt.wantRegion != "" {
+				assert.Equal(t, tt.wantRegion, creds.Region)
+			}
+
+			if tt.wantCall == nil {
+				assert.Empty(t, calls, "web identity provider must not be used")
+				return
+			}
+
+			require.Len(t, calls, 1)
+			call := calls[0]
+			assert.Equal(t, tt.wantCall.RoleARN, call.RoleARN)
+			assert.Equal(t, tt.wantCall.TokenFile, call.TokenFile)
+			assert.Equal(t, tt.wantCall.Region, call.Region)
+			if tt.wantCall.RoleSessionName != "" {
+				assert.Equal(t, tt.wantCall.RoleSessionName, call.RoleSessionName)
+			} else {
+				assert.NotEmpty(t, call.RoleSessionName)
+			}
+			assert.Equal(t, "webIdentitySessionToken", creds.SessionToken)
+			assert.False(t, creds.Expiration.IsZero())
+		})
+	}
+}
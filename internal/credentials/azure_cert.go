@@ -0,0 +1,91 @@
+package credentials
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"fmt"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+)
+
+// azureCertificateWarnWindow is the remaining validity under which a client certificate
+// is logged as expiring soon
+const azureCertificateWarnWindow = 30 * 24 * time.Hour
+
+// ParseAzureCertificate parses the PEM or PKCS#12 client certificate of a service
+// principal. It returns the certificate chain and the private key; password decrypts a
+// PKCS#12 file and is empty for PEM.
+func ParseAzureCertificate(data []byte, password string) ([]*x509.Certificate, crypto.PrivateKey, error) {
+	var pass []byte
+	if password != "" {
+		pass = []byte(password)
+	}
+	return azidentity.ParseCertificates(data, pass)
+}
+
+// loadAzureCertificate reads the client certificate at path and checks that it holds a
+// certificate and its private key and has not expired. A certificate expiring within
+// azureCertificateWarnWindow is logged.
+func (l *DefaultLoader) loadAzureCertificate(ctx context.Context, path, password string) (string, error) {
+	if err := l.checkFilePermissions(path, "Azure client certificate"); err != nil {
+		return "", err
+	}
+
+	data, err := l.readCredentialsFile(ctx, path, "Azure client certificate")
+	if err != nil {
+		return "", err
+	}
+	if err := verifySHA256(path, "Azure client certificate", data, l.expectedSHA256); err != nil {
+		return "", err
+	}
+
+	certs, key, err := ParseAzureCertificate(data, password)
+	if err != nil {
+		return "", errors.Wrap(
+			errors.ErrCredentialMalformed,
+			err,
+			"failed to parse Azure client certificate",
+		).WithField("path", redactPath(path)).
+			WithDetail("expected a PEM file with the certificate and an unencrypted private key, or a PKCS#12 file and its password")
+	}
+
+	cert := signingCertificate(certs, key)
+	remaining := time.Until(cert.NotAfter)
+	if remaining <= 0 {
+		return "", errors.New(
+			errors.ErrCredentialExpired,
+			"Azure client certificate expired",
+		).WithFields(map[string]interface{}{
+			"path":       redactPath(path),
+			"expired_at": cert.NotAfter.UTC().Format(time.RFC3339),
+		}).WithDetail("renew the certificate and upload it to the app registration")
+	}
+	if remaining < azureCertificateWarnWindow {
+		l.logger.Warn("Azure client certificate expires soon",
+			logger.String("path", redactPath(path)),
+			logger.String("expires_at", cert.NotAfter.UTC().Format(time.RFC3339)),
+			logger.String("expires_in", fmt.Sprintf("%dd", int(remaining.Hours()/24))),
+		)
+	}
+
+	return string(data), nil
+}
+
+// signingCertificate returns the certificate of certs whose public key is that of key,
+// or the first one
+func signingCertificate(certs []*x509.Certificate, key crypto.PrivateKey) *x509.Certificate {
+	if signer, ok := key.(crypto.Signer); ok {
+		public, ok := signer.Public().(interface{ Equal(crypto.PublicKey) bool })
+		for _, cert := range certs {
+			if ok && public.Equal(cert.PublicKey) {
+				return cert
+			}
+		}
+	}
+	return certs[0]
+}
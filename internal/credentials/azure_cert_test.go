@@ -0,0 +1,186 @@
+package credentials
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+)
+
+// azurePFXPassword is the password of testdata/azure-sp.pfx, a self-signed certificate
+// valid until 2125
+const azurePFXPassword = "test-password"
+
+// writeAzureCertificate writes a self-signed PEM certificate and its private key that
+// expire at notAfter, and returns the file path
+func writeAzureCertificate(t *testing.T, notAfter time.Time) string {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "hyperfleet-test-sp"},
+		NotBefore:    notAfter.Add(-365 * 24 * time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	data = append(data, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})...)
+	path := filepath.Join(t.TempDir(), "sp.pem")
+	require.NoError(t, os.WriteFile(path, data, 0600))
+	return path
+}
+
+// writeAzureCertificateCredentials writes an Azure credentials file of a certificate-based
+// service principal and returns its path
+func writeAzureCertificateCredentials(t *testing.T, certPath, password string) string {
+	t.Helper()
+
+	content := fmt.Sprintf(`{
+		"client_id": "11111111-1111-1111-1111-111111111111",
+		"tenant_id": "22222222-2222-2222-2222-222222222222",
+		"client_certificate_path": %q,
+		"client_certificate_password": %q,
+		"send_certificate_chain": true
+	}`, certPath, password)
+	path := filepath.Join(t.TempDir(), "azure.json")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func TestLoadAzure_ClientCertificate(t *testing.T) {
+	tests := []struct {
+		name     string
+		certPath func(t *testing.T) string
+		password string
+	}{
+		{
+			name:     "PEM",
+			certPath: func(t *testing.T) string { return writeAzureCertificate(t, time.Now().Add(365*24*time.Hour)) },
+		},
+		{
+			name:     "PFX",
+			certPath: func(t *testing.T) string { return filepath.Join("testdata", "azure-sp.pfx") },
+			password: azurePFXPassword,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			certPath := tt.certPath(t)
+			loader := NewLoader(logger.Nop())
+
+			creds, err := loader.LoadAzure(context.Background(), AzureCredentialOptions{
+				CredentialsFile: writeAzureCertificateCredentials(t, certPath, tt.password),
+			})
+			require.NoError(t, err)
+			assert.Empty(t, creds.ClientSecret)
+			assert.Equal(t, certPath, creds.ClientCertificatePath)
+			assert.Equal(t, tt.password, creds.ClientCertificatePassword)
+			assert.True(t, creds.SendCertificateChain)
+
+			certs, key, err := ParseAzureCertificate([]byte(creds.ClientCertificate), creds.ClientCertificatePassword)
+			require.NoError(t, err)
+			assert.Equal(t, "hyperfleet-test-sp", certs[0].Subject.CommonName)
+			assert.NotNil(t, key)
+		})
+	}
+}
+
+func TestLoadAzure_ClientCertificateFromEnvironment(t *testing.T) {
+	certPath := writeAzureCertificate(t, time.Now().Add(365*24*time.Hour))
+	t.Setenv("AZURE_CREDENTIALS_FILE", "")
+	t.Setenv("AZURE_CLIENT_ID", "33333333-3333-3333-3333-333333333333")
+	t.Setenv("AZURE_CLIENT_SECRET", "")
+	t.Setenv("AZURE_TENANT_ID", "44444444-4444-4444-4444-444444444444")
+	t.Setenv("AZURE_CLIENT_CERTIFICATE_PATH", certPath)
+	t.Setenv("AZURE_CLIENT_SEND_CERTIFICATE_CHAIN", "1")
+
+	creds, err := NewLoader(logger.Nop()).LoadAzure(context.Background(), AzureCredentialOptions{UseEnvironment: true})
+	require.NoError(t, err)
+	assert.Equal(t, certPath, creds.ClientCertificatePath)
+	assert.NotEmpty(t, creds.ClientCertificate)
+	assert.True(t, creds.SendCertificateChain)
+}
+
+func TestLoadAzure_ClientCertificateExpiry(t *testing.T) {
+	t.Run("expired", func(t *testing.T) {
+		certPath := writeAzureCertificate(t, time.Now().Add(-time.Hour))
+
+		_, err := NewLoader(logger.Nop()).LoadAzure(context.Background(), AzureCredentialOptions{
+			CredentialsFile: writeAzureCertificateCredentials(t, certPath, ""),
+		})
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, errors.ErrCredentialExpired))
+		assert.Contains(t, err.Error(), "Azure client certificate expired")
+	})
+
+	t.Run("expires within 30 days", func(t *testing.T) {
+		certPath := writeAzureCertificate(t, time.Now().Add(10*24*time.Hour+time.Hour))
+		log := &warnRecorder{Logger: logger.Nop()}
+
+		_, err := NewLoader(log).LoadAzure(context.Background(), AzureCredentialOptions{
+			CredentialsFile: writeAzureCertificateCredentials(t, certPath, ""),
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{"Azure client certificate expires soon"}, log.warnings)
+		assert.Contains(t, log.fields[0], logger.String("expires_in", "10d"))
+	})
+
+	t.Run("expires later", func(t *testing.T) {
+		certPath := writeAzureCertificate(t, time.Now().Add(90*24*time.Hour))
+		log := &warnRecorder{Logger: logger.Nop()}
+
+		_, err := NewLoader(log).LoadAzure(context.Background(), AzureCredentialOptions{
+			CredentialsFile: writeAzureCertificateCredentials(t, certPath, ""),
+		})
+		require.NoError(t, err)
+		assert.Empty(t, log.warnings)
+	})
+}
+
+func TestLoadAzure_ClientCertificateInvalid(t *testing.T) {
+	notCertificate := filepath.Join(t.TempDir(), "sp.pem")
+	require.NoError(t, os.WriteFile(notCertificate, []byte("not a certificate"), 0600))
+
+	tests := []struct {
+		name     string
+		certPath string
+		password string
+		wantCode errors.ErrorCode
+	}{
+		{name: "not a certificate", certPath: notCertificate, wantCode: errors.ErrCredentialMalformed},
+		{name: "wrong PFX password", certPath: filepath.Join("testdata", "azure-sp.pfx"), password: "wrong", wantCode: errors.ErrCredentialMalformed},
+		{name: "missing file", certPath: filepath.Join(t.TempDir(), "missing.pem"), wantCode: errors.ErrCredentialLoadFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewLoader(logger.Nop()).LoadAzure(context.Background(), AzureCredentialOptions{
+				CredentialsFile: writeAzureCertificateCredentials(t, tt.certPath, tt.password),
+			})
+			require.Error(t, err)
+			assert.Equal(t, tt.wantCode, errors.GetCode(err), err.Error())
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package credentials
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+)
+
+// LoadDigitalOcean loads a DigitalOcean API token from a file or DIGITALOCEAN_TOKEN
+func (l *DefaultLoader) LoadDigitalOcean(ctx context.Context, opts DigitalOceanCredentialOptions) (*DigitalOceanCredentials, error) {
+	creds := &DigitalOceanCredentials{}
+
+	credentialsFile := opts.CredentialsFile
+	if credentialsFile == "" {
+		credentialsFile = os.Getenv("DIGITALOCEAN_CREDENTIALS_FILE")
+	}
+
+	if credentialsFile != "" {
+		token, err := l.loadDigitalOceanTokenFile(ctx, credentialsFile)
+		if err != nil {
+			return nil, err
+		}
+		creds.Token = token
+	} else if opts.UseEnvironment {
+		creds.Token = os.Getenv("DIGITALOCEAN_TOKEN")
+		if creds.Token == "" {
+			// doctl reads DIGITALOCEAN_ACCESS_TOKEN
+			creds.Token = os.Getenv("DIGITALOCEAN_ACCESS_TOKEN")
+		}
+	}
+
+	if err := l.validateDigitalOceanCredentials(creds); err != nil {
+		return nil, err
+	}
+
+	l.logger.Debug("DigitalOcean credentials loaded",
+		logger.Bool("from_file", credentialsFile != ""),
+	)
+
+	return creds, nil
+}
+
+// loadDigitalOceanTokenFile reads an API token from a file holding only the token,
+// or from a doctl config file (access-token: ...)
+func (l *DefaultLoader) loadDigitalOceanTokenFile(ctx context.Context, path string) (string, error) {
+	if err := l.checkFilePermissions(path, "DigitalOcean credentials"); err != nil {
+		return "", err
+	}
+
+	data, err := l.readCredentialsFile(ctx, path, "DigitalOcean credentials")
+	if err != nil {
+		return "", err
+	}
+
+	content := strings.TrimSpace(string(data))
+	for _, line := range strings.Split(content, "\n") {
+		if value, found := strings.CutPrefix(strings.TrimSpace(line), "access-token:"); found {
+			return strings.Trim(strings.TrimSpace(value), `"'`), nil
+		}
+	}
+	return content, nil
+}
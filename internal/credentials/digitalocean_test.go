@@ -0,0 +1,105 @@
+package credentials
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadDigitalOcean(t *testing.T) {
+	dir := t.TempDir()
+	writeFile := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+		return path
+	}
+	tokenFile := writeFile("token", "dop_v1_filetoken\n")
+	doctlConfig := writeFile("doctl.yaml", "context: default\naccess-token: \"dop_v1_doctltoken\"\n")
+	twoTokens := writeFile("two", "dop_v1_one dop_v1_two\n")
+
+	tests := []struct {
+		name        string
+		opts        DigitalOceanCredentialOptions
+		env         map[string]string
+		wantToken   string
+		wantErrCode errors.ErrorCode
+	}{
+		{
+			name:      "token file",
+			opts:      DigitalOceanCredentialOptions{CredentialsFile: tokenFile},
+			wantToken: "dop_v1_filetoken",
+		},
+		{
+			name:      "doctl config file",
+			opts:      DigitalOceanCredentialOptions{CredentialsFile: doctlConfig},
+			wantToken: "dop_v1_doctltoken",
+		},
+		{
+			name:      "file takes precedence over the environment",
+			opts:      DigitalOceanCredentialOptions{CredentialsFile: tokenFile, UseEnvironment: true},
+			env:       map[string]string{"DIGITALOCEAN_TOKEN": "dop_v1_envtoken"},
+			wantToken: "dop_v1_filetoken",
+		},
+		{
+			name:      "file from environment",
+			opts:      DigitalOceanCredentialOptions{UseEnvironment: true},
+			env:       map[string]string{"DIGITALOCEAN_CREDENTIALS_FILE": tokenFile},
+			wantToken: "dop_v1_filetoken",
+		},
+		{
+			name:      "DIGITALOCEAN_TOKEN",
+			opts:      DigitalOceanCredentialOptions{UseEnvironment: true},
+			env:       map[string]string{"DIGITALOCEAN_TOKEN": "dop_v1_envtoken", "DIGITALOCEAN_ACCESS_TOKEN": "dop_v1_doctl"},
+			wantToken: "dop_v1_envtoken",
+		},
+		{
+			name:      "DIGITALOCEAN_ACCESS_TOKEN",
+			opts:      DigitalOceanCredentialOptions{UseEnvironment: true},
+			env:       map[string]string{"DIGITALOCEAN_ACCESS_TOKEN": "dop_v1_doctl"},
+			wantToken: "dop_v1_doctl",
+		},
+		{
+			name:        "environment ignored",
+			opts:        DigitalOceanCredentialOptions{},
+			env:         map[string]string{"DIGITALOCEAN_TOKEN": "dop_v1_envtoken"},
+			wantErrCode: errors.ErrCredentialNotFound,
+		},
+		{
+			name:        "missing file",
+			opts:        DigitalOceanCredentialOptions{CredentialsFile: filepath.Join(dir, "nonexistent")},
+			wantErrCode: errors.ErrCredentialLoadFailed,
+		},
+		{
+			name:        "whitespace in token",
+			opts:        DigitalOceanCredentialOptions{CredentialsFile: twoTokens},
+			wantErrCode: errors.ErrCredentialMalformed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{"DIGITALOCEAN_CREDENTIALS_FILE", "DIGITALOCEAN_TOKEN", "DIGITALOCEAN_ACCESS_TOKEN"} {
+				t.Setenv(key, "")
+			}
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			creds, err := NewLoader(logger.Nop()).LoadDigitalOcean(context.Background(), tt.opts)
+			if tt.wantErrCode != "" {
+				require.Error(t, err)
+				assert.True(t, errors.Is(err, tt.wantErrCode), "got %v", err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantToken, creds.Token)
+		})
+	}
+}
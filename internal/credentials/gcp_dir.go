@@ -0,0 +1,140 @@
+package credentials
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+)
+
+// GCPKeyFile describes a service account key found in a credentials directory
+type GCPKeyFile struct {
+	Path        string
+	ProjectID   string
+	ClientEmail string
+}
+
+// GCPKeyDir selects GCP service account keys from a directory by project ID.
+// The directory is scanned once and the result cached for the lifetime of the
+// value, which is a single CLI invocation.
+type GCPKeyDir struct {
+	dir string
+
+	once sync.Once
+	keys []GCPKeyFile
+	err  error
+}
+
+// NewGCPKeyDir creates a key selector for the given directory
+func NewGCPKeyDir(dir string) *GCPKeyDir {
+	return &GCPKeyDir{dir: dir}
+}
+
+// List returns the service account keys in the directory, sorted by file name.
+// Files without a .json extension and JSON files that are not service account
+// keys are ignored.
+func (d *GCPKeyDir) List() ([]GCPKeyFile, error) {
+	d.once.Do(func() {
+		d.keys, d.err = scanGCPKeyDir(d.dir)
+	})
+	return d.keys, d.err
+}
+
+// Select returns the path of the key whose project_id matches projectID. When
+// projectID is empty the directory must contain exactly one key.
+func (d *GCPKeyDir) Select(projectID string) (string, error) {
+	keys, err := d.List()
+	if err != nil {
+		return "", err
+	}
+
+	if len(keys) == 0 {
+		return "", errors.New(
+			errors.ErrCredentialNotFound,
+			"no GCP service account keys found in credentials directory",
+		).WithField("dir", d.dir)
+	}
+
+	var matches []GCPKeyFile
+	for _, key := range keys {
+		if projectID == "" || key.ProjectID == projectID {
+			matches = append(matches, key)
+		}
+	}
+
+	switch len(matches) {
+	case 1:
+		return matches[0].Path, nil
+	case 0:
+		return "", errors.New(
+			errors.ErrCredentialNotFound,
+			"no GCP service account key in credentials directory matches the project",
+		).WithFields(map[string]interface{}{
+			"dir":     d.dir,
+			"project": projectID,
+		}).WithDetail("candidates: " + describeGCPKeys(keys))
+	default:
+		title := "multiple GCP service account keys in credentials directory match the project"
+		if projectID == "" {
+			title = "multiple GCP service account keys in credentials directory; set --project-id to select one"
+		}
+		return "", errors.New(errors.ErrCredentialInvalid, title).
+			WithFields(map[string]interface{}{
+				"dir":     d.dir,
+				"project": projectID,
+			}).WithDetail("candidates: " + describeGCPKeys(matches))
+	}
+}
+
+// scanGCPKeyDir reads the project and client email of every service account key in dir
+func scanGCPKeyDir(dir string) ([]GCPKeyFile, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, errors.Wrap(
+			errors.ErrCredentialLoadFailed,
+			err,
+			"failed to read GCP credentials directory",
+		).WithField("dir", dir)
+	}
+
+	var keys []GCPKeyFile
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.EqualFold(filepath.Ext(entry.Name()), ".json") {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+
+		var creds GCPCredentials
+		if err := json.Unmarshal(data, &creds); err != nil || creds.Type != "service_account" {
+			continue
+		}
+
+		keys = append(keys, GCPKeyFile{
+			Path:        path,
+			ProjectID:   creds.ProjectID,
+			ClientEmail: creds.ClientEmail,
+		})
+	}
+
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Path < keys[j].Path })
+	return keys, nil
+}
+
+// describeGCPKeys formats keys as "file (project)" for error details
+func describeGCPKeys(keys []GCPKeyFile) string {
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		parts = append(parts, fmt.Sprintf("%s (%s)", filepath.Base(key.Path), key.ProjectID))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,135 @@
+package credentials
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeGCPKey writes a minimal service account key for project into dir
+func writeGCPKey(t *testing.T, dir, name, project string) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	content := fmt.Sprintf(`{"type":"service_account","project_id":%q,"client_email":"sa@%s.iam.gserviceaccount.com"}`, project, project)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func TestGCPKeyDir_List(t *testing.T) {
+	dir := t.TempDir()
+	writeGCPKey(t, dir, "b.json", "project-b")
+	writeGCPKey(t, dir, "a.json", "project-a")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("notes"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "broken.json"), []byte("{"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "user.json"), []byte(`{"type":"authorized_user"}`), 0600))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "nested.json"), 0700))
+
+	keys, err := NewGCPKeyDir(dir).List()
+	require.NoError(t, err)
+
+	require.Len(t, keys, 2)
+	assert.Equal(t, "a.json", filepath.Base(keys[0].Path))
+	assert.Equal(t, "project-a", keys[0].ProjectID)
+	assert.Equal(t, "sa@project-a.iam.gserviceaccount.com", keys[0].ClientEmail)
+	assert.Equal(t, "b.json", filepath.Base(keys[1].Path))
+}
+
+func TestGCPKeyDir_ListIsCached(t *testing.T) {
+	dir := t.TempDir()
+	writeGCPKey(t, dir, "a.json", "project-a")
+
+	keyDir := NewGCPKeyDir(dir)
+	keys, err := keyDir.List()
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+
+	writeGCPKey(t, dir, "b.json", "project-b")
+
+	keys, err = keyDir.List()
+	require.NoError(t, err)
+	assert.Len(t, keys, 1, "directory should only be scanned once")
+}
+
+func TestGCPKeyDir_Select(t *testing.T) {
+	tests := []struct {
+		name        string
+		keys        map[string]string // file name -> project
+		project     string
+		wantFile    string
+		wantErrCode errors.ErrorCode
+		wantDetail  string
+	}{
+		{
+			name:     "single match",
+			keys:     map[string]string{"a.json": "project-a", "b.json": "project-b"},
+			project:  "project-b",
+			wantFile: "b.json",
+		},
+		{
+			name:     "single key without project",
+			keys:     map[string]string{"a.json": "project-a"},
+			wantFile: "a.json",
+		},
+		{
+			name:        "no match lists candidates",
+			keys:        map[string]string{"a.json": "project-a", "b.json": "project-b"},
+			project:     "project-c",
+			wantErrCode: errors.ErrCredentialNotFound,
+			wantDetail:  "a.json (project-a), b.json (project-b)",
+		},
+		{
+			name:        "ambiguous match lists candidates",
+			keys:        map[string]string{"a.json": "project-a", "a2.json": "project-a", "b.json": "project-b"},
+			project:     "project-a",
+			wantErrCode: errors.ErrCredentialInvalid,
+			wantDetail:  "a.json (project-a), a2.json (project-a)",
+		},
+		{
+			name:        "ambiguous without project",
+			keys:        map[string]string{"a.json": "project-a", "b.json": "project-b"},
+			wantErrCode: errors.ErrCredentialInvalid,
+		},
+		{
+			name:        "empty directory",
+			keys:        map[string]string{},
+			project:     "project-a",
+			wantErrCode: errors.ErrCredentialNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, project := range tt.keys {
+				writeGCPKey(t, dir, name, project)
+			}
+
+			path, err := NewGCPKeyDir(dir).Select(tt.project)
+
+			if tt.wantErrCode != "" {
+				require.Error(t, err)
+				assert.True(t, errors.Is(err, tt.wantErrCode), "expected error code %s, got %v", tt.wantErrCode, err)
+				if tt.wantDetail != "" {
+					var appErr *errors.Error
+					require.True(t, errors.As(err, &appErr))
+					assert.Contains(t, appErr.Detail, tt.wantDetail)
+				}
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, filepath.Join(dir, tt.wantFile), path)
+		})
+	}
+}
+
+func TestGCPKeyDir_MissingDirectory(t *testing.T) {
+	_, err := NewGCPKeyDir(filepath.Join(t.TempDir(), "missing")).Select("project-a")
+	assert.True(t, errors.Is(err, errors.ErrCredentialLoadFailed))
+}
@@ -0,0 +1,182 @@
+package credentials
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+)
+
+// maxCredentialsFileSize caps the credentials files read by the loader; real ones are a
+// few KiB, so a larger file is a wrong path or a corrupt render
+const maxCredentialsFileSize = 1 << 20
+
+// utf8BOM is the byte order mark some Windows editors write at the start of a file
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// sha256Pattern matches a hex-encoded SHA-256 digest
+var sha256Pattern = regexp.MustCompile(`^[0-9a-fA-F]{64}$`)
+
+// credentialsFormat selects the structural checks run on a credentials file
+type credentialsFormat int
+
+const (
+	formatJSON credentialsFormat = iota
+	formatINI
+)
+
+// WithExpectedSHA256 makes every GCP, AWS and Azure credentials file the loader reads
+// fail to load unless its SHA-256 is one of sums, a comma-separated list of hex digests,
+// and makes credentials that do not come from a file fail to load. An empty list
+// disables the check.
+func WithExpectedSHA256(sums string) LoaderOption {
+	return func(l *DefaultLoader) {
+		l.expectedSHA256 = splitSHA256(sums)
+	}
+}
+
+// ValidateSHA256 checks that sums is a comma-separated list of hex-encoded SHA-256
+// digests
+func ValidateSHA256(sums string) error {
+	list := splitSHA256(sums)
+	if len(list) == 0 {
+		return fmt.Errorf("must be 64 hex digits, as printed by sha256sum")
+	}
+	for _, sum := range list {
+		if !sha256Pattern.MatchString(sum) {
+			return fmt.Errorf("must be 64 hex digits, as printed by sha256sum")
+		}
+	}
+	return nil
+}
+
+// splitSHA256 returns the lowercased digests of a comma-separated list
+func splitSHA256(sums string) []string {
+	var list []string
+	for _, sum := range strings.Split(sums, ",") {
+		if sum = strings.TrimSpace(sum); sum != "" {
+			list = append(list, strings.ToLower(sum))
+		}
+	}
+	return list
+}
+
+// requireVerifiedFile fails when checksums are expected, since kind credentials from
+// source were not read from a file whose checksum could be verified
+func (l *DefaultLoader) requireVerifiedFile(kind, source string) error {
+	if len(l.expectedSHA256) == 0 {
+		return nil
+	}
+	return errors.New(
+		errors.ErrCredentialInvalid,
+		fmt.Sprintf("%s credentials from %s cannot be checked against the expected SHA-256", kind, source),
+	).WithField("source", source).
+		WithDetail("load the credentials from a file, or drop the expected SHA-256")
+}
+
+// loadCredentialsFile reads a GCP, AWS or Azure credentials file and validates it with
+// validateCredentialsData, against the expected checksums of the loader, before it is
+// parsed
+func (l *DefaultLoader) loadCredentialsFile(ctx context.Context, path, kind string, format credentialsFormat) ([]byte, error) {
+	data, err := l.readCredentialsFile(ctx, path, kind)
+	if err != nil {
+		return nil, err
+	}
+	return validateCredentialsData(path, kind, data, format, l.expectedSHA256)
+}
+
+// validateCredentialsData rejects empty and oversized credentials files, a checksum
+// other than those of expectedSHA256 and JSON that ends inside an object, array or
+// string, as left by a render that is still being written. It returns data without a
+// UTF-8 BOM.
+func validateCredentialsData(path, kind string, data []byte, format credentialsFormat, expectedSHA256 []string) ([]byte, error) {
+	if len(data) > maxCredentialsFileSize {
+		return nil, oversizeError(path, kind)
+	}
+
+	if err := verifySHA256(path, kind, data, expectedSHA256); err != nil {
+		return nil, err
+	}
+
+	data = bytes.TrimPrefix(data, utf8BOM)
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, errors.New(
+			errors.ErrCredentialMalformed,
+			fmt.Sprintf("%s file is empty", kind),
+		).WithField("path", redactPath(path)).
+			WithDetail("the file may not have been rendered yet")
+	}
+
+	if format == formatJSON && jsonTruncated(data) {
+		return nil, errors.New(
+			errors.ErrCredentialMalformed,
+			fmt.Sprintf("%s JSON is truncated", kind),
+		).WithField("path", redactPath(path)).
+			WithDetail("the file ends inside an object or string; it may have been read while still being written")
+	}
+	return data, nil
+}
+
+// verifySHA256 fails unless the checksum of data is one of expectedSHA256; an empty
+// list skips the check
+func verifySHA256(path, kind string, data []byte, expectedSHA256 []string) error {
+	if len(expectedSHA256) == 0 {
+		return nil
+	}
+
+	sum := sha256.Sum256(data)
+	actual := hex.EncodeToString(sum[:])
+	for _, expected := range expectedSHA256 {
+		if actual == strings.ToLower(expected) {
+			return nil
+		}
+	}
+	return errors.New(
+		errors.ErrCredentialInvalid,
+		fmt.Sprintf("%s file does not match the expected SHA-256", kind),
+	).WithFields(map[string]interface{}{
+		"path":            redactPath(path),
+		"expected_sha256": strings.Join(expectedSHA256, ","),
+		"actual_sha256":   actual,
+	})
+}
+
+// oversizeError reports a credentials file larger than maxCredentialsFileSize
+func oversizeError(path, kind string) error {
+	return errors.New(
+		errors.ErrCredentialMalformed,
+		fmt.Sprintf("%s file is larger than %d bytes", kind, maxCredentialsFileSize),
+	).WithField("path", redactPath(path)).
+		WithDetail("check that the path names the credentials file")
+}
+
+// jsonTruncated reports whether data ends inside a JSON object, array or string.
+// Other syntax errors are left to the JSON parser.
+func jsonTruncated(data []byte) bool {
+	depth := 0
+	inString, escaped := false, false
+	for _, c := range data {
+		switch {
+		case escaped:
+			escaped = false
+		case inString && c == '\\':
+			escaped = true
+		case c == '"':
+			inString = !inString
+		case inString:
+		case c == '{' || c == '[':
+			depth++
+		case c == '}' || c == ']':
+			depth--
+			if depth < 0 {
+				return false
+			}
+		}
+	}
+	return inString || depth > 0
+}
@@ -4,8 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
 	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
@@ -21,18 +23,49 @@ type Loader interface {
 
 	// LoadAzure loads Azure service principal credentials
 	LoadAzure(ctx context.Context, opts AzureCredentialOptions) (*AzureCredentials, error)
+
+	// LoadOCI loads OCI API signing key credentials
+	LoadOCI(ctx context.Context, opts OCICredentialOptions) (*OCICredentials, error)
+
+	// LoadDigitalOcean loads a DigitalOcean API token
+	LoadDigitalOcean(ctx context.Context, opts DigitalOceanCredentialOptions) (*DigitalOceanCredentials, error)
+
+	// LoadOIDC loads OIDC client credentials
+	LoadOIDC(ctx context.Context, opts OIDCCredentialOptions) (*OIDCCredentials, error)
 }
 
 // DefaultLoader implements Loader with standard credential loading
 type DefaultLoader struct {
-	logger logger.Logger
+	logger            logger.Logger
+	assumeRole        assumeRoleFunc
+	webIdentity       webIdentityFunc
+	credentialProcess credentialProcessFunc
+	instanceMetadata  instanceMetadataFunc
+	fetchSecret       fetchSecretFunc
+	strictPermissions bool
+
+	// expectedSHA256 are the checksums one of which every GCP, AWS and Azure
+	// credentials file must have; empty skips the check
+	expectedSHA256 []string
+
+	// httpClient carries the requests of secret sources; nil uses their default
+	httpClient *http.Client
 }
 
 // NewLoader creates a new credential loader
-func NewLoader(logger logger.Logger) Loader {
-	return &DefaultLoader{
-		logger: logger,
+func NewLoader(logger logger.Logger, opts ...LoaderOption) Loader {
+	loader := &DefaultLoader{
+		logger:            logger,
+		assumeRole:        stsAssumeRole,
+		webIdentity:       stsWebIdentity,
+		credentialProcess: runCredentialProcess,
+		instanceMetadata:  sdkInstanceMetadata,
+		fetchSecret:       fetchFromSource,
+	}
+	for _, opt := range opts {
+		opt(loader)
 	}
+	return loader
 }
 
 // LoadGCP loads GCP service account credentials from a JSON file
@@ -47,14 +80,14 @@ func (l *DefaultLoader) LoadGCP(ctx context.Context, path string) (*GCPCredentia
 		}
 	}
 
+	if err := l.checkFilePermissions(path, "GCP credentials"); err != nil {
+		return nil, err
+	}
+
 	// Read the file
-	data, err := os.ReadFile(path)
+	data, err := l.loadCredentialsFile(ctx, path, "GCP credentials", formatJSON)
 	if err != nil {
-		return nil, errors.Wrap(
-			errors.ErrCredentialLoadFailed,
-			err,
-			"failed to read GCP credentials file",
-		).WithField("path", redactPath(path))
+		return nil, err
 	}
 
 	// Parse JSON
@@ -77,7 +110,7 @@ func (l *DefaultLoader) LoadGCP(ctx context.Context, path string) (*GCPCredentia
 	l.logger.Debug("GCP credentials loaded",
 		logger.String("path", redactPath(path)),
 		logger.String("project_id", creds.ProjectID),
-		logger.String("client_email", creds.ClientEmail),
+		logger.Email("client_email", creds.ClientEmail),
 	)
 
 	return &creds, nil
@@ -90,6 +123,7 @@ func (l *DefaultLoader) LoadAWS(ctx context.Context, opts AWSCredentialOptions)
 		SecretAccessKey: opts.SecretAccessKey,
 		SessionToken:    opts.SessionToken,
 		Region:          opts.Region,
+		Expiration:      opts.SessionExpiration,
 	}
 
 	credentialsFile := opts.CredentialsFile
@@ -97,9 +131,62 @@ func (l *DefaultLoader) LoadAWS(ctx context.Context, opts AWSCredentialOptions)
 		credentialsFile = os.Getenv("AWS_CREDENTIALS_FILE")
 	}
 
-	// If credentials file is specified, load from file
-	if credentialsFile != "" {
-		fileCreds, err := loadAWSFromFile(credentialsFile, opts.Profile)
+	configFile := opts.ConfigFile
+	if configFile == "" {
+		configFile = os.Getenv("AWS_CONFIG_FILE")
+	}
+
+	// Shared files under ~/.aws are optional, explicitly configured files are not
+	var sharedCredentialsFile, sharedConfigFile string
+	if opts.UseSharedConfig && credentialsFile == "" && configFile == "" {
+		sharedCredentialsFile, sharedConfigFile = defaultSharedAWSFiles()
+		if fileExists(sharedCredentialsFile) {
+			credentialsFile = sharedCredentialsFile
+		}
+		if fileExists(sharedConfigFile) {
+			configFile = sharedConfigFile
+		}
+	}
+
+	profile := opts.Profile
+	if profile == "" {
+		profile = os.Getenv("AWS_PROFILE")
+	}
+
+	roleARN := opts.RoleARN
+	if roleARN == "" {
+		roleARN = os.Getenv("AWS_ROLE_ARN")
+	}
+
+	// Web identity from the environment is only used when no credentials file is configured
+	envTokenFile := ""
+	if opts.UseEnvironment && roleARN != "" {
+		envTokenFile = os.Getenv("AWS_WEB_IDENTITY_TOKEN_FILE")
+	}
+	webIdentityRegion := creds.Region
+	if webIdentityRegion == "" && opts.UseEnvironment {
+		webIdentityRegion = envAWSRegion()
+	}
+
+	// Only the shared files can be checked against the expected checksums
+	if opts.WebIdentityTokenFile != "" {
+		if err := l.requireVerifiedFile("AWS", "web identity"); err != nil {
+			return nil, err
+		}
+	} else if credentialsFile == "" && configFile == "" {
+		if err := l.requireVerifiedFile("AWS", "the environment or instance metadata"); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.WebIdentityTokenFile != "" {
+		// An explicit web identity token file takes precedence over everything else
+		return l.finishAWS(l.loadAWSWebIdentity(ctx, opts.WebIdentityTokenFile, roleARN, webIdentityRegion))
+	}
+
+	// If a credentials or config file is specified, load from file
+	if credentialsFile != "" || configFile != "" {
+		fileCreds, err := l.loadAWSFromFiles(ctx, credentialsFile, configFile, profile, opts.CredentialProcessTimeout)
 		if err != nil {
 			return nil, err
 		}
@@ -107,9 +194,12 @@ func (l *DefaultLoader) LoadAWS(ctx context.Context, opts AWSCredentialOptions)
 		creds.AccessKeyID = fileCreds.AccessKeyID
 		creds.SecretAccessKey = fileCreds.SecretAccessKey
 		creds.SessionToken = fileCreds.SessionToken
+		creds.Expiration = fileCreds.Expiration
 		if fileCreds.Region != "" {
 			creds.Region = fileCreds.Region
 		}
+	} else if envTokenFile != "" {
+		return l.finishAWS(l.loadAWSWebIdentity(ctx, envTokenFile, roleARN, webIdentityRegion))
 	} else if opts.UseEnvironment {
 		// Load from individual environment variables
 		if accessKey := os.Getenv("AWS_ACCESS_KEY_ID"); accessKey != "" {
@@ -121,19 +211,40 @@ func (l *DefaultLoader) LoadAWS(ctx context.Context, opts AWSCredentialOptions)
 		if sessionToken := os.Getenv("AWS_SESSION_TOKEN"); sessionToken != "" {
 			creds.SessionToken = sessionToken
 		}
-		if region := os.Getenv("AWS_REGION"); region != "" {
-			creds.Region = region
-		} else if region := os.Getenv("AWS_DEFAULT_REGION"); region != "" {
+		if raw := os.Getenv("AWS_CREDENTIAL_EXPIRATION"); raw != "" {
+			expiration, err := parseAWSExpiration(raw, "AWS_CREDENTIAL_EXPIRATION")
+			if err != nil {
+				return nil, err
+			}
+			creds.Expiration = expiration
+		}
+		if region := envAWSRegion(); region != "" {
 			creds.Region = region
 		}
 	}
 
+	// The metadata endpoint is the last resort, so it never shadows files or environment
+	if opts.UseInstanceMetadata && credentialsFile == "" && configFile == "" &&
+		creds.AccessKeyID == "" && creds.SecretAccessKey == "" {
+		return l.finishAWS(l.loadAWSInstanceMetadata(ctx, creds.Region))
+	}
+
+	return l.finishAWS(creds, nil)
+}
+
+// finishAWS validates loaded AWS credentials
+func (l *DefaultLoader) finishAWS(creds *AWSCredentials, err error) (*AWSCredentials, error) {
+	if err != nil {
+		return nil, err
+	}
+
 	// Validate
 	if err := l.validateAWSCredentials(creds); err != nil {
 		return nil, err
 	}
 
 	l.logger.Debug("AWS credentials loaded",
+		logger.AccessKeyID("access_key_id", creds.AccessKeyID),
 		logger.String("region", creds.Region),
 		logger.Bool("has_session_token", creds.SessionToken != ""),
 	)
@@ -141,102 +252,92 @@ func (l *DefaultLoader) LoadAWS(ctx context.Context, opts AWSCredentialOptions)
 	return creds, nil
 }
 
-// loadAWSFromFile loads AWS credentials from INI format file
-func loadAWSFromFile(path string, profile string) (*AWSCredentials, error) {
+// loadAWSFromFiles loads AWS credentials from shared credentials and config files (INI format).
+// Profiles may come from either file and can use source_profile/role_arn chaining or credential_process.
+func (l *DefaultLoader) loadAWSFromFiles(ctx context.Context, credentialsFile, configFile, profile string, processTimeout time.Duration) (*AWSCredentials, error) {
 	if profile == "" {
 		profile = "default"
 	}
 
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return nil, errors.Wrap(
-			errors.ErrCredentialLoadFailed,
-			err,
-			"failed to read AWS credentials file",
-		).WithField("path", redactPath(path))
+	resolver := &awsProfileResolver{
+		credentials:    map[string]map[string]string{},
+		config:         map[string]map[string]string{},
+		assumeRole:     l.assumeRole,
+		runProcess:     l.credentialProcess,
+		processTimeout: processTimeout,
 	}
-
-	creds, err := parseAWSCredentialsINI(string(data), profile)
-	if err != nil {
-		return nil, errors.Wrap(
-			errors.ErrCredentialMalformed,
-			err,
-			"failed to parse AWS credentials file",
-		).WithField("path", redactPath(path))
+	if resolver.assumeRole == nil {
+		resolver.assumeRole = stsAssumeRole
 	}
-
-	return creds, nil
-}
-
-// parseAWSCredentialsINI parses AWS credentials in INI format
-func parseAWSCredentialsINI(content string, profile string) (*AWSCredentials, error) {
-	if profile == "" {
-		profile = "default"
+	if resolver.runProcess == nil {
+		resolver.runProcess = runCredentialProcess
 	}
 
-	creds := &AWSCredentials{}
-	inProfile := false
-	profileFound := false
-	profileHeader := "[" + profile + "]"
-
-	lines := splitLines(content)
-	for _, line := range lines {
-		line = trimSpace(line)
-		if line == "" || hasPrefix(line, "#") || hasPrefix(line, ";") {
-			continue
+	if credentialsFile != "" {
+		if err := l.checkFilePermissions(credentialsFile, "AWS credentials"); err != nil {
+			return nil, err
 		}
-
-		if hasPrefix(line, "[") {
-			inProfile = (line == profileHeader)
-			if inProfile {
-				profileFound = true
-			}
-			continue
+		data, err := l.loadCredentialsFile(ctx, credentialsFile, "AWS credentials", formatINI)
+		if err != nil {
+			return nil, err
 		}
+		resolver.credentials = parseAWSINI(string(data), false)
+	}
 
-		if !inProfile {
-			continue
+	if configFile != "" {
+		if err := l.checkFilePermissions(configFile, "AWS config"); err != nil {
+			return nil, err
 		}
-
-		parts := splitKeyValue(line)
-		if len(parts) != 2 {
-			continue
+		data, err := l.loadCredentialsFile(ctx, configFile, "AWS config", formatINI)
+		if err != nil {
+			return nil, err
 		}
+		resolver.config = parseAWSINI(string(data), true)
+	}
 
-		key := trimSpace(parts[0])
-		value := trimSpace(parts[1])
-
-		switch key {
-		case "aws_access_key_id":
-			creds.AccessKeyID = value
-		case "aws_secret_access_key":
-			creds.SecretAccessKey = value
-		case "aws_session_token":
-			creds.SessionToken = value
-		case "region":
-			creds.Region = value
+	creds, err := resolver.resolve(ctx, profile)
+	if err != nil {
+		var appErr *errors.Error
+		if errors.As(err, &appErr) && appErr.Code == errors.ErrCredentialMalformed {
+			return nil, appErr.WithFields(map[string]interface{}{
+				"credentials_path": redactPath(credentialsFile),
+				"config_path":      redactPath(configFile),
+			})
 		}
+		return nil, err
 	}
 
-	// Verify that the profile was found
-	if !profileFound {
-		return nil, fmt.Errorf("profile '%s' not found in credentials file", profile)
+	l.logger.Debug("AWS profile resolved",
+		logger.String("profile", profile),
+		logger.Bool("has_session_token", creds.SessionToken != ""),
+	)
+
+	return creds, nil
+}
+
+// parseAWSCredentialsINI parses static AWS credentials for a profile from a credentials file in INI format
+func parseAWSCredentialsINI(content string, profile string) (*AWSCredentials, error) {
+	if profile == "" {
+		profile = "default"
 	}
 
-	// Verify that required credentials were found
-	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
-		return nil, fmt.Errorf("profile '%s' missing required credentials (aws_access_key_id and aws_secret_access_key)", profile)
+	section, found := awsINISection(parseAWSINI(content, false), profile)
+	if !found {
+		return nil, fmt.Errorf("profile '%s' not found in credentials file", profile)
 	}
 
-	return creds, nil
+	return staticAWSCredentials(profile, section)
 }
 
 // LoadAzure loads Azure credentials from file or environment
 func (l *DefaultLoader) LoadAzure(ctx context.Context, opts AzureCredentialOptions) (*AzureCredentials, error) {
 	creds := &AzureCredentials{
-		ClientID:     opts.ClientID,
-		ClientSecret: opts.ClientSecret,
-		TenantID:     opts.TenantID,
+		ClientID:                  opts.ClientID,
+		ClientSecret:              opts.ClientSecret,
+		TenantID:                  opts.TenantID,
+		ClientCertificatePath:     opts.ClientCertificatePath,
+		ClientCertificatePassword: opts.ClientCertificatePassword,
+		SendCertificateChain:      opts.SendCertificateChain,
 	}
 
 	credentialsFile := opts.CredentialsFile
@@ -244,9 +345,16 @@ func (l *DefaultLoader) LoadAzure(ctx context.Context, opts AzureCredentialOptio
 		credentialsFile = os.Getenv("AZURE_CREDENTIALS_FILE")
 	}
 
+	// Only a credentials file can be checked against the expected checksums
+	if credentialsFile == "" {
+		if err := l.requireVerifiedFile("Azure", "the environment"); err != nil {
+			return nil, err
+		}
+	}
+
 	// If credentials file is specified, load from file
 	if credentialsFile != "" {
-		fileCreds, err := loadAzureFromFile(credentialsFile)
+		fileCreds, err := l.loadAzureFromFile(ctx, credentialsFile)
 		if err != nil {
 			return nil, err
 		}
@@ -254,6 +362,10 @@ func (l *DefaultLoader) LoadAzure(ctx context.Context, opts AzureCredentialOptio
 		creds.ClientID = fileCreds.ClientID
 		creds.ClientSecret = fileCreds.ClientSecret
 		creds.TenantID = fileCreds.TenantID
+		creds.SubscriptionID = fileCreds.SubscriptionID
+		creds.ClientCertificatePath = fileCreds.ClientCertificatePath
+		creds.ClientCertificatePassword = fileCreds.ClientCertificatePassword
+		creds.SendCertificateChain = fileCreds.SendCertificateChain
 	} else if opts.UseEnvironment {
 		// Load from individual environment variables
 		if clientID := os.Getenv("AZURE_CLIENT_ID"); clientID != "" {
@@ -265,36 +377,63 @@ func (l *DefaultLoader) LoadAzure(ctx context.Context, opts AzureCredentialOptio
 		if tenantID := os.Getenv("AZURE_TENANT_ID"); tenantID != "" {
 			creds.TenantID = tenantID
 		}
+		// The variables of the Azure SDK EnvironmentCredential
+		if certPath := os.Getenv("AZURE_CLIENT_CERTIFICATE_PATH"); certPath != "" {
+			creds.ClientCertificatePath = certPath
+		}
+		if certPassword := os.Getenv("AZURE_CLIENT_CERTIFICATE_PASSWORD"); certPassword != "" {
+			creds.ClientCertificatePassword = certPassword
+		}
+		if sendChain := os.Getenv("AZURE_CLIENT_SEND_CERTIFICATE_CHAIN"); sendChain != "" {
+			creds.SendCertificateChain = sendChain == "1" || strings.EqualFold(sendChain, "true")
+		}
 	}
 
 	// Validate
 	if err := l.validateAzureCredentials(creds); err != nil {
 		return nil, err
 	}
+	if creds.ClientCertificatePath != "" {
+		certificate, err := l.loadAzureCertificate(ctx, creds.ClientCertificatePath, creds.ClientCertificatePassword)
+		if err != nil {
+			return nil, err
+		}
+		creds.ClientCertificate = certificate
+	}
 
 	l.logger.Debug("Azure credentials loaded",
-		logger.String("tenant_id", creds.TenantID),
-		logger.String("client_id", creds.ClientID),
+		logger.ID("tenant_id", creds.TenantID),
+		logger.ID("client_id", creds.ClientID),
 	)
 
 	return creds, nil
 }
 
 // loadAzureFromFile loads Azure credentials from JSON file
-func loadAzureFromFile(path string) (*AzureCredentials, error) {
-	data, err := os.ReadFile(path)
+func (l *DefaultLoader) loadAzureFromFile(ctx context.Context, path string) (*AzureCredentials, error) {
+	if err := l.checkFilePermissions(path, "Azure credentials"); err != nil {
+		return nil, err
+	}
+
+	data, err := l.loadCredentialsFile(ctx, path, "Azure credentials", formatJSON)
 	if err != nil {
-		return nil, errors.Wrap(
-			errors.ErrCredentialLoadFailed,
-			err,
-			"failed to read Azure credentials file",
-		).WithField("path", redactPath(path))
+		return nil, err
 	}
 
+	// The camelCase fields are those of az ad sp create-for-rbac --sdk-auth output
 	var creds struct {
-		ClientID     string `json:"client_id"`
-		ClientSecret string `json:"client_secret"`
-		TenantID     string `json:"tenant_id"`
+		ClientID          string `json:"client_id"`
+		ClientSecret      string `json:"client_secret"`
+		TenantID          string `json:"tenant_id"`
+		SubscriptionID    string `json:"subscription_id"`
+		SDKClientID       string `json:"clientId"`
+		SDKClientSecret   string `json:"clientSecret"`
+		SDKTenantID       string `json:"tenantId"`
+		SDKSubscriptionID string `json:"subscriptionId"`
+
+		ClientCertificatePath     string `json:"client_certificate_path"`
+		ClientCertificatePassword string `json:"client_certificate_password"`
+		SendCertificateChain      bool   `json:"send_certificate_chain"`
 	}
 
 	if err := json.Unmarshal(data, &creds); err != nil {
@@ -306,9 +445,14 @@ func loadAzureFromFile(path string) (*AzureCredentials, error) {
 	}
 
 	return &AzureCredentials{
-		ClientID:     creds.ClientID,
-		ClientSecret: creds.ClientSecret,
-		TenantID:     creds.TenantID,
+		ClientID:       firstNonEmpty(creds.ClientID, creds.SDKClientID),
+		ClientSecret:   firstNonEmpty(creds.ClientSecret, creds.SDKClientSecret),
+		TenantID:       firstNonEmpty(creds.TenantID, creds.SDKTenantID),
+		SubscriptionID: firstNonEmpty(creds.SubscriptionID, creds.SDKSubscriptionID),
+
+		ClientCertificatePath:     creds.ClientCertificatePath,
+		ClientCertificatePassword: creds.ClientCertificatePassword,
+		SendCertificateChain:      creds.SendCertificateChain,
 	}, nil
 }
 
@@ -325,6 +469,38 @@ func redactPath(path string) string {
 	return path
 }
 
+// envAWSRegion returns the region from AWS_REGION or AWS_DEFAULT_REGION
+func envAWSRegion() string {
+	if region := os.Getenv("AWS_REGION"); region != "" {
+		return region
+	}
+	return os.Getenv("AWS_DEFAULT_REGION")
+}
+
+// parseAWSExpiration parses the RFC 3339 expiration of temporary credentials, as written
+// by aws sts assume-role exports and credential helpers
+func parseAWSExpiration(raw, source string) (time.Time, error) {
+	expiration, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return time.Time{}, errors.Wrap(
+			errors.ErrCredentialMalformed,
+			err,
+			fmt.Sprintf("invalid %s", source),
+		).WithField("value", raw).
+			WithDetail("expected an RFC 3339 time such as 2024-01-02T15:04:05Z")
+	}
+	return expiration, nil
+}
+
+// fileExists reports whether a regular file exists at path
+func fileExists(path string) bool {
+	if path == "" {
+		return false
+	}
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
 // Helper functions for parsing INI files
 
 func splitLines(content string) []string {
@@ -0,0 +1,135 @@
+package credentials
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+)
+
+// defaultOCIProfile is the profile the OCI CLI reads when none is set
+const defaultOCIProfile = "DEFAULT"
+
+// LoadOCI loads an OCI API signing key from an OCI CLI config file or the
+// OCI_CLI_* environment variables
+func (l *DefaultLoader) LoadOCI(ctx context.Context, opts OCICredentialOptions) (*OCICredentials, error) {
+	creds := &OCICredentials{}
+	var keyFile string
+
+	configFile := opts.ConfigFile
+	if configFile == "" {
+		configFile = os.Getenv("OCI_CLI_CONFIG_FILE")
+	}
+	if configFile == "" && opts.UseEnvironment {
+		if home, err := os.UserHomeDir(); err == nil && fileExists(filepath.Join(home, ".oci", "config")) {
+			configFile = filepath.Join(home, ".oci", "config")
+		}
+	}
+
+	if configFile != "" {
+		section, err := l.loadOCIConfig(ctx, configFile, opts.Profile)
+		if err != nil {
+			return nil, err
+		}
+		creds.TenancyID = section["tenancy"]
+		creds.UserID = section["user"]
+		creds.Fingerprint = section["fingerprint"]
+		creds.Region = section["region"]
+		keyFile = section["key_file"]
+		if section["pass_phrase"] != "" {
+			return nil, errors.New(
+				errors.ErrCredentialInvalid,
+				"passphrase-protected OCI API keys are not supported",
+			).WithField("path", redactPath(configFile))
+		}
+	} else if opts.UseEnvironment {
+		creds.TenancyID = os.Getenv("OCI_CLI_TENANCY")
+		creds.UserID = os.Getenv("OCI_CLI_USER")
+		creds.Fingerprint = os.Getenv("OCI_CLI_FINGERPRINT")
+		creds.Region = os.Getenv("OCI_CLI_REGION")
+		keyFile = os.Getenv("OCI_CLI_KEY_FILE")
+	}
+
+	// Explicit values take precedence over the config file
+	if opts.TenancyID != "" {
+		creds.TenancyID = opts.TenancyID
+	}
+	if opts.UserID != "" {
+		creds.UserID = opts.UserID
+	}
+	if opts.Region != "" {
+		creds.Region = opts.Region
+	}
+
+	if keyFile != "" {
+		key, err := l.loadOCIKeyFile(ctx, keyFile)
+		if err != nil {
+			return nil, err
+		}
+		creds.PrivateKey = key
+	}
+
+	if err := l.validateOCICredentials(creds); err != nil {
+		return nil, err
+	}
+
+	l.logger.Debug("OCI credentials loaded",
+		logger.ID("tenancy_id", creds.TenancyID),
+		logger.ID("user_id", creds.UserID),
+		logger.String("region", creds.Region),
+	)
+
+	return creds, nil
+}
+
+// loadOCIConfig reads a profile from an OCI CLI config file
+func (l *DefaultLoader) loadOCIConfig(ctx context.Context, path, profile string) (map[string]string, error) {
+	if profile == "" {
+		profile = os.Getenv("OCI_CLI_PROFILE")
+	}
+	if profile == "" {
+		profile = defaultOCIProfile
+	}
+
+	data, err := l.readCredentialsFile(ctx, path, "OCI config")
+	if err != nil {
+		return nil, err
+	}
+
+	// The OCI config file uses the same INI layout as the AWS shared credentials file
+	section, found := awsINISection(parseAWSINI(string(data), false), profile)
+	if !found {
+		return nil, errors.New(
+			errors.ErrCredentialNotFound,
+			"profile not found in OCI config file",
+		).WithFields(map[string]interface{}{
+			"path":    redactPath(path),
+			"profile": profile,
+		})
+	}
+
+	return section, nil
+}
+
+// loadOCIKeyFile reads the PEM API signing key referenced by key_file
+func (l *DefaultLoader) loadOCIKeyFile(ctx context.Context, path string) (string, error) {
+	if strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, path[2:])
+		}
+	}
+
+	if err := l.checkFilePermissions(path, "OCI API key"); err != nil {
+		return "", err
+	}
+
+	data, err := l.readCredentialsFile(ctx, path, "OCI API key")
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
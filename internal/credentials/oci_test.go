@@ -0,0 +1,184 @@
+package credentials
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeOCIConfig writes an OCI config file with a DEFAULT and an "other" profile
+// that both point at a key file in dir
+func writeOCIConfig(t *testing.T, dir string) string {
+	t.Helper()
+
+	keyFile := filepath.Join(dir, "oci_api_key.pem")
+	require.NoError(t, os.WriteFile(keyFile, []byte(testPrivateKey), 0600))
+
+	path := filepath.Join(dir, "config")
+	content := fmt.Sprintf(`[DEFAULT]
+user=ocid1.user.oc1..default
+fingerprint=aa:bb:cc
+tenancy=ocid1.tenancy.oc1..default
+region=us-ashburn-1
+key_file=%[1]s
+
+[other]
+user=ocid1.user.oc1..other
+fingerprint=dd:ee:ff
+tenancy=ocid1.tenancy.oc1..other
+region=eu-frankfurt-1
+key_file=%[1]s
+
+[no-key]
+user=ocid1.user.oc1..nokey
+tenancy=ocid1.tenancy.oc1..nokey
+`, keyFile)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func TestLoadOCI(t *testing.T) {
+	dir := t.TempDir()
+	configFile := writeOCIConfig(t, dir)
+
+	tests := []struct {
+		name          string
+		opts          OCICredentialOptions
+		env           map[string]string
+		wantTenancy   string
+		wantUser      string
+		wantRegion    string
+		wantErrCode   errors.ErrorCode
+		wantErrFields []string
+	}{
+		{
+			name:        "default profile",
+			opts:        OCICredentialOptions{ConfigFile: configFile},
+			wantTenancy: "ocid1.tenancy.oc1..default",
+			wantUser:    "ocid1.user.oc1..default",
+			wantRegion:  "us-ashburn-1",
+		},
+		{
+			name:        "named profile",
+			opts:        OCICredentialOptions{ConfigFile: configFile, Profile: "other"},
+			wantTenancy: "ocid1.tenancy.oc1..other",
+			wantUser:    "ocid1.user.oc1..other",
+			wantRegion:  "eu-frankfurt-1",
+		},
+		{
+			name:        "profile from environment",
+			opts:        OCICredentialOptions{ConfigFile: configFile},
+			env:         map[string]string{"OCI_CLI_PROFILE": "other"},
+			wantTenancy: "ocid1.tenancy.oc1..other",
+			wantUser:    "ocid1.user.oc1..other",
+			wantRegion:  "eu-frankfurt-1",
+		},
+		{
+			name:        "config file from environment",
+			opts:        OCICredentialOptions{UseEnvironment: true},
+			env:         map[string]string{"OCI_CLI_CONFIG_FILE": configFile},
+			wantTenancy: "ocid1.tenancy.oc1..default",
+			wantUser:    "ocid1.user.oc1..default",
+			wantRegion:  "us-ashburn-1",
+		},
+		{
+			name: "explicit values override the config file",
+			opts: OCICredentialOptions{
+				ConfigFile: configFile,
+				TenancyID:  "ocid1.tenancy.oc1..flag",
+				UserID:     "ocid1.user.oc1..flag",
+				Region:     "uk-london-1",
+			},
+			wantTenancy: "ocid1.tenancy.oc1..flag",
+			wantUser:    "ocid1.user.oc1..flag",
+			wantRegion:  "uk-london-1",
+		},
+		{
+			name: "environment variables",
+			opts: OCICredentialOptions{UseEnvironment: true},
+			env: map[string]string{
+				"HOME":                dir,
+				"OCI_CLI_TENANCY":     "ocid1.tenancy.oc1..env",
+				"OCI_CLI_USER":        "ocid1.user.oc1..env",
+				"OCI_CLI_FINGERPRINT": "11:22:33",
+				"OCI_CLI_KEY_FILE":    filepath.Join(dir, "oci_api_key.pem"),
+				"OCI_CLI_REGION":      "ap-tokyo-1",
+			},
+			wantTenancy: "ocid1.tenancy.oc1..env",
+			wantUser:    "ocid1.user.oc1..env",
+			wantRegion:  "ap-tokyo-1",
+		},
+		{
+			name:        "unknown profile",
+			opts:        OCICredentialOptions{ConfigFile: configFile, Profile: "missing"},
+			wantErrCode: errors.ErrCredentialNotFound,
+		},
+		{
+			name:          "incomplete profile names every missing field",
+			opts:          OCICredentialOptions{ConfigFile: configFile, Profile: "no-key"},
+			wantErrCode:   errors.ErrCredentialNotFound,
+			wantErrFields: []string{"fingerprint", "key_file"},
+		},
+		{
+			name:        "missing config file",
+			opts:        OCICredentialOptions{ConfigFile: filepath.Join(dir, "nonexistent")},
+			wantErrCode: errors.ErrCredentialLoadFailed,
+		},
+		{
+			name:          "nothing configured",
+			opts:          OCICredentialOptions{UseEnvironment: true},
+			env:           map[string]string{"HOME": t.TempDir()},
+			wantErrCode:   errors.ErrCredentialNotFound,
+			wantErrFields: []string{"tenancy", "user", "fingerprint", "key_file"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{"OCI_CLI_CONFIG_FILE", "OCI_CLI_PROFILE", "OCI_CLI_TENANCY", "OCI_CLI_USER", "OCI_CLI_FINGERPRINT", "OCI_CLI_KEY_FILE", "OCI_CLI_REGION"} {
+				t.Setenv(key, "")
+			}
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			creds, err := NewLoader(logger.Nop()).LoadOCI(context.Background(), tt.opts)
+			if tt.wantErrCode != "" {
+				require.Error(t, err)
+				assert.True(t, errors.Is(err, tt.wantErrCode), "got %v", err)
+				if tt.wantErrFields != nil {
+					var appErr *errors.Error
+					require.True(t, errors.As(err, &appErr))
+					assert.Equal(t, tt.wantErrFields, appErr.Fields["missing"])
+				}
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantTenancy, creds.TenancyID)
+			assert.Equal(t, tt.wantUser, creds.UserID)
+			assert.Equal(t, tt.wantRegion, creds.Region)
+			assert.Equal(t, testPrivateKey, creds.PrivateKey)
+		})
+	}
+}
+
+func TestLoadOCI_RejectsPassphrase(t *testing.T) {
+	dir := t.TempDir()
+	configFile := writeOCIConfig(t, dir)
+	data, err := os.ReadFile(configFile)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(configFile, append([]byte("[locked]\npass_phrase=secret\n"), data...), 0600))
+
+	_, err = NewLoader(logger.Nop()).LoadOCI(context.Background(), OCICredentialOptions{ConfigFile: configFile, Profile: "locked"})
+
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, errors.ErrCredentialInvalid))
+}
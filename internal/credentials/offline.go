@@ -0,0 +1,84 @@
+package credentials
+
+import (
+	"context"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+)
+
+// offlinePlaceholder stands in for credentials that would only be known after a remote call
+const offlinePlaceholder = "OFFLINE-PLACEHOLDER"
+
+// NewOfflineLoader creates a loader that reads and validates local credential sources
+// without contacting any cloud API or running credential_process helpers.
+// Credentials referenced in a secret source are not fetched and fail to load.
+// Role assumption and web identity steps are checked for their required settings but not
+// performed: role assumption returns the source credentials, and web identity,
+// credential_process and the instance metadata endpoint return placeholder keys.
+func NewOfflineLoader(logger logger.Logger, opts ...LoaderOption) Loader {
+	loader := &DefaultLoader{
+		logger:            logger,
+		assumeRole:        offlineAssumeRole,
+		webIdentity:       offlineWebIdentity,
+		credentialProcess: offlineCredentialProcess,
+		instanceMetadata:  offlineInstanceMetadata,
+		fetchSecret:       offlineFetchSecret,
+	}
+	for _, opt := range opts {
+		opt(loader)
+	}
+	return loader
+}
+
+// offlineAssumeRole returns the source credentials instead of calling STS
+func offlineAssumeRole(ctx context.Context, input assumeRoleInput) (*AWSCredentials, error) {
+	creds := *input.Source
+	return &creds, nil
+}
+
+// offlineWebIdentity checks the token file is readable and non-empty instead of calling STS
+func offlineWebIdentity(ctx context.Context, input webIdentityInput) (*AWSCredentials, error) {
+	data, err := os.ReadFile(input.TokenFile)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(string(data)) == "" {
+		return nil, errors.New(
+			errors.ErrCredentialMalformed,
+			"web identity token file is empty",
+		).WithField("path", redactPath(input.TokenFile))
+	}
+
+	return &AWSCredentials{
+		AccessKeyID:     offlinePlaceholder,
+		SecretAccessKey: offlinePlaceholder,
+	}, nil
+}
+
+// offlineInstanceMetadata returns placeholder keys instead of calling the metadata endpoint
+func offlineInstanceMetadata(ctx context.Context, region string) (*AWSCredentials, error) {
+	return &AWSCredentials{
+		AccessKeyID:     offlinePlaceholder,
+		SecretAccessKey: offlinePlaceholder,
+	}, nil
+}
+
+// offlineCredentialProcess skips running the helper
+func offlineCredentialProcess(ctx context.Context, command string, timeout time.Duration) (*AWSCredentials, error) {
+	return &AWSCredentials{
+		AccessKeyID:     offlinePlaceholder,
+		SecretAccessKey: offlinePlaceholder,
+	}, nil
+}
+
+// offlineFetchSecret refuses to fetch from a secret source, which is a remote call
+func offlineFetchSecret(ctx context.Context, source SecretSource, ref string) ([]byte, error) {
+	return nil, errors.New(
+		errors.ErrCredentialNotFound,
+		"credentials in a secret source are not fetched offline",
+	)
+}
@@ -0,0 +1,61 @@
+package credentials
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+)
+
+// LoadOIDC loads OIDC client credentials: a private key for private_key_jwt when
+// PrivateKeyFile is set, otherwise a client secret from a file or OIDC_CLIENT_SECRET
+func (l *DefaultLoader) LoadOIDC(ctx context.Context, opts OIDCCredentialOptions) (*OIDCCredentials, error) {
+	creds := &OIDCCredentials{}
+
+	credentialsFile := opts.CredentialsFile
+	if credentialsFile == "" {
+		credentialsFile = os.Getenv("OIDC_CLIENT_SECRET_FILE")
+	}
+
+	switch {
+	case opts.PrivateKeyFile != "":
+		key, err := l.readOIDCFile(ctx, opts.PrivateKeyFile, "OIDC private key")
+		if err != nil {
+			return nil, err
+		}
+		creds.PrivateKey = key
+	case credentialsFile != "":
+		secret, err := l.readOIDCFile(ctx, credentialsFile, "OIDC client secret")
+		if err != nil {
+			return nil, err
+		}
+		creds.ClientSecret = strings.TrimSpace(secret)
+	case opts.UseEnvironment:
+		creds.ClientSecret = os.Getenv("OIDC_CLIENT_SECRET")
+	}
+
+	if err := l.validateOIDCCredentials(creds); err != nil {
+		return nil, err
+	}
+
+	l.logger.Debug("OIDC credentials loaded",
+		logger.Bool("private_key", creds.PrivateKey != ""),
+		logger.Bool("from_file", opts.PrivateKeyFile != "" || credentialsFile != ""),
+	)
+
+	return creds, nil
+}
+
+// readOIDCFile reads a client secret or private key file
+func (l *DefaultLoader) readOIDCFile(ctx context.Context, path, kind string) (string, error) {
+	if err := l.checkFilePermissions(path, kind); err != nil {
+		return "", err
+	}
+
+	data, err := l.readCredentialsFile(ctx, path, kind)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
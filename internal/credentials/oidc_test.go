@@ -0,0 +1,113 @@
+package credentials
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadOIDC(t *testing.T) {
+	dir := t.TempDir()
+	writeFile := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+		return path
+	}
+	secretFile := writeFile("secret", "file-secret\n")
+	twoLines := writeFile("two-lines", "first\nsecond\n")
+	keyFile := writeFile("key.pem", testPrivateKey)
+	notPEM := writeFile("key.txt", "not a key\n")
+
+	tests := []struct {
+		name        string
+		opts        OIDCCredentialOptions
+		env         map[string]string
+		wantSecret  string
+		wantKey     string
+		wantErrCode errors.ErrorCode
+	}{
+		{
+			name:       "secret file",
+			opts:       OIDCCredentialOptions{CredentialsFile: secretFile},
+			wantSecret: "file-secret",
+		},
+		{
+			name:       "file takes precedence over the environment",
+			opts:       OIDCCredentialOptions{CredentialsFile: secretFile, UseEnvironment: true},
+			env:        map[string]string{"OIDC_CLIENT_SECRET": "env-secret"},
+			wantSecret: "file-secret",
+		},
+		{
+			name:       "file from environment",
+			opts:       OIDCCredentialOptions{UseEnvironment: true},
+			env:        map[string]string{"OIDC_CLIENT_SECRET_FILE": secretFile},
+			wantSecret: "file-secret",
+		},
+		{
+			name:       "OIDC_CLIENT_SECRET",
+			opts:       OIDCCredentialOptions{UseEnvironment: true},
+			env:        map[string]string{"OIDC_CLIENT_SECRET": "env-secret"},
+			wantSecret: "env-secret",
+		},
+		{
+			name:    "private key",
+			opts:    OIDCCredentialOptions{PrivateKeyFile: keyFile},
+			wantKey: testPrivateKey,
+		},
+		{
+			name:    "private key takes precedence over the secret",
+			opts:    OIDCCredentialOptions{PrivateKeyFile: keyFile, CredentialsFile: secretFile, UseEnvironment: true},
+			env:     map[string]string{"OIDC_CLIENT_SECRET": "env-secret"},
+			wantKey: testPrivateKey,
+		},
+		{
+			name:        "environment ignored",
+			opts:        OIDCCredentialOptions{},
+			env:         map[string]string{"OIDC_CLIENT_SECRET": "env-secret"},
+			wantErrCode: errors.ErrCredentialNotFound,
+		},
+		{
+			name:        "missing file",
+			opts:        OIDCCredentialOptions{CredentialsFile: filepath.Join(dir, "nonexistent")},
+			wantErrCode: errors.ErrCredentialLoadFailed,
+		},
+		{
+			name:        "secret on several lines",
+			opts:        OIDCCredentialOptions{CredentialsFile: twoLines},
+			wantErrCode: errors.ErrCredentialMalformed,
+		},
+		{
+			name:        "private key not PEM",
+			opts:        OIDCCredentialOptions{PrivateKeyFile: notPEM},
+			wantErrCode: errors.ErrCredentialMalformed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{"OIDC_CLIENT_SECRET_FILE", "OIDC_CLIENT_SECRET"} {
+				t.Setenv(key, "")
+			}
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			creds, err := NewLoader(logger.Nop()).LoadOIDC(context.Background(), tt.opts)
+			if tt.wantErrCode != "" {
+				require.Error(t, err)
+				assert.True(t, errors.Is(err, tt.wantErrCode), "got %v", err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantSecret, creds.ClientSecret)
+			assert.Equal(t, tt.wantKey, creds.PrivateKey)
+		})
+	}
+}
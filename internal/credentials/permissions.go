@@ -0,0 +1,67 @@
+package credentials
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+)
+
+// insecurePermBits are the group and other permission bits a credentials file must not have
+const insecurePermBits os.FileMode = 0077
+
+// LoaderOption configures a DefaultLoader
+type LoaderOption func(*DefaultLoader)
+
+// WithStrictPermissions makes credentials files readable by group or others an error
+// instead of a warning
+func WithStrictPermissions(strict bool) LoaderOption {
+	return func(l *DefaultLoader) {
+		l.strictPermissions = strict
+	}
+}
+
+// checkFilePermissions warns, or fails in strict mode, when a credentials file has group
+// or other permission bits set. Symlinks are followed so the mode of the real file is checked.
+// Windows has no POSIX mode bits, so the check is skipped there.
+func (l *DefaultLoader) checkFilePermissions(path, kind string) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		// Leave reporting a missing or unreadable file to the read that follows
+		return nil
+	}
+
+	mode := info.Mode().Perm()
+	if mode&insecurePermBits == 0 {
+		return nil
+	}
+
+	realPath := path
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		realPath = resolved
+	}
+
+	if l.strictPermissions {
+		return errors.New(
+			errors.ErrCredentialInvalid,
+			fmt.Sprintf("%s file is accessible by group or others", kind),
+		).WithFields(map[string]interface{}{
+			"path": redactPath(realPath),
+			"mode": fmt.Sprintf("%#o", mode),
+		}).WithDetail(fmt.Sprintf("restrict it with: chmod 600 %s", realPath))
+	}
+
+	l.logger.Warn("Credentials file is accessible by group or others; restrict it with chmod 600",
+		logger.String("kind", kind),
+		logger.String("path", redactPath(realPath)),
+		logger.String("mode", fmt.Sprintf("%#o", mode)),
+	)
+	return nil
+}
@@ -0,0 +1,153 @@
+package credentials
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// warnRecorder records warning messages and their fields
+type warnRecorder struct {
+	logger.Logger
+	warnings []string
+	fields   [][]logger.Field
+}
+
+func (l *warnRecorder) Warn(msg string, fields ...logger.Field) {
+	l.warnings = append(l.warnings, msg)
+	l.fields = append(l.fields, fields)
+}
+
+const azureCredentialsJSON = `{"client_id":"client","client_secret":"secret","tenant_id":"tenant"}`
+
+// writeCredentialsFile writes content to a temp file and sets its mode explicitly,
+// independent of the process umask
+func writeCredentialsFile(t *testing.T, mode os.FileMode) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "azure.json")
+	require.NoError(t, os.WriteFile(path, []byte(azureCredentialsJSON), 0600))
+	require.NoError(t, os.Chmod(path, mode))
+	return path
+}
+
+func TestCheckFilePermissions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("POSIX permission bits are not checked on Windows")
+	}
+
+	tests := []struct {
+		name      string
+		mode      os.FileMode
+		strict    bool
+		wantErr   bool
+		wantWarns int
+	}{
+		{
+			name: "owner only",
+			mode: 0600,
+		},
+		{
+			name:   "owner only in strict mode",
+			mode:   0600,
+			strict: true,
+		},
+		{
+			name:      "world readable warns",
+			mode:      0644,
+			wantWarns: 1,
+		},
+		{
+			name:      "group readable warns",
+			mode:      0640,
+			wantWarns: 1,
+		},
+		{
+			name:    "world readable fails in strict mode",
+			mode:    0644,
+			strict:  true,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := &warnRecorder{Logger: logger.Nop()}
+			loader := NewLoader(log, WithStrictPermissions(tt.strict))
+			path := writeCredentialsFile(t, tt.mode)
+
+			creds, err := loader.LoadAzure(context.Background(), AzureCredentialOptions{CredentialsFile: path})
+
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.True(t, errors.Is(err, errors.ErrCredentialInvalid),
+					"expected error code %s, got %v", errors.ErrCredentialInvalid, err)
+				var appErr *errors.Error
+				require.True(t, errors.As(err, &appErr))
+				assert.Equal(t, "0644", appErr.Fields["mode"])
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, "client", creds.ClientID)
+			}
+			assert.Len(t, log.warnings, tt.wantWarns)
+		})
+	}
+}
+
+func TestCheckFilePermissions_FollowsSymlinks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("POSIX permission bits are not checked on Windows")
+	}
+
+	target := writeCredentialsFile(t, 0644)
+	link := filepath.Join(t.TempDir(), "link.json")
+	require.NoError(t, os.Symlink(target, link))
+
+	// The link itself is 0777; the mode of the file it points to is what matters
+	log := &warnRecorder{Logger: logger.Nop()}
+	_, err := NewLoader(log).LoadAzure(context.Background(), AzureCredentialOptions{CredentialsFile: link})
+	require.NoError(t, err)
+	require.Len(t, log.warnings, 1)
+
+	var mode string
+	for _, field := range log.fields[0] {
+		if field.Key == "mode" {
+			mode = field.Value.(string)
+		}
+	}
+	assert.Equal(t, "0644", mode)
+
+	require.NoError(t, os.Chmod(target, 0600))
+	_, err = NewLoader(log, WithStrictPermissions(true)).LoadAzure(context.Background(), AzureCredentialOptions{CredentialsFile: link})
+	assert.NoError(t, err)
+}
+
+func TestCheckFilePermissions_AllFileTypes(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("POSIX permission bits are not checked on Windows")
+	}
+
+	dir := t.TempDir()
+	gcpPath := filepath.Join(dir, "gcp.json")
+	require.NoError(t, os.WriteFile(gcpPath, []byte(`{"type":"service_account"}`), 0600))
+	require.NoError(t, os.Chmod(gcpPath, 0644))
+	awsPath := filepath.Join(dir, "aws-credentials")
+	require.NoError(t, os.WriteFile(awsPath, []byte("[default]\naws_access_key_id = AKIA\naws_secret_access_key = secret\n"), 0600))
+	require.NoError(t, os.Chmod(awsPath, 0644))
+
+	loader := NewLoader(logger.Nop(), WithStrictPermissions(true))
+	ctx := context.Background()
+
+	_, err := loader.LoadGCP(ctx, gcpPath)
+	assert.True(t, errors.Is(err, errors.ErrCredentialInvalid), "GCP: %v", err)
+
+	_, err = loader.LoadAWS(ctx, AWSCredentialOptions{CredentialsFile: awsPath})
+	assert.True(t, errors.Is(err, errors.ErrCredentialInvalid), "AWS: %v", err)
+}
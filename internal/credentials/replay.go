@@ -0,0 +1,560 @@
+package credentials
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+)
+
+// FixtureVersion is the current credential fixture format version
+const FixtureVersion = 1
+
+// Fixture is a recorded credential load result. Fixtures are stored as one JSON
+// file per provider (gcp.json, aws.json, azure.json, oci.json, digitalocean.json,
+// oidc.json) in a fixture directory:
+//
+//	{
+//	  "version": 1,
+//	  "provider": "aws",
+//	  "aws": {
+//	    "access_key_id": "AKIAEXAMPLEEXAMPLE00",
+//	    "secret_access_key": "...",
+//	    "session_token": "...",
+//	    "region": "us-east-1",
+//	    "expiration": "2024-03-01T12:00:00Z"
+//	  }
+//	}
+//
+// The gcp object uses the service account key file format, the azure object
+// uses client_id, client_secret and tenant_id, the oci object uses tenancy_id,
+// user_id, fingerprint, private_key and region, the digitalocean object uses
+// token, and the oidc object uses client_secret or private_key. A failed load is
+// recorded as
+// "error": {"code": "ERR_CREDENTIAL_NOT_FOUND", "title": "..."} and replayed as
+// the same error code.
+type Fixture struct {
+	Version      int                  `json:"version"`
+	Provider     string               `json:"provider"`
+	GCP          *GCPCredentials      `json:"gcp,omitempty"`
+	AWS          *awsFixture          `json:"aws,omitempty"`
+	Azure        *azureFixture        `json:"azure,omitempty"`
+	OCI          *ociFixture          `json:"oci,omitempty"`
+	DigitalOcean *digitalOceanFixture `json:"digitalocean,omitempty"`
+	OIDC         *oidcFixture         `json:"oidc,omitempty"`
+	Error        *FixtureError        `json:"error,omitempty"`
+}
+
+// FixtureError is a recorded credential load failure
+type FixtureError struct {
+	Code  errors.ErrorCode `json:"code"`
+	Title string           `json:"title"`
+}
+
+type awsFixture struct {
+	AccessKeyID     string    `json:"access_key_id"`
+	SecretAccessKey string    `json:"secret_access_key"`
+	SessionToken    string    `json:"session_token,omitempty"`
+	Region          string    `json:"region,omitempty"`
+	Expiration      time.Time `json:"expiration,omitempty"`
+}
+
+type azureFixture struct {
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	TenantID     string `json:"tenant_id"`
+}
+
+type ociFixture struct {
+	TenancyID   string `json:"tenancy_id"`
+	UserID      string `json:"user_id"`
+	Fingerprint string `json:"fingerprint"`
+	PrivateKey  string `json:"private_key"`
+	Region      string `json:"region,omitempty"`
+}
+
+type digitalOceanFixture struct {
+	Token string `json:"token"`
+}
+
+type oidcFixture struct {
+	ClientSecret string `json:"client_secret,omitempty"`
+	PrivateKey   string `json:"private_key,omitempty"`
+}
+
+// RecordingLoader wraps a real loader and records every result as a scrubbed
+// fixture in a directory, for later use with NewReplayLoader
+type RecordingLoader struct {
+	inner Loader
+	dir   string
+}
+
+// NewRecordingLoader creates a loader that delegates to inner and records scrubbed
+// fixtures into dir. Each provider's fixture is overwritten by its latest load.
+func NewRecordingLoader(inner Loader, dir string) *RecordingLoader {
+	return &RecordingLoader{inner: inner, dir: dir}
+}
+
+// LoadGCP loads and records GCP credentials
+func (r *RecordingLoader) LoadGCP(ctx context.Context, path string) (*GCPCredentials, error) {
+	creds, err := r.inner.LoadGCP(ctx, path)
+
+	fixture := &Fixture{Version: FixtureVersion, Provider: "gcp", Error: fixtureError(err)}
+	if creds != nil {
+		recorded := *creds
+		fixture.GCP = &recorded
+	}
+	if recordErr := r.record(fixture); recordErr != nil {
+		return nil, recordErr
+	}
+
+	return creds, err
+}
+
+// LoadAWS loads and records AWS credentials
+func (r *RecordingLoader) LoadAWS(ctx context.Context, opts AWSCredentialOptions) (*AWSCredentials, error) {
+	creds, err := r.inner.LoadAWS(ctx, opts)
+
+	fixture := &Fixture{Version: FixtureVersion, Provider: "aws", Error: fixtureError(err)}
+	if creds != nil {
+		fixture.AWS = &awsFixture{
+			AccessKeyID:     creds.AccessKeyID,
+			SecretAccessKey: creds.SecretAccessKey,
+			SessionToken:    creds.SessionToken,
+			Region:          creds.Region,
+			Expiration:      creds.Expiration,
+		}
+	}
+	if recordErr := r.record(fixture); recordErr != nil {
+		return nil, recordErr
+	}
+
+	return creds, err
+}
+
+// LoadAzure loads and records Azure credentials
+func (r *RecordingLoader) LoadAzure(ctx context.Context, opts AzureCredentialOptions) (*AzureCredentials, error) {
+	creds, err := r.inner.LoadAzure(ctx, opts)
+
+	fixture := &Fixture{Version: FixtureVersion, Provider: "azure", Error: fixtureError(err)}
+	if creds != nil {
+		fixture.Azure = &azureFixture{
+			ClientID:     creds.ClientID,
+			ClientSecret: creds.ClientSecret,
+			TenantID:     creds.TenantID,
+		}
+	}
+	if recordErr := r.record(fixture); recordErr != nil {
+		return nil, recordErr
+	}
+
+	return creds, err
+}
+
+// LoadOCI loads and records OCI credentials
+func (r *RecordingLoader) LoadOCI(ctx context.Context, opts OCICredentialOptions) (*OCICredentials, error) {
+	creds, err := r.inner.LoadOCI(ctx, opts)
+
+	fixture := &Fixture{Version: FixtureVersion, Provider: "oci", Error: fixtureError(err)}
+	if creds != nil {
+		fixture.OCI = &ociFixture{
+			TenancyID:   creds.TenancyID,
+			UserID:      creds.UserID,
+			Fingerprint: creds.Fingerprint,
+			PrivateKey:  creds.PrivateKey,
+			Region:      creds.Region,
+		}
+	}
+	if recordErr := r.record(fixture); recordErr != nil {
+		return nil, recordErr
+	}
+
+	return creds, err
+}
+
+// LoadDigitalOcean loads and records DigitalOcean credentials
+func (r *RecordingLoader) LoadDigitalOcean(ctx context.Context, opts DigitalOceanCredentialOptions) (*DigitalOceanCredentials, error) {
+	creds, err := r.inner.LoadDigitalOcean(ctx, opts)
+
+	fixture := &Fixture{Version: FixtureVersion, Provider: "digitalocean", Error: fixtureError(err)}
+	if creds != nil {
+		fixture.DigitalOcean = &digitalOceanFixture{Token: creds.Token}
+	}
+	if recordErr := r.record(fixture); recordErr != nil {
+		return nil, recordErr
+	}
+
+	return creds, err
+}
+
+// LoadOIDC loads and records OIDC client credentials
+func (r *RecordingLoader) LoadOIDC(ctx context.Context, opts OIDCCredentialOptions) (*OIDCCredentials, error) {
+	creds, err := r.inner.LoadOIDC(ctx, opts)
+
+	fixture := &Fixture{Version: FixtureVersion, Provider: "oidc", Error: fixtureError(err)}
+	if creds != nil {
+		fixture.OIDC = &oidcFixture{ClientSecret: creds.ClientSecret, PrivateKey: creds.PrivateKey}
+	}
+	if recordErr := r.record(fixture); recordErr != nil {
+		return nil, recordErr
+	}
+
+	return creds, err
+}
+
+// record scrubs the fixture and writes it to the fixture directory
+func (r *RecordingLoader) record(fixture *Fixture) error {
+	ScrubFixture(fixture)
+	return writeFixture(fixturePath(r.dir, fixture.Provider), fixture)
+}
+
+// fixtureError converts a load error into its recorded form
+func fixtureError(err error) *FixtureError {
+	if err == nil {
+		return nil
+	}
+
+	var appErr *errors.Error
+	if errors.As(err, &appErr) {
+		return &FixtureError{Code: appErr.Code, Title: appErr.Title}
+	}
+	return &FixtureError{Code: errors.ErrCredentialLoadFailed, Title: err.Error()}
+}
+
+// ReplayLoader serves credentials from fixture files recorded by a RecordingLoader
+type ReplayLoader struct {
+	dir string
+}
+
+// NewReplayLoader creates a loader that serves credentials from the fixtures in dir.
+// Load options are ignored; every call for a provider returns its fixture.
+func NewReplayLoader(dir string) *ReplayLoader {
+	return &ReplayLoader{dir: dir}
+}
+
+// LoadGCP returns the recorded GCP credentials
+func (r *ReplayLoader) LoadGCP(ctx context.Context, path string) (*GCPCredentials, error) {
+	fixture, err := r.load("gcp")
+	if err != nil {
+		return nil, err
+	}
+	if fixture.GCP == nil {
+		return nil, missingFixtureCredentials("gcp")
+	}
+
+	creds := *fixture.GCP
+	raw, err := json.Marshal(creds)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrCredentialMalformed, err, "failed to encode GCP fixture")
+	}
+	creds.RawJSON = string(raw)
+
+	return &creds, nil
+}
+
+// LoadAWS returns the recorded AWS credentials
+func (r *ReplayLoader) LoadAWS(ctx context.Context, opts AWSCredentialOptions) (*AWSCredentials, error) {
+	fixture, err := r.load("aws")
+	if err != nil {
+		return nil, err
+	}
+	if fixture.AWS == nil {
+		return nil, missingFixtureCredentials("aws")
+	}
+
+	return &AWSCredentials{
+		AccessKeyID:     fixture.AWS.AccessKeyID,
+		SecretAccessKey: fixture.AWS.SecretAccessKey,
+		SessionToken:    fixture.AWS.SessionToken,
+		Region:          fixture.AWS.Region,
+		Expiration:      fixture.AWS.Expiration,
+	}, nil
+}
+
+// LoadAzure returns the recorded Azure credentials
+func (r *ReplayLoader) LoadAzure(ctx context.Context, opts AzureCredentialOptions) (*AzureCredentials, error) {
+	fixture, err := r.load("azure")
+	if err != nil {
+		return nil, err
+	}
+	if fixture.Azure == nil {
+		return nil, missingFixtureCredentials("azure")
+	}
+
+	return &AzureCredentials{
+		ClientID:     fixture.Azure.ClientID,
+		ClientSecret: fixture.Azure.ClientSecret,
+		TenantID:     fixture.Azure.TenantID,
+	}, nil
+}
+
+// LoadOCI returns the recorded OCI credentials
+func (r *ReplayLoader) LoadOCI(ctx context.Context, opts OCICredentialOptions) (*OCICredentials, error) {
+	fixture, err := r.load("oci")
+	if err != nil {
+		return nil, err
+	}
+	if fixture.OCI == nil {
+		return nil, missingFixtureCredentials("oci")
+	}
+
+	return &OCICredentials{
+		TenancyID:   fixture.OCI.TenancyID,
+		UserID:      fixture.OCI.UserID,
+		Fingerprint: fixture.OCI.Fingerprint,
+		PrivateKey:  fixture.OCI.PrivateKey,
+		Region:      fixture.OCI.Region,
+	}, nil
+}
+
+// LoadDigitalOcean returns the recorded DigitalOcean credentials
+func (r *ReplayLoader) LoadDigitalOcean(ctx context.Context, opts DigitalOceanCredentialOptions) (*DigitalOceanCredentials, error) {
+	fixture, err := r.load("digitalocean")
+	if err != nil {
+		return nil, err
+	}
+	if fixture.DigitalOcean == nil {
+		return nil, missingFixtureCredentials("digitalocean")
+	}
+
+	return &DigitalOceanCredentials{Token: fixture.DigitalOcean.Token}, nil
+}
+
+// LoadOIDC returns the recorded OIDC client credentials
+func (r *ReplayLoader) LoadOIDC(ctx context.Context, opts OIDCCredentialOptions) (*OIDCCredentials, error) {
+	fixture, err := r.load("oidc")
+	if err != nil {
+		return nil, err
+	}
+	if fixture.OIDC == nil {
+		return nil, missingFixtureCredentials("oidc")
+	}
+
+	return &OIDCCredentials{
+		ClientSecret: fixture.OIDC.ClientSecret,
+		PrivateKey:   fixture.OIDC.PrivateKey,
+	}, nil
+}
+
+// load reads a provider fixture and replays a recorded error
+func (r *ReplayLoader) load(provider string) (*Fixture, error) {
+	path := fixturePath(r.dir, provider)
+	fixture, err := readFixture(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if fixture.Error != nil {
+		return nil, errors.New(fixture.Error.Code, fixture.Error.Title).
+			WithField("fixture", filepath.Base(path))
+	}
+	return fixture, nil
+}
+
+func missingFixtureCredentials(provider string) error {
+	return errors.New(
+		errors.ErrCredentialMalformed,
+		"credential fixture has neither credentials nor an error",
+	).WithField("provider", provider)
+}
+
+// ScrubFixtureDir scrubs every fixture in dir in place. Use it on fixtures that were
+// recorded by hand or edited after recording, before committing them.
+func ScrubFixtureDir(dir string) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return errors.Wrap(errors.ErrInternal, err, "failed to list credential fixtures")
+	}
+
+	for _, path := range paths {
+		fixture, err := readFixture(path)
+		if err != nil {
+			return err
+		}
+		ScrubFixture(fixture)
+		if err := writeFixture(path, fixture); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ScrubFixture replaces secret values with fakes of the same shape: PEM keys stay
+// PEM-encoded, AWS key IDs keep their AKIA/ASIA prefix and length, DigitalOcean
+// tokens keep their dop_v1_ style prefix and length, and identifiers
+// that look like UUIDs stay UUIDs. Non-secret values such as project IDs, client
+// emails, OCIDs and regions are kept. Scrubbing is idempotent.
+func ScrubFixture(fixture *Fixture) {
+	if gcp := fixture.GCP; gcp != nil {
+		gcp.PrivateKey = fakePEM(gcp.PrivateKey)
+		gcp.PrivateKeyID = fakeOfLength(gcp.PrivateKeyID, "0")
+		gcp.ClientID = fakeOfLength(gcp.ClientID, "1")
+	}
+
+	if aws := fixture.AWS; aws != nil {
+		aws.AccessKeyID = fakeAccessKeyID(aws.AccessKeyID)
+		aws.SecretAccessKey = fakeOfLength(aws.SecretAccessKey, "EXAMPLEKEY")
+		aws.SessionToken = fakeOfLength(aws.SessionToken, "EXAMPLESESSION")
+	}
+
+	if azure := fixture.Azure; azure != nil {
+		azure.ClientID = fakeUUID(azure.ClientID, "1")
+		azure.TenantID = fakeUUID(azure.TenantID, "2")
+		azure.ClientSecret = fakeOfLength(azure.ClientSecret, "fake-client-secret-")
+	}
+
+	if oci := fixture.OCI; oci != nil {
+		oci.PrivateKey = fakePEM(oci.PrivateKey)
+		oci.Fingerprint = fakeOfLength(oci.Fingerprint, "00:")
+	}
+
+	if do := fixture.DigitalOcean; do != nil {
+		do.Token = fakeDigitalOceanToken(do.Token)
+	}
+
+	if oidc := fixture.OIDC; oidc != nil {
+		oidc.ClientSecret = fakeOfLength(oidc.ClientSecret, "fake-client-secret-")
+		oidc.PrivateKey = fakePEM(oidc.PrivateKey)
+	}
+}
+
+// fakeOfLength returns pattern repeated to the length of value ("" stays "")
+func fakeOfLength(value, pattern string) string {
+	if value == "" {
+		return ""
+	}
+	return strings.Repeat(pattern, len(value)/len(pattern)+1)[:len(value)]
+}
+
+// fakeAccessKeyID keeps the 4-character key type prefix (AKIA, ASIA) and the length
+func fakeAccessKeyID(id string) string {
+	if len(id) <= 4 {
+		return fakeOfLength(id, "X")
+	}
+	return id[:4] + fakeOfLength(id[4:], "EXAMPLE0")
+}
+
+// fakeDigitalOceanToken keeps the token type prefix (dop_v1_, doo_v1_) and the length
+func fakeDigitalOceanToken(token string) string {
+	if len(token) > 7 && token[3] == '_' && token[6] == '_' {
+		return token[:7] + fakeOfLength(token[7:], "0")
+	}
+	return fakeOfLength(token, "0")
+}
+
+// fakeUUID replaces each hex digit of a UUID-shaped value with digit, keeping dashes
+func fakeUUID(value, digit string) string {
+	var b strings.Builder
+	for _, r := range value {
+		if r == '-' {
+			b.WriteRune(r)
+		} else {
+			b.WriteString(digit)
+		}
+	}
+	return b.String()
+}
+
+// fakePEM replaces the base64 body of each PEM block with zero bytes of the same size
+func fakePEM(value string) string {
+	if value == "" {
+		return ""
+	}
+
+	var out []string
+	var body strings.Builder
+	for _, line := range strings.Split(strings.TrimSuffix(value, "\n"), "\n") {
+		if strings.HasPrefix(line, "-----") {
+			if body.Len() > 0 {
+				out = append(out, wrapBase64(fakeBase64(body.String()))...)
+				body.Reset()
+			}
+			out = append(out, line)
+			continue
+		}
+		body.WriteString(strings.TrimSpace(line))
+	}
+	if body.Len() > 0 {
+		out = append(out, wrapBase64(fakeBase64(body.String()))...)
+	}
+
+	scrubbed := strings.Join(out, "\n")
+	if strings.HasSuffix(value, "\n") {
+		scrubbed += "\n"
+	}
+	return scrubbed
+}
+
+// fakeBase64 returns the base64 encoding of zero bytes as long as the decoded input
+func fakeBase64(encoded string) string {
+	size := base64.StdEncoding.DecodedLen(len(encoded))
+	if decoded, err := base64.StdEncoding.DecodeString(encoded); err == nil {
+		size = len(decoded)
+	}
+	return base64.StdEncoding.EncodeToString(make([]byte, size))
+}
+
+// wrapBase64 splits base64 text into 64-character PEM lines
+func wrapBase64(s string) []string {
+	var lines []string
+	for len(s) > 64 {
+		lines = append(lines, s[:64])
+		s = s[64:]
+	}
+	return append(lines, s)
+}
+
+func fixturePath(dir, provider string) string {
+	return filepath.Join(dir, provider+".json")
+}
+
+func readFixture(path string) (*Fixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, errors.New(
+				errors.ErrCredentialNotFound,
+				"credential fixture not found",
+			).WithField("fixture", path)
+		}
+		return nil, errors.Wrap(errors.ErrCredentialLoadFailed, err, "failed to read credential fixture").
+			WithField("fixture", path)
+	}
+
+	var fixture Fixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return nil, errors.Wrap(errors.ErrCredentialMalformed, err, "failed to parse credential fixture").
+			WithField("fixture", path)
+	}
+	if fixture.Version != FixtureVersion {
+		return nil, errors.New(
+			errors.ErrCredentialMalformed,
+			"unsupported credential fixture version",
+		).WithFields(map[string]interface{}{
+			"fixture": path,
+			"version": fixture.Version,
+		})
+	}
+	return &fixture, nil
+}
+
+func writeFixture(path string, fixture *Fixture) error {
+	data, err := json.MarshalIndent(fixture, "", "  ")
+	if err != nil {
+		return errors.Wrap(errors.ErrInternal, err, "failed to encode credential fixture")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return errors.Wrap(errors.ErrInternal, err, "failed to create fixture directory")
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0600); err != nil {
+		return errors.Wrap(errors.ErrInternal, err, "failed to write credential fixture").
+			WithField("fixture", path)
+	}
+	return nil
+}
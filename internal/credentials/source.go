@@ -0,0 +1,145 @@
+package credentials
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+)
+
+// SecretSource fetches credentials kept outside the filesystem, e.g. in a secret
+// manager. Credentials file paths of the form SCHEME://REF are fetched from the
+// source registered for SCHEME, and the returned bytes are parsed as the file would be.
+type SecretSource interface {
+	// Fetch returns the content of the secret ref names
+	Fetch(ctx context.Context, ref string) ([]byte, error)
+}
+
+// fetchSecretFunc fetches ref from a secret source; replaced in offline loaders
+type fetchSecretFunc func(ctx context.Context, source SecretSource, ref string) ([]byte, error)
+
+// SecretSourceFactory creates a SecretSource sending its requests through client,
+// which is nil when the loader has no HTTP client configured
+type SecretSourceFactory func(client *http.Client) SecretSource
+
+var (
+	secretSourcesMu sync.RWMutex
+	secretSources   = make(map[string]SecretSourceFactory)
+
+	// secretURIPattern matches SCHEME://REF; single-letter schemes are left to
+	// Windows drive paths
+	secretURIPattern = regexp.MustCompile(`^([a-zA-Z][a-zA-Z0-9+.-]+)://(.+)$`)
+)
+
+// RegisterSecretSource registers the secret source of a URI scheme, e.g. "vault". It
+// panics when the scheme is already registered.
+func RegisterSecretSource(scheme string, factory SecretSourceFactory) {
+	secretSourcesMu.Lock()
+	defer secretSourcesMu.Unlock()
+
+	scheme = strings.ToLower(scheme)
+	if _, exists := secretSources[scheme]; exists {
+		panic(fmt.Sprintf("secret source %s already registered", scheme))
+	}
+	secretSources[scheme] = factory
+}
+
+// SecretSchemes returns the registered secret source schemes, sorted
+func SecretSchemes() []string {
+	secretSourcesMu.RLock()
+	defer secretSourcesMu.RUnlock()
+
+	schemes := make([]string, 0, len(secretSources))
+	for scheme := range secretSources {
+		schemes = append(schemes, scheme)
+	}
+	sort.Strings(schemes)
+	return schemes
+}
+
+// IsSecretURI reports whether a credentials path is a SCHEME://REF secret reference
+// rather than a file
+func IsSecretURI(path string) bool {
+	return secretURIPattern.MatchString(path)
+}
+
+// WithHTTPClient sets the HTTP client secret sources send their requests through
+func WithHTTPClient(client *http.Client) LoaderOption {
+	return func(l *DefaultLoader) {
+		l.httpClient = client
+	}
+}
+
+// readCredentialsFile reads a credentials file, or fetches it from the secret source
+// of its scheme when path is a SCHEME://REF secret reference. kind names the file in
+// errors, e.g. "GCP credentials".
+func (l *DefaultLoader) readCredentialsFile(ctx context.Context, path, kind string) ([]byte, error) {
+	match := secretURIPattern.FindStringSubmatch(path)
+	if match == nil {
+		data, err := readFileLimited(path)
+		if err != nil {
+			return nil, errors.Wrap(
+				errors.ErrCredentialLoadFailed,
+				err,
+				"failed to read "+kind+" file",
+			).WithField("path", redactPath(path))
+		}
+		if len(data) > maxCredentialsFileSize {
+			return nil, oversizeError(path, kind)
+		}
+		return data, nil
+	}
+
+	scheme, ref := strings.ToLower(match[1]), match[2]
+	secretSourcesMu.RLock()
+	factory, exists := secretSources[scheme]
+	secretSourcesMu.RUnlock()
+	if !exists {
+		return nil, errors.New(
+			errors.ErrInvalidArgument,
+			fmt.Sprintf("no secret source for %s:// %s references", scheme, kind),
+		).WithField("path", redactPath(path)).
+			WithDetail("supported schemes: " + strings.Join(SecretSchemes(), ", "))
+	}
+
+	fetch := l.fetchSecret
+	if fetch == nil {
+		fetch = fetchFromSource
+	}
+	data, err := fetch(ctx, factory(l.httpClient), ref)
+	if err != nil {
+		var appErr *errors.Error
+		if errors.As(err, &appErr) {
+			return nil, appErr.WithField("path", redactPath(path))
+		}
+		return nil, errors.Wrap(
+			errors.ErrCredentialLoadFailed,
+			err,
+			fmt.Sprintf("failed to fetch %s from %s", kind, scheme),
+		).WithField("path", redactPath(path))
+	}
+	return data, nil
+}
+
+// readFileLimited reads at most one byte more than maxCredentialsFileSize of path, so
+// that an oversized file is detected without reading all of it
+func readFileLimited(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(io.LimitReader(f, maxCredentialsFileSize+1))
+}
+
+// fetchFromSource fetches ref from source
+func fetchFromSource(ctx context.Context, source SecretSource, ref string) ([]byte, error) {
+	return source.Fetch(ctx, ref)
+}
@@ -0,0 +1,72 @@
+package credentials
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/smithy-go"
+)
+
+func init() {
+	RegisterSecretSource("awssm", func(client *http.Client) SecretSource {
+		return &SecretsManagerSource{Client: client}
+	})
+}
+
+// SecretsManagerSource fetches credentials from AWS Secrets Manager with the SDK default
+// credential chain. A reference is a secret name or ARN; the region is the ARN's, or
+// the default chain's for names. AWS_ENDPOINT_URL_SECRETS_MANAGER overrides the
+// endpoint, e.g. with a VPC endpoint. The secret string, or the decoded secret binary,
+// is returned.
+type SecretsManagerSource struct {
+	// Client sends the Secrets Manager requests; nil uses the SDK default. The
+	// credential chain keeps the SDK transport, which honors AWS_CA_BUNDLE.
+	Client *http.Client
+}
+
+// Fetch implements SecretSource
+func (s *SecretsManagerSource) Fetch(ctx context.Context, ref string) ([]byte, error) {
+	var loadOpts []func(*awsconfig.LoadOptions) error
+	// arn:PARTITION:secretsmanager:REGION:ACCOUNT:secret:NAME
+	if parts := strings.SplitN(ref, ":", 6); len(parts) == 6 && parts[0] == "arn" && parts[3] != "" {
+		loadOpts = append(loadOpts, awsconfig.WithRegion(parts[3]))
+	}
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, loadOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS configuration: %w", err)
+	}
+	if cfg.Region == "" {
+		return nil, fmt.Errorf("no AWS region for secret %s: use its ARN or set AWS_REGION", ref)
+	}
+
+	client := secretsmanager.NewFromConfig(cfg, func(o *secretsmanager.Options) {
+		if s.Client != nil {
+			o.HTTPClient = s.Client
+		}
+	})
+	out, err := client.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{SecretId: aws.String(ref)})
+	if err != nil {
+		var apiErr smithy.APIError
+		var respErr *awshttp.ResponseError
+		if stderrors.As(err, &apiErr) && stderrors.As(err, &respErr) {
+			return nil, fmt.Errorf("secrets manager returned HTTP %d for %s: %s %s",
+				respErr.HTTPStatusCode(), ref, apiErr.ErrorCode(), apiErr.ErrorMessage())
+		}
+		return nil, fmt.Errorf("secrets manager request failed: %w", err)
+	}
+
+	if out.SecretString != nil {
+		return []byte(*out.SecretString), nil
+	}
+	if len(out.SecretBinary) > 0 {
+		return out.SecretBinary, nil
+	}
+	return nil, fmt.Errorf("secret %s has no value", ref)
+}
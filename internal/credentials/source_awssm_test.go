@@ -0,0 +1,62 @@
+package credentials
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecretsManagerSource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secretsmanager.GetSecretValue", r.Header.Get("X-Amz-Target"))
+		assert.Contains(t, r.Header.Get("Authorization"), "Credential=AKIASOURCEEXAMPLE/")
+		assert.Contains(t, r.Header.Get("Authorization"), "/secretsmanager/aws4_request")
+
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		var input struct{ SecretId string }
+		require.NoError(t, json.Unmarshal(body, &input))
+
+		switch input.SecretId {
+		case "ci/azure":
+			assert.Contains(t, r.Header.Get("Authorization"), "/us-west-2/")
+			_, _ = w.Write([]byte(`{"Name": "ci/azure", "SecretString": "{\"client_id\": \"id\"}"}`))
+		case "arn:aws:secretsmanager:eu-central-1:123456789012:secret:ci/key-AbCdEf":
+			assert.Contains(t, r.Header.Get("Authorization"), "/eu-central-1/", "the region of the ARN is used")
+			_, _ = w.Write([]byte(`{"SecretBinary": "a2V5LWJ5dGVz"}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"__type": "com.amazonaws.secretsmanager#ResourceNotFoundException", "message": "Secrets Manager can't find the specified secret."}`))
+		}
+	}))
+	defer server.Close()
+
+	t.Setenv("AWS_ENDPOINT_URL_SECRETS_MANAGER", server.URL)
+	t.Setenv("AWS_REGION", "us-west-2")
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIASOURCEEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "source-secret-key")
+	t.Setenv("AWS_SESSION_TOKEN", "")
+	t.Setenv("AWS_PROFILE", "")
+	t.Setenv("AWS_CONFIG_FILE", "/nonexistent")
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", "/nonexistent")
+	ctx := context.Background()
+	source := &SecretsManagerSource{Client: server.Client()}
+
+	data, err := source.Fetch(ctx, "ci/azure")
+	require.NoError(t, err)
+	assert.Equal(t, `{"client_id": "id"}`, string(data))
+
+	data, err = source.Fetch(ctx, "arn:aws:secretsmanager:eu-central-1:123456789012:secret:ci/key-AbCdEf")
+	require.NoError(t, err)
+	assert.Equal(t, "key-bytes", string(data))
+
+	_, err = source.Fetch(ctx, "ci/missing")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "secrets manager returned HTTP 400 for ci/missing: ResourceNotFoundException")
+}
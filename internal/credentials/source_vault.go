@@ -0,0 +1,145 @@
+package credentials
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// maxSecretSize bounds the secret source responses read into memory
+const maxSecretSize = 1 << 20
+
+func init() {
+	RegisterSecretSource("vault", func(client *http.Client) SecretSource {
+		return &VaultSource{Client: client}
+	})
+}
+
+// VaultSource fetches credentials from HashiCorp Vault with the address, token and
+// namespace of the Vault CLI: VAULT_ADDR, VAULT_TOKEN (default: ~/.vault-token) and
+// VAULT_NAMESPACE. A reference is the API path of a secret, e.g. secret/data/gcp-sa
+// for the KV version 2 secret gcp-sa, optionally followed by #FIELD. The secret's only
+// field, or FIELD, is returned as is; a secret with several fields and no FIELD is
+// returned as a JSON object, as a service account key stored field by field would be.
+type VaultSource struct {
+	// Client sends the Vault requests; nil uses http.DefaultClient
+	Client *http.Client
+}
+
+// Fetch implements SecretSource
+func (s *VaultSource) Fetch(ctx context.Context, ref string) ([]byte, error) {
+	path, field, _ := strings.Cut(ref, "#")
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil, fmt.Errorf("vault reference has no secret path")
+	}
+
+	addr := strings.TrimRight(os.Getenv("VAULT_ADDR"), "/")
+	if addr == "" {
+		return nil, fmt.Errorf("VAULT_ADDR is not set")
+	}
+	token, err := vaultToken()
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, addr+"/v1/"+path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid vault request: %w", err)
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if namespace := os.Getenv("VAULT_NAMESPACE"); namespace != "" {
+		req.Header.Set("X-Vault-Namespace", namespace)
+	}
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("vault request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxSecretSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read vault response: %w", err)
+	}
+
+	var result struct {
+		Data   map[string]interface{} `json:"data"`
+		Errors []string               `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil && resp.StatusCode == http.StatusOK {
+		return nil, fmt.Errorf("failed to parse vault response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		message := strings.Join(result.Errors, "; ")
+		if message == "" {
+			message = http.StatusText(resp.StatusCode)
+		}
+		return nil, fmt.Errorf("vault returned HTTP %d for %s: %s", resp.StatusCode, path, message)
+	}
+
+	data := result.Data
+	// KV version 2 nests the secret under data.data, next to its metadata
+	if inner, ok := data["data"].(map[string]interface{}); ok {
+		if _, ok := data["metadata"]; ok {
+			data = inner
+		}
+	}
+	return vaultSecretValue(data, field)
+}
+
+// vaultSecretValue returns field of a Vault secret, its only field when field is
+// empty, or all of its fields as a JSON object
+func vaultSecretValue(data map[string]interface{}, field string) ([]byte, error) {
+	if field == "" && len(data) == 1 {
+		for key := range data {
+			field = key
+		}
+	}
+	if field == "" {
+		if len(data) == 0 {
+			return nil, fmt.Errorf("vault secret is empty")
+		}
+		return json.Marshal(data)
+	}
+
+	value, ok := data[field]
+	if !ok {
+		fields := make([]string, 0, len(data))
+		for key := range data {
+			fields = append(fields, key)
+		}
+		sort.Strings(fields)
+		return nil, fmt.Errorf("vault secret has no field %q (fields: %s)", field, strings.Join(fields, ", "))
+	}
+	if text, ok := value.(string); ok {
+		return []byte(text), nil
+	}
+	return json.Marshal(value)
+}
+
+// vaultToken returns VAULT_TOKEN, or the token the Vault CLI stored at login
+func vaultToken() (string, error) {
+	if token := os.Getenv("VAULT_TOKEN"); token != "" {
+		return token, nil
+	}
+	home, err := os.UserHomeDir()
+	if err == nil {
+		if data, err := os.ReadFile(filepath.Join(home, ".vault-token")); err == nil {
+			if token := strings.TrimSpace(string(data)); token != "" {
+				return token, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("no vault token: set VAULT_TOKEN or log in with the Vault CLI")
+}
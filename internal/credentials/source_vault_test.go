@@ -0,0 +1,108 @@
+package credentials
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVaultSource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "test-token" {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"errors": ["permission denied"]}`))
+			return
+		}
+		assert.Equal(t, "team-a", r.Header.Get("X-Vault-Namespace"))
+
+		switch r.URL.Path {
+		case "/v1/secret/data/gcp-sa":
+			// KV version 2
+			_, _ = w.Write([]byte(`{"data": {"data": {"credentials.json": "{\"type\": \"service_account\"}"}, "metadata": {"version": 3}}}`))
+		case "/v1/secret/data/fields":
+			_, _ = w.Write([]byte(`{"data": {"data": {"client_id": "id", "client_secret": "secret"}, "metadata": {"version": 1}}}`))
+		case "/v1/kv/do-token":
+			// KV version 1
+			_, _ = w.Write([]byte(`{"data": {"token": "dop_v1_token"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"errors": []}`))
+		}
+	}))
+	defer server.Close()
+
+	t.Setenv("VAULT_ADDR", server.URL+"/")
+	t.Setenv("VAULT_TOKEN", "test-token")
+	t.Setenv("VAULT_NAMESPACE", "team-a")
+	ctx := context.Background()
+	source := &VaultSource{Client: server.Client()}
+
+	tests := []struct {
+		name    string
+		ref     string
+		want    string
+		wantErr string
+	}{
+		{
+			name: "only field of a KV v2 secret",
+			ref:  "secret/data/gcp-sa",
+			want: `{"type": "service_account"}`,
+		},
+		{
+			name: "only field of a KV v1 secret",
+			ref:  "kv/do-token",
+			want: "dop_v1_token",
+		},
+		{
+			name: "selected field",
+			ref:  "secret/data/fields#client_secret",
+			want: "secret",
+		},
+		{
+			name: "several fields as JSON",
+			ref:  "secret/data/fields",
+			want: `{"client_id":"id","client_secret":"secret"}`,
+		},
+		{
+			name:    "missing field",
+			ref:     "secret/data/fields#private_key",
+			wantErr: `vault secret has no field "private_key" (fields: client_id, client_secret)`,
+		},
+		{
+			name:    "missing secret",
+			ref:     "secret/data/missing",
+			wantErr: "vault returned HTTP 404 for secret/data/missing: Not Found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := source.Fetch(ctx, tt.ref)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, string(data))
+		})
+	}
+
+	t.Run("rejected token", func(t *testing.T) {
+		t.Setenv("VAULT_TOKEN", "other-token")
+		_, err := source.Fetch(ctx, "secret/data/gcp-sa")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "vault returned HTTP 403 for secret/data/gcp-sa: permission denied")
+	})
+
+	t.Run("no address", func(t *testing.T) {
+		t.Setenv("VAULT_ADDR", "")
+		_, err := source.Fetch(ctx, "secret/data/gcp-sa")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "VAULT_ADDR is not set")
+	})
+}
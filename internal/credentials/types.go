@@ -1,5 +1,7 @@
 package credentials
 
+import "time"
+
 // GCPCredentials represents GCP service account credentials
 type GCPCredentials struct {
 	Type                    string `json:"type"`
@@ -13,6 +15,12 @@ type GCPCredentials struct {
 	AuthProviderX509CertURL string `json:"auth_provider_x509_cert_url"`
 	ClientX509CertURL       string `json:"client_x509_cert_url"`
 
+	// UniverseDomain is the Google Cloud universe of the service account, such as a
+	// sovereign cloud; empty means googleapis.com
+	UniverseDomain string `json:"universe_domain,omitempty"`
+
+	// RawJSON is the file as read, which is handed to the Google libraries so that
+	// fields not modeled here still reach them
 	RawJSON string `json:"-"`
 }
 
@@ -22,6 +30,7 @@ type AWSCredentials struct {
 	SecretAccessKey string
 	SessionToken    string // Optional
 	Region          string
+	Expiration      time.Time // Optional, zero for long-lived credentials
 }
 
 // AzureCredentials represents Azure service principal credentials
@@ -29,6 +38,19 @@ type AzureCredentials struct {
 	ClientID     string
 	ClientSecret string
 	TenantID     string
+	// SubscriptionID is set when the credentials file names a subscription
+	SubscriptionID string
+
+	// ClientCertificatePath is the PEM or PKCS#12 certificate and private key of a
+	// certificate-based service principal
+	ClientCertificatePath string
+	// ClientCertificatePassword decrypts a PKCS#12 ClientCertificatePath (optional)
+	ClientCertificatePassword string
+	// ClientCertificate is the content of ClientCertificatePath, checked by the loader
+	ClientCertificate string
+	// SendCertificateChain sends the certificate chain with token requests, for
+	// subject name and issuer authentication
+	SendCertificateChain bool
 }
 
 // AWSCredentialOptions holds options for loading AWS credentials
@@ -45,6 +67,9 @@ type AWSCredentialOptions struct {
 	// SessionToken for temporary credentials
 	SessionToken string
 
+	// SessionExpiration is when explicitly provided session credentials expire (optional)
+	SessionExpiration time.Time
+
 	// Region for AWS operations
 	Region string
 
@@ -54,8 +79,25 @@ type AWSCredentialOptions struct {
 	// UseSharedConfig determines if credentials should be loaded from ~/.aws/
 	UseSharedConfig bool
 
+	// ConfigFile path to AWS shared config file (e.g. ~/.aws/config)
+	ConfigFile string
+
 	// Profile name for shared config
 	Profile string
+
+	// CredentialProcessTimeout bounds credential_process execution (default: 30s)
+	CredentialProcessTimeout time.Duration
+
+	// WebIdentityTokenFile path to an OIDC token exchanged via AssumeRoleWithWebIdentity
+	// (default: AWS_WEB_IDENTITY_TOKEN_FILE when UseEnvironment is set)
+	WebIdentityTokenFile string
+
+	// RoleARN assumed with the web identity token (default: AWS_ROLE_ARN)
+	RoleARN string
+
+	// UseInstanceMetadata falls back to the EC2 instance metadata service or the ECS
+	// container credentials endpoint when no file or environment credentials are found
+	UseInstanceMetadata bool
 }
 
 // AzureCredentialOptions holds options for loading Azure credentials
@@ -72,9 +114,83 @@ type AzureCredentialOptions struct {
 	// TenantID explicitly provided
 	TenantID string
 
+	// ClientCertificatePath explicitly provided, a PEM or PKCS#12 file
+	ClientCertificatePath string
+
+	// ClientCertificatePassword decrypts a PKCS#12 ClientCertificatePath
+	ClientCertificatePassword string
+
+	// SendCertificateChain sends the certificate chain with token requests
+	SendCertificateChain bool
+
 	// UseEnvironment determines if credentials should be loaded from environment
 	UseEnvironment bool
 
 	// UseManagedIdentity determines if managed identity should be used
 	UseManagedIdentity bool
 }
+
+// OCICredentials represents an OCI API signing key and the identity it belongs to
+type OCICredentials struct {
+	TenancyID   string
+	UserID      string
+	Fingerprint string
+	PrivateKey  string // PEM-encoded RSA key
+	Region      string
+}
+
+// OCICredentialOptions holds options for loading OCI credentials
+type OCICredentialOptions struct {
+	// ConfigFile path to an OCI CLI config file (default: OCI_CLI_CONFIG_FILE, then ~/.oci/config)
+	ConfigFile string
+
+	// Profile in the config file (default: OCI_CLI_PROFILE, then DEFAULT)
+	Profile string
+
+	// TenancyID explicitly provided; overrides the config file
+	TenancyID string
+
+	// UserID explicitly provided; overrides the config file
+	UserID string
+
+	// Region explicitly provided; overrides the config file
+	Region string
+
+	// UseEnvironment determines if OCI_CLI_* environment variables are read when no config file is found
+	UseEnvironment bool
+}
+
+// DigitalOceanCredentials represents a DigitalOcean API token
+type DigitalOceanCredentials struct {
+	Token string
+}
+
+// DigitalOceanCredentialOptions holds options for loading DigitalOcean credentials
+type DigitalOceanCredentialOptions struct {
+	// CredentialsFile path to a file holding the API token (default: DIGITALOCEAN_CREDENTIALS_FILE)
+	CredentialsFile string
+
+	// UseEnvironment determines if DIGITALOCEAN_TOKEN is read when no credentials file is set
+	UseEnvironment bool
+}
+
+// OIDCCredentials represents the client credentials of an OIDC client: a client
+// secret, or a private key that signs private_key_jwt client assertions
+type OIDCCredentials struct {
+	ClientSecret string
+
+	// PrivateKey is a PEM-encoded RSA or EC private key
+	PrivateKey string
+}
+
+// OIDCCredentialOptions holds options for loading OIDC client credentials
+type OIDCCredentialOptions struct {
+	// CredentialsFile path to a file holding the client secret (default: OIDC_CLIENT_SECRET_FILE)
+	CredentialsFile string
+
+	// PrivateKeyFile path to a PEM private key for private_key_jwt; takes precedence over the secret
+	PrivateKeyFile string
+
+	// UseEnvironment determines if OIDC_CLIENT_SECRET is read when no file is set
+	UseEnvironment bool
+}
@@ -1,9 +1,58 @@
 package credentials
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
 )
 
+// credentialField is a required credential field and the settings that supply it
+type credentialField struct {
+	// key is the JSON or INI key of the field in a credentials file
+	key string
+
+	// env is the environment variable that supplies it; empty for file-only fields
+	env string
+}
+
+// incompleteCredentials returns one ErrCredentialInvalid naming every missing field, in
+// the order they were checked so the message is stable for scripts. The missing field
+// keys and their environment variables are also listed in the error fields.
+func incompleteCredentials(provider, file string, missing []credentialField) error {
+	keys := make([]string, 0, len(missing))
+	var envs []string
+	for _, field := range missing {
+		keys = append(keys, field.key)
+		if field.env != "" {
+			envs = append(envs, field.env)
+		}
+	}
+
+	detail := fmt.Sprintf("set %s in %s", joinList(keys), file)
+	if len(envs) > 0 {
+		detail = fmt.Sprintf("set %s, or %s in %s", joinList(envs), joinList(keys), file)
+	}
+
+	err := errors.New(
+		errors.ErrCredentialInvalid,
+		fmt.Sprintf("%s credentials incomplete: missing %s", provider, strings.Join(keys, ", ")),
+	).WithField("missing", keys).
+		WithDetail(detail)
+	if len(envs) > 0 {
+		err = err.WithField("env_vars", envs)
+	}
+	return err
+}
+
+// joinList joins items as "a", "a and b" or "a, b and c"
+func joinList(items []string) string {
+	if len(items) <= 1 {
+		return strings.Join(items, "")
+	}
+	return strings.Join(items[:len(items)-1], ", ") + " and " + items[len(items)-1]
+}
+
 // validateGCPCredentials validates GCP service account credentials
 func (l *DefaultLoader) validateGCPCredentials(creds *GCPCredentials) error {
 	if creds.Type != "service_account" {
@@ -14,25 +63,18 @@ func (l *DefaultLoader) validateGCPCredentials(creds *GCPCredentials) error {
 			WithDetail("expected 'service_account'")
 	}
 
+	var missing []credentialField
 	if creds.ProjectID == "" {
-		return errors.New(
-			errors.ErrCredentialMalformed,
-			"GCP credentials missing project_id",
-		)
+		missing = append(missing, credentialField{key: "project_id"})
 	}
-
 	if creds.PrivateKey == "" {
-		return errors.New(
-			errors.ErrCredentialMalformed,
-			"GCP credentials missing private_key",
-		)
+		missing = append(missing, credentialField{key: "private_key"})
 	}
-
 	if creds.ClientEmail == "" {
-		return errors.New(
-			errors.ErrCredentialMalformed,
-			"GCP credentials missing client_email",
-		)
+		missing = append(missing, credentialField{key: "client_email"})
+	}
+	if len(missing) > 0 {
+		return incompleteCredentials("GCP", "the service account key file", missing)
 	}
 
 	return nil
@@ -40,18 +82,15 @@ func (l *DefaultLoader) validateGCPCredentials(creds *GCPCredentials) error {
 
 // validateAWSCredentials validates AWS credentials
 func (l *DefaultLoader) validateAWSCredentials(creds *AWSCredentials) error {
+	var missing []credentialField
 	if creds.AccessKeyID == "" {
-		return errors.New(
-			errors.ErrCredentialNotFound,
-			"AWS access key ID not found",
-		).WithDetail("set AWS_ACCESS_KEY_ID environment variable")
+		missing = append(missing, credentialField{key: "aws_access_key_id", env: "AWS_ACCESS_KEY_ID"})
 	}
-
 	if creds.SecretAccessKey == "" {
-		return errors.New(
-			errors.ErrCredentialNotFound,
-			"AWS secret access key not found",
-		).WithDetail("set AWS_SECRET_ACCESS_KEY environment variable")
+		missing = append(missing, credentialField{key: "aws_secret_access_key", env: "AWS_SECRET_ACCESS_KEY"})
+	}
+	if len(missing) > 0 {
+		return incompleteCredentials("AWS", "the credentials file", missing)
 	}
 
 	return nil
@@ -59,25 +98,103 @@ func (l *DefaultLoader) validateAWSCredentials(creds *AWSCredentials) error {
 
 // validateAzureCredentials validates Azure credentials
 func (l *DefaultLoader) validateAzureCredentials(creds *AzureCredentials) error {
+	var missing []credentialField
 	if creds.ClientID == "" {
+		missing = append(missing, credentialField{key: "client_id", env: "AZURE_CLIENT_ID"})
+	}
+	// A certificate-based service principal has no client secret
+	if creds.ClientSecret == "" && creds.ClientCertificatePath == "" {
+		missing = append(missing, credentialField{key: "client_secret", env: "AZURE_CLIENT_SECRET"})
+	}
+	if creds.TenantID == "" {
+		missing = append(missing, credentialField{key: "tenant_id", env: "AZURE_TENANT_ID"})
+	}
+	if len(missing) > 0 {
+		return incompleteCredentials("Azure", "the AZURE_CREDENTIALS_FILE JSON", missing)
+	}
+
+	return nil
+}
+
+// validateOCICredentials validates OCI credentials, naming every missing field
+func (l *DefaultLoader) validateOCICredentials(creds *OCICredentials) error {
+	var missing []string
+	if creds.TenancyID == "" {
+		missing = append(missing, "tenancy")
+	}
+	if creds.UserID == "" {
+		missing = append(missing, "user")
+	}
+	if creds.Fingerprint == "" {
+		missing = append(missing, "fingerprint")
+	}
+	if creds.PrivateKey == "" {
+		missing = append(missing, "key_file")
+	}
+
+	if len(missing) > 0 {
 		return errors.New(
 			errors.ErrCredentialNotFound,
-			"Azure client ID not found",
-		).WithDetail("set AZURE_CLIENT_ID environment variable")
+			"OCI credentials incomplete",
+		).WithField("missing", missing).
+			WithDetail("set them in the OCI config file or OCI_CLI_TENANCY, OCI_CLI_USER, OCI_CLI_FINGERPRINT and OCI_CLI_KEY_FILE")
 	}
 
-	if creds.ClientSecret == "" {
+	if !strings.Contains(creds.PrivateKey, "PRIVATE KEY-----") {
+		return errors.New(
+			errors.ErrCredentialMalformed,
+			"OCI API key is not a PEM private key",
+		)
+	}
+
+	return nil
+}
+
+// validateDigitalOceanCredentials validates a DigitalOcean API token
+func (l *DefaultLoader) validateDigitalOceanCredentials(creds *DigitalOceanCredentials) error {
+	if creds.Token == "" {
 		return errors.New(
 			errors.ErrCredentialNotFound,
-			"Azure client secret not found",
-		).WithDetail("set AZURE_CLIENT_SECRET environment variable")
+			"DigitalOcean API token not found",
+		).WithDetail("set DIGITALOCEAN_TOKEN environment variable or use --credentials-file flag")
 	}
 
-	if creds.TenantID == "" {
+	// A token file holding more than the token would be sent as a broken header
+	if strings.ContainsAny(creds.Token, " \t\r\n") {
+		return errors.New(
+			errors.ErrCredentialMalformed,
+			"DigitalOcean API token contains whitespace",
+		)
+	}
+
+	return nil
+}
+
+// validateOIDCCredentials validates OIDC client credentials
+func (l *DefaultLoader) validateOIDCCredentials(creds *OIDCCredentials) error {
+	if creds.PrivateKey != "" {
+		if !strings.Contains(creds.PrivateKey, "PRIVATE KEY-----") {
+			return errors.New(
+				errors.ErrCredentialMalformed,
+				"OIDC private key is not a PEM private key",
+			)
+		}
+		return nil
+	}
+
+	if creds.ClientSecret == "" {
 		return errors.New(
 			errors.ErrCredentialNotFound,
-			"Azure tenant ID not found",
-		).WithDetail("set AZURE_TENANT_ID environment variable")
+			"OIDC client secret not found",
+		).WithDetail("set OIDC_CLIENT_SECRET environment variable, or use --credentials-file or --private-key-file flag")
+	}
+
+	// A secret file holding more than the secret would be sent as a broken credential
+	if strings.ContainsAny(creds.ClientSecret, "\r\n") {
+		return errors.New(
+			errors.ErrCredentialMalformed,
+			"OIDC client secret spans several lines",
+		)
 	}
 
 	return nil
@@ -0,0 +1,291 @@
+package credentials
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+)
+
+const (
+	// reloadTimeout bounds a reload triggered by a file change
+	reloadTimeout = 30 * time.Second
+
+	// expiryReloadWindow is the remaining lifetime at which watched session credentials
+	// are reloaded on use, since no file change announces their expiry
+	expiryReloadWindow = 5 * time.Minute
+)
+
+// Reload outcomes passed to ReloadObserver
+const (
+	ReloadSuccess = "success"
+	ReloadFailure = "failure"
+)
+
+// ReloadObserver records the outcome of credential reloads, e.g. as a metric
+type ReloadObserver interface {
+	RecordCredentialReload(provider, status string)
+}
+
+// Watcher is a Loader for long-running modes whose credentials files rotate on disk,
+// e.g. when rendered by Vault Agent. The first load of a GCP, AWS or Azure credentials
+// file is kept and its directory watched; when the file changes it is loaded and
+// validated again, and the new credentials replace the old ones atomically. A file
+// that fails to load keeps the previous credentials in use. Credentials that do not
+// come from a file, including those of secret sources, and the other clouds, are
+// loaded on every call as by the inner Loader.
+type Watcher struct {
+	inner    Loader
+	logger   logger.Logger
+	observer ReloadObserver
+	fsw      *fsnotify.Watcher
+
+	mu      sync.RWMutex
+	entries map[string]*watchedCredentials
+	dirs    map[string]bool
+
+	done chan struct{}
+}
+
+// watchedCredentials are loaded credentials and the files they were loaded from
+type watchedCredentials struct {
+	provider string
+	files    []string
+	load     func(ctx context.Context) (interface{}, error)
+	current  interface{}
+}
+
+// NewWatcher creates a Watcher loading through inner. observer may be nil. Close stops
+// watching.
+func NewWatcher(inner Loader, log logger.Logger, observer ReloadObserver) (*Watcher, error) {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create credentials file watcher: %w", err)
+	}
+
+	w := &Watcher{
+		inner:    inner,
+		logger:   log,
+		observer: observer,
+		fsw:      fsw,
+		entries:  make(map[string]*watchedCredentials),
+		dirs:     make(map[string]bool),
+		done:     make(chan struct{}),
+	}
+	go w.run()
+	return w, nil
+}
+
+// Close stops watching the credentials files
+func (w *Watcher) Close() error {
+	err := w.fsw.Close()
+	<-w.done
+	return err
+}
+
+// LoadGCP loads GCP credentials, watching the service account file
+func (w *Watcher) LoadGCP(ctx context.Context, path string) (*GCPCredentials, error) {
+	file := firstNonEmpty(path, os.Getenv("GOOGLE_APPLICATION_CREDENTIALS"))
+	creds, err := w.load(ctx, "gcp", "gcp:"+path, []string{file}, func(ctx context.Context) (interface{}, error) {
+		return w.inner.LoadGCP(ctx, path)
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return creds.(*GCPCredentials), nil
+}
+
+// LoadAWS loads AWS credentials, watching the credentials and config files. Session
+// credentials are also reloaded when they are about to expire.
+func (w *Watcher) LoadAWS(ctx context.Context, opts AWSCredentialOptions) (*AWSCredentials, error) {
+	files := []string{
+		firstNonEmpty(opts.CredentialsFile, os.Getenv("AWS_CREDENTIALS_FILE")),
+		firstNonEmpty(opts.ConfigFile, os.Getenv("AWS_CONFIG_FILE")),
+	}
+	creds, err := w.load(ctx, "aws", fmt.Sprintf("aws:%+v", opts), files, func(ctx context.Context) (interface{}, error) {
+		return w.inner.LoadAWS(ctx, opts)
+	}, func(current interface{}) bool {
+		expiration := current.(*AWSCredentials).Expiration
+		return !expiration.IsZero() && time.Until(expiration) < expiryReloadWindow
+	})
+	if err != nil {
+		return nil, err
+	}
+	return creds.(*AWSCredentials), nil
+}
+
+// LoadAzure loads Azure credentials, watching the credentials file
+func (w *Watcher) LoadAzure(ctx context.Context, opts AzureCredentialOptions) (*AzureCredentials, error) {
+	file := firstNonEmpty(opts.CredentialsFile, os.Getenv("AZURE_CREDENTIALS_FILE"))
+	creds, err := w.load(ctx, "azure", fmt.Sprintf("azure:%+v", opts), []string{file}, func(ctx context.Context) (interface{}, error) {
+		return w.inner.LoadAzure(ctx, opts)
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return creds.(*AzureCredentials), nil
+}
+
+// LoadOCI loads OCI credentials through the inner Loader
+func (w *Watcher) LoadOCI(ctx context.Context, opts OCICredentialOptions) (*OCICredentials, error) {
+	return w.inner.LoadOCI(ctx, opts)
+}
+
+// LoadDigitalOcean loads a DigitalOcean token through the inner Loader
+func (w *Watcher) LoadDigitalOcean(ctx context.Context, opts DigitalOceanCredentialOptions) (*DigitalOceanCredentials, error) {
+	return w.inner.LoadDigitalOcean(ctx, opts)
+}
+
+// LoadOIDC loads OIDC client credentials through the inner Loader
+func (w *Watcher) LoadOIDC(ctx context.Context, opts OIDCCredentialOptions) (*OIDCCredentials, error) {
+	return w.inner.LoadOIDC(ctx, opts)
+}
+
+// load returns the kept credentials of key, loading and watching them on first use.
+// stale reports kept credentials that must be loaded again anyway.
+func (w *Watcher) load(ctx context.Context, provider, key string, files []string, load func(ctx context.Context) (interface{}, error), stale func(interface{}) bool) (interface{}, error) {
+	var watched []string
+	for _, file := range files {
+		// Secret source references have no file to watch and are fetched on every call
+		if file != "" && !IsSecretURI(file) {
+			watched = append(watched, filepath.Clean(file))
+		}
+	}
+	if len(watched) == 0 {
+		return load(ctx)
+	}
+
+	w.mu.RLock()
+	entry, exists := w.entries[key]
+	var current interface{}
+	if exists {
+		current = entry.current
+	}
+	w.mu.RUnlock()
+	if exists && (stale == nil || !stale(current)) {
+		return current, nil
+	}
+
+	creds, err := load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if entry, exists := w.entries[key]; exists {
+		entry.current = creds
+		return creds, nil
+	}
+	for _, file := range watched {
+		dir := filepath.Dir(file)
+		if w.dirs[dir] {
+			continue
+		}
+		// The directory is watched because Vault Agent and Kubernetes secret volumes
+		// replace files instead of writing to them
+		if err := w.fsw.Add(dir); err != nil {
+			w.logger.Warn("Failed to watch credentials directory; credentials will not be reloaded",
+				logger.String("provider", provider),
+				logger.String("dir", dir),
+				logger.Error(err),
+			)
+			return creds, nil
+		}
+		w.dirs[dir] = true
+	}
+	w.entries[key] = &watchedCredentials{provider: provider, files: watched, load: load, current: creds}
+	return creds, nil
+}
+
+// run reloads the credentials whose files change until the watcher is closed
+func (w *Watcher) run() {
+	defer close(w.done)
+	for {
+		select {
+		case event, ok := <-w.fsw.Events:
+			if !ok {
+				return
+			}
+			if event.Op == fsnotify.Chmod {
+				continue
+			}
+			for _, entry := range w.affected(event.Name) {
+				w.reload(entry)
+			}
+		case err, ok := <-w.fsw.Errors:
+			if !ok {
+				return
+			}
+			w.logger.Warn("Credentials file watcher error", logger.Error(err))
+		}
+	}
+}
+
+// affected returns the credentials loaded from name, or from every file of its
+// directory when name is a Kubernetes "..data" style symlink swap
+func (w *Watcher) affected(name string) []*watchedCredentials {
+	name = filepath.Clean(name)
+	swap := strings.HasPrefix(filepath.Base(name), "..")
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	var entries []*watchedCredentials
+	for _, entry := range w.entries {
+		for _, file := range entry.files {
+			if file == name || (swap && filepath.Dir(file) == filepath.Dir(name)) {
+				entries = append(entries, entry)
+				break
+			}
+		}
+	}
+	return entries
+}
+
+// reload loads the credentials of entry again, keeping the previous ones on failure
+func (w *Watcher) reload(entry *watchedCredentials) {
+	ctx, cancel := context.WithTimeout(context.Background(), reloadTimeout)
+	defer cancel()
+
+	creds, err := entry.load(ctx)
+	if err != nil {
+		w.logger.Error("Failed to reload rotated credentials; keeping the previous credentials",
+			logger.String("provider", entry.provider),
+			logger.Error(err),
+		)
+		w.record(entry.provider, ReloadFailure)
+		return
+	}
+
+	w.mu.Lock()
+	entry.current = creds
+	w.mu.Unlock()
+
+	w.logger.Info("Reloaded rotated credentials", logger.String("provider", entry.provider))
+	w.record(entry.provider, ReloadSuccess)
+}
+
+// record reports a reload outcome to the observer, if any
+func (w *Watcher) record(provider, status string) {
+	if w.observer != nil {
+		w.observer.RecordCredentialReload(provider, status)
+	}
+}
+
+// firstNonEmpty returns the first non-empty value
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,180 @@
+package credentials
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+)
+
+// reloadCounter counts reloads by status
+type reloadCounter struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func (c *reloadCounter) RecordCredentialReload(provider, status string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.counts == nil {
+		c.counts = make(map[string]int)
+	}
+	c.counts[provider+"/"+status]++
+}
+
+func (c *reloadCounter) count(key string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.counts[key]
+}
+
+// writeAzureCredentials atomically replaces path with Azure credentials of clientID,
+// as Vault Agent does
+func writeAzureCredentials(t *testing.T, path, clientID string) {
+	t.Helper()
+
+	content := fmt.Sprintf(`{
+		"client_id": %q,
+		"client_secret": "secret-of-%s",
+		"tenant_id": "44444444-4444-4444-4444-444444444444"
+	}`, clientID, clientID)
+	tmp := path + ".tmp"
+	require.NoError(t, os.WriteFile(tmp, []byte(content), 0600))
+	require.NoError(t, os.Rename(tmp, path))
+}
+
+// newTestWatcher creates a Watcher over the default loader, closed when the test ends
+func newTestWatcher(t *testing.T, observer ReloadObserver) *Watcher {
+	t.Helper()
+
+	w, err := NewWatcher(NewLoader(logger.Nop()), logger.Nop(), observer)
+	require.NoError(t, err)
+	t.Cleanup(func() { w.Close() })
+	return w
+}
+
+func TestWatcher_ReloadsRotatedFile(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "azure.json")
+	writeAzureCredentials(t, path, "11111111-1111-1111-1111-111111111111")
+
+	counter := &reloadCounter{}
+	w := newTestWatcher(t, counter)
+	opts := AzureCredentialOptions{CredentialsFile: path}
+
+	creds, err := w.LoadAzure(ctx, opts)
+	require.NoError(t, err)
+	assert.Equal(t, "11111111-1111-1111-1111-111111111111", creds.ClientID)
+
+	writeAzureCredentials(t, path, "22222222-2222-2222-2222-222222222222")
+	assert.Eventually(t, func() bool {
+		creds, err := w.LoadAzure(ctx, opts)
+		return err == nil && creds.ClientID == "22222222-2222-2222-2222-222222222222"
+	}, 5*time.Second, 10*time.Millisecond, "later loads use the rotated credentials")
+	assert.Equal(t, 1, counter.count("azure/"+ReloadSuccess))
+
+	// A broken file keeps the rotated credentials in use
+	require.NoError(t, os.WriteFile(path, []byte(`{"client_id": `), 0600))
+	assert.Eventually(t, func() bool {
+		return counter.count("azure/"+ReloadFailure) > 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	creds, err = w.LoadAzure(ctx, opts)
+	require.NoError(t, err)
+	assert.Equal(t, "22222222-2222-2222-2222-222222222222", creds.ClientID)
+	assert.Equal(t, "secret-of-22222222-2222-2222-2222-222222222222", creds.ClientSecret)
+}
+
+func TestWatcher_ReloadsSymlinkSwap(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	// Kubernetes secret volumes point the file at ..data, which is swapped to a new
+	// directory on every update
+	writeVersion := func(version, clientID string) {
+		versionDir := filepath.Join(dir, version)
+		require.NoError(t, os.Mkdir(versionDir, 0700))
+		writeAzureCredentials(t, filepath.Join(versionDir, "azure.json"), clientID)
+		link := filepath.Join(dir, "..data_tmp")
+		require.NoError(t, os.Symlink(version, link))
+		require.NoError(t, os.Rename(link, filepath.Join(dir, "..data")))
+	}
+	writeVersion("..v1", "11111111-1111-1111-1111-111111111111")
+	path := filepath.Join(dir, "azure.json")
+	require.NoError(t, os.Symlink(filepath.Join("..data", "azure.json"), path))
+
+	w := newTestWatcher(t, nil)
+	opts := AzureCredentialOptions{CredentialsFile: path}
+
+	creds, err := w.LoadAzure(ctx, opts)
+	require.NoError(t, err)
+	assert.Equal(t, "11111111-1111-1111-1111-111111111111", creds.ClientID)
+
+	writeVersion("..v2", "22222222-2222-2222-2222-222222222222")
+	assert.Eventually(t, func() bool {
+		creds, err := w.LoadAzure(ctx, opts)
+		return err == nil && creds.ClientID == "22222222-2222-2222-2222-222222222222"
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestWatcher_PassesThroughWithoutFile(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv("AZURE_CREDENTIALS_FILE", "")
+	t.Setenv("AZURE_CLIENT_ID", "33333333-3333-3333-3333-333333333333")
+	t.Setenv("AZURE_CLIENT_SECRET", "env-client-secret-value")
+	t.Setenv("AZURE_TENANT_ID", "44444444-4444-4444-4444-444444444444")
+
+	w := newTestWatcher(t, nil)
+	opts := AzureCredentialOptions{UseEnvironment: true}
+
+	creds, err := w.LoadAzure(ctx, opts)
+	require.NoError(t, err)
+	assert.Equal(t, "33333333-3333-3333-3333-333333333333", creds.ClientID)
+
+	// Credentials from the environment are not kept
+	t.Setenv("AZURE_CLIENT_ID", "55555555-5555-5555-5555-555555555555")
+	creds, err = w.LoadAzure(ctx, opts)
+	require.NoError(t, err)
+	assert.Equal(t, "55555555-5555-5555-5555-555555555555", creds.ClientID)
+}
+
+func TestWatcher_ReloadsExpiringAWSCredentials(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "credentials")
+	expiration := time.Now().Add(time.Minute).UTC().Format(time.RFC3339)
+	content := "[default]\naws_access_key_id = AKIAEXAMPLE1\naws_secret_access_key = secret1\naws_session_token = token1\naws_session_expiration = " + expiration + "\n"
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+
+	inner := &countingLoader{Loader: NewLoader(logger.Nop())}
+	w, err := NewWatcher(inner, logger.Nop(), nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { w.Close() })
+
+	opts := AWSCredentialOptions{CredentialsFile: path, Profile: "default"}
+	creds, err := w.LoadAWS(ctx, opts)
+	require.NoError(t, err)
+	require.False(t, creds.Expiration.IsZero())
+
+	_, err = w.LoadAWS(ctx, opts)
+	require.NoError(t, err)
+	assert.Equal(t, 2, inner.aws, "credentials about to expire are loaded again")
+}
+
+// countingLoader counts the AWS loads of the wrapped Loader
+type countingLoader struct {
+	Loader
+	aws int
+}
+
+func (l *countingLoader) LoadAWS(ctx context.Context, opts AWSCredentialOptions) (*AWSCredentials, error) {
+	l.aws++
+	return l.Loader.LoadAWS(ctx, opts)
+}
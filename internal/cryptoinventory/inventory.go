@@ -0,0 +1,213 @@
+// Package cryptoinventory reports the cryptographic operations and libraries the
+// credential provider uses, for FIPS and compliance reviews
+package cryptoinventory
+
+import (
+	"crypto/fips140"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"strings"
+)
+
+// SchemaVersion is the version of the Inventory JSON document. Bump it when a field
+// is removed or changes meaning; adding fields is backwards compatible.
+const SchemaVersion = 1
+
+// Inventory is the crypto-inventory report
+type Inventory struct {
+	SchemaVersion int           `json:"schemaVersion"`
+	GoVersion     string        `json:"goVersion"`
+	Backend       Backend       `json:"backend"`
+	TLS           []TLSStack    `json:"tls"`
+	Providers     []ProviderUse `json:"providers"`
+	Hashing       []Operation   `json:"hashing"`
+	Modules       []Module      `json:"modules"`
+}
+
+// Backend describes the Go cryptography implementation the binary runs with
+type Backend struct {
+	// Name is "go" for the native Go implementation, or "boringcrypto" /
+	// "systemcrypto" when the binary was built with that GOEXPERIMENT
+	Name string `json:"name"`
+
+	// FIPS140Enabled reports crypto/fips140.Enabled at run time
+	FIPS140Enabled bool `json:"fips140Enabled"`
+
+	// GOFIPS140 is the FIPS 140-3 module version selected at build time, if any
+	GOFIPS140 string `json:"gofips140,omitempty"`
+
+	// GOEXPERIMENT is the experiment list the binary was built with, if any
+	GOEXPERIMENT string `json:"goexperiment,omitempty"`
+}
+
+// TLSStack is a TLS implementation used for outbound connections
+type TLSStack struct {
+	Library    string   `json:"library"`
+	MinVersion string   `json:"minVersion"`
+	UsedBy     []string `json:"usedBy"`
+}
+
+// ProviderUse lists the cryptographic operations one provider performs
+type ProviderUse struct {
+	Provider   string      `json:"provider"`
+	Operations []Operation `json:"operations"`
+}
+
+// Operation is one cryptographic operation
+type Operation struct {
+	Purpose   string `json:"purpose"`
+	Algorithm string `json:"algorithm"`
+	Library   string `json:"library"`
+}
+
+// Module is a security-relevant dependency and the version built into the binary
+type Module struct {
+	Path    string `json:"path"`
+	Version string `json:"version"`
+}
+
+// providerOperations is the per-provider table of signing and token operations.
+// TestProviderTableMatchesRegistry keeps it in sync with the registered providers.
+var providerOperations = map[string][]Operation{
+	"gcp": {
+		{Purpose: "service account JWT assertion for the OAuth2 token exchange", Algorithm: "RS256 (RSASSA-PKCS1-v1_5, SHA-256)", Library: "golang.org/x/oauth2/google"},
+	},
+	"aws": {
+		{Purpose: "presigned STS GetCallerIdentity URL used as the EKS token", Algorithm: "SigV4 (HMAC-SHA256)", Library: "github.com/aws/aws-sdk-go-v2/aws/signer/v4"},
+		{Purpose: "STS and EKS API request signing", Algorithm: "SigV4 (HMAC-SHA256)", Library: "github.com/aws/aws-sdk-go-v2/aws/signer/v4"},
+	},
+	"azure": {
+		{Purpose: "AAD client credentials token acquisition", Algorithm: "OAuth2 client secret over TLS (no local signing)", Library: "github.com/Azure/azure-sdk-for-go/sdk/azidentity"},
+		{Purpose: "client certificate JWT assertion for the AAD token request", Algorithm: "RS256 (RSASSA-PKCS1-v1_5, SHA-256) with an x5t certificate thumbprint header", Library: "github.com/AzureAD/microsoft-authentication-library-for-go"},
+	},
+	"oci": {
+		{Purpose: "API request and OKE token signing", Algorithm: "RSA-SHA256 (RSASSA-PKCS1-v1_5) HTTP signature", Library: "crypto/rsa"},
+		{Purpose: "request body digest (x-content-sha256)", Algorithm: "SHA-256", Library: "crypto/sha256"},
+	},
+	"digitalocean": {
+		{Purpose: "API token sent as the bearer token", Algorithm: "none (static token over TLS)", Library: "net/http"},
+	},
+	"oidc": {
+		{Purpose: "private_key_jwt client assertion for the token request", Algorithm: "RS256 (RSASSA-PKCS1-v1_5, SHA-256) or ES256 (ECDSA P-256, SHA-256)", Library: "github.com/golang-jwt/jwt/v5"},
+		{Purpose: "client secret authentication for the token request", Algorithm: "OAuth2 client secret over TLS (no local signing)", Library: "net/http"},
+	},
+}
+
+// hashingOperations are the provider-independent hashing operations
+var hashingOperations = []Operation{
+	{Purpose: "token store key fingerprint", Algorithm: "SHA-256", Library: "crypto/sha256"},
+}
+
+// securityModules are the module path prefixes reported in Inventory.Modules
+var securityModules = []string{
+	"cloud.google.com/go/auth",
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore",
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity",
+	"github.com/AzureAD/microsoft-authentication-library-for-go",
+	"github.com/aws/aws-sdk-go-v2",
+	"github.com/aws/smithy-go",
+	"github.com/golang-jwt/jwt",
+	"golang.org/x/crypto",
+	"golang.org/x/net",
+	"golang.org/x/oauth2",
+	"google.golang.org/api",
+}
+
+// Providers returns the names of the providers in the operations table, sorted
+func Providers() []string {
+	names := make([]string, 0, len(providerOperations))
+	for name := range providerOperations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Build returns the inventory for the given providers. Providers missing from the
+// table are listed with no operations.
+func Build(providers []string) *Inventory {
+	info, _ := debug.ReadBuildInfo()
+	settings := buildSettings(info)
+
+	inv := &Inventory{
+		SchemaVersion: SchemaVersion,
+		GoVersion:     runtime.Version(),
+		Backend:       detectBackend(settings),
+		Hashing:       hashingOperations,
+		Modules:       securityDependencies(info),
+	}
+
+	sorted := append([]string(nil), providers...)
+	sort.Strings(sorted)
+	for _, name := range sorted {
+		operations := providerOperations[name]
+		if operations == nil {
+			operations = []Operation{}
+		}
+		inv.Providers = append(inv.Providers, ProviderUse{Provider: name, Operations: operations})
+	}
+
+	// Every provider, the token store and the cloud SDKs use net/http, which uses crypto/tls
+	inv.TLS = []TLSStack{{
+		Library:    "crypto/tls",
+		MinVersion: "TLS 1.2",
+		UsedBy:     sorted,
+	}}
+
+	return inv
+}
+
+// detectBackend reports the crypto backend from the run-time FIPS mode and the build settings
+func detectBackend(settings map[string]string) Backend {
+	backend := Backend{
+		Name:           "go",
+		FIPS140Enabled: fips140.Enabled(),
+		GOFIPS140:      settings["GOFIPS140"],
+		GOEXPERIMENT:   settings["GOEXPERIMENT"],
+	}
+
+	for _, experiment := range strings.Split(backend.GOEXPERIMENT, ",") {
+		switch experiment {
+		case "boringcrypto", "systemcrypto", "opensslcrypto", "cngcrypto", "darwincrypto":
+			backend.Name = experiment
+		}
+	}
+	return backend
+}
+
+// buildSettings returns the build settings of info as a map
+func buildSettings(info *debug.BuildInfo) map[string]string {
+	settings := make(map[string]string)
+	if info == nil {
+		return settings
+	}
+	for _, setting := range info.Settings {
+		settings[setting.Key] = setting.Value
+	}
+	return settings
+}
+
+// securityDependencies returns the security-relevant modules linked into the binary, sorted by path
+func securityDependencies(info *debug.BuildInfo) []Module {
+	modules := []Module{}
+	if info == nil {
+		return modules
+	}
+
+	for _, dep := range info.Deps {
+		version := dep.Version
+		if dep.Replace != nil {
+			version = dep.Replace.Version
+		}
+		for _, prefix := range securityModules {
+			if dep.Path == prefix || strings.HasPrefix(dep.Path, prefix+"/") {
+				modules = append(modules, Module{Path: dep.Path, Version: version})
+				break
+			}
+		}
+	}
+
+	sort.Slice(modules, func(i, j int) bool { return modules[i].Path < modules[j].Path })
+	return modules
+}
@@ -0,0 +1,90 @@
+package cryptoinventory
+
+import (
+	"encoding/json"
+	"runtime/debug"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectBackend(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings map[string]string
+		wantName string
+	}{
+		{
+			name:     "native Go crypto",
+			settings: map[string]string{},
+			wantName: "go",
+		},
+		{
+			name:     "boringcrypto experiment",
+			settings: map[string]string{"GOEXPERIMENT": "boringcrypto"},
+			wantName: "boringcrypto",
+		},
+		{
+			name:     "systemcrypto among other experiments",
+			settings: map[string]string{"GOEXPERIMENT": "loopvar,systemcrypto"},
+			wantName: "systemcrypto",
+		},
+		{
+			name:     "unrelated experiment",
+			settings: map[string]string{"GOEXPERIMENT": "rangefunc"},
+			wantName: "go",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := detectBackend(tt.settings)
+
+			assert.Equal(t, tt.wantName, backend.Name)
+			assert.Equal(t, tt.settings["GOEXPERIMENT"], backend.GOEXPERIMENT)
+		})
+	}
+}
+
+func TestSecurityDependencies(t *testing.T) {
+	info := &debug.BuildInfo{
+		Deps: []*debug.Module{
+			{Path: "golang.org/x/oauth2", Version: "v0.34.0"},
+			{Path: "github.com/spf13/cobra", Version: "v1.10.2"},
+			{Path: "github.com/aws/aws-sdk-go-v2/service/sts", Version: "v1.41.6"},
+			{Path: "golang.org/x/cryptography", Version: "v0.1.0"},
+			{Path: "golang.org/x/crypto", Version: "v0.40.0", Replace: &debug.Module{Path: "example.com/fork/crypto", Version: "v0.40.1"}},
+		},
+	}
+
+	assert.Equal(t, []Module{
+		{Path: "github.com/aws/aws-sdk-go-v2/service/sts", Version: "v1.41.6"},
+		{Path: "golang.org/x/crypto", Version: "v0.40.1"},
+		{Path: "golang.org/x/oauth2", Version: "v0.34.0"},
+	}, securityDependencies(info))
+	assert.Empty(t, securityDependencies(nil))
+}
+
+func TestBuild(t *testing.T) {
+	inv := Build([]string{"oci", "gcp", "unlisted"})
+
+	assert.Equal(t, SchemaVersion, inv.SchemaVersion)
+	require.Len(t, inv.Providers, 3)
+	assert.Equal(t, "gcp", inv.Providers[0].Provider)
+	assert.Equal(t, "RS256 (RSASSA-PKCS1-v1_5, SHA-256)", inv.Providers[0].Operations[0].Algorithm)
+	assert.Equal(t, "oci", inv.Providers[1].Provider)
+	assert.Equal(t, "unlisted", inv.Providers[2].Provider)
+	assert.Empty(t, inv.Providers[2].Operations)
+	require.Len(t, inv.TLS, 1)
+	assert.Equal(t, []string{"gcp", "oci", "unlisted"}, inv.TLS[0].UsedBy)
+
+	// The top-level JSON fields are the schema; renaming one is an incompatible change
+	data, err := json.Marshal(inv)
+	require.NoError(t, err)
+	var doc map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(data, &doc))
+	for _, key := range []string{"schemaVersion", "goVersion", "backend", "tls", "providers", "hashing", "modules"} {
+		assert.Contains(t, doc, key)
+	}
+}
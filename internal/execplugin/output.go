@@ -111,3 +111,31 @@ func FormatToken(token *provider.Token) (string, error) {
 
 	return string(data), nil
 }
+
+// ParseToken reads a token from ExecCredential JSON, such as the output of FormatToken.
+// A credential without an expiration timestamp is reported as already expired.
+func ParseToken(data []byte) (*provider.Token, error) {
+	var execCred ExecCredential
+	if err := json.Unmarshal(data, &execCred); err != nil {
+		return nil, errors.Wrap(
+			errors.ErrTokenMalformed,
+			err,
+			"failed to parse ExecCredential JSON",
+		)
+	}
+	if execCred.Status == nil || execCred.Status.Token == "" {
+		return nil, errors.New(
+			errors.ErrTokenMalformed,
+			"ExecCredential has no status.token",
+		)
+	}
+
+	token := &provider.Token{
+		AccessToken: execCred.Status.Token,
+		TokenType:   "Bearer",
+	}
+	if execCred.Status.ExpirationTimestamp != nil {
+		token.ExpiresAt = execCred.Status.ExpirationTimestamp.Time
+	}
+	return token, nil
+}
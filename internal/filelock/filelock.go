@@ -0,0 +1,174 @@
+// Package filelock provides advisory cross-process locking for files that several
+// invocations may rewrite at once, such as a shared kubeconfig.
+package filelock
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+)
+
+const (
+	// DefaultTimeout bounds how long Acquire waits for another holder to release the lock
+	DefaultTimeout = 30 * time.Second
+
+	// pollInterval is how often a contended lock is retried
+	pollInterval = 25 * time.Millisecond
+
+	// lockSuffix names the sidecar lock file. The lock cannot be held on the target
+	// itself because an atomic rename replaces the locked inode.
+	lockSuffix = ".lock"
+)
+
+// Lock is an advisory lock held on the sidecar lock file of a path
+type Lock struct {
+	file *os.File
+}
+
+// LockPath returns the sidecar lock file used for path
+func LockPath(path string) string {
+	return path + lockSuffix
+}
+
+// Acquire takes an exclusive lock for path, waiting up to timeout for other holders.
+// A zero timeout uses DefaultTimeout.
+func Acquire(ctx context.Context, path string, timeout time.Duration) (*Lock, error) {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	lockPath := LockPath(path)
+	file, err := os.OpenFile(lockPath, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, errors.Wrap(
+			errors.ErrInternal,
+			err,
+			"failed to open lock file",
+		).WithField("lock_file", lockPath)
+	}
+
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		locked, err := tryLock(file)
+		if err != nil {
+			file.Close()
+			return nil, errors.Wrap(
+				errors.ErrInternal,
+				err,
+				"failed to lock file",
+			).WithField("lock_file", lockPath)
+		}
+		if locked {
+			return &Lock{file: file}, nil
+		}
+
+		select {
+		case <-ticker.C:
+		case <-deadline.C:
+			file.Close()
+			return nil, errors.New(
+				errors.ErrFileLocked,
+				"timed out waiting for another process to release the file lock",
+			).WithFields(map[string]interface{}{
+				"file":      path,
+				"lock_file": lockPath,
+				"timeout":   timeout.String(),
+			})
+		case <-ctx.Done():
+			file.Close()
+			return nil, errors.Wrap(
+				errors.ErrFileLocked,
+				ctx.Err(),
+				"cancelled while waiting for the file lock",
+			).WithField("lock_file", lockPath)
+		}
+	}
+}
+
+// Release unlocks and closes the lock file. The lock file is left in place so that
+// a waiter never locks an unlinked file.
+func (l *Lock) Release() error {
+	if err := unlock(l.file); err != nil {
+		l.file.Close()
+		return errors.Wrap(errors.ErrInternal, err, "failed to unlock file")
+	}
+	return l.file.Close()
+}
+
+// Update runs a locked read-modify-write cycle on path. fn receives the current
+// contents (nil when the file does not exist) and returns the new contents, which
+// are written atomically with perm and read back before the lock is released.
+func Update(ctx context.Context, path string, timeout time.Duration, perm os.FileMode, fn func(current []byte) ([]byte, error)) error {
+	lock, err := Acquire(ctx, path, timeout)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	current, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(errors.ErrInternal, err, "failed to read file").WithField("file", path)
+	}
+
+	updated, err := fn(current)
+	if err != nil {
+		return err
+	}
+
+	if err := WriteAtomic(path, updated, perm); err != nil {
+		return err
+	}
+
+	written, err := os.ReadFile(path)
+	if err != nil {
+		return errors.Wrap(errors.ErrInternal, err, "failed to read back file").WithField("file", path)
+	}
+	if !bytes.Equal(written, updated) {
+		return errors.New(
+			errors.ErrFileLocked,
+			"file changed while locked; another writer is not using the lock",
+		).WithField("file", path)
+	}
+
+	return nil
+}
+
+// WriteAtomic writes data to a temp file in the same directory and renames it over
+// path so readers never see a partial file
+func WriteAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return errors.Wrap(errors.ErrInternal, err, "failed to create temp file").WithField("file", path)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return errors.Wrap(errors.ErrInternal, err, "failed to write temp file").WithField("file", path)
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return errors.Wrap(errors.ErrInternal, err, "failed to set file permissions").WithField("file", path)
+	}
+	// Flush before the rename so that a crash cannot leave path truncated
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return errors.Wrap(errors.ErrInternal, err, "failed to sync temp file").WithField("file", path)
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.Wrap(errors.ErrInternal, err, "failed to close temp file").WithField("file", path)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return errors.Wrap(errors.ErrInternal, err, "failed to replace file").WithField("file", path)
+	}
+	return nil
+}
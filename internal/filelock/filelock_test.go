@@ -0,0 +1,231 @@
+package filelock
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+)
+
+// helperEnv makes the test binary act as a second writer process
+const helperEnv = "FILELOCK_TEST_HELPER"
+
+// addClusters appends one line per cluster to path, one locked update each, with a
+// pause between read and write to widen the lost-update window
+func addClusters(path, prefix string, count int) error {
+	for i := 0; i < count; i++ {
+		name := fmt.Sprintf("%s-%d", prefix, i)
+		err := Update(context.Background(), path, 10*time.Second, 0600, func(current []byte) ([]byte, error) {
+			time.Sleep(time.Millisecond)
+			return append(current, []byte(name+"\n")...), nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readClusters(t *testing.T, path string) []string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	names := strings.Fields(string(data))
+	sort.Strings(names)
+	return names
+}
+
+func expectedClusters(prefixes []string, count int) []string {
+	var names []string
+	for _, prefix := range prefixes {
+		for i := 0; i < count; i++ {
+			names = append(names, fmt.Sprintf("%s-%d", prefix, i))
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestHelperProcess(t *testing.T) {
+	spec := os.Getenv(helperEnv)
+	if spec == "" {
+		t.Skip("only runs as a helper process")
+	}
+	parts := strings.SplitN(spec, ":", 3)
+	count, err := strconv.Atoi(parts[1])
+	require.NoError(t, err)
+	require.NoError(t, addClusters(parts[2], parts[0], count))
+}
+
+func TestUpdate_ConcurrentGoroutines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "kubeconfig")
+	prefixes := []string{"gke", "eks"}
+	const count = 20
+
+	var wg sync.WaitGroup
+	errs := make(chan error, len(prefixes))
+	for _, prefix := range prefixes {
+		wg.Add(1)
+		go func(prefix string) {
+			defer wg.Done()
+			errs <- addClusters(path, prefix, count)
+		}(prefix)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, expectedClusters(prefixes, count), readClusters(t, path))
+}
+
+func TestUpdate_ConcurrentProcesses(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "kubeconfig")
+	prefixes := []string{"gke", "aks"}
+	const count = 20
+
+	cmds := make([]*exec.Cmd, 0, len(prefixes))
+	for _, prefix := range prefixes {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestHelperProcess$")
+		cmd.Env = append(os.Environ(), fmt.Sprintf("%s=%s:%d:%s", helperEnv, prefix, count, path))
+		cmd.Stdout = os.Stderr
+		cmd.Stderr = os.Stderr
+		require.NoError(t, cmd.Start())
+		cmds = append(cmds, cmd)
+	}
+	for _, cmd := range cmds {
+		require.NoError(t, cmd.Wait())
+	}
+
+	assert.Equal(t, expectedClusters(prefixes, count), readClusters(t, path))
+}
+
+func TestAcquire_Timeout(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "kubeconfig")
+
+	held, err := Acquire(context.Background(), path, time.Second)
+	require.NoError(t, err)
+	defer held.Release()
+
+	start := time.Now()
+	_, err = Acquire(context.Background(), path, 100*time.Millisecond)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, errors.ErrFileLocked))
+	assert.Contains(t, err.Error(), "timed out")
+	assert.Less(t, time.Since(start), 5*time.Second)
+
+	var e *errors.Error
+	require.True(t, errors.As(err, &e))
+	assert.Equal(t, LockPath(path), e.Fields["lock_file"])
+	assert.Equal(t, "100ms", e.Fields["timeout"])
+}
+
+func TestAcquire_ContextCancelled(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "kubeconfig")
+
+	held, err := Acquire(context.Background(), path, time.Second)
+	require.NoError(t, err)
+	defer held.Release()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = Acquire(ctx, path, time.Minute)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, errors.ErrFileLocked))
+}
+
+func TestAcquire_AfterRelease(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "kubeconfig")
+
+	first, err := Acquire(context.Background(), path, time.Second)
+	require.NoError(t, err)
+	require.NoError(t, first.Release())
+
+	second, err := Acquire(context.Background(), path, 100*time.Millisecond)
+	require.NoError(t, err)
+	require.NoError(t, second.Release())
+}
+
+func TestUpdate(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing string
+		fn       func(current []byte) ([]byte, error)
+		want     string
+		wantErr  bool
+	}{
+		{
+			name: "creates missing file",
+			fn: func(current []byte) ([]byte, error) {
+				if current != nil {
+					return nil, fmt.Errorf("expected no contents, got %q", current)
+				}
+				return []byte("new"), nil
+			},
+			want: "new",
+		},
+		{
+			name:     "replaces existing contents",
+			existing: "old",
+			fn: func(current []byte) ([]byte, error) {
+				return append(current, []byte("+new")...), nil
+			},
+			want: "old+new",
+		},
+		{
+			name:     "error leaves file untouched",
+			existing: "old",
+			fn: func([]byte) ([]byte, error) {
+				return nil, fmt.Errorf("boom")
+			},
+			want:    "old",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "kubeconfig")
+			if tt.existing != "" {
+				require.NoError(t, os.WriteFile(path, []byte(tt.existing), 0644))
+			}
+
+			err := Update(context.Background(), path, time.Second, 0600, tt.fn)
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+
+			data, err := os.ReadFile(path)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, string(data))
+
+			if !tt.wantErr && runtime.GOOS != "windows" {
+				info, err := os.Stat(path)
+				require.NoError(t, err)
+				assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+			}
+
+			entries, err := os.ReadDir(filepath.Dir(path))
+			require.NoError(t, err)
+			for _, entry := range entries {
+				assert.NotContains(t, entry.Name(), ".tmp-", "temp file left behind")
+			}
+		})
+	}
+}
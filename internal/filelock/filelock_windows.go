@@ -0,0 +1,33 @@
+//go:build windows
+
+package filelock
+
+import (
+	"errors"
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// lockRange covers the whole file; LockFileEx locks byte ranges
+const lockRange = ^uint32(0)
+
+// tryLock takes a non-blocking exclusive LockFileEx lock, reporting false when it is held elsewhere
+func tryLock(file *os.File) (bool, error) {
+	err := windows.LockFileEx(
+		windows.Handle(file.Fd()),
+		windows.LOCKFILE_EXCLUSIVE_LOCK|windows.LOCKFILE_FAIL_IMMEDIATELY,
+		0,
+		lockRange,
+		lockRange,
+		new(windows.Overlapped),
+	)
+	if errors.Is(err, windows.ERROR_LOCK_VIOLATION) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func unlock(file *os.File) error {
+	return windows.UnlockFileEx(windows.Handle(file.Fd()), 0, lockRange, lockRange, new(windows.Overlapped))
+}
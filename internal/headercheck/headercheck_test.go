@@ -0,0 +1,238 @@
+package headercheck
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+)
+
+func TestMeasureToken(t *testing.T) {
+	tests := []struct {
+		name       string
+		tokenLen   int
+		threshold  int
+		wantExceed bool
+		wantLimit  int
+	}{
+		{
+			name:       "small token under default threshold",
+			tokenLen:   1024,
+			threshold:  0,
+			wantExceed: false,
+			wantLimit:  DefaultTokenSizeWarnThreshold,
+		},
+		{
+			name:       "token just under default threshold including prefix",
+			tokenLen:   DefaultTokenSizeWarnThreshold - authorizationOverhead,
+			threshold:  0,
+			wantExceed: false,
+			wantLimit:  DefaultTokenSizeWarnThreshold,
+		},
+		{
+			name:       "prefix pushes token over default threshold",
+			tokenLen:   DefaultTokenSizeWarnThreshold - authorizationOverhead + 1,
+			threshold:  0,
+			wantExceed: true,
+			wantLimit:  DefaultTokenSizeWarnThreshold,
+		},
+		{
+			name:       "custom threshold",
+			tokenLen:   9000,
+			threshold:  8 * 1024,
+			wantExceed: true,
+			wantLimit:  8 * 1024,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			size := MeasureToken(strings.Repeat("a", tt.tokenLen), tt.threshold)
+
+			assert.Equal(t, tt.tokenLen, size.TokenBytes)
+			assert.Equal(t, tt.tokenLen+authorizationOverhead, size.HeaderBytes)
+			assert.Equal(t, tt.wantLimit, size.Threshold)
+			assert.Equal(t, tt.wantExceed, size.ExceedsThreshold)
+		})
+	}
+}
+
+type recordingLogger struct {
+	logger.Logger
+	warnings []string
+}
+
+func (l *recordingLogger) Warn(msg string, fields ...logger.Field) {
+	l.warnings = append(l.warnings, msg)
+}
+
+func TestWarnIfLarge(t *testing.T) {
+	log := &recordingLogger{Logger: logger.Nop()}
+
+	WarnIfLarge(log, strings.Repeat("a", 100), 0)
+	assert.Empty(t, log.warnings)
+
+	size := WarnIfLarge(log, strings.Repeat("a", 200), 100)
+	assert.True(t, size.ExceedsThreshold)
+	require.Len(t, log.warnings, 1)
+	assert.Contains(t, log.warnings[0], "exceeds size threshold")
+}
+
+func TestSyntheticHeader(t *testing.T) {
+	header := syntheticHeader(DefaultProbeHeaderSize)
+
+	assert.Len(t, header, DefaultProbeHeaderSize)
+	assert.True(t, strings.HasPrefix(header, "Bearer "))
+	assert.NotEqual(t, header[:8*1024], header[len(header)-8*1024:], "content must vary along its length")
+}
+
+// newReflector returns a server that echoes request headers in httpbin's /headers format
+func newReflector(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers := map[string]string{}
+		for name := range r.Header {
+			headers[name] = r.Header.Get(name)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"headers": headers})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// newProxy returns a forward HTTP proxy that truncates the Authorization header to
+// limit bytes (0 disables truncation), or rejects it with 431 when reject is set
+func newProxy(t *testing.T, limit int, reject bool) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		if reject && len(auth) > limit {
+			w.WriteHeader(http.StatusRequestHeaderFieldsTooLarge)
+			return
+		}
+		if limit > 0 && len(auth) > limit {
+			auth = auth[:limit]
+		}
+
+		out, err := http.NewRequestWithContext(r.Context(), r.Method, r.URL.String(), nil)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		out.Header = r.Header.Clone()
+		out.Header.Set("Authorization", auth)
+
+		resp, err := http.DefaultTransport.RoundTrip(out)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		defer resp.Body.Close()
+
+		w.WriteHeader(resp.StatusCode)
+		_, _ = io.Copy(w, resp.Body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func clientVia(t *testing.T, proxy *httptest.Server) *http.Client {
+	t.Helper()
+
+	proxyURL, err := url.Parse(proxy.URL)
+	require.NoError(t, err)
+	return &http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(proxyURL)}}
+}
+
+func TestProbeProxy_Reflector(t *testing.T) {
+	reflector := newReflector(t)
+
+	tests := []struct {
+		name          string
+		limit         int
+		reject        bool
+		wantTruncated bool
+		wantRejected  bool
+		wantReceived  int
+	}{
+		{
+			name:         "transparent proxy",
+			limit:        0,
+			wantReceived: DefaultProbeHeaderSize,
+		},
+		{
+			name:          "proxy truncates at 8KB",
+			limit:         8 * 1024,
+			wantTruncated: true,
+			wantReceived:  8 * 1024,
+		},
+		{
+			name:         "proxy rejects large header",
+			limit:        8 * 1024,
+			reject:       true,
+			wantRejected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proxy := newProxy(t, tt.limit, tt.reject)
+
+			result, err := ProbeProxy(context.Background(), ProbeOptions{
+				URL:       reflector.URL + "/headers",
+				Reflector: true,
+				Client:    clientVia(t, proxy),
+			})
+			require.NoError(t, err)
+
+			assert.Equal(t, "reflector", result.Mode)
+			assert.Equal(t, proxy.URL, result.Proxy)
+			assert.Equal(t, DefaultProbeHeaderSize, result.SentBytes)
+			assert.Equal(t, tt.wantReceived, result.ReceivedBytes)
+			assert.Equal(t, tt.wantTruncated, result.Truncated)
+			assert.Equal(t, tt.wantRejected, result.Rejected)
+			assert.Equal(t, !tt.wantTruncated && !tt.wantRejected, result.OK())
+			assert.NotEmpty(t, result.Detail)
+		})
+	}
+}
+
+func TestProbeProxy_StatusMode(t *testing.T) {
+	cluster := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer cluster.Close()
+
+	proxy := newProxy(t, 0, false)
+
+	result, err := ProbeProxy(context.Background(), ProbeOptions{
+		URL:    cluster.URL,
+		Client: clientVia(t, proxy),
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "status", result.Mode)
+	assert.Equal(t, http.StatusUnauthorized, result.StatusCode)
+	assert.True(t, result.OK())
+	assert.Contains(t, result.Detail, "reflector")
+}
+
+func TestProbeProxy_InvalidOptions(t *testing.T) {
+	_, err := ProbeProxy(context.Background(), ProbeOptions{})
+	assert.Error(t, err)
+
+	_, err = ProbeProxy(context.Background(), ProbeOptions{URL: "http://example.invalid", HeaderSize: 3})
+	assert.Error(t, err)
+}
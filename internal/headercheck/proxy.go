@@ -0,0 +1,231 @@
+package headercheck
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+)
+
+// DefaultProbeHeaderSize is the size of the synthetic Authorization header sent by
+// ProbeProxy. It is above the common 8KB/12KB proxy limits and matches the upper
+// range of real EKS tokens.
+const DefaultProbeHeaderSize = 16 * 1024
+
+// defaultProbeTimeout bounds the probe request when no client is supplied
+const defaultProbeTimeout = 10 * time.Second
+
+// maxReflectorBody caps how much of the reflector response is read
+const maxReflectorBody = 1 << 20
+
+// ProbeOptions configures a large-header probe
+type ProbeOptions struct {
+	// URL is the probe target. With Reflector set it must echo the request
+	// headers as JSON ({"headers": {"Authorization": "..."}}, as httpbin's
+	// /headers endpoint does); otherwise it is typically the cluster endpoint.
+	URL string
+
+	// Reflector indicates that URL echoes request headers back, which allows
+	// truncation to be detected exactly
+	Reflector bool
+
+	// HeaderSize is the size of the synthetic Authorization header value
+	// (default: DefaultProbeHeaderSize)
+	HeaderSize int
+
+	// Client is the HTTP client used for the probe (default: a client using the
+	// proxy configured through HTTPS_PROXY/HTTP_PROXY/NO_PROXY)
+	Client *http.Client
+}
+
+// ProbeResult describes what happened to the synthetic header
+type ProbeResult struct {
+	// URL is the probe target
+	URL string `json:"url"`
+
+	// Proxy is the proxy the request was routed through, if any
+	Proxy string `json:"proxy,omitempty"`
+
+	// Mode is "reflector" or "status"
+	Mode string `json:"mode"`
+
+	// SentBytes is the size of the Authorization header value that was sent
+	SentBytes int `json:"sentBytes"`
+
+	// ReceivedBytes is the size of the Authorization header value seen by the
+	// reflector (reflector mode only)
+	ReceivedBytes int `json:"receivedBytes,omitempty"`
+
+	// StatusCode is the HTTP status returned by the target or proxy
+	StatusCode int `json:"statusCode"`
+
+	// Truncated is true when the header arrived shorter or altered
+	Truncated bool `json:"truncated"`
+
+	// Rejected is true when the proxy or server refused the request because of
+	// the header size
+	Rejected bool `json:"rejected"`
+
+	// Detail is a human-readable summary of the finding
+	Detail string `json:"detail"`
+}
+
+// OK returns true when the synthetic header was delivered intact (or, in status
+// mode, delivered without being rejected)
+func (r *ProbeResult) OK() bool {
+	return !r.Truncated && !r.Rejected
+}
+
+// ProbeProxy sends a synthetic large Authorization header to the target through
+// the configured proxy and reports whether it was truncated or rejected.
+// An error is returned only when the probe itself could not be performed.
+func ProbeProxy(ctx context.Context, opts ProbeOptions) (*ProbeResult, error) {
+	if opts.URL == "" {
+		return nil, errors.New(errors.ErrInvalidArgument, "probe URL is required")
+	}
+
+	size := opts.HeaderSize
+	if size <= 0 {
+		size = DefaultProbeHeaderSize
+	}
+	if size <= authorizationOverhead {
+		return nil, errors.New(errors.ErrInvalidArgument, "probe header size is too small").
+			WithField("header_size", size)
+	}
+
+	client := opts.Client
+	if client == nil {
+		client = &http.Client{
+			Timeout:   defaultProbeTimeout,
+			Transport: &http.Transport{Proxy: http.ProxyFromEnvironment},
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, opts.URL, nil)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrInvalidArgument, err, "invalid probe URL").
+			WithField("url", opts.URL)
+	}
+
+	header := syntheticHeader(size)
+	req.Header.Set("Authorization", header)
+
+	result := &ProbeResult{
+		URL:       opts.URL,
+		Proxy:     proxyFor(client, req),
+		Mode:      "status",
+		SentBytes: len(header),
+	}
+	if opts.Reflector {
+		result.Mode = "reflector"
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrNetworkUnreachable, err, "probe request failed").
+			WithField("url", opts.URL)
+	}
+	defer resp.Body.Close()
+
+	result.StatusCode = resp.StatusCode
+
+	if resp.StatusCode == http.StatusRequestHeaderFieldsTooLarge || resp.StatusCode == http.StatusBadRequest {
+		result.Rejected = true
+		result.Detail = fmt.Sprintf("request with a %d byte Authorization header was rejected with status %d", len(header), resp.StatusCode)
+		return result, nil
+	}
+
+	if !opts.Reflector {
+		result.Detail = fmt.Sprintf("header delivered with status %d; use a reflector endpoint to verify it arrived intact", resp.StatusCode)
+		return result, nil
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, errors.New(errors.ErrNetworkUnreachable, "reflector returned unexpected status").
+			WithField("url", opts.URL).
+			WithField("status", resp.StatusCode)
+	}
+
+	received, err := reflectedAuthorization(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	result.ReceivedBytes = len(received)
+	switch {
+	case received == header:
+		result.Detail = fmt.Sprintf("%d byte Authorization header delivered intact", len(header))
+	case len(received) < len(header):
+		result.Truncated = true
+		result.Detail = fmt.Sprintf("Authorization header truncated from %d to %d bytes", len(header), len(received))
+	default:
+		result.Truncated = true
+		result.Detail = "Authorization header was modified in transit"
+	}
+
+	return result, nil
+}
+
+// syntheticHeader builds a "Bearer ..." value of exactly size bytes whose
+// content changes along its length, so truncation at any offset is detectable
+func syntheticHeader(size int) string {
+	const alphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+	var b strings.Builder
+	b.Grow(size)
+	b.WriteString("Bearer ")
+	for i := 0; b.Len() < size; i++ {
+		b.WriteByte(alphabet[(i/64)%len(alphabet)])
+	}
+	return b.String()
+}
+
+// proxyFor returns the proxy URL the client would use for req, if it can be determined
+func proxyFor(client *http.Client, req *http.Request) string {
+	transport, ok := client.Transport.(*http.Transport)
+	if !ok || transport.Proxy == nil {
+		return ""
+	}
+
+	proxyURL, err := transport.Proxy(req)
+	if err != nil || proxyURL == nil {
+		return ""
+	}
+	return proxyURL.Redacted()
+}
+
+// reflectedAuthorization extracts the Authorization header from a reflector
+// response. Header values may be strings or string arrays.
+func reflectedAuthorization(body io.Reader) (string, error) {
+	var payload struct {
+		Headers map[string]json.RawMessage `json:"headers"`
+	}
+	if err := json.NewDecoder(io.LimitReader(body, maxReflectorBody)).Decode(&payload); err != nil {
+		return "", errors.Wrap(errors.ErrInvalidFormat, err, "failed to parse reflector response")
+	}
+
+	for name, raw := range payload.Headers {
+		if !strings.EqualFold(name, "Authorization") {
+			continue
+		}
+
+		var value string
+		if err := json.Unmarshal(raw, &value); err == nil {
+			return value, nil
+		}
+
+		var values []string
+		if err := json.Unmarshal(raw, &values); err == nil && len(values) > 0 {
+			return values[0], nil
+		}
+
+		return "", errors.New(errors.ErrInvalidFormat, "reflector returned an unsupported Authorization header format")
+	}
+
+	return "", nil
+}
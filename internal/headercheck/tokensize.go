@@ -0,0 +1,60 @@
+package headercheck
+
+import (
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+)
+
+// DefaultTokenSizeWarnThreshold is the bearer token size (in bytes) above which a
+// warning is logged. Some corporate proxies truncate or reject headers larger
+// than 8-16KB, and EKS tokens routinely approach that range.
+const DefaultTokenSizeWarnThreshold = 12 * 1024
+
+// authorizationOverhead is the size of the "Bearer " prefix added to the token
+// in the Authorization header
+const authorizationOverhead = len("Bearer ")
+
+// TokenSize describes the size of a bearer token as sent on the wire
+type TokenSize struct {
+	// TokenBytes is the length of the raw token
+	TokenBytes int `json:"tokenBytes"`
+
+	// HeaderBytes is the length of the Authorization header value ("Bearer <token>")
+	HeaderBytes int `json:"headerBytes"`
+
+	// Threshold is the configured warning threshold
+	Threshold int `json:"threshold"`
+
+	// ExceedsThreshold is true when HeaderBytes is above Threshold
+	ExceedsThreshold bool `json:"exceedsThreshold"`
+}
+
+// MeasureToken returns the size of the token and whether its Authorization header
+// exceeds the threshold. A threshold <= 0 uses DefaultTokenSizeWarnThreshold.
+func MeasureToken(token string, threshold int) TokenSize {
+	if threshold <= 0 {
+		threshold = DefaultTokenSizeWarnThreshold
+	}
+
+	headerBytes := len(token) + authorizationOverhead
+	return TokenSize{
+		TokenBytes:       len(token),
+		HeaderBytes:      headerBytes,
+		Threshold:        threshold,
+		ExceedsThreshold: headerBytes > threshold,
+	}
+}
+
+// WarnIfLarge measures the token and logs a warning when its Authorization header
+// exceeds the threshold. It returns the measurement so callers can include it in
+// structured output.
+func WarnIfLarge(log logger.Logger, token string, threshold int) TokenSize {
+	size := MeasureToken(token, threshold)
+	if size.ExceedsThreshold {
+		log.Warn("Bearer token exceeds size threshold; proxies that truncate large headers may cause 401 responses",
+			logger.Int("token_bytes", size.TokenBytes),
+			logger.Int("header_bytes", size.HeaderBytes),
+			logger.Int("threshold", size.Threshold),
+		)
+	}
+	return size
+}
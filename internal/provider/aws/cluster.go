@@ -5,9 +5,13 @@ import (
 	"encoding/base64"
 	"fmt"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/eks"
+	"github.com/aws/aws-sdk-go-v2/service/eks/types"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
 
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
 	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
 )
 
@@ -16,6 +20,12 @@ type ClusterInfo struct {
 	// Endpoint is the cluster API server endpoint (with https://)
 	Endpoint string
 
+	// PublicEndpoint and PrivateEndpoint are Endpoint when public or private API
+	// server access is enabled, and empty otherwise. EKS serves both from the same
+	// hostname, which resolves to private addresses inside the cluster VPC.
+	PublicEndpoint  string
+	PrivateEndpoint string
+
 	// CertificateAuthority is the base64-encoded cluster CA certificate
 	CertificateAuthority string
 
@@ -31,6 +41,24 @@ type ClusterInfo struct {
 
 // GetClusterInfo retrieves cluster information from EKS
 func (p *Provider) GetClusterInfo(ctx context.Context, clusterName string) (*ClusterInfo, error) {
+	ctx, span := provider.StartSpan(ctx, p.config.Tracing, provider.ProviderAWS, "GetClusterInfo", clusterName, p.config.Region)
+	info, err := p.getClusterInfo(ctx, clusterName)
+	provider.EndSpan(ctx, span, err)
+	return info, err
+}
+
+// getClusterInfo is GetClusterInfo without tracing
+func (p *Provider) getClusterInfo(ctx context.Context, clusterName string) (*ClusterInfo, error) {
+	if name, _, ok := parseClusterARN(clusterName); ok {
+		clusterName = name
+	}
+	if err := validateClusterName(clusterName); err != nil {
+		return nil, err
+	}
+	if err := validateRegion(p.config.Region); err != nil {
+		return nil, err
+	}
+
 	p.logger.Info("Getting EKS cluster info",
 		logger.String("cluster", clusterName),
 		logger.String("region", p.config.Region),
@@ -46,9 +74,12 @@ func (p *Provider) GetClusterInfo(ctx context.Context, clusterName string) (*Clu
 		return nil, fmt.Errorf("failed to load AWS credentials: %w", err)
 	}
 
-	cfg, err := config.LoadDefaultConfig(ctx,
-		config.WithRegion(creds.Region),
-	)
+	region := p.config.Region
+	if region == "" {
+		region = creds.Region
+	}
+
+	cfg, err := config.LoadDefaultConfig(ctx, p.config.loadOptions(region)...)
 	if err != nil {
 		p.logger.Error("Failed to create AWS config",
 			logger.String("cluster", clusterName),
@@ -56,25 +87,26 @@ func (p *Provider) GetClusterInfo(ctx context.Context, clusterName string) (*Clu
 		)
 		return nil, fmt.Errorf("failed to create AWS config: %w", err)
 	}
-
-	eksClient := eks.NewFromConfig(cfg)
+	region = cfg.Region
 
 	p.logger.Debug("Fetching cluster details",
 		logger.String("cluster", clusterName),
-		logger.String("region", creds.Region),
+		logger.String("region", region),
 	)
 
-	// Describe cluster
-	input := &eks.DescribeClusterInput{
-		Name: &clusterName,
+	describe := p.describeCluster
+	if describe == nil {
+		describe = p.eksDescribeCluster
 	}
-
-	output, err := eksClient.DescribeCluster(ctx, input)
+	output, err := describe(ctx, cfg, clusterName)
 	if err != nil {
 		p.logger.Error("Failed to describe cluster",
 			logger.String("cluster", clusterName),
 			logger.Error(err),
 		)
+		if isClusterNotFound(err) {
+			return nil, p.clusterNotFoundError(ctx, cfg, clusterName, describe, err)
+		}
 		return nil, fmt.Errorf("failed to describe cluster: %w", err)
 	}
 
@@ -91,25 +123,63 @@ func (p *Provider) GetClusterInfo(ctx context.Context, clusterName string) (*Clu
 	}
 
 	caCert := *cluster.CertificateAuthority.Data
+	if err := provider.ValidateCertificateAuthority(caCert); err != nil {
+		p.logger.Error("Cluster returned an invalid CA certificate",
+			logger.String("cluster", clusterName),
+			logger.Error(err),
+		)
+		return nil, err
+	}
 
+	publicEndpoint, privateEndpoint := clusterEndpoints(cluster)
 	info := &ClusterInfo{
 		Endpoint:             *cluster.Endpoint,
+		PublicEndpoint:       publicEndpoint,
+		PrivateEndpoint:      privateEndpoint,
 		CertificateAuthority: caCert,
 		Version:              getStringValue(cluster.Version),
-		Region:               creds.Region,
+		Region:               region,
 		ARN:                  getStringValue(cluster.Arn),
 	}
 
-	p.logger.Info("Successfully retrieved cluster info",
+	provider.LoggerWithBaggage(ctx, p.config.Tracing, p.logger).Info("Successfully retrieved cluster info",
 		logger.String("cluster", clusterName),
 		logger.String("endpoint", *cluster.Endpoint),
 		logger.String("version", getStringValue(cluster.Version)),
-		logger.String("region", creds.Region),
+		logger.String("region", region),
 	)
 
 	return info, nil
 }
 
+// eksDescribeCluster is the describeClusterFunc that calls EKS DescribeCluster in the
+// region of cfg
+func (p *Provider) eksDescribeCluster(ctx context.Context, cfg aws.Config, name string) (*eks.DescribeClusterOutput, error) {
+	return eks.NewFromConfig(cfg).DescribeCluster(ctx, &eks.DescribeClusterInput{Name: &name}, func(o *eks.Options) {
+		if baggage := p.config.Tracing.BaggageHeader(ctx); baggage != "" {
+			o.APIOptions = append(o.APIOptions, smithyhttp.AddHeaderValue("baggage", baggage))
+		}
+	})
+}
+
+// clusterEndpoints returns the endpoint of cluster as its public and private endpoint
+// according to the API server access it has enabled. A cluster without a VPC config
+// is taken to be public, which is the EKS default.
+func clusterEndpoints(cluster *types.Cluster) (public, private string) {
+	endpoint := getStringValue(cluster.Endpoint)
+	vpc := cluster.ResourcesVpcConfig
+	if vpc == nil {
+		return endpoint, ""
+	}
+	if vpc.EndpointPublicAccess {
+		public = endpoint
+	}
+	if vpc.EndpointPrivateAccess {
+		private = endpoint
+	}
+	return public, private
+}
+
 // getStringValue safely gets string value from pointer
 func getStringValue(s *string) string {
 	if s == nil {
@@ -0,0 +1,132 @@
+package aws
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+	"github.com/aws/aws-sdk-go-v2/service/eks/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/credentials"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/testutil"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+)
+
+func TestClusterEndpoints(t *testing.T) {
+	const endpoint = "https://ABCDEF.gr7.us-east-1.eks.amazonaws.com"
+
+	tests := []struct {
+		name        string
+		vpc         *types.VpcConfigResponse
+		wantPublic  string
+		wantPrivate string
+	}{
+		{name: "no VPC config", wantPublic: endpoint},
+		{name: "public only", vpc: &types.VpcConfigResponse{EndpointPublicAccess: true}, wantPublic: endpoint},
+		{name: "public and private", vpc: &types.VpcConfigResponse{EndpointPublicAccess: true, EndpointPrivateAccess: true}, wantPublic: endpoint, wantPrivate: endpoint},
+		{name: "private only", vpc: &types.VpcConfigResponse{EndpointPrivateAccess: true}, wantPrivate: endpoint},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			public, private := clusterEndpoints(&types.Cluster{Endpoint: aws.String(endpoint), ResourcesVpcConfig: tt.vpc})
+			assert.Equal(t, tt.wantPublic, public)
+			assert.Equal(t, tt.wantPrivate, private)
+		})
+	}
+}
+
+func TestSuggestRegions(t *testing.T) {
+	tests := []struct {
+		region string
+		want   []string
+	}{
+		{region: "us-east-1", want: []string{"us-east-2", "us-west-2", "us-west-1"}},
+		{region: "us-west-2", want: []string{"us-west-1", "us-east-1", "us-east-2"}},
+		{region: "eu-west-1", want: []string{"eu-west-2", "eu-west-3", "eu-central-1"}},
+		{region: "us-gov-west-1", want: []string{"us-gov-east-1"}},
+		{region: "cn-north-1", want: []string{"cn-northwest-1"}},
+		{region: "sa-east-1", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.region, func(t *testing.T) {
+			assert.Equal(t, tt.want, suggestRegions(tt.region))
+		})
+	}
+}
+
+func TestGetClusterInfo_RegionMismatch(t *testing.T) {
+	// newProvider returns a provider in us-east-1 whose cluster lives in clusterRegion,
+	// and the regions it described
+	newProvider := func(suggest bool, clusterRegion string) (*Provider, *[]string) {
+		var described []string
+		p := &Provider{
+			config:      &Config{Region: "us-east-1", SuggestRegion: suggest},
+			logger:      logger.Nop(),
+			credLoader:  testutil.NewMockCredLoader(),
+			awsCredOpts: credentials.AWSCredentialOptions{},
+			describeCluster: func(ctx context.Context, cfg aws.Config, name string) (*eks.DescribeClusterOutput, error) {
+				described = append(described, cfg.Region)
+				if cfg.Region != clusterRegion {
+					return nil, &types.ResourceNotFoundException{Message: aws.String("No cluster found for name: " + name + ".")}
+				}
+				return &eks.DescribeClusterOutput{Cluster: &types.Cluster{Name: aws.String(name)}}, nil
+			},
+		}
+		return p, &described
+	}
+
+	t.Run("without --suggest-region", func(t *testing.T) {
+		p, described := newProvider(false, "us-west-2")
+
+		_, err := p.GetClusterInfo(context.Background(), "my-cluster")
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, errors.ErrClusterNotFound))
+		assert.Contains(t, err.Error(), "EKS cluster my-cluster not found in region us-east-1")
+		assert.Contains(t, err.Error(), "--suggest-region")
+		assert.Equal(t, []string{"us-east-1"}, *described, "no extra calls by default")
+	})
+
+	t.Run("found in a nearby region", func(t *testing.T) {
+		p, described := newProvider(true, "us-west-2")
+
+		_, err := p.GetClusterInfo(context.Background(), "my-cluster")
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, errors.ErrClusterNotFound))
+		assert.Contains(t, err.Error(), "the cluster exists in us-west-2, which suggests a region mismatch; set --region=us-west-2")
+		assert.Equal(t, []string{"us-east-1", "us-east-2", "us-west-2"}, *described, "the search stops at the first hit")
+
+		var appErr *errors.Error
+		require.True(t, errors.As(err, &appErr))
+		assert.Equal(t, "us-west-2", appErr.Fields["suggested_region"])
+		assert.Equal(t, "us-east-1", appErr.Fields["region"])
+	})
+
+	t.Run("not found nearby", func(t *testing.T) {
+		p, described := newProvider(true, "ap-south-1")
+
+		_, err := p.GetClusterInfo(context.Background(), "my-cluster")
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, errors.ErrClusterNotFound))
+		assert.Contains(t, err.Error(), "not found in us-east-2, us-west-2, us-west-1 either")
+		assert.Len(t, *described, 1+maxSuggestRegions)
+	})
+
+	t.Run("other errors are not a region mismatch", func(t *testing.T) {
+		p, described := newProvider(true, "us-west-2")
+		p.describeCluster = func(ctx context.Context, cfg aws.Config, name string) (*eks.DescribeClusterOutput, error) {
+			*described = append(*described, cfg.Region)
+			return nil, &types.InvalidParameterException{Message: aws.String("invalid")}
+		}
+
+		_, err := p.GetClusterInfo(context.Background(), "my-cluster")
+		require.Error(t, err)
+		assert.False(t, errors.Is(err, errors.ErrClusterNotFound))
+		assert.Equal(t, []string{"us-east-1"}, *described)
+	})
+}
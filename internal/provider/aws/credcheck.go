@@ -0,0 +1,106 @@
+package aws
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/credentials"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+)
+
+// expiryWarningWindow is how close to expiry session credentials are logged as a warning
+const expiryWarningWindow = 5 * time.Minute
+
+// refreshHint tells users with expired session credentials how to get new ones
+const refreshHint = "re-run the assume-role flow (aws sts assume-role, aws sso login or your credential helper) " +
+	"and export the new credentials, or pass --skip-credential-check to skip this check"
+
+// expiredTokenCodes are the STS error codes returned for expired session credentials
+var expiredTokenCodes = map[string]bool{
+	"ExpiredToken":          true,
+	"ExpiredTokenException": true,
+	"RequestExpired":        true,
+}
+
+// callerIdentityFunc calls STS GetCallerIdentity with cfg
+type callerIdentityFunc func(ctx context.Context, cfg aws.Config) (*sts.GetCallerIdentityOutput, error)
+
+// stsCallerIdentity is the callerIdentityFunc that calls STS, at the configured STS
+// endpoint when there is one
+func (g *TokenGenerator) stsCallerIdentity(ctx context.Context, cfg aws.Config) (*sts.GetCallerIdentityOutput, error) {
+	var optFns []func(*sts.Options)
+	if g.config.STSEndpoint != "" {
+		endpoint, _, err := g.stsEndpoint(ctx, cfg.Region)
+		if err != nil {
+			return nil, err
+		}
+		optFns = append(optFns, func(o *sts.Options) {
+			o.BaseEndpoint = aws.String(endpoint)
+		})
+	}
+	return sts.NewFromConfig(cfg, optFns...).GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+}
+
+// checkSessionCredentials fails fast when session credentials have expired, since EKS
+// would otherwise reject the token with an error that blames the cluster. A known
+// expiration is checked locally; otherwise STS GetCallerIdentity is called. Long-lived
+// credentials are not checked.
+func (g *TokenGenerator) checkSessionCredentials(ctx context.Context, creds *credentials.AWSCredentials, cfg aws.Config) error {
+	if g.config.SkipCredentialCheck || creds.SessionToken == "" {
+		return nil
+	}
+
+	if !creds.Expiration.IsZero() {
+		remaining := creds.Expiration.Sub(g.clock.Now())
+		if remaining <= 0 {
+			return expiredCredentialsError(creds.Expiration, -remaining)
+		}
+		if remaining < expiryWarningWindow {
+			g.logger.Warn("AWS session credentials expire soon",
+				logger.String("expires_at", creds.Expiration.Format(time.RFC3339)),
+				logger.Duration("expires_in_seconds", int64(remaining.Seconds())),
+			)
+		}
+		return nil
+	}
+
+	_, err := g.callerIdentity(ctx, cfg)
+	if err == nil {
+		return nil
+	}
+
+	var coded interface{ ErrorCode() string }
+	if stderrors.As(err, &coded) && expiredTokenCodes[coded.ErrorCode()] {
+		return errors.Wrap(
+			errors.ErrCredentialExpired,
+			err,
+			"AWS session credentials have expired",
+		).WithField("provider", "aws").
+			WithDetail(refreshHint)
+	}
+
+	// Anything else, such as a network failure, is left to the token request itself
+	g.logger.Debug("Skipping AWS session credential check",
+		logger.Error(err),
+	)
+	return nil
+}
+
+// expiredCredentialsError reports session credentials that expired ago before now
+func expiredCredentialsError(expiration time.Time, ago time.Duration) error {
+	ago = ago.Round(time.Second)
+	return errors.New(
+		errors.ErrCredentialExpired,
+		fmt.Sprintf("AWS session credentials expired %s ago", ago),
+	).WithFields(map[string]interface{}{
+		"provider":    "aws",
+		"expired_at":  expiration.Format(time.RFC3339),
+		"expired_for": ago.String(),
+	}).WithDetail(refreshHint)
+}
@@ -0,0 +1,134 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/testutil"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+)
+
+// stsError is an STS API error with a code, like smithy.GenericAPIError
+type stsError struct {
+	code string
+}
+
+func (e stsError) Error() string     { return "api error " + e.code }
+func (e stsError) ErrorCode() string { return e.code }
+
+// recordingLogger keeps the warnings it is given
+type recordingLogger struct {
+	logger.Logger
+	warnings []string
+}
+
+func (l *recordingLogger) Warn(msg string, fields ...logger.Field) {
+	l.warnings = append(l.warnings, msg)
+}
+
+// With keeps recording into l, dropping the bound fields
+func (l *recordingLogger) With(fields ...logger.Field) logger.Logger {
+	return l
+}
+
+func TestTokenGenerator_CheckSessionCredentials(t *testing.T) {
+	// The SDK signs at the real time, which the generator clock must be near
+	now := time.Now()
+
+	tests := []struct {
+		name         string
+		expiration   time.Time
+		noSession    bool
+		skip         bool
+		stsErr       error
+		wantErrCode  errors.ErrorCode
+		wantErrText  string
+		wantSTSCalls int
+		wantWarning  bool
+	}{
+		{
+			name:        "expired",
+			expiration:  now.Add(-90 * time.Minute),
+			wantErrCode: errors.ErrCredentialExpired,
+			wantErrText: "expired 1h30m0s ago",
+		},
+		{
+			name:        "near expiry",
+			expiration:  now.Add(2 * time.Minute),
+			wantWarning: true,
+		},
+		{
+			name:       "valid",
+			expiration: now.Add(time.Hour),
+		},
+		{
+			name:         "expiration unknown and STS reports it expired",
+			stsErr:       stsError{code: "ExpiredToken"},
+			wantErrCode:  errors.ErrCredentialExpired,
+			wantErrText:  "have expired",
+			wantSTSCalls: 1,
+		},
+		{
+			name:         "expiration unknown and STS accepts it",
+			wantSTSCalls: 1,
+		},
+		{
+			name:         "STS unreachable",
+			stsErr:       fmt.Errorf("dial tcp: connection refused"),
+			wantSTSCalls: 1,
+		},
+		{
+			name:      "long-lived credentials",
+			noSession: true,
+		},
+		{
+			name:       "skipped",
+			expiration: now.Add(-time.Hour),
+			skip:       true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			creds := testutil.CreateValidAWSCredentialsWithSessionToken()
+			creds.Expiration = tt.expiration
+			if tt.noSession {
+				creds.SessionToken = ""
+			}
+
+			log := &recordingLogger{Logger: logger.Nop()}
+			generator := NewTokenGenerator(&Config{SkipCredentialCheck: tt.skip}, testutil.NewMockCredLoader().WithAWSCreds(creds), log)
+			generator.clock = testutil.NewMockTime(now)
+			stsCalls := 0
+			generator.callerIdentity = func(ctx context.Context, cfg aws.Config) (*sts.GetCallerIdentityOutput, error) {
+				stsCalls++
+				return &sts.GetCallerIdentityOutput{}, tt.stsErr
+			}
+
+			_, err := generator.GenerateToken(context.Background(), provider.GetTokenOptions{
+				ClusterName: "my-cluster",
+				Region:      "us-east-1",
+			})
+
+			assert.Equal(t, tt.wantSTSCalls, stsCalls)
+			assert.Equal(t, tt.wantWarning, len(log.warnings) > 0, "warnings: %v", log.warnings)
+			if tt.wantErrCode == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, tt.wantErrCode), "got %v", err)
+			assert.Contains(t, err.Error(), tt.wantErrText)
+			assert.Contains(t, err.Error(), "assume-role")
+		})
+	}
+}
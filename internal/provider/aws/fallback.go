@@ -0,0 +1,232 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+)
+
+// primarySource names the configured credentials in fallback logs and errors
+const primarySource = "primary"
+
+// roleSessionName is the session name of roles assumed for fallback entries
+const roleSessionName = "hyperfleet-credential-provider"
+
+// fallbackStopCodes are the codes of errors every credential source would fail with
+// again, such as a malformed request, so the fallback entries are not tried
+var fallbackStopCodes = map[errors.ErrorCode]bool{
+	errors.ErrInvalidArgument:  true,
+	errors.ErrInvalidFormat:    true,
+	errors.ErrMissingRequired:  true,
+	errors.ErrValidationFailed: true,
+}
+
+// Fallback is a credential source tried in order when the configured credentials fail to
+// produce a token: a profile of the AWS shared files, the region of the STS endpoint the
+// token is presigned for and a role assumed with the profile credentials. Empty fields
+// keep the provider configuration.
+type Fallback struct {
+	Profile string
+	Region  string
+	RoleARN string
+}
+
+// String returns the entry in --aws-fallback format, e.g. profile=backup,region=us-west-2
+func (f Fallback) String() string {
+	var parts []string
+	if f.Profile != "" {
+		parts = append(parts, "profile="+f.Profile)
+	}
+	if f.Region != "" {
+		parts = append(parts, "region="+f.Region)
+	}
+	if f.RoleARN != "" {
+		parts = append(parts, "role_arn="+f.RoleARN)
+	}
+	return strings.Join(parts, ",")
+}
+
+// ParseFallback parses an --aws-fallback entry: comma-separated profile, region and
+// role_arn settings such as profile=backup,region=us-west-2. At least one is required.
+func ParseFallback(spec string) (Fallback, error) {
+	var f Fallback
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(part, "=")
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if !ok || value == "" {
+			return Fallback{}, fallbackError(spec, fmt.Sprintf("AWS fallback setting %q is not key=value", part))
+		}
+		switch key {
+		case "profile":
+			f.Profile = value
+		case "region":
+			f.Region = value
+		case "role_arn":
+			f.RoleARN = value
+		default:
+			return Fallback{}, fallbackError(spec, fmt.Sprintf("unknown AWS fallback setting %q", key))
+		}
+	}
+	if f == (Fallback{}) {
+		return Fallback{}, fallbackError(spec, "AWS fallback entry is empty")
+	}
+	if err := f.Validate(); err != nil {
+		return Fallback{}, err
+	}
+	return f, nil
+}
+
+// Validate checks the region and role ARN of the entry
+func (f Fallback) Validate() error {
+	if err := validateRegion(f.Region); err != nil {
+		return err
+	}
+	if f.RoleARN == "" {
+		return nil
+	}
+	parsed, err := arn.Parse(f.RoleARN)
+	if err != nil || parsed.Service != "iam" || !strings.HasPrefix(parsed.Resource, "role/") {
+		return errors.New(
+			errors.ErrInvalidArgument,
+			"invalid AWS fallback role ARN",
+		).WithFields(map[string]interface{}{
+			"provider": "aws",
+			"role_arn": f.RoleARN,
+		}).WithDetail("expected an IAM role ARN such as arn:aws:iam::123456789012:role/my-role")
+	}
+	return nil
+}
+
+// fallbackError returns the ERR_INVALID_ARGUMENT error of a malformed --aws-fallback entry
+func fallbackError(spec, message string) error {
+	return errors.New(errors.ErrInvalidArgument, message).
+		WithFields(map[string]interface{}{
+			"provider": "aws",
+			"fallback": spec,
+		}).
+		WithDetail("expected comma-separated profile=, region= and role_arn= settings, e.g. profile=backup,region=us-west-2")
+}
+
+// assumeRoleFunc exchanges the credentials of cfg for those of roleARN
+type assumeRoleFunc func(ctx context.Context, cfg aws.Config, roleARN string) (aws.Credentials, error)
+
+// stsAssumeRole is the assumeRoleFunc that calls STS AssumeRole, at the configured STS
+// endpoint when there is one
+func (g *TokenGenerator) stsAssumeRole(ctx context.Context, cfg aws.Config, roleARN string) (aws.Credentials, error) {
+	var optFns []func(*sts.Options)
+	if g.config.STSEndpoint != "" {
+		endpoint, _, err := g.stsEndpoint(ctx, cfg.Region)
+		if err != nil {
+			return aws.Credentials{}, err
+		}
+		optFns = append(optFns, func(o *sts.Options) {
+			o.BaseEndpoint = aws.String(endpoint)
+		})
+	}
+	output, err := sts.NewFromConfig(cfg, optFns...).AssumeRole(ctx, &sts.AssumeRoleInput{
+		RoleArn:         aws.String(roleARN),
+		RoleSessionName: aws.String(roleSessionName),
+	})
+	if err != nil {
+		return aws.Credentials{}, err
+	}
+	if output.Credentials == nil {
+		return aws.Credentials{}, fmt.Errorf("AssumeRole returned no credentials")
+	}
+	creds := aws.Credentials{
+		AccessKeyID:     aws.ToString(output.Credentials.AccessKeyId),
+		SecretAccessKey: aws.ToString(output.Credentials.SecretAccessKey),
+		SessionToken:    aws.ToString(output.Credentials.SessionToken),
+	}
+	if output.Credentials.Expiration != nil {
+		creds.CanExpire = true
+		creds.Expires = *output.Credentials.Expiration
+	}
+	return creds, nil
+}
+
+// fallbackAttempt is a failed token attempt with one credential source
+type fallbackAttempt struct {
+	source string
+	err    error
+}
+
+// generateToken generates a token with the configured credentials, then with each
+// fallback entry in order until one succeeds. Errors that every entry would repeat, and
+// a cancelled or expired ctx, end the chain early. When every entry fails, the error
+// lists the code of each attempt.
+func (p *Provider) generateToken(ctx context.Context, opts provider.GetTokenOptions) (*provider.Token, error) {
+	sources := append([]Fallback{{}}, p.config.Fallbacks...)
+
+	var attempts []fallbackAttempt
+	for i, source := range sources {
+		name := primarySource
+		if i > 0 {
+			name = source.String()
+		}
+
+		token, err := p.tokenGenerator.GenerateTokenFrom(ctx, opts, source)
+		if err == nil {
+			err = p.tokenGenerator.ValidateToken(token)
+		}
+		if err == nil {
+			if i > 0 {
+				p.logger.Warn("AWS token generated with a fallback credential source",
+					logger.String("cluster", opts.ClusterName),
+					logger.String("fallback", name),
+					logger.Int("attempt", i+1),
+				)
+				if p.config.Metrics != nil {
+					p.config.Metrics.RecordTokenFallback(provider.ProviderAWS.String(), name)
+				}
+			}
+			return token, nil
+		}
+
+		if len(sources) == 1 || fallbackStopCodes[errors.GetCode(err)] || ctx.Err() != nil {
+			return nil, err
+		}
+		attempts = append(attempts, fallbackAttempt{source: name, err: err})
+		if i < len(sources)-1 {
+			p.logger.Warn("AWS credential source failed, trying the next fallback",
+				logger.String("cluster", opts.ClusterName),
+				logger.String("source", name),
+				logger.String("code", string(errors.GetCode(err))),
+				logger.Error(err),
+			)
+		}
+	}
+
+	return nil, fallbackExhaustedError(attempts)
+}
+
+// fallbackExhaustedError returns the ERR_TOKEN_GENERATION_FAILED error of a chain whose
+// every attempt failed, wrapping the last failure
+func fallbackExhaustedError(attempts []fallbackAttempt) error {
+	codes := make([]string, len(attempts))
+	messages := make([]string, len(attempts))
+	for i, attempt := range attempts {
+		codes[i] = fmt.Sprintf("%s: %s", attempt.source, errors.GetCode(attempt.err))
+		messages[i] = fmt.Sprintf("%s: %v", attempt.source, attempt.err)
+	}
+	return errors.Wrap(
+		errors.ErrTokenGenerationFailed,
+		attempts[len(attempts)-1].err,
+		fmt.Sprintf("all %d AWS credential sources failed", len(attempts)),
+	).WithFields(map[string]interface{}{
+		"provider": "aws",
+		"attempts": strings.Join(codes, "; "),
+	}).WithDetail(strings.Join(messages, "; "))
+}
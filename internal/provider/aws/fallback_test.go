@@ -0,0 +1,228 @@
+package aws
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/prometheus/client_golang/prometheus"
+	prometheustestutil "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/credentials"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/testutil"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/metrics"
+)
+
+// profileLoader is a credential loader whose AWS credentials depend on the profile. It
+// records the profiles it was asked for.
+type profileLoader struct {
+	*testutil.MockCredLoader
+	errs     map[string]error
+	profiles []string
+}
+
+func (l *profileLoader) LoadAWS(ctx context.Context, opts credentials.AWSCredentialOptions) (*credentials.AWSCredentials, error) {
+	l.profiles = append(l.profiles, opts.Profile)
+	if err := l.errs[opts.Profile]; err != nil {
+		return nil, err
+	}
+	return testutil.CreateValidAWSCredentials(), nil
+}
+
+// newFallbackProvider returns a provider with fallbacks whose loader fails for the
+// profiles of errs
+func newFallbackProvider(errs map[string]error, m *metrics.Metrics, fallbacks ...Fallback) (*Provider, *profileLoader) {
+	log := logger.Nop()
+	config := &Config{Profile: "main", TokenDuration: 15 * time.Minute, Fallbacks: fallbacks, Metrics: m}
+	loader := &profileLoader{MockCredLoader: testutil.NewMockCredLoader(), errs: errs}
+	return &Provider{
+		config:         config,
+		logger:         log,
+		tokenGenerator: NewTokenGenerator(config, loader, log),
+		credLoader:     loader,
+	}, loader
+}
+
+func TestParseFallback(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		want    Fallback
+		wantErr string
+	}{
+		{
+			name: "all settings",
+			spec: "profile=backup, region=us-west-2,role_arn=arn:aws:iam::123456789012:role/eks-admin",
+			want: Fallback{Profile: "backup", Region: "us-west-2", RoleARN: "arn:aws:iam::123456789012:role/eks-admin"},
+		},
+		{
+			name: "region only",
+			spec: "region=eu-west-1",
+			want: Fallback{Region: "eu-west-1"},
+		},
+		{
+			name:    "empty",
+			spec:    " , ",
+			wantErr: "AWS fallback entry is empty",
+		},
+		{
+			name:    "unknown setting",
+			spec:    "profile=backup,account=123456789012",
+			wantErr: `unknown AWS fallback setting "account"`,
+		},
+		{
+			name:    "not key=value",
+			spec:    "backup",
+			wantErr: `AWS fallback setting "backup" is not key=value`,
+		},
+		{
+			name:    "invalid region",
+			spec:    "region=west",
+			wantErr: "invalid AWS region",
+		},
+		{
+			name:    "not a role ARN",
+			spec:    "role_arn=arn:aws:iam::123456789012:user/alice",
+			wantErr: "invalid AWS fallback role ARN",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseFallback(tt.spec)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.True(t, errors.Is(err, errors.ErrInvalidArgument))
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+
+			reparsed, err := ParseFallback(got.String())
+			require.NoError(t, err)
+			assert.Equal(t, got, reparsed, "String should round-trip")
+		})
+	}
+}
+
+func TestProvider_GetToken_FallbackOrder(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	m := metrics.NewMetrics(metrics.Config{Namespace: "test", Registry: registry})
+	awsProvider, loader := newFallbackProvider(map[string]error{
+		"main":   errors.New(errors.ErrCredentialNotFound, "no credentials for main"),
+		"backup": errors.New(errors.ErrCredentialExpired, "backup expired"),
+	}, m, Fallback{Profile: "backup"}, Fallback{Profile: "dr", Region: "us-west-2"}, Fallback{Profile: "unused"})
+
+	token, err := awsProvider.GetToken(context.Background(), provider.GetTokenOptions{
+		ClusterName: "my-cluster",
+		Region:      "us-east-1",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"main", "backup", "dr"}, loader.profiles, "entries should be tried in order until one succeeds")
+
+	info, err := InspectToken(token.AccessToken, false)
+	require.NoError(t, err)
+	assert.Equal(t, "us-west-2", info.Region, "the token should be presigned in the region of the entry")
+	assert.Equal(t, float64(1), prometheustestutil.ToFloat64(m.TokenFallbackTotal.WithLabelValues("aws", "profile=dr,region=us-west-2")))
+}
+
+func TestProvider_GetToken_FallbackPrimarySucceeds(t *testing.T) {
+	awsProvider, loader := newFallbackProvider(nil, nil, Fallback{Profile: "backup"})
+
+	_, err := awsProvider.GetToken(context.Background(), provider.GetTokenOptions{ClusterName: "my-cluster", Region: "us-east-1"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"main"}, loader.profiles)
+}
+
+func TestProvider_GetToken_FallbackShortCircuit(t *testing.T) {
+	t.Run("invalid cluster name", func(t *testing.T) {
+		awsProvider, loader := newFallbackProvider(nil, nil, Fallback{Profile: "backup"})
+
+		_, err := awsProvider.GetToken(context.Background(), provider.GetTokenOptions{ClusterName: ""})
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, errors.ErrInvalidArgument))
+		assert.Empty(t, loader.profiles, "validation errors should not load any credentials")
+	})
+
+	t.Run("invalid argument from the primary attempt", func(t *testing.T) {
+		awsProvider, loader := newFallbackProvider(nil, nil, Fallback{Profile: "backup"})
+		awsProvider.config.STSEndpoint = "http://sts.example.com"
+
+		_, err := awsProvider.GetToken(context.Background(), provider.GetTokenOptions{ClusterName: "my-cluster"})
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, errors.ErrInvalidArgument))
+		assert.Equal(t, []string{"main"}, loader.profiles, "every entry would fail the same way")
+	})
+
+	t.Run("cancelled context", func(t *testing.T) {
+		awsProvider, loader := newFallbackProvider(map[string]error{
+			"main": errors.New(errors.ErrCredentialNotFound, "no credentials for main"),
+		}, nil, Fallback{Profile: "backup"})
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := awsProvider.GetToken(ctx, provider.GetTokenOptions{ClusterName: "my-cluster"})
+		require.Error(t, err)
+		assert.Equal(t, []string{"main"}, loader.profiles)
+	})
+}
+
+func TestProvider_GetToken_FallbackExhausted(t *testing.T) {
+	awsProvider, loader := newFallbackProvider(map[string]error{
+		"main":   errors.New(errors.ErrCredentialNotFound, "no credentials for main"),
+		"backup": errors.New(errors.ErrCredentialExpired, "backup expired"),
+	}, nil, Fallback{Profile: "backup"})
+
+	_, err := awsProvider.GetToken(context.Background(), provider.GetTokenOptions{ClusterName: "my-cluster"})
+	require.Error(t, err)
+	assert.Equal(t, []string{"main", "backup"}, loader.profiles)
+	assert.True(t, errors.Is(err, errors.ErrTokenGenerationFailed))
+	assert.Contains(t, err.Error(), "all 2 AWS credential sources failed")
+
+	var appErr *errors.Error
+	require.True(t, errors.As(err, &appErr))
+	assert.Equal(t, "primary: ERR_CREDENTIAL_LOAD_FAILED; profile=backup: ERR_CREDENTIAL_LOAD_FAILED", appErr.Fields["attempts"])
+	assert.Contains(t, appErr.Detail, "no credentials for main")
+	assert.Contains(t, appErr.Detail, "backup expired")
+}
+
+func TestProvider_GetToken_FallbackWithoutEntries(t *testing.T) {
+	awsProvider, _ := newFallbackProvider(map[string]error{
+		"main": errors.New(errors.ErrCredentialNotFound, "no credentials for main"),
+	}, nil)
+
+	_, err := awsProvider.GetToken(context.Background(), provider.GetTokenOptions{ClusterName: "my-cluster"})
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, errors.ErrCredentialLoadFailed), "a single source should keep its own error")
+}
+
+func TestProvider_GetToken_FallbackAssumesRole(t *testing.T) {
+	awsProvider, _ := newFallbackProvider(map[string]error{
+		"main": errors.New(errors.ErrCredentialNotFound, "no credentials for main"),
+	}, nil, Fallback{Profile: "backup", RoleARN: "arn:aws:iam::123456789012:role/eks-admin"})
+
+	var assumed string
+	awsProvider.tokenGenerator.assumeRole = func(ctx context.Context, cfg aws.Config, roleARN string) (aws.Credentials, error) {
+		assumed = roleARN
+		return aws.Credentials{
+			AccessKeyID:     "ASIAROLEEXAMPLE00001",
+			SecretAccessKey: "role-secret",
+			SessionToken:    "role-session",
+		}, nil
+	}
+
+	token, err := awsProvider.GetToken(context.Background(), provider.GetTokenOptions{ClusterName: "my-cluster", Region: "us-east-1"})
+	require.NoError(t, err)
+	assert.Equal(t, "arn:aws:iam::123456789012:role/eks-admin", assumed)
+
+	info, err := InspectToken(token.AccessToken, false)
+	require.NoError(t, err)
+	assert.Contains(t, info.AccessKeyID, "ASIA", "the token should be signed with the role credentials")
+}
@@ -0,0 +1,97 @@
+package aws
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+)
+
+// amzDateFormat is the timestamp format of the X-Amz-Date query parameter
+const amzDateFormat = "20060102T150405Z"
+
+// TokenInfo is a human-readable breakdown of an EKS bearer token.
+// Sensitive fields are redacted unless the token was inspected in unsafe mode.
+type TokenInfo struct {
+	ClusterName   string    `json:"clusterName"`
+	ClusterID     string    `json:"clusterId"`
+	Method        string    `json:"method"`
+	Host          string    `json:"host"`
+	Action        string    `json:"action"`
+	Algorithm     string    `json:"algorithm"`
+	AccessKeyID   string    `json:"accessKeyId"`
+	Region        string    `json:"region"`
+	Service       string    `json:"service"`
+	SignedHeaders string    `json:"signedHeaders"`
+	SignedAt      time.Time `json:"signedAt"`
+	ExpiresAt     time.Time `json:"expiresAt"`
+	Signature     string    `json:"signature"`
+	SessionToken  string    `json:"sessionToken,omitempty"`
+	URL           string    `json:"url,omitempty"`
+}
+
+// InspectToken decodes an EKS bearer token and extracts the presigned URL parameters.
+// The signature, session token, full access key ID and full presigned URL are only
+// included when unsafe is set.
+func InspectToken(token string, unsafe bool) (*TokenInfo, error) {
+	payload, err := DecodeToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	presigned, err := url.Parse(payload.URL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse presigned URL: %w", err)
+	}
+	query := presigned.Query()
+
+	info := &TokenInfo{
+		ClusterName:   payload.ClusterName,
+		ClusterID:     payload.ClusterID(),
+		Method:        payload.Method,
+		Host:          presigned.Host,
+		Action:        query.Get("Action"),
+		Algorithm:     query.Get("X-Amz-Algorithm"),
+		SignedHeaders: query.Get("X-Amz-SignedHeaders"),
+		Signature:     provider.RedactUnlessUnsafe(query.Get("X-Amz-Signature"), unsafe),
+		SessionToken:  provider.RedactUnlessUnsafe(query.Get("X-Amz-Security-Token"), unsafe),
+	}
+
+	// X-Amz-Credential is <access key id>/<date>/<region>/<service>/aws4_request
+	credential := strings.Split(query.Get("X-Amz-Credential"), "/")
+	if len(credential) != 5 {
+		return nil, fmt.Errorf("presigned URL has malformed X-Amz-Credential")
+	}
+	info.AccessKeyID = credential[0]
+	if !unsafe {
+		info.AccessKeyID = provider.RedactPartial(credential[0], 4)
+	}
+	info.Region = credential[2]
+	info.Service = credential[3]
+
+	signedAt, err := time.Parse(amzDateFormat, query.Get("X-Amz-Date"))
+	if err != nil {
+		return nil, fmt.Errorf("presigned URL has malformed X-Amz-Date: %w", err)
+	}
+	info.SignedAt = signedAt
+	info.ExpiresAt = signedAt.Add(defaultPresignDuration)
+	if raw := query.Get("X-Amz-Expires"); raw != "" {
+		expires, err := strconv.Atoi(raw)
+		if err != nil {
+			return nil, fmt.Errorf("presigned URL has malformed X-Amz-Expires: %w", err)
+		}
+		info.ExpiresAt = signedAt.Add(time.Duration(expires) * time.Second)
+	}
+
+	// The STS URL is shown without its credential, signature and session token
+	info.URL = logger.RedactPresignedURL(payload.URL)
+	if unsafe {
+		info.URL = payload.URL
+	}
+
+	return info, nil
+}
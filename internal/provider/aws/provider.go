@@ -16,6 +16,9 @@ type Provider struct {
 	tokenGenerator *TokenGenerator
 	credLoader     credentials.Loader
 	awsCredOpts    credentials.AWSCredentialOptions
+
+	// describeCluster describes EKS clusters; nil calls EKS
+	describeCluster describeClusterFunc
 }
 
 // NewProvider creates a new AWS provider
@@ -27,19 +30,26 @@ func NewProvider(config *Config, log logger.Logger) (*Provider, error) {
 	// Note: For AWS, region is optional and can be provided at token generation time
 	// Unlike GCP which requires project_id, AWS can work with just credentials
 
-	credLoader := credentials.NewLoader(log)
+	credLoader, err := provider.NewCredentialLoader(log, config.StrictPermissions, config.WatchCredentials, config.Metrics, config.HTTPClient,
+		credentials.WithExpectedSHA256(config.CredentialsSHA256))
+	if err != nil {
+		return nil, err
+	}
 
 	tokenGenerator := NewTokenGenerator(config, credLoader, log)
 
 	// Setup AWS credential options
 	awsCredOpts := credentials.AWSCredentialOptions{
-		CredentialsFile: config.CredentialsFile, // Use config.CredentialsFile if provided
-		UseEnvironment:  true,
+		CredentialsFile:     config.CredentialsFile, // Use config.CredentialsFile if provided
+		Profile:             config.Profile,
+		UseEnvironment:      true,
+		UseInstanceMetadata: credentials.DetectAWSInstanceMetadata(),
 	}
 
 	log.Debug("AWS provider initialized",
 		logger.String("region", config.Region),
 		logger.Duration("token_duration_seconds", int64(config.TokenDuration.Seconds())),
+		logger.Int("fallbacks", len(config.Fallbacks)),
 	)
 
 	return &Provider{
@@ -53,16 +63,23 @@ func NewProvider(config *Config, log logger.Logger) (*Provider, error) {
 
 // GetToken generates an EKS authentication token
 func (p *Provider) GetToken(ctx context.Context, opts provider.GetTokenOptions) (*provider.Token, error) {
-	if opts.ClusterName == "" {
-		return nil, errors.New(
-			errors.ErrInvalidArgument,
-			"cluster name is required",
-		).WithField("provider", "aws")
+	// A cluster ARN names the cluster and its region
+	if name, region, ok := parseClusterARN(opts.ClusterName); ok {
+		opts.ClusterName = name
+		if opts.Region == "" {
+			opts.Region = region
+		}
+	}
+	if err := validateClusterName(opts.ClusterName); err != nil {
+		return nil, err
 	}
 
 	if opts.Region == "" && p.config.Region != "" {
 		opts.Region = p.config.Region
 	}
+	if err := validateRegion(opts.Region); err != nil {
+		return nil, err
+	}
 
 	// Region is still optional for AWS (can use default region from env)
 	if opts.Region == "" {
@@ -75,8 +92,12 @@ func (p *Provider) GetToken(ctx context.Context, opts provider.GetTokenOptions)
 		logger.String("account_id", opts.AccountID),
 	)
 
-	// Generate token using token generator
-	token, err := p.tokenGenerator.GenerateToken(ctx, opts)
+	name := provider.ProviderAWS.String()
+	p.config.Metrics.IncInflight(name)
+	defer p.config.Metrics.DecInflight(name)
+
+	// Generate token with the configured credentials, then the fallback entries
+	token, err := p.generateToken(ctx, opts)
 	if err != nil {
 		p.logger.Error("Failed to generate AWS token",
 			logger.String("cluster", opts.ClusterName),
@@ -86,10 +107,9 @@ func (p *Provider) GetToken(ctx context.Context, opts provider.GetTokenOptions)
 		return nil, err
 	}
 
-	if err := p.tokenGenerator.ValidateToken(token); err != nil {
-		return nil, err
-	}
-
+	now := p.tokenGenerator.clock.Now()
+	p.config.Metrics.SetTokenExpiry(name, opts.ClusterName, token.ExpiresAt.Sub(now))
+	p.config.Metrics.SetLastSuccess(name, opts.ClusterName, now)
 	return token, nil
 }
 
@@ -101,8 +121,10 @@ func (p *Provider) ValidateCredentials(ctx context.Context) error {
 
 	// Try to load credentials
 	credOpts := credentials.AWSCredentialOptions{
-		Region:         p.config.Region,
-		UseEnvironment: true,
+		Region:              p.config.Region,
+		Profile:             p.config.Profile,
+		UseEnvironment:      true,
+		UseInstanceMetadata: credentials.DetectAWSInstanceMetadata(),
 	}
 
 	creds, err := p.credLoader.LoadAWS(ctx, credOpts)
@@ -145,7 +167,23 @@ func (p *Provider) ValidateCredentials(ctx context.Context) error {
 	return nil
 }
 
+// ValidateToken checks a token with the token generator
+func (p *Provider) ValidateToken(token *provider.Token) error {
+	return p.tokenGenerator.ValidateToken(token)
+}
+
+// RefreshToken returns currentToken while the token generator accepts it and it is
+// outside the refresh threshold, and otherwise generates a new token with GetToken
+func (p *Provider) RefreshToken(ctx context.Context, opts provider.GetTokenOptions, currentToken *provider.Token) (*provider.Token, error) {
+	return provider.RefreshIfNeeded(ctx, p, opts, currentToken, p.tokenGenerator.clock, p.tokenGenerator.refreshThreshold())
+}
+
 // Name returns the provider name
 func (p *Provider) Name() string {
 	return "aws"
 }
+
+// Close stops watching the credentials file of Config.WatchCredentials
+func (p *Provider) Close() error {
+	return provider.CloseCredentialLoader(p.credLoader)
+}
@@ -2,17 +2,23 @@ package aws
 
 import (
 	"context"
+	"fmt"
+	"net/url"
 	"os"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	prometheustestutil "github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/testutil"
 	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
 	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/metrics"
 )
 
 func TestNewProvider(t *testing.T) {
@@ -355,3 +361,173 @@ func TestProvider_Integration(t *testing.T) {
 	assert.False(t, token.IsExpired())
 	assert.True(t, token.ExpiresAt.After(time.Now()))
 }
+
+func TestProvider_GetToken_ClusterARN(t *testing.T) {
+	log := logger.Nop()
+	config := &Config{TokenDuration: 15 * time.Minute}
+	mockLoader := testutil.NewMockCredLoader().WithAWSCreds(testutil.CreateValidAWSCredentials())
+	awsProvider := &Provider{
+		config:         config,
+		logger:         log,
+		tokenGenerator: NewTokenGenerator(config, mockLoader, log),
+		credLoader:     mockLoader,
+	}
+
+	token, err := awsProvider.GetToken(context.Background(), provider.GetTokenOptions{
+		ClusterName: "arn:aws:eks:eu-west-1:123456789012:cluster/my-cluster",
+	})
+	require.NoError(t, err)
+
+	info, err := InspectToken(token.AccessToken, false)
+	require.NoError(t, err)
+	assert.Equal(t, "my-cluster", info.ClusterID)
+	assert.Equal(t, "eu-west-1", info.Region, "the region should come from the ARN")
+}
+
+func TestProvider_RefreshToken(t *testing.T) {
+	// The SDK signs at the real time, which the generator clock must be near
+	now := time.Now()
+
+	tests := []struct {
+		name        string
+		current     *provider.Token
+		wantRefresh bool
+	}{
+		{
+			name:        "no current token",
+			wantRefresh: true,
+		},
+		{
+			name:    "valid token outside the threshold",
+			current: &provider.Token{AccessToken: v1Prefix + "current", ExpiresAt: now.Add(10 * time.Minute)},
+		},
+		{
+			name:        "token within the threshold",
+			current:     &provider.Token{AccessToken: v1Prefix + "current", ExpiresAt: now.Add(defaultRefreshThreshold)},
+			wantRefresh: true,
+		},
+		{
+			name:        "expired token",
+			current:     &provider.Token{AccessToken: v1Prefix + "current", ExpiresAt: now.Add(-time.Minute)},
+			wantRefresh: true,
+		},
+		{
+			name:        "token without the EKS prefix",
+			current:     &provider.Token{AccessToken: "not-an-eks-token", ExpiresAt: now.Add(10 * time.Minute)},
+			wantRefresh: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := logger.Nop()
+			config := &Config{TokenDuration: 15 * time.Minute}
+			mockLoader := testutil.NewMockCredLoader().WithAWSCreds(testutil.CreateValidAWSCredentials())
+			awsProvider := &Provider{
+				config:         config,
+				logger:         log,
+				tokenGenerator: NewTokenGenerator(config, mockLoader, log),
+				credLoader:     mockLoader,
+			}
+			awsProvider.tokenGenerator.clock = testutil.NewMockTime(now)
+
+			// A cluster ARN is only accepted by GetToken, so a refresh must go through it
+			opts := provider.GetTokenOptions{ClusterName: "arn:aws:eks:eu-west-1:123456789012:cluster/my-cluster"}
+			token, err := awsProvider.RefreshToken(context.Background(), opts, tt.current)
+			require.NoError(t, err)
+			if !tt.wantRefresh {
+				assert.Same(t, tt.current, token)
+				return
+			}
+			assert.NotSame(t, tt.current, token)
+			require.NoError(t, awsProvider.ValidateToken(token))
+			info, err := InspectToken(token.AccessToken, false)
+			require.NoError(t, err)
+			assert.Equal(t, "my-cluster", info.ClusterID)
+		})
+	}
+}
+
+func TestProvider_GetToken_DebugLogsRedacted(t *testing.T) {
+	log, output := testutil.NewDebugLogger(t)
+	creds := testutil.CreateValidAWSCredentialsWithSessionToken()
+	config := &Config{TokenDuration: 15 * time.Minute}
+	mockLoader := testutil.NewMockCredLoader().WithAWSCreds(creds)
+	awsProvider := &Provider{
+		config:         config,
+		logger:         log,
+		tokenGenerator: NewTokenGenerator(config, mockLoader, log),
+		credLoader:     mockLoader,
+	}
+
+	token, err := awsProvider.GetToken(context.Background(), provider.GetTokenOptions{
+		ClusterName: "my-cluster",
+		Region:      "us-east-1",
+	})
+	require.NoError(t, err)
+
+	payload, err := DecodeToken(token.AccessToken)
+	require.NoError(t, err)
+	testutil.AssertNotLogged(t, output.String(),
+		creds.AccessKeyID,
+		creds.SecretAccessKey,
+		creds.SessionToken,
+		payload.URL,
+		"X-Amz-Signature",
+		"X-Amz-Credential",
+		token.AccessToken,
+	)
+	assert.Contains(t, output.String(), "sts.us-east-1.amazonaws.com", "the presigned URL host is logged")
+
+	// The query parameters that explain a rejected token are logged, the signature never
+	presigned, err := url.Parse(payload.URL)
+	require.NoError(t, err)
+	testutil.AssertNotLogged(t, output.String(), presigned.Query().Get("X-Amz-Signature"))
+	assert.Contains(t, output.String(), `"action":"GetCallerIdentity"`)
+	assert.Contains(t, output.String(), `"version":"2011-06-15"`)
+	assert.Contains(t, output.String(), `"signed_headers":"host"`)
+	assert.Contains(t, output.String(), `"signature":"***"`)
+	assert.Contains(t, output.String(), "/us-east-1/sts/aws4_request", "the credential scope is logged")
+	assert.Contains(t, output.String(), fmt.Sprintf(`"url_length":%d`, len(payload.URL)))
+}
+
+func TestProvider_GetToken_LogsBoundFields(t *testing.T) {
+	log, output := testutil.NewDebugLogger(t)
+	config := &Config{TokenDuration: 15 * time.Minute}
+	mockLoader := testutil.NewMockCredLoader().WithAWSCreds(testutil.CreateValidAWSCredentials())
+	generator := NewTokenGenerator(config, mockLoader, log)
+
+	_, err := generator.GenerateToken(context.Background(), provider.GetTokenOptions{
+		ClusterName: "my-cluster",
+		Region:      "us-east-1",
+	})
+	require.NoError(t, err)
+
+	// Every line of a token generation carries the provider and cluster once
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	require.NotEmpty(t, lines)
+	for _, line := range lines {
+		assert.Equal(t, 1, strings.Count(line, `"provider":"aws"`), line)
+		assert.Equal(t, 1, strings.Count(line, `"cluster":"my-cluster"`), line)
+	}
+}
+
+func TestProvider_GetToken_Gauges(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	m := metrics.NewMetrics(metrics.Config{Namespace: "test", Registry: registry})
+	awsProvider, _ := newFallbackProvider(nil, m)
+	// The gauges are set at the time of the provider clock
+	now := time.Now().Truncate(time.Second)
+	awsProvider.tokenGenerator.clock = testutil.NewMockTime(now)
+
+	_, err := awsProvider.GetToken(context.Background(), provider.GetTokenOptions{ClusterName: "my-cluster", Region: "us-east-1"})
+	require.NoError(t, err)
+
+	assert.Equal(t, (15 * time.Minute).Seconds(), prometheustestutil.ToFloat64(m.TokenExpirySeconds.WithLabelValues("aws", "my-cluster")))
+	assert.Equal(t, float64(now.Unix()), prometheustestutil.ToFloat64(m.LastSuccessfulToken.WithLabelValues("aws", "my-cluster")))
+	assert.Equal(t, float64(0), prometheustestutil.ToFloat64(m.InflightTokenRequests.WithLabelValues("aws")), "the generation should no longer be in flight")
+
+	_, err = awsProvider.GetToken(context.Background(), provider.GetTokenOptions{ClusterName: "other-cluster", Region: "us-east-1"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, prometheustestutil.CollectAndCount(m.TokenExpirySeconds))
+}
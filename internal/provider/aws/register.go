@@ -0,0 +1,75 @@
+package aws
+
+import (
+	"context"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+)
+
+func init() {
+	provider.MustRegisterProvider(provider.ProviderAWS, provider.Registration{
+		Constructor:  newFromConfig,
+		Capabilities: provider.Capabilities{BoundAudience: true, EndpointAccess: true},
+		Defaults: provider.Defaults{
+			TokenDuration:    defaultPresignDuration,
+			RefreshThreshold: defaultRefreshThreshold,
+			MaxDuration:      maxTokenDuration,
+		},
+		ValidateOptions: validateOptions,
+		Inputs: []provider.Input{
+			{Name: "region", RequiredFor: provider.OperationClusterLookup | provider.OperationKubeconfig},
+		},
+	})
+}
+
+// newFromConfig creates an AWS provider from the shared provider configuration
+func newFromConfig(cfg *provider.Config, log logger.Logger) (provider.Provider, error) {
+	config := DefaultConfig()
+	config.Region = cfg.Region
+	config.AccountID = cfg.AccountID
+	config.Profile = cfg.AWSProfile
+	config.CredentialsFile = cfg.CredentialsFile
+	config.StrictPermissions = cfg.StrictPermissions
+	config.CredentialsSHA256 = cfg.CredentialsSHA256
+	config.SkipCredentialCheck = cfg.SkipCredentialCheck
+	config.STSEndpoint = cfg.STSEndpoint
+	config.SuggestRegion = cfg.AWSSuggestRegion
+	config.Tracing = cfg.Tracing
+	config.HTTPClient = cfg.HTTPClient
+	config.WatchCredentials = cfg.WatchCredentials
+	config.Metrics = cfg.Metrics
+	if cfg.TokenDuration > 0 {
+		config.TokenDuration = cfg.TokenDuration
+	}
+	for _, spec := range cfg.AWSFallbacks {
+		fallback, err := ParseFallback(spec)
+		if err != nil {
+			return nil, err
+		}
+		config.Fallbacks = append(config.Fallbacks, fallback)
+	}
+
+	return NewProvider(config, log)
+}
+
+// DescribeCluster implements provider.ClusterDescriber
+func (p *Provider) DescribeCluster(ctx context.Context, opts provider.ClusterInfoOptions) (*provider.ClusterInfo, error) {
+	info, err := p.GetClusterInfo(ctx, opts.ClusterName)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint, err := provider.ClusterEndpoint(opts.EndpointAccess, info.Endpoint, info.PublicEndpoint, info.PrivateEndpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	return &provider.ClusterInfo{
+		Endpoint:             endpoint,
+		CertificateAuthority: info.CertificateAuthority,
+		Version:              info.Version,
+		Region:               info.Region,
+		ARN:                  info.ARN,
+	}, nil
+}
@@ -0,0 +1,110 @@
+package aws
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+	"github.com/aws/aws-sdk-go-v2/service/eks/types"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+)
+
+// maxSuggestRegions bounds the extra DescribeCluster calls of a --suggest-region lookup
+const maxSuggestRegions = 3
+
+// eksRegions are the regions searched for a cluster that is not found in the configured
+// one, in the order they are tried within a region group
+var eksRegions = []string{
+	"us-east-1", "us-east-2", "us-west-2", "us-west-1",
+	"ca-central-1", "ca-west-1",
+	"eu-west-1", "eu-central-1", "eu-west-2", "eu-west-3", "eu-north-1", "eu-south-1", "eu-central-2", "eu-south-2",
+	"ap-northeast-1", "ap-southeast-1", "ap-southeast-2", "ap-south-1", "ap-northeast-2", "ap-northeast-3",
+	"ap-east-1", "ap-southeast-3", "ap-southeast-4", "ap-south-2",
+	"sa-east-1", "me-south-1", "me-central-1", "af-south-1", "il-central-1", "mx-central-1",
+	"us-gov-west-1", "us-gov-east-1",
+	"cn-north-1", "cn-northwest-1",
+}
+
+// describeClusterFunc describes the EKS cluster name in the region of cfg
+type describeClusterFunc func(ctx context.Context, cfg aws.Config, name string) (*eks.DescribeClusterOutput, error)
+
+// isClusterNotFound reports whether err is the EKS error of a cluster that does not exist
+func isClusterNotFound(err error) bool {
+	var notFound *types.ResourceNotFoundException
+	return stderrors.As(err, &notFound)
+}
+
+// regionGroup returns the group of region searched by --suggest-region: its geography,
+// with GovCloud kept apart from the commercial US regions
+func regionGroup(region string) string {
+	if strings.HasPrefix(region, "us-gov-") {
+		return "us-gov"
+	}
+	group, _, _ := strings.Cut(region, "-")
+	return group
+}
+
+// suggestRegions returns up to maxSuggestRegions likely regions of a cluster not found in
+// region: the other regions of its group, those in the same area (such as us-east) first
+func suggestRegions(region string) []string {
+	area := region
+	if i := strings.LastIndex(region, "-"); i > 0 {
+		area = region[:i]
+	}
+
+	var sameArea, sameGroup []string
+	for _, candidate := range eksRegions {
+		switch {
+		case candidate == region || regionGroup(candidate) != regionGroup(region):
+		case strings.HasPrefix(candidate, area+"-"):
+			sameArea = append(sameArea, candidate)
+		default:
+			sameGroup = append(sameGroup, candidate)
+		}
+	}
+	candidates := append(sameArea, sameGroup...)
+	if len(candidates) > maxSuggestRegions {
+		candidates = candidates[:maxSuggestRegions]
+	}
+	return candidates
+}
+
+// clusterNotFoundError returns the ERR_CLUSTER_NOT_FOUND error of a cluster missing from
+// the region of cfg. With SuggestRegion, the cluster is looked for in a few likely regions
+// and the error names the one it is found in.
+func (p *Provider) clusterNotFoundError(ctx context.Context, cfg aws.Config, name string, describe describeClusterFunc, err error) error {
+	notFound := errors.Wrap(
+		errors.ErrClusterNotFound,
+		err,
+		fmt.Sprintf("EKS cluster %s not found in region %s", name, cfg.Region),
+	).WithFields(map[string]interface{}{
+		"provider": "aws",
+		"cluster":  name,
+		"region":   cfg.Region,
+	})
+	if !p.config.SuggestRegion {
+		return notFound.WithDetail("check that --region is the region of the cluster, or pass --suggest-region to look for it in nearby regions")
+	}
+
+	candidates := suggestRegions(cfg.Region)
+	for _, candidate := range candidates {
+		regional := cfg.Copy()
+		regional.Region = candidate
+		if _, err := describe(ctx, regional, name); err != nil {
+			p.logger.Debug("Cluster not found in suggested region",
+				logger.String("cluster", name),
+				logger.String("region", candidate),
+				logger.Error(err),
+			)
+			continue
+		}
+		return notFound.WithField("suggested_region", candidate).
+			WithDetail(fmt.Sprintf("the cluster exists in %s, which suggests a region mismatch; set --region=%s", candidate, candidate))
+	}
+	return notFound.WithDetail(fmt.Sprintf("the cluster was not found in %s either; check the cluster name and the AWS account", strings.Join(candidates, ", ")))
+}
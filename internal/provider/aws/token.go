@@ -29,6 +29,14 @@ const (
 
 	// defaultPresignDuration is the default duration for presigned URLs
 	defaultPresignDuration = 15 * time.Minute
+
+	// defaultRefreshThreshold is the remaining lifetime at which a token is refreshed,
+	// shorter than for other providers because of the 15 minute token duration
+	defaultRefreshThreshold = 2 * time.Minute
+
+	// maxTokenDuration is the longest lifetime of an EKS token: the API server rejects
+	// presigned STS URLs older than 15 minutes
+	maxTokenDuration = 15 * time.Minute
 )
 
 // TokenGenerator handles AWS STS token generation for EKS clusters
@@ -36,23 +44,49 @@ type TokenGenerator struct {
 	config     *Config
 	credLoader credentials.Loader
 	logger     logger.Logger
+
+	// clock, callerIdentity and assumeRole are replaced in tests
+	clock          provider.Clock
+	callerIdentity callerIdentityFunc
+	assumeRole     assumeRoleFunc
 }
 
-// NewTokenGenerator creates a new AWS token generator
-func NewTokenGenerator(config *Config, credLoader credentials.Loader, logger logger.Logger) *TokenGenerator {
-	return &TokenGenerator{
+// NewTokenGenerator creates a new AWS token generator. Its log lines carry provider=aws.
+func NewTokenGenerator(config *Config, credLoader credentials.Loader, log logger.Logger) *TokenGenerator {
+	g := &TokenGenerator{
 		config:     config,
 		credLoader: credLoader,
-		logger:     logger,
+		logger:     log.With(logger.String("provider", "aws")),
+		clock:      provider.SystemClock,
 	}
+	g.callerIdentity = g.stsCallerIdentity
+	g.assumeRole = g.stsAssumeRole
+	return g
 }
 
 // GenerateToken generates a presigned STS token for EKS authentication
 func (g *TokenGenerator) GenerateToken(ctx context.Context, opts provider.GetTokenOptions) (*provider.Token, error) {
+	return g.GenerateTokenFrom(ctx, opts, Fallback{})
+}
+
+// GenerateTokenFrom generates a token with the credentials of source. Its empty profile
+// and region are the configured ones, and its role is assumed when set.
+func (g *TokenGenerator) GenerateTokenFrom(ctx context.Context, opts provider.GetTokenOptions, source Fallback) (*provider.Token, error) {
+	if source.Region != "" {
+		opts.Region = source.Region
+	}
+	ctx, span := provider.StartSpan(ctx, g.config.Tracing, provider.ProviderAWS, "GenerateToken", opts.ClusterName, opts.Region)
+	token, err := g.generateToken(ctx, opts, source)
+	provider.EndSpan(ctx, span, err)
+	return token, err
+}
+
+// generateToken is GenerateTokenFrom without tracing
+func (g *TokenGenerator) generateToken(ctx context.Context, opts provider.GetTokenOptions, source Fallback) (*provider.Token, error) {
 	startTime := time.Now()
+	log := g.logger.With(logger.String("cluster", opts.ClusterName))
 
-	g.logger.Debug("Starting AWS token generation",
-		logger.String("cluster", opts.ClusterName),
+	log.Debug("Starting AWS token generation",
 		logger.String("region", opts.Region),
 		logger.String("account_id", opts.AccountID),
 	)
@@ -64,12 +98,20 @@ func (g *TokenGenerator) GenerateToken(ctx context.Context, opts provider.GetTok
 		).WithField("provider", "aws")
 	}
 
-	awsConfig, err := g.loadAWSConfig(ctx, opts)
+	awsConfig, err := g.loadAWSConfig(ctx, opts, source)
 	if err != nil {
 		return nil, err
 	}
 
-	stsClient := sts.NewFromConfig(awsConfig)
+	// Pin the STS endpoint rather than relying on the SDK default, which may be the
+	// global sts.amazonaws.com endpoint that some partitions do not serve
+	stsEndpoint, stsHost, err := g.stsEndpoint(ctx, awsConfig.Region)
+	if err != nil {
+		return nil, err
+	}
+	stsClient := sts.NewFromConfig(awsConfig, func(o *sts.Options) {
+		o.BaseEndpoint = aws.String(stsEndpoint)
+	})
 	presignClient := sts.NewPresignClient(stsClient)
 
 	presignedURL, err := g.createPresignedURL(ctx, presignClient, opts)
@@ -77,42 +119,66 @@ func (g *TokenGenerator) GenerateToken(ctx context.Context, opts provider.GetTok
 		return nil, err
 	}
 
-	tokenString, err := g.encodeToken(opts.ClusterName, presignedURL)
+	// EKS accepts the presigned URL for 15 minutes from its signing time; a signing time
+	// far from the current time means a skewed clock and a token that is dead on arrival
+	signedAt, err := presignedSigningTime(presignedURL)
 	if err != nil {
 		return nil, err
 	}
+	if err := provider.CheckClockSkew(g.clock, log, "aws", signedAt.Add(defaultPresignDuration), defaultPresignDuration); err != nil {
+		return nil, err
+	}
 
-	expiresAt := time.Now().Add(g.getTokenDuration())
+	clusterID := boundClusterID(opts)
+	tokenString, err := g.encodeToken(clusterID, presignedURL)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifySTSHost(tokenString, stsHost); err != nil {
+		return nil, err
+	}
+
+	expiresAt := g.clock.Now().Add(g.getTokenDuration())
 	token := &provider.Token{
 		AccessToken: tokenString,
 		ExpiresAt:   expiresAt,
 		TokenType:   "Bearer",
+		Provider:    provider.ProviderAWS.String(),
+		ClusterName: opts.ClusterName,
+		Identity:    logger.RedactAccessKeyID(presignedAccessKeyID(presignedURL)),
+		Audience:    clusterID,
 	}
 
 	duration := time.Since(startTime)
-	g.logger.Info("AWS token generated successfully",
-		logger.String("cluster", opts.ClusterName),
+	provider.LoggerWithBaggage(ctx, g.config.Tracing, log).Info("AWS token generated successfully",
 		logger.String("region", opts.Region),
 		logger.Duration("duration_ms", duration.Milliseconds()),
 		logger.String("expires_at", token.ExpiresAt.Format(time.RFC3339)),
-		logger.Duration("expires_in_seconds", int64(token.ExpiresIn().Seconds())),
+		logger.Duration("expires_in_seconds", int64(token.ExpiresInWith(g.clock).Seconds())),
 	)
 
 	return token, nil
 }
 
-// loadAWSConfig loads AWS configuration from credentials and environment
-func (g *TokenGenerator) loadAWSConfig(ctx context.Context, opts provider.GetTokenOptions) (aws.Config, error) {
+// loadAWSConfig loads AWS configuration from the credentials of source and environment
+func (g *TokenGenerator) loadAWSConfig(ctx context.Context, opts provider.GetTokenOptions, source Fallback) (aws.Config, error) {
 	// Determine region
 	region := opts.Region
 	if region == "" && g.config.Region != "" {
 		region = g.config.Region
 	}
 
+	profile := source.Profile
+	if profile == "" {
+		profile = g.config.Profile
+	}
+
 	// Load AWS credentials
 	credOpts := credentials.AWSCredentialOptions{
-		Region:         region,
-		UseEnvironment: true,
+		Region:              region,
+		Profile:             profile,
+		UseEnvironment:      true,
+		UseInstanceMetadata: credentials.DetectAWSInstanceMetadata(),
 	}
 
 	creds, err := g.credLoader.LoadAWS(ctx, credOpts)
@@ -124,14 +190,13 @@ func (g *TokenGenerator) loadAWSConfig(ctx context.Context, opts provider.GetTok
 		).WithField("provider", "aws")
 	}
 
-	g.logger.Debug("AWS credentials loaded",
+	g.logger.With(logger.String("cluster", opts.ClusterName)).Debug("AWS credentials loaded",
 		logger.String("region", creds.Region),
 		logger.Bool("has_session_token", creds.SessionToken != ""),
 	)
 
 	// Load AWS config with credentials
-	cfg, err := config.LoadDefaultConfig(ctx,
-		config.WithRegion(region),
+	cfg, err := config.LoadDefaultConfig(ctx, g.config.loadOptions(region,
 		config.WithCredentialsProvider(aws.CredentialsProviderFunc(func(ctx context.Context) (aws.Credentials, error) {
 			return aws.Credentials{
 				AccessKeyID:     creds.AccessKeyID,
@@ -139,7 +204,7 @@ func (g *TokenGenerator) loadAWSConfig(ctx context.Context, opts provider.GetTok
 				SessionToken:    creds.SessionToken,
 			}, nil
 		})),
-	)
+	)...)
 	if err != nil {
 		return aws.Config{}, errors.Wrap(
 			errors.ErrCredentialInvalid,
@@ -148,6 +213,27 @@ func (g *TokenGenerator) loadAWSConfig(ctx context.Context, opts provider.GetTok
 		).WithField("provider", "aws")
 	}
 
+	if err := g.checkSessionCredentials(ctx, creds, cfg); err != nil {
+		return aws.Config{}, err
+	}
+
+	if source.RoleARN != "" {
+		roleCreds, err := g.assumeRole(ctx, cfg, source.RoleARN)
+		if err != nil {
+			return aws.Config{}, errors.Wrap(
+				errors.ErrCredentialInvalid,
+				err,
+				"failed to assume AWS role",
+			).WithFields(map[string]interface{}{
+				"provider": "aws",
+				"role_arn": source.RoleARN,
+			})
+		}
+		cfg.Credentials = aws.CredentialsProviderFunc(func(ctx context.Context) (aws.Credentials, error) {
+			return roleCreds, nil
+		})
+	}
+
 	return cfg, nil
 }
 
@@ -170,14 +256,149 @@ func (g *TokenGenerator) createPresignedURL(ctx context.Context, presigner *sts.
 		})
 	}
 
-	g.logger.Debug("Presigned URL created",
-		logger.String("url_length", fmt.Sprintf("%d", len(presignResult.URL))),
+	fields := []logger.Field{
+		logger.PresignedURL("url", presignResult.URL),
+		logger.Int("url_length", len(presignResult.URL)),
 		logger.String("method", presignResult.Method),
-	)
+	}
+	g.logger.With(logger.String("cluster", opts.ClusterName)).Debug("Presigned URL created", append(fields, logger.PresignedURLParams(presignResult.URL)...)...)
 
 	return presignResult.URL, nil
 }
 
+// presignedSigningTime returns the X-Amz-Date signing time of a presigned URL
+func presignedSigningTime(presignedURL string) (time.Time, error) {
+	parsedURL, err := url.Parse(presignedURL)
+	if err == nil {
+		var signedAt time.Time
+		signedAt, err = time.Parse(amzDateFormat, parsedURL.Query().Get("X-Amz-Date"))
+		if err == nil {
+			return signedAt, nil
+		}
+	}
+	return time.Time{}, errors.Wrap(
+		errors.ErrTokenMalformed,
+		err,
+		"failed to read the signing time of the presigned URL",
+	).WithField("provider", "aws")
+}
+
+// presignedAccessKeyID returns the access key ID that signed a presigned URL, or "" when
+// its X-Amz-Credential is missing
+func presignedAccessKeyID(presignedURL string) string {
+	parsedURL, err := url.Parse(presignedURL)
+	if err != nil {
+		return ""
+	}
+	// X-Amz-Credential is <access key id>/<date>/<region>/<service>/aws4_request
+	accessKeyID, _, _ := strings.Cut(parsedURL.Query().Get("X-Amz-Credential"), "/")
+	return accessKeyID
+}
+
+// stsEndpoint returns the URL and host of the STS endpoint tokens presign requests to:
+// the configured STS endpoint, such as an interface VPC endpoint, or else the regional
+// endpoint of region
+func (g *TokenGenerator) stsEndpoint(ctx context.Context, region string) (string, string, error) {
+	if g.config.STSEndpoint == "" {
+		endpoint, err := regionalSTSEndpoint(ctx, region)
+		if err != nil {
+			return "", "", err
+		}
+		return endpoint.String(), endpoint.Host, nil
+	}
+
+	host, err := stsEndpointHost(g.config.STSEndpoint)
+	if err != nil {
+		return "", "", err
+	}
+	return strings.TrimSuffix(g.config.STSEndpoint, "/"), host, nil
+}
+
+// stsEndpointHost returns the host of an STS endpoint URL, which must be an https URL
+// without a path, query or user info
+func stsEndpointHost(endpoint string) (string, error) {
+	parsed, err := url.Parse(endpoint)
+	if err != nil || parsed.Scheme != "https" || parsed.Host == "" ||
+		strings.Trim(parsed.Path, "/") != "" || parsed.RawQuery != "" || parsed.User != nil {
+		return "", errors.New(
+			errors.ErrInvalidArgument,
+			"STS endpoint must be an https URL such as https://vpce-0123-abcd.sts.us-east-1.vpce.amazonaws.com",
+		).WithFields(map[string]interface{}{
+			"provider":     "aws",
+			"sts_endpoint": endpoint,
+		})
+	}
+	return parsed.Host, nil
+}
+
+// ValidateSTSEndpoint checks an STS endpoint override before it is written into a
+// kubeconfig, where a malformed value would only fail when kubectl runs the plugin
+func ValidateSTSEndpoint(endpoint string) error {
+	_, err := stsEndpointHost(endpoint)
+	return err
+}
+
+// regionalSTSEndpoint returns the STS endpoint of region in its partition as the SDK
+// resolves it, e.g. https://sts.us-east-1.amazonaws.com or
+// https://sts.cn-north-1.amazonaws.com.cn
+func regionalSTSEndpoint(ctx context.Context, region string) (*url.URL, error) {
+	if region == "" {
+		return nil, errors.New(
+			errors.ErrInvalidArgument,
+			"region is required for the regional STS endpoint",
+		).WithField("provider", "aws")
+	}
+
+	endpoint, err := sts.NewDefaultEndpointResolverV2().ResolveEndpoint(ctx, sts.EndpointParameters{
+		Region:            aws.String(region),
+		UseGlobalEndpoint: aws.Bool(false),
+	})
+	if err != nil {
+		return nil, errors.Wrap(
+			errors.ErrInvalidArgument,
+			err,
+			"failed to resolve the regional STS endpoint",
+		).WithFields(map[string]interface{}{
+			"provider": "aws",
+			"region":   region,
+		})
+	}
+	return &endpoint.URI, nil
+}
+
+// verifySTSHost checks that token presigns a request to the STS endpoint at host, so
+// that a token never silently falls back to another endpoint
+func verifySTSHost(token, host string) error {
+	payload, err := DecodeToken(token)
+	if err != nil {
+		return errors.Wrap(
+			errors.ErrTokenMalformed,
+			err,
+			"failed to decode generated token",
+		).WithField("provider", "aws")
+	}
+
+	parsedURL, err := url.Parse(payload.URL)
+	if err != nil {
+		return errors.Wrap(
+			errors.ErrTokenMalformed,
+			err,
+			"failed to parse presigned URL",
+		).WithField("provider", "aws")
+	}
+	if parsedURL.Host != host {
+		return errors.New(
+			errors.ErrTokenGenerationFailed,
+			"presigned URL does not use the expected STS endpoint",
+		).WithFields(map[string]interface{}{
+			"provider": "aws",
+			"expected": host,
+			"actual":   parsedURL.Host,
+		})
+	}
+	return nil
+}
+
 // encodeToken encodes the presigned URL and cluster name into an EKS bearer token
 // Format: "k8s-aws-v1." + base64url(JSON payload)
 func (g *TokenGenerator) encodeToken(clusterName string, presignedURL string) (string, error) {
@@ -217,7 +438,7 @@ func (g *TokenGenerator) encodeToken(clusterName string, presignedURL string) (s
 
 	token := v1Prefix + encoded
 
-	g.logger.Debug("Token encoded",
+	g.logger.With(logger.String("cluster", clusterName)).Debug("Token encoded",
 		logger.String("token_length", fmt.Sprintf("%d", len(token))),
 		logger.String("prefix", v1Prefix),
 	)
@@ -225,6 +446,20 @@ func (g *TokenGenerator) encodeToken(clusterName string, presignedURL string) (s
 	return token, nil
 }
 
+// boundClusterID returns the cluster ID the token is bound to through the x-k8s-aws-id
+// header: the audience when one is set, then the cluster ID, for aws-iam-authenticator
+// deployments configured with a cluster ID other than the EKS cluster name, and the
+// cluster name otherwise
+func boundClusterID(opts provider.GetTokenOptions) string {
+	if opts.Audience != "" {
+		return opts.Audience
+	}
+	if opts.ClusterID != "" {
+		return opts.ClusterID
+	}
+	return opts.ClusterName
+}
+
 // stsPresignedURLPayload represents the payload for an EKS authentication token
 type stsPresignedURLPayload struct {
 	URL         string              `json:"url"`
@@ -233,12 +468,25 @@ type stsPresignedURLPayload struct {
 	Headers     map[string][]string `json:"headers"`
 }
 
-// getTokenDuration returns the configured token duration or default
+// ClusterID returns the cluster ID the token is bound to, from the x-k8s-aws-id header
+func (p *stsPresignedURLPayload) ClusterID() string {
+	if ids := p.Headers[clusterIDHeader]; len(ids) > 0 {
+		return ids[0]
+	}
+	return p.ClusterName
+}
+
+// getTokenDuration returns the configured token duration or the registered default
 func (g *TokenGenerator) getTokenDuration() time.Duration {
 	if g.config.TokenDuration > 0 {
 		return g.config.TokenDuration
 	}
-	return defaultPresignDuration
+	return provider.DefaultsOf(string(provider.ProviderAWS)).TokenDuration
+}
+
+// refreshThreshold returns the remaining lifetime at which a token is refreshed
+func (g *TokenGenerator) refreshThreshold() time.Duration {
+	return provider.RefreshThresholdOf(string(provider.ProviderAWS))
 }
 
 // ValidateToken validates that a token is valid and not expired
@@ -250,6 +498,10 @@ func (g *TokenGenerator) ValidateToken(token *provider.Token) error {
 		).WithField("provider", "aws")
 	}
 
+	if err := provider.CheckTokenIssuer(token, provider.ProviderAWS); err != nil {
+		return err
+	}
+
 	if token.AccessToken == "" {
 		return errors.New(
 			errors.ErrTokenInvalid,
@@ -267,7 +519,7 @@ func (g *TokenGenerator) ValidateToken(token *provider.Token) error {
 		})
 	}
 
-	if token.IsExpired() {
+	if token.IsExpiredWith(g.clock) {
 		return errors.New(
 			errors.ErrTokenExpired,
 			"token has expired",
@@ -277,33 +529,23 @@ func (g *TokenGenerator) ValidateToken(token *provider.Token) error {
 		})
 	}
 
-	// Warn if token expires soon (less than 2 minutes for AWS's shorter duration)
-	if token.ExpiresIn() < 2*time.Minute {
-		g.logger.Warn("Token expires soon",
-			logger.String("provider", "aws"),
-			logger.Duration("expires_in_seconds", int64(token.ExpiresIn().Seconds())),
-		)
-	}
+	provider.WarnExpiringSoon(g.logger, token, g.clock, g.refreshThreshold())
 
 	return nil
 }
 
 // RefreshToken refreshes an expired or soon-to-expire token
 func (g *TokenGenerator) RefreshToken(ctx context.Context, opts provider.GetTokenOptions, currentToken *provider.Token) (*provider.Token, error) {
-	if currentToken != nil && !currentToken.IsExpired() {
-		// For AWS, refresh if less than 2 minutes remaining (due to shorter 15min duration)
-		if currentToken.ExpiresIn() > 2*time.Minute {
-			g.logger.Debug("Token still valid, no refresh needed",
-				logger.String("provider", "aws"),
-				logger.Duration("expires_in_seconds", int64(currentToken.ExpiresIn().Seconds())),
-			)
-			return currentToken, nil
-		}
+	log := g.logger.With(logger.String("cluster", opts.ClusterName))
+	if currentToken != nil && !currentToken.NeedsRefresh(g.clock, g.refreshThreshold()) {
+		log.Debug("Token still valid, no refresh needed",
+			logger.Duration("expires_in_seconds", int64(currentToken.ExpiresInWith(g.clock).Seconds())),
+		)
+		return currentToken, nil
 	}
 
-	g.logger.Info("Refreshing AWS token",
-		logger.String("cluster", opts.ClusterName),
-		logger.Bool("expired", currentToken == nil || currentToken.IsExpired()),
+	log.Info("Refreshing AWS token",
+		logger.Bool("expired", currentToken == nil || currentToken.IsExpiredWith(g.clock)),
 	)
 
 	// Generate new token
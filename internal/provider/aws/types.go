@@ -1,21 +1,95 @@
 package aws
 
 import (
+	"crypto/tls"
+	"net/http"
 	"time"
+
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/config"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/metrics"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/tracing"
 )
 
 // Config holds AWS provider configuration
 type Config struct {
 	Region          string
 	AccountID       string
+	Profile         string
 	RoleARN         string
 	CredentialsFile string
 	TokenDuration   time.Duration
+
+	// StrictPermissions rejects credentials files readable by group or others
+	StrictPermissions bool
+
+	// CredentialsSHA256 are the comma-separated SHA-256 digests, one of which every
+	// credentials file read must have; empty skips the check
+	CredentialsSHA256 string
+
+	// SkipCredentialCheck skips the pre-flight check that session credentials have not
+	// expired, saving an STS call when their expiration is unknown
+	SkipCredentialCheck bool
+
+	// STSEndpoint overrides the regional STS endpoint that tokens presign requests to,
+	// e.g. an interface VPC endpoint reached through PrivateLink
+	STSEndpoint string
+
+	// Tracing records spans for token generation and cluster lookups; nil disables tracing
+	Tracing *tracing.Provider
+
+	// HTTPClient carries the STS and EKS requests; nil uses the SDK default
+	HTTPClient *http.Client
+
+	// WatchCredentials reloads the credentials file when it changes on disk
+	WatchCredentials bool
+
+	// SuggestRegion looks for a cluster that is not found in its region in a few likely
+	// regions, to report a region mismatch
+	SuggestRegion bool
+
+	// Fallbacks are the credential sources tried in order when the configured
+	// credentials fail to produce a token
+	Fallbacks []Fallback
+
+	// Metrics records credential reloads, fallbacks and token gauges; nil disables them
+	Metrics *metrics.Metrics
+}
+
+// loadOptions returns the options of an AWS SDK config in region, with the
+// configured HTTP client when there is one
+func (c *Config) loadOptions(region string, opts ...func(*config.LoadOptions) error) []func(*config.LoadOptions) error {
+	opts = append([]func(*config.LoadOptions) error{config.WithRegion(region)}, opts...)
+	if c.HTTPClient != nil {
+		opts = append(opts, config.WithHTTPClient(c.sdkHTTPClient()))
+	}
+	return opts
+}
+
+// sdkHTTPClient adapts the configured HTTP client for the SDK. The proxy and trusted
+// CAs of an http.Transport are applied to the SDK's own client instead, which can still
+// add the AWS_CA_BUNDLE certificates; other clients are used as they are.
+func (c *Config) sdkHTTPClient() config.HTTPClient {
+	transport, ok := c.HTTPClient.Transport.(*http.Transport)
+	if !ok {
+		return c.HTTPClient
+	}
+	return awshttp.NewBuildableClient().WithTransportOptions(func(tr *http.Transport) {
+		tr.Proxy = transport.Proxy
+		if transport.TLSClientConfig != nil && transport.TLSClientConfig.RootCAs != nil {
+			if tr.TLSClientConfig == nil {
+				tr.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+			}
+			tr.TLSClientConfig.RootCAs = transport.TLSClientConfig.RootCAs
+		}
+	})
 }
 
 // DefaultConfig returns default AWS configuration
 func DefaultConfig() *Config {
 	return &Config{
-		TokenDuration: 15 * time.Minute,
+		TokenDuration: provider.DefaultsOf(string(provider.ProviderAWS)).TokenDuration,
 	}
 }
@@ -0,0 +1,87 @@
+package aws
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+)
+
+const maxClusterNameLength = 100
+
+var (
+	// clusterNamePattern is the EKS cluster name constraint
+	clusterNamePattern = regexp.MustCompile(`^[0-9A-Za-z][A-Za-z0-9\-_]*$`)
+
+	// regionPattern matches AWS region codes, including the GovCloud, China and ISO
+	// partitions (us-east-1, us-gov-west-1, cn-north-1, us-isob-east-1)
+	regionPattern = regexp.MustCompile(`^[a-z]{2}(-gov|-iso[a-z]?)?-[a-z]+-\d+$`)
+)
+
+// validateClusterName checks a cluster name against the EKS naming rules
+func validateClusterName(name string) error {
+	if name == "" {
+		return errors.New(
+			errors.ErrInvalidArgument,
+			"cluster name is required",
+		).WithField("provider", "aws")
+	}
+	if len(name) > maxClusterNameLength || !clusterNamePattern.MatchString(name) {
+		return errors.New(
+			errors.ErrInvalidArgument,
+			"invalid EKS cluster name",
+		).WithFields(map[string]interface{}{
+			"provider":     "aws",
+			"cluster_name": name,
+		}).WithDetail("expected up to 100 letters, digits, hyphens and underscores, starting with a letter or digit")
+	}
+	return nil
+}
+
+// validateOptions implements provider.OptionsValidator with the checks GetToken
+// makes before generating a token
+func validateOptions(opts provider.GetTokenOptions) error {
+	if name, _, ok := parseClusterARN(opts.ClusterName); ok {
+		opts.ClusterName = name
+	}
+	if err := validateClusterName(opts.ClusterName); err != nil {
+		return err
+	}
+	return validateRegion(opts.Region)
+}
+
+// parseClusterARN returns the name and region of an EKS cluster ARN such as
+// arn:aws:eks:us-east-1:123456789012:cluster/my-cluster. ok is false for anything
+// else, including plain cluster names.
+func parseClusterARN(s string) (name, region string, ok bool) {
+	if !arn.IsARN(s) {
+		return "", "", false
+	}
+	parsed, err := arn.Parse(s)
+	if err != nil || parsed.Service != "eks" {
+		return "", "", false
+	}
+	name, ok = strings.CutPrefix(parsed.Resource, "cluster/")
+	if !ok || name == "" {
+		return "", "", false
+	}
+	return name, parsed.Region, true
+}
+
+// validateRegion checks a region has the AWS region code format. An empty region is
+// allowed because the SDK falls back to the default region of the environment.
+func validateRegion(region string) error {
+	if region == "" || regionPattern.MatchString(region) {
+		return nil
+	}
+	return errors.New(
+		errors.ErrInvalidArgument,
+		"invalid AWS region",
+	).WithFields(map[string]interface{}{
+		"provider": "aws",
+		"region":   region,
+	}).WithDetail("expected a region code such as us-east-1")
+}
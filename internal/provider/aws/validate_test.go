@@ -0,0 +1,121 @@
+package aws
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/testutil"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+)
+
+func TestValidateClusterName(t *testing.T) {
+	tests := []struct {
+		name        string
+		clusterName string
+		wantErr     bool
+	}{
+		{name: "simple", clusterName: "my-cluster"},
+		{name: "mixed case with underscore", clusterName: "Prod_Cluster-01"},
+		{name: "starts with digit", clusterName: "01-cluster"},
+		{name: "maximum length", clusterName: strings.Repeat("a", 100)},
+		{name: "empty", clusterName: "", wantErr: true},
+		{name: "too long", clusterName: strings.Repeat("a", 101), wantErr: true},
+		{name: "starts with hyphen", clusterName: "-cluster", wantErr: true},
+		{name: "contains period", clusterName: "my.cluster", wantErr: true},
+		{name: "ARN instead of name", clusterName: "arn:aws:eks:us-east-1:123456789012:cluster/my-cluster", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateClusterName(tt.clusterName)
+			if !tt.wantErr {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, errors.ErrInvalidArgument))
+		})
+	}
+}
+
+func TestParseClusterARN(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		wantName   string
+		wantRegion string
+		wantOK     bool
+	}{
+		{name: "cluster ARN", input: "arn:aws:eks:us-east-1:123456789012:cluster/my-cluster", wantName: "my-cluster", wantRegion: "us-east-1", wantOK: true},
+		{name: "GovCloud partition", input: "arn:aws-us-gov:eks:us-gov-west-1:123456789012:cluster/gov-cluster", wantName: "gov-cluster", wantRegion: "us-gov-west-1", wantOK: true},
+		{name: "plain name", input: "my-cluster"},
+		{name: "nodegroup ARN", input: "arn:aws:eks:us-east-1:123456789012:nodegroup/my-cluster/ng/abc"},
+		{name: "other service", input: "arn:aws:iam::123456789012:role/my-role"},
+		{name: "missing name", input: "arn:aws:eks:us-east-1:123456789012:cluster/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name, region, ok := parseClusterARN(tt.input)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.wantName, name)
+			assert.Equal(t, tt.wantRegion, region)
+		})
+	}
+}
+
+func TestValidateRegion(t *testing.T) {
+	tests := []struct {
+		region  string
+		wantErr bool
+	}{
+		{region: ""},
+		{region: "us-east-1"},
+		{region: "ap-southeast-4"},
+		{region: "us-gov-west-1"},
+		{region: "cn-north-1"},
+		{region: "us-isob-east-1"},
+		{region: "us-east1", wantErr: true},
+		{region: "us-east-1a", wantErr: true},
+		{region: "US-EAST-1", wantErr: true},
+		{region: "useast", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.region, func(t *testing.T) {
+			err := validateRegion(tt.region)
+			if !tt.wantErr {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, errors.ErrInvalidArgument))
+			var appErr *errors.Error
+			require.True(t, errors.As(err, &appErr))
+			assert.Equal(t, tt.region, appErr.Fields["region"])
+			assert.Contains(t, appErr.Detail, "us-east-1")
+		})
+	}
+}
+
+// Invalid input is rejected before any credential is loaded
+func TestProvider_ValidatesBeforeLoadingCredentials(t *testing.T) {
+	loader := testutil.NewMockCredLoader().
+		WithAWSError(errors.New(errors.ErrCredentialNotFound, "credentials must not be loaded"))
+	p, err := NewProvider(&Config{Region: "us-east1"}, logger.Nop())
+	require.NoError(t, err)
+	p.credLoader = loader
+	p.tokenGenerator.credLoader = loader
+
+	_, err = p.GetToken(context.Background(), provider.GetTokenOptions{ClusterName: "my-cluster"})
+	assert.True(t, errors.Is(err, errors.ErrInvalidArgument), "got %v", err)
+
+	_, err = p.GetClusterInfo(context.Background(), "my.cluster")
+	assert.True(t, errors.Is(err, errors.ErrInvalidArgument), "got %v", err)
+}
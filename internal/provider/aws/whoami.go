@@ -0,0 +1,70 @@
+package aws
+
+import (
+	"context"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+)
+
+// identityRegion is the STS region used when no region is configured. Any region
+// reports the same caller identity.
+const identityRegion = "us-east-1"
+
+// WhoAmI implements provider.IdentityReporter with STS GetCallerIdentity
+func (p *Provider) WhoAmI(ctx context.Context) (*provider.Identity, error) {
+	region := p.config.Region
+	if region == "" {
+		region = identityRegion
+	}
+
+	cfg, err := p.tokenGenerator.loadAWSConfig(ctx, provider.GetTokenOptions{Region: region}, Fallback{})
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := p.tokenGenerator.callerIdentity(ctx, cfg)
+	if err != nil {
+		return nil, errors.Wrap(
+			errors.ErrCredentialValidationFailed,
+			err,
+			"failed to get AWS caller identity",
+		).WithField("provider", "aws")
+	}
+
+	identity := identityFromCallerIdentity(out)
+	p.logger.Debug("AWS caller identity retrieved",
+		logger.String("account", identity.Account),
+		logger.String("type", identity.Type),
+	)
+	return identity, nil
+}
+
+// identityFromCallerIdentity converts a GetCallerIdentity response to an Identity
+func identityFromCallerIdentity(out *sts.GetCallerIdentityOutput) *provider.Identity {
+	arn := aws.ToString(out.Arn)
+	return &provider.Identity{
+		Type:    principalType(arn),
+		Account: aws.ToString(out.Account),
+		ARN:     arn,
+		UserID:  aws.ToString(out.UserId),
+		Source:  "sts:GetCallerIdentity",
+	}
+}
+
+// principalType returns the resource type of a caller ARN: user for
+// arn:aws:iam::123456789012:user/alice, assumed-role for
+// arn:aws:sts::123456789012:assumed-role/role/session and root for the account root
+func principalType(arn string) string {
+	parts := strings.SplitN(arn, ":", 6)
+	if len(parts) != 6 || parts[0] != "arn" {
+		return ""
+	}
+	resourceType, _, _ := strings.Cut(parts[5], "/")
+	return resourceType
+}
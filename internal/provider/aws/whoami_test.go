@@ -0,0 +1,95 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/testutil"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+)
+
+func TestIdentityFromCallerIdentity(t *testing.T) {
+	tests := []struct {
+		name     string
+		arn      string
+		wantType string
+	}{
+		{name: "IAM user", arn: "arn:aws:iam::123456789012:user/alice", wantType: "user"},
+		{name: "IAM user with path", arn: "arn:aws:iam::123456789012:user/team/alice", wantType: "user"},
+		{name: "assumed role", arn: "arn:aws:sts::123456789012:assumed-role/deployer/session", wantType: "assumed-role"},
+		{name: "federated user", arn: "arn:aws:sts::123456789012:federated-user/bob", wantType: "federated-user"},
+		{name: "account root", arn: "arn:aws:iam::123456789012:root", wantType: "root"},
+		{name: "GovCloud partition", arn: "arn:aws-us-gov:iam::123456789012:user/alice", wantType: "user"},
+		{name: "not an ARN", arn: "alice", wantType: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			identity := identityFromCallerIdentity(&sts.GetCallerIdentityOutput{
+				Account: aws.String("123456789012"),
+				Arn:     aws.String(tt.arn),
+				UserId:  aws.String("AIDAEXAMPLE"),
+			})
+
+			assert.Equal(t, tt.wantType, identity.Type)
+			assert.Equal(t, "123456789012", identity.Account)
+			assert.Equal(t, tt.arn, identity.ARN)
+			assert.Equal(t, "AIDAEXAMPLE", identity.UserID)
+			assert.Equal(t, "sts:GetCallerIdentity", identity.Source)
+		})
+	}
+}
+
+func TestProvider_WhoAmI(t *testing.T) {
+	tests := []struct {
+		name       string
+		region     string
+		stsErr     error
+		wantRegion string
+		wantErr    bool
+	}{
+		{name: "configured region", region: "eu-west-1", wantRegion: "eu-west-1"},
+		{name: "default region", wantRegion: identityRegion},
+		{name: "STS failure", region: "eu-west-1", stsErr: fmt.Errorf("access denied"), wantRegion: "eu-west-1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := logger.Nop()
+			config := &Config{Region: tt.region}
+			mockLoader := testutil.NewMockCredLoader().WithAWSCreds(testutil.CreateValidAWSCredentials())
+			generator := NewTokenGenerator(config, mockLoader, log)
+			var gotRegion string
+			generator.callerIdentity = func(ctx context.Context, cfg aws.Config) (*sts.GetCallerIdentityOutput, error) {
+				gotRegion = cfg.Region
+				if tt.stsErr != nil {
+					return nil, tt.stsErr
+				}
+				return &sts.GetCallerIdentityOutput{
+					Account: aws.String("123456789012"),
+					Arn:     aws.String("arn:aws:iam::123456789012:user/alice"),
+					UserId:  aws.String("AIDAEXAMPLE"),
+				}, nil
+			}
+			awsProvider := &Provider{config: config, logger: log, tokenGenerator: generator, credLoader: mockLoader}
+
+			identity, err := awsProvider.WhoAmI(context.Background())
+			assert.Equal(t, tt.wantRegion, gotRegion)
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.True(t, errors.Is(err, errors.ErrCredentialValidationFailed))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "user", identity.Type)
+			assert.Equal(t, "123456789012", identity.Account)
+		})
+	}
+}
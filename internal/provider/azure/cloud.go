@@ -0,0 +1,85 @@
+package azure
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+)
+
+const (
+	// CloudPublic is the Azure public cloud, used when no cloud is configured
+	CloudPublic = "public"
+
+	// CloudUSGovernment is Azure Government
+	CloudUSGovernment = "usgovernment"
+
+	// CloudChina is Azure China, operated by 21Vianet
+	CloudChina = "china"
+)
+
+// cloudEnvironment is the authority host and Resource Manager endpoint of an Azure cloud
+type cloudEnvironment struct {
+	// configuration selects the Entra ID authority host for azidentity and the ARM clients
+	configuration cloud.Configuration
+
+	// resourceManager is the ARM endpoint; its .default scope is the default token scope
+	resourceManager string
+}
+
+var cloudEnvironments = map[string]cloudEnvironment{
+	CloudPublic: {
+		configuration:   cloud.AzurePublic,
+		resourceManager: "https://management.azure.com",
+	},
+	CloudUSGovernment: {
+		configuration:   cloud.AzureGovernment,
+		resourceManager: "https://management.usgovcloudapi.net",
+	},
+	CloudChina: {
+		configuration:   cloud.AzureChina,
+		resourceManager: "https://management.chinacloudapi.cn",
+	},
+}
+
+// lookupCloud returns the environment of a cloud name; an empty name selects the public cloud
+func lookupCloud(name string) (cloudEnvironment, error) {
+	if name == "" {
+		name = CloudPublic
+	}
+	env, ok := cloudEnvironments[strings.ToLower(name)]
+	if !ok {
+		return cloudEnvironment{}, errors.New(
+			errors.ErrConfigInvalid,
+			fmt.Sprintf("unknown Azure cloud %q", name),
+		).WithFields(map[string]interface{}{
+			"provider": "azure",
+			"cloud":    name,
+		}).WithDetail("expected one of: " + strings.Join(Clouds(), ", "))
+	}
+	return env, nil
+}
+
+// ValidateCloud returns ErrConfigInvalid when name is not a supported cloud
+func ValidateCloud(name string) error {
+	_, err := lookupCloud(name)
+	return err
+}
+
+// Clouds returns the supported Azure cloud names, sorted
+func Clouds() []string {
+	names := make([]string, 0, len(cloudEnvironments))
+	for name := range cloudEnvironments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// resourceManagerScope is the token scope of the cloud's Resource Manager
+func (e cloudEnvironment) resourceManagerScope() string {
+	return e.resourceManager + "/.default"
+}
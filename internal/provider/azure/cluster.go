@@ -3,10 +3,14 @@ package azure
 import (
 	"context"
 	"fmt"
+	"net/http"
 
-	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerservice/armcontainerservice/v4"
 
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/credentials"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
 	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
 )
 
@@ -15,6 +19,11 @@ type ClusterInfo struct {
 	// Endpoint is the cluster API server endpoint (with https://)
 	Endpoint string
 
+	// PublicEndpoint and PrivateEndpoint are the endpoints of the public FQDN and of
+	// the private FQDN of a private cluster, each empty when the cluster does not have it
+	PublicEndpoint  string
+	PrivateEndpoint string
+
 	// CertificateAuthority is the base64-encoded cluster CA certificate
 	CertificateAuthority string
 
@@ -30,6 +39,25 @@ type ClusterInfo struct {
 
 // GetClusterInfo retrieves cluster information from AKS
 func (p *Provider) GetClusterInfo(ctx context.Context, clusterName, resourceGroup string) (*ClusterInfo, error) {
+	ctx, span := provider.StartSpan(ctx, p.config.Tracing, provider.ProviderAzure, "GetClusterInfo", clusterName, "")
+	info, err := p.getClusterInfo(ctx, clusterName, resourceGroup)
+	provider.EndSpan(ctx, span, err)
+	return info, err
+}
+
+// getClusterInfo is GetClusterInfo without tracing
+func (p *Provider) getClusterInfo(ctx context.Context, clusterName, resourceGroup string) (*ClusterInfo, error) {
+	if err := validateClusterName(clusterName); err != nil {
+		return nil, err
+	}
+	if err := validateResourceGroup(resourceGroup); err != nil {
+		return nil, err
+	}
+	env, err := lookupCloud(p.config.Cloud)
+	if err != nil {
+		return nil, err
+	}
+
 	p.logger.Info("Getting AKS cluster info",
 		logger.String("cluster", clusterName),
 		logger.String("resource_group", resourceGroup),
@@ -46,37 +74,23 @@ func (p *Provider) GetClusterInfo(ctx context.Context, clusterName, resourceGrou
 		return nil, fmt.Errorf("failed to load Azure credentials: %w", err)
 	}
 
-	credential, err := azidentity.NewClientSecretCredential(
-		creds.TenantID,
-		creds.ClientID,
-		creds.ClientSecret,
-		nil,
-	)
+	managedClustersClient, err := p.managedClustersClient(creds, env)
 	if err != nil {
-		p.logger.Error("Failed to create Azure credential",
+		p.logger.Error("Failed to create AKS client",
 			logger.String("cluster", clusterName),
 			logger.Error(err),
 		)
-		return nil, fmt.Errorf("failed to create Azure credential: %w", err)
+		return nil, err
 	}
 
-	clientFactory, err := armcontainerservice.NewClientFactory(p.config.SubscriptionID, credential, nil)
-	if err != nil {
-		p.logger.Error("Failed to create AKS client factory",
-			logger.String("cluster", clusterName),
-			logger.Error(err),
-		)
-		return nil, fmt.Errorf("failed to create AKS client factory: %w", err)
-	}
-
-	managedClustersClient := clientFactory.NewManagedClustersClient()
-
 	p.logger.Debug("Fetching cluster details",
 		logger.String("cluster", clusterName),
 		logger.String("resource_group", resourceGroup),
 	)
 
-	cluster, err := managedClustersClient.Get(ctx, resourceGroup, clusterName, nil)
+	header := http.Header{}
+	p.config.Tracing.InjectBaggage(ctx, header)
+	cluster, err := managedClustersClient.Get(policy.WithHTTPHeader(ctx, header), resourceGroup, clusterName, nil)
 	if err != nil {
 		p.logger.Error("Failed to get cluster",
 			logger.String("cluster", clusterName),
@@ -89,7 +103,8 @@ func (p *Provider) GetClusterInfo(ctx context.Context, clusterName, resourceGrou
 	if cluster.Properties == nil {
 		return nil, fmt.Errorf("cluster properties are nil")
 	}
-	if cluster.Properties.Fqdn == nil || *cluster.Properties.Fqdn == "" {
+	publicEndpoint, privateEndpoint := clusterEndpoints(cluster.Properties)
+	if publicEndpoint == "" && privateEndpoint == "" {
 		return nil, fmt.Errorf("cluster FQDN is empty")
 	}
 
@@ -116,19 +131,31 @@ func (p *Provider) GetClusterInfo(ctx context.Context, clusterName, resourceGrou
 		)
 		return nil, fmt.Errorf("failed to extract CA certificate: %w", err)
 	}
+	if err := provider.ValidateCertificateAuthority(caCert); err != nil {
+		p.logger.Error("Cluster returned an invalid CA certificate",
+			logger.String("cluster", clusterName),
+			logger.Error(err),
+		)
+		return nil, err
+	}
 
-	// Build endpoint URL
-	endpoint := "https://" + *cluster.Properties.Fqdn
+	// Private clusters without a public FQDN are only reachable through the private one
+	endpoint := publicEndpoint
+	if endpoint == "" {
+		endpoint = privateEndpoint
+	}
 
 	info := &ClusterInfo{
 		Endpoint:             endpoint,
+		PublicEndpoint:       publicEndpoint,
+		PrivateEndpoint:      privateEndpoint,
 		CertificateAuthority: caCert,
 		Version:              getStringValue(cluster.Properties.KubernetesVersion),
 		Location:             getStringValue(cluster.Location),
 		ResourceID:           getStringValue(cluster.ID),
 	}
 
-	p.logger.Info("Successfully retrieved cluster info",
+	provider.LoggerWithBaggage(ctx, p.config.Tracing, p.logger).Info("Successfully retrieved cluster info",
 		logger.String("cluster", clusterName),
 		logger.String("endpoint", endpoint),
 		logger.String("version", getStringValue(cluster.Properties.KubernetesVersion)),
@@ -138,6 +165,52 @@ func (p *Provider) GetClusterInfo(ctx context.Context, clusterName, resourceGrou
 	return info, nil
 }
 
+// clusterEndpoints returns the https endpoints of the public and private FQDNs of a
+// cluster, each empty when the cluster does not have it
+func clusterEndpoints(properties *armcontainerservice.ManagedClusterProperties) (public, private string) {
+	if fqdn := getStringValue(properties.Fqdn); fqdn != "" {
+		public = "https://" + fqdn
+	}
+	if fqdn := getStringValue(properties.PrivateFQDN); fqdn != "" {
+		private = "https://" + fqdn
+	}
+	return public, private
+}
+
+// clusterClient is the AKS client built for one set of service principal credentials
+type clusterClient struct {
+	creds  credentials.AzureCredentials
+	client *armcontainerservice.ManagedClustersClient
+}
+
+// managedClustersClient returns the AKS client for creds, creating it on first use.
+// The client and its credential are kept on the provider, so looking up many clusters
+// with the same service principal reuses one Entra ID token; rotated credentials get
+// a new client.
+func (p *Provider) managedClustersClient(creds *credentials.AzureCredentials, env cloudEnvironment) (*armcontainerservice.ManagedClustersClient, error) {
+	p.clientMu.Lock()
+	defer p.clientMu.Unlock()
+
+	if p.clusterClient != nil && p.clusterClient.creds == *creds {
+		return p.clusterClient.client, nil
+	}
+
+	credential, _, err := newServicePrincipalCredential(creds, p.config.PreferSecret, p.config.clientOptions(env))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Azure credential: %w", err)
+	}
+
+	clientFactory, err := armcontainerservice.NewClientFactory(p.config.SubscriptionID, credential, &arm.ClientOptions{
+		ClientOptions: p.config.clientOptions(env),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AKS client factory: %w", err)
+	}
+
+	p.clusterClient = &clusterClient{creds: *creds, client: clientFactory.NewManagedClustersClient()}
+	return p.clusterClient.client, nil
+}
+
 // extractCACertFromKubeconfig extracts the CA certificate from raw kubeconfig data
 func extractCACertFromKubeconfig(kubeconfigData []byte) (string, error) {
 	// Parse kubeconfig YAML to extract certificate-authority-data
@@ -0,0 +1,181 @@
+package azure
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/pem"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerservice/armcontainerservice/v4"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/credentials"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/testutil"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+)
+
+func TestProvider_ManagedClustersClientReuse(t *testing.T) {
+	p, err := NewProvider(&Config{SubscriptionID: "00000000-0000-0000-0000-000000000000"}, logger.Nop())
+	require.NoError(t, err)
+	env, err := lookupCloud("")
+	require.NoError(t, err)
+
+	creds := &credentials.AzureCredentials{ClientID: "client", ClientSecret: "secret", TenantID: "tenant"}
+	first, err := p.managedClustersClient(creds, env)
+	require.NoError(t, err)
+
+	same := *creds
+	second, err := p.managedClustersClient(&same, env)
+	require.NoError(t, err)
+	assert.Same(t, first, second, "the same service principal shares one client and token")
+
+	rotated := *creds
+	rotated.ClientSecret = "rotated-secret"
+	third, err := p.managedClustersClient(&rotated, env)
+	require.NoError(t, err)
+	assert.NotSame(t, first, third, "rotated credentials get a new client")
+}
+
+func TestClusterEndpoints(t *testing.T) {
+	tests := []struct {
+		name        string
+		properties  armcontainerservice.ManagedClusterProperties
+		wantPublic  string
+		wantPrivate string
+	}{
+		{
+			name:       "public cluster",
+			properties: armcontainerservice.ManagedClusterProperties{Fqdn: to.Ptr("my-cluster.hcp.eastus.azmk8s.io")},
+			wantPublic: "https://my-cluster.hcp.eastus.azmk8s.io",
+		},
+		{
+			name: "private cluster with a public FQDN",
+			properties: armcontainerservice.ManagedClusterProperties{
+				Fqdn:        to.Ptr("my-cluster.hcp.eastus.azmk8s.io"),
+				PrivateFQDN: to.Ptr("my-cluster.privatelink.eastus.azmk8s.io"),
+			},
+			wantPublic:  "https://my-cluster.hcp.eastus.azmk8s.io",
+			wantPrivate: "https://my-cluster.privatelink.eastus.azmk8s.io",
+		},
+		{
+			name:        "private cluster without a public FQDN",
+			properties:  armcontainerservice.ManagedClusterProperties{PrivateFQDN: to.Ptr("my-cluster.privatelink.eastus.azmk8s.io")},
+			wantPrivate: "https://my-cluster.privatelink.eastus.azmk8s.io",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			public, private := clusterEndpoints(&tt.properties)
+			assert.Equal(t, tt.wantPublic, public)
+			assert.Equal(t, tt.wantPrivate, private)
+		})
+	}
+}
+
+// fakeAKS answers the Entra ID and Resource Manager requests of a cluster lookup with
+// a cluster of the given properties
+func fakeAKS(t *testing.T, properties string) http.Handler {
+	authority := "https://login.microsoftonline.com/" + testutil.CreateValidAzureCredentials().TenantID
+	certificate, _ := pem.Decode(testutil.SelfSignedCertificatePEM(t, time.Now().Add(24*time.Hour)))
+	ca := base64.StdEncoding.EncodeToString(pem.EncodeToMemory(certificate))
+	kubeconfig := "apiVersion: v1\nclusters:\n- cluster:\n    certificate-authority-data: " + ca + "\n"
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/discovery/instance"):
+			_, _ = w.Write([]byte(`{"tenant_discovery_endpoint":"` + authority + `/v2.0/.well-known/openid-configuration",` +
+				`"metadata":[{"preferred_network":"login.microsoftonline.com","preferred_cache":"login.windows.net","aliases":["login.microsoftonline.com"]}]}`))
+		case strings.HasSuffix(r.URL.Path, "/.well-known/openid-configuration"):
+			_, _ = w.Write([]byte(`{"token_endpoint":"` + authority + `/oauth2/v2.0/token",` +
+				`"authorization_endpoint":"` + authority + `/oauth2/v2.0/authorize",` +
+				`"issuer":"` + authority + `/v2.0"}`))
+		case strings.HasSuffix(r.URL.Path, "/oauth2/v2.0/token"):
+			_, _ = w.Write([]byte(`{"access_token":"arm-access-token","token_type":"Bearer","expires_in":3600}`))
+		case strings.HasSuffix(r.URL.Path, "/managedClusters/my-cluster"):
+			_, _ = w.Write([]byte(`{"id":"/subscriptions/sub/resourceGroups/my-rg/providers/Microsoft.ContainerService/managedClusters/my-cluster",` +
+				`"location":"eastus","properties":` + properties + `}`))
+		case strings.HasSuffix(r.URL.Path, "/managedClusters/my-cluster/listClusterAdminCredential"):
+			_, _ = w.Write([]byte(`{"kubeconfigs":[{"name":"clusterAdmin","value":"` +
+				base64.StdEncoding.EncodeToString([]byte(kubeconfig)) + `"}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+}
+
+func TestProvider_DescribeCluster_EndpointAccess(t *testing.T) {
+	const (
+		public  = "my-cluster.hcp.eastus.azmk8s.io"
+		private = "my-cluster.privatelink.eastus.azmk8s.io"
+	)
+	tests := []struct {
+		name       string
+		properties string
+		access     string
+		want       string
+		wantErr    string
+	}{
+		{
+			name:       "private cluster defaults to the public FQDN",
+			properties: `{"fqdn":"` + public + `","privateFQDN":"` + private + `"}`,
+			want:       "https://" + public,
+		},
+		{
+			name:       "private cluster with private access",
+			properties: `{"fqdn":"` + public + `","privateFQDN":"` + private + `"}`,
+			access:     "private",
+			want:       "https://" + private,
+		},
+		{
+			name:       "private cluster without a public FQDN defaults to the private one",
+			properties: `{"privateFQDN":"` + private + `"}`,
+			want:       "https://" + private,
+		},
+		{
+			name:       "private access to a public cluster",
+			properties: `{"fqdn":"` + public + `"}`,
+			access:     "private",
+			wantErr:    "cluster has no private endpoint",
+		},
+		{
+			name:       "public access to a private cluster without a public FQDN",
+			properties: `{"privateFQDN":"` + private + `"}`,
+			access:     "public",
+			wantErr:    "cluster has no public endpoint",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, _ := testutil.NewRecordingClient(fakeAKS(t, tt.properties))
+			p, err := NewProvider(&Config{SubscriptionID: "00000000-0000-0000-0000-000000000000", HTTPClient: client}, logger.Nop())
+			require.NoError(t, err)
+			p.credLoader = testutil.NewMockCredLoader().WithAzureCreds(testutil.CreateValidAzureCredentials())
+
+			info, err := p.DescribeCluster(context.Background(), provider.ClusterInfoOptions{
+				ClusterName:    "my-cluster",
+				ResourceGroup:  "my-rg",
+				EndpointAccess: tt.access,
+			})
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.True(t, errors.Is(err, errors.ErrClusterInvalidConfig))
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, info.Endpoint)
+			assert.Equal(t, "eastus", info.Location)
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package azure
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+)
+
+// TokenInfo is a human-readable breakdown of an Azure AD access token
+type TokenInfo struct {
+	Algorithm string     `json:"algorithm,omitempty"`
+	KeyID     string     `json:"keyId,omitempty"`
+	Issuer    string     `json:"issuer"`
+	Audience  string     `json:"audience"`
+	AppID     string     `json:"appId,omitempty"`
+	TenantID  string     `json:"tenantId,omitempty"`
+	ObjectID  string     `json:"objectId,omitempty"`
+	Subject   string     `json:"subject,omitempty"`
+	IssuedAt  *time.Time `json:"issuedAt,omitempty"`
+	NotBefore *time.Time `json:"notBefore,omitempty"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	Signature string     `json:"signature"`
+}
+
+// InspectToken decodes an Azure AD access token without verifying it.
+// The signature is only included when unsafe is set.
+func InspectToken(token string, unsafe bool) (*TokenInfo, error) {
+	if !provider.IsJWT(token) {
+		return nil, fmt.Errorf("token is not a JWT")
+	}
+
+	jwt, err := provider.DecodeJWT(token)
+	if err != nil {
+		return nil, err
+	}
+
+	// v1 tokens carry the client in appid, v2 tokens in azp
+	appID := jwt.StringClaim("appid")
+	if appID == "" {
+		appID = jwt.StringClaim("azp")
+	}
+
+	return &TokenInfo{
+		Algorithm: jwt.HeaderString("alg"),
+		KeyID:     jwt.HeaderString("kid"),
+		Issuer:    jwt.StringClaim("iss"),
+		Audience:  jwt.StringClaim("aud"),
+		AppID:     appID,
+		TenantID:  jwt.StringClaim("tid"),
+		ObjectID:  jwt.StringClaim("oid"),
+		Subject:   jwt.StringClaim("sub"),
+		IssuedAt:  jwt.TimeClaim("iat"),
+		NotBefore: jwt.TimeClaim("nbf"),
+		ExpiresAt: jwt.TimeClaim("exp"),
+		Signature: provider.RedactUnlessUnsafe(jwt.Signature, unsafe),
+	}, nil
+}
@@ -0,0 +1,39 @@
+package azure
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+)
+
+func TestInspectToken(t *testing.T) {
+	claims := `{"aud":"6dae42f8-4368-4678-94ff-3960e28e3630","iss":"https://sts.windows.net/87654321-4321-4321-4321-210987654321/",` +
+		`"appid":"11111111-2222-3333-4444-555555555555","tid":"87654321-4321-4321-4321-210987654321","oid":"oid-1",` +
+		`"iat":1709290800,"nbf":1709290800,"exp":1709294400}`
+	token := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256","kid":"kid-1","typ":"JWT"}`)) + "." +
+		base64.RawURLEncoding.EncodeToString([]byte(claims)) + ".c2lnbmF0dXJl"
+
+	info, err := InspectToken(token, false)
+	require.NoError(t, err)
+
+	assert.Equal(t, "6dae42f8-4368-4678-94ff-3960e28e3630", info.Audience)
+	assert.Equal(t, "https://sts.windows.net/87654321-4321-4321-4321-210987654321/", info.Issuer)
+	assert.Equal(t, "11111111-2222-3333-4444-555555555555", info.AppID)
+	assert.Equal(t, "87654321-4321-4321-4321-210987654321", info.TenantID)
+	assert.Equal(t, "oid-1", info.ObjectID)
+	require.NotNil(t, info.ExpiresAt)
+	assert.Equal(t, time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC), *info.ExpiresAt)
+	assert.Equal(t, provider.Redacted, info.Signature)
+
+	info, err = InspectToken(token, true)
+	require.NoError(t, err)
+	assert.Equal(t, "c2lnbmF0dXJl", info.Signature)
+
+	_, err = InspectToken("not-a-jwt", false)
+	assert.Error(t, err)
+}
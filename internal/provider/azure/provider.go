@@ -2,6 +2,7 @@ package azure
 
 import (
 	"context"
+	"sync"
 
 	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/credentials"
 	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
@@ -16,6 +17,10 @@ type Provider struct {
 	tokenGenerator *TokenGenerator
 	credLoader     credentials.Loader
 	azureCredOpts  credentials.AzureCredentialOptions
+
+	// clusterClient is shared by cluster lookups; see managedClustersClient
+	clientMu      sync.Mutex
+	clusterClient *clusterClient
 }
 
 // NewProvider creates a new Azure provider
@@ -23,8 +28,15 @@ func NewProvider(config *Config, log logger.Logger) (*Provider, error) {
 	if config == nil {
 		config = DefaultConfig()
 	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
 
-	credLoader := credentials.NewLoader(log)
+	credLoader, err := provider.NewCredentialLoader(log, config.StrictPermissions, config.WatchCredentials, config.Metrics, config.HTTPClient,
+		credentials.WithExpectedSHA256(config.CredentialsSHA256))
+	if err != nil {
+		return nil, err
+	}
 
 	tokenGenerator := NewTokenGenerator(config, credLoader, log)
 
@@ -45,12 +57,26 @@ func NewProvider(config *Config, log logger.Logger) (*Provider, error) {
 
 // GetToken generates an AKS authentication token
 func (p *Provider) GetToken(ctx context.Context, opts provider.GetTokenOptions) (*provider.Token, error) {
+	if err := validateClusterName(opts.ClusterName); err != nil {
+		return nil, err
+	}
+	// The resource group is optional for tokens and only checked when set
+	if opts.ResourceGroup != "" {
+		if err := validateResourceGroup(opts.ResourceGroup); err != nil {
+			return nil, err
+		}
+	}
+
 	p.logger.Info("Getting Azure token",
 		logger.String("cluster", opts.ClusterName),
 		logger.String("subscription", opts.SubscriptionID),
-		logger.String("tenant", opts.TenantID),
+		logger.ID("tenant", opts.TenantID),
 	)
 
+	name := provider.ProviderAzure.String()
+	p.config.Metrics.IncInflight(name)
+	defer p.config.Metrics.DecInflight(name)
+
 	// Generate token using token generator
 	token, err := p.tokenGenerator.GenerateToken(ctx, opts)
 	if err != nil {
@@ -67,6 +93,9 @@ func (p *Provider) GetToken(ctx context.Context, opts provider.GetTokenOptions)
 		return nil, err
 	}
 
+	now := p.tokenGenerator.clock.Now()
+	p.config.Metrics.SetTokenExpiry(name, opts.ClusterName, token.ExpiresAt.Sub(now))
+	p.config.Metrics.SetLastSuccess(name, opts.ClusterName, now)
 	return token, nil
 }
 
@@ -94,7 +123,23 @@ func (p *Provider) ValidateCredentials(ctx context.Context) error {
 	return nil
 }
 
+// ValidateToken checks a token with the token generator
+func (p *Provider) ValidateToken(token *provider.Token) error {
+	return p.tokenGenerator.ValidateToken(token)
+}
+
+// RefreshToken returns currentToken while the token generator accepts it and it is
+// outside the refresh threshold, and otherwise generates a new token with GetToken
+func (p *Provider) RefreshToken(ctx context.Context, opts provider.GetTokenOptions, currentToken *provider.Token) (*provider.Token, error) {
+	return provider.RefreshIfNeeded(ctx, p, opts, currentToken, p.tokenGenerator.clock, p.tokenGenerator.refreshThreshold())
+}
+
 // Name returns the provider name
 func (p *Provider) Name() string {
 	return "azure"
 }
+
+// Close stops watching the credentials file of Config.WatchCredentials
+func (p *Provider) Close() error {
+	return provider.CloseCredentialLoader(p.credLoader)
+}
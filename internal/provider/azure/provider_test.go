@@ -2,14 +2,20 @@ package azure
 
 import (
 	"context"
+	"encoding/base64"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/credentials"
 	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/testutil"
 	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
 	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
 )
@@ -241,3 +247,102 @@ func TestProvider_Integration(t *testing.T) {
 	assert.False(t, token.IsExpired())
 	assert.True(t, token.ExpiresAt.After(time.Now()))
 }
+
+// staticCredential is an azcore.TokenCredential that returns a fixed token
+type staticCredential struct {
+	token string
+}
+
+func (c staticCredential) GetToken(ctx context.Context, opts policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	return azcore.AccessToken{Token: c.token, ExpiresOn: time.Now().Add(time.Hour)}, nil
+}
+
+// TestTokenGenerator_ClockSkew checks that an Entra ID token already expired at the
+// time of a skewed clock fails with a clock skew error
+func TestTokenGenerator_ClockSkew(t *testing.T) {
+	log, output := testutil.NewDebugLogger(t)
+	creds := testutil.CreateValidAzureCredentials()
+	mockLoader := testutil.NewMockCredLoader().WithAzureCreds(creds)
+	generator := NewTokenGenerator(&Config{TenantID: creds.TenantID}, mockLoader, log)
+	generator.newCredential = func(c *credentials.AzureCredentials, env cloudEnvironment) (azcore.TokenCredential, error) {
+		return staticCredential{token: "access-token"}, nil
+	}
+	generator.clock = testutil.NewMockTime(time.Now().Add(2 * time.Hour))
+
+	_, err := generator.GenerateToken(context.Background(), provider.GetTokenOptions{ClusterName: "my-cluster", TenantID: creds.TenantID})
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, errors.ErrTokenInvalid), "got %v", err)
+	assert.Contains(t, err.Error(), "already expired")
+	assert.Contains(t, err.Error(), "possible clock skew")
+	assert.Contains(t, output.String(), "Possible clock skew")
+}
+
+func TestProvider_DebugLogsRedacted(t *testing.T) {
+	creds := testutil.CreateValidAzureCredentials()
+	accessToken := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256"}`)) + "." +
+		base64.RawURLEncoding.EncodeToString([]byte(`{"appid":"`+creds.ClientID+`","tid":"`+creds.TenantID+`"}`)) + ".c2lnbmF0dXJl"
+
+	log, output := testutil.NewDebugLogger(t)
+	config := &Config{TenantID: creds.TenantID, SubscriptionID: "33333333-3333-3333-3333-333333333333"}
+	mockLoader := testutil.NewMockCredLoader().WithAzureCreds(creds)
+	generator := NewTokenGenerator(config, mockLoader, log)
+	generator.newCredential = func(c *credentials.AzureCredentials, env cloudEnvironment) (azcore.TokenCredential, error) {
+		return staticCredential{token: accessToken}, nil
+	}
+	azureProvider := &Provider{config: config, logger: log, tokenGenerator: generator, credLoader: mockLoader}
+
+	token, err := azureProvider.GetToken(context.Background(), provider.GetTokenOptions{
+		ClusterName:    "my-cluster",
+		TenantID:       creds.TenantID,
+		SubscriptionID: config.SubscriptionID,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, accessToken, token.AccessToken)
+	assert.Equal(t, "azure", token.Provider)
+	assert.Equal(t, "my-cluster", token.ClusterName)
+	assert.Equal(t, "***"+creds.ClientID[len(creds.ClientID)-4:], token.Identity)
+	assert.Equal(t, []string{"https://management.azure.com/.default"}, token.Scopes)
+
+	identity, err := azureProvider.WhoAmI(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, creds.ClientID, identity.AppID)
+
+	testutil.AssertNotLogged(t, output.String(),
+		creds.TenantID,
+		creds.ClientID,
+		creds.ClientSecret,
+		accessToken,
+	)
+	assert.Contains(t, output.String(), "***2222", "the last 4 characters of the tenant ID are logged")
+}
+
+// TestProvider_WatchCredentials checks that tokens generated after the credentials
+// file is rotated use the new client
+func TestProvider_WatchCredentials(t *testing.T) {
+	const tenantID = "44444444-4444-4444-4444-444444444444"
+	path := filepath.Join(t.TempDir(), "azure.json")
+	writeCredentials := func(clientID string) {
+		content := `{"client_id": "` + clientID + `", "client_secret": "secret", "tenant_id": "` + tenantID + `"}`
+		require.NoError(t, os.WriteFile(path+".tmp", []byte(content), 0600))
+		require.NoError(t, os.Rename(path+".tmp", path))
+	}
+	writeCredentials("11111111-1111-1111-1111-111111111111")
+	t.Setenv("AZURE_CREDENTIALS_FILE", path)
+
+	azureProvider, err := NewProvider(&Config{TenantID: tenantID, WatchCredentials: true}, logger.Nop())
+	require.NoError(t, err)
+	azureProvider.tokenGenerator.newCredential = func(c *credentials.AzureCredentials, env cloudEnvironment) (azcore.TokenCredential, error) {
+		return staticCredential{token: "token-of-" + c.ClientID}, nil
+	}
+	opts := provider.GetTokenOptions{ClusterName: "my-cluster", TenantID: tenantID}
+
+	token, err := azureProvider.GetToken(context.Background(), opts)
+	require.NoError(t, err)
+	assert.Equal(t, "token-of-11111111-1111-1111-1111-111111111111", token.AccessToken)
+
+	writeCredentials("22222222-2222-2222-2222-222222222222")
+	assert.Eventually(t, func() bool {
+		token, err := azureProvider.GetToken(context.Background(), opts)
+		return err == nil && token.AccessToken == "token-of-22222222-2222-2222-2222-222222222222"
+	}, 5*time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,69 @@
+package azure
+
+import (
+	"context"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+)
+
+func init() {
+	provider.MustRegisterProvider(provider.ProviderAzure, provider.Registration{
+		Constructor:  newFromConfig,
+		Capabilities: provider.Capabilities{BoundAudience: true, EndpointAccess: true, LookupAnyRegion: true},
+		Defaults: provider.Defaults{
+			TokenDuration:    defaultTokenDuration,
+			RefreshThreshold: defaultRefreshThreshold,
+			MaxDuration:      maxTokenDuration,
+		},
+		ValidateOptions: validateOptions,
+		Inputs: []provider.Input{
+			{Name: "subscription-id", RequiredFor: provider.OperationClusterLookup | provider.OperationKubeconfig},
+			{Name: "tenant-id", RequiredFor: provider.OperationClusterLookup | provider.OperationKubeconfig},
+			{Name: "resource-group", RequiredFor: provider.OperationClusterLookup | provider.OperationKubeconfig},
+			{Name: "azure-cloud", Validate: ValidateCloud},
+		},
+	})
+}
+
+// newFromConfig creates an Azure provider from the shared provider configuration
+func newFromConfig(cfg *provider.Config, log logger.Logger) (provider.Provider, error) {
+	config := DefaultConfig()
+	config.TenantID = cfg.TenantID
+	config.SubscriptionID = cfg.SubscriptionID
+	config.CredentialsFile = cfg.CredentialsFile
+	config.Cloud = cfg.AzureCloud
+	config.StrictPermissions = cfg.StrictPermissions
+	config.CredentialsSHA256 = cfg.CredentialsSHA256
+	config.PreferSecret = cfg.AzurePreferSecret
+	config.Tracing = cfg.Tracing
+	config.HTTPClient = cfg.HTTPClient
+	config.WatchCredentials = cfg.WatchCredentials
+	config.Metrics = cfg.Metrics
+	if cfg.TokenDuration > 0 {
+		config.TokenDuration = cfg.TokenDuration
+	}
+
+	return NewProvider(config, log)
+}
+
+// DescribeCluster implements provider.ClusterDescriber
+func (p *Provider) DescribeCluster(ctx context.Context, opts provider.ClusterInfoOptions) (*provider.ClusterInfo, error) {
+	info, err := p.GetClusterInfo(ctx, opts.ClusterName, opts.ResourceGroup)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint, err := provider.ClusterEndpoint(opts.EndpointAccess, info.Endpoint, info.PublicEndpoint, info.PrivateEndpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	return &provider.ClusterInfo{
+		Endpoint:             endpoint,
+		CertificateAuthority: info.CertificateAuthority,
+		Version:              info.Version,
+		Location:             info.Location,
+		ResourceID:           info.ResourceID,
+	}, nil
+}
@@ -0,0 +1,62 @@
+package azure
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+)
+
+// subscriptionIDPattern is the GUID form of Azure subscription IDs
+var subscriptionIDPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// ClusterResourceID is an AKS cluster addressed by its ARM resource ID
+type ClusterResourceID struct {
+	SubscriptionID string
+	ResourceGroup  string
+	ClusterName    string
+}
+
+// ParseClusterResourceID parses and validates an AKS cluster resource ID of the form
+// /subscriptions/SUB/resourceGroups/RG/providers/Microsoft.ContainerService/managedClusters/NAME.
+// The segment names are matched case-insensitively, as ARM does.
+func ParseClusterResourceID(id string) (*ClusterResourceID, error) {
+	invalid := func(detail string) error {
+		return errors.New(errors.ErrInvalidArgument, "invalid AKS cluster resource ID").
+			WithFields(map[string]interface{}{
+				"provider":    "azure",
+				"resource_id": id,
+			}).WithDetail(detail)
+	}
+
+	trimmed := strings.TrimSpace(id)
+	if trimmed == "" {
+		return nil, invalid("the resource ID is empty")
+	}
+
+	segments := strings.Split(strings.Trim(trimmed, "/"), "/")
+	if !strings.HasPrefix(trimmed, "/") || len(segments) != 8 {
+		return nil, invalid("expected /subscriptions/SUBSCRIPTION/resourceGroups/GROUP/providers/Microsoft.ContainerService/managedClusters/NAME")
+	}
+	for i, want := range []string{"subscriptions", "", "resourceGroups", "", "providers", "Microsoft.ContainerService", "managedClusters"} {
+		if want != "" && !strings.EqualFold(segments[i], want) {
+			return nil, invalid("expected " + want + " instead of " + segments[i])
+		}
+	}
+
+	parsed := &ClusterResourceID{
+		SubscriptionID: segments[1],
+		ResourceGroup:  segments[3],
+		ClusterName:    segments[7],
+	}
+	if !subscriptionIDPattern.MatchString(parsed.SubscriptionID) {
+		return nil, invalid("the subscription " + parsed.SubscriptionID + " is not a GUID")
+	}
+	if err := validateResourceGroup(parsed.ResourceGroup); err != nil {
+		return nil, err
+	}
+	if err := validateClusterName(parsed.ClusterName); err != nil {
+		return nil, err
+	}
+	return parsed, nil
+}
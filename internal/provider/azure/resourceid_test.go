@@ -0,0 +1,91 @@
+package azure
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+)
+
+func TestParseClusterResourceID(t *testing.T) {
+	const subscription = "00000000-0000-0000-0000-000000000000"
+
+	tests := []struct {
+		name    string
+		id      string
+		want    *ClusterResourceID
+		wantErr string
+	}{
+		{
+			name: "well-formed",
+			id:   "/subscriptions/" + subscription + "/resourceGroups/my-rg/providers/Microsoft.ContainerService/managedClusters/my-cluster",
+			want: &ClusterResourceID{SubscriptionID: subscription, ResourceGroup: "my-rg", ClusterName: "my-cluster"},
+		},
+		{
+			name: "segment names in another case",
+			id:   "/SUBSCRIPTIONS/" + subscription + "/resourcegroups/my-rg/Providers/microsoft.containerservice/MANAGEDCLUSTERS/my-cluster",
+			want: &ClusterResourceID{SubscriptionID: subscription, ResourceGroup: "my-rg", ClusterName: "my-cluster"},
+		},
+		{
+			name: "trailing slash and whitespace",
+			id:   " /subscriptions/" + subscription + "/resourceGroups/my-rg/providers/Microsoft.ContainerService/managedClusters/my-cluster/\n",
+			want: &ClusterResourceID{SubscriptionID: subscription, ResourceGroup: "my-rg", ClusterName: "my-cluster"},
+		},
+		{
+			name:    "empty",
+			id:      "",
+			wantErr: "the resource ID is empty",
+		},
+		{
+			name:    "not absolute",
+			id:      "subscriptions/" + subscription + "/resourceGroups/my-rg/providers/Microsoft.ContainerService/managedClusters/my-cluster",
+			wantErr: "expected /subscriptions/",
+		},
+		{
+			name:    "resource group ID",
+			id:      "/subscriptions/" + subscription + "/resourceGroups/my-rg",
+			wantErr: "expected /subscriptions/",
+		},
+		{
+			name:    "child resource",
+			id:      "/subscriptions/" + subscription + "/resourceGroups/my-rg/providers/Microsoft.ContainerService/managedClusters/my-cluster/agentPools/pool1",
+			wantErr: "expected /subscriptions/",
+		},
+		{
+			name:    "other resource type",
+			id:      "/subscriptions/" + subscription + "/resourceGroups/my-rg/providers/Microsoft.Compute/virtualMachines/my-vm",
+			wantErr: "expected Microsoft.ContainerService instead of Microsoft.Compute",
+		},
+		{
+			name:    "subscription is not a GUID",
+			id:      "/subscriptions/my-subscription/resourceGroups/my-rg/providers/Microsoft.ContainerService/managedClusters/my-cluster",
+			wantErr: "the subscription my-subscription is not a GUID",
+		},
+		{
+			name:    "invalid cluster name",
+			id:      "/subscriptions/" + subscription + "/resourceGroups/my-rg/providers/Microsoft.ContainerService/managedClusters/-cluster",
+			wantErr: "invalid AKS cluster name",
+		},
+		{
+			name:    "invalid resource group",
+			id:      "/subscriptions/" + subscription + "/resourceGroups/my-rg./providers/Microsoft.ContainerService/managedClusters/my-cluster",
+			wantErr: "invalid Azure resource group",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseClusterResourceID(tt.id)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.True(t, errors.Is(err, errors.ErrInvalidArgument))
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
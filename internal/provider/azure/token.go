@@ -2,7 +2,9 @@ package azure
 
 import (
 	"context"
+	stderrors "errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
@@ -16,12 +18,18 @@ import (
 )
 
 const (
-	// aksResourceScope is the Azure resource scope for AKS
-	// This is the standard scope for accessing AKS clusters
-	aksResourceScope = "https://management.azure.com/.default"
-
 	// defaultTokenDuration is the default duration for Azure AD tokens
 	defaultTokenDuration = 1 * time.Hour
+
+	// defaultRefreshThreshold is the remaining lifetime at which a token is refreshed
+	defaultRefreshThreshold = 5 * time.Minute
+
+	// maxTokenDuration is the lifetime Entra ID guarantees for access tokens, which
+	// are issued for 60 to 90 minutes
+	maxTokenDuration = 1 * time.Hour
+
+	// maxIssuedTokenLifetime is the longest lifetime Entra ID issues access tokens for
+	maxIssuedTokenLifetime = 90 * time.Minute
 )
 
 // TokenGenerator handles Azure AD token generation for AKS clusters
@@ -29,25 +37,42 @@ type TokenGenerator struct {
 	config     *Config
 	credLoader credentials.Loader
 	logger     logger.Logger
+
+	// clock decides expiry and refresh; replaced in tests
+	clock provider.Clock
+
+	// newCredential creates the Azure AD credential; replaced in tests
+	newCredential func(creds *credentials.AzureCredentials, env cloudEnvironment) (azcore.TokenCredential, error)
 }
 
-// NewTokenGenerator creates a new Azure token generator
+// NewTokenGenerator creates a new Azure token generator. Its log lines carry provider=azure.
 func NewTokenGenerator(config *Config, credLoader credentials.Loader, log logger.Logger) *TokenGenerator {
-	return &TokenGenerator{
+	g := &TokenGenerator{
 		config:     config,
 		credLoader: credLoader,
-		logger:     log,
+		logger:     log.With(logger.String("provider", "azure")),
+		clock:      provider.SystemClock,
 	}
+	g.newCredential = g.createCredential
+	return g
 }
 
 // GenerateToken generates an Azure AD token for AKS authentication
 func (g *TokenGenerator) GenerateToken(ctx context.Context, opts provider.GetTokenOptions) (*provider.Token, error) {
+	ctx, span := provider.StartSpan(ctx, g.config.Tracing, provider.ProviderAzure, "GenerateToken", opts.ClusterName, opts.Region)
+	token, err := g.generateToken(ctx, opts)
+	provider.EndSpan(ctx, span, err)
+	return token, err
+}
+
+// generateToken is GenerateToken without tracing
+func (g *TokenGenerator) generateToken(ctx context.Context, opts provider.GetTokenOptions) (*provider.Token, error) {
 	startTime := time.Now()
+	log := g.logger.With(logger.String("cluster", opts.ClusterName))
 
-	g.logger.Debug("Starting Azure token generation",
-		logger.String("cluster", opts.ClusterName),
+	log.Debug("Starting Azure token generation",
 		logger.String("subscription_id", opts.SubscriptionID),
-		logger.String("tenant_id", opts.TenantID),
+		logger.ID("tenant_id", opts.TenantID),
 	)
 
 	if opts.ClusterName == "" {
@@ -57,17 +82,23 @@ func (g *TokenGenerator) GenerateToken(ctx context.Context, opts provider.GetTok
 		).WithField("provider", "azure")
 	}
 
+	env, err := lookupCloud(g.config.Cloud)
+	if err != nil {
+		return nil, err
+	}
+
 	azureCreds, err := g.loadAzureCredentials(ctx, opts)
 	if err != nil {
 		return nil, err
 	}
 
-	credential, err := g.createCredential(azureCreds)
+	credential, err := g.newCredential(azureCreds, env)
 	if err != nil {
 		return nil, err
 	}
 
-	accessToken, expiresOn, err := g.getAccessToken(ctx, credential)
+	scope := tokenScope(env, opts.Audience)
+	accessToken, expiresOn, err := g.getAccessToken(ctx, credential, scope)
 	if err != nil {
 		return nil, err
 	}
@@ -76,11 +107,18 @@ func (g *TokenGenerator) GenerateToken(ctx context.Context, opts provider.GetTok
 		AccessToken: accessToken,
 		ExpiresAt:   expiresOn,
 		TokenType:   "Bearer",
+		Provider:    provider.ProviderAzure.String(),
+		ClusterName: opts.ClusterName,
+		Identity:    logger.RedactID(azureCreds.ClientID),
+		Audience:    opts.Audience,
+		Scopes:      []string{scope},
+	}
+	if err := provider.CheckClockSkew(g.clock, log, "azure", token.ExpiresAt, maxIssuedTokenLifetime); err != nil {
+		return nil, err
 	}
 
 	duration := time.Since(startTime)
-	g.logger.Info("Azure token generated successfully",
-		logger.String("cluster", opts.ClusterName),
+	provider.LoggerWithBaggage(ctx, g.config.Tracing, log).Info("Azure token generated successfully",
 		logger.String("subscription_id", opts.SubscriptionID),
 		logger.Duration("duration_ms", duration.Milliseconds()),
 		logger.String("expires_at", token.ExpiresAt.Format(time.RFC3339)),
@@ -114,24 +152,18 @@ func (g *TokenGenerator) loadAzureCredentials(ctx context.Context, opts provider
 	}
 
 	g.logger.Debug("Azure credentials loaded",
-		logger.String("tenant_id", creds.TenantID),
+		logger.ID("tenant_id", creds.TenantID),
 		logger.Bool("has_client_secret", creds.ClientSecret != ""),
+		logger.Bool("has_client_certificate", creds.ClientCertificate != ""),
 	)
 
 	return creds, nil
 }
 
-// createCredential creates an Azure credential from service principal credentials
-func (g *TokenGenerator) createCredential(creds *credentials.AzureCredentials) (azcore.TokenCredential, error) {
-	credential, err := azidentity.NewClientSecretCredential(
-		creds.TenantID,
-		creds.ClientID,
-		creds.ClientSecret,
-		&azidentity.ClientSecretCredentialOptions{
-			ClientOptions: policy.ClientOptions{
-			},
-		},
-	)
+// createCredential creates an Azure credential from service principal credentials that
+// authenticates against the authority host of the cloud
+func (g *TokenGenerator) createCredential(creds *credentials.AzureCredentials, env cloudEnvironment) (azcore.TokenCredential, error) {
+	credential, credentialType, err := newServicePrincipalCredential(creds, g.config.PreferSecret, g.config.clientOptions(env))
 	if err != nil {
 		return nil, errors.Wrap(
 			errors.ErrCredentialInvalid,
@@ -141,19 +173,62 @@ func (g *TokenGenerator) createCredential(creds *credentials.AzureCredentials) (
 	}
 
 	g.logger.Debug("Azure credential created",
-		logger.String("credential_type", "ClientSecretCredential"),
+		logger.String("credential_type", credentialType),
 	)
 
 	return credential, nil
 }
 
-// getAccessToken retrieves an Azure AD access token using the credential
-func (g *TokenGenerator) getAccessToken(ctx context.Context, credential azcore.TokenCredential) (string, time.Time, error) {
-	// Request token with AKS resource scope
+// newServicePrincipalCredential creates the credential of a service principal and
+// returns its type. A client certificate is used unless preferSecret is set and the
+// credentials also have a client secret.
+func newServicePrincipalCredential(creds *credentials.AzureCredentials, preferSecret bool, options policy.ClientOptions) (azcore.TokenCredential, string, error) {
+	if creds.ClientCertificate != "" && (creds.ClientSecret == "" || !preferSecret) {
+		certs, key, err := credentials.ParseAzureCertificate([]byte(creds.ClientCertificate), creds.ClientCertificatePassword)
+		if err != nil {
+			return nil, "", err
+		}
+		credential, err := azidentity.NewClientCertificateCredential(creds.TenantID, creds.ClientID, certs, key,
+			&azidentity.ClientCertificateCredentialOptions{
+				ClientOptions:        options,
+				SendCertificateChain: creds.SendCertificateChain,
+			},
+		)
+		return credential, "ClientCertificateCredential", err
+	}
+
+	credential, err := azidentity.NewClientSecretCredential(creds.TenantID, creds.ClientID, creds.ClientSecret,
+		&azidentity.ClientSecretCredentialOptions{
+			ClientOptions: options,
+		},
+	)
+	return credential, "ClientSecretCredential", err
+}
+
+// tokenScope returns the scope requested for the token: the .default scope of the
+// audience when one is set, and the Resource Manager scope of the cloud otherwise
+func tokenScope(env cloudEnvironment, audience string) string {
+	if audience == "" {
+		return env.resourceManagerScope()
+	}
+	if strings.HasSuffix(audience, "/.default") {
+		return audience
+	}
+	return strings.TrimSuffix(audience, "/") + "/.default"
+}
+
+// getAccessToken retrieves an Azure AD access token for scope using the credential
+func (g *TokenGenerator) getAccessToken(ctx context.Context, credential azcore.TokenCredential, scope string) (string, time.Time, error) {
 	tokenResult, err := credential.GetToken(ctx, policy.TokenRequestOptions{
-		Scopes: []string{aksResourceScope},
+		Scopes: []string{scope},
 	})
 	if err != nil {
+		var authErr *azidentity.AuthenticationFailedError
+		if stderrors.As(err, &authErr) {
+			if rateLimitErr := provider.RateLimitError(err, authErr.RawResponse, "Azure AD token endpoint rate limit exceeded"); rateLimitErr != nil {
+				return "", time.Time{}, rateLimitErr.WithField("provider", "azure")
+			}
+		}
 		return "", time.Time{}, errors.Wrap(
 			errors.ErrTokenGenerationFailed,
 			err,
@@ -169,12 +244,17 @@ func (g *TokenGenerator) getAccessToken(ctx context.Context, credential azcore.T
 	return tokenResult.Token, tokenResult.ExpiresOn, nil
 }
 
-// getTokenDuration returns the configured token duration or default
+// getTokenDuration returns the configured token duration or the registered default
 func (g *TokenGenerator) getTokenDuration() time.Duration {
 	if g.config.TokenDuration > 0 {
 		return g.config.TokenDuration
 	}
-	return defaultTokenDuration
+	return provider.DefaultsOf(string(provider.ProviderAzure)).TokenDuration
+}
+
+// refreshThreshold returns the remaining lifetime at which a token is refreshed
+func (g *TokenGenerator) refreshThreshold() time.Duration {
+	return provider.RefreshThresholdOf(string(provider.ProviderAzure))
 }
 
 // ValidateToken validates that a token is valid and not expired
@@ -186,6 +266,10 @@ func (g *TokenGenerator) ValidateToken(token *provider.Token) error {
 		).WithField("provider", "azure")
 	}
 
+	if err := provider.CheckTokenIssuer(token, provider.ProviderAzure); err != nil {
+		return err
+	}
+
 	if token.AccessToken == "" {
 		return errors.New(
 			errors.ErrTokenInvalid,
@@ -193,7 +277,7 @@ func (g *TokenGenerator) ValidateToken(token *provider.Token) error {
 		).WithField("provider", "azure")
 	}
 
-	if token.IsExpired() {
+	if token.IsExpiredWith(g.clock) {
 		return errors.New(
 			errors.ErrTokenExpired,
 			"token has expired",
@@ -203,33 +287,23 @@ func (g *TokenGenerator) ValidateToken(token *provider.Token) error {
 		})
 	}
 
-	// Warn if token expires soon (less than 5 minutes)
-	if token.ExpiresIn() < 5*time.Minute {
-		g.logger.Warn("Token expires soon",
-			logger.String("provider", "azure"),
-			logger.Duration("expires_in_seconds", int64(token.ExpiresIn().Seconds())),
-		)
-	}
+	provider.WarnExpiringSoon(g.logger, token, g.clock, g.refreshThreshold())
 
 	return nil
 }
 
 // RefreshToken refreshes an expired or soon-to-expire token
 func (g *TokenGenerator) RefreshToken(ctx context.Context, opts provider.GetTokenOptions, currentToken *provider.Token) (*provider.Token, error) {
-	if currentToken != nil && !currentToken.IsExpired() {
-		// For Azure, refresh if less than 5 minutes remaining
-		if currentToken.ExpiresIn() > 5*time.Minute {
-			g.logger.Debug("Token still valid, no refresh needed",
-				logger.String("provider", "azure"),
-				logger.Duration("expires_in_seconds", int64(currentToken.ExpiresIn().Seconds())),
-			)
-			return currentToken, nil
-		}
+	log := g.logger.With(logger.String("cluster", opts.ClusterName))
+	if currentToken != nil && !currentToken.NeedsRefresh(g.clock, g.refreshThreshold()) {
+		log.Debug("Token still valid, no refresh needed",
+			logger.Duration("expires_in_seconds", int64(currentToken.ExpiresInWith(g.clock).Seconds())),
+		)
+		return currentToken, nil
 	}
 
-	g.logger.Info("Refreshing Azure token",
-		logger.String("cluster", opts.ClusterName),
-		logger.Bool("expired", currentToken == nil || currentToken.IsExpired()),
+	log.Info("Refreshing Azure token",
+		logger.Bool("expired", currentToken == nil || currentToken.IsExpiredWith(g.clock)),
 	)
 
 	// Generate new token
@@ -2,12 +2,16 @@ package azure
 
 import (
 	"context"
+	"net/http"
+	"net/url"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/credentials"
 	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
 	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/testutil"
 	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
@@ -280,9 +284,96 @@ func TestToken_Properties(t *testing.T) {
 	}
 }
 
-// TestAKSResourceScope tests the AKS resource scope constant
-func TestAKSResourceScope(t *testing.T) {
-	assert.Equal(t, "https://management.azure.com/.default", aksResourceScope)
+// TestTokenGenerator_RefreshToken tests the refresh decision at a fixed time. The loader
+// has no credentials, so a refresh shows up as a credential error instead of a network call.
+func TestTokenGenerator_RefreshToken(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		current     *provider.Token
+		wantRefresh bool
+	}{
+		{
+			name:        "no current token",
+			wantRefresh: true,
+		},
+		{
+			name:    "expires in 1 hour",
+			current: &provider.Token{AccessToken: "current-token", ExpiresAt: now.Add(time.Hour)},
+		},
+		{
+			name:    "expires just after the threshold and clock skew",
+			current: &provider.Token{AccessToken: "current-token", ExpiresAt: now.Add(defaultRefreshThreshold + provider.DefaultClockSkew + time.Nanosecond)},
+		},
+		{
+			name:        "expires exactly at the threshold and clock skew",
+			current:     &provider.Token{AccessToken: "current-token", ExpiresAt: now.Add(defaultRefreshThreshold + provider.DefaultClockSkew)},
+			wantRefresh: true,
+		},
+		{
+			name:        "expired",
+			current:     &provider.Token{AccessToken: "current-token", ExpiresAt: now.Add(-time.Minute)},
+			wantRefresh: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockLoader := testutil.NewMockCredLoader().WithAzureError(
+				errors.New(errors.ErrCredentialNotFound, "Azure credentials not configured"),
+			)
+			generator := NewTokenGenerator(DefaultConfig(), mockLoader, logger.Nop())
+			generator.clock = testutil.NewMockTime(now)
+
+			token, err := generator.RefreshToken(context.Background(), provider.GetTokenOptions{ClusterName: "my-cluster"}, tt.current)
+			if tt.wantRefresh {
+				require.Error(t, err)
+				assert.True(t, errors.Is(err, errors.ErrCredentialLoadFailed), "expected a refresh attempt, got %v", err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Same(t, tt.current, token, "should return the same token without refresh")
+		})
+	}
+}
+
+// TestLookupCloud tests the authority host and default scope chosen for each cloud
+func TestLookupCloud(t *testing.T) {
+	tests := []struct {
+		cloud         string
+		wantAuthority string
+		wantScope     string
+		wantErr       bool
+	}{
+		{cloud: "", wantAuthority: "https://login.microsoftonline.com/", wantScope: "https://management.azure.com/.default"},
+		{cloud: "public", wantAuthority: "https://login.microsoftonline.com/", wantScope: "https://management.azure.com/.default"},
+		{cloud: "usgovernment", wantAuthority: "https://login.microsoftonline.us/", wantScope: "https://management.usgovcloudapi.net/.default"},
+		{cloud: "USGovernment", wantAuthority: "https://login.microsoftonline.us/", wantScope: "https://management.usgovcloudapi.net/.default"},
+		{cloud: "china", wantAuthority: "https://login.chinacloudapi.cn/", wantScope: "https://management.chinacloudapi.cn/.default"},
+		{cloud: "germany", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.cloud, func(t *testing.T) {
+			env, err := lookupCloud(tt.cloud)
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.True(t, errors.Is(err, errors.ErrConfigInvalid))
+				assert.Contains(t, err.Error(), "china, public, usgovernment")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantAuthority, env.configuration.ActiveDirectoryAuthorityHost)
+			assert.Equal(t, tt.wantScope, tokenScope(env, ""))
+		})
+	}
+}
+
+func TestNewProvider_UnknownCloud(t *testing.T) {
+	_, err := NewProvider(&Config{Cloud: "germany"}, logger.Nop())
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, errors.ErrConfigInvalid))
 }
 
 // TestConfig_Validation tests config validation
@@ -368,6 +459,23 @@ func TestGetTokenDuration(t *testing.T) {
 	}
 }
 
+func TestTokenGenerator_DefaultsOverride(t *testing.T) {
+	require.NoError(t, provider.OverrideDefaults(string(provider.ProviderAzure), provider.Defaults{
+		TokenDuration:    30 * time.Minute,
+		RefreshThreshold: 10 * time.Minute,
+	}))
+	t.Cleanup(func() {
+		require.NoError(t, provider.OverrideDefaults(string(provider.ProviderAzure), provider.Defaults{}))
+	})
+
+	generator := NewTokenGenerator(&Config{}, testutil.NewMockCredLoader(), logger.Nop())
+	assert.Equal(t, 30*time.Minute, generator.getTokenDuration(), "the overridden default replaces the built-in one")
+	assert.Equal(t, 10*time.Minute, generator.refreshThreshold())
+
+	generator = NewTokenGenerator(&Config{TokenDuration: 45 * time.Minute}, testutil.NewMockCredLoader(), logger.Nop())
+	assert.Equal(t, 45*time.Minute, generator.getTokenDuration(), "a configured duration wins over the default")
+}
+
 // TestTenantIDPassthrough tests that tenant ID from opts/config is passed to credential loader
 // The actual priority logic is in the credential loader, not the token generator
 func TestTenantIDPassthrough(t *testing.T) {
@@ -426,3 +534,134 @@ func TestTenantIDPassthrough(t *testing.T) {
 		})
 	}
 }
+
+func TestTokenScope(t *testing.T) {
+	tests := []struct {
+		audience string
+		want     string
+	}{
+		{audience: "", want: "https://management.azure.com/.default"},
+		{audience: "api://aks-gateway", want: "api://aks-gateway/.default"},
+		{audience: "https://gateway.example.com/", want: "https://gateway.example.com/.default"},
+		{audience: "6dae42f8-4368-4678-94ff-3960e28e3630/.default", want: "6dae42f8-4368-4678-94ff-3960e28e3630/.default"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.audience, func(t *testing.T) {
+			assert.Equal(t, tt.want, tokenScope(cloudEnvironments[CloudPublic], tt.audience))
+		})
+	}
+}
+
+// TestTokenGenerator_HTTPClient checks that Entra ID requests go through the
+// configured HTTP client
+func TestTokenGenerator_HTTPClient(t *testing.T) {
+	creds := testutil.CreateValidAzureCredentials()
+	authority := "https://login.microsoftonline.com/" + creds.TenantID
+	client, transport := testutil.NewRecordingClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/discovery/instance"):
+			_, _ = w.Write([]byte(`{"tenant_discovery_endpoint":"` + authority + `/v2.0/.well-known/openid-configuration",` +
+				`"metadata":[{"preferred_network":"login.microsoftonline.com","preferred_cache":"login.windows.net","aliases":["login.microsoftonline.com"]}]}`))
+		case strings.HasSuffix(r.URL.Path, "/.well-known/openid-configuration"):
+			_, _ = w.Write([]byte(`{"token_endpoint":"` + authority + `/oauth2/v2.0/token",` +
+				`"authorization_endpoint":"` + authority + `/oauth2/v2.0/authorize",` +
+				`"issuer":"` + authority + `/v2.0"}`))
+		case strings.HasSuffix(r.URL.Path, "/oauth2/v2.0/token"):
+			_, _ = w.Write([]byte(`{"access_token":"entra-access-token","token_type":"Bearer","expires_in":3600}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	config := DefaultConfig()
+	config.HTTPClient = client
+	generator := NewTokenGenerator(config, testutil.NewMockCredLoader(), logger.Nop())
+	env, err := lookupCloud("")
+	require.NoError(t, err)
+
+	credential, err := generator.createCredential(creds, env)
+	require.NoError(t, err)
+	token, _, err := generator.getAccessToken(context.Background(), credential, env.resourceManagerScope())
+	require.NoError(t, err)
+	assert.Equal(t, "entra-access-token", token)
+
+	require.NotEmpty(t, transport.Hosts())
+	for _, host := range transport.Hosts() {
+		assert.Equal(t, "login.microsoftonline.com", host)
+	}
+}
+
+func TestNewServicePrincipalCredential(t *testing.T) {
+	withSecret := testutil.CreateCertificateAzureCredentials(t)
+	withSecret.ClientSecret = "test-client-secret-value-12345"
+
+	tests := []struct {
+		name         string
+		creds        *credentials.AzureCredentials
+		preferSecret bool
+		wantType     string
+		wantParam    string
+	}{
+		{name: "secret", creds: testutil.CreateValidAzureCredentials(), wantType: "ClientSecretCredential", wantParam: "client_secret"},
+		{name: "certificate", creds: testutil.CreateCertificateAzureCredentials(t), wantType: "ClientCertificateCredential", wantParam: "client_assertion"},
+		{name: "certificate wins over secret", creds: withSecret, wantType: "ClientCertificateCredential", wantParam: "client_assertion"},
+		{name: "prefer secret", creds: withSecret, preferSecret: true, wantType: "ClientSecretCredential", wantParam: "client_secret"},
+		{name: "prefer secret without secret", creds: testutil.CreateCertificateAzureCredentials(t), preferSecret: true, wantType: "ClientCertificateCredential", wantParam: "client_assertion"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authority := "https://login.microsoftonline.com/" + tt.creds.TenantID
+			var form url.Values
+			client, _ := testutil.NewRecordingClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				switch {
+				case strings.HasSuffix(r.URL.Path, "/discovery/instance"):
+					_, _ = w.Write([]byte(`{"tenant_discovery_endpoint":"` + authority + `/v2.0/.well-known/openid-configuration",` +
+						`"metadata":[{"preferred_network":"login.microsoftonline.com","preferred_cache":"login.windows.net","aliases":["login.microsoftonline.com"]}]}`))
+				case strings.HasSuffix(r.URL.Path, "/.well-known/openid-configuration"):
+					_, _ = w.Write([]byte(`{"token_endpoint":"` + authority + `/oauth2/v2.0/token",` +
+						`"authorization_endpoint":"` + authority + `/oauth2/v2.0/authorize",` +
+						`"issuer":"` + authority + `/v2.0"}`))
+				case strings.HasSuffix(r.URL.Path, "/oauth2/v2.0/token"):
+					_ = r.ParseForm()
+					form = r.PostForm
+					_, _ = w.Write([]byte(`{"access_token":"entra-access-token","token_type":"Bearer","expires_in":3600}`))
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+
+			config := DefaultConfig()
+			config.HTTPClient = client
+			env, err := lookupCloud("")
+			require.NoError(t, err)
+
+			credential, credentialType, err := newServicePrincipalCredential(tt.creds, tt.preferSecret, config.clientOptions(env))
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantType, credentialType)
+
+			generator := NewTokenGenerator(config, testutil.NewMockCredLoader(), logger.Nop())
+			_, _, err = generator.getAccessToken(context.Background(), credential, env.resourceManagerScope())
+			require.NoError(t, err)
+			assert.NotEmpty(t, form.Get(tt.wantParam), "the token request should authenticate with %s", tt.wantParam)
+		})
+	}
+}
+
+func TestConfig_ClientOptions(t *testing.T) {
+	env, err := lookupCloud(CloudChina)
+	require.NoError(t, err)
+
+	opts := DefaultConfig().clientOptions(env)
+	assert.Equal(t, env.configuration, opts.Cloud)
+	assert.Nil(t, opts.Transport, "a nil client keeps the SDK default transport")
+
+	client := &http.Client{}
+	config := DefaultConfig()
+	config.HTTPClient = client
+	opts = config.clientOptions(env)
+	assert.Same(t, client, opts.Transport)
+}
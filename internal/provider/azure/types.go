@@ -1,7 +1,14 @@
 package azure
 
 import (
+	"net/http"
 	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/metrics"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/tracing"
 )
 
 // Config holds Azure provider configuration
@@ -11,11 +18,52 @@ type Config struct {
 	ResourceGroup   string
 	CredentialsFile string
 	TokenDuration   time.Duration
+
+	// Cloud is the Azure cloud: public (default), usgovernment or china
+	Cloud string
+
+	// StrictPermissions rejects credentials files readable by group or others
+	StrictPermissions bool
+
+	// CredentialsSHA256 are the comma-separated SHA-256 digests, one of which every
+	// credentials file read must have; empty skips the check
+	CredentialsSHA256 string
+
+	// PreferSecret authenticates with the client secret when the credentials have both a
+	// secret and a client certificate; the certificate is used otherwise
+	PreferSecret bool
+
+	// Tracing records spans for token generation and cluster lookups; nil disables tracing
+	Tracing *tracing.Provider
+
+	// HTTPClient carries the Entra ID and Resource Manager requests; nil uses the SDK default
+	HTTPClient *http.Client
+
+	// WatchCredentials reloads the credentials file when it changes on disk
+	WatchCredentials bool
+
+	// Metrics records credential reloads and token gauges; nil disables them
+	Metrics *metrics.Metrics
+}
+
+// clientOptions returns the SDK client options for the cloud, with the configured
+// HTTP client as the transport when there is one
+func (c *Config) clientOptions(env cloudEnvironment) policy.ClientOptions {
+	opts := policy.ClientOptions{Cloud: env.configuration}
+	if c.HTTPClient != nil {
+		opts.Transport = c.HTTPClient
+	}
+	return opts
+}
+
+// Validate checks the configured cloud is known
+func (c *Config) Validate() error {
+	return ValidateCloud(c.Cloud)
 }
 
 // DefaultConfig returns default Azure configuration
 func DefaultConfig() *Config {
 	return &Config{
-		TokenDuration: 1 * time.Hour,
+		TokenDuration: provider.DefaultsOf(string(provider.ProviderAzure)).TokenDuration,
 	}
 }
@@ -0,0 +1,73 @@
+package azure
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+)
+
+var (
+	// clusterNamePattern is the AKS managed cluster naming rule: 1 to 63 letters,
+	// digits, underscores and hyphens, starting and ending with a letter or digit
+	clusterNamePattern = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9_-]{0,61}[a-zA-Z0-9])?$`)
+
+	// resourceGroupPattern is the ARM resource group naming rule: 1 to 90 letters,
+	// digits, underscores, parentheses, hyphens and periods
+	resourceGroupPattern = regexp.MustCompile(`^[\p{L}\p{N}_().-]{1,90}$`)
+)
+
+// validateClusterName checks a cluster name against the AKS naming rules
+func validateClusterName(name string) error {
+	if name == "" {
+		return errors.New(
+			errors.ErrInvalidArgument,
+			"cluster name is required",
+		).WithField("provider", "azure")
+	}
+	if !clusterNamePattern.MatchString(name) {
+		return errors.New(
+			errors.ErrInvalidArgument,
+			"invalid AKS cluster name",
+		).WithFields(map[string]interface{}{
+			"provider":     "azure",
+			"cluster_name": name,
+		}).WithDetail("expected 1 to 63 letters, digits, underscores and hyphens, starting and ending with a letter or digit")
+	}
+	return nil
+}
+
+// validateOptions implements provider.OptionsValidator with the checks GetToken
+// makes before generating a token
+func validateOptions(opts provider.GetTokenOptions) error {
+	if err := validateClusterName(opts.ClusterName); err != nil {
+		return err
+	}
+	// The resource group is optional for tokens and only checked when set
+	if opts.ResourceGroup != "" {
+		return validateResourceGroup(opts.ResourceGroup)
+	}
+	return nil
+}
+
+// validateResourceGroup checks a resource group name against the ARM naming rules
+func validateResourceGroup(resourceGroup string) error {
+	if resourceGroup == "" {
+		return errors.New(
+			errors.ErrInvalidArgument,
+			"resource group is required",
+		).WithField("provider", "azure")
+	}
+	// A resource group name cannot end with a period
+	if !resourceGroupPattern.MatchString(resourceGroup) || strings.HasSuffix(resourceGroup, ".") {
+		return errors.New(
+			errors.ErrInvalidArgument,
+			"invalid Azure resource group",
+		).WithFields(map[string]interface{}{
+			"provider":       "azure",
+			"resource_group": resourceGroup,
+		}).WithDetail("expected 1 to 90 letters, digits, underscores, parentheses, hyphens and periods, not ending with a period")
+	}
+	return nil
+}
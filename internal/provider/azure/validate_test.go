@@ -0,0 +1,99 @@
+package azure
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/testutil"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+)
+
+func TestValidateClusterName(t *testing.T) {
+	tests := []struct {
+		name        string
+		clusterName string
+		wantErr     bool
+	}{
+		{name: "simple", clusterName: "my-cluster"},
+		{name: "mixed case with underscore", clusterName: "Prod_AKS01"},
+		{name: "single character", clusterName: "a"},
+		{name: "maximum length", clusterName: strings.Repeat("a", 63)},
+		{name: "empty", clusterName: "", wantErr: true},
+		{name: "too long", clusterName: strings.Repeat("a", 64), wantErr: true},
+		{name: "starts with hyphen", clusterName: "-cluster", wantErr: true},
+		{name: "ends with underscore", clusterName: "cluster_", wantErr: true},
+		{name: "contains period", clusterName: "my.cluster", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateClusterName(tt.clusterName)
+			if !tt.wantErr {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, errors.ErrInvalidArgument))
+		})
+	}
+}
+
+func TestValidateResourceGroup(t *testing.T) {
+	tests := []struct {
+		name          string
+		resourceGroup string
+		wantErr       bool
+	}{
+		{name: "simple", resourceGroup: "my-rg"},
+		{name: "all allowed punctuation", resourceGroup: "rg_(prod).east-1"},
+		{name: "unicode letters", resourceGroup: "grupo-producción"},
+		{name: "maximum length", resourceGroup: strings.Repeat("r", 90)},
+		{name: "empty", resourceGroup: "", wantErr: true},
+		{name: "too long", resourceGroup: strings.Repeat("r", 91), wantErr: true},
+		{name: "ends with period", resourceGroup: "my-rg.", wantErr: true},
+		{name: "contains slash", resourceGroup: "subscriptions/my-rg", wantErr: true},
+		{name: "contains space", resourceGroup: "my rg", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateResourceGroup(tt.resourceGroup)
+			if !tt.wantErr {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, errors.ErrInvalidArgument))
+			if tt.resourceGroup != "" {
+				var appErr *errors.Error
+				require.True(t, errors.As(err, &appErr))
+				assert.Equal(t, tt.resourceGroup, appErr.Fields["resource_group"])
+			}
+		})
+	}
+}
+
+// Invalid input is rejected before any credential is loaded
+func TestProvider_ValidatesBeforeLoadingCredentials(t *testing.T) {
+	loader := testutil.NewMockCredLoader().
+		WithAzureError(errors.New(errors.ErrCredentialNotFound, "credentials must not be loaded"))
+	p, err := NewProvider(&Config{SubscriptionID: "12345678-1234-1234-1234-123456789012"}, logger.Nop())
+	require.NoError(t, err)
+	p.credLoader = loader
+	p.tokenGenerator.credLoader = loader
+
+	_, err = p.GetToken(context.Background(), provider.GetTokenOptions{ClusterName: "my.cluster"})
+	assert.True(t, errors.Is(err, errors.ErrInvalidArgument), "got %v", err)
+
+	_, err = p.GetToken(context.Background(), provider.GetTokenOptions{ClusterName: "my-cluster", ResourceGroup: "my-rg."})
+	assert.True(t, errors.Is(err, errors.ErrInvalidArgument), "got %v", err)
+
+	_, err = p.GetClusterInfo(context.Background(), "my-cluster", "")
+	assert.True(t, errors.Is(err, errors.ErrInvalidArgument), "got %v", err)
+}
@@ -0,0 +1,48 @@
+package azure
+
+import (
+	"context"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+)
+
+// WhoAmI implements provider.IdentityReporter. Azure AD has no caller identity API
+// for service principals, so a Resource Manager token is requested and its claims
+// are read; the token is not sent anywhere else.
+func (p *Provider) WhoAmI(ctx context.Context) (*provider.Identity, error) {
+	token, err := p.tokenGenerator.GenerateToken(ctx, provider.GetTokenOptions{
+		ClusterName:    "whoami",
+		SubscriptionID: p.config.SubscriptionID,
+		TenantID:       p.config.TenantID,
+	})
+	if err != nil {
+		return nil, errors.Wrap(
+			errors.ErrCredentialValidationFailed,
+			err,
+			"failed to get an Azure AD token",
+		).WithField("provider", "azure")
+	}
+
+	return identityFromToken(token.AccessToken)
+}
+
+// identityFromToken describes the service principal an access token was issued to
+func identityFromToken(accessToken string) (*provider.Identity, error) {
+	info, err := InspectToken(accessToken, false)
+	if err != nil {
+		return nil, errors.Wrap(
+			errors.ErrTokenInvalid,
+			err,
+			"failed to decode Azure AD token",
+		).WithField("provider", "azure")
+	}
+
+	return &provider.Identity{
+		Type:     "service_principal",
+		AppID:    info.AppID,
+		ObjectID: info.ObjectID,
+		TenantID: info.TenantID,
+		Source:   "access token claims",
+	}, nil
+}
@@ -0,0 +1,49 @@
+package azure
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+)
+
+func TestIdentityFromToken(t *testing.T) {
+	tests := []struct {
+		name      string
+		claims    string
+		wantAppID string
+	}{
+		{
+			name:      "v1 token",
+			claims:    `{"appid":"11111111-2222-3333-4444-555555555555","tid":"tenant-1","oid":"oid-1"}`,
+			wantAppID: "11111111-2222-3333-4444-555555555555",
+		},
+		{
+			name:      "v2 token",
+			claims:    `{"azp":"11111111-2222-3333-4444-555555555555","tid":"tenant-1","oid":"oid-1"}`,
+			wantAppID: "11111111-2222-3333-4444-555555555555",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256"}`)) + "." +
+				base64.RawURLEncoding.EncodeToString([]byte(tt.claims)) + ".c2lnbmF0dXJl"
+
+			identity, err := identityFromToken(token)
+			require.NoError(t, err)
+			assert.Equal(t, "service_principal", identity.Type)
+			assert.Equal(t, tt.wantAppID, identity.AppID)
+			assert.Equal(t, "oid-1", identity.ObjectID)
+			assert.Equal(t, "tenant-1", identity.TenantID)
+			assert.Equal(t, "access token claims", identity.Source)
+		})
+	}
+
+	_, err := identityFromToken("not-a-jwt")
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, errors.ErrTokenInvalid))
+}
@@ -0,0 +1,46 @@
+package provider
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"strings"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+)
+
+// ValidateCertificateAuthority checks that a cluster CA returned by a cloud API is a
+// base64-encoded bundle of one or more PEM certificates. A bad CA would otherwise only
+// surface later as an opaque TLS error from the kubeconfig that embeds it.
+func ValidateCertificateAuthority(ca string) error {
+	if strings.TrimSpace(ca) == "" {
+		return errors.New(errors.ErrClusterInvalidConfig, "cluster CA certificate is empty")
+	}
+
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(ca))
+	if err != nil {
+		return errors.Wrap(errors.ErrClusterInvalidConfig, err, "cluster CA certificate is not valid base64")
+	}
+
+	count := 0
+	for rest := data; ; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			return errors.New(errors.ErrClusterInvalidConfig, "cluster CA contains a non-certificate PEM block").
+				WithField("pem_type", block.Type)
+		}
+		if _, err := x509.ParseCertificate(block.Bytes); err != nil {
+			return errors.Wrap(errors.ErrClusterInvalidConfig, err, "cluster CA certificate cannot be parsed")
+		}
+		count++
+	}
+
+	if count == 0 {
+		return errors.New(errors.ErrClusterInvalidConfig, "cluster CA contains no PEM certificates")
+	}
+	return nil
+}
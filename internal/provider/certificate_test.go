@@ -0,0 +1,103 @@
+package provider
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+)
+
+// testCertificatePEM returns a self-signed CA certificate in PEM form
+func testCertificatePEM(t *testing.T) []byte {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-cluster-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func TestValidateCertificateAuthority(t *testing.T) {
+	cert := testCertificatePEM(t)
+	encode := base64.StdEncoding.EncodeToString
+
+	tests := []struct {
+		name    string
+		ca      string
+		wantErr bool
+	}{
+		{
+			name: "single certificate",
+			ca:   encode(cert),
+		},
+		{
+			name: "certificate bundle",
+			ca:   encode(append(append([]byte{}, cert...), testCertificatePEM(t)...)),
+		},
+		{
+			name: "surrounding whitespace",
+			ca:   " " + encode(cert) + "\n",
+		},
+		{
+			name:    "empty",
+			ca:      "",
+			wantErr: true,
+		},
+		{
+			name:    "invalid base64",
+			ca:      "not-base64!!",
+			wantErr: true,
+		},
+		{
+			name:    "base64 without PEM",
+			ca:      encode([]byte("hello world")),
+			wantErr: true,
+		},
+		{
+			name:    "non-certificate PEM block",
+			ca:      encode(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: []byte("key")})),
+			wantErr: true,
+		},
+		{
+			name:    "certificate PEM with garbage body",
+			ca:      encode(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte("garbage")})),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateCertificateAuthority(tt.ca)
+
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.True(t, errors.Is(err, errors.ErrClusterInvalidConfig),
+					"expected error code %s, got %v", errors.ErrClusterInvalidConfig, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
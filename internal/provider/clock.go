@@ -0,0 +1,139 @@
+package provider
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+)
+
+// Clock tells the current time. Token expiry and refresh decisions read the time
+// through a Clock so tests can fix it.
+type Clock interface {
+	Now() time.Time
+}
+
+// systemClock is the Clock backed by time.Now
+type systemClock struct{}
+
+// Now returns the current local time
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// SystemClock is the default Clock
+var SystemClock Clock = systemClock{}
+
+// ClockFunc adapts a function returning the current time, such as a provider's
+// replaceable now, to a Clock
+type ClockFunc func() time.Time
+
+// Now returns f()
+func (f ClockFunc) Now() time.Time {
+	return f()
+}
+
+// DefaultClockSkew is the clock skew tolerance used until SetClockSkew is called
+const DefaultClockSkew = time.Minute
+
+var (
+	clockSkewMu sync.RWMutex
+	clockSkew   = DefaultClockSkew
+)
+
+// SetClockSkew sets how far the local clock may be off from the clocks of the cloud and
+// the API server. Tokens are refreshed that much earlier than their refresh threshold,
+// stores drop tokens that close to expiry, and ValidateToken warns that much earlier,
+// so that a server whose clock is ahead does not see a token expire before it is
+// replaced. The tolerance only ever makes a token be considered expired sooner. Zero
+// disables it; a negative skew, or one that leaves a provider no time between its
+// refresh threshold and the end of its default token duration, is rejected.
+func SetClockSkew(skew time.Duration) error {
+	if skew < 0 {
+		return errors.New(
+			errors.ErrConfigInvalid,
+			fmt.Sprintf("clock skew must not be negative, got %s", skew),
+		).WithField("clock_skew", skew.String())
+	}
+	for _, name := range Registered() {
+		if err := DefaultsOf(name).validate(name, skew); err != nil {
+			return err
+		}
+	}
+
+	clockSkewMu.Lock()
+	defer clockSkewMu.Unlock()
+	clockSkew = skew
+	return nil
+}
+
+// ClockSkew returns the clock skew tolerance set with SetClockSkew
+func ClockSkew() time.Duration {
+	clockSkewMu.RLock()
+	defer clockSkewMu.RUnlock()
+	return clockSkew
+}
+
+// WarnExpiringSoon logs a warning when token expires within threshold plus ClockSkew
+// at the time of clock, the window in which it is refreshed. The warning says so when
+// the token is inside the skew tolerance itself, where a server with a clock ahead of
+// the local one may already reject it.
+func WarnExpiringSoon(log logger.Logger, token *Token, clock Clock, threshold time.Duration) {
+	skew := ClockSkew()
+	expiresIn := token.ExpiresInWith(clock)
+	if expiresIn >= threshold+skew {
+		return
+	}
+
+	message := "Token expires soon"
+	if expiresIn < skew {
+		message = "Token expires within the clock skew tolerance and may already be rejected"
+	}
+	log.Warn(message,
+		logger.Duration("expires_in_seconds", int64(expiresIn.Seconds())),
+		logger.Duration("clock_skew_seconds", int64(skew.Seconds())),
+	)
+}
+
+// LifetimeSlack is how much longer than the longest lifetime a provider issues a fresh
+// token may appear to live before the local clock is considered skewed. It is unrelated
+// to the tolerance set with SetClockSkew.
+const LifetimeSlack = 5 * time.Minute
+
+// clockSkewDetail tells users how to fix a skewed clock
+const clockSkewDetail = "possible clock skew: the local clock is far from the time the token was issued; " +
+	"sync the system clock (e.g. with NTP) and retry"
+
+// CheckClockSkew checks the lifetime a freshly generated token of providerName appears
+// to have at the time of clock. A token that is already expired, or that outlives
+// maxLifetime, the longest lifetime the provider issues, by more than LifetimeSlack,
+// means the local clock is far ahead or behind: the skew is logged
+// as a warning and an ErrTokenInvalid error is returned instead of a dead token.
+func CheckClockSkew(clock Clock, log logger.Logger, providerName string, expiresAt time.Time, maxLifetime time.Duration) error {
+	now := clock.Now()
+	ttl := expiresAt.Sub(now)
+	if ttl > 0 && ttl <= maxLifetime+LifetimeSlack {
+		return nil
+	}
+
+	log.Warn("Possible clock skew: generated token has an implausible lifetime",
+		logger.String("provider", providerName),
+		logger.String("local_time", now.Format(time.RFC3339)),
+		logger.String("expires_at", expiresAt.Format(time.RFC3339)),
+		logger.Duration("expires_in_seconds", int64(ttl.Seconds())),
+	)
+
+	message := "generated token is already expired at the local time"
+	if ttl > 0 {
+		message = "generated token expires later than the provider issues tokens for"
+	}
+	return errors.New(errors.ErrTokenInvalid, message).
+		WithFields(map[string]interface{}{
+			"provider":   providerName,
+			"local_time": now.Format(time.RFC3339),
+			"expires_at": expiresAt.Format(time.RFC3339),
+		}).
+		WithDetail(clockSkewDetail)
+}
@@ -0,0 +1,202 @@
+package provider_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/testutil"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+)
+
+func TestToken_ExpiryWithClock(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	clock := testutil.NewMockTime(now)
+	token := &provider.Token{AccessToken: "token", ExpiresAt: now.Add(10 * time.Minute)}
+
+	assert.Equal(t, 10*time.Minute, token.ExpiresInWith(clock))
+	assert.False(t, token.IsExpiredWith(clock))
+
+	clock.Advance(10 * time.Minute)
+	assert.Equal(t, time.Duration(0), token.ExpiresInWith(clock))
+	assert.False(t, token.IsExpiredWith(clock), "a token is valid up to and including its expiry instant")
+
+	clock.Advance(time.Nanosecond)
+	assert.Equal(t, -time.Nanosecond, token.ExpiresInWith(clock))
+	assert.True(t, token.IsExpiredWith(clock))
+}
+
+// setClockSkew sets the clock skew tolerance for the duration of the test
+func setClockSkew(t *testing.T, skew time.Duration) {
+	t.Helper()
+	require.NoError(t, provider.SetClockSkew(skew))
+	t.Cleanup(func() { require.NoError(t, provider.SetClockSkew(provider.DefaultClockSkew)) })
+}
+
+func TestToken_NeedsRefresh(t *testing.T) {
+	const threshold = 5 * time.Minute
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		skew      time.Duration
+		expiresIn time.Duration
+		want      bool
+	}{
+		{name: "well before the threshold", expiresIn: time.Hour, want: false},
+		{name: "just before the threshold", expiresIn: threshold + time.Nanosecond, want: false},
+		{name: "exactly at the threshold", expiresIn: threshold, want: true},
+		{name: "inside the threshold", expiresIn: threshold - time.Nanosecond, want: true},
+		{name: "at expiry", expiresIn: 0, want: true},
+		{name: "expired", expiresIn: -time.Minute, want: true},
+		{name: "just before the threshold and skew", skew: time.Minute, expiresIn: threshold + time.Minute + time.Nanosecond, want: false},
+		{name: "exactly at the threshold and skew", skew: time.Minute, expiresIn: threshold + time.Minute, want: true},
+		{name: "past the threshold, inside the skew", skew: time.Minute, expiresIn: threshold + time.Second, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setClockSkew(t, tt.skew)
+			token := &provider.Token{AccessToken: "token", ExpiresAt: now.Add(tt.expiresIn)}
+			assert.Equal(t, tt.want, token.NeedsRefresh(testutil.NewMockTime(now), threshold))
+		})
+	}
+}
+
+func TestToken_ExpiresWithinSkew(t *testing.T) {
+	setClockSkew(t, time.Minute)
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	clock := testutil.NewMockTime(now)
+
+	assert.False(t, (&provider.Token{ExpiresAt: now.Add(time.Minute + time.Nanosecond)}).ExpiresWithinSkew(clock))
+	assert.True(t, (&provider.Token{ExpiresAt: now.Add(time.Minute)}).ExpiresWithinSkew(clock))
+	assert.True(t, (&provider.Token{ExpiresAt: now.Add(-time.Second)}).ExpiresWithinSkew(clock))
+	assert.False(t, (&provider.Token{ExpiresAt: now.Add(30 * time.Second)}).IsExpiredWith(clock), "expiry itself is not moved")
+}
+
+// TestToken_NeedsRefresh_ClockDrift replays the life of a token whose expiry the server
+// set, on a local clock ahead of or behind the server's, checking that the token is
+// never kept once the server sees it within the refresh threshold of expiry
+func TestToken_NeedsRefresh_ClockDrift(t *testing.T) {
+	const (
+		lifetime  = 15 * time.Minute
+		threshold = 30 * time.Second
+	)
+	issued := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	token := &provider.Token{AccessToken: "token", ExpiresAt: issued.Add(lifetime)}
+
+	tests := []struct {
+		name     string
+		skew     time.Duration
+		drift    time.Duration
+		wantSafe bool
+	}{
+		{name: "local clock behind, covered by the skew", skew: time.Minute, drift: -45 * time.Second, wantSafe: true},
+		{name: "local clock ahead, covered by the skew", skew: time.Minute, drift: 45 * time.Second, wantSafe: true},
+		{name: "local clock behind, no skew", skew: 0, drift: -45 * time.Second, wantSafe: false},
+		{name: "local clock ahead, no skew", skew: 0, drift: 45 * time.Second, wantSafe: true},
+		{name: "local clock behind by more than the skew", skew: time.Minute, drift: -2 * time.Minute, wantSafe: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setClockSkew(t, tt.skew)
+
+			safe := true
+			for server := issued; server.Before(token.ExpiresAt); server = server.Add(time.Second) {
+				local := testutil.NewMockTime(server.Add(tt.drift))
+				if !token.NeedsRefresh(local, threshold) && token.ExpiresAt.Sub(server) <= threshold {
+					safe = false
+				}
+			}
+			assert.Equal(t, tt.wantSafe, safe)
+		})
+	}
+}
+
+func TestSetClockSkew(t *testing.T) {
+	assert.Equal(t, provider.DefaultClockSkew, provider.ClockSkew())
+
+	setClockSkew(t, 2*time.Minute)
+	assert.Equal(t, 2*time.Minute, provider.ClockSkew())
+
+	err := provider.SetClockSkew(-time.Second)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, errors.ErrConfigInvalid))
+	assert.Equal(t, 2*time.Minute, provider.ClockSkew(), "a rejected skew leaves the tolerance unchanged")
+}
+
+func TestWarnExpiringSoon(t *testing.T) {
+	setClockSkew(t, time.Minute)
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		expiresIn time.Duration
+		wantLog   string
+	}{
+		{name: "outside the refresh window", expiresIn: 10 * time.Minute},
+		{name: "inside the threshold plus skew", expiresIn: 2 * time.Minute, wantLog: "Token expires soon"},
+		{name: "inside the skew", expiresIn: 30 * time.Second, wantLog: "Token expires within the clock skew tolerance"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log, output := testutil.NewDebugLogger(t)
+			token := &provider.Token{AccessToken: "token", ExpiresAt: now.Add(tt.expiresIn)}
+
+			provider.WarnExpiringSoon(log, token, testutil.NewMockTime(now), 2*time.Minute)
+			if tt.wantLog == "" {
+				assert.Empty(t, output.String())
+				return
+			}
+			assert.Contains(t, output.String(), tt.wantLog)
+			assert.Contains(t, output.String(), `"clock_skew_seconds":60`)
+		})
+	}
+}
+
+func TestSystemClock(t *testing.T) {
+	before := time.Now()
+	now := provider.SystemClock.Now()
+	assert.False(t, now.Before(before))
+	assert.WithinDuration(t, time.Now(), now, time.Second)
+}
+
+func TestCheckClockSkew(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	clock := testutil.NewMockTime(now)
+
+	tests := []struct {
+		name      string
+		expiresAt time.Time
+		wantErr   string
+	}{
+		{name: "fresh token", expiresAt: now.Add(time.Hour)},
+		{name: "within the tolerance", expiresAt: now.Add(time.Hour + provider.LifetimeSlack)},
+		{name: "already expired", expiresAt: now.Add(-time.Minute), wantErr: "already expired"},
+		{name: "expires now", expiresAt: now, wantErr: "already expired"},
+		{name: "absurdly long", expiresAt: now.Add(3 * time.Hour), wantErr: "expires later than"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log, output := testutil.NewDebugLogger(t)
+
+			err := provider.CheckClockSkew(clock, log, "gcp", tt.expiresAt, time.Hour)
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				assert.NotContains(t, output.String(), "clock skew")
+				return
+			}
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, errors.ErrTokenInvalid))
+			assert.Contains(t, err.Error(), tt.wantErr)
+			assert.Contains(t, err.Error(), "possible clock skew")
+			assert.Contains(t, output.String(), "Possible clock skew")
+		})
+	}
+}
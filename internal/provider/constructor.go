@@ -0,0 +1,337 @@
+package provider
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/metrics"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/tracing"
+)
+
+// Config is the provider-independent configuration passed to registered constructors.
+// Each provider reads the fields that apply to it and ignores the rest.
+type Config struct {
+	// Region is the cloud region or location
+	Region string
+
+	// ProjectID is the GCP project ID (GCP only)
+	ProjectID string
+
+	// AccountID is the AWS account ID (AWS only, optional)
+	AccountID string
+
+	// AWSProfile is the profile read from the AWS shared files; empty uses AWS_PROFILE (AWS only)
+	AWSProfile string
+
+	// SubscriptionID is the Azure subscription ID (Azure only)
+	SubscriptionID string
+
+	// TenantID is the Azure tenant ID (Azure only)
+	TenantID string
+
+	// AzureCloud is the Azure cloud: public, usgovernment or china (Azure only, default: public)
+	AzureCloud string
+
+	// TenancyID is the OCI tenancy OCID (OCI only, optional)
+	TenancyID string
+
+	// UserID is the OCI user OCID (OCI only, optional)
+	UserID string
+
+	// CompartmentID is the OCI compartment searched for clusters by name (OCI only, optional)
+	CompartmentID string
+
+	// CredentialsFile is the path to the credentials file
+	CredentialsFile string
+
+	// CredentialsDir is a directory of GCP service account keys (GCP only)
+	CredentialsDir string
+
+	// GCPUseADC is the application default credentials mode: auto, true or false (GCP only)
+	GCPUseADC string
+
+	// OIDCIssuerURL is the issuer whose discovery document names the token endpoint (OIDC only)
+	OIDCIssuerURL string
+
+	// OIDCClientID is the OAuth client ID (OIDC only)
+	OIDCClientID string
+
+	// OIDCPrivateKeyFile is a PEM private key that signs private_key_jwt client
+	// assertions instead of a client secret (OIDC only, optional)
+	OIDCPrivateKeyFile string
+
+	// OIDCScopes are the requested scopes; nil uses the provider default (OIDC only)
+	OIDCScopes []string
+
+	// OIDCSubjectTokenFile holds a token, such as a Kubernetes service account token,
+	// exchanged for the access token with RFC 8693 token exchange (OIDC only, optional)
+	OIDCSubjectTokenFile string
+
+	// OIDCSubjectTokenType is the RFC 8693 type of the subject token (OIDC only, optional)
+	OIDCSubjectTokenType string
+
+	// OIDCUseIDToken returns the ID token instead of the access token (OIDC only)
+	OIDCUseIDToken bool
+
+	// TokenDuration is the token lifetime; zero uses the provider default
+	TokenDuration time.Duration
+
+	// StrictPermissions rejects credentials files readable by group or others
+	StrictPermissions bool
+
+	// CredentialsSHA256 is a comma-separated list of SHA-256 digests, one of which every
+	// credentials file read must have (GCP, AWS and Azure only). Credentials that do not
+	// come from a file then fail to load. Empty skips the check.
+	CredentialsSHA256 string
+
+	// SkipCredentialCheck skips the pre-flight expiry check of session credentials (AWS only)
+	SkipCredentialCheck bool
+
+	// AWSFallbacks are credential sources tried in order when the configured AWS
+	// credentials fail, in --aws-fallback format such as profile=backup,region=us-west-2
+	// (AWS only)
+	AWSFallbacks []string
+
+	// AWSSuggestRegion looks for a cluster that is not found in its region in a few
+	// likely regions, to report a region mismatch (AWS only)
+	AWSSuggestRegion bool
+
+	// AzurePreferSecret authenticates with the client secret when the Azure credentials
+	// have both a secret and a client certificate (Azure only)
+	AzurePreferSecret bool
+
+	// STSEndpoint overrides the regional STS endpoint that tokens presign requests to,
+	// e.g. an interface VPC endpoint (AWS only)
+	STSEndpoint string
+
+	// GKEEndpoint overrides the Container API endpoint of cluster lookups (GCP only)
+	GKEEndpoint string
+
+	// Tracing records spans for token generation and cluster lookups; nil disables tracing
+	Tracing *tracing.Provider
+
+	// HTTPClient carries the cloud SDK requests, e.g. through a configured proxy; nil
+	// keeps the SDK default transports
+	HTTPClient *http.Client
+
+	// WatchCredentials reloads the GCP, AWS and Azure credentials files when they change
+	// on disk, e.g. when Vault Agent rotates them (long-running modes), until Close
+	WatchCredentials bool
+
+	// Metrics records credential reloads and token gauges; nil disables them
+	Metrics *metrics.Metrics
+}
+
+// Constructor creates a provider from the shared configuration
+type Constructor func(cfg *Config, log logger.Logger) (Provider, error)
+
+// Capabilities lists the optional features a provider supports, so commands can
+// reject unsupported options before loading credentials or calling a cloud API
+type Capabilities struct {
+	// BoundAudience is set when tokens can be bound to a caller-chosen audience
+	// (GetTokenOptions.Audience)
+	BoundAudience bool
+
+	// LookupAnyRegion is set when one provider instance can describe clusters in every
+	// region, because DescribeCluster reads ClusterInfoOptions.Region or the cloud has
+	// no regions. Providers without it bind their clients to Config.Region.
+	LookupAnyRegion bool
+
+	// EndpointAccess is set when DescribeCluster honors ClusterInfoOptions.EndpointAccess
+	// to pick the public or private endpoint of a cluster
+	EndpointAccess bool
+}
+
+// DefaultRefreshThreshold is the refresh threshold of providers that register none
+const DefaultRefreshThreshold = time.Minute
+
+// MinTokenDuration is the shortest sensible token lifetime. Shorter tokens are allowed,
+// but kubectl would have to run the exec plugin for almost every request.
+const MinTokenDuration = time.Minute
+
+// Registration is what a provider package registers about its provider. Only
+// Constructor is required; the zero value of every other field is the behavior of a
+// provider that declares nothing.
+type Registration struct {
+	// Constructor creates the provider
+	Constructor Constructor
+
+	// Capabilities are the optional features the provider supports
+	Capabilities Capabilities
+
+	// Defaults are the built-in token lifetime settings; unset fields use
+	// DefaultTokenDuration and DefaultRefreshThreshold
+	Defaults Defaults
+
+	// ValidateOptions checks token options against the naming rules of the provider;
+	// nil only requires a cluster name
+	ValidateOptions OptionsValidator
+
+	// Inputs are the provider-specific inputs, so commands can report every missing or
+	// invalid one at once
+	Inputs []Input
+}
+
+var (
+	registrationsMu sync.RWMutex
+	registrations   = make(map[ProviderName]Registration)
+)
+
+// RegisterProvider registers a provider by name. Provider packages call it from init so
+// that importing a provider makes it available to New.
+func RegisterProvider(name ProviderName, registration Registration) error {
+	if name == "" || registration.Constructor == nil {
+		return errors.New(
+			errors.ErrInvalidArgument,
+			"provider name and constructor are required",
+		).WithField("provider", name)
+	}
+
+	registrationsMu.Lock()
+	defer registrationsMu.Unlock()
+
+	if _, exists := registrations[name]; exists {
+		return errors.New(
+			errors.ErrAlreadyExists,
+			fmt.Sprintf("provider %s already registered", name),
+		).WithField("provider", name)
+	}
+
+	registration.Inputs = append([]Input(nil), registration.Inputs...)
+	registrations[name] = registration
+	return nil
+}
+
+// MustRegisterProvider registers a provider and panics on error
+func MustRegisterProvider(name ProviderName, registration Registration) {
+	if err := RegisterProvider(name, registration); err != nil {
+		panic(err)
+	}
+}
+
+// registrationOf returns the registration of the named provider and whether it exists
+func registrationOf(name string) (Registration, bool) {
+	registrationsMu.RLock()
+	defer registrationsMu.RUnlock()
+
+	registration, exists := registrations[ProviderName(name)]
+	return registration, exists
+}
+
+// CapabilitiesOf returns the optional features of the named provider
+func CapabilitiesOf(name string) Capabilities {
+	registration, _ := registrationOf(name)
+	return registration.Capabilities
+}
+
+// RefreshThresholdOf returns the remaining lifetime at which tokens of the named
+// provider are refreshed
+func RefreshThresholdOf(name string) time.Duration {
+	return DefaultsOf(name).RefreshThreshold
+}
+
+// CheckAudienceSupported returns an error naming the provider when its tokens
+// cannot be bound to an audience
+func CheckAudienceSupported(name string) error {
+	if CapabilitiesOf(name).BoundAudience {
+		return nil
+	}
+
+	var supported []string
+	for _, registered := range Registered() {
+		if CapabilitiesOf(registered).BoundAudience {
+			supported = append(supported, registered)
+		}
+	}
+	return errors.New(
+		errors.ErrInvalidArgument,
+		fmt.Sprintf("provider %s does not support bound audiences", name),
+	).WithField("provider", name).
+		WithDetail("audiences are supported by: " + strings.Join(supported, ", "))
+}
+
+// CheckEndpointAccessSupported returns an ErrInvalidArgument error naming the providers
+// that support it when the named provider cannot pick between public and private
+// cluster endpoints
+func CheckEndpointAccessSupported(name string) error {
+	if CapabilitiesOf(name).EndpointAccess {
+		return nil
+	}
+
+	var supported []string
+	for _, registered := range Registered() {
+		if CapabilitiesOf(registered).EndpointAccess {
+			supported = append(supported, registered)
+		}
+	}
+	return errors.New(
+		errors.ErrInvalidArgument,
+		fmt.Sprintf("provider %s does not support --endpoint-access", name),
+	).WithField("provider", name).
+		WithDetail("endpoint access is supported by: " + strings.Join(supported, ", "))
+}
+
+// CheckTokenDuration returns an ErrInvalidArgument error when duration exceeds the
+// longest token lifetime the named provider can issue
+func CheckTokenDuration(name string, duration time.Duration) error {
+	limit := DefaultsOf(name).MaxDuration
+	if limit == 0 || duration <= limit {
+		return nil
+	}
+	return errors.New(
+		errors.ErrInvalidArgument,
+		fmt.Sprintf("token duration %s exceeds the %s maximum of %s", duration, name, limit),
+	).WithField("provider", name).
+		WithField("token_duration", duration.String()).
+		WithField("max_token_duration", limit.String()).
+		WithDetail(fmt.Sprintf("use --token-duration=%s or less", limit))
+}
+
+// New creates the named provider using its registered constructor
+func New(name string, cfg *Config, log logger.Logger) (Provider, error) {
+	registration, exists := registrationOf(name)
+	if !exists {
+		return nil, unsupportedProviderError(name)
+	}
+
+	if cfg == nil {
+		cfg = &Config{}
+	}
+	return registration.Constructor(cfg, log)
+}
+
+// Registered returns the names of the registered providers, sorted
+func Registered() []string {
+	registrationsMu.RLock()
+	defer registrationsMu.RUnlock()
+
+	names := make([]string, 0, len(registrations))
+	for name := range registrations {
+		names = append(names, name.String())
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// CheckRegistered returns the same error as New when no provider is registered under name.
+// Commands call it to reject an unsupported provider before validating provider-specific flags.
+func CheckRegistered(name string) error {
+	if _, exists := registrationOf(name); !exists {
+		return unsupportedProviderError(name)
+	}
+	return nil
+}
+
+func unsupportedProviderError(name string) error {
+	return errors.New(
+		errors.ErrProviderNotSupported,
+		fmt.Sprintf("unsupported provider: %s (must be one of: %s)", name, strings.Join(Registered(), ", ")),
+	).WithField("provider", name)
+}
@@ -0,0 +1,211 @@
+package provider
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+)
+
+// fakeProvider records the configuration it was constructed with
+type fakeProvider struct {
+	cfg *Config
+}
+
+func (p *fakeProvider) GetToken(ctx context.Context, opts GetTokenOptions) (*Token, error) {
+	return &Token{AccessToken: "fake"}, nil
+}
+
+func (p *fakeProvider) ValidateCredentials(ctx context.Context) error { return nil }
+
+func (p *fakeProvider) ValidateToken(token *Token) error { return nil }
+
+func (p *fakeProvider) RefreshToken(ctx context.Context, opts GetTokenOptions, currentToken *Token) (*Token, error) {
+	return p.GetToken(ctx, opts)
+}
+
+func (p *fakeProvider) Name() string { return "fake" }
+
+// registerFake registers a fakeProvider under name for the duration of the test, with
+// the rest of registration
+func registerFake(t *testing.T, name ProviderName, registration Registration) {
+	t.Helper()
+
+	registration.Constructor = func(cfg *Config, log logger.Logger) (Provider, error) {
+		return &fakeProvider{cfg: cfg}, nil
+	}
+	require.NoError(t, RegisterProvider(name, registration))
+	t.Cleanup(func() {
+		registrationsMu.Lock()
+		delete(registrations, name)
+		delete(defaultOverrides, name)
+		registrationsMu.Unlock()
+	})
+}
+
+func TestNew(t *testing.T) {
+	registerFake(t, "fake", Registration{})
+
+	prov, err := New("fake", &Config{Region: "us-east-1"}, logger.Nop())
+	require.NoError(t, err)
+	require.IsType(t, &fakeProvider{}, prov)
+	assert.Equal(t, "us-east-1", prov.(*fakeProvider).cfg.Region)
+
+	// A nil config is replaced with an empty one
+	prov, err = New("fake", nil, logger.Nop())
+	require.NoError(t, err)
+	assert.NotNil(t, prov.(*fakeProvider).cfg)
+}
+
+func TestNew_Unsupported(t *testing.T) {
+	registerFake(t, "fake", Registration{})
+
+	_, err := New("unknown", &Config{}, logger.Nop())
+
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, errors.ErrProviderNotSupported))
+	assert.Contains(t, err.Error(), "unsupported provider: unknown")
+	assert.Contains(t, err.Error(), "fake")
+
+	assert.Equal(t, err.Error(), CheckRegistered("unknown").Error())
+	assert.NoError(t, CheckRegistered("fake"))
+}
+
+func TestRegisterProvider(t *testing.T) {
+	registerFake(t, "fake-b", Registration{})
+	registerFake(t, "fake-a", Registration{})
+
+	assert.Subset(t, Registered(), []string{"fake-a", "fake-b"})
+	names := Registered()
+	assert.IsIncreasing(t, names)
+
+	tests := []struct {
+		name        string
+		provider    ProviderName
+		constructor Constructor
+		wantErrCode errors.ErrorCode
+	}{
+		{
+			name:     "duplicate name",
+			provider: "fake-a",
+			constructor: func(cfg *Config, log logger.Logger) (Provider, error) {
+				return nil, nil
+			},
+			wantErrCode: errors.ErrAlreadyExists,
+		},
+		{
+			name:     "empty name",
+			provider: "",
+			constructor: func(cfg *Config, log logger.Logger) (Provider, error) {
+				return nil, nil
+			},
+			wantErrCode: errors.ErrInvalidArgument,
+		},
+		{
+			name:        "nil constructor",
+			provider:    "fake-c",
+			wantErrCode: errors.ErrInvalidArgument,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := RegisterProvider(tt.provider, Registration{Constructor: tt.constructor})
+
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, tt.wantErrCode),
+				"expected error code %s, got %v", tt.wantErrCode, err)
+		})
+	}
+
+	assert.Panics(t, func() {
+		MustRegisterProvider("fake-a", Registration{Constructor: func(cfg *Config, log logger.Logger) (Provider, error) {
+			return nil, nil
+		}})
+	})
+}
+
+func TestRegisterProvider_Registration(t *testing.T) {
+	inputs := []Input{{Name: "region", RequiredFor: OperationClusterLookup}}
+	registerFake(t, "fake-registered", Registration{
+		ValidateOptions: func(opts GetTokenOptions) error {
+			return errors.New(errors.ErrInvalidArgument, "invalid cluster name "+opts.ClusterName)
+		},
+		Inputs: inputs,
+	})
+	registerFake(t, "fake-bare", Registration{})
+
+	inputs[0].Name = "changed"
+	assert.Equal(t, []Input{{Name: "region", RequiredFor: OperationClusterLookup}}, InputsOf("fake-registered"),
+		"the registered inputs are a copy")
+	assert.Empty(t, InputsOf("fake-bare"))
+
+	err := GetTokenOptions{ClusterName: "Bad_Name"}.Validate("fake-registered")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid cluster name Bad_Name")
+	assert.NoError(t, GetTokenOptions{ClusterName: "Bad_Name"}.Validate("fake-bare"), "without a validator only the cluster name is required")
+}
+
+func TestCheckAudienceSupported(t *testing.T) {
+	registerFake(t, "fake-bound", Registration{Capabilities: Capabilities{BoundAudience: true}})
+	registerFake(t, "fake-unbound", Registration{})
+
+	assert.True(t, CapabilitiesOf("fake-bound").BoundAudience)
+	assert.False(t, CapabilitiesOf("fake-unbound").BoundAudience)
+	assert.NoError(t, CheckAudienceSupported("fake-bound"))
+
+	err := CheckAudienceSupported("fake-unbound")
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, errors.ErrInvalidArgument))
+	assert.Contains(t, err.Error(), "provider fake-unbound does not support bound audiences")
+	var appErr *errors.Error
+	require.True(t, errors.As(err, &appErr))
+	assert.Equal(t, "fake-unbound", appErr.Fields["provider"])
+	assert.Contains(t, appErr.Detail, "fake-bound")
+	assert.NotContains(t, appErr.Detail, "fake-unbound")
+}
+
+func TestCheckEndpointAccessSupported(t *testing.T) {
+	registerFake(t, "fake-endpoints", Registration{Capabilities: Capabilities{EndpointAccess: true}})
+	registerFake(t, "fake-single-endpoint", Registration{})
+
+	assert.NoError(t, CheckEndpointAccessSupported("fake-endpoints"))
+
+	err := CheckEndpointAccessSupported("fake-single-endpoint")
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, errors.ErrInvalidArgument))
+	assert.Contains(t, err.Error(), "provider fake-single-endpoint does not support --endpoint-access")
+	var appErr *errors.Error
+	require.True(t, errors.As(err, &appErr))
+	assert.Contains(t, appErr.Detail, "fake-endpoints")
+	assert.NotContains(t, appErr.Detail, "fake-single-endpoint")
+}
+
+func TestCheckTokenDuration(t *testing.T) {
+	registerFake(t, "fake-capped", Registration{Defaults: Defaults{MaxDuration: 15 * time.Minute}})
+	registerFake(t, "fake-uncapped", Registration{})
+
+	assert.NoError(t, CheckTokenDuration("fake-capped", 15*time.Minute), "the maximum itself is allowed")
+	assert.NoError(t, CheckTokenDuration("fake-uncapped", 24*time.Hour))
+
+	err := CheckTokenDuration("fake-capped", 16*time.Minute)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, errors.ErrInvalidArgument))
+	assert.Contains(t, err.Error(), "token duration 16m0s exceeds the fake-capped maximum of 15m0s")
+	var appErr *errors.Error
+	require.True(t, errors.As(err, &appErr))
+	assert.Equal(t, "15m0s", appErr.Fields["max_token_duration"])
+}
+
+func TestRefreshThresholdOf(t *testing.T) {
+	registerFake(t, "fake-threshold", Registration{Defaults: Defaults{RefreshThreshold: 5 * time.Minute}})
+	registerFake(t, "fake-default-threshold", Registration{})
+
+	assert.Equal(t, 5*time.Minute, RefreshThresholdOf("fake-threshold"))
+	assert.Equal(t, DefaultRefreshThreshold, RefreshThresholdOf("fake-default-threshold"))
+}
@@ -0,0 +1,50 @@
+package provider
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/credentials"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/metrics"
+)
+
+// NewCredentialLoader returns the credentials loader of a provider. With watch the
+// loader keeps the loaded credentials files and reloads them when they change on
+// disk, recording each reload in m when it is not nil; the files are then watched until
+// CloseCredentialLoader, which suits serve and refresh --loop. httpClient carries the
+// requests of secret sources such as Vault. opts are applied after those options.
+func NewCredentialLoader(log logger.Logger, strictPermissions, watch bool, m *metrics.Metrics, httpClient *http.Client, opts ...credentials.LoaderOption) (credentials.Loader, error) {
+	loader := credentials.NewLoader(log, append([]credentials.LoaderOption{
+		credentials.WithStrictPermissions(strictPermissions),
+		credentials.WithHTTPClient(httpClient),
+	}, opts...)...)
+	if !watch {
+		return loader, nil
+	}
+
+	// A nil *metrics.Metrics must not become a non-nil observer
+	var observer credentials.ReloadObserver
+	if m != nil {
+		observer = m
+	}
+	return credentials.NewWatcher(loader, log, observer)
+}
+
+// CloseCredentialLoader stops watching the credentials files of a loader created with
+// watch; other loaders hold nothing to close
+func CloseCredentialLoader(loader credentials.Loader) error {
+	if closer, ok := loader.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// Close releases what p holds, such as the credentials file watcher of
+// Config.WatchCredentials. Providers that hold nothing need no closing.
+func Close(p Provider) error {
+	if closer, ok := p.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package provider
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/config"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+)
+
+// DefaultTokenDuration is the token lifetime of providers that register none
+const DefaultTokenDuration = time.Hour
+
+// Defaults are the token lifetime settings of a provider. Provider packages register
+// their built-in defaults from init; operators can override them fleet-wide with
+// OverrideDefaults, and --token-duration still takes precedence over both.
+type Defaults struct {
+	// TokenDuration is the token lifetime used when none is configured.
+	// Zero uses DefaultTokenDuration.
+	TokenDuration time.Duration
+
+	// RefreshThreshold is the remaining lifetime at which tokens are refreshed.
+	// Zero uses DefaultRefreshThreshold.
+	RefreshThreshold time.Duration
+
+	// MaxDuration is the longest token lifetime the cloud issues, which token
+	// durations must not exceed. Zero means no limit.
+	MaxDuration time.Duration
+}
+
+// defaultOverrides are the defaults set with OverrideDefaults, guarded by registrationsMu
+var defaultOverrides = make(map[ProviderName]Defaults)
+
+// DefaultsOf returns the token lifetime settings of the named provider: its registered
+// defaults with any override applied and unset fields filled in
+func DefaultsOf(name string) Defaults {
+	registrationsMu.RLock()
+	d := mergeDefaults(registrations[ProviderName(name)].Defaults, defaultOverrides[ProviderName(name)])
+	registrationsMu.RUnlock()
+
+	if d.TokenDuration == 0 {
+		d.TokenDuration = DefaultTokenDuration
+	}
+	if d.RefreshThreshold == 0 {
+		d.RefreshThreshold = DefaultRefreshThreshold
+	}
+	return d
+}
+
+// OverrideDefaults replaces the registered defaults of the named provider with the
+// non-zero fields of override. MaxDuration can only be lowered, since it is a limit
+// of the cloud. The result must pass Validate; an empty override restores the
+// registered defaults.
+func OverrideDefaults(name string, override Defaults) error {
+	registrationsMu.Lock()
+	defer registrationsMu.Unlock()
+
+	registration, exists := registrations[ProviderName(name)]
+	if !exists {
+		return errors.New(
+			errors.ErrConfigInvalid,
+			fmt.Sprintf("cannot override defaults of unknown provider %s", name),
+		).WithField("provider", name)
+	}
+
+	registered := registration.Defaults
+	if override.MaxDuration < 0 || (registered.MaxDuration > 0 && override.MaxDuration > registered.MaxDuration) {
+		return errors.New(
+			errors.ErrConfigInvalid,
+			fmt.Sprintf("max token duration %s of provider %s must be positive and at most %s",
+				override.MaxDuration, name, registered.MaxDuration),
+		).WithField("provider", name)
+	}
+
+	if err := mergeDefaults(registered, override).Validate(name); err != nil {
+		return err
+	}
+
+	defaultOverrides[ProviderName(name)] = override
+	return nil
+}
+
+// ApplyConfigDefaults overrides the defaults of every provider named in the defaults
+// section of a config file, and restores the registered defaults of the others
+func ApplyConfigDefaults(overrides map[string]config.ProviderDefaults) error {
+	names := make([]string, 0, len(overrides))
+	for name := range overrides {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := OverrideDefaults(name, Defaults{
+			TokenDuration:    overrides[name].TokenDuration,
+			RefreshThreshold: overrides[name].RefreshThreshold,
+		}); err != nil {
+			return err
+		}
+	}
+	for _, name := range Registered() {
+		if _, overridden := overrides[name]; !overridden {
+			if err := OverrideDefaults(name, Defaults{}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Validate returns an ErrConfigInvalid error when the settings are inconsistent: the
+// refresh threshold plus the ClockSkew tolerance must be shorter than the token
+// duration, which must not exceed the maximum. Unset fields are checked with the
+// values DefaultsOf fills in.
+func (d Defaults) Validate(name string) error {
+	return d.validate(name, ClockSkew())
+}
+
+// validate implements Validate with the clock skew tolerance skew
+func (d Defaults) validate(name string, skew time.Duration) error {
+	duration := d.TokenDuration
+	if duration == 0 {
+		duration = DefaultTokenDuration
+	}
+	threshold := d.RefreshThreshold
+	if threshold == 0 {
+		threshold = DefaultRefreshThreshold
+	}
+
+	var problem string
+	switch {
+	case duration < 0 || threshold < 0:
+		problem = "token duration and refresh threshold must be positive"
+	case threshold >= duration:
+		problem = fmt.Sprintf("refresh threshold %s must be shorter than the token duration %s", threshold, duration)
+	case threshold+skew >= duration:
+		problem = fmt.Sprintf("refresh threshold %s plus the clock skew tolerance %s must be shorter than the token duration %s",
+			threshold, skew, duration)
+	case d.MaxDuration > 0 && duration > d.MaxDuration:
+		problem = fmt.Sprintf("token duration %s exceeds the maximum of %s", duration, d.MaxDuration)
+	default:
+		return nil
+	}
+	return errors.New(
+		errors.ErrConfigInvalid,
+		fmt.Sprintf("invalid %s defaults: %s", name, problem),
+	).WithField("provider", name).
+		WithField("token_duration", duration.String()).
+		WithField("refresh_threshold", threshold.String()).
+		WithField("clock_skew", skew.String())
+}
+
+// mergeDefaults returns base with the non-zero fields of override applied
+func mergeDefaults(base, override Defaults) Defaults {
+	if override.TokenDuration != 0 {
+		base.TokenDuration = override.TokenDuration
+	}
+	if override.RefreshThreshold != 0 {
+		base.RefreshThreshold = override.RefreshThreshold
+	}
+	if override.MaxDuration != 0 {
+		base.MaxDuration = override.MaxDuration
+	}
+	return base
+}
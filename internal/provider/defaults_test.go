@@ -0,0 +1,133 @@
+package provider
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/config"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+)
+
+func TestDefaultsOf(t *testing.T) {
+	registerFake(t, "fake-defaults", Registration{Defaults: Defaults{TokenDuration: 15 * time.Minute, RefreshThreshold: 2 * time.Minute, MaxDuration: 15 * time.Minute}})
+	registerFake(t, "fake-no-defaults", Registration{})
+
+	assert.Equal(t, Defaults{TokenDuration: 15 * time.Minute, RefreshThreshold: 2 * time.Minute, MaxDuration: 15 * time.Minute},
+		DefaultsOf("fake-defaults"))
+	assert.Equal(t, Defaults{TokenDuration: DefaultTokenDuration, RefreshThreshold: DefaultRefreshThreshold},
+		DefaultsOf("fake-no-defaults"), "unset fields are filled in")
+}
+
+func TestOverrideDefaults(t *testing.T) {
+	registerFake(t, "fake-tunable", Registration{Defaults: Defaults{TokenDuration: time.Hour, RefreshThreshold: 5 * time.Minute, MaxDuration: time.Hour}})
+
+	require.NoError(t, OverrideDefaults("fake-tunable", Defaults{TokenDuration: 30 * time.Minute}))
+	assert.Equal(t, Defaults{TokenDuration: 30 * time.Minute, RefreshThreshold: 5 * time.Minute, MaxDuration: time.Hour},
+		DefaultsOf("fake-tunable"), "unset override fields keep the registered defaults")
+	assert.Equal(t, 5*time.Minute, RefreshThresholdOf("fake-tunable"))
+
+	require.NoError(t, OverrideDefaults("fake-tunable", Defaults{TokenDuration: 30 * time.Minute, MaxDuration: 45 * time.Minute}))
+	assert.NoError(t, CheckTokenDuration("fake-tunable", 45*time.Minute))
+	assert.Error(t, CheckTokenDuration("fake-tunable", 46*time.Minute), "a lowered maximum applies to --token-duration")
+
+	require.NoError(t, OverrideDefaults("fake-tunable", Defaults{}))
+	assert.Equal(t, time.Hour, DefaultsOf("fake-tunable").TokenDuration, "an empty override restores the registered defaults")
+}
+
+func TestOverrideDefaults_Invalid(t *testing.T) {
+	registerFake(t, "fake-strict", Registration{Defaults: Defaults{TokenDuration: 15 * time.Minute, RefreshThreshold: 2 * time.Minute, MaxDuration: 15 * time.Minute}})
+
+	tests := []struct {
+		name     string
+		provider string
+		override Defaults
+		wantMsg  string
+	}{
+		{
+			name:     "threshold equal to duration",
+			provider: "fake-strict",
+			override: Defaults{TokenDuration: 5 * time.Minute, RefreshThreshold: 5 * time.Minute},
+			wantMsg:  "refresh threshold 5m0s must be shorter than the token duration 5m0s",
+		},
+		{
+			name:     "threshold above the registered duration",
+			provider: "fake-strict",
+			override: Defaults{RefreshThreshold: 20 * time.Minute},
+			wantMsg:  "refresh threshold 20m0s must be shorter than the token duration 15m0s",
+		},
+		{
+			name:     "threshold within the clock skew of the duration",
+			provider: "fake-strict",
+			override: Defaults{TokenDuration: 5 * time.Minute, RefreshThreshold: 4*time.Minute + 30*time.Second},
+			wantMsg:  "refresh threshold 4m30s plus the clock skew tolerance 1m0s must be shorter than the token duration 5m0s",
+		},
+		{
+			name:     "duration above the maximum",
+			provider: "fake-strict",
+			override: Defaults{TokenDuration: 30 * time.Minute},
+			wantMsg:  "token duration 30m0s exceeds the maximum of 15m0s",
+		},
+		{
+			name:     "raised maximum",
+			provider: "fake-strict",
+			override: Defaults{MaxDuration: time.Hour},
+			wantMsg:  "max token duration 1h0m0s of provider fake-strict must be positive and at most 15m0s",
+		},
+		{
+			name:     "negative duration",
+			provider: "fake-strict",
+			override: Defaults{TokenDuration: -time.Minute},
+			wantMsg:  "token duration and refresh threshold must be positive",
+		},
+		{
+			name:     "unknown provider",
+			provider: "fake-missing",
+			override: Defaults{TokenDuration: time.Minute},
+			wantMsg:  "cannot override defaults of unknown provider fake-missing",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := OverrideDefaults(tt.provider, tt.override)
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, errors.ErrConfigInvalid))
+			assert.Contains(t, err.Error(), tt.wantMsg)
+			assert.Equal(t, 15*time.Minute, DefaultsOf("fake-strict").TokenDuration, "a rejected override is not applied")
+		})
+	}
+}
+
+func TestSetClockSkew_Defaults(t *testing.T) {
+	registerFake(t, "fake-short", Registration{Defaults: Defaults{TokenDuration: 4 * time.Minute, RefreshThreshold: time.Minute}})
+	t.Cleanup(func() { require.NoError(t, SetClockSkew(DefaultClockSkew)) })
+
+	require.NoError(t, SetClockSkew(2*time.Minute))
+
+	err := SetClockSkew(3 * time.Minute)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, errors.ErrConfigInvalid))
+	assert.Contains(t, err.Error(), "invalid fake-short defaults: refresh threshold 1m0s plus the clock skew tolerance 3m0s must be shorter than the token duration 4m0s")
+	assert.Equal(t, 2*time.Minute, ClockSkew(), "a rejected skew leaves the tolerance unchanged")
+}
+
+func TestApplyConfigDefaults(t *testing.T) {
+	registerFake(t, "fake-file-a", Registration{})
+	registerFake(t, "fake-file-b", Registration{Defaults: Defaults{TokenDuration: time.Hour}})
+
+	require.NoError(t, OverrideDefaults("fake-file-b", Defaults{TokenDuration: 10 * time.Minute}))
+	require.NoError(t, ApplyConfigDefaults(map[string]config.ProviderDefaults{
+		"fake-file-a": {TokenDuration: 30 * time.Minute, RefreshThreshold: 3 * time.Minute},
+	}))
+
+	assert.Equal(t, 30*time.Minute, DefaultsOf("fake-file-a").TokenDuration)
+	assert.Equal(t, 3*time.Minute, RefreshThresholdOf("fake-file-a"))
+	assert.Equal(t, time.Hour, DefaultsOf("fake-file-b").TokenDuration, "providers missing from the file are restored")
+
+	err := ApplyConfigDefaults(map[string]config.ProviderDefaults{"fake-file-missing": {TokenDuration: time.Minute}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown provider fake-file-missing")
+}
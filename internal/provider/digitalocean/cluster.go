@@ -0,0 +1,250 @@
+package digitalocean
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/tracing"
+)
+
+// clusterIDPattern matches DOKS cluster IDs, which are UUIDs
+var clusterIDPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+
+// ClusterInfo contains DOKS cluster information
+type ClusterInfo struct {
+	// Endpoint is the cluster API server endpoint (with https://)
+	Endpoint string
+
+	// CertificateAuthority is the base64-encoded cluster CA certificate
+	CertificateAuthority string
+
+	// Version is the DOKS version slug (e.g. 1.31.1-do.5)
+	Version string
+
+	// Region is the DigitalOcean region slug
+	Region string
+
+	// ClusterID is the cluster UUID
+	ClusterID string
+}
+
+// doksCluster is the subset of the Kubernetes cluster resource that is used
+type doksCluster struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Region   string `json:"region"`
+	Version  string `json:"version"`
+	Endpoint string `json:"endpoint"`
+}
+
+// apiClient calls the DigitalOcean API with a bearer token
+type apiClient struct {
+	httpClient *http.Client
+	baseURL    string
+	token      string
+	tracing    *tracing.Provider
+}
+
+// GetClusterInfo retrieves the endpoint, CA and version of a DOKS cluster. The cluster
+// may be given by ID or by name; cluster names are unique within an account.
+func (p *Provider) GetClusterInfo(ctx context.Context, clusterName string) (*ClusterInfo, error) {
+	ctx, span := provider.StartSpan(ctx, p.config.Tracing, provider.ProviderDigitalOcean, "GetClusterInfo", clusterName, "")
+	info, err := p.getClusterInfo(ctx, clusterName)
+	provider.EndSpan(ctx, span, err)
+	return info, err
+}
+
+// getClusterInfo is GetClusterInfo without tracing
+func (p *Provider) getClusterInfo(ctx context.Context, clusterName string) (*ClusterInfo, error) {
+	p.logger.Info("Getting DOKS cluster info",
+		logger.String("cluster", clusterName),
+	)
+
+	client, err := p.newAPIClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cluster, err := client.findCluster(ctx, clusterName)
+	if err != nil {
+		p.logger.Error("Failed to get cluster",
+			logger.String("cluster", clusterName),
+			logger.Error(err),
+		)
+		return nil, err
+	}
+
+	// The credentials endpoint is the only one that returns the cluster CA
+	var creds struct {
+		Server                   string `json:"server"`
+		CertificateAuthorityData string `json:"certificate_authority_data"`
+	}
+	if err := client.get(ctx, "/v2/kubernetes/clusters/"+cluster.ID+"/credentials", &creds); err != nil {
+		return nil, err
+	}
+	if err := provider.ValidateCertificateAuthority(creds.CertificateAuthorityData); err != nil {
+		p.logger.Error("Cluster returned an invalid CA certificate",
+			logger.String("cluster", clusterName),
+			logger.Error(err),
+		)
+		return nil, err
+	}
+
+	endpoint := creds.Server
+	if endpoint == "" {
+		endpoint = cluster.Endpoint
+	}
+	if endpoint == "" {
+		return nil, errors.New(errors.ErrClusterInvalidConfig, "DOKS cluster has no API server endpoint").
+			WithField("cluster", clusterName)
+	}
+
+	info := &ClusterInfo{
+		Endpoint:             endpoint,
+		CertificateAuthority: creds.CertificateAuthorityData,
+		Version:              cluster.Version,
+		Region:               cluster.Region,
+		ClusterID:            cluster.ID,
+	}
+
+	provider.LoggerWithBaggage(ctx, p.config.Tracing, p.logger).Info("Successfully retrieved cluster info",
+		logger.String("cluster", clusterName),
+		logger.String("endpoint", info.Endpoint),
+		logger.String("version", info.Version),
+		logger.String("region", info.Region),
+	)
+
+	return info, nil
+}
+
+// newAPIClient loads the API token and returns a client that sends it
+func (p *Provider) newAPIClient(ctx context.Context) (*apiClient, error) {
+	creds, err := p.credLoader.LoadDigitalOcean(ctx, p.doCredOpts)
+	if err != nil {
+		p.logger.Error("Failed to load DigitalOcean credentials", logger.Error(err))
+		return nil, err
+	}
+
+	return &apiClient{
+		httpClient: p.httpClient,
+		baseURL:    p.apiURL,
+		token:      creds.Token,
+		tracing:    p.config.Tracing,
+	}, nil
+}
+
+// findCluster returns the cluster with the given ID, or lists the account's
+// clusters page by page to find it by name
+func (c *apiClient) findCluster(ctx context.Context, name string) (*doksCluster, error) {
+	if clusterIDPattern.MatchString(name) {
+		var resp struct {
+			Cluster doksCluster `json:"kubernetes_cluster"`
+		}
+		if err := c.get(ctx, "/v2/kubernetes/clusters/"+name, &resp); err != nil {
+			return nil, err
+		}
+		return &resp.Cluster, nil
+	}
+
+	path := "/v2/kubernetes/clusters?per_page=200"
+	for path != "" {
+		var page struct {
+			Clusters []doksCluster `json:"kubernetes_clusters"`
+			Links    struct {
+				Pages struct {
+					Next string `json:"next"`
+				} `json:"pages"`
+			} `json:"links"`
+		}
+		if err := c.get(ctx, path, &page); err != nil {
+			return nil, err
+		}
+
+		for i := range page.Clusters {
+			if page.Clusters[i].Name == name {
+				return &page.Clusters[i], nil
+			}
+		}
+
+		path = ""
+		if next := page.Links.Pages.Next; next != "" {
+			nextURL, err := url.Parse(next)
+			if err != nil {
+				return nil, errors.Wrap(errors.ErrInternal, err, "invalid DigitalOcean pagination link")
+			}
+			path = nextURL.RequestURI()
+		}
+	}
+
+	return nil, errors.New(
+		errors.ErrClusterNotFound,
+		fmt.Sprintf("DOKS cluster %s not found", name),
+	).WithField("cluster", name)
+}
+
+// get sends an authenticated GET request and decodes the JSON response into out
+func (c *apiClient) get(ctx context.Context, path string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		return errors.Wrap(errors.ErrInternal, err, "failed to build DigitalOcean request")
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Accept", "application/json")
+	c.tracing.InjectBaggage(ctx, req.Header)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return errors.Wrap(errors.ErrNetworkUnreachable, err, "DigitalOcean request failed").
+			WithField("url", c.baseURL+path)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrap(errors.ErrNetworkUnreachable, err, "failed to read DigitalOcean response")
+	}
+	if resp.StatusCode >= 300 {
+		return apiError(resp.StatusCode, data)
+	}
+
+	if err := json.Unmarshal(data, out); err != nil {
+		return errors.Wrap(errors.ErrInternal, err, "failed to decode DigitalOcean response")
+	}
+	return nil
+}
+
+// apiError maps a DigitalOcean API error response to an error code
+func apiError(status int, body []byte) error {
+	var payload struct {
+		ID      string `json:"id"`
+		Message string `json:"message"`
+	}
+	_ = json.Unmarshal(body, &payload)
+
+	code := errors.ErrInternal
+	switch status {
+	case http.StatusUnauthorized:
+		code = errors.ErrUnauthenticated
+	case http.StatusForbidden:
+		code = errors.ErrPermissionDenied
+	case http.StatusNotFound:
+		code = errors.ErrClusterNotFound
+	}
+
+	message := payload.Message
+	if message == "" {
+		message = http.StatusText(status)
+	}
+	return errors.New(code, "DigitalOcean API error: "+message).WithFields(map[string]interface{}{
+		"status": status,
+		"do_id":  payload.ID,
+	})
+}
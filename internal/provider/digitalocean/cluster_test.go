@@ -0,0 +1,240 @@
+package digitalocean
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/credentials"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/testutil"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/tracing"
+)
+
+const testClusterID = "bd5f5959-5e1e-4205-a714-a914373942af"
+
+// testCA returns a base64-encoded self-signed CA certificate
+func testCA(t *testing.T) string {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "doks-cluster-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	return base64.StdEncoding.EncodeToString(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+// fakeDigitalOceanAPI serves two pages of clusters, with my-cluster on the second
+func fakeDigitalOceanAPI(t *testing.T, ca string, requests *[]string) *httptest.Server {
+	var server *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v2/kubernetes/clusters", func(w http.ResponseWriter, r *http.Request) {
+		*requests = append(*requests, r.URL.RequestURI())
+		if r.URL.Query().Get("page") == "2" {
+			fmt.Fprintf(w, `{"kubernetes_clusters":[{"id":%q,"name":"my-cluster","region":"nyc1","version":"1.31.1-do.5","endpoint":"https://bd5f5959.k8s.ondigitalocean.com"}],"links":{}}`, testClusterID)
+			return
+		}
+		fmt.Fprintf(w, `{"kubernetes_clusters":[{"id":"other","name":"other-cluster"}],"links":{"pages":{"next":"%s/v2/kubernetes/clusters?page=2&per_page=200"}}}`, server.URL)
+	})
+	mux.HandleFunc("/v2/kubernetes/clusters/"+testClusterID, func(w http.ResponseWriter, r *http.Request) {
+		*requests = append(*requests, r.URL.RequestURI())
+		fmt.Fprintf(w, `{"kubernetes_cluster":{"id":%q,"name":"my-cluster","region":"nyc1","version":"1.31.1-do.5","endpoint":"https://bd5f5959.k8s.ondigitalocean.com"}}`, testClusterID)
+	})
+	mux.HandleFunc("/v2/kubernetes/clusters/"+testClusterID+"/credentials", func(w http.ResponseWriter, r *http.Request) {
+		*requests = append(*requests, r.URL.RequestURI())
+		fmt.Fprintf(w, `{"server":"https://bd5f5959.k8s.ondigitalocean.com","certificate_authority_data":%q,"token":"short-lived","expires_at":"2030-01-08T00:00:00Z"}`, ca)
+	})
+
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+testToken {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"id":"Unauthorized","message":"Unable to authenticate you"}`)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// newTestProvider returns a provider that calls server with token
+func newTestProvider(t *testing.T, server *httptest.Server, token string) *Provider {
+	t.Helper()
+
+	p, err := NewProvider(nil, logger.Nop())
+	require.NoError(t, err)
+	p.credLoader = testutil.NewMockCredLoader().WithDigitalOceanCreds(&credentials.DigitalOceanCredentials{Token: token})
+	p.httpClient = server.Client()
+	p.apiURL = server.URL
+	return p
+}
+
+func TestGetClusterInfo(t *testing.T) {
+	ca := testCA(t)
+
+	tests := []struct {
+		name         string
+		cluster      string
+		token        string
+		wantRequests []string
+		wantCode     errors.ErrorCode
+	}{
+		{
+			name:    "by name across pages",
+			cluster: "my-cluster",
+			token:   testToken,
+			wantRequests: []string{
+				"/v2/kubernetes/clusters?per_page=200",
+				"/v2/kubernetes/clusters?page=2&per_page=200",
+				"/v2/kubernetes/clusters/" + testClusterID + "/credentials",
+			},
+		},
+		{
+			name:    "by ID",
+			cluster: testClusterID,
+			token:   testToken,
+			wantRequests: []string{
+				"/v2/kubernetes/clusters/" + testClusterID,
+				"/v2/kubernetes/clusters/" + testClusterID + "/credentials",
+			},
+		},
+		{
+			name:    "name not found",
+			cluster: "missing",
+			token:   testToken,
+			wantRequests: []string{
+				"/v2/kubernetes/clusters?per_page=200",
+				"/v2/kubernetes/clusters?page=2&per_page=200",
+			},
+			wantCode: errors.ErrClusterNotFound,
+		},
+		{
+			name:     "rejected token",
+			cluster:  "my-cluster",
+			token:    "dop_v1_revoked",
+			wantCode: errors.ErrUnauthenticated,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests []string
+			p := newTestProvider(t, fakeDigitalOceanAPI(t, ca, &requests), tt.token)
+
+			info, err := p.GetClusterInfo(context.Background(), tt.cluster)
+
+			assert.Equal(t, tt.wantRequests, requests)
+			if tt.wantCode != "" {
+				assert.True(t, errors.Is(err, tt.wantCode), "got %v", err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "https://bd5f5959.k8s.ondigitalocean.com", info.Endpoint)
+			assert.Equal(t, ca, info.CertificateAuthority)
+			assert.Equal(t, "1.31.1-do.5", info.Version)
+			assert.Equal(t, "nyc1", info.Region)
+			assert.Equal(t, testClusterID, info.ClusterID)
+		})
+	}
+}
+
+func TestGetClusterInfo_PropagatesBaggage(t *testing.T) {
+	ca := testCA(t)
+	var requests []string
+	api := fakeDigitalOceanAPI(t, ca, &requests)
+
+	var received []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = append(received, r.Header.Get("baggage"))
+		proxy, err := http.NewRequestWithContext(r.Context(), r.Method, api.URL+r.URL.RequestURI(), nil)
+		require.NoError(t, err)
+		proxy.Header = r.Header
+		resp, err := api.Client().Do(proxy)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		w.WriteHeader(resp.StatusCode)
+		io.Copy(w, resp.Body)
+	}))
+	defer server.Close()
+
+	exporter := tracetest.NewInMemoryExporter()
+	p := newTestProvider(t, server, testToken)
+	p.config.Tracing = tracing.NewProviderFromTracerProvider(
+		sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)),
+		tracing.DefaultConfig(),
+	)
+
+	bag, err := baggage.Parse("hyperfleet.cluster=spoke-1,hyperfleet.fleet=eu,session=abc")
+	require.NoError(t, err)
+	ctx := baggage.ContextWithBaggage(context.Background(), bag)
+
+	_, err = p.GetClusterInfo(ctx, testClusterID)
+	require.NoError(t, err)
+
+	require.Len(t, received, 2)
+	for _, header := range received {
+		assert.Equal(t, "hyperfleet.cluster=spoke-1,hyperfleet.fleet=eu", header)
+	}
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	assert.Subset(t, spans[0].Attributes, []attribute.KeyValue{
+		attribute.String("hyperfleet.cluster", "spoke-1"),
+		attribute.String("hyperfleet.fleet", "eu"),
+	})
+	assert.NotContains(t, spans[0].Attributes, attribute.String("session", "abc"))
+}
+
+func TestGetClusterInfo_InvalidCA(t *testing.T) {
+	var requests []string
+	p := newTestProvider(t, fakeDigitalOceanAPI(t, base64.StdEncoding.EncodeToString([]byte("not a certificate")), &requests), testToken)
+
+	_, err := p.GetClusterInfo(context.Background(), testClusterID)
+
+	assert.True(t, errors.Is(err, errors.ErrClusterInvalidConfig), "got %v", err)
+}
+
+func TestValidateCredentials(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/account" || r.Header.Get("Authorization") != "Bearer "+testToken {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{"account":{"email":"dev@example.com","status":"active"}}`)
+	}))
+	t.Cleanup(server.Close)
+
+	assert.NoError(t, newTestProvider(t, server, testToken).ValidateCredentials(context.Background()))
+
+	err := newTestProvider(t, server, "dop_v1_revoked").ValidateCredentials(context.Background())
+	assert.True(t, errors.Is(err, errors.ErrCredentialValidationFailed), "got %v", err)
+}
@@ -0,0 +1,168 @@
+package digitalocean
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/credentials"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+)
+
+// apiTimeout bounds each DigitalOcean API call
+const apiTimeout = 30 * time.Second
+
+// Provider implements the DigitalOcean token provider
+type Provider struct {
+	config     *Config
+	logger     logger.Logger
+	credLoader credentials.Loader
+	doCredOpts credentials.DigitalOceanCredentialOptions
+	httpClient *http.Client
+	apiURL     string
+	now        func() time.Time
+}
+
+// NewProvider creates a new DigitalOcean provider
+func NewProvider(config *Config, log logger.Logger) (*Provider, error) {
+	if config == nil {
+		config = DefaultConfig()
+	}
+
+	credLoader := credentials.NewLoader(log,
+		credentials.WithStrictPermissions(config.StrictPermissions),
+		credentials.WithHTTPClient(config.HTTPClient),
+	)
+
+	doCredOpts := credentials.DigitalOceanCredentialOptions{
+		CredentialsFile: config.CredentialsFile,
+		UseEnvironment:  true,
+	}
+
+	return &Provider{
+		config:     config,
+		logger:     log,
+		credLoader: credLoader,
+		doCredOpts: doCredOpts,
+		httpClient: newHTTPClient(config),
+		apiURL:     defaultAPIURL,
+		now:        time.Now,
+	}, nil
+}
+
+// newHTTPClient returns the client of the DigitalOcean API calls, which uses the configured
+// transport, if any, and is bounded by apiTimeout
+func newHTTPClient(config *Config) *http.Client {
+	client := &http.Client{Timeout: apiTimeout}
+	if config.HTTPClient != nil {
+		client.Transport = config.HTTPClient.Transport
+	}
+	return client
+}
+
+// GetToken returns the DigitalOcean API token, which DOKS accepts directly as a bearer token
+func (p *Provider) GetToken(ctx context.Context, opts provider.GetTokenOptions) (*provider.Token, error) {
+	ctx, span := provider.StartSpan(ctx, p.config.Tracing, provider.ProviderDigitalOcean, "GetToken", opts.ClusterName, "")
+	token, err := p.getToken(ctx, opts)
+	provider.EndSpan(ctx, span, err)
+	return token, err
+}
+
+// getToken is GetToken without tracing
+func (p *Provider) getToken(ctx context.Context, opts provider.GetTokenOptions) (*provider.Token, error) {
+	if opts.ClusterName == "" {
+		return nil, errors.New(
+			errors.ErrInvalidArgument,
+			"cluster name is required",
+		).WithField("provider", "digitalocean")
+	}
+
+	provider.LoggerWithBaggage(ctx, p.config.Tracing, p.logger).Info("Getting DigitalOcean token",
+		logger.String("cluster", opts.ClusterName),
+	)
+
+	creds, err := p.credLoader.LoadDigitalOcean(ctx, p.doCredOpts)
+	if err != nil {
+		p.logger.Error("Failed to load DigitalOcean credentials",
+			logger.String("cluster", opts.ClusterName),
+			logger.Error(err),
+		)
+		return nil, err
+	}
+
+	// The API token is opaque, so the account it belongs to is not known without a call
+	return &provider.Token{
+		AccessToken: creds.Token,
+		ExpiresAt:   p.now().Add(p.config.TokenDuration),
+		TokenType:   "Bearer",
+		Provider:    provider.ProviderDigitalOcean.String(),
+		ClusterName: opts.ClusterName,
+	}, nil
+}
+
+// ValidateCredentials validates the DigitalOcean API token by reading the account it belongs to
+func (p *Provider) ValidateCredentials(ctx context.Context) error {
+	p.logger.Debug("Validating DigitalOcean credentials")
+
+	client, err := p.newAPIClient(ctx)
+	if err != nil {
+		return errors.Wrap(
+			errors.ErrCredentialValidationFailed,
+			err,
+			"failed to validate DigitalOcean credentials",
+		).WithField("provider", "digitalocean")
+	}
+
+	var account struct {
+		Account struct {
+			Email  string `json:"email"`
+			Status string `json:"status"`
+		} `json:"account"`
+	}
+	if err := client.get(ctx, "/v2/account", &account); err != nil {
+		return errors.Wrap(
+			errors.ErrCredentialValidationFailed,
+			err,
+			"DigitalOcean API rejected the token",
+		).WithField("provider", "digitalocean")
+	}
+
+	p.logger.Info("DigitalOcean credentials validated successfully",
+		logger.String("account_status", account.Account.Status),
+	)
+	return nil
+}
+
+// ValidateToken checks that a token is set and has not expired. DigitalOcean API tokens are opaque,
+// so the API server is left to judge the token itself.
+func (p *Provider) ValidateToken(token *provider.Token) error {
+	if token == nil || token.AccessToken == "" {
+		return errors.New(errors.ErrTokenInvalid, "token is empty").WithField("provider", "digitalocean")
+	}
+	if err := provider.CheckTokenIssuer(token, provider.ProviderDigitalOcean); err != nil {
+		return err
+	}
+	if token.IsExpiredWith(provider.ClockFunc(p.now)) {
+		return errors.New(
+			errors.ErrTokenExpired,
+			"token has expired",
+		).WithFields(map[string]interface{}{
+			"provider":   "digitalocean",
+			"expires_at": token.ExpiresAt.Format(time.RFC3339),
+		})
+	}
+	return nil
+}
+
+// RefreshToken returns currentToken while it is valid and outside the refresh
+// threshold, and otherwise gets a new token with GetToken
+func (p *Provider) RefreshToken(ctx context.Context, opts provider.GetTokenOptions, currentToken *provider.Token) (*provider.Token, error) {
+	return provider.RefreshIfNeeded(ctx, p, opts, currentToken, provider.ClockFunc(p.now), provider.RefreshThresholdOf(p.Name()))
+}
+
+// Name returns the provider name
+func (p *Provider) Name() string {
+	return "digitalocean"
+}
@@ -0,0 +1,96 @@
+package digitalocean
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/credentials"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/testutil"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+)
+
+const testToken = "dop_v1_0123456789abcdef"
+
+func TestGetToken(t *testing.T) {
+	now := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		opts      provider.GetTokenOptions
+		mock      *testutil.MockCredLoader
+		wantToken string
+		wantCode  errors.ErrorCode
+	}{
+		{
+			name:      "API token is the bearer token",
+			opts:      provider.GetTokenOptions{ClusterName: "my-cluster"},
+			mock:      testutil.NewMockCredLoader().WithDigitalOceanCreds(&credentials.DigitalOceanCredentials{Token: testToken}),
+			wantToken: testToken,
+		},
+		{
+			name:     "cluster name required",
+			opts:     provider.GetTokenOptions{},
+			mock:     testutil.NewMockCredLoader(),
+			wantCode: errors.ErrInvalidArgument,
+		},
+		{
+			name: "credential error",
+			opts: provider.GetTokenOptions{ClusterName: "my-cluster"},
+			mock: testutil.NewMockCredLoader().WithDigitalOceanError(
+				errors.New(errors.ErrCredentialNotFound, "DigitalOcean API token not found"),
+			),
+			wantCode: errors.ErrCredentialNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewProvider(&Config{TokenDuration: 30 * time.Minute}, logger.Nop())
+			require.NoError(t, err)
+			p.credLoader = tt.mock
+			p.now = func() time.Time { return now }
+
+			token, err := p.GetToken(context.Background(), tt.opts)
+
+			if tt.wantCode != "" {
+				assert.True(t, errors.Is(err, tt.wantCode), "got %v", err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantToken, token.AccessToken)
+			assert.Equal(t, "Bearer", token.TokenType)
+			assert.Equal(t, now.Add(30*time.Minute), token.ExpiresAt)
+			assert.Equal(t, "digitalocean", token.Provider)
+			assert.Equal(t, "my-cluster", token.ClusterName)
+			assert.Empty(t, token.Identity, "API tokens are opaque")
+		})
+	}
+}
+
+func TestNewFromConfig(t *testing.T) {
+	prov, err := provider.New("digitalocean", &provider.Config{TokenDuration: 10 * time.Minute}, logger.Nop())
+	require.NoError(t, err)
+
+	p, ok := prov.(*Provider)
+	require.True(t, ok)
+	assert.Equal(t, "digitalocean", p.Name())
+	assert.Equal(t, 10*time.Minute, p.config.TokenDuration)
+}
+
+func TestNewFromConfig_HTTPClient(t *testing.T) {
+	client := &http.Client{Transport: &http.Transport{}}
+	prov, err := provider.New("digitalocean", &provider.Config{HTTPClient: client}, logger.Nop())
+	require.NoError(t, err)
+
+	p, ok := prov.(*Provider)
+	require.True(t, ok)
+	assert.Same(t, client.Transport, p.httpClient.Transport)
+	assert.Equal(t, apiTimeout, p.httpClient.Timeout, "the API timeout is kept")
+}
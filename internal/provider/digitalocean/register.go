@@ -0,0 +1,45 @@
+package digitalocean
+
+import (
+	"context"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+)
+
+func init() {
+	provider.MustRegisterProvider(provider.ProviderDigitalOcean, provider.Registration{
+		Constructor:  newFromConfig,
+		Capabilities: provider.Capabilities{LookupAnyRegion: true},
+	})
+}
+
+// newFromConfig creates a DigitalOcean provider from the shared provider configuration
+func newFromConfig(cfg *provider.Config, log logger.Logger) (provider.Provider, error) {
+	config := DefaultConfig()
+	config.CredentialsFile = cfg.CredentialsFile
+	config.StrictPermissions = cfg.StrictPermissions
+	config.Tracing = cfg.Tracing
+	config.HTTPClient = cfg.HTTPClient
+	if cfg.TokenDuration > 0 {
+		config.TokenDuration = cfg.TokenDuration
+	}
+
+	return NewProvider(config, log)
+}
+
+// DescribeCluster implements provider.ClusterDescriber
+func (p *Provider) DescribeCluster(ctx context.Context, opts provider.ClusterInfoOptions) (*provider.ClusterInfo, error) {
+	info, err := p.GetClusterInfo(ctx, opts.ClusterName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &provider.ClusterInfo{
+		Endpoint:             info.Endpoint,
+		CertificateAuthority: info.CertificateAuthority,
+		Version:              info.Version,
+		Region:               info.Region,
+		ResourceID:           info.ClusterID,
+	}, nil
+}
@@ -0,0 +1,37 @@
+package digitalocean
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/tracing"
+)
+
+// defaultAPIURL is the DigitalOcean public API
+const defaultAPIURL = "https://api.digitalocean.com"
+
+// Config holds DigitalOcean provider configuration
+type Config struct {
+	CredentialsFile string
+
+	// TokenDuration is how long kubectl caches the token. The API token itself does
+	// not expire, so this only bounds how stale a revoked token can be.
+	TokenDuration time.Duration
+
+	// StrictPermissions rejects credentials files readable by group or others
+	StrictPermissions bool
+
+	// Tracing records spans for token generation and cluster lookups; nil disables tracing
+	Tracing *tracing.Provider
+
+	// HTTPClient provides the transport of the DigitalOcean API requests; nil uses the default
+	HTTPClient *http.Client
+}
+
+// DefaultConfig returns default DigitalOcean configuration
+func DefaultConfig() *Config {
+	return &Config{
+		TokenDuration: provider.DefaultsOf(string(provider.ProviderDigitalOcean)).TokenDuration,
+	}
+}
@@ -0,0 +1,74 @@
+package provider
+
+import (
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+)
+
+// EndpointAccess selects which API server endpoint of a cluster with both a public
+// and a private endpoint is used
+type EndpointAccess string
+
+const (
+	// EndpointAccessDefault uses the public endpoint when the cluster has one and the
+	// private endpoint otherwise
+	EndpointAccessDefault EndpointAccess = ""
+
+	// EndpointAccessPrivate uses the private endpoint, reachable from the cluster
+	// network, a peered network or over a VPN
+	EndpointAccessPrivate EndpointAccess = "private"
+
+	// EndpointAccessPublic uses the public endpoint
+	EndpointAccessPublic EndpointAccess = "public"
+)
+
+// ParseEndpointAccess parses the value of --endpoint-access
+func ParseEndpointAccess(value string) (EndpointAccess, error) {
+	switch access := EndpointAccess(value); access {
+	case EndpointAccessDefault, EndpointAccessPrivate, EndpointAccessPublic:
+		return access, nil
+	default:
+		return "", errors.New(errors.ErrInvalidArgument, "invalid endpoint access").
+			WithField("endpoint_access", value).
+			WithDetail("use private or public")
+	}
+}
+
+// SelectEndpoint returns the endpoint of a cluster picked by access from its public
+// and private endpoints, either of which may be empty when the cluster has none
+func SelectEndpoint(access EndpointAccess, public, private string) (string, error) {
+	switch access {
+	case EndpointAccessPrivate:
+		if private == "" {
+			return "", errors.New(errors.ErrClusterInvalidConfig, "cluster has no private endpoint").
+				WithDetail("enable private API server access or use --endpoint-access=public")
+		}
+		return private, nil
+	case EndpointAccessPublic:
+		if public == "" {
+			return "", errors.New(errors.ErrClusterInvalidConfig, "cluster has no public endpoint").
+				WithDetail("enable public API server access or use --endpoint-access=private")
+		}
+		return public, nil
+	default:
+		if public != "" {
+			return public, nil
+		}
+		if private == "" {
+			return "", errors.New(errors.ErrClusterInvalidConfig, "cluster endpoint is empty")
+		}
+		return private, nil
+	}
+}
+
+// ClusterEndpoint returns the endpoint of a cluster picked by the --endpoint-access value
+// access from its public and private endpoints, or defaultEndpoint when access is empty
+func ClusterEndpoint(access, defaultEndpoint, public, private string) (string, error) {
+	parsed, err := ParseEndpointAccess(access)
+	if err != nil {
+		return "", err
+	}
+	if parsed == EndpointAccessDefault {
+		return defaultEndpoint, nil
+	}
+	return SelectEndpoint(parsed, public, private)
+}
@@ -0,0 +1,72 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+)
+
+func TestParseEndpointAccess(t *testing.T) {
+	for _, value := range []string{"", "private", "public"} {
+		access, err := ParseEndpointAccess(value)
+		require.NoError(t, err)
+		assert.Equal(t, EndpointAccess(value), access)
+	}
+
+	_, err := ParseEndpointAccess("internal")
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, errors.ErrInvalidArgument))
+	assert.Contains(t, err.Error(), "invalid endpoint access")
+}
+
+func TestSelectEndpoint(t *testing.T) {
+	const public, private = "https://public.example.com", "https://private.example.com"
+
+	tests := []struct {
+		name    string
+		access  EndpointAccess
+		public  string
+		private string
+		want    string
+		wantErr string
+	}{
+		{name: "default prefers public", access: EndpointAccessDefault, public: public, private: private, want: public},
+		{name: "default falls back to private", access: EndpointAccessDefault, private: private, want: private},
+		{name: "default without endpoints", access: EndpointAccessDefault, wantErr: "cluster endpoint is empty"},
+		{name: "private", access: EndpointAccessPrivate, public: public, private: private, want: private},
+		{name: "private missing", access: EndpointAccessPrivate, public: public, wantErr: "cluster has no private endpoint"},
+		{name: "public", access: EndpointAccessPublic, public: public, private: private, want: public},
+		{name: "public missing", access: EndpointAccessPublic, private: private, wantErr: "cluster has no public endpoint"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SelectEndpoint(tt.access, tt.public, tt.private)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				assert.True(t, errors.Is(err, errors.ErrClusterInvalidConfig))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestClusterEndpoint(t *testing.T) {
+	got, err := ClusterEndpoint("", "https://default.example.com", "https://public.example.com", "")
+	require.NoError(t, err)
+	assert.Equal(t, "https://default.example.com", got, "no access keeps the provider default")
+
+	got, err = ClusterEndpoint("private", "https://public.example.com", "https://public.example.com", "https://private.example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "https://private.example.com", got)
+
+	_, err = ClusterEndpoint("internal", "https://public.example.com", "https://public.example.com", "")
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, errors.ErrInvalidArgument))
+}
@@ -43,6 +43,10 @@ func (f *Factory) CreateFromConfig(ctx context.Context, cfg *config.Config) (Pro
 		return nil, err
 	}
 
+	if err := ApplyConfigDefaults(cfg.Defaults); err != nil {
+		return nil, err
+	}
+
 	provider, err := f.registry.Create(ctx, providerName)
 	if err != nil {
 		return nil, err
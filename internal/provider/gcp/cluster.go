@@ -4,10 +4,13 @@ import (
 	"context"
 	"fmt"
 
+	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
 	"google.golang.org/api/container/v1"
 	"google.golang.org/api/option"
 
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
 	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
 )
 
@@ -16,6 +19,11 @@ type ClusterInfo struct {
 	// Endpoint is the cluster API server endpoint (without https://)
 	Endpoint string
 
+	// PublicEndpoint and PrivateEndpoint are the external and internal IP addresses
+	// of the control plane, each empty when the cluster does not have it
+	PublicEndpoint  string
+	PrivateEndpoint string
+
 	// CertificateAuthority is the base64-encoded cluster CA certificate
 	CertificateAuthority string
 
@@ -28,31 +36,54 @@ type ClusterInfo struct {
 
 // GetClusterInfo retrieves cluster information from GKE
 func (p *Provider) GetClusterInfo(ctx context.Context, clusterName, location string) (*ClusterInfo, error) {
+	ctx, span := provider.StartSpan(ctx, p.config.Tracing, provider.ProviderGCP, "GetClusterInfo", clusterName, location)
+	info, err := p.getClusterInfo(ctx, clusterName, location)
+	provider.EndSpan(ctx, span, err)
+	return info, err
+}
+
+// getClusterInfo is GetClusterInfo without tracing
+func (p *Provider) getClusterInfo(ctx context.Context, clusterName, location string) (*ClusterInfo, error) {
+	if err := validateClusterName(clusterName); err != nil {
+		return nil, err
+	}
+	if err := validateProjectID(p.config.ProjectID); err != nil {
+		return nil, err
+	}
+	if location == "" {
+		return nil, errors.New(
+			errors.ErrInvalidArgument,
+			"location is required",
+		).WithField("provider", "gcp").
+			WithDetail("set --region to the cluster region or zone")
+	}
+	locationType, err := ParseLocation(location)
+	if err != nil {
+		return nil, err
+	}
+
 	p.logger.Info("Getting GKE cluster info",
 		logger.String("cluster", clusterName),
 		logger.String("project", p.config.ProjectID),
 		logger.String("location", location),
+		logger.String("location_type", string(locationType)),
 	)
 
-	creds, err := p.credLoader.LoadGCP(ctx, p.config.CredentialsFile)
+	gcpCreds, projectID, err := p.clusterCredentials(ctx)
 	if err != nil {
 		p.logger.Error("Failed to load GCP credentials",
 			logger.String("cluster", clusterName),
 			logger.Error(err),
 		)
-		return nil, fmt.Errorf("failed to load GCP credentials: %w", err)
+		return nil, err
 	}
 
-	gcpCreds, err := google.CredentialsFromJSON(ctx, []byte(creds.RawJSON), container.CloudPlatformScope)
+	opts, err := p.serviceOptions(ctx, gcpCreds)
 	if err != nil {
-		p.logger.Error("Failed to create GCP credentials",
-			logger.String("cluster", clusterName),
-			logger.Error(err),
-		)
-		return nil, fmt.Errorf("failed to create GCP credentials: %w", err)
+		return nil, err
 	}
 
-	svc, err := container.NewService(ctx, option.WithCredentials(gcpCreds))
+	svc, err := container.NewService(ctx, opts...)
 	if err != nil {
 		p.logger.Error("Failed to create Container service",
 			logger.String("cluster", clusterName),
@@ -61,20 +92,24 @@ func (p *Provider) GetClusterInfo(ctx context.Context, clusterName, location str
 		return nil, fmt.Errorf("failed to create Container service: %w", err)
 	}
 
-	// Build cluster resource name
+	// Build cluster resource name; the location is a region for regional clusters
+	// and a zone for zonal clusters
 	// Format: projects/{project}/locations/{location}/clusters/{cluster}
 	name := fmt.Sprintf("projects/%s/locations/%s/clusters/%s",
-		creds.ProjectID, location, clusterName)
+		projectID, location, clusterName)
 
 	p.logger.Debug("Fetching cluster details",
 		logger.String("resource_name", name),
 	)
 
-	cluster, err := svc.Projects.Locations.Clusters.Get(name).Context(ctx).Do()
+	call := svc.Projects.Locations.Clusters.Get(name)
+	p.config.Tracing.InjectBaggage(ctx, call.Header())
+	cluster, err := call.Context(ctx).Do()
 	if err != nil {
 		p.logger.Error("Failed to get cluster info",
 			logger.String("cluster", clusterName),
 			logger.String("location", location),
+			logger.String("location_type", string(locationType)),
 			logger.Error(err),
 		)
 		return nil, fmt.Errorf("failed to get cluster info: %w", err)
@@ -86,15 +121,25 @@ func (p *Provider) GetClusterInfo(ctx context.Context, clusterName, location str
 	if cluster.MasterAuth == nil || cluster.MasterAuth.ClusterCaCertificate == "" {
 		return nil, fmt.Errorf("cluster CA certificate is empty")
 	}
+	if err := provider.ValidateCertificateAuthority(cluster.MasterAuth.ClusterCaCertificate); err != nil {
+		p.logger.Error("Cluster returned an invalid CA certificate",
+			logger.String("cluster", clusterName),
+			logger.Error(err),
+		)
+		return nil, err
+	}
 
+	publicEndpoint, privateEndpoint := clusterEndpoints(cluster)
 	info := &ClusterInfo{
 		Endpoint:             cluster.Endpoint,
+		PublicEndpoint:       publicEndpoint,
+		PrivateEndpoint:      privateEndpoint,
 		CertificateAuthority: cluster.MasterAuth.ClusterCaCertificate,
 		Version:              cluster.CurrentMasterVersion,
 		Location:             cluster.Location,
 	}
 
-	p.logger.Info("Successfully retrieved cluster info",
+	provider.LoggerWithBaggage(ctx, p.config.Tracing, p.logger).Info("Successfully retrieved cluster info",
 		logger.String("cluster", clusterName),
 		logger.String("endpoint", cluster.Endpoint),
 		logger.String("version", cluster.CurrentMasterVersion),
@@ -103,3 +148,93 @@ func (p *Provider) GetClusterInfo(ctx context.Context, clusterName, location str
 
 	return info, nil
 }
+
+// clusterEndpoints returns the public and private control plane endpoints of cluster,
+// preferring the IP endpoints config over the older private cluster config. A cluster
+// with neither only has its public endpoint.
+func clusterEndpoints(cluster *container.Cluster) (public, private string) {
+	if config := cluster.ControlPlaneEndpointsConfig; config != nil && config.IpEndpointsConfig != nil {
+		public = config.IpEndpointsConfig.PublicEndpoint
+		private = config.IpEndpointsConfig.PrivateEndpoint
+	}
+	if config := cluster.PrivateClusterConfig; config != nil {
+		if public == "" {
+			public = config.PublicEndpoint
+		}
+		if private == "" {
+			private = config.PrivateEndpoint
+		}
+	}
+	if public == "" && private == "" {
+		public = cluster.Endpoint
+	}
+	return public, private
+}
+
+// clusterCredentials returns credentials for the Container API and the project the
+// cluster lives in, using application default credentials when they are in effect
+func (p *Provider) clusterCredentials(ctx context.Context) (*google.Credentials, string, error) {
+	if p.tokenGenerator.usesADC() {
+		adc, err := p.tokenGenerator.defaultCredentials(ctx, container.CloudPlatformScope)
+		if err != nil {
+			return nil, "", err
+		}
+
+		projectID := p.config.ProjectID
+		if projectID == "" {
+			projectID = adc.ProjectID
+		}
+		if projectID == "" {
+			return nil, "", fmt.Errorf("GCP project ID is required: the application default credentials do not specify a project")
+		}
+		return adc, projectID, nil
+	}
+
+	creds, err := p.tokenGenerator.readCredentials(ctx)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to load GCP credentials: %w", err)
+	}
+	credsJSON, err := credentialsJSON(creds)
+	if err != nil {
+		return nil, "", err
+	}
+
+	gcpCreds, err := p.tokenGenerator.credentialsFromJSON(p.config.withHTTPClient(ctx), credsJSON, container.CloudPlatformScope)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create GCP credentials: %w", err)
+	}
+
+	return gcpCreds, creds.ProjectID, nil
+}
+
+// serviceOptions authenticates the Container API client with creds and points it at
+// the configured endpoint, or else the endpoint of the credentials' universe domain.
+// With a configured HTTP client, the API requests are sent through it by an oauth2
+// client on top of it.
+func (p *Provider) serviceOptions(ctx context.Context, creds *google.Credentials) ([]option.ClientOption, error) {
+	universeDomain, err := creds.GetUniverseDomain()
+	if err != nil {
+		return nil, errors.Wrap(
+			errors.ErrCredentialInvalid,
+			err,
+			"failed to get the universe domain of the GCP credentials",
+		).WithField("provider", "gcp")
+	}
+	if err := checkGKEEndpoint(p.config.GKEEndpoint, universeDomain); err != nil {
+		return nil, err
+	}
+
+	var opts []option.ClientOption
+	if p.config.HTTPClient == nil {
+		opts = append(opts, option.WithCredentials(creds))
+	} else {
+		opts = append(opts, option.WithHTTPClient(oauth2.NewClient(p.config.withHTTPClient(ctx), creds.TokenSource)))
+	}
+	if universeDomain != defaultUniverseDomain {
+		opts = append(opts, option.WithUniverseDomain(universeDomain))
+	}
+	if p.config.GKEEndpoint != "" {
+		opts = append(opts, option.WithEndpoint(p.config.GKEEndpoint))
+	}
+	return opts, nil
+}
@@ -0,0 +1,196 @@
+package gcp
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/container/v1"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/testutil"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+)
+
+// TestProvider_GetClusterInfo_HTTPClient checks that both the token request and the
+// Container API call go through the configured HTTP client
+func TestProvider_GetClusterInfo_HTTPClient(t *testing.T) {
+	// Any certificate will do for the CA check; borrow the test server's
+	server := httptest.NewTLSServer(http.NotFoundHandler())
+	server.Close()
+	ca := base64.StdEncoding.EncodeToString(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))
+
+	client, transport := testutil.NewRecordingClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Host == "oauth2.example.test" {
+			writeTokenResponse(w, r)
+			return
+		}
+		if !strings.Contains(r.URL.Path, "/projects/test-project-12345/locations/us-central1/clusters/my-cluster") ||
+			r.Header.Get("Authorization") != "Bearer ya29.test-access-token" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"name":"my-cluster","endpoint":"34.68.222.124","currentMasterVersion":"1.33.5-gke.2118001",` +
+			`"location":"us-central1","masterAuth":{"clusterCaCertificate":"` + ca + `"}}`))
+	}))
+
+	creds := signingCredentials(t, "https://oauth2.example.test/token")
+	raw, err := json.Marshal(creds)
+	require.NoError(t, err)
+	creds.RawJSON = string(raw)
+
+	config := &Config{ProjectID: creds.ProjectID, CredentialsFile: "/sa.json", Scopes: DefaultScopes(), UseADC: ADCModeNever, HTTPClient: client}
+	mockLoader := testutil.NewMockCredLoader().WithGCPCreds(creds)
+	gcpProvider := &Provider{
+		config:         config,
+		logger:         logger.Nop(),
+		tokenGenerator: NewTokenGenerator(config, mockLoader, logger.Nop()),
+		credLoader:     mockLoader,
+	}
+
+	info, err := gcpProvider.GetClusterInfo(context.Background(), "my-cluster", "us-central1")
+	require.NoError(t, err)
+	assert.Equal(t, "34.68.222.124", info.Endpoint)
+	assert.Equal(t, []string{"oauth2.example.test", "container.googleapis.com"}, transport.Hosts())
+}
+
+// TestProvider_GetClusterInfo_UniverseDomain checks that cluster lookups with credentials
+// from a non-default universe go to its Container API endpoint or the --gke-endpoint
+// override, and that an override in another universe is rejected
+func TestProvider_GetClusterInfo_UniverseDomain(t *testing.T) {
+	server := httptest.NewTLSServer(http.NotFoundHandler())
+	server.Close()
+	ca := base64.StdEncoding.EncodeToString(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))
+
+	tests := []struct {
+		name        string
+		gkeEndpoint string
+		wantHosts   []string
+		wantErrCode errors.ErrorCode
+	}{
+		{
+			name:      "universe endpoint",
+			wantHosts: []string{"oauth2.example-universe.goog", "container.example-universe.goog"},
+		},
+		{
+			name:        "endpoint override",
+			gkeEndpoint: "https://gke.private.example-universe.goog/",
+			wantHosts:   []string{"oauth2.example-universe.goog", "gke.private.example-universe.goog"},
+		},
+		{
+			name:        "endpoint in another universe",
+			gkeEndpoint: "https://container.googleapis.com/",
+			wantErrCode: errors.ErrConfigInvalid,
+		},
+		{
+			name:        "malformed endpoint",
+			gkeEndpoint: "container.example-universe.goog",
+			wantErrCode: errors.ErrConfigInvalid,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, transport := testutil.NewRecordingClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Host == "oauth2.example-universe.goog" {
+					writeTokenResponse(w, r)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"name":"my-cluster","endpoint":"10.0.0.2","location":"us-central1",` +
+					`"masterAuth":{"clusterCaCertificate":"` + ca + `"}}`))
+			}))
+
+			creds := signingCredentials(t, "https://oauth2.example-universe.goog/token")
+			creds.UniverseDomain = "example-universe.goog"
+			raw, err := json.Marshal(creds)
+			require.NoError(t, err)
+			creds.RawJSON = string(raw)
+
+			config := &Config{ProjectID: creds.ProjectID, CredentialsFile: "/sa.json", Scopes: DefaultScopes(), UseADC: ADCModeNever, HTTPClient: client, GKEEndpoint: tt.gkeEndpoint}
+			mockLoader := testutil.NewMockCredLoader().WithGCPCreds(creds)
+			gcpProvider := &Provider{
+				config:         config,
+				logger:         logger.Nop(),
+				tokenGenerator: NewTokenGenerator(config, mockLoader, logger.Nop()),
+				credLoader:     mockLoader,
+			}
+
+			info, err := gcpProvider.GetClusterInfo(context.Background(), "my-cluster", "us-central1")
+			if tt.wantErrCode != "" {
+				require.Error(t, err)
+				assert.True(t, errors.Is(err, tt.wantErrCode), "expected error code %s, got %v", tt.wantErrCode, err)
+				assert.Empty(t, transport.Hosts(), "no request is sent with a mismatched endpoint")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "10.0.0.2", info.Endpoint)
+			assert.Equal(t, tt.wantHosts, transport.Hosts())
+		})
+	}
+}
+
+func TestCheckGKEEndpoint(t *testing.T) {
+	assert.NoError(t, checkGKEEndpoint("", "googleapis.com"))
+	assert.NoError(t, checkGKEEndpoint("https://container.googleapis.com/", "googleapis.com"))
+	assert.NoError(t, checkGKEEndpoint("https://us-central1-container.googleapis.com", "googleapis.com"))
+
+	err := checkGKEEndpoint("https://container.example-universe.goog/", "googleapis.com")
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, errors.ErrConfigInvalid))
+	assert.Contains(t, err.Error(), "universe domain")
+
+	// A host that merely ends with the universe domain is not in it
+	assert.Error(t, checkGKEEndpoint("https://container.notgoogleapis.com/", "googleapis.com"))
+	assert.Error(t, checkGKEEndpoint("http://container.googleapis.com/", "googleapis.com"))
+}
+
+func TestClusterEndpoints(t *testing.T) {
+	tests := []struct {
+		name        string
+		cluster     container.Cluster
+		wantPublic  string
+		wantPrivate string
+	}{
+		{
+			name:       "public cluster",
+			cluster:    container.Cluster{Endpoint: "34.68.222.124"},
+			wantPublic: "34.68.222.124",
+		},
+		{
+			name: "IP endpoints config",
+			cluster: container.Cluster{
+				Endpoint: "34.68.222.124",
+				ControlPlaneEndpointsConfig: &container.ControlPlaneEndpointsConfig{
+					IpEndpointsConfig: &container.IPEndpointsConfig{PublicEndpoint: "34.68.222.124", PrivateEndpoint: "10.0.0.2"},
+				},
+			},
+			wantPublic:  "34.68.222.124",
+			wantPrivate: "10.0.0.2",
+		},
+		{
+			name: "private cluster config",
+			cluster: container.Cluster{
+				Endpoint:             "10.0.0.2",
+				PrivateClusterConfig: &container.PrivateClusterConfig{EnablePrivateEndpoint: true, PrivateEndpoint: "10.0.0.2"},
+			},
+			wantPrivate: "10.0.0.2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			public, private := clusterEndpoints(&tt.cluster)
+			assert.Equal(t, tt.wantPublic, public)
+			assert.Equal(t, tt.wantPrivate, private)
+		})
+	}
+}
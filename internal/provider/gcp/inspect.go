@@ -0,0 +1,61 @@
+package gcp
+
+import (
+	"strings"
+	"time"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+)
+
+// accessTokenPrefix is the prefix of opaque Google OAuth2 access tokens
+const accessTokenPrefix = "ya29."
+
+// TokenInfo is a human-readable breakdown of a GCP token.
+// OAuth2 access tokens are opaque, so only JWT (ID) tokens carry claims.
+type TokenInfo struct {
+	Format    string     `json:"format"`
+	Algorithm string     `json:"algorithm,omitempty"`
+	KeyID     string     `json:"keyId,omitempty"`
+	Issuer    string     `json:"issuer,omitempty"`
+	Audience  string     `json:"audience,omitempty"`
+	Subject   string     `json:"subject,omitempty"`
+	Email     string     `json:"email,omitempty"`
+	IssuedAt  *time.Time `json:"issuedAt,omitempty"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	Signature string     `json:"signature,omitempty"`
+	Token     string     `json:"token,omitempty"`
+}
+
+// InspectToken decodes a GCP token without verifying it. Opaque access tokens
+// are reported as such; their expiry is only known to Google's tokeninfo endpoint.
+// The signature and raw token are only included when unsafe is set.
+func InspectToken(token string, unsafe bool) (*TokenInfo, error) {
+	if !provider.IsJWT(token) {
+		info := &TokenInfo{Format: "opaque"}
+		if strings.HasPrefix(token, accessTokenPrefix) {
+			info.Format = "oauth2-access-token"
+		}
+		if unsafe {
+			info.Token = token
+		}
+		return info, nil
+	}
+
+	jwt, err := provider.DecodeJWT(token)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenInfo{
+		Format:    "jwt",
+		Algorithm: jwt.HeaderString("alg"),
+		KeyID:     jwt.HeaderString("kid"),
+		Issuer:    jwt.StringClaim("iss"),
+		Audience:  jwt.StringClaim("aud"),
+		Subject:   jwt.StringClaim("sub"),
+		Email:     jwt.StringClaim("email"),
+		IssuedAt:  jwt.TimeClaim("iat"),
+		ExpiresAt: jwt.TimeClaim("exp"),
+		Signature: provider.RedactUnlessUnsafe(jwt.Signature, unsafe),
+	}, nil
+}
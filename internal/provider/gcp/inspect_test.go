@@ -0,0 +1,74 @@
+package gcp
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+)
+
+func TestInspectToken(t *testing.T) {
+	idToken := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256","kid":"abc"}`)) + "." +
+		base64.RawURLEncoding.EncodeToString([]byte(`{"iss":"https://accounts.google.com","aud":"https://example.com","sub":"1234","email":"sa@proj.iam.gserviceaccount.com","iat":1709290800,"exp":1709294400}`)) +
+		".c2lnbmF0dXJl"
+
+	tests := []struct {
+		name     string
+		token    string
+		unsafe   bool
+		validate func(t *testing.T, info *TokenInfo)
+	}{
+		{
+			name:  "ID token claims are decoded and signature redacted",
+			token: idToken,
+			validate: func(t *testing.T, info *TokenInfo) {
+				assert.Equal(t, "jwt", info.Format)
+				assert.Equal(t, "RS256", info.Algorithm)
+				assert.Equal(t, "abc", info.KeyID)
+				assert.Equal(t, "https://accounts.google.com", info.Issuer)
+				assert.Equal(t, "https://example.com", info.Audience)
+				assert.Equal(t, "sa@proj.iam.gserviceaccount.com", info.Email)
+				require.NotNil(t, info.ExpiresAt)
+				assert.Equal(t, time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC), *info.ExpiresAt)
+				assert.Equal(t, provider.Redacted, info.Signature)
+			},
+		},
+		{
+			name:   "unsafe includes signature",
+			token:  idToken,
+			unsafe: true,
+			validate: func(t *testing.T, info *TokenInfo) {
+				assert.Equal(t, "c2lnbmF0dXJl", info.Signature)
+			},
+		},
+		{
+			name:  "opaque access token",
+			token: "ya29.a0AfH6SMBexample",
+			validate: func(t *testing.T, info *TokenInfo) {
+				assert.Equal(t, "oauth2-access-token", info.Format)
+				assert.Nil(t, info.ExpiresAt)
+				assert.Empty(t, info.Token)
+			},
+		},
+		{
+			name:   "opaque access token unsafe",
+			token:  "ya29.a0AfH6SMBexample",
+			unsafe: true,
+			validate: func(t *testing.T, info *TokenInfo) {
+				assert.Equal(t, "ya29.a0AfH6SMBexample", info.Token)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := InspectToken(tt.token, tt.unsafe)
+			require.NoError(t, err)
+			tt.validate(t, info)
+		})
+	}
+}
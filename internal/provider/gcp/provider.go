@@ -21,19 +21,34 @@ func NewProvider(config *Config, log logger.Logger) (*Provider, error) {
 		config = DefaultConfig()
 	}
 
-	if config.ProjectID == "" {
+	// With application default credentials the project can come from the ADC lookup
+	if config.ProjectID == "" && !config.usesADC() {
 		return nil, errors.New(
 			errors.ErrConfigMissingField,
 			"GCP project_id is required",
 		).WithField("provider", "gcp")
 	}
 
-	credLoader := credentials.NewLoader(log)
+	// Application default credentials are not read from a file whose checksum could be checked
+	if config.CredentialsSHA256 != "" && config.usesADC() {
+		return nil, errors.New(
+			errors.ErrCredentialInvalid,
+			"GCP application default credentials cannot be checked against the expected SHA-256",
+		).WithField("provider", "gcp").
+			WithDetail("use a service account file, or drop the expected SHA-256")
+	}
+
+	credLoader, err := provider.NewCredentialLoader(log, config.StrictPermissions, config.WatchCredentials, config.Metrics, config.HTTPClient,
+		credentials.WithExpectedSHA256(config.CredentialsSHA256))
+	if err != nil {
+		return nil, err
+	}
 	tokenGenerator := NewTokenGenerator(config, credLoader, log)
 
 	log.Debug("GCP provider initialized",
 		logger.String("project_id", config.ProjectID),
 		logger.Int("num_scopes", len(config.Scopes)),
+		logger.Bool("use_adc", config.usesADC()),
 	)
 
 	return &Provider{
@@ -45,16 +60,25 @@ func NewProvider(config *Config, log logger.Logger) (*Provider, error) {
 }
 
 func (p *Provider) GetToken(ctx context.Context, opts provider.GetTokenOptions) (*provider.Token, error) {
-	if opts.ClusterName == "" {
-		return nil, errors.New(
-			errors.ErrInvalidArgument,
-			"cluster name is required",
-		).WithField("provider", "gcp")
+	if err := validateClusterName(opts.ClusterName); err != nil {
+		return nil, err
 	}
 
 	if opts.ProjectID == "" {
 		opts.ProjectID = p.config.ProjectID
 	}
+	if err := validateProjectID(opts.ProjectID); err != nil {
+		return nil, err
+	}
+	if opts.Region != "" {
+		if _, err := ParseLocation(opts.Region); err != nil {
+			return nil, err
+		}
+	}
+	if opts.ProjectID == "" {
+		// Only used for logging; the token itself is not project-scoped
+		opts.ProjectID, _ = p.resolveProjectID(ctx)
+	}
 
 	p.logger.Info("Generating GCP token",
 		logger.String("cluster", opts.ClusterName),
@@ -62,6 +86,10 @@ func (p *Provider) GetToken(ctx context.Context, opts provider.GetTokenOptions)
 		logger.String("region", opts.Region),
 	)
 
+	name := provider.ProviderGCP.String()
+	p.config.Metrics.IncInflight(name)
+	defer p.config.Metrics.DecInflight(name)
+
 	token, err := p.tokenGenerator.GenerateToken(ctx, opts)
 	if err != nil {
 		p.logger.Error("Failed to generate GCP token",
@@ -76,6 +104,9 @@ func (p *Provider) GetToken(ctx context.Context, opts provider.GetTokenOptions)
 		return nil, err
 	}
 
+	now := p.tokenGenerator.clock.Now()
+	p.config.Metrics.SetTokenExpiry(name, opts.ClusterName, token.ExpiresAt.Sub(now))
+	p.config.Metrics.SetLastSuccess(name, opts.ClusterName, now)
 	return token, nil
 }
 
@@ -84,7 +115,11 @@ func (p *Provider) ValidateCredentials(ctx context.Context) error {
 		logger.String("project_id", p.config.ProjectID),
 	)
 
-	creds, err := p.credLoader.LoadGCP(ctx, p.config.CredentialsFile)
+	if p.tokenGenerator.usesADC() {
+		return p.validateDefaultCredentials(ctx)
+	}
+
+	creds, err := p.tokenGenerator.readCredentials(ctx)
 	if err != nil {
 		return errors.Wrap(
 			errors.ErrCredentialValidationFailed,
@@ -104,39 +139,133 @@ func (p *Provider) ValidateCredentials(ctx context.Context) error {
 		})
 	}
 
-	// Try to generate a test token to verify credentials work
-	testOpts := provider.GetTokenOptions{
-		ClusterName: "test-cluster",
-		ProjectID:   p.config.ProjectID,
-		Region:      "us-central1",
+	// The offline checks pass for a well-formed key that was revoked or deleted, so mint
+	// an access token once and discard it
+	if err := p.fetchTestToken(ctx, creds); err != nil {
+		return err
+	}
+
+	p.logger.Info("GCP credentials validated successfully",
+		logger.String("project_id", creds.ProjectID),
+		logger.Email("client_email", creds.ClientEmail),
+	)
+
+	return nil
+}
+
+// fetchTestToken obtains an access token for the service account key from the token
+// endpoint and discards it
+func (p *Provider) fetchTestToken(ctx context.Context, creds *credentials.GCPCredentials) error {
+	tokenSource, err := p.tokenGenerator.createTokenSource(ctx, creds)
+	if err != nil {
+		return err
+	}
+
+	token, err := tokenWithContext(ctx, tokenSource)
+	if err != nil {
+		return errors.Wrap(
+			errors.ErrCredentialInvalid,
+			err,
+			"credentials loaded but failed to obtain an access token",
+		).WithField("provider", "gcp").
+			WithDetail("the service account key may have been revoked or deleted, or the service account disabled; create a new key")
+	}
+	if token.AccessToken == "" {
+		return errors.New(
+			errors.ErrCredentialInvalid,
+			"credentials returned an empty access token",
+		).WithField("provider", "gcp")
 	}
 
-	token, err := p.tokenGenerator.GenerateToken(ctx, testOpts)
+	return nil
+}
+
+// validateDefaultCredentials verifies that application default credentials can be
+// found and can mint a token
+func (p *Provider) validateDefaultCredentials(ctx context.Context) error {
+	adc, err := p.tokenGenerator.defaultCredentials(ctx, p.config.Scopes...)
 	if err != nil {
 		return errors.Wrap(
 			errors.ErrCredentialValidationFailed,
 			err,
-			"credentials loaded but failed to generate test token",
+			"failed to validate GCP credentials",
 		).WithField("provider", "gcp")
 	}
 
-	if err := p.tokenGenerator.ValidateToken(token); err != nil {
+	if p.config.ProjectID != "" && adc.ProjectID != "" && adc.ProjectID != p.config.ProjectID {
+		return errors.New(
+			errors.ErrCredentialInvalid,
+			"project ID mismatch between config and application default credentials",
+		).WithFields(map[string]interface{}{
+			"provider":       "gcp",
+			"config_project": p.config.ProjectID,
+			"adc_project":    adc.ProjectID,
+		})
+	}
+
+	token, err := tokenWithContext(ctx, adc.TokenSource)
+	if err != nil {
 		return errors.Wrap(
 			errors.ErrCredentialValidationFailed,
 			err,
-			"test token validation failed",
+			"application default credentials found but failed to generate test token",
+		).WithField("provider", "gcp")
+	}
+	if token.AccessToken == "" {
+		return errors.New(
+			errors.ErrCredentialValidationFailed,
+			"application default credentials returned an empty token",
 		).WithField("provider", "gcp")
 	}
 
-	p.logger.Info("GCP credentials validated successfully",
-		logger.String("project_id", creds.ProjectID),
-		logger.String("client_email", creds.ClientEmail),
+	p.logger.Info("GCP application default credentials validated successfully",
+		logger.String("project_id", adc.ProjectID),
 	)
 
 	return nil
 }
 
+// resolveProjectID returns the configured project ID, falling back to the project
+// of the application default credentials
+func (p *Provider) resolveProjectID(ctx context.Context) (string, error) {
+	if p.config.ProjectID != "" {
+		return p.config.ProjectID, nil
+	}
+
+	if p.tokenGenerator.usesADC() {
+		adc, err := p.tokenGenerator.defaultCredentials(ctx, p.config.Scopes...)
+		if err != nil {
+			return "", err
+		}
+		if adc.ProjectID != "" {
+			return adc.ProjectID, nil
+		}
+	}
+
+	return "", errors.New(
+		errors.ErrConfigMissingField,
+		"GCP project_id is required",
+	).WithField("provider", "gcp").
+		WithDetail("set --project-id; the application default credentials do not specify a project")
+}
+
+// ValidateToken checks a token with the token generator
+func (p *Provider) ValidateToken(token *provider.Token) error {
+	return p.tokenGenerator.ValidateToken(token)
+}
+
+// RefreshToken returns currentToken while the token generator accepts it and it is
+// outside the refresh threshold, and otherwise generates a new token with GetToken
+func (p *Provider) RefreshToken(ctx context.Context, opts provider.GetTokenOptions, currentToken *provider.Token) (*provider.Token, error) {
+	return provider.RefreshIfNeeded(ctx, p, opts, currentToken, p.tokenGenerator.clock, p.tokenGenerator.refreshThreshold())
+}
+
 // Name returns the provider name
 func (p *Provider) Name() string {
 	return "gcp"
 }
+
+// Close stops watching the credentials file of Config.WatchCredentials
+func (p *Provider) Close() error {
+	return provider.CloseCredentialLoader(p.credLoader)
+}
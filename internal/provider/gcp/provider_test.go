@@ -18,6 +18,7 @@ import (
 )
 
 func TestNewProvider(t *testing.T) {
+	t.Setenv("GOOGLE_APPLICATION_CREDENTIALS", "")
 	log := logger.Nop()
 
 	tests := []struct {
@@ -36,10 +37,9 @@ func TestNewProvider(t *testing.T) {
 			wantErr: false,
 		},
 		{
-			name:        "nil config uses default",
-			config:      nil,
-			wantErr:     true, // Default config has empty ProjectID
-			wantErrCode: errors.ErrConfigMissingField,
+			name:    "nil config uses default",
+			config:  nil,
+			wantErr: false, // Default config falls back to ADC, which can supply the project
 		},
 		{
 			name: "missing project ID",
@@ -47,10 +47,42 @@ func TestNewProvider(t *testing.T) {
 				ProjectID:     "",
 				TokenDuration: 1 * time.Hour,
 				Scopes:        DefaultScopes(),
+				UseADC:        ADCModeNever,
 			},
 			wantErr:     true,
 			wantErrCode: errors.ErrConfigMissingField,
 		},
+		{
+			name: "expected SHA-256 with application default credentials",
+			config: &Config{
+				ProjectID:         "test-project",
+				TokenDuration:     1 * time.Hour,
+				Scopes:            DefaultScopes(),
+				UseADC:            ADCModeAlways,
+				CredentialsSHA256: "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+			},
+			wantErr:     true,
+			wantErrCode: errors.ErrCredentialInvalid,
+		},
+		{
+			name: "missing project ID with credentials file",
+			config: &Config{
+				CredentialsFile: "/path/to/sa.json",
+				TokenDuration:   1 * time.Hour,
+				Scopes:          DefaultScopes(),
+			},
+			wantErr:     true,
+			wantErrCode: errors.ErrConfigMissingField,
+		},
+		{
+			name: "missing project ID with ADC",
+			config: &Config{
+				TokenDuration: 1 * time.Hour,
+				Scopes:        DefaultScopes(),
+				UseADC:        ADCModeAlways,
+			},
+			wantErr: false,
+		},
 		{
 			name: "with credentials file",
 			config: &Config{
@@ -321,3 +353,67 @@ func TestProvider_Integration(t *testing.T) {
 	assert.False(t, token.IsExpired())
 	assert.True(t, token.ExpiresAt.After(time.Now()))
 }
+
+func TestProvider_ApplicationDefaultCredentials(t *testing.T) {
+	tests := []struct {
+		name          string
+		configProject string
+		adcProject    string
+		wantProject   string
+		wantErrCode   errors.ErrorCode
+	}{
+		{
+			name:        "project taken from ADC",
+			adcProject:  "adc-project",
+			wantProject: "adc-project",
+		},
+		{
+			name:          "configured project wins",
+			configProject: "adc-project",
+			adcProject:    "adc-project",
+			wantProject:   "adc-project",
+		},
+		{
+			name:        "no project anywhere",
+			wantErrCode: errors.ErrConfigMissingField,
+		},
+		{
+			name:          "project mismatch",
+			configProject: "config-project",
+			adcProject:    "adc-project",
+			wantProject:   "config-project",
+			wantErrCode:   errors.ErrCredentialInvalid,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gcpProvider, err := NewProvider(&Config{
+				ProjectID: tt.configProject,
+				Scopes:    DefaultScopes(),
+				UseADC:    ADCModeAlways,
+			}, logger.Nop())
+			require.NoError(t, err)
+			gcpProvider.tokenGenerator.findDefaultCredentials = fakeADC(tt.adcProject, "adc-token", nil)
+
+			projectID, err := gcpProvider.resolveProjectID(context.Background())
+			if tt.wantProject == "" {
+				assert.True(t, errors.Is(err, tt.wantErrCode), "expected error code %s, got %v", tt.wantErrCode, err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.wantProject, projectID)
+			}
+
+			token, err := gcpProvider.GetToken(context.Background(), provider.GetTokenOptions{ClusterName: "test-cluster"})
+			require.NoError(t, err)
+			assert.Equal(t, "adc-token", token.AccessToken)
+
+			err = gcpProvider.ValidateCredentials(context.Background())
+			if tt.wantErrCode == errors.ErrCredentialInvalid {
+				assert.True(t, errors.Is(err, tt.wantErrCode), "expected error code %s, got %v", tt.wantErrCode, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package gcp
+
+import (
+	"context"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+)
+
+func init() {
+	provider.MustRegisterProvider(provider.ProviderGCP, provider.Registration{
+		Constructor:  newFromConfig,
+		Capabilities: provider.Capabilities{BoundAudience: true, EndpointAccess: true, LookupAnyRegion: true},
+		Defaults: provider.Defaults{
+			TokenDuration:    maxTokenDuration,
+			RefreshThreshold: defaultRefreshThreshold,
+			MaxDuration:      maxTokenDuration,
+		},
+		ValidateOptions: validateOptions,
+		Inputs: []provider.Input{
+			// The kubeconfig exec plugin is always given the project, while a lookup can take
+			// it from application default credentials
+			{Name: "project-id", RequiredFor: provider.OperationKubeconfig},
+			{Name: "project-id", RequiredFor: provider.OperationClusterLookup, Satisfied: adcSuppliesProject},
+			{Name: "region", RequiredFor: provider.OperationClusterLookup | provider.OperationKubeconfig, Note: "location can be region or zone"},
+			{Name: "gcp-use-adc", Validate: func(value string) error {
+				_, err := ParseADCMode(value)
+				return err
+			}},
+		},
+	})
+}
+
+// adcSuppliesProject reports whether application default credentials are in effect,
+// which can supply the project ID
+func adcSuppliesProject(values map[string]string) bool {
+	mode, err := ParseADCMode(values["gcp-use-adc"])
+	return err == nil && UsesADC(mode, values["credentials-file"] != "" || values["credentials-dir"] != "")
+}
+
+// newFromConfig creates a GCP provider from the shared provider configuration
+func newFromConfig(cfg *provider.Config, log logger.Logger) (provider.Provider, error) {
+	useADC, err := ParseADCMode(cfg.GCPUseADC)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrInvalidArgument, err, "invalid GCP application default credentials mode")
+	}
+
+	config := DefaultConfig()
+	config.ProjectID = cfg.ProjectID
+	config.CredentialsFile = cfg.CredentialsFile
+	config.CredentialsDir = cfg.CredentialsDir
+	config.UseADC = useADC
+	config.StrictPermissions = cfg.StrictPermissions
+	config.CredentialsSHA256 = cfg.CredentialsSHA256
+	config.GKEEndpoint = cfg.GKEEndpoint
+	config.Tracing = cfg.Tracing
+	config.HTTPClient = cfg.HTTPClient
+	config.WatchCredentials = cfg.WatchCredentials
+	config.Metrics = cfg.Metrics
+	if cfg.TokenDuration > 0 {
+		config.TokenDuration = cfg.TokenDuration
+	}
+
+	return NewProvider(config, log)
+}
+
+// DescribeCluster implements provider.ClusterDescriber
+func (p *Provider) DescribeCluster(ctx context.Context, opts provider.ClusterInfoOptions) (*provider.ClusterInfo, error) {
+	info, err := p.GetClusterInfo(ctx, opts.ClusterName, opts.Region)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint, err := provider.ClusterEndpoint(opts.EndpointAccess, info.Endpoint, info.PublicEndpoint, info.PrivateEndpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	return &provider.ClusterInfo{
+		Endpoint:             "https://" + endpoint,
+		CertificateAuthority: info.CertificateAuthority,
+		Version:              info.Version,
+		Location:             info.Location,
+	}, nil
+}
@@ -3,10 +3,13 @@ package gcp
 import (
 	"context"
 	"encoding/json"
+	stderrors "errors"
+	"path/filepath"
 	"time"
 
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
+	"google.golang.org/api/idtoken"
 
 	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/credentials"
 	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
@@ -14,58 +17,97 @@ import (
 	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
 )
 
+const (
+	// defaultRefreshThreshold is the remaining lifetime at which a token is refreshed
+	defaultRefreshThreshold = 5 * time.Minute
+
+	// maxTokenDuration is the lifetime of Google OAuth2 access tokens
+	maxTokenDuration = 1 * time.Hour
+)
+
 // TokenGenerator handles GCP OAuth2 token generation for GKE clusters
 type TokenGenerator struct {
 	config     *Config
 	credLoader credentials.Loader
 	logger     logger.Logger
+	keyDir     *credentials.GCPKeyDir
+
+	// findDefaultCredentials looks up Application Default Credentials; replaced in tests
+	findDefaultCredentials func(ctx context.Context, scopes ...string) (*google.Credentials, error)
+
+	// credentialsFromJSON creates credentials from a service account file; replaced in tests
+	credentialsFromJSON func(ctx context.Context, jsonData []byte, scopes ...string) (*google.Credentials, error)
+
+	// newIDTokenSource creates audience-bound ID token sources; replaced in tests
+	newIDTokenSource func(ctx context.Context, audience string, opts ...idtoken.ClientOption) (oauth2.TokenSource, error)
+
+	// clock decides expiry and refresh; replaced in tests
+	clock provider.Clock
+
+	// tokenInfoURL is the OAuth2 token introspection endpoint; replaced in tests
+	tokenInfoURL string
 }
 
-// NewTokenGenerator creates a new GCP token generator
-func NewTokenGenerator(config *Config, credLoader credentials.Loader, logger logger.Logger) *TokenGenerator {
-	return &TokenGenerator{
-		config:     config,
-		credLoader: credLoader,
-		logger:     logger,
+// NewTokenGenerator creates a new GCP token generator. Its log lines carry provider=gcp.
+func NewTokenGenerator(config *Config, credLoader credentials.Loader, log logger.Logger) *TokenGenerator {
+	g := &TokenGenerator{
+		config:                 config,
+		credLoader:             credLoader,
+		logger:                 log.With(logger.String("provider", "gcp")),
+		findDefaultCredentials: google.FindDefaultCredentials,
+		credentialsFromJSON:    google.CredentialsFromJSON,
+		newIDTokenSource:       idtoken.NewTokenSource,
+		clock:                  provider.SystemClock,
+		tokenInfoURL:           defaultTokenInfoURL,
 	}
+	if config.CredentialsDir != "" {
+		g.keyDir = credentials.NewGCPKeyDir(config.CredentialsDir)
+	}
+	return g
 }
 
-// GenerateToken generates an OAuth2 access token for GKE authentication
+// GenerateToken generates an OAuth2 access token for GKE authentication, or an ID token
+// for opts.Audience when it is set
 func (g *TokenGenerator) GenerateToken(ctx context.Context, opts provider.GetTokenOptions) (*provider.Token, error) {
+	ctx, span := provider.StartSpan(ctx, g.config.Tracing, provider.ProviderGCP, "GenerateToken", opts.ClusterName, opts.Region)
+	token, err := g.generateToken(ctx, opts)
+	provider.EndSpan(ctx, span, err)
+	return token, err
+}
+
+// generateToken is GenerateToken without tracing
+func (g *TokenGenerator) generateToken(ctx context.Context, opts provider.GetTokenOptions) (*provider.Token, error) {
 	startTime := time.Now()
+	log := g.logger.With(logger.String("cluster", opts.ClusterName))
 
-	g.logger.Debug("Starting GCP token generation",
-		logger.String("cluster", opts.ClusterName),
+	log.Debug("Starting GCP token generation",
 		logger.String("project", opts.ProjectID),
 		logger.String("region", opts.Region),
 	)
 
-	creds, err := g.loadCredentials(ctx)
-	if err != nil {
-		return nil, err
-	}
-
-	g.logger.Debug("Credentials loaded",
-		logger.String("client_email", creds.ClientEmail),
-		logger.String("project_id", creds.ProjectID),
-	)
-
-	tokenSource, err := g.createTokenSource(ctx, creds)
+	tokenSource, identity, err := g.tokenSource(ctx, opts.Audience)
 	if err != nil {
 		return nil, err
 	}
 
-	oauth2Token, err := tokenSource.Token()
+	oauth2Token, err := tokenWithContext(ctx, tokenSource)
 	if err != nil {
+		fields := map[string]interface{}{
+			"provider": "gcp",
+			"cluster":  opts.ClusterName,
+			"project":  opts.ProjectID,
+		}
+		var retrieveErr *oauth2.RetrieveError
+		if stderrors.As(err, &retrieveErr) {
+			if rateLimitErr := provider.RateLimitError(err, retrieveErr.Response, "GCP token endpoint rate limit exceeded"); rateLimitErr != nil {
+				return nil, rateLimitErr.WithFields(fields)
+			}
+		}
 		return nil, errors.Wrap(
 			errors.ErrTokenGenerationFailed,
 			err,
 			"failed to get OAuth2 token from token source",
-		).WithFields(map[string]interface{}{
-			"provider": "gcp",
-			"cluster":  opts.ClusterName,
-			"project":  opts.ProjectID,
-		})
+		).WithFields(fields)
 	}
 
 	if oauth2Token.AccessToken == "" {
@@ -79,15 +121,25 @@ func (g *TokenGenerator) GenerateToken(ctx context.Context, opts provider.GetTok
 		AccessToken: oauth2Token.AccessToken,
 		ExpiresAt:   oauth2Token.Expiry,
 		TokenType:   oauth2Token.TokenType,
+		Provider:    provider.ProviderGCP.String(),
+		ClusterName: opts.ClusterName,
+		Identity:    identity,
+		Audience:    opts.Audience,
+	}
+	if opts.Audience == "" {
+		// ID tokens carry an audience instead of scopes
+		token.Scopes = g.config.Scopes
 	}
 
 	if token.TokenType == "" {
 		token.TokenType = "Bearer"
 	}
+	if err := provider.CheckClockSkew(g.clock, log, "gcp", token.ExpiresAt, maxTokenDuration); err != nil {
+		return nil, err
+	}
 
 	duration := time.Since(startTime)
-	g.logger.Info("GCP token generated successfully",
-		logger.String("cluster", opts.ClusterName),
+	provider.LoggerWithBaggage(ctx, g.config.Tracing, log).Info("GCP token generated successfully",
 		logger.String("project", opts.ProjectID),
 		logger.Duration("duration_ms", duration.Milliseconds()),
 		logger.String("expires_at", token.ExpiresAt.Format(time.RFC3339)),
@@ -97,9 +149,176 @@ func (g *TokenGenerator) GenerateToken(ctx context.Context, opts provider.GetTok
 	return token, nil
 }
 
+// tokenWithContext calls ts.Token and gives up when ctx is done. oauth2.TokenSource takes
+// no context: sources created from a key file use the context they were created with,
+// but the metadata server source behind ADC ignores it and could stall a caller.
+func tokenWithContext(ctx context.Context, ts oauth2.TokenSource) (*oauth2.Token, error) {
+	type result struct {
+		token *oauth2.Token
+		err   error
+	}
+
+	done := make(chan result, 1)
+	go func() {
+		token, err := ts.Token()
+		done <- result{token: token, err: err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.token, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// tokenSource returns a token source backed by Application Default Credentials when
+// ADC is in effect, or by the configured service account file otherwise, and the
+// redacted email of the service account when it is known. A non-empty audience makes
+// it return ID tokens bound to that audience instead of access tokens.
+func (g *TokenGenerator) tokenSource(ctx context.Context, audience string) (oauth2.TokenSource, string, error) {
+	if g.usesADC() {
+		adc, err := g.defaultCredentials(ctx, g.config.Scopes...)
+		if err != nil {
+			return nil, "", err
+		}
+		identity := adcIdentity(adc.JSON)
+		if audience != "" {
+			// Without a credentials file, ADC come from the metadata server, which the
+			// ID token source finds on its own
+			var opts []idtoken.ClientOption
+			if len(adc.JSON) > 0 {
+				opts = append(opts, idtoken.WithCredentialsJSON(adc.JSON))
+			}
+			ts, err := g.createIDTokenSource(ctx, audience, opts...)
+			return ts, identity, err
+		}
+		return adc.TokenSource, identity, nil
+	}
+
+	creds, err := g.loadCredentials(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+
+	g.logger.Debug("Credentials loaded",
+		logger.Email("client_email", creds.ClientEmail),
+		logger.String("project_id", creds.ProjectID),
+	)
+	identity := logger.RedactEmail(creds.ClientEmail)
+
+	if audience != "" {
+		credsJSON, err := credentialsJSON(creds)
+		if err != nil {
+			return nil, "", err
+		}
+		ts, err := g.createIDTokenSource(ctx, audience, idtoken.WithAuthCredentialsJSON(idtoken.ServiceAccount, credsJSON))
+		return ts, identity, err
+	}
+
+	ts, err := g.createTokenSource(ctx, creds)
+	return ts, identity, err
+}
+
+// adcIdentity returns the redacted client_email of an ADC credentials file, or "" for
+// user credentials and the metadata server, whose account is not known without a call
+func adcIdentity(credsJSON []byte) string {
+	var file struct {
+		ClientEmail string `json:"client_email"`
+	}
+	if len(credsJSON) == 0 || json.Unmarshal(credsJSON, &file) != nil {
+		return ""
+	}
+	return logger.RedactEmail(file.ClientEmail)
+}
+
+// createIDTokenSource creates a token source for ID tokens whose aud claim is audience
+func (g *TokenGenerator) createIDTokenSource(ctx context.Context, audience string, opts ...idtoken.ClientOption) (oauth2.TokenSource, error) {
+	tokenSource, err := g.newIDTokenSource(g.config.withHTTPClient(ctx), audience, opts...)
+	if err != nil {
+		return nil, errors.Wrap(
+			errors.ErrCredentialInvalid,
+			err,
+			"failed to create GCP ID token source",
+		).WithFields(map[string]interface{}{
+			"provider": "gcp",
+			"audience": audience,
+		}).WithDetail("ID tokens require service account credentials; gcloud user credentials cannot mint them")
+	}
+
+	g.logger.Debug("ID token source created",
+		logger.String("audience", audience),
+	)
+
+	return tokenSource, nil
+}
+
+// usesADC reports whether Application Default Credentials replace the service account file
+func (g *TokenGenerator) usesADC() bool {
+	return g.config.usesADC()
+}
+
+// credentialsPath returns the service account file to load: the configured file,
+// or the key in the credentials directory matching the project. An empty path
+// makes the loader fall back to GOOGLE_APPLICATION_CREDENTIALS.
+func (g *TokenGenerator) credentialsPath() (string, error) {
+	if g.config.CredentialsFile != "" || g.keyDir == nil {
+		return g.config.CredentialsFile, nil
+	}
+
+	path, err := g.keyDir.Select(g.config.ProjectID)
+	if err != nil {
+		return "", err
+	}
+
+	g.logger.Debug("Selected GCP service account key from credentials directory",
+		logger.String("project_id", g.config.ProjectID),
+		logger.String("file", filepath.Base(path)),
+	)
+
+	return path, nil
+}
+
+// readCredentials loads the service account credentials from the configured file or
+// credentials directory
+func (g *TokenGenerator) readCredentials(ctx context.Context) (*credentials.GCPCredentials, error) {
+	path, err := g.credentialsPath()
+	if err != nil {
+		return nil, err
+	}
+	return g.credLoader.LoadGCP(ctx, path)
+}
+
+// defaultCredentials finds Application Default Credentials, which come from the
+// metadata server on GKE/GCE or from gcloud user credentials on workstations
+func (g *TokenGenerator) defaultCredentials(ctx context.Context, scopes ...string) (*google.Credentials, error) {
+	adc, err := g.findDefaultCredentials(g.config.withHTTPClient(ctx), scopes...)
+	if err != nil {
+		return nil, errors.Wrap(
+			errors.ErrCredentialNotFound,
+			err,
+			"failed to find GCP application default credentials",
+		).WithField("provider", "gcp").
+			WithDetail("run on GKE/GCE with an attached service account, run 'gcloud auth application-default login', or use --credentials-file")
+	}
+
+	if g.config.ProjectID != "" && adc.ProjectID != "" && adc.ProjectID != g.config.ProjectID {
+		g.logger.Warn("Project ID mismatch between config and application default credentials",
+			logger.String("config_project", g.config.ProjectID),
+			logger.String("adc_project", adc.ProjectID),
+		)
+	}
+
+	g.logger.Debug("Using GCP application default credentials",
+		logger.String("project_id", adc.ProjectID),
+	)
+
+	return adc, nil
+}
+
 // loadCredentials loads GCP service account credentials
 func (g *TokenGenerator) loadCredentials(ctx context.Context) (*credentials.GCPCredentials, error) {
-	creds, err := g.credLoader.LoadGCP(ctx, g.config.CredentialsFile)
+	creds, err := g.readCredentials(ctx)
 	if err != nil {
 		return nil, errors.Wrap(
 			errors.ErrCredentialLoadFailed,
@@ -118,9 +337,14 @@ func (g *TokenGenerator) loadCredentials(ctx context.Context) (*credentials.GCPC
 	return creds, nil
 }
 
-// createTokenSource creates an OAuth2 token source from GCP credentials
-func (g *TokenGenerator) createTokenSource(ctx context.Context, creds *credentials.GCPCredentials) (oauth2.TokenSource, error) {
-	// Convert credentials struct to JSON bytes
+// credentialsJSON returns the service account file creds were read from, so that fields
+// such as universe_domain reach the Google libraries, or creds marshaled to JSON when
+// they were not read from a file
+func credentialsJSON(creds *credentials.GCPCredentials) ([]byte, error) {
+	if creds.RawJSON != "" {
+		return []byte(creds.RawJSON), nil
+	}
+
 	credsJSON, err := json.Marshal(creds)
 	if err != nil {
 		return nil, errors.Wrap(
@@ -129,8 +353,17 @@ func (g *TokenGenerator) createTokenSource(ctx context.Context, creds *credentia
 			"failed to marshal GCP credentials to JSON",
 		).WithField("provider", "gcp")
 	}
+	return credsJSON, nil
+}
+
+// createTokenSource creates an OAuth2 token source from GCP credentials
+func (g *TokenGenerator) createTokenSource(ctx context.Context, creds *credentials.GCPCredentials) (oauth2.TokenSource, error) {
+	credsJSON, err := credentialsJSON(creds)
+	if err != nil {
+		return nil, err
+	}
 
-	googleCreds, err := google.CredentialsFromJSON(ctx, credsJSON, g.config.Scopes...)
+	googleCreds, err := g.credentialsFromJSON(g.config.withHTTPClient(ctx), credsJSON, g.config.Scopes...)
 	if err != nil {
 		return nil, errors.Wrap(
 			errors.ErrCredentialInvalid,
@@ -150,6 +383,11 @@ func (g *TokenGenerator) createTokenSource(ctx context.Context, creds *credentia
 	return googleCreds.TokenSource, nil
 }
 
+// refreshThreshold returns the remaining lifetime at which a token is refreshed
+func (g *TokenGenerator) refreshThreshold() time.Duration {
+	return provider.RefreshThresholdOf(string(provider.ProviderGCP))
+}
+
 // ValidateToken validates that a token is valid and not expired
 func (g *TokenGenerator) ValidateToken(token *provider.Token) error {
 	if token == nil {
@@ -159,6 +397,10 @@ func (g *TokenGenerator) ValidateToken(token *provider.Token) error {
 		).WithField("provider", "gcp")
 	}
 
+	if err := provider.CheckTokenIssuer(token, provider.ProviderGCP); err != nil {
+		return err
+	}
+
 	if token.AccessToken == "" {
 		return errors.New(
 			errors.ErrTokenInvalid,
@@ -166,7 +408,7 @@ func (g *TokenGenerator) ValidateToken(token *provider.Token) error {
 		).WithField("provider", "gcp")
 	}
 
-	if token.IsExpired() {
+	if token.IsExpiredWith(g.clock) {
 		return errors.New(
 			errors.ErrTokenExpired,
 			"token has expired",
@@ -176,33 +418,23 @@ func (g *TokenGenerator) ValidateToken(token *provider.Token) error {
 		})
 	}
 
-	// Warn if token expires soon (less than 5 minutes)
-	if token.ExpiresIn() < 5*time.Minute {
-		g.logger.Warn("Token expires soon",
-			logger.String("provider", "gcp"),
-			logger.Duration("expires_in_seconds", int64(token.ExpiresIn().Seconds())),
-		)
-	}
+	provider.WarnExpiringSoon(g.logger, token, g.clock, g.refreshThreshold())
 
 	return nil
 }
 
 // RefreshToken refreshes an expired or soon-to-expire token
 func (g *TokenGenerator) RefreshToken(ctx context.Context, opts provider.GetTokenOptions, currentToken *provider.Token) (*provider.Token, error) {
-	if currentToken != nil && !currentToken.IsExpired() {
-		// Token is still valid, check if it's close to expiring
-		if currentToken.ExpiresIn() > 5*time.Minute {
-			g.logger.Debug("Token still valid, no refresh needed",
-				logger.String("provider", "gcp"),
-				logger.Duration("expires_in_seconds", int64(currentToken.ExpiresIn().Seconds())),
-			)
-			return currentToken, nil
-		}
+	log := g.logger.With(logger.String("cluster", opts.ClusterName))
+	if currentToken != nil && !currentToken.NeedsRefresh(g.clock, g.refreshThreshold()) {
+		log.Debug("Token still valid, no refresh needed",
+			logger.Duration("expires_in_seconds", int64(currentToken.ExpiresInWith(g.clock).Seconds())),
+		)
+		return currentToken, nil
 	}
 
-	g.logger.Info("Refreshing GCP token",
-		logger.String("cluster", opts.ClusterName),
-		logger.Bool("expired", currentToken == nil || currentToken.IsExpired()),
+	log.Info("Refreshing GCP token",
+		logger.Bool("expired", currentToken == nil || currentToken.IsExpiredWith(g.clock)),
 	)
 
 	// Generate new token
@@ -1,15 +1,117 @@
 package gcp
 
 import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
 	"time"
+
+	"golang.org/x/oauth2"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/metrics"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/tracing"
 )
 
 // Config holds GCP provider configuration
 type Config struct {
 	ProjectID         string
 	CredentialsFile   string
+	CredentialsDir    string
 	TokenDuration     time.Duration
 	Scopes            []string
+	UseADC            ADCMode
+
+	// StrictPermissions rejects credentials files readable by group or others
+	StrictPermissions bool
+
+	// CredentialsSHA256 are the comma-separated SHA-256 digests, one of which every
+	// credentials file read must have; empty skips the check
+	CredentialsSHA256 string
+
+	// GKEEndpoint overrides the Container API endpoint of cluster lookups; empty uses the
+	// endpoint of the credentials' universe domain
+	GKEEndpoint string
+
+	// Tracing records spans for token generation and cluster lookups; nil disables tracing
+	Tracing *tracing.Provider
+
+	// HTTPClient carries the OAuth2 and Container API requests; nil uses the default
+	HTTPClient *http.Client
+
+	// WatchCredentials reloads the credentials file when it changes on disk
+	WatchCredentials bool
+
+	// Metrics records credential reloads and token gauges; nil disables them
+	Metrics *metrics.Metrics
+}
+
+// withHTTPClient returns ctx carrying the configured HTTP client, which the oauth2
+// and Google API packages use for the token requests of credentials created with it
+func (c *Config) withHTTPClient(ctx context.Context) context.Context {
+	if c.HTTPClient == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, oauth2.HTTPClient, c.HTTPClient)
+}
+
+// httpClient returns the configured HTTP client, or http.DefaultClient
+func (c *Config) httpClient() *http.Client {
+	if c.HTTPClient == nil {
+		return http.DefaultClient
+	}
+	return c.HTTPClient
+}
+
+// ADCMode controls the Application Default Credentials fallback
+type ADCMode string
+
+const (
+	// ADCModeAuto uses ADC only when no credentials file is configured and
+	// GOOGLE_APPLICATION_CREDENTIALS is unset
+	ADCModeAuto ADCMode = "auto"
+
+	// ADCModeAlways always uses ADC, ignoring the credentials file
+	ADCModeAlways ADCMode = "true"
+
+	// ADCModeNever never falls back to ADC
+	ADCModeNever ADCMode = "false"
+)
+
+// ParseADCMode parses an ADC mode flag value (auto, true, false). An empty
+// value is treated as auto.
+func ParseADCMode(value string) (ADCMode, error) {
+	switch value {
+	case "", string(ADCModeAuto):
+		return ADCModeAuto, nil
+	case string(ADCModeAlways):
+		return ADCModeAlways, nil
+	case string(ADCModeNever):
+		return ADCModeNever, nil
+	default:
+		return "", fmt.Errorf("invalid ADC mode %q (must be one of: auto, true, false)", value)
+	}
+}
+
+// UsesADC reports whether Application Default Credentials are used instead of
+// a service account file for the given mode; hasExplicitCredentials reports whether
+// a credentials file or credentials directory is configured
+func UsesADC(mode ADCMode, hasExplicitCredentials bool) bool {
+	switch mode {
+	case ADCModeAlways:
+		return true
+	case ADCModeNever:
+		return false
+	default:
+		return !hasExplicitCredentials && os.Getenv("GOOGLE_APPLICATION_CREDENTIALS") == ""
+	}
+}
+
+// usesADC reports whether application default credentials are in effect; a
+// configured credentials file or credentials directory disables the auto fallback
+func (c *Config) usesADC() bool {
+	return UsesADC(c.UseADC, c.CredentialsFile != "" || c.CredentialsDir != "")
 }
 
 // DefaultScopes returns the default OAuth scopes for GKE access
@@ -23,7 +125,8 @@ func DefaultScopes() []string {
 // DefaultConfig returns default GCP configuration
 func DefaultConfig() *Config {
 	return &Config{
-		TokenDuration: 1 * time.Hour,
+		TokenDuration: provider.DefaultsOf(string(provider.ProviderGCP)).TokenDuration,
 		Scopes:        DefaultScopes(),
+		UseADC:        ADCModeAuto,
 	}
 }
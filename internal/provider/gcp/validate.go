@@ -0,0 +1,140 @@
+package gcp
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+)
+
+// LocationType tells whether a GKE location is a region or a zone
+type LocationType string
+
+const (
+	// LocationRegion is a region such as us-central1 (regional cluster)
+	LocationRegion LocationType = "region"
+
+	// LocationZone is a zone such as us-central1-a (zonal cluster)
+	LocationZone LocationType = "zone"
+)
+
+var (
+	// clusterNamePattern is the GKE cluster name constraint: up to 40 lowercase
+	// letters, digits and hyphens, starting with a letter and not ending with a hyphen
+	clusterNamePattern = regexp.MustCompile(`^[a-z]([-a-z0-9]{0,38}[a-z0-9])?$`)
+
+	// projectIDPattern matches project IDs, including legacy domain-scoped ones
+	// such as example.com:my-project
+	projectIDPattern = regexp.MustCompile(`^([a-z][a-z0-9.-]*[a-z0-9]:)?[a-z][a-z0-9-]{4,28}[a-z0-9]$`)
+
+	regionPattern = regexp.MustCompile(`^[a-z]+-[a-z]+\d+$`)
+	zonePattern   = regexp.MustCompile(`^[a-z]+-[a-z]+\d+-[a-z]$`)
+)
+
+// validateClusterName checks a cluster name against the GKE naming rules
+func validateClusterName(name string) error {
+	if name == "" {
+		return errors.New(
+			errors.ErrInvalidArgument,
+			"cluster name is required",
+		).WithField("provider", "gcp")
+	}
+	if !clusterNamePattern.MatchString(name) {
+		return errors.New(
+			errors.ErrInvalidArgument,
+			"invalid GKE cluster name",
+		).WithFields(map[string]interface{}{
+			"provider":     "gcp",
+			"cluster_name": name,
+		}).WithDetail("expected up to 40 lowercase letters, digits and hyphens, starting with a letter")
+	}
+	return nil
+}
+
+// validateOptions implements provider.OptionsValidator with the checks GetToken
+// makes before generating a token
+func validateOptions(opts provider.GetTokenOptions) error {
+	if err := validateClusterName(opts.ClusterName); err != nil {
+		return err
+	}
+	if err := validateProjectID(opts.ProjectID); err != nil {
+		return err
+	}
+	if opts.Region != "" {
+		if _, err := ParseLocation(opts.Region); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateProjectID checks a project ID has the GCP shape. An empty project ID is
+// allowed because it can be resolved from the credentials.
+func validateProjectID(projectID string) error {
+	if projectID == "" || projectIDPattern.MatchString(projectID) {
+		return nil
+	}
+	return errors.New(
+		errors.ErrInvalidArgument,
+		"invalid GCP project ID",
+	).WithFields(map[string]interface{}{
+		"provider":   "gcp",
+		"project_id": projectID,
+	}).WithDetail("expected 6 to 30 lowercase letters, digits and hyphens, starting with a letter")
+}
+
+// ParseLocation reports whether a GKE location is a region (us-central1) or a
+// zone (us-central1-a)
+func ParseLocation(location string) (LocationType, error) {
+	switch {
+	case regionPattern.MatchString(location):
+		return LocationRegion, nil
+	case zonePattern.MatchString(location):
+		return LocationZone, nil
+	}
+	return "", errors.New(
+		errors.ErrInvalidArgument,
+		"invalid GKE location",
+	).WithFields(map[string]interface{}{
+		"provider": "gcp",
+		"location": location,
+	}).WithDetail("expected a region such as us-central1 or a zone such as us-central1-a")
+}
+
+// defaultUniverseDomain is the universe of public Google Cloud
+const defaultUniverseDomain = "googleapis.com"
+
+// checkGKEEndpoint checks that a Container API endpoint override is an https URL in
+// universeDomain, the universe of the credentials: another universe rejects their
+// tokens with an error that does not name the mismatch. An empty endpoint is valid.
+func checkGKEEndpoint(endpoint, universeDomain string) error {
+	if endpoint == "" {
+		return nil
+	}
+
+	parsed, err := url.Parse(endpoint)
+	if err != nil || parsed.Scheme != "https" || parsed.Hostname() == "" {
+		return errors.New(
+			errors.ErrConfigInvalid,
+			"GKE endpoint must be an https URL such as https://container.googleapis.com/",
+		).WithFields(map[string]interface{}{
+			"provider":     "gcp",
+			"gke_endpoint": endpoint,
+		})
+	}
+
+	host := parsed.Hostname()
+	if host != universeDomain && !strings.HasSuffix(host, "."+universeDomain) {
+		return errors.New(
+			errors.ErrConfigInvalid,
+			"GKE endpoint is not in the universe domain of the credentials",
+		).WithFields(map[string]interface{}{
+			"provider":        "gcp",
+			"gke_endpoint":    endpoint,
+			"universe_domain": universeDomain,
+		}).WithDetail("use credentials from the universe of --gke-endpoint, or an endpoint under " + universeDomain)
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package gcp
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/testutil"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+)
+
+func TestValidateClusterName(t *testing.T) {
+	tests := []struct {
+		name        string
+		clusterName string
+		wantErr     bool
+	}{
+		{name: "simple", clusterName: "my-cluster"},
+		{name: "single letter", clusterName: "c"},
+		{name: "maximum length", clusterName: "c" + strings.Repeat("a", 39)},
+		{name: "empty", clusterName: "", wantErr: true},
+		{name: "too long", clusterName: "c" + strings.Repeat("a", 40), wantErr: true},
+		{name: "uppercase", clusterName: "My-Cluster", wantErr: true},
+		{name: "starts with digit", clusterName: "1-cluster", wantErr: true},
+		{name: "ends with hyphen", clusterName: "my-cluster-", wantErr: true},
+		{name: "underscore", clusterName: "my_cluster", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateClusterName(tt.clusterName)
+			if !tt.wantErr {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, errors.ErrInvalidArgument))
+		})
+	}
+}
+
+func TestValidateProjectID(t *testing.T) {
+	tests := []struct {
+		projectID string
+		wantErr   bool
+	}{
+		{projectID: ""},
+		{projectID: "my-project"},
+		{projectID: "my-project-123456"},
+		{projectID: "example.com:my-project"},
+		{projectID: "short", wantErr: true},
+		{projectID: "My-Project", wantErr: true},
+		{projectID: "1-project", wantErr: true},
+		{projectID: "my-project-", wantErr: true},
+		{projectID: "projects/my-project", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.projectID, func(t *testing.T) {
+			err := validateProjectID(tt.projectID)
+			if !tt.wantErr {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, errors.ErrInvalidArgument))
+		})
+	}
+}
+
+func TestParseLocation(t *testing.T) {
+	tests := []struct {
+		location string
+		want     LocationType
+		wantErr  bool
+	}{
+		{location: "us-central1", want: LocationRegion},
+		{location: "northamerica-northeast2", want: LocationRegion},
+		{location: "us-central1-a", want: LocationZone},
+		{location: "europe-west4-c", want: LocationZone},
+		{location: "", wantErr: true},
+		{location: "us-central", wantErr: true},
+		{location: "us-east-1", wantErr: true},
+		{location: "us-central1-ab", wantErr: true},
+		{location: "US-CENTRAL1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.location, func(t *testing.T) {
+			got, err := ParseLocation(tt.location)
+			if !tt.wantErr {
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+				return
+			}
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, errors.ErrInvalidArgument))
+			var appErr *errors.Error
+			require.True(t, errors.As(err, &appErr))
+			assert.Equal(t, tt.location, appErr.Fields["location"])
+		})
+	}
+}
+
+// Invalid input is rejected before any credential is loaded
+func TestProvider_ValidatesBeforeLoadingCredentials(t *testing.T) {
+	loader := testutil.NewMockCredLoader().
+		WithGCPError(errors.New(errors.ErrCredentialNotFound, "credentials must not be loaded"))
+	p, err := NewProvider(&Config{ProjectID: "my-project", UseADC: ADCModeNever}, logger.Nop())
+	require.NoError(t, err)
+	p.credLoader = loader
+	p.tokenGenerator.credLoader = loader
+
+	tests := []struct {
+		name string
+		call func() error
+	}{
+		{
+			name: "token with invalid cluster name",
+			call: func() error {
+				_, err := p.GetToken(context.Background(), provider.GetTokenOptions{ClusterName: "My_Cluster"})
+				return err
+			},
+		},
+		{
+			name: "token with invalid project",
+			call: func() error {
+				_, err := p.GetToken(context.Background(), provider.GetTokenOptions{ClusterName: "my-cluster", ProjectID: "Bad"})
+				return err
+			},
+		},
+		{
+			name: "token with invalid location",
+			call: func() error {
+				_, err := p.GetToken(context.Background(), provider.GetTokenOptions{ClusterName: "my-cluster", Region: "us-east-1"})
+				return err
+			},
+		},
+		{
+			name: "cluster info without location",
+			call: func() error {
+				_, err := p.GetClusterInfo(context.Background(), "my-cluster", "")
+				return err
+			},
+		},
+		{
+			name: "cluster info with invalid location",
+			call: func() error {
+				_, err := p.GetClusterInfo(context.Background(), "my-cluster", "us-central")
+				return err
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call()
+			assert.True(t, errors.Is(err, errors.ErrInvalidArgument), "got %v", err)
+		})
+	}
+}
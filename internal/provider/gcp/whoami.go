@@ -0,0 +1,134 @@
+package gcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/credentials"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+)
+
+// defaultTokenInfoURL is Google's OAuth2 token introspection endpoint
+const defaultTokenInfoURL = "https://oauth2.googleapis.com/tokeninfo"
+
+// serviceAccountDomain is the email domain of Google service accounts
+const serviceAccountDomain = ".gserviceaccount.com"
+
+// tokenInfo is the part of a tokeninfo response that identifies the principal
+type tokenInfo struct {
+	Email string `json:"email"`
+	Sub   string `json:"sub"`
+	Azp   string `json:"azp"`
+}
+
+// WhoAmI implements provider.IdentityReporter. A service account key identifies
+// itself; application default credentials without one, such as the metadata
+// server or gcloud user credentials, are identified by introspecting a token.
+func (p *Provider) WhoAmI(ctx context.Context) (*provider.Identity, error) {
+	if !p.tokenGenerator.usesADC() {
+		creds, err := p.tokenGenerator.loadCredentials(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return identityFromServiceAccount(creds, "credentials file"), nil
+	}
+
+	adc, err := p.tokenGenerator.defaultCredentials(ctx, p.config.Scopes...)
+	if err != nil {
+		return nil, err
+	}
+
+	var creds credentials.GCPCredentials
+	if len(adc.JSON) > 0 && json.Unmarshal(adc.JSON, &creds) == nil && creds.ClientEmail != "" {
+		identity := identityFromServiceAccount(&creds, "application default credentials")
+		if identity.Account == "" {
+			identity.Account = adc.ProjectID
+		}
+		return identity, nil
+	}
+
+	token, err := tokenWithContext(ctx, adc.TokenSource)
+	if err != nil {
+		return nil, errors.Wrap(
+			errors.ErrCredentialValidationFailed,
+			err,
+			"failed to get a token from GCP application default credentials",
+		).WithField("provider", "gcp")
+	}
+
+	info, err := p.tokenGenerator.introspect(ctx, token.AccessToken)
+	if err != nil {
+		return nil, errors.Wrap(
+			errors.ErrCredentialValidationFailed,
+			err,
+			"failed to introspect GCP access token",
+		).WithField("provider", "gcp")
+	}
+
+	identity := identityFromTokenInfo(info)
+	identity.Account = adc.ProjectID
+	return identity, nil
+}
+
+// introspect asks the tokeninfo endpoint who accessToken belongs to. The token is
+// sent in the request body so that it does not appear in proxy or server logs.
+func (g *TokenGenerator) introspect(ctx context.Context, accessToken string) (*tokenInfo, error) {
+	body := url.Values{"access_token": {accessToken}}.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.tokenInfoURL, strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := g.config.httpClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("tokeninfo returned HTTP %d", resp.StatusCode)
+	}
+
+	var info tokenInfo
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return nil, fmt.Errorf("failed to decode tokeninfo response: %w", err)
+	}
+	return &info, nil
+}
+
+// identityFromServiceAccount describes the service account in a key file
+func identityFromServiceAccount(creds *credentials.GCPCredentials, source string) *provider.Identity {
+	return &provider.Identity{
+		Type:    "service_account",
+		Account: creds.ProjectID,
+		UserID:  creds.ClientID,
+		Email:   creds.ClientEmail,
+		Source:  source,
+	}
+}
+
+// identityFromTokenInfo describes the principal of an introspected token
+func identityFromTokenInfo(info *tokenInfo) *provider.Identity {
+	identity := &provider.Identity{
+		UserID: info.Sub,
+		Email:  info.Email,
+		Source: "tokeninfo",
+	}
+	if identity.UserID == "" {
+		identity.UserID = info.Azp
+	}
+
+	switch {
+	case strings.HasSuffix(info.Email, serviceAccountDomain):
+		identity.Type = "service_account"
+	case info.Email != "":
+		identity.Type = "user"
+	}
+	return identity
+}
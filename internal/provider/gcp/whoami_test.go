@@ -0,0 +1,158 @@
+package gcp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2/google"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/testutil"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+)
+
+func TestIdentityFromTokenInfo(t *testing.T) {
+	tests := []struct {
+		name       string
+		info       tokenInfo
+		wantType   string
+		wantUserID string
+	}{
+		{
+			name:       "service account",
+			info:       tokenInfo{Email: "sa@my-project.iam.gserviceaccount.com", Sub: "1234", Azp: "1234"},
+			wantType:   "service_account",
+			wantUserID: "1234",
+		},
+		{
+			name:       "user",
+			info:       tokenInfo{Email: "alice@example.com", Sub: "5678", Azp: "client.apps.googleusercontent.com"},
+			wantType:   "user",
+			wantUserID: "5678",
+		},
+		{
+			name:       "no email scope",
+			info:       tokenInfo{Azp: "1234"},
+			wantType:   "",
+			wantUserID: "1234",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			identity := identityFromTokenInfo(&tt.info)
+			assert.Equal(t, tt.wantType, identity.Type)
+			assert.Equal(t, tt.wantUserID, identity.UserID)
+			assert.Equal(t, tt.info.Email, identity.Email)
+			assert.Equal(t, "tokeninfo", identity.Source)
+		})
+	}
+}
+
+func TestProvider_WhoAmI_CredentialsFile(t *testing.T) {
+	creds := testutil.CreateValidGCPCredentials()
+	config := &Config{ProjectID: creds.ProjectID, CredentialsFile: "/sa.json", Scopes: DefaultScopes(), UseADC: ADCModeNever}
+	gcpProvider := &Provider{
+		config:         config,
+		logger:         logger.Nop(),
+		tokenGenerator: NewTokenGenerator(config, testutil.NewMockCredLoader().WithGCPCreds(creds), logger.Nop()),
+	}
+
+	identity, err := gcpProvider.WhoAmI(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "service_account", identity.Type)
+	assert.Equal(t, creds.ClientEmail, identity.Email)
+	assert.Equal(t, creds.ClientID, identity.UserID)
+	assert.Equal(t, creds.ProjectID, identity.Account)
+	assert.Equal(t, "credentials file", identity.Source)
+}
+
+func TestProvider_WhoAmI_ADC(t *testing.T) {
+	tests := []struct {
+		name        string
+		json        []byte
+		status      int
+		response    string
+		wantType    string
+		wantEmail   string
+		wantSource  string
+		wantAccount string
+		wantErrCode errors.ErrorCode
+	}{
+		{
+			name:        "service account key",
+			json:        []byte(`{"type":"service_account","project_id":"key-project","client_email":"sa@key-project.iam.gserviceaccount.com","client_id":"42"}`),
+			wantType:    "service_account",
+			wantEmail:   "sa@key-project.iam.gserviceaccount.com",
+			wantSource:  "application default credentials",
+			wantAccount: "key-project",
+		},
+		{
+			name:        "metadata server",
+			status:      http.StatusOK,
+			response:    `{"email":"node@adc-project.iam.gserviceaccount.com","sub":"42"}`,
+			wantType:    "service_account",
+			wantEmail:   "node@adc-project.iam.gserviceaccount.com",
+			wantSource:  "tokeninfo",
+			wantAccount: "adc-project",
+		},
+		{
+			name:        "gcloud user credentials",
+			json:        []byte(`{"type":"authorized_user","client_id":"client.apps.googleusercontent.com"}`),
+			status:      http.StatusOK,
+			response:    `{"email":"alice@example.com","sub":"7"}`,
+			wantType:    "user",
+			wantEmail:   "alice@example.com",
+			wantSource:  "tokeninfo",
+			wantAccount: "adc-project",
+		},
+		{
+			name:        "token rejected",
+			status:      http.StatusBadRequest,
+			response:    `{"error_description":"Invalid Value"}`,
+			wantErrCode: errors.ErrCredentialValidationFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, http.MethodPost, r.Method)
+				assert.Equal(t, "adc-token", r.FormValue("access_token"))
+				assert.Empty(t, r.URL.RawQuery, "the token is not sent in the URL")
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.response))
+			}))
+			defer server.Close()
+
+			config := &Config{Scopes: DefaultScopes(), UseADC: ADCModeAlways}
+			generator := NewTokenGenerator(config, testutil.NewMockCredLoader(), logger.Nop())
+			generator.tokenInfoURL = server.URL
+			findADC := fakeADC("adc-project", "adc-token", nil)
+			generator.findDefaultCredentials = func(ctx context.Context, scopes ...string) (*google.Credentials, error) {
+				adc, err := findADC(ctx, scopes...)
+				if err == nil {
+					adc.JSON = tt.json
+				}
+				return adc, err
+			}
+			gcpProvider := &Provider{config: config, logger: logger.Nop(), tokenGenerator: generator}
+
+			identity, err := gcpProvider.WhoAmI(context.Background())
+			if tt.wantErrCode != "" {
+				require.Error(t, err)
+				assert.True(t, errors.Is(err, tt.wantErrCode), "expected error code %s, got %v", tt.wantErrCode, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantEmail, identity.Email)
+			assert.Equal(t, tt.wantSource, identity.Source)
+			assert.Equal(t, tt.wantAccount, identity.Account)
+			assert.Equal(t, tt.wantType, identity.Type)
+		})
+	}
+}
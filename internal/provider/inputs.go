@@ -0,0 +1,39 @@
+package provider
+
+// Operation is a command operation that needs provider-specific inputs
+type Operation uint8
+
+const (
+	// OperationClusterLookup describes a cluster (get-cluster-info, get-token --verify)
+	OperationClusterLookup Operation = 1 << iota
+
+	// OperationKubeconfig writes a kubeconfig whose exec plugin repeats the inputs
+	OperationKubeconfig
+)
+
+// Input is a provider-specific option, named like its CLI flag (for example "project-id")
+type Input struct {
+	// Name is the flag name; the environment variable is HFCP_ followed by the
+	// upper-cased name with hyphens replaced by underscores
+	Name string
+
+	// RequiredFor lists the operations that fail without the input
+	RequiredFor Operation
+
+	// Note is appended to the missing-input message (optional)
+	Note string
+
+	// Satisfied reports whether a missing input is supplied another way, given the
+	// values of all inputs by name (optional)
+	Satisfied func(values map[string]string) bool
+
+	// Validate checks a non-empty value (optional)
+	Validate func(value string) error
+}
+
+// InputsOf returns the provider-specific inputs of the named provider, in the order
+// they were registered
+func InputsOf(name string) []Input {
+	registration, _ := registrationOf(name)
+	return registration.Inputs
+}
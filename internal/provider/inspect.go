@@ -0,0 +1,117 @@
+package provider
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Redacted is the placeholder printed in place of sensitive token material
+const Redacted = "[REDACTED]"
+
+// RedactPartial keeps the first and last n characters of s and masks the rest.
+// Values too short to partially reveal are fully redacted.
+func RedactPartial(s string, n int) string {
+	if s == "" {
+		return ""
+	}
+	if len(s) <= 2*n+4 {
+		return Redacted
+	}
+	return s[:n] + "****" + s[len(s)-n:]
+}
+
+// RedactUnlessUnsafe returns s when unsafe is set and Redacted otherwise
+func RedactUnlessUnsafe(s string, unsafe bool) string {
+	if s == "" || unsafe {
+		return s
+	}
+	return Redacted
+}
+
+// JWT is a decoded JSON Web Token. The signature is NOT verified.
+type JWT struct {
+	Header    map[string]interface{}
+	Claims    map[string]interface{}
+	Signature string
+}
+
+// IsJWT reports whether token looks like a compact-serialized JWT
+func IsJWT(token string) bool {
+	return strings.Count(token, ".") == 2 && strings.HasPrefix(token, "eyJ")
+}
+
+// DecodeJWT decodes the header and claims of a JWT without verifying its signature.
+// It is intended for debugging only.
+func DecodeJWT(token string) (*JWT, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("token is not a JWT: expected 3 segments, got %d", len(parts))
+	}
+
+	header, err := decodeJWTSegment(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode JWT header: %w", err)
+	}
+
+	claims, err := decodeJWTSegment(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode JWT claims: %w", err)
+	}
+
+	return &JWT{
+		Header:    header,
+		Claims:    claims,
+		Signature: parts[2],
+	}, nil
+}
+
+func decodeJWTSegment(segment string) (map[string]interface{}, error) {
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(segment, "="))
+	if err != nil {
+		return nil, err
+	}
+
+	var out map[string]interface{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// StringClaim returns a string claim, or "" when absent. Array-valued claims
+// (such as a multi-valued aud) are joined with commas.
+func (j *JWT) StringClaim(name string) string {
+	switch v := j.Claims[name].(type) {
+	case string:
+		return v
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return strings.Join(values, ",")
+	default:
+		return ""
+	}
+}
+
+// TimeClaim returns a NumericDate claim (exp, iat, nbf) as a time, or nil when absent
+func (j *JWT) TimeClaim(name string) *time.Time {
+	v, ok := j.Claims[name].(float64)
+	if !ok {
+		return nil
+	}
+	t := time.Unix(int64(v), 0).UTC()
+	return &t
+}
+
+// HeaderString returns a string header parameter (alg, kid, typ), or "" when absent
+func (j *JWT) HeaderString(name string) string {
+	s, _ := j.Header[name].(string)
+	return s
+}
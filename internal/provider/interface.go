@@ -13,7 +13,15 @@ type Provider interface {
 	// ValidateCredentials verifies that credentials are valid
 	ValidateCredentials(ctx context.Context) error
 
-	// Name returns the provider name (gcp, aws, azure)
+	// ValidateToken checks that a token issued by the provider is well-formed and
+	// has not expired, without calling the cloud
+	ValidateToken(token *Token) error
+
+	// RefreshToken returns currentToken while it is valid and outside the provider's
+	// refresh threshold, and otherwise generates a new token like GetToken
+	RefreshToken(ctx context.Context, opts GetTokenOptions, currentToken *Token) (*Token, error)
+
+	// Name returns the provider name (gcp, aws, azure, oci, digitalocean, oidc)
 	Name() string
 }
 
@@ -31,6 +39,10 @@ type GetTokenOptions struct {
 	// AccountID is the AWS account ID (AWS only, optional)
 	AccountID string
 
+	// ClusterID is the cluster ID the token is bound to when it differs from
+	// ClusterName, as with EKS Anywhere and aws-iam-authenticator (AWS only, optional)
+	ClusterID string
+
 	// SubscriptionID is the Azure subscription ID (Azure only)
 	SubscriptionID string
 
@@ -39,6 +51,13 @@ type GetTokenOptions struct {
 
 	// ResourceGroup is the Azure resource group (Azure only, optional)
 	ResourceGroup string
+
+	// CompartmentID is the compartment searched for a cluster given by name (OCI only, optional)
+	CompartmentID string
+
+	// Audience binds the token to an audience other than the cluster default (optional).
+	// Only providers registered with Capabilities.BoundAudience support it.
+	Audience string
 }
 
 // Token represents a Kubernetes authentication token
@@ -51,16 +70,146 @@ type Token struct {
 
 	// TokenType is the token type (usually "Bearer")
 	TokenType string
+
+	// The fields below describe how the token was issued. Generators set them, and
+	// they are not part of the ExecCredential, so a token read back from one has
+	// none of them.
+
+	// Provider is the name of the provider that issued the token
+	Provider string
+
+	// ClusterName is the cluster the token was generated for
+	ClusterName string
+
+	// Identity names the principal that minted the token: a service account email, a
+	// partially redacted AWS access key ID or an Azure client ID. It is safe to log.
+	Identity string
+
+	// Audience is the audience the token is bound to, when the provider binds one
+	Audience string
+
+	// Scopes are the OAuth2 scopes the token was requested with, when the provider
+	// requests any
+	Scopes []string
 }
 
 // IsExpired returns true if the token has expired
 func (t *Token) IsExpired() bool {
-	return time.Now().After(t.ExpiresAt)
+	return t.IsExpiredWith(SystemClock)
 }
 
 // ExpiresIn returns the duration until the token expires
 func (t *Token) ExpiresIn() time.Duration {
-	return time.Until(t.ExpiresAt)
+	return t.ExpiresInWith(SystemClock)
+}
+
+// IsExpiredWith returns true if the token has expired at the time of clock
+func (t *Token) IsExpiredWith(clock Clock) bool {
+	return clock.Now().After(t.ExpiresAt)
+}
+
+// ExpiresInWith returns the duration from the time of clock until the token expires
+func (t *Token) ExpiresInWith(clock Clock) time.Duration {
+	return t.ExpiresAt.Sub(clock.Now())
+}
+
+// NeedsRefresh returns true if the token has expired or expires within threshold plus
+// ClockSkew at the time of clock. A token expiring exactly at that point is refreshed.
+func (t *Token) NeedsRefresh(clock Clock, threshold time.Duration) bool {
+	return t.IsExpiredWith(clock) || t.ExpiresInWith(clock) <= threshold+ClockSkew()
+}
+
+// ExpiresWithinSkew returns true if the token has expired or expires within ClockSkew
+// at the time of clock, when a server whose clock is ahead may already reject it
+func (t *Token) ExpiresWithinSkew(clock Clock) bool {
+	return t.IsExpiredWith(clock) || t.ExpiresInWith(clock) <= ClockSkew()
+}
+
+// ClusterDescriber is implemented by providers that can look up the API server
+// endpoint and CA certificate of a cluster
+type ClusterDescriber interface {
+	// DescribeCluster returns the connection details of a cluster
+	DescribeCluster(ctx context.Context, opts ClusterInfoOptions) (*ClusterInfo, error)
+}
+
+// ClusterInfoOptions identifies the cluster to describe
+type ClusterInfoOptions struct {
+	// ClusterName is the Kubernetes cluster name
+	ClusterName string
+
+	// Region is the cloud region or location (GCP, AWS and OCI)
+	Region string
+
+	// ResourceGroup is the Azure resource group (Azure only)
+	ResourceGroup string
+
+	// EndpointAccess picks the public or private API server endpoint of clusters that
+	// have both: private, public, or empty for the provider default
+	EndpointAccess string
+}
+
+// ClusterInfo is the provider-independent description of a cluster
+type ClusterInfo struct {
+	// Endpoint is the cluster API server URL (with https://)
+	Endpoint string
+
+	// CertificateAuthority is the base64-encoded cluster CA certificate
+	CertificateAuthority string
+
+	// Version is the Kubernetes version
+	Version string
+
+	// Location is the cluster location (GCP and Azure)
+	Location string
+
+	// Region is the cluster region (AWS, OCI and DigitalOcean)
+	Region string
+
+	// ARN is the cluster ARN (AWS only)
+	ARN string
+
+	// ResourceID is the cluster resource ID (Azure), OCID (OCI) or UUID (DigitalOcean)
+	ResourceID string
+}
+
+// IdentityReporter is implemented by providers that can report the identity their
+// credentials authenticate as
+type IdentityReporter interface {
+	// WhoAmI returns the authenticated identity
+	WhoAmI(ctx context.Context) (*Identity, error)
+}
+
+// Identity is the provider-independent description of an authenticated principal.
+// Fields a provider does not report are empty.
+type Identity struct {
+	// Type is the kind of principal, such as user, assumed-role, service_account
+	// or service_principal
+	Type string
+
+	// Account is the AWS account ID or GCP project ID
+	Account string
+
+	// ARN is the caller ARN (AWS only)
+	ARN string
+
+	// UserID is the AWS user ID or GCP client ID
+	UserID string
+
+	// Email is the service account or user email (GCP only)
+	Email string
+
+	// AppID is the service principal application (client) ID (Azure only)
+	AppID string
+
+	// ObjectID is the service principal object ID (Azure only)
+	ObjectID string
+
+	// TenantID is the Azure tenant ID (Azure only)
+	TenantID string
+
+	// Source says where the identity was read from, such as the STS API or a
+	// credentials file
+	Source string
 }
 
 // ProviderName represents a cloud provider name
@@ -75,6 +224,15 @@ const (
 
 	// ProviderAzure is Microsoft Azure
 	ProviderAzure ProviderName = "azure"
+
+	// ProviderOCI is Oracle Cloud Infrastructure
+	ProviderOCI ProviderName = "oci"
+
+	// ProviderDigitalOcean is DigitalOcean
+	ProviderDigitalOcean ProviderName = "digitalocean"
+
+	// ProviderOIDC is a generic OpenID Connect issuer
+	ProviderOIDC ProviderName = "oidc"
 )
 
 // String returns the string representation of the provider name
@@ -85,7 +243,7 @@ func (p ProviderName) String() string {
 // IsValid returns true if the provider name is valid
 func (p ProviderName) IsValid() bool {
 	switch p {
-	case ProviderGCP, ProviderAWS, ProviderAzure:
+	case ProviderGCP, ProviderAWS, ProviderAzure, ProviderOCI, ProviderDigitalOcean, ProviderOIDC:
 		return true
 	default:
 		return false
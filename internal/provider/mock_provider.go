@@ -3,6 +3,8 @@ package provider
 import (
 	"context"
 	"time"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
 )
 
 // MockProvider is a mock implementation of Provider for testing
@@ -10,6 +12,8 @@ type MockProvider struct {
 	NameValue                string
 	GetTokenFunc             func(ctx context.Context, opts GetTokenOptions) (*Token, error)
 	ValidateCredentialsFunc  func(ctx context.Context) error
+	ValidateTokenFunc        func(token *Token) error
+	RefreshTokenFunc         func(ctx context.Context, opts GetTokenOptions, currentToken *Token) (*Token, error)
 }
 
 // GetToken implements Provider
@@ -33,6 +37,28 @@ func (m *MockProvider) ValidateCredentials(ctx context.Context) error {
 	return nil
 }
 
+// ValidateToken implements Provider. By default it only rejects empty and expired tokens.
+func (m *MockProvider) ValidateToken(token *Token) error {
+	if m.ValidateTokenFunc != nil {
+		return m.ValidateTokenFunc(token)
+	}
+	if token == nil || token.AccessToken == "" {
+		return errors.New(errors.ErrTokenInvalid, "token is empty")
+	}
+	if token.IsExpired() {
+		return errors.New(errors.ErrTokenExpired, "token has expired")
+	}
+	return nil
+}
+
+// RefreshToken implements Provider
+func (m *MockProvider) RefreshToken(ctx context.Context, opts GetTokenOptions, currentToken *Token) (*Token, error) {
+	if m.RefreshTokenFunc != nil {
+		return m.RefreshTokenFunc(ctx, opts, currentToken)
+	}
+	return RefreshIfNeeded(ctx, m, opts, currentToken, SystemClock, DefaultRefreshThreshold)
+}
+
 // Name implements Provider
 func (m *MockProvider) Name() string {
 	if m.NameValue != "" {
@@ -0,0 +1,296 @@
+package oci
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/credentials"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/tracing"
+)
+
+// containerEngineAPIVersion is the version prefix of the Container Engine REST API
+const containerEngineAPIVersion = "/20180222"
+
+// ClusterInfo contains OKE cluster information
+type ClusterInfo struct {
+	// Endpoint is the cluster API server endpoint (with https://)
+	Endpoint string
+
+	// CertificateAuthority is the base64-encoded cluster CA certificate
+	CertificateAuthority string
+
+	// Version is the Kubernetes version
+	Version string
+
+	// Region is the OCI region
+	Region string
+
+	// ClusterID is the cluster OCID
+	ClusterID string
+}
+
+// okeCluster is the subset of the Container Engine cluster resource that is used
+type okeCluster struct {
+	ID                string `json:"id"`
+	Name              string `json:"name"`
+	KubernetesVersion string `json:"kubernetesVersion"`
+	LifecycleState    string `json:"lifecycleState"`
+	Endpoints         struct {
+		Kubernetes      string `json:"kubernetes"`
+		PublicEndpoint  string `json:"public-endpoint"`
+		PrivateEndpoint string `json:"private-endpoint"`
+	} `json:"endpoints"`
+}
+
+// apiClient calls the Container Engine API of one region with signed requests
+type apiClient struct {
+	httpClient *http.Client
+	baseURL    string
+	signer     *requestSigner
+	tracing    *tracing.Provider
+}
+
+// GetClusterInfo retrieves the endpoint, CA and version of an OKE cluster. The cluster
+// may be given by OCID or by name; a name is looked up in the configured compartment.
+func (p *Provider) GetClusterInfo(ctx context.Context, clusterName, region string) (*ClusterInfo, error) {
+	ctx, span := provider.StartSpan(ctx, p.config.Tracing, provider.ProviderOCI, "GetClusterInfo", clusterName, region)
+	info, err := p.getClusterInfo(ctx, clusterName, region)
+	provider.EndSpan(ctx, span, err)
+	return info, err
+}
+
+// getClusterInfo is GetClusterInfo without tracing
+func (p *Provider) getClusterInfo(ctx context.Context, clusterName, region string) (*ClusterInfo, error) {
+	creds, region, err := p.loadCredentials(ctx, region)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := p.newAPIClient(creds, region)
+	if err != nil {
+		return nil, err
+	}
+
+	p.logger.Info("Getting OKE cluster info",
+		logger.String("cluster", clusterName),
+		logger.String("region", region),
+	)
+
+	clusterID, err := client.resolveClusterID(ctx, clusterName, p.config.CompartmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	var cluster okeCluster
+	if err := client.do(ctx, http.MethodGet, containerEngineAPIVersion+"/clusters/"+clusterID, nil, &cluster); err != nil {
+		return nil, err
+	}
+
+	endpointType := "PUBLIC_ENDPOINT"
+	if cluster.Endpoints.PublicEndpoint == "" {
+		endpointType = "PRIVATE_ENDPOINT"
+	}
+	server, caCert, err := client.kubeconfigContent(ctx, clusterID, endpointType)
+	if err != nil {
+		return nil, err
+	}
+	if err := provider.ValidateCertificateAuthority(caCert); err != nil {
+		p.logger.Error("Cluster returned an invalid CA certificate",
+			logger.String("cluster", clusterName),
+			logger.Error(err),
+		)
+		return nil, err
+	}
+
+	info := &ClusterInfo{
+		Endpoint:             server,
+		CertificateAuthority: caCert,
+		Version:              cluster.KubernetesVersion,
+		Region:               region,
+		ClusterID:            clusterID,
+	}
+
+	provider.LoggerWithBaggage(ctx, p.config.Tracing, p.logger).Info("Successfully retrieved cluster info",
+		logger.String("cluster", clusterName),
+		logger.String("endpoint", info.Endpoint),
+		logger.String("version", info.Version),
+	)
+
+	return info, nil
+}
+
+// newAPIClient creates a Container Engine client signing with creds
+func (p *Provider) newAPIClient(creds *credentials.OCICredentials, region string) (*apiClient, error) {
+	signer, err := newRequestSigner(creds, p.tokenGenerator.now)
+	if err != nil {
+		return nil, err
+	}
+
+	return &apiClient{
+		httpClient: p.httpClient,
+		baseURL:    p.tokenGenerator.endpoint(region),
+		signer:     signer,
+		tracing:    p.config.Tracing,
+	}, nil
+}
+
+// resolveClusterID returns the OCID of the named cluster. OCIDs are returned as is;
+// names are listed in compartmentID, which is then required.
+func (c *apiClient) resolveClusterID(ctx context.Context, name, compartmentID string) (string, error) {
+	if isClusterOCID(name) {
+		return name, nil
+	}
+	if compartmentID == "" {
+		return "", errors.New(
+			errors.ErrInvalidArgument,
+			"--compartment-id is required to look up an OKE cluster by name",
+		).WithField("cluster", name).
+			WithDetail("pass the cluster OCID as --cluster-name or set --compartment-id")
+	}
+
+	query := url.Values{}
+	query.Set("compartmentId", compartmentID)
+	query.Set("name", name)
+
+	var clusters []okeCluster
+	if err := c.do(ctx, http.MethodGet, containerEngineAPIVersion+"/clusters?"+query.Encode(), nil, &clusters); err != nil {
+		return "", err
+	}
+
+	var ids []string
+	for _, cluster := range clusters {
+		if cluster.Name == name && cluster.LifecycleState != "DELETED" && cluster.LifecycleState != "DELETING" {
+			ids = append(ids, cluster.ID)
+		}
+	}
+	switch len(ids) {
+	case 0:
+		return "", errors.New(
+			errors.ErrClusterNotFound,
+			fmt.Sprintf("OKE cluster %s not found", name),
+		).WithFields(map[string]interface{}{
+			"cluster":        name,
+			"compartment_id": compartmentID,
+		})
+	case 1:
+		return ids[0], nil
+	default:
+		return "", errors.New(
+			errors.ErrClusterInvalidConfig,
+			fmt.Sprintf("more than one OKE cluster is named %s", name),
+		).WithField("cluster_ids", ids).
+			WithDetail("pass the cluster OCID as --cluster-name")
+	}
+}
+
+// kubeconfigContent fetches the cluster kubeconfig and returns its server and CA data
+func (c *apiClient) kubeconfigContent(ctx context.Context, clusterID, endpointType string) (string, string, error) {
+	body, err := json.Marshal(map[string]string{
+		"tokenVersion": "2.0.0",
+		"endpoint":     endpointType,
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	var raw bytes.Buffer
+	if err := c.do(ctx, http.MethodPost, containerEngineAPIVersion+"/clusters/"+clusterID+"/kubeconfig/content", body, &raw); err != nil {
+		return "", "", err
+	}
+
+	var kubeconfig struct {
+		Clusters []struct {
+			Cluster struct {
+				Server                   string `yaml:"server"`
+				CertificateAuthorityData string `yaml:"certificate-authority-data"`
+			} `yaml:"cluster"`
+		} `yaml:"clusters"`
+	}
+	if err := yaml.Unmarshal(raw.Bytes(), &kubeconfig); err != nil {
+		return "", "", errors.Wrap(errors.ErrClusterInvalidConfig, err, "failed to parse OKE kubeconfig").
+			WithField("cluster_id", clusterID)
+	}
+	if len(kubeconfig.Clusters) == 0 || kubeconfig.Clusters[0].Cluster.Server == "" {
+		return "", "", errors.New(errors.ErrClusterInvalidConfig, "OKE kubeconfig has no cluster server").
+			WithField("cluster_id", clusterID)
+	}
+
+	cluster := kubeconfig.Clusters[0].Cluster
+	return cluster.Server, cluster.CertificateAuthorityData, nil
+}
+
+// do sends a signed request and decodes the JSON response into out. A *bytes.Buffer
+// out receives the raw body instead.
+func (c *apiClient) do(ctx context.Context, method, path string, body []byte, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, nil)
+	if err != nil {
+		return errors.Wrap(errors.ErrInternal, err, "failed to build OCI request")
+	}
+	req.Header.Set("Accept", "application/json")
+	if err := c.signer.Sign(req, body); err != nil {
+		return err
+	}
+	c.tracing.InjectBaggage(ctx, req.Header)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return errors.Wrap(errors.ErrNetworkUnreachable, err, "OCI request failed").
+			WithField("url", c.baseURL+path)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrap(errors.ErrNetworkUnreachable, err, "failed to read OCI response")
+	}
+	if resp.StatusCode >= 300 {
+		return apiError(resp.StatusCode, data)
+	}
+
+	if buf, ok := out.(*bytes.Buffer); ok {
+		buf.Write(data)
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return errors.Wrap(errors.ErrInternal, err, "failed to decode OCI response")
+	}
+	return nil
+}
+
+// apiError maps an OCI API error response to an error code
+func apiError(status int, body []byte) error {
+	var payload struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	}
+	_ = json.Unmarshal(body, &payload)
+
+	code := errors.ErrInternal
+	switch status {
+	case http.StatusUnauthorized:
+		code = errors.ErrUnauthenticated
+	case http.StatusForbidden:
+		code = errors.ErrPermissionDenied
+	case http.StatusNotFound:
+		code = errors.ErrClusterNotFound
+	}
+
+	message := payload.Message
+	if message == "" {
+		message = http.StatusText(status)
+	}
+	return errors.New(code, "OCI API error: "+message).WithFields(map[string]interface{}{
+		"status":   status,
+		"oci_code": payload.Code,
+	})
+}
@@ -0,0 +1,200 @@
+package oci
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/testutil"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+)
+
+// testCA returns a base64-encoded self-signed CA certificate
+func testCA(t *testing.T) string {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "oke-cluster-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	return base64.StdEncoding.EncodeToString(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+// newTestProvider returns a provider whose Container Engine API is served by handler
+func newTestProvider(t *testing.T, config *Config, handler http.Handler) *Provider {
+	t.Helper()
+
+	server := httptest.NewTLSServer(handler)
+	t.Cleanup(server.Close)
+
+	creds, _ := testCredentials(t)
+	p, err := NewProvider(config, logger.Nop())
+	require.NoError(t, err)
+	p.credLoader = testutil.NewMockCredLoader().WithOCICreds(creds)
+	p.httpClient = server.Client()
+	p.tokenGenerator.endpoint = func(region string) string { return server.URL }
+	return p
+}
+
+// fakeContainerEngine serves one cluster named my-cluster and records the requests it received
+func fakeContainerEngine(t *testing.T, ca string, requests *[]string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/20180222/clusters", func(w http.ResponseWriter, r *http.Request) {
+		*requests = append(*requests, r.Method+" "+r.URL.RequestURI())
+		clusters := []map[string]string{}
+		if r.URL.Query().Get("name") == "my-cluster" {
+			clusters = append(clusters,
+				map[string]string{"id": "ocid1.cluster.oc1.iad.old", "name": "my-cluster", "lifecycleState": "DELETED"},
+				map[string]string{"id": testClusterID, "name": "my-cluster", "lifecycleState": "ACTIVE"},
+			)
+		}
+		json.NewEncoder(w).Encode(clusters)
+	})
+	mux.HandleFunc("/20180222/clusters/"+testClusterID, func(w http.ResponseWriter, r *http.Request) {
+		*requests = append(*requests, r.Method+" "+r.URL.RequestURI())
+		fmt.Fprint(w, `{"id":"`+testClusterID+`","name":"my-cluster","kubernetesVersion":"v1.31.1","endpoints":{"public-endpoint":"203.0.113.10:6443"}}`)
+	})
+	mux.HandleFunc("/20180222/clusters/"+testClusterID+"/kubeconfig/content", func(w http.ResponseWriter, r *http.Request) {
+		*requests = append(*requests, r.Method+" "+r.URL.RequestURI())
+		var body map[string]string
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "PUBLIC_ENDPOINT", body["endpoint"])
+		assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "Signature "))
+		fmt.Fprintf(w, "apiVersion: v1\nclusters:\n- cluster:\n    certificate-authority-data: %s\n    server: https://203.0.113.10:6443\n  name: cluster-x\n", ca)
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"code":"NotAuthorizedOrNotFound","message":"Authorization failed or requested resource not found."}`)
+	})
+	return mux
+}
+
+func TestGetClusterInfo(t *testing.T) {
+	ca := testCA(t)
+
+	tests := []struct {
+		name         string
+		config       *Config
+		cluster      string
+		wantRequests int
+		wantCode     errors.ErrorCode
+	}{
+		{
+			name:         "by OCID",
+			config:       &Config{},
+			cluster:      testClusterID,
+			wantRequests: 2,
+		},
+		{
+			name:         "by name in compartment",
+			config:       &Config{CompartmentID: "ocid1.compartment.oc1..aaaa"},
+			cluster:      "my-cluster",
+			wantRequests: 3,
+		},
+		{
+			name:     "by name without compartment",
+			config:   &Config{},
+			cluster:  "my-cluster",
+			wantCode: errors.ErrInvalidArgument,
+		},
+		{
+			name:         "name not found",
+			config:       &Config{CompartmentID: "ocid1.compartment.oc1..aaaa"},
+			cluster:      "other",
+			wantRequests: 1,
+			wantCode:     errors.ErrClusterNotFound,
+		},
+		{
+			name:         "unknown OCID",
+			config:       &Config{},
+			cluster:      "ocid1.cluster.oc1.iad.missing",
+			wantRequests: 0,
+			wantCode:     errors.ErrClusterNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests []string
+			p := newTestProvider(t, tt.config, fakeContainerEngine(t, ca, &requests))
+
+			info, err := p.GetClusterInfo(context.Background(), tt.cluster, "")
+
+			assert.Len(t, requests, tt.wantRequests, "requests: %v", requests)
+			if tt.wantCode != "" {
+				assert.True(t, errors.Is(err, tt.wantCode), "got %v", err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "https://203.0.113.10:6443", info.Endpoint)
+			assert.Equal(t, ca, info.CertificateAuthority)
+			assert.Equal(t, "v1.31.1", info.Version)
+			assert.Equal(t, "us-ashburn-1", info.Region)
+			assert.Equal(t, testClusterID, info.ClusterID)
+		})
+	}
+}
+
+func TestGetToken_ResolvesClusterName(t *testing.T) {
+	var requests []string
+	p := newTestProvider(t, &Config{}, fakeContainerEngine(t, testCA(t), &requests))
+
+	token, err := p.GetToken(context.Background(), provider.GetTokenOptions{
+		ClusterName:   "my-cluster",
+		CompartmentID: "ocid1.compartment.oc1..aaaa",
+	})
+	require.NoError(t, err)
+
+	decoded, err := base64.URLEncoding.DecodeString(token.AccessToken)
+	require.NoError(t, err)
+	assert.Contains(t, string(decoded), "/cluster_request/"+testClusterID+"?")
+	assert.Len(t, requests, 1)
+}
+
+func TestAPIError(t *testing.T) {
+	tests := []struct {
+		status   int
+		wantCode errors.ErrorCode
+	}{
+		{http.StatusUnauthorized, errors.ErrUnauthenticated},
+		{http.StatusForbidden, errors.ErrPermissionDenied},
+		{http.StatusNotFound, errors.ErrClusterNotFound},
+		{http.StatusInternalServerError, errors.ErrInternal},
+	}
+
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			err := apiError(tt.status, []byte(`{"code":"X","message":"boom"}`))
+
+			assert.True(t, errors.Is(err, tt.wantCode))
+			assert.Contains(t, err.Error(), "boom")
+		})
+	}
+}
@@ -0,0 +1,216 @@
+package oci
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/credentials"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+)
+
+// apiTimeout bounds each Container Engine API call
+const apiTimeout = 30 * time.Second
+
+// Provider implements the OCI token provider
+type Provider struct {
+	config         *Config
+	logger         logger.Logger
+	tokenGenerator *TokenGenerator
+	credLoader     credentials.Loader
+	ociCredOpts    credentials.OCICredentialOptions
+	httpClient     *http.Client
+}
+
+// NewProvider creates a new OCI provider
+func NewProvider(config *Config, log logger.Logger) (*Provider, error) {
+	if config == nil {
+		config = DefaultConfig()
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	credLoader := credentials.NewLoader(log,
+		credentials.WithStrictPermissions(config.StrictPermissions),
+		credentials.WithHTTPClient(config.HTTPClient),
+	)
+
+	tokenGenerator := NewTokenGenerator(config, credLoader, log)
+
+	// Flags override the tenancy, user and region of the OCI config file
+	ociCredOpts := credentials.OCICredentialOptions{
+		ConfigFile:     config.CredentialsFile,
+		Profile:        config.Profile,
+		TenancyID:      config.TenancyID,
+		UserID:         config.UserID,
+		Region:         config.Region,
+		UseEnvironment: true,
+	}
+
+	log.Debug("OCI provider initialized",
+		logger.String("region", config.Region),
+		logger.String("compartment_id", config.CompartmentID),
+	)
+
+	return &Provider{
+		config:         config,
+		logger:         log,
+		tokenGenerator: tokenGenerator,
+		credLoader:     credLoader,
+		ociCredOpts:    ociCredOpts,
+		httpClient:     newHTTPClient(config),
+	}, nil
+}
+
+// newHTTPClient returns the client of the Container Engine calls, which uses the configured
+// transport, if any, and is bounded by apiTimeout
+func newHTTPClient(config *Config) *http.Client {
+	client := &http.Client{Timeout: apiTimeout}
+	if config.HTTPClient != nil {
+		client.Transport = config.HTTPClient.Transport
+	}
+	return client
+}
+
+// GetToken generates an OKE authentication token
+func (p *Provider) GetToken(ctx context.Context, opts provider.GetTokenOptions) (*provider.Token, error) {
+	if opts.ClusterName == "" {
+		return nil, errors.New(
+			errors.ErrInvalidArgument,
+			"cluster name is required",
+		).WithField("provider", "oci")
+	}
+
+	creds, region, err := p.loadCredentials(ctx, opts.Region)
+	if err != nil {
+		return nil, err
+	}
+
+	p.logger.Info("Generating OCI token",
+		logger.String("cluster", opts.ClusterName),
+		logger.String("region", region),
+	)
+
+	// Tokens are bound to the cluster OCID, so a cluster name costs one lookup
+	clusterID := opts.ClusterName
+	if !isClusterOCID(clusterID) {
+		compartmentID := opts.CompartmentID
+		if compartmentID == "" {
+			compartmentID = p.config.CompartmentID
+		}
+		client, err := p.newAPIClient(creds, region)
+		if err != nil {
+			return nil, err
+		}
+		if clusterID, err = client.resolveClusterID(ctx, opts.ClusterName, compartmentID); err != nil {
+			return nil, err
+		}
+	}
+
+	token, err := p.tokenGenerator.GenerateToken(ctx, creds, region, clusterID)
+	if err != nil {
+		p.logger.Error("Failed to generate OCI token",
+			logger.String("cluster", opts.ClusterName),
+			logger.String("region", region),
+			logger.Error(err),
+		)
+		return nil, err
+	}
+
+	if err := p.tokenGenerator.ValidateToken(token); err != nil {
+		return nil, err
+	}
+	// The token is bound to the OCID, but callers know the cluster by the name they gave
+	token.ClusterName = opts.ClusterName
+
+	return token, nil
+}
+
+// ValidateCredentials validates OCI credentials by loading the API key and signing a
+// test token with it
+func (p *Provider) ValidateCredentials(ctx context.Context) error {
+	p.logger.Debug("Validating OCI credentials",
+		logger.String("region", p.config.Region),
+	)
+
+	creds, region, err := p.loadCredentials(ctx, "")
+	if err != nil {
+		return errors.Wrap(
+			errors.ErrCredentialValidationFailed,
+			err,
+			"failed to validate OCI credentials",
+		).WithField("provider", "oci")
+	}
+
+	token, err := p.tokenGenerator.GenerateToken(ctx, creds, region, "ocid1.cluster.oc1..validation")
+	if err != nil {
+		return errors.Wrap(
+			errors.ErrCredentialValidationFailed,
+			err,
+			"credentials loaded but failed to generate test token",
+		).WithField("provider", "oci")
+	}
+	if err := p.tokenGenerator.ValidateToken(token); err != nil {
+		return errors.Wrap(
+			errors.ErrCredentialValidationFailed,
+			err,
+			"test token validation failed",
+		).WithField("provider", "oci")
+	}
+
+	p.logger.Info("OCI credentials validated successfully",
+		logger.String("region", region),
+	)
+	return nil
+}
+
+// ValidateToken checks a token with the token generator
+func (p *Provider) ValidateToken(token *provider.Token) error {
+	return p.tokenGenerator.ValidateToken(token)
+}
+
+// RefreshToken returns currentToken while the token generator accepts it and it is
+// outside the refresh threshold, and otherwise generates a new token with GetToken
+func (p *Provider) RefreshToken(ctx context.Context, opts provider.GetTokenOptions, currentToken *provider.Token) (*provider.Token, error) {
+	return provider.RefreshIfNeeded(ctx, p, opts, currentToken, provider.ClockFunc(p.tokenGenerator.now), provider.RefreshThresholdOf(p.Name()))
+}
+
+// Name returns the provider name
+func (p *Provider) Name() string {
+	return "oci"
+}
+
+// loadCredentials loads the API key and resolves the region: the argument, then the
+// provider configuration, then the region of the OCI config file
+func (p *Provider) loadCredentials(ctx context.Context, region string) (*credentials.OCICredentials, string, error) {
+	creds, err := p.credLoader.LoadOCI(ctx, p.ociCredOpts)
+	if err != nil {
+		p.logger.Error("Failed to load OCI credentials", logger.Error(err))
+		return nil, "", err
+	}
+
+	if region == "" {
+		region = p.config.Region
+	}
+	if region == "" {
+		region = creds.Region
+	}
+	if region == "" {
+		return nil, "", errors.New(
+			errors.ErrInvalidArgument,
+			"OCI region is required",
+		).WithField("provider", "oci").
+			WithDetail("set --region or the region of the OCI config profile")
+	}
+	if !regionPattern.MatchString(region) {
+		return nil, "", errors.New(
+			errors.ErrInvalidArgument,
+			"invalid OCI region",
+		).WithField("region", region)
+	}
+
+	return creds, region, nil
+}
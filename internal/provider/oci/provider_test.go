@@ -0,0 +1,147 @@
+package oci
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/credentials"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+)
+
+// testCredentials returns OCI credentials with a freshly generated API key
+func testCredentials(t *testing.T) (*credentials.OCICredentials, *rsa.PrivateKey) {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	return &credentials.OCICredentials{
+		TenancyID:   "ocid1.tenancy.oc1..aaaatenancy",
+		UserID:      "ocid1.user.oc1..aaaauser",
+		Fingerprint: "3f:a2:9c:11:04:5b:7e:d0:88:19:c6:2a:f4:90:ab:cd",
+		PrivateKey:  string(keyPEM),
+		Region:      "us-ashburn-1",
+	}, key
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    Config
+		wantField string
+	}{
+		{
+			name:   "empty config is valid",
+			config: Config{},
+		},
+		{
+			name: "all identifiers set",
+			config: Config{
+				Region:        "us-ashburn-1",
+				TenancyID:     "ocid1.tenancy.oc1..aaaa",
+				UserID:        "ocid1.user.oc1..aaaa",
+				CompartmentID: "ocid1.compartment.oc1..aaaa",
+			},
+		},
+		{
+			name:   "tenancy as root compartment",
+			config: Config{CompartmentID: "ocid1.tenancy.oc1..aaaa"},
+		},
+		{
+			name:      "invalid region",
+			config:    Config{Region: "us-east1"},
+			wantField: "region",
+		},
+		{
+			name:      "user OCID as tenancy",
+			config:    Config{TenancyID: "ocid1.user.oc1..aaaa"},
+			wantField: "tenancy_id",
+		},
+		{
+			name:      "invalid user",
+			config:    Config{UserID: "admin"},
+			wantField: "user_id",
+		},
+		{
+			name:      "invalid compartment",
+			config:    Config{CompartmentID: "ocid1.vcn.oc1..aaaa"},
+			wantField: "compartment_id",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+
+			if tt.wantField == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, errors.ErrInvalidArgument))
+			var e *errors.Error
+			require.True(t, errors.As(err, &e))
+			assert.Contains(t, e.Fields, tt.wantField)
+		})
+	}
+}
+
+func TestNewProvider(t *testing.T) {
+	p, err := NewProvider(nil, logger.Nop())
+	require.NoError(t, err)
+	assert.Equal(t, "oci", p.Name())
+
+	_, err = NewProvider(&Config{TenancyID: "not-an-ocid"}, logger.Nop())
+	assert.True(t, errors.Is(err, errors.ErrInvalidArgument))
+}
+
+func TestNewProvider_HTTPClient(t *testing.T) {
+	p, err := NewProvider(nil, logger.Nop())
+	require.NoError(t, err)
+	assert.Nil(t, p.httpClient.Transport, "no configured client keeps the default transport")
+
+	client := &http.Client{Transport: &http.Transport{}}
+	p, err = NewProvider(&Config{HTTPClient: client}, logger.Nop())
+	require.NoError(t, err)
+	assert.Same(t, client.Transport, p.httpClient.Transport)
+	assert.Equal(t, apiTimeout, p.httpClient.Timeout, "the API timeout is kept")
+}
+
+func TestParsePrivateKey(t *testing.T) {
+	creds, key := testCredentials(t)
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		pem     string
+		wantErr bool
+	}{
+		{name: "PKCS1", pem: creds.PrivateKey},
+		{name: "PKCS8", pem: string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8}))},
+		{name: "not PEM", pem: "ssh-rsa AAAA", wantErr: true},
+		{name: "garbage PEM", pem: string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: []byte("junk")})), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, err := parsePrivateKey(tt.pem)
+
+			if tt.wantErr {
+				assert.True(t, errors.Is(err, errors.ErrCredentialMalformed))
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, key.Equal(parsed))
+		})
+	}
+}
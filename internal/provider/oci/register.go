@@ -0,0 +1,47 @@
+package oci
+
+import (
+	"context"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+)
+
+func init() {
+	provider.MustRegisterProvider(provider.ProviderOCI, provider.Registration{
+		Constructor:  newFromConfig,
+		Capabilities: provider.Capabilities{LookupAnyRegion: true},
+		Defaults:     provider.Defaults{TokenDuration: tokenLifetime},
+	})
+}
+
+// newFromConfig creates an OCI provider from the shared provider configuration
+func newFromConfig(cfg *provider.Config, log logger.Logger) (provider.Provider, error) {
+	config := DefaultConfig()
+	config.Region = cfg.Region
+	config.TenancyID = cfg.TenancyID
+	config.UserID = cfg.UserID
+	config.CompartmentID = cfg.CompartmentID
+	config.CredentialsFile = cfg.CredentialsFile
+	config.StrictPermissions = cfg.StrictPermissions
+	config.Tracing = cfg.Tracing
+	config.HTTPClient = cfg.HTTPClient
+
+	return NewProvider(config, log)
+}
+
+// DescribeCluster implements provider.ClusterDescriber
+func (p *Provider) DescribeCluster(ctx context.Context, opts provider.ClusterInfoOptions) (*provider.ClusterInfo, error) {
+	info, err := p.GetClusterInfo(ctx, opts.ClusterName, opts.Region)
+	if err != nil {
+		return nil, err
+	}
+
+	return &provider.ClusterInfo{
+		Endpoint:             info.Endpoint,
+		CertificateAuthority: info.CertificateAuthority,
+		Version:              info.Version,
+		Region:               info.Region,
+		ResourceID:           info.ClusterID,
+	}, nil
+}
@@ -0,0 +1,125 @@
+package oci
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/credentials"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+)
+
+// requestSigner signs HTTP requests with an OCI API key using the OCI request
+// signature scheme (draft-cavage HTTP signatures with rsa-sha256)
+type requestSigner struct {
+	keyID string
+	key   *rsa.PrivateKey
+	now   func() time.Time
+}
+
+// newRequestSigner parses the API key of creds. The key ID is tenancy/user/fingerprint.
+func newRequestSigner(creds *credentials.OCICredentials, now func() time.Time) (*requestSigner, error) {
+	key, err := parsePrivateKey(creds.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &requestSigner{
+		keyID: creds.TenancyID + "/" + creds.UserID + "/" + creds.Fingerprint,
+		key:   key,
+		now:   now,
+	}, nil
+}
+
+// parsePrivateKey decodes a PKCS#1 or PKCS#8 PEM RSA private key
+func parsePrivateKey(pemKey string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(pemKey))
+	if block == nil {
+		return nil, errors.New(
+			errors.ErrCredentialMalformed,
+			"OCI API key is not PEM-encoded",
+		)
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrap(
+			errors.ErrCredentialMalformed,
+			err,
+			"failed to parse OCI API key",
+		)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New(
+			errors.ErrCredentialMalformed,
+			"OCI API key is not an RSA key",
+		).WithField("type", fmt.Sprintf("%T", parsed))
+	}
+	return key, nil
+}
+
+// Sign adds the date, host and Authorization headers to req. Requests with a body
+// also sign its length, type and SHA-256 digest.
+func (s *requestSigner) Sign(req *http.Request, body []byte) error {
+	req.Header.Set("Date", s.now().UTC().Format(http.TimeFormat))
+	req.Host = req.URL.Host
+
+	headers := []string{"date", "(request-target)", "host"}
+	switch req.Method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch:
+		sum := sha256.Sum256(body)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Content-Length", strconv.Itoa(len(body)))
+		req.Header.Set("X-Content-Sha256", base64.StdEncoding.EncodeToString(sum[:]))
+		req.ContentLength = int64(len(body))
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		headers = append(headers, "content-length", "content-type", "x-content-sha256")
+	}
+
+	digest := sha256.Sum256([]byte(signingString(req, headers)))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return errors.Wrap(errors.ErrTokenGenerationFailed, err, "failed to sign OCI request")
+	}
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		`Signature version="1",headers="%s",keyId="%s",algorithm="rsa-sha256",signature="%s"`,
+		strings.Join(headers, " "),
+		s.keyID,
+		base64.StdEncoding.EncodeToString(signature),
+	))
+	return nil
+}
+
+// signingString builds the newline-separated "name: value" lines that are signed
+func signingString(req *http.Request, headers []string) string {
+	lines := make([]string, 0, len(headers))
+	for _, name := range headers {
+		var value string
+		switch name {
+		case "(request-target)":
+			value = strings.ToLower(req.Method) + " " + req.URL.RequestURI()
+		case "host":
+			value = req.URL.Host
+		default:
+			value = req.Header.Get(name)
+		}
+		lines = append(lines, name+": "+value)
+	}
+	return strings.Join(lines, "\n")
+}
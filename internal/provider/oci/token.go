@@ -0,0 +1,130 @@
+package oci
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/credentials"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+)
+
+const (
+	// tokenLifetime matches the OCI CLI: OKE accepts a signed request for about five
+	// minutes after its date header
+	tokenLifetime = 4 * time.Minute
+
+	// clusterRequestPath is the path OKE verifies the signed token request against
+	clusterRequestPath = "/cluster_request/"
+)
+
+// TokenGenerator creates OKE bearer tokens from signed cluster requests
+type TokenGenerator struct {
+	config     *Config
+	credLoader credentials.Loader
+	logger     logger.Logger
+	endpoint   func(region string) string
+	now        func() time.Time
+}
+
+// NewTokenGenerator creates a new OCI token generator
+func NewTokenGenerator(config *Config, credLoader credentials.Loader, log logger.Logger) *TokenGenerator {
+	return &TokenGenerator{
+		config:     config,
+		credLoader: credLoader,
+		logger:     log,
+		endpoint:   containerEngineEndpoint,
+		now:        time.Now,
+	}
+}
+
+// containerEngineEndpoint returns the Container Engine API endpoint of a region
+func containerEngineEndpoint(region string) string {
+	return "https://containerengine." + region + ".oraclecloud.com"
+}
+
+// GenerateToken signs a GET request for the cluster and encodes it as a token, the
+// same way as "oci ce cluster generate-token". clusterID must be the cluster OCID.
+func (g *TokenGenerator) GenerateToken(ctx context.Context, creds *credentials.OCICredentials, region, clusterID string) (*provider.Token, error) {
+	ctx, span := provider.StartSpan(ctx, g.config.Tracing, provider.ProviderOCI, "GenerateToken", clusterID, region)
+	token, err := g.generateToken(ctx, creds, region, clusterID)
+	provider.EndSpan(ctx, span, err)
+	return token, err
+}
+
+// generateToken is GenerateToken without tracing
+func (g *TokenGenerator) generateToken(ctx context.Context, creds *credentials.OCICredentials, region, clusterID string) (*provider.Token, error) {
+	if !isClusterOCID(clusterID) {
+		return nil, errors.New(
+			errors.ErrInvalidArgument,
+			"OKE tokens require the cluster OCID",
+		).WithField("cluster", clusterID)
+	}
+
+	signer, err := newRequestSigner(creds, g.now)
+	if err != nil {
+		return nil, err
+	}
+
+	requestURL := g.endpoint(region) + clusterRequestPath + clusterID
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL, nil)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrTokenGenerationFailed, err, "failed to build OKE token request")
+	}
+	if err := signer.Sign(req, nil); err != nil {
+		return nil, err
+	}
+
+	query := url.Values{}
+	query.Set("authorization", req.Header.Get("Authorization"))
+	query.Set("date", req.Header.Get("Date"))
+	signedURL := requestURL + "?" + query.Encode()
+
+	provider.LoggerWithBaggage(ctx, g.config.Tracing, g.logger).Debug("OKE token generated",
+		logger.String("cluster", clusterID),
+		logger.String("region", region),
+	)
+
+	return &provider.Token{
+		AccessToken: base64.URLEncoding.EncodeToString([]byte(signedURL)),
+		ExpiresAt:   g.now().Add(tokenLifetime),
+		TokenType:   "Bearer",
+		Provider:    provider.ProviderOCI.String(),
+		ClusterName: clusterID,
+		Identity:    logger.RedactID(creds.UserID),
+	}, nil
+}
+
+// ValidateToken checks that a token decodes to a signed OKE cluster request
+func (g *TokenGenerator) ValidateToken(token *provider.Token) error {
+	if token == nil || token.AccessToken == "" {
+		return errors.New(errors.ErrTokenInvalid, "token is empty").WithField("provider", "oci")
+	}
+	if err := provider.CheckTokenIssuer(token, provider.ProviderOCI); err != nil {
+		return err
+	}
+
+	decoded, err := base64.URLEncoding.DecodeString(token.AccessToken)
+	if err != nil {
+		return errors.Wrap(errors.ErrTokenMalformed, err, "OKE token is not base64url-encoded").WithField("provider", "oci")
+	}
+
+	signedURL, err := url.Parse(string(decoded))
+	if err != nil || signedURL.Scheme != "https" || !strings.HasPrefix(signedURL.Path, clusterRequestPath) {
+		return errors.New(errors.ErrTokenMalformed, "OKE token is not a signed cluster request").WithField("provider", "oci")
+	}
+	query := signedURL.Query()
+	if !strings.HasPrefix(query.Get("authorization"), "Signature ") || query.Get("date") == "" {
+		return errors.New(errors.ErrTokenMalformed, "OKE token is missing its signature").WithField("provider", "oci")
+	}
+
+	if token.IsExpired() {
+		return errors.New(errors.ErrTokenExpired, "token has expired").WithField("provider", "oci")
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package oci
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/testutil"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+)
+
+const testClusterID = "ocid1.cluster.oc1.iad.aaaacluster"
+
+var signaturePattern = regexp.MustCompile(`^Signature version="1",headers="([^"]+)",keyId="([^"]+)",algorithm="rsa-sha256",signature="([^"]+)"$`)
+
+func TestGenerateToken(t *testing.T) {
+	creds, key := testCredentials(t)
+	now := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	generator := NewTokenGenerator(DefaultConfig(), testutil.NewMockCredLoader(), logger.Nop())
+	generator.now = func() time.Time { return now }
+
+	token, err := generator.GenerateToken(context.Background(), creds, "us-ashburn-1", testClusterID)
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer", token.TokenType)
+	assert.Equal(t, now.Add(tokenLifetime), token.ExpiresAt)
+	assert.Equal(t, "oci", token.Provider)
+	assert.Equal(t, testClusterID, token.ClusterName)
+	assert.Equal(t, logger.RedactID(creds.UserID), token.Identity)
+
+	decoded, err := base64.URLEncoding.DecodeString(token.AccessToken)
+	require.NoError(t, err)
+	signedURL, err := url.Parse(string(decoded))
+	require.NoError(t, err)
+	assert.Equal(t, "https", signedURL.Scheme)
+	assert.Equal(t, "containerengine.us-ashburn-1.oraclecloud.com", signedURL.Host)
+	assert.Equal(t, "/cluster_request/"+testClusterID, signedURL.Path)
+
+	query := signedURL.Query()
+	assert.Equal(t, "Wed, 02 Jan 2030 03:04:05 GMT", query.Get("date"))
+
+	match := signaturePattern.FindStringSubmatch(query.Get("authorization"))
+	require.NotNil(t, match, "authorization %q", query.Get("authorization"))
+	assert.Equal(t, "date (request-target) host", match[1])
+	assert.Equal(t, creds.TenancyID+"/"+creds.UserID+"/"+creds.Fingerprint, match[2])
+
+	// The signature covers the date, request line and host of the cluster request
+	signed := strings.Join([]string{
+		"date: " + query.Get("date"),
+		"(request-target): get /cluster_request/" + testClusterID,
+		"host: " + signedURL.Host,
+	}, "\n")
+	signature, err := base64.StdEncoding.DecodeString(match[3])
+	require.NoError(t, err)
+	digest := sha256.Sum256([]byte(signed))
+	assert.NoError(t, rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], signature))
+}
+
+func TestGenerateToken_RequiresClusterOCID(t *testing.T) {
+	creds, _ := testCredentials(t)
+	generator := NewTokenGenerator(DefaultConfig(), testutil.NewMockCredLoader(), logger.Nop())
+
+	_, err := generator.GenerateToken(context.Background(), creds, "us-ashburn-1", "my-cluster")
+
+	assert.True(t, errors.Is(err, errors.ErrInvalidArgument))
+}
+
+func TestSign_Body(t *testing.T) {
+	creds, key := testCredentials(t)
+	signer, err := newRequestSigner(creds, time.Now)
+	require.NoError(t, err)
+
+	body := []byte(`{"tokenVersion":"2.0.0"}`)
+	req, err := http.NewRequest(http.MethodPost, "https://containerengine.us-ashburn-1.oraclecloud.com/20180222/clusters/x/kubeconfig/content", nil)
+	require.NoError(t, err)
+	require.NoError(t, signer.Sign(req, body))
+
+	match := signaturePattern.FindStringSubmatch(req.Header.Get("Authorization"))
+	require.NotNil(t, match)
+	assert.Equal(t, "date (request-target) host content-length content-type x-content-sha256", match[1])
+
+	sum := sha256.Sum256(body)
+	assert.Equal(t, base64.StdEncoding.EncodeToString(sum[:]), req.Header.Get("X-Content-Sha256"))
+
+	signature, err := base64.StdEncoding.DecodeString(match[3])
+	require.NoError(t, err)
+	digest := sha256.Sum256([]byte(signingString(req, strings.Fields(match[1]))))
+	assert.NoError(t, rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], signature))
+}
+
+func TestValidateToken(t *testing.T) {
+	creds, _ := testCredentials(t)
+	generator := NewTokenGenerator(DefaultConfig(), testutil.NewMockCredLoader(), logger.Nop())
+	valid, err := generator.GenerateToken(context.Background(), creds, "us-ashburn-1", testClusterID)
+	require.NoError(t, err)
+
+	encode := func(s string) string { return base64.URLEncoding.EncodeToString([]byte(s)) }
+
+	tests := []struct {
+		name     string
+		token    *provider.Token
+		wantCode errors.ErrorCode
+	}{
+		{
+			name:  "valid",
+			token: valid,
+		},
+		{
+			name:     "empty",
+			token:    &provider.Token{},
+			wantCode: errors.ErrTokenInvalid,
+		},
+		{
+			name:     "not base64",
+			token:    &provider.Token{AccessToken: "!!!", ExpiresAt: valid.ExpiresAt},
+			wantCode: errors.ErrTokenMalformed,
+		},
+		{
+			name:     "not a cluster request",
+			token:    &provider.Token{AccessToken: encode("https://example.com/other"), ExpiresAt: valid.ExpiresAt},
+			wantCode: errors.ErrTokenMalformed,
+		},
+		{
+			name:     "unsigned",
+			token:    &provider.Token{AccessToken: encode("https://containerengine.us-ashburn-1.oraclecloud.com/cluster_request/" + testClusterID), ExpiresAt: valid.ExpiresAt},
+			wantCode: errors.ErrTokenMalformed,
+		},
+		{
+			name:     "expired",
+			token:    &provider.Token{AccessToken: valid.AccessToken, ExpiresAt: time.Now().Add(-time.Minute)},
+			wantCode: errors.ErrTokenExpired,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := generator.ValidateToken(tt.token)
+
+			if tt.wantCode == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.True(t, errors.Is(err, tt.wantCode), "got %v", err)
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package oci
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/tracing"
+)
+
+// regionPattern matches OCI region identifiers such as us-ashburn-1
+var regionPattern = regexp.MustCompile(`^[a-z]+(-[a-z]+)+-\d+$`)
+
+// Config holds OCI provider configuration
+type Config struct {
+	// Region is the OCI region (default: the region in the OCI config file)
+	Region string
+
+	// TenancyID overrides the tenancy OCID of the OCI config file
+	TenancyID string
+
+	// UserID overrides the user OCID of the OCI config file
+	UserID string
+
+	// CompartmentID is the compartment searched when a cluster is given by name
+	CompartmentID string
+
+	// CredentialsFile is the OCI CLI config file holding the API signing key settings
+	CredentialsFile string
+
+	// Profile is the profile of the OCI config file (default: DEFAULT)
+	Profile string
+
+	// StrictPermissions rejects credentials files readable by group or others
+	StrictPermissions bool
+
+	// Tracing records spans for token generation and cluster lookups; nil disables tracing
+	Tracing *tracing.Provider
+
+	// HTTPClient provides the transport of the Container Engine requests; nil uses the default
+	HTTPClient *http.Client
+}
+
+// DefaultConfig returns default OCI configuration
+func DefaultConfig() *Config {
+	return &Config{}
+}
+
+// Validate checks the identifiers that are set have the OCI shape
+func (c *Config) Validate() error {
+	if c.Region != "" && !regionPattern.MatchString(c.Region) {
+		return errors.New(
+			errors.ErrInvalidArgument,
+			"invalid OCI region",
+		).WithField("region", c.Region).
+			WithDetail("expected a region identifier such as us-ashburn-1")
+	}
+
+	checks := []struct {
+		field    string
+		value    string
+		prefixes []string
+	}{
+		{"tenancy_id", c.TenancyID, []string{"ocid1.tenancy."}},
+		{"user_id", c.UserID, []string{"ocid1.user."}},
+		// The root compartment of a tenancy is addressed by the tenancy OCID
+		{"compartment_id", c.CompartmentID, []string{"ocid1.compartment.", "ocid1.tenancy."}},
+	}
+	for _, check := range checks {
+		if check.value != "" && !hasAnyPrefix(check.value, check.prefixes) {
+			return errors.New(
+				errors.ErrInvalidArgument,
+				"invalid OCI "+strings.ReplaceAll(check.field, "_", " "),
+			).WithField(check.field, check.value).
+				WithDetail("expected an OCID starting with " + strings.Join(check.prefixes, " or "))
+		}
+	}
+
+	return nil
+}
+
+// isClusterOCID reports whether a cluster name is an OKE cluster OCID
+func isClusterOCID(name string) bool {
+	return strings.HasPrefix(name, "ocid1.cluster.")
+}
+
+func hasAnyPrefix(value string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(value, prefix) {
+			return true
+		}
+	}
+	return false
+}
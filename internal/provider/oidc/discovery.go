@@ -0,0 +1,111 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+)
+
+// discoveryPath is the OpenID Connect discovery document, relative to the issuer
+const discoveryPath = "/.well-known/openid-configuration"
+
+// maxResponseBytes bounds the discovery and token responses read from the issuer
+const maxResponseBytes = 1 << 20
+
+// discoveryDocument holds the fields of the OpenID Connect discovery document the
+// provider uses
+type discoveryDocument struct {
+	Issuer           string   `json:"issuer"`
+	TokenEndpoint    string   `json:"token_endpoint"`
+	TokenAuthMethods []string `json:"token_endpoint_auth_methods_supported"`
+}
+
+// supportsAuthMethod reports whether the issuer accepts a client authentication method.
+// Per OpenID Connect Discovery, an issuer that lists none supports client_secret_basic.
+func (d *discoveryDocument) supportsAuthMethod(method string) bool {
+	if len(d.TokenAuthMethods) == 0 {
+		return method == "client_secret_basic"
+	}
+	for _, supported := range d.TokenAuthMethods {
+		if supported == method {
+			return true
+		}
+	}
+	return false
+}
+
+// discover returns the issuer's discovery document, fetching it on first use
+func (p *Provider) discover(ctx context.Context) (*discoveryDocument, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	discoveryURL := p.issuer() + discoveryPath
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, discoveryURL, nil)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrInternal, err, "failed to build OIDC discovery request")
+	}
+	req.Header.Set("Accept", "application/json")
+	p.config.Tracing.InjectBaggage(ctx, req.Header)
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrNetworkUnreachable, err, "OIDC discovery request failed").
+			WithField("url", discoveryURL)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrNetworkUnreachable, err, "failed to read OIDC discovery document")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(
+			errors.ErrConfigInvalid,
+			"OIDC discovery document not found",
+		).WithFields(map[string]interface{}{
+			"url":    discoveryURL,
+			"status": resp.StatusCode,
+		}).WithDetail("check that --issuer-url is the issuer, without the /.well-known path")
+	}
+
+	var doc discoveryDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, errors.Wrap(errors.ErrConfigInvalid, err, "failed to decode OIDC discovery document").
+			WithField("url", discoveryURL)
+	}
+
+	// A mismatched issuer means the document was served for another issuer, and
+	// its tokens would not be accepted for this one
+	if strings.TrimSuffix(doc.Issuer, "/") != p.issuer() {
+		return nil, errors.New(
+			errors.ErrConfigInvalid,
+			"OIDC discovery document names a different issuer",
+		).WithFields(map[string]interface{}{
+			"issuer":          p.config.IssuerURL,
+			"document_issuer": doc.Issuer,
+		})
+	}
+	if doc.TokenEndpoint == "" {
+		return nil, errors.New(
+			errors.ErrConfigInvalid,
+			"OIDC discovery document has no token_endpoint",
+		).WithField("url", discoveryURL)
+	}
+
+	p.logger.Debug("OIDC discovery document loaded",
+		logger.String("issuer", doc.Issuer),
+		logger.String("token_endpoint", doc.TokenEndpoint),
+	)
+
+	p.discovery = &doc
+	return p.discovery, nil
+}
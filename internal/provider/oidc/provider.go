@@ -0,0 +1,250 @@
+package oidc
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/credentials"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+)
+
+// requestTimeout bounds each discovery and token request
+const requestTimeout = 30 * time.Second
+
+// Provider implements the generic OIDC token provider: it gets tokens from the
+// issuer's token endpoint with the client credentials grant, or with RFC 8693 token
+// exchange when a subject token file is configured
+type Provider struct {
+	config       *Config
+	logger       logger.Logger
+	credLoader   credentials.Loader
+	oidcCredOpts credentials.OIDCCredentialOptions
+	httpClient   *http.Client
+	now          func() time.Time
+
+	// mu guards the discovery document, which is fetched once per provider
+	mu        sync.Mutex
+	discovery *discoveryDocument
+}
+
+// NewProvider creates a new OIDC provider
+func NewProvider(config *Config, log logger.Logger) (*Provider, error) {
+	if config == nil {
+		config = DefaultConfig()
+	}
+
+	if config.IssuerURL == "" {
+		return nil, errors.New(
+			errors.ErrConfigMissingField,
+			"OIDC issuer URL is required",
+		).WithDetail("set --issuer-url or HFCP_ISSUER_URL")
+	}
+	if err := ValidateIssuerURL(config.IssuerURL); err != nil {
+		return nil, err
+	}
+	if config.ClientID == "" {
+		return nil, errors.New(
+			errors.ErrConfigMissingField,
+			"OIDC client ID is required",
+		).WithDetail("set --client-id or HFCP_CLIENT_ID")
+	}
+	if config.SubjectTokenType == "" {
+		config.SubjectTokenType = TokenTypeJWT
+	}
+
+	credLoader := credentials.NewLoader(log,
+		credentials.WithStrictPermissions(config.StrictPermissions),
+		credentials.WithHTTPClient(config.HTTPClient),
+	)
+
+	oidcCredOpts := credentials.OIDCCredentialOptions{
+		CredentialsFile: config.CredentialsFile,
+		PrivateKeyFile:  config.PrivateKeyFile,
+		UseEnvironment:  true,
+	}
+
+	return &Provider{
+		config:       config,
+		logger:       log,
+		credLoader:   credLoader,
+		oidcCredOpts: oidcCredOpts,
+		httpClient:   newHTTPClient(config),
+		now:          time.Now,
+	}, nil
+}
+
+// newHTTPClient returns the client of the issuer requests, which uses the configured
+// transport, if any, and is bounded by requestTimeout
+func newHTTPClient(config *Config) *http.Client {
+	client := &http.Client{Timeout: requestTimeout}
+	if config.HTTPClient != nil {
+		client.Transport = config.HTTPClient.Transport
+	}
+	return client
+}
+
+// ValidateIssuerURL checks that an issuer URL is absolute and uses HTTPS. Plain HTTP
+// is only accepted for loopback issuers, such as a local development server.
+func ValidateIssuerURL(value string) error {
+	u, err := url.Parse(value)
+	if err != nil || u.Host == "" {
+		return errors.New(
+			errors.ErrInvalidArgument,
+			fmt.Sprintf("invalid OIDC issuer URL %q", value),
+		).WithDetail("use a URL such as https://issuer.example.com")
+	}
+
+	switch u.Scheme {
+	case "https":
+		return nil
+	case "http":
+		if host := u.Hostname(); host == "localhost" || net.ParseIP(host).IsLoopback() {
+			return nil
+		}
+	}
+	return errors.New(
+		errors.ErrInvalidArgument,
+		fmt.Sprintf("OIDC issuer URL %q must use https", value),
+	).WithDetail("client credentials are sent to the issuer, so only loopback issuers may use http")
+}
+
+// GetToken requests a token from the issuer's token endpoint
+func (p *Provider) GetToken(ctx context.Context, opts provider.GetTokenOptions) (*provider.Token, error) {
+	ctx, span := provider.StartSpan(ctx, p.config.Tracing, provider.ProviderOIDC, "GetToken", opts.ClusterName, "")
+	token, err := p.getToken(ctx, opts)
+	provider.EndSpan(ctx, span, err)
+	return token, err
+}
+
+// getToken is GetToken without tracing
+func (p *Provider) getToken(ctx context.Context, opts provider.GetTokenOptions) (*provider.Token, error) {
+	if opts.ClusterName == "" {
+		return nil, errors.New(
+			errors.ErrInvalidArgument,
+			"cluster name is required",
+		).WithField("provider", "oidc")
+	}
+
+	log := provider.LoggerWithBaggage(ctx, p.config.Tracing, p.logger)
+	log.Info("Getting OIDC token",
+		logger.String("cluster", opts.ClusterName),
+		logger.String("issuer", p.config.IssuerURL),
+		logger.String("grant_type", p.grantType()),
+	)
+
+	creds, err := p.credLoader.LoadOIDC(ctx, p.oidcCredOpts)
+	if err != nil {
+		p.logger.Error("Failed to load OIDC credentials",
+			logger.String("cluster", opts.ClusterName),
+			logger.Error(err),
+		)
+		return nil, err
+	}
+
+	token, err := p.requestToken(ctx, creds, opts.Audience)
+	if err != nil {
+		p.logger.Error("Failed to get OIDC token",
+			logger.String("cluster", opts.ClusterName),
+			logger.Error(err),
+		)
+		return nil, err
+	}
+	token.Provider = provider.ProviderOIDC.String()
+	token.ClusterName = opts.ClusterName
+	token.Identity = logger.RedactID(p.config.ClientID)
+	token.Audience = opts.Audience
+	token.Scopes = p.config.Scopes
+
+	log.Debug("OIDC token issued",
+		logger.String("cluster", opts.ClusterName),
+		logger.String("expires_at", token.ExpiresAt.Format(time.RFC3339)),
+	)
+	return token, nil
+}
+
+// ValidateCredentials reads the issuer's discovery document and requests a token,
+// so that an unknown client or a rejected secret or key is reported
+func (p *Provider) ValidateCredentials(ctx context.Context) error {
+	p.logger.Debug("Validating OIDC credentials",
+		logger.String("issuer", p.config.IssuerURL),
+	)
+
+	creds, err := p.credLoader.LoadOIDC(ctx, p.oidcCredOpts)
+	if err != nil {
+		return errors.Wrap(
+			errors.ErrCredentialValidationFailed,
+			err,
+			"failed to load OIDC credentials",
+		).WithField("provider", "oidc")
+	}
+
+	if _, err := p.requestToken(ctx, creds, ""); err != nil {
+		return errors.Wrap(
+			errors.ErrCredentialValidationFailed,
+			err,
+			"OIDC issuer did not issue a token",
+		).WithFields(map[string]interface{}{
+			"provider": "oidc",
+			"issuer":   p.config.IssuerURL,
+		})
+	}
+
+	p.logger.Info("OIDC credentials validated successfully",
+		logger.String("issuer", p.config.IssuerURL),
+		logger.String("grant_type", p.grantType()),
+	)
+	return nil
+}
+
+// grantType returns the grant the provider requests tokens with
+func (p *Provider) grantType() string {
+	if p.config.SubjectTokenFile != "" {
+		return grantTokenExchange
+	}
+	return grantClientCredentials
+}
+
+// ValidateToken checks that a token is set and has not expired. Issuers choose the token format,
+// so the API server is left to judge the token itself.
+func (p *Provider) ValidateToken(token *provider.Token) error {
+	if token == nil || token.AccessToken == "" {
+		return errors.New(errors.ErrTokenInvalid, "token is empty").WithField("provider", "oidc")
+	}
+	if err := provider.CheckTokenIssuer(token, provider.ProviderOIDC); err != nil {
+		return err
+	}
+	if token.IsExpiredWith(provider.ClockFunc(p.now)) {
+		return errors.New(
+			errors.ErrTokenExpired,
+			"token has expired",
+		).WithFields(map[string]interface{}{
+			"provider":   "oidc",
+			"expires_at": token.ExpiresAt.Format(time.RFC3339),
+		})
+	}
+	return nil
+}
+
+// RefreshToken returns currentToken while it is valid and outside the refresh
+// threshold, and otherwise gets a new token with GetToken
+func (p *Provider) RefreshToken(ctx context.Context, opts provider.GetTokenOptions, currentToken *provider.Token) (*provider.Token, error) {
+	return provider.RefreshIfNeeded(ctx, p, opts, currentToken, provider.ClockFunc(p.now), provider.RefreshThresholdOf(p.Name()))
+}
+
+// Name returns the provider name
+func (p *Provider) Name() string {
+	return "oidc"
+}
+
+// issuer returns the configured issuer without a trailing slash
+func (p *Provider) issuer() string {
+	return strings.TrimSuffix(p.config.IssuerURL, "/")
+}
@@ -0,0 +1,382 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/credentials"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/testutil"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+)
+
+// stubIssuer is a local OIDC issuer serving a discovery document and a token endpoint
+type stubIssuer struct {
+	*httptest.Server
+
+	// authMethods is token_endpoint_auth_methods_supported; nil omits it
+	authMethods []string
+
+	// token answers token requests; the form is already parsed
+	token http.HandlerFunc
+
+	discoveries atomic.Int32
+}
+
+// newStubIssuer starts a TLS issuer whose token endpoint answers with token
+func newStubIssuer(t *testing.T, token http.HandlerFunc) *stubIssuer {
+	issuer := &stubIssuer{token: token}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		issuer.discoveries.Add(1)
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"issuer":                                issuer.URL,
+			"token_endpoint":                        issuer.URL + "/oauth2/token",
+			"token_endpoint_auth_methods_supported": issuer.authMethods,
+		})
+	})
+	mux.HandleFunc("/oauth2/token", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.ParseForm() != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		issuer.token(w, r)
+	})
+	issuer.Server = httptest.NewTLSServer(mux)
+	t.Cleanup(issuer.Close)
+	return issuer
+}
+
+// newTestProvider returns a provider for the issuer with mocked credentials
+func newTestProvider(t *testing.T, issuer *stubIssuer, config *Config, creds *credentials.OIDCCredentials) *Provider {
+	if config == nil {
+		config = DefaultConfig()
+	}
+	config.IssuerURL = issuer.URL
+	config.ClientID = "hyperfleet"
+	config.HTTPClient = issuer.Client()
+
+	p, err := NewProvider(config, logger.Nop())
+	require.NoError(t, err)
+	p.credLoader = testutil.NewMockCredLoader().WithOIDCCreds(creds)
+	return p
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func TestNewProvider(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   *Config
+		wantCode errors.ErrorCode
+	}{
+		{name: "valid", config: &Config{IssuerURL: "https://issuer.example.com", ClientID: "client"}},
+		{name: "loopback http issuer", config: &Config{IssuerURL: "http://127.0.0.1:8080", ClientID: "client"}},
+		{name: "localhost http issuer", config: &Config{IssuerURL: "http://localhost:8080/realms/dev", ClientID: "client"}},
+		{name: "missing issuer", config: &Config{ClientID: "client"}, wantCode: errors.ErrConfigMissingField},
+		{name: "missing client ID", config: &Config{IssuerURL: "https://issuer.example.com"}, wantCode: errors.ErrConfigMissingField},
+		{name: "remote http issuer", config: &Config{IssuerURL: "http://issuer.example.com", ClientID: "client"}, wantCode: errors.ErrInvalidArgument},
+		{name: "issuer without host", config: &Config{IssuerURL: "issuer.example.com", ClientID: "client"}, wantCode: errors.ErrInvalidArgument},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewProvider(tt.config, logger.Nop())
+			if tt.wantCode != "" {
+				require.Error(t, err)
+				assert.True(t, errors.Is(err, tt.wantCode), "got %v", err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "oidc", p.Name())
+			assert.Equal(t, TokenTypeJWT, p.config.SubjectTokenType)
+		})
+	}
+}
+
+func TestNewFromConfig(t *testing.T) {
+	client := &http.Client{Transport: &http.Transport{}}
+	prov, err := provider.New("oidc", &provider.Config{
+		OIDCIssuerURL:        "https://issuer.example.com",
+		OIDCClientID:         "client",
+		OIDCScopes:           []string{"openid", "kubernetes"},
+		OIDCSubjectTokenFile: "/var/run/secrets/tokens/oidc",
+		OIDCUseIDToken:       true,
+		HTTPClient:           client,
+	}, logger.Nop())
+	require.NoError(t, err)
+
+	p, ok := prov.(*Provider)
+	require.True(t, ok)
+	assert.Equal(t, []string{"openid", "kubernetes"}, p.config.Scopes)
+	assert.Equal(t, TokenTypeJWT, p.config.SubjectTokenType)
+	assert.True(t, p.config.UseIDToken)
+	assert.Same(t, client.Transport, p.httpClient.Transport)
+	assert.Equal(t, requestTimeout, p.httpClient.Timeout, "the request timeout is kept")
+}
+
+func TestGetToken_ClientCredentials(t *testing.T) {
+	var form map[string]string
+	var user, password string
+	issuer := newStubIssuer(t, func(w http.ResponseWriter, r *http.Request) {
+		form = map[string]string{}
+		for key := range r.PostForm {
+			form[key] = r.PostForm.Get(key)
+		}
+		user, password, _ = r.BasicAuth()
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"access_token": "access-token",
+			"token_type":   "Bearer",
+			"expires_in":   600,
+		})
+	})
+	now := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	p := newTestProvider(t, issuer, nil, &credentials.OIDCCredentials{ClientSecret: "s3cr:et"})
+	p.now = func() time.Time { return now }
+
+	token, err := p.GetToken(context.Background(), provider.GetTokenOptions{ClusterName: "my-cluster", Audience: "kubernetes"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "access-token", token.AccessToken)
+	assert.Equal(t, "Bearer", token.TokenType)
+	assert.Equal(t, now.Add(10*time.Minute), token.ExpiresAt)
+	assert.Equal(t, map[string]string{
+		"grant_type": "client_credentials",
+		"scope":      "openid",
+		"audience":   "kubernetes",
+	}, form)
+	assert.Equal(t, "hyperfleet", user)
+	assert.Equal(t, "s3cr%3Aet", password, "the secret is form-encoded before Basic encoding")
+
+	// The discovery document is fetched once per provider
+	_, err = p.GetToken(context.Background(), provider.GetTokenOptions{ClusterName: "my-cluster"})
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), issuer.discoveries.Load())
+}
+
+func TestGetToken_ClientSecretPost(t *testing.T) {
+	var form map[string]string
+	issuer := newStubIssuer(t, func(w http.ResponseWriter, r *http.Request) {
+		form = map[string]string{"client_id": r.PostForm.Get("client_id"), "client_secret": r.PostForm.Get("client_secret")}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"access_token": "access-token", "expires_in": 60})
+	})
+	issuer.authMethods = []string{"client_secret_post", "private_key_jwt"}
+	p := newTestProvider(t, issuer, nil, &credentials.OIDCCredentials{ClientSecret: "secret"})
+
+	_, err := p.GetToken(context.Background(), provider.GetTokenOptions{ClusterName: "my-cluster"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"client_id": "hyperfleet", "client_secret": "secret"}, form)
+}
+
+func TestGetToken_TokenExchange(t *testing.T) {
+	subjectTokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(subjectTokenFile, []byte("sa-token-1\n"), 0600))
+
+	var subjectTokens []string
+	var form map[string]string
+	issuer := newStubIssuer(t, func(w http.ResponseWriter, r *http.Request) {
+		form = map[string]string{
+			"grant_type":           r.PostForm.Get("grant_type"),
+			"subject_token_type":   r.PostForm.Get("subject_token_type"),
+			"requested_token_type": r.PostForm.Get("requested_token_type"),
+		}
+		subjectTokens = append(subjectTokens, r.PostForm.Get("subject_token"))
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"access_token":      "exchanged-token",
+			"issued_token_type": "urn:ietf:params:oauth:token-type:access_token",
+			"token_type":        "Bearer",
+			"expires_in":        3600,
+		})
+	})
+	config := DefaultConfig()
+	config.SubjectTokenFile = subjectTokenFile
+	p := newTestProvider(t, issuer, config, &credentials.OIDCCredentials{ClientSecret: "secret"})
+
+	token, err := p.GetToken(context.Background(), provider.GetTokenOptions{ClusterName: "my-cluster"})
+	require.NoError(t, err)
+	assert.Equal(t, "exchanged-token", token.AccessToken)
+	assert.Equal(t, map[string]string{
+		"grant_type":           "urn:ietf:params:oauth:grant-type:token-exchange",
+		"subject_token_type":   "urn:ietf:params:oauth:token-type:jwt",
+		"requested_token_type": "",
+	}, form)
+
+	// A rotated subject token is picked up by the next request
+	require.NoError(t, os.WriteFile(subjectTokenFile, []byte("sa-token-2\n"), 0600))
+	_, err = p.GetToken(context.Background(), provider.GetTokenOptions{ClusterName: "my-cluster"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"sa-token-1", "sa-token-2"}, subjectTokens)
+}
+
+func TestGetToken_SubjectTokenFileErrors(t *testing.T) {
+	dir := t.TempDir()
+	empty := filepath.Join(dir, "empty")
+	require.NoError(t, os.WriteFile(empty, []byte("\n"), 0600))
+
+	tests := []struct {
+		name     string
+		file     string
+		wantCode errors.ErrorCode
+	}{
+		{name: "missing file", file: filepath.Join(dir, "nonexistent"), wantCode: errors.ErrCredentialLoadFailed},
+		{name: "empty file", file: empty, wantCode: errors.ErrCredentialNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issuer := newStubIssuer(t, func(w http.ResponseWriter, r *http.Request) {
+				t.Error("no token request is expected")
+			})
+			config := DefaultConfig()
+			config.SubjectTokenFile = tt.file
+			p := newTestProvider(t, issuer, config, &credentials.OIDCCredentials{ClientSecret: "secret"})
+
+			_, err := p.GetToken(context.Background(), provider.GetTokenOptions{ClusterName: "my-cluster"})
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, tt.wantCode), "got %v", err)
+		})
+	}
+}
+
+func TestGetToken_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     provider.GetTokenOptions
+		mock     *testutil.MockCredLoader
+		wantCode errors.ErrorCode
+	}{
+		{
+			name:     "cluster name required",
+			opts:     provider.GetTokenOptions{},
+			mock:     testutil.NewMockCredLoader(),
+			wantCode: errors.ErrInvalidArgument,
+		},
+		{
+			name: "credential error",
+			opts: provider.GetTokenOptions{ClusterName: "my-cluster"},
+			mock: testutil.NewMockCredLoader().WithOIDCError(
+				errors.New(errors.ErrCredentialNotFound, "OIDC client secret not found"),
+			),
+			wantCode: errors.ErrCredentialNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issuer := newStubIssuer(t, func(w http.ResponseWriter, r *http.Request) {
+				t.Error("no token request is expected")
+			})
+			p := newTestProvider(t, issuer, nil, nil)
+			p.credLoader = tt.mock
+
+			_, err := p.GetToken(context.Background(), tt.opts)
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, tt.wantCode), "got %v", err)
+		})
+	}
+}
+
+func TestDiscover_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		wantErr string
+	}{
+		{
+			name: "not found",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.NotFound(w, r)
+			},
+			wantErr: "OIDC discovery document not found",
+		},
+		{
+			name: "different issuer",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				writeJSON(w, http.StatusOK, map[string]string{"issuer": "https://other.example.com", "token_endpoint": "https://other.example.com/token"})
+			},
+			wantErr: "names a different issuer",
+		},
+		{
+			name: "no token endpoint",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				writeJSON(w, http.StatusOK, map[string]string{"issuer": "https://" + r.Host})
+			},
+			wantErr: "has no token_endpoint",
+		},
+		{
+			name: "not JSON",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte("<html></html>"))
+			},
+			wantErr: "failed to decode OIDC discovery document",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewTLSServer(tt.handler)
+			defer server.Close()
+
+			p, err := NewProvider(&Config{IssuerURL: server.URL, ClientID: "client", HTTPClient: server.Client()}, logger.Nop())
+			require.NoError(t, err)
+
+			_, err = p.discover(context.Background())
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, errors.ErrConfigInvalid), "got %v", err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestValidateCredentials(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		wantErr bool
+	}{
+		{
+			name: "token issued",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				writeJSON(w, http.StatusOK, map[string]interface{}{"access_token": "access-token", "expires_in": 300})
+			},
+		},
+		{
+			name: "client rejected",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issuer := newStubIssuer(t, tt.handler)
+			p := newTestProvider(t, issuer, nil, &credentials.OIDCCredentials{ClientSecret: "secret"})
+
+			err := p.ValidateCredentials(context.Background())
+			if !tt.wantErr {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, errors.ErrCredentialValidationFailed), "got %v", err)
+			assert.Contains(t, err.Error(), "invalid_client")
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package oidc
+
+import (
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+)
+
+func init() {
+	provider.MustRegisterProvider(provider.ProviderOIDC, provider.Registration{
+		Constructor:  newFromConfig,
+		Capabilities: provider.Capabilities{BoundAudience: true, LookupAnyRegion: true},
+		Inputs: []provider.Input{
+			{Name: "issuer-url", RequiredFor: provider.OperationKubeconfig, Validate: ValidateIssuerURL},
+			{Name: "client-id", RequiredFor: provider.OperationKubeconfig},
+		},
+	})
+}
+
+// newFromConfig creates an OIDC provider from the shared provider configuration
+func newFromConfig(cfg *provider.Config, log logger.Logger) (provider.Provider, error) {
+	config := DefaultConfig()
+	config.IssuerURL = cfg.OIDCIssuerURL
+	config.ClientID = cfg.OIDCClientID
+	config.CredentialsFile = cfg.CredentialsFile
+	config.PrivateKeyFile = cfg.OIDCPrivateKeyFile
+	if len(cfg.OIDCScopes) > 0 {
+		config.Scopes = cfg.OIDCScopes
+	}
+	config.SubjectTokenFile = cfg.OIDCSubjectTokenFile
+	if cfg.OIDCSubjectTokenType != "" {
+		config.SubjectTokenType = cfg.OIDCSubjectTokenType
+	}
+	config.UseIDToken = cfg.OIDCUseIDToken
+	config.StrictPermissions = cfg.StrictPermissions
+	config.Tracing = cfg.Tracing
+	config.HTTPClient = cfg.HTTPClient
+
+	return NewProvider(config, log)
+}
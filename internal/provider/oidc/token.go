@@ -0,0 +1,310 @@
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/credentials"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+)
+
+// assertionLifetime is how long a private_key_jwt client assertion is valid
+const assertionLifetime = 5 * time.Minute
+
+// tokenResponse is a successful RFC 6749 / RFC 8693 token response
+type tokenResponse struct {
+	AccessToken     string `json:"access_token"`
+	IDToken         string `json:"id_token"`
+	TokenType       string `json:"token_type"`
+	IssuedTokenType string `json:"issued_token_type"`
+	ExpiresIn       int64  `json:"expires_in"`
+}
+
+// tokenErrorResponse is an RFC 6749 section 5.2 error response
+type tokenErrorResponse struct {
+	Error       string `json:"error"`
+	Description string `json:"error_description"`
+}
+
+// requestToken discovers the token endpoint and requests a token with creds.
+// A non-empty audience is sent as the RFC 8693 audience parameter.
+func (p *Provider) requestToken(ctx context.Context, creds *credentials.OIDCCredentials, audience string) (*provider.Token, error) {
+	doc, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form, err := p.tokenForm(audience)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, doc.TokenEndpoint, nil)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrInternal, err, "failed to build OIDC token request")
+	}
+	if err := p.authenticate(req, form, doc, creds); err != nil {
+		return nil, err
+	}
+	body := form.Encode()
+	req.Body = io.NopCloser(strings.NewReader(body))
+	req.ContentLength = int64(len(body))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	p.config.Tracing.InjectBaggage(ctx, req.Header)
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrNetworkUnreachable, err, "OIDC token request failed").
+			WithField("url", doc.TokenEndpoint)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrNetworkUnreachable, err, "failed to read OIDC token response")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, tokenError(resp.StatusCode, resp.Header, data)
+	}
+
+	var tr tokenResponse
+	if err := json.Unmarshal(data, &tr); err != nil {
+		return nil, errors.Wrap(errors.ErrTokenInvalid, err, "failed to decode OIDC token response")
+	}
+	return p.tokenFromResponse(&tr)
+}
+
+// tokenForm returns the grant parameters of the token request
+func (p *Provider) tokenForm(audience string) (url.Values, error) {
+	form := url.Values{}
+	form.Set("grant_type", p.grantType())
+	if len(p.config.Scopes) > 0 {
+		form.Set("scope", strings.Join(p.config.Scopes, " "))
+	}
+	if audience != "" {
+		form.Set("audience", audience)
+	}
+
+	if p.config.SubjectTokenFile != "" {
+		// The file is read for every request, since kubelet rotates projected
+		// service account tokens in place
+		data, err := os.ReadFile(p.config.SubjectTokenFile)
+		if err != nil {
+			return nil, errors.Wrap(
+				errors.ErrCredentialLoadFailed,
+				err,
+				"failed to read OIDC subject token file",
+			).WithField("path", p.config.SubjectTokenFile)
+		}
+		subjectToken := strings.TrimSpace(string(data))
+		if subjectToken == "" {
+			return nil, errors.New(
+				errors.ErrCredentialNotFound,
+				"OIDC subject token file is empty",
+			).WithField("path", p.config.SubjectTokenFile)
+		}
+		form.Set("subject_token", subjectToken)
+		form.Set("subject_token_type", p.config.SubjectTokenType)
+		if p.config.UseIDToken {
+			form.Set("requested_token_type", tokenTypeIDToken)
+		}
+	}
+
+	return form, nil
+}
+
+// authenticate adds the client authentication to the token request: a
+// private_key_jwt client assertion when a private key is loaded, otherwise the
+// client secret with HTTP Basic authentication, or in the form when the issuer
+// only accepts client_secret_post
+func (p *Provider) authenticate(req *http.Request, form url.Values, doc *discoveryDocument, creds *credentials.OIDCCredentials) error {
+	if creds.PrivateKey != "" {
+		assertion, err := p.clientAssertion(creds.PrivateKey, doc.TokenEndpoint)
+		if err != nil {
+			return err
+		}
+		form.Set("client_id", p.config.ClientID)
+		form.Set("client_assertion_type", clientAssertionTypeJWT)
+		form.Set("client_assertion", assertion)
+		return nil
+	}
+
+	if !doc.supportsAuthMethod("client_secret_basic") && doc.supportsAuthMethod("client_secret_post") {
+		form.Set("client_id", p.config.ClientID)
+		form.Set("client_secret", creds.ClientSecret)
+		return nil
+	}
+
+	// RFC 6749 section 2.3.1 form-encodes the client ID and secret before Basic encoding
+	req.SetBasicAuth(url.QueryEscape(p.config.ClientID), url.QueryEscape(creds.ClientSecret))
+	return nil
+}
+
+// clientAssertion signs an RFC 7523 client assertion for the token endpoint with an
+// RSA (RS256) or EC (ES256) private key
+func (p *Provider) clientAssertion(pemKey, tokenEndpoint string) (string, error) {
+	key, method, err := parseSigningKey(pemKey)
+	if err != nil {
+		return "", err
+	}
+
+	jti := make([]byte, 16)
+	if _, err := rand.Read(jti); err != nil {
+		return "", errors.Wrap(errors.ErrInternal, err, "failed to generate client assertion ID")
+	}
+
+	now := p.now()
+	claims := jwt.RegisteredClaims{
+		Issuer:    p.config.ClientID,
+		Subject:   p.config.ClientID,
+		Audience:  jwt.ClaimStrings{tokenEndpoint},
+		ID:        hex.EncodeToString(jti),
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(assertionLifetime)),
+	}
+
+	assertion, err := jwt.NewWithClaims(method, claims).SignedString(key)
+	if err != nil {
+		return "", errors.Wrap(errors.ErrTokenGenerationFailed, err, "failed to sign OIDC client assertion")
+	}
+	return assertion, nil
+}
+
+// parseSigningKey decodes a PKCS#1, SEC 1 or PKCS#8 PEM private key and returns
+// the JWT signing method for its type
+func parseSigningKey(pemKey string) (interface{}, jwt.SigningMethod, error) {
+	block, _ := pem.Decode([]byte(pemKey))
+	if block == nil {
+		return nil, nil, errors.New(
+			errors.ErrCredentialMalformed,
+			"OIDC private key is not PEM-encoded",
+		)
+	}
+
+	var parsed interface{}
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		parsed, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, nil, errors.Wrap(
+			errors.ErrCredentialMalformed,
+			err,
+			"failed to parse OIDC private key",
+		)
+	}
+
+	switch key := parsed.(type) {
+	case *rsa.PrivateKey:
+		return key, jwt.SigningMethodRS256, nil
+	case *ecdsa.PrivateKey:
+		return key, jwt.SigningMethodES256, nil
+	default:
+		return nil, nil, errors.New(
+			errors.ErrCredentialMalformed,
+			"OIDC private key is not an RSA or EC key",
+		).WithField("type", fmt.Sprintf("%T", parsed))
+	}
+}
+
+// tokenFromResponse picks the access or ID token from a token response and
+// determines its expiry
+func (p *Provider) tokenFromResponse(tr *tokenResponse) (*provider.Token, error) {
+	value := tr.AccessToken
+	// An exchanged ID token is returned as access_token with issued_token_type
+	// id_token; a client credentials response carries it in id_token
+	if p.config.UseIDToken && tr.IssuedTokenType != tokenTypeIDToken {
+		value = tr.IDToken
+	}
+	if value == "" {
+		kind := "access token"
+		if p.config.UseIDToken {
+			kind = "ID token"
+		}
+		return nil, errors.New(
+			errors.ErrTokenInvalid,
+			"OIDC token response has no "+kind,
+		).WithField("issuer", p.config.IssuerURL)
+	}
+
+	var expiresAt time.Time
+	if tr.ExpiresIn > 0 && !p.config.UseIDToken {
+		expiresAt = p.now().Add(time.Duration(tr.ExpiresIn) * time.Second)
+	} else if claims, err := provider.DecodeJWT(value); err == nil {
+		// expires_in describes the access token, so an ID token's own exp is used
+		if exp := claims.TimeClaim("exp"); exp != nil {
+			expiresAt = *exp
+		}
+	}
+	if expiresAt.IsZero() && tr.ExpiresIn > 0 {
+		expiresAt = p.now().Add(time.Duration(tr.ExpiresIn) * time.Second)
+	}
+	if expiresAt.IsZero() {
+		return nil, errors.New(
+			errors.ErrTokenInvalid,
+			"OIDC token expiry is unknown",
+		).WithField("issuer", p.config.IssuerURL).
+			WithDetail("the token response has no expires_in and the token has no exp claim")
+	}
+
+	return &provider.Token{
+		AccessToken: value,
+		ExpiresAt:   expiresAt,
+		TokenType:   "Bearer",
+	}, nil
+}
+
+// tokenError maps an OIDC token endpoint error response to an error code. A rate
+// limited response carries its Retry-After header.
+func tokenError(status int, header http.Header, body []byte) error {
+	var payload tokenErrorResponse
+	_ = json.Unmarshal(body, &payload)
+
+	code := errors.ErrTokenGenerationFailed
+	switch {
+	case status == http.StatusTooManyRequests:
+		code = errors.ErrRateLimitExceeded
+	case payload.Error == "invalid_client", payload.Error == "unauthorized_client", payload.Error == "invalid_grant":
+		code = errors.ErrCredentialInvalid
+	case status == http.StatusUnauthorized:
+		code = errors.ErrCredentialInvalid
+	}
+
+	message := payload.Error
+	if payload.Description != "" {
+		message += ": " + payload.Description
+	}
+	if message == "" {
+		message = http.StatusText(status)
+	}
+	tokenErr := errors.New(code, "OIDC token request rejected: "+message).WithFields(map[string]interface{}{
+		"status":     status,
+		"oidc_error": payload.Error,
+	})
+	if retryAfter := header.Get("Retry-After"); code == errors.ErrRateLimitExceeded && retryAfter != "" {
+		tokenErr.WithField(provider.RetryAfterField, retryAfter)
+	}
+	return tokenErr
+}
@@ -0,0 +1,68 @@
+package oidc
+
+import (
+	"net/http"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/tracing"
+)
+
+// Grant types and token types of RFC 6749 and RFC 8693
+const (
+	grantClientCredentials = "client_credentials"
+	grantTokenExchange     = "urn:ietf:params:oauth:grant-type:token-exchange"
+
+	// TokenTypeJWT is the RFC 8693 type of a JWT subject token, such as a Kubernetes
+	// service account token
+	TokenTypeJWT = "urn:ietf:params:oauth:token-type:jwt"
+
+	tokenTypeIDToken = "urn:ietf:params:oauth:token-type:id_token"
+
+	clientAssertionTypeJWT = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+)
+
+// Config holds OIDC provider configuration
+type Config struct {
+	// IssuerURL is the issuer; the token endpoint is read from its
+	// /.well-known/openid-configuration document
+	IssuerURL string
+
+	// ClientID is the OAuth client ID
+	ClientID string
+
+	// CredentialsFile holds the client secret (default: OIDC_CLIENT_SECRET_FILE)
+	CredentialsFile string
+
+	// PrivateKeyFile holds a PEM RSA or EC private key that signs private_key_jwt
+	// client assertions; it takes precedence over the client secret
+	PrivateKeyFile string
+
+	// Scopes are the requested scopes
+	Scopes []string
+
+	// SubjectTokenFile selects RFC 8693 token exchange of the token in this file,
+	// which is read again for each request so that rotated tokens are picked up
+	SubjectTokenFile string
+
+	// SubjectTokenType is the RFC 8693 type of the subject token
+	SubjectTokenType string
+
+	// UseIDToken returns the ID token instead of the access token
+	UseIDToken bool
+
+	// StrictPermissions rejects credentials files readable by group or others
+	StrictPermissions bool
+
+	// Tracing records spans for token generation; nil disables tracing
+	Tracing *tracing.Provider
+
+	// HTTPClient provides the transport of the issuer requests; nil uses the default
+	HTTPClient *http.Client
+}
+
+// DefaultConfig returns default OIDC configuration
+func DefaultConfig() *Config {
+	return &Config{
+		Scopes:           []string{"openid"},
+		SubjectTokenType: TokenTypeJWT,
+	}
+}
@@ -0,0 +1,73 @@
+package provider
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+)
+
+const (
+	// RetryAfterField is the error field holding the Retry-After header of a cloud API
+	// response rejected with HTTP 429, as sent: delay-seconds or an HTTP-date
+	RetryAfterField = "retry_after"
+
+	// RetryAfterSecondsField is the error field holding the wait in seconds before a
+	// request rejected by the local rate limiter may be retried
+	RetryAfterSecondsField = "retry_after_seconds"
+)
+
+// RateLimitError returns an ERR_RATE_LIMIT_EXCEEDED error wrapping err when resp is a
+// response with HTTP status 429, carrying its Retry-After header in RetryAfterField.
+// It returns nil for any other response.
+func RateLimitError(err error, resp *http.Response, title string) *errors.Error {
+	if resp == nil || resp.StatusCode != http.StatusTooManyRequests {
+		return nil
+	}
+	rateLimitErr := errors.Wrap(errors.ErrRateLimitExceeded, err, title)
+	if value := resp.Header.Get("Retry-After"); value != "" {
+		rateLimitErr.WithField(RetryAfterField, value)
+	}
+	return rateLimitErr
+}
+
+// RetryAfter returns how long to wait after now before retrying the request that
+// failed with err. It reads the RetryAfterField or RetryAfterSecondsField of an
+// ERR_RATE_LIMIT_EXCEEDED error; the bool is false for other errors and for rate
+// limit errors without a usable hint.
+func RetryAfter(err error, now time.Time) (time.Duration, bool) {
+	var e *errors.Error
+	if !errors.As(err, &e) || e.Code != errors.ErrRateLimitExceeded {
+		return 0, false
+	}
+	if value, ok := e.Fields[RetryAfterField].(string); ok {
+		return ParseRetryAfter(value, now)
+	}
+	if seconds, ok := e.Fields[RetryAfterSecondsField].(int); ok && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	return 0, false
+}
+
+// ParseRetryAfter parses a Retry-After header value, either delay-seconds or an
+// HTTP-date, into the wait after now. A date in the past is a wait of zero.
+func ParseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	if wait := date.Sub(now); wait > 0 {
+		return wait, true
+	}
+	return 0, true
+}
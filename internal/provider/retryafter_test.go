@@ -0,0 +1,79 @@
+package provider
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		value    string
+		wantWait time.Duration
+		wantOK   bool
+	}{
+		{name: "seconds", value: "2", wantWait: 2 * time.Second, wantOK: true},
+		{name: "seconds with spaces", value: " 120 ", wantWait: 2 * time.Minute, wantOK: true},
+		{name: "zero", value: "0", wantWait: 0, wantOK: true},
+		{name: "HTTP-date", value: "Sat, 01 Jun 2024 12:00:30 GMT", wantWait: 30 * time.Second, wantOK: true},
+		{name: "HTTP-date in the past", value: "Sat, 01 Jun 2024 11:59:00 GMT", wantWait: 0, wantOK: true},
+		{name: "negative seconds", value: "-5"},
+		{name: "garbage", value: "soon"},
+		{name: "empty", value: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wait, ok := ParseRetryAfter(tt.value, now)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.wantWait, wait)
+		})
+	}
+}
+
+func TestRateLimitError(t *testing.T) {
+	cause := fmt.Errorf("oauth2: cannot fetch token: 429 Too Many Requests")
+	now := time.Now()
+
+	resp := &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{"Retry-After": []string{"2"}}}
+	err := RateLimitError(cause, resp, "token endpoint rate limit exceeded")
+	require.NotNil(t, err)
+	assert.True(t, errors.Is(err, errors.ErrRateLimitExceeded))
+	assert.Equal(t, "2", err.Fields[RetryAfterField])
+	wait, ok := RetryAfter(err, now)
+	require.True(t, ok)
+	assert.Equal(t, 2*time.Second, wait)
+
+	// Without the header the error carries no hint
+	err = RateLimitError(cause, &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{}}, "rate limited")
+	require.NotNil(t, err)
+	_, ok = RetryAfter(err, now)
+	assert.False(t, ok)
+
+	assert.Nil(t, RateLimitError(cause, &http.Response{StatusCode: http.StatusBadRequest}, "rate limited"))
+	assert.Nil(t, RateLimitError(cause, nil, "rate limited"))
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Now()
+
+	// The seconds of a request rejected by the local rate limiter
+	wait, ok := RetryAfter(errors.New(errors.ErrRateLimitExceeded, "throttled").WithField(RetryAfterSecondsField, 3), now)
+	require.True(t, ok)
+	assert.Equal(t, 3*time.Second, wait)
+
+	// Other error codes carry no hint, even with the field set
+	_, ok = RetryAfter(errors.New(errors.ErrTokenGenerationFailed, "failed").WithField(RetryAfterField, "2"), now)
+	assert.False(t, ok)
+	_, ok = RetryAfter(fmt.Errorf("plain error"), now)
+	assert.False(t, ok)
+}
@@ -0,0 +1,46 @@
+package provider
+
+import (
+	"strings"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+)
+
+// CheckTokenIssuer rejects a token whose Provider names a provider other than name, as
+// when the token of one provider is validated by another. Tokens without a Provider,
+// such as those read back from an ExecCredential, pass.
+func CheckTokenIssuer(token *Token, name ProviderName) error {
+	if token == nil || token.Provider == "" || token.Provider == name.String() {
+		return nil
+	}
+	return errors.New(
+		errors.ErrTokenInvalid,
+		"token was issued by another provider",
+	).WithFields(map[string]interface{}{
+		"provider":       name.String(),
+		"token_provider": token.Provider,
+		"token_cluster":  token.ClusterName,
+	})
+}
+
+// TokenLogFields returns the issuing metadata of token as log fields, leaving out the
+// fields that are not set
+func TokenLogFields(token *Token) []logger.Field {
+	if token == nil {
+		return nil
+	}
+	var fields []logger.Field
+	for _, field := range []struct{ key, value string }{
+		{"provider", token.Provider},
+		{"cluster", token.ClusterName},
+		{"identity", token.Identity},
+		{"audience", token.Audience},
+		{"scopes", strings.Join(token.Scopes, ",")},
+	} {
+		if field.value != "" {
+			fields = append(fields, logger.String(field.key, field.value))
+		}
+	}
+	return fields
+}
@@ -0,0 +1,47 @@
+package provider
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+)
+
+func TestCheckTokenIssuer(t *testing.T) {
+	assert.NoError(t, CheckTokenIssuer(&Token{AccessToken: "token", Provider: "gcp"}, ProviderGCP))
+	assert.NoError(t, CheckTokenIssuer(&Token{AccessToken: "token"}, ProviderGCP), "tokens read from an ExecCredential have no provider")
+	assert.NoError(t, CheckTokenIssuer(nil, ProviderGCP))
+
+	err := CheckTokenIssuer(&Token{AccessToken: "token", Provider: "aws", ClusterName: "my-cluster"}, ProviderGCP)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, errors.ErrTokenInvalid))
+
+	var appErr *errors.Error
+	require.True(t, errors.As(err, &appErr))
+	assert.Equal(t, "aws", appErr.Fields["token_provider"])
+	assert.Equal(t, "gcp", appErr.Fields["provider"])
+}
+
+func TestTokenLogFields(t *testing.T) {
+	token := &Token{
+		AccessToken: "ya29.secret",
+		ExpiresAt:   time.Now().Add(time.Hour),
+		Provider:    "gcp",
+		ClusterName: "my-cluster",
+		Identity:    "d***@my-project.iam.gserviceaccount.com",
+		Scopes:      []string{"openid", "email"},
+	}
+	assert.Equal(t, []logger.Field{
+		logger.String("provider", "gcp"),
+		logger.String("cluster", "my-cluster"),
+		logger.String("identity", "d***@my-project.iam.gserviceaccount.com"),
+		logger.String("scopes", "openid,email"),
+	}, TokenLogFields(token))
+
+	assert.Empty(t, TokenLogFields(&Token{AccessToken: "token"}))
+	assert.Empty(t, TokenLogFields(nil))
+}
@@ -0,0 +1,262 @@
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+)
+
+// TokenStore persists tokens keyed by an options fingerprint.
+// Implementations must be safe for concurrent use.
+type TokenStore interface {
+	// Get returns the stored token for key, or nil if there is none.
+	// Expired tokens are pruned and reported as missing.
+	Get(ctx context.Context, key string) (*Token, error)
+
+	// Put stores token under key, replacing any previous token
+	Put(ctx context.Context, key string, token *Token) error
+
+	// Delete removes the token stored under key; deleting a missing key is not an error
+	Delete(ctx context.Context, key string) error
+}
+
+// TokenRefresher refreshes a token, returning currentToken unchanged while it is still valid.
+// Providers and the provider token generators implement it.
+type TokenRefresher interface {
+	RefreshToken(ctx context.Context, opts GetTokenOptions, currentToken *Token) (*Token, error)
+}
+
+// RefreshIfNeeded implements Provider.RefreshToken for p. It returns currentToken when
+// it does not need a refresh within threshold at the time of clock and p.ValidateToken
+// accepts it, and otherwise calls p.GetToken, so new tokens are requested with the same
+// option checks as GetToken.
+func RefreshIfNeeded(ctx context.Context, p Provider, opts GetTokenOptions, currentToken *Token, clock Clock, threshold time.Duration) (*Token, error) {
+	if currentToken != nil && !currentToken.NeedsRefresh(clock, threshold) && p.ValidateToken(currentToken) == nil {
+		return currentToken, nil
+	}
+	return p.GetToken(ctx, opts)
+}
+
+// Fingerprint returns the store key for tokens generated by providerName with opts
+func Fingerprint(providerName string, opts GetTokenOptions) string {
+	parts := []string{
+		providerName,
+		opts.ClusterName,
+		opts.Region,
+		opts.ProjectID,
+		opts.AccountID,
+		opts.SubscriptionID,
+		opts.TenantID,
+		opts.ResourceGroup,
+	}
+	// Appended only when set so the keys of tokens stored without them do not change
+	if opts.Audience != "" {
+		parts = append(parts, opts.Audience)
+	}
+	if opts.ClusterID != "" || opts.CompartmentID != "" {
+		parts = append(parts, "cluster-id="+opts.ClusterID, "compartment-id="+opts.CompartmentID)
+	}
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
+
+// ScopedFingerprint is Fingerprint for tokens also minted with scope, such as the
+// credentials in use; an empty scope gives the Fingerprint
+func ScopedFingerprint(providerName string, opts GetTokenOptions, scope string) string {
+	key := Fingerprint(providerName, opts)
+	if scope == "" {
+		return key
+	}
+	sum := sha256.Sum256([]byte(key + "\x00" + scope))
+	return hex.EncodeToString(sum[:])
+}
+
+// MemoryTokenStore is an in-process TokenStore
+type MemoryTokenStore struct {
+	mu     sync.Mutex
+	tokens map[string]Token
+}
+
+// NewMemoryTokenStore creates an empty in-memory token store
+func NewMemoryTokenStore() *MemoryTokenStore {
+	return &MemoryTokenStore{
+		tokens: make(map[string]Token),
+	}
+}
+
+// Get implements TokenStore
+func (s *MemoryTokenStore) Get(ctx context.Context, key string) (*Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	token, ok := s.tokens[key]
+	if !ok {
+		return nil, nil
+	}
+	if token.ExpiresWithinSkew(SystemClock) {
+		delete(s.tokens, key)
+		return nil, nil
+	}
+	return &token, nil
+}
+
+// Put implements TokenStore
+func (s *MemoryTokenStore) Put(ctx context.Context, key string, token *Token) error {
+	if token == nil {
+		return errors.New(
+			errors.ErrInvalidArgument,
+			"token is nil",
+		)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tokens[key] = *token
+	return nil
+}
+
+// Delete implements TokenStore
+func (s *MemoryTokenStore) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.tokens, key)
+	return nil
+}
+
+// CacheOutcome says how a token request was served by the token store
+type CacheOutcome string
+
+const (
+	// CacheHit means the stored token was still valid and returned as is
+	CacheHit CacheOutcome = "hit"
+
+	// CacheMiss means no token was stored and a new one was generated
+	CacheMiss CacheOutcome = "miss"
+
+	// CacheStaleRefresh means the stored token was close to expiry and was replaced
+	CacheStaleRefresh CacheOutcome = "stale_refresh"
+
+	// CacheBypass means the store could not be read and the token was generated without it
+	CacheBypass CacheOutcome = "bypass"
+)
+
+// RequestObserver records the latency of token requests by cache outcome.
+// *metrics.Metrics implements it.
+type RequestObserver interface {
+	RecordTokenRequestDuration(provider, cacheOutcome string, duration time.Duration)
+}
+
+// RequestObservers records every request with each of its observers
+type RequestObservers []RequestObserver
+
+// RecordTokenRequestDuration implements RequestObserver
+func (o RequestObservers) RecordTokenRequestDuration(provider, cacheOutcome string, duration time.Duration) {
+	for _, observer := range o {
+		observer.RecordTokenRequestDuration(provider, cacheOutcome, duration)
+	}
+}
+
+// StoreRefresher wraps a TokenRefresher with read-through/write-through persistence,
+// so providers stay storage-agnostic
+type StoreRefresher struct {
+	providerName string
+	refresher    TokenRefresher
+	store        TokenStore
+	logger       logger.Logger
+	observer     RequestObserver
+	scope        string
+	clock        Clock
+}
+
+// NewStoreRefresher creates a refresher that persists tokens in store
+func NewStoreRefresher(providerName string, refresher TokenRefresher, store TokenStore, log logger.Logger) *StoreRefresher {
+	return &StoreRefresher{
+		providerName: providerName,
+		refresher:    refresher,
+		store:        store,
+		logger:       log,
+		clock:        SystemClock,
+	}
+}
+
+// WithRequestObserver records the duration and cache outcome of every successful
+// request with observer
+func (r *StoreRefresher) WithRequestObserver(observer RequestObserver) *StoreRefresher {
+	r.observer = observer
+	return r
+}
+
+// WithScope keeps the tokens of r apart from those stored with another scope in the
+// same store, e.g. tokens minted with other credentials for the same options
+func (r *StoreRefresher) WithScope(scope string) *StoreRefresher {
+	r.scope = scope
+	return r
+}
+
+// RefreshToken loads the stored token, refreshes it if needed and stores the result.
+// Store failures are logged and never fail the refresh.
+func (r *StoreRefresher) RefreshToken(ctx context.Context, opts GetTokenOptions) (*Token, error) {
+	token, _, err := r.Refresh(ctx, opts)
+	return token, err
+}
+
+// Refresh is RefreshToken that also reports how the store served the request.
+// Failed requests are not observed, so the latency histogram only covers tokens returned.
+func (r *StoreRefresher) Refresh(ctx context.Context, opts GetTokenOptions) (*Token, CacheOutcome, error) {
+	start := r.clock.Now()
+	key := ScopedFingerprint(r.providerName, opts, r.scope)
+
+	outcome := CacheMiss
+	current, err := r.store.Get(ctx, key)
+	if err != nil {
+		r.logger.Warn("Failed to read token from store",
+			logger.String("provider", r.providerName),
+			logger.Error(err),
+		)
+		current = nil
+		outcome = CacheBypass
+	}
+
+	token, err := r.refresher.RefreshToken(ctx, opts, current)
+	if err != nil {
+		return nil, outcome, err
+	}
+
+	if current != nil {
+		if sameToken(token, current) {
+			r.observe(CacheHit, start)
+			return token, CacheHit, nil
+		}
+		outcome = CacheStaleRefresh
+	}
+
+	if err := r.store.Put(ctx, key, token); err != nil {
+		r.logger.Warn("Failed to write token to store",
+			logger.String("provider", r.providerName),
+			logger.Error(err),
+		)
+	}
+
+	r.observe(outcome, start)
+	return token, outcome, nil
+}
+
+func (r *StoreRefresher) observe(outcome CacheOutcome, start time.Time) {
+	if r.observer != nil {
+		r.observer.RecordTokenRequestDuration(r.providerName, string(outcome), r.clock.Now().Sub(start))
+	}
+}
+
+// sameToken reports whether a and b are the same bearer token; the issuing metadata is
+// not compared since tokens read from a store may lack it
+func sameToken(a, b *Token) bool {
+	return a.AccessToken == b.AccessToken && a.ExpiresAt == b.ExpiresAt && a.TokenType == b.TokenType
+}
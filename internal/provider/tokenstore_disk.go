@@ -0,0 +1,218 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+)
+
+// storeKeyPattern restricts disk store keys to safe file names
+var storeKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// diskTokenEntry is the on-disk representation of a stored token
+type diskTokenEntry struct {
+	Fingerprint string    `json:"fingerprint"`
+	AccessToken string    `json:"accessToken"`
+	TokenType   string    `json:"tokenType"`
+	ExpiresAt   time.Time `json:"expiresAt"`
+	Provider    string    `json:"provider,omitempty"`
+	ClusterName string    `json:"clusterName,omitempty"`
+	Identity    string    `json:"identity,omitempty"`
+	Audience    string    `json:"audience,omitempty"`
+	Scopes      []string  `json:"scopes,omitempty"`
+}
+
+// DiskTokenStore is a TokenStore that keeps one 0600 JSON file per key in a directory
+type DiskTokenStore struct {
+	mu  sync.Mutex
+	dir string
+}
+
+// NewDiskTokenStore creates a disk token store rooted at dir, creating it if needed
+func NewDiskTokenStore(dir string) (*DiskTokenStore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, errors.Wrap(
+			errors.ErrInternal,
+			err,
+			"failed to create token store directory",
+		).WithField("dir", dir)
+	}
+	// MkdirAll leaves an existing directory's mode alone
+	if err := os.Chmod(dir, 0700); err != nil {
+		return nil, errors.Wrap(
+			errors.ErrInternal,
+			err,
+			"failed to restrict token store directory permissions",
+		).WithField("dir", dir)
+	}
+
+	return &DiskTokenStore{dir: dir}, nil
+}
+
+// Get implements TokenStore
+func (s *DiskTokenStore) Get(ctx context.Context, key string) (*Token, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(
+			errors.ErrInternal,
+			err,
+			"failed to read stored token",
+		).WithField("path", path)
+	}
+
+	var entry diskTokenEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		// A corrupt entry is treated as missing and replaced on the next Put
+		return nil, nil
+	}
+
+	// Guard against entries written for different options
+	if entry.Fingerprint != key {
+		return nil, nil
+	}
+
+	token := &Token{
+		AccessToken: entry.AccessToken,
+		TokenType:   entry.TokenType,
+		ExpiresAt:   entry.ExpiresAt,
+		Provider:    entry.Provider,
+		ClusterName: entry.ClusterName,
+		Identity:    entry.Identity,
+		Audience:    entry.Audience,
+		Scopes:      entry.Scopes,
+	}
+	if token.ExpiresWithinSkew(SystemClock) {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return nil, errors.Wrap(
+				errors.ErrInternal,
+				err,
+				"failed to prune expired token",
+			).WithField("path", path)
+		}
+		return nil, nil
+	}
+
+	return token, nil
+}
+
+// Put implements TokenStore
+func (s *DiskTokenStore) Put(ctx context.Context, key string, token *Token) error {
+	if token == nil {
+		return errors.New(
+			errors.ErrInvalidArgument,
+			"token is nil",
+		)
+	}
+
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(diskTokenEntry{
+		Fingerprint: key,
+		AccessToken: token.AccessToken,
+		TokenType:   token.TokenType,
+		ExpiresAt:   token.ExpiresAt,
+		Provider:    token.Provider,
+		ClusterName: token.ClusterName,
+		Identity:    token.Identity,
+		Audience:    token.Audience,
+		Scopes:      token.Scopes,
+	})
+	if err != nil {
+		return errors.Wrap(
+			errors.ErrInternal,
+			err,
+			"failed to encode token",
+		)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Write to a temp file and rename so readers never see a partial entry
+	tmp, err := os.CreateTemp(s.dir, ".token-*")
+	if err != nil {
+		return errors.Wrap(
+			errors.ErrInternal,
+			err,
+			"failed to create temporary token file",
+		).WithField("dir", s.dir)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return errors.Wrap(
+			errors.ErrInternal,
+			err,
+			"failed to write token",
+		).WithField("path", path)
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.Wrap(
+			errors.ErrInternal,
+			err,
+			"failed to write token",
+		).WithField("path", path)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return errors.Wrap(
+			errors.ErrInternal,
+			err,
+			"failed to store token",
+		).WithField("path", path)
+	}
+
+	return nil
+}
+
+// Delete implements TokenStore
+func (s *DiskTokenStore) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(
+			errors.ErrInternal,
+			err,
+			"failed to delete stored token",
+		).WithField("path", path)
+	}
+
+	return nil
+}
+
+// path returns the file path for key
+func (s *DiskTokenStore) path(key string) (string, error) {
+	if !storeKeyPattern.MatchString(key) {
+		return "", errors.New(
+			errors.ErrInvalidArgument,
+			"invalid token store key",
+		).WithField("key", key)
+	}
+	return filepath.Join(s.dir, key+".json"), nil
+}
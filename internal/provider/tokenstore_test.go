@@ -0,0 +1,446 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+)
+
+// tokenStoreFactories lists every TokenStore implementation run through the contract suite
+func tokenStoreFactories() map[string]func(t *testing.T) TokenStore {
+	return map[string]func(t *testing.T) TokenStore{
+		"memory": func(t *testing.T) TokenStore {
+			return NewMemoryTokenStore()
+		},
+		"disk": func(t *testing.T) TokenStore {
+			store, err := NewDiskTokenStore(filepath.Join(t.TempDir(), "tokens"))
+			require.NoError(t, err)
+			return store
+		},
+	}
+}
+
+func TestTokenStoreContract(t *testing.T) {
+	ctx := context.Background()
+	opts := GetTokenOptions{ClusterName: "my-cluster", Region: "us-east-1"}
+
+	for name, newStore := range tokenStoreFactories() {
+		t.Run(name, func(t *testing.T) {
+			t.Run("get missing key", func(t *testing.T) {
+				store := newStore(t)
+				token, err := store.Get(ctx, Fingerprint("aws", opts))
+				require.NoError(t, err)
+				assert.Nil(t, token)
+			})
+
+			t.Run("put then get", func(t *testing.T) {
+				store := newStore(t)
+				key := Fingerprint("aws", opts)
+				want := &Token{
+					AccessToken: "token-1",
+					ExpiresAt:   time.Now().Add(time.Hour).UTC().Truncate(time.Second),
+					TokenType:   "Bearer",
+					Provider:    "aws",
+					ClusterName: "my-cluster",
+					Identity:    "AKIA***",
+					Audience:    "my-cluster",
+				}
+				require.NoError(t, store.Put(ctx, key, want))
+
+				got, err := store.Get(ctx, key)
+				require.NoError(t, err)
+				require.NotNil(t, got)
+				assert.Equal(t, want.AccessToken, got.AccessToken)
+				assert.Equal(t, want.TokenType, got.TokenType)
+				assert.True(t, want.ExpiresAt.Equal(got.ExpiresAt))
+				assert.Equal(t, want.Provider, got.Provider)
+				assert.Equal(t, want.ClusterName, got.ClusterName)
+				assert.Equal(t, want.Identity, got.Identity)
+				assert.Equal(t, want.Audience, got.Audience)
+			})
+
+			t.Run("put replaces previous token", func(t *testing.T) {
+				store := newStore(t)
+				key := Fingerprint("aws", opts)
+				require.NoError(t, store.Put(ctx, key, &Token{AccessToken: "old", ExpiresAt: time.Now().Add(time.Hour)}))
+				require.NoError(t, store.Put(ctx, key, &Token{AccessToken: "new", ExpiresAt: time.Now().Add(time.Hour)}))
+
+				got, err := store.Get(ctx, key)
+				require.NoError(t, err)
+				require.NotNil(t, got)
+				assert.Equal(t, "new", got.AccessToken)
+			})
+
+			t.Run("delete", func(t *testing.T) {
+				store := newStore(t)
+				key := Fingerprint("aws", opts)
+				require.NoError(t, store.Put(ctx, key, &Token{AccessToken: "token", ExpiresAt: time.Now().Add(time.Hour)}))
+				require.NoError(t, store.Delete(ctx, key))
+				require.NoError(t, store.Delete(ctx, key), "deleting a missing key is not an error")
+
+				got, err := store.Get(ctx, key)
+				require.NoError(t, err)
+				assert.Nil(t, got)
+			})
+
+			t.Run("expired token is pruned", func(t *testing.T) {
+				store := newStore(t)
+				key := Fingerprint("aws", opts)
+				require.NoError(t, store.Put(ctx, key, &Token{AccessToken: "stale", ExpiresAt: time.Now().Add(-time.Minute)}))
+
+				got, err := store.Get(ctx, key)
+				require.NoError(t, err)
+				assert.Nil(t, got)
+
+				// Writing a fresh token after pruning still works
+				require.NoError(t, store.Put(ctx, key, &Token{AccessToken: "fresh", ExpiresAt: time.Now().Add(time.Hour)}))
+				got, err = store.Get(ctx, key)
+				require.NoError(t, err)
+				require.NotNil(t, got)
+				assert.Equal(t, "fresh", got.AccessToken)
+			})
+
+			t.Run("fingerprint mismatch", func(t *testing.T) {
+				store := newStore(t)
+				require.NoError(t, store.Put(ctx, Fingerprint("aws", opts), &Token{AccessToken: "token", ExpiresAt: time.Now().Add(time.Hour)}))
+
+				otherOpts := opts
+				otherOpts.Region = "eu-west-1"
+				for _, key := range []string{Fingerprint("aws", otherOpts), Fingerprint("gcp", opts)} {
+					got, err := store.Get(ctx, key)
+					require.NoError(t, err)
+					assert.Nil(t, got)
+				}
+			})
+
+			t.Run("concurrent access", func(t *testing.T) {
+				store := newStore(t)
+				var wg sync.WaitGroup
+				for i := 0; i < 20; i++ {
+					wg.Add(1)
+					go func(i int) {
+						defer wg.Done()
+						key := Fingerprint("aws", GetTokenOptions{ClusterName: fmt.Sprintf("cluster-%d", i%4)})
+						token := &Token{AccessToken: fmt.Sprintf("token-%d", i), ExpiresAt: time.Now().Add(time.Hour)}
+						assert.NoError(t, store.Put(ctx, key, token))
+						got, err := store.Get(ctx, key)
+						assert.NoError(t, err)
+						assert.NotNil(t, got)
+						if i%5 == 0 {
+							assert.NoError(t, store.Delete(ctx, key))
+						}
+					}(i)
+				}
+				wg.Wait()
+			})
+		})
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	opts := GetTokenOptions{ClusterName: "my-cluster", ProjectID: "my-project", Region: "us-central1"}
+
+	assert.Equal(t, Fingerprint("gcp", opts), Fingerprint("gcp", opts))
+	assert.NotEqual(t, Fingerprint("gcp", opts), Fingerprint("aws", opts))
+
+	other := opts
+	other.ProjectID = "other-project"
+	assert.NotEqual(t, Fingerprint("gcp", opts), Fingerprint("gcp", other))
+
+	bound := opts
+	bound.Audience = "https://gateway.example.com"
+	assert.NotEqual(t, Fingerprint("gcp", opts), Fingerprint("gcp", bound))
+
+	clusterID := opts
+	clusterID.ClusterID = "other-cluster-id"
+	assert.NotEqual(t, Fingerprint("gcp", opts), Fingerprint("gcp", clusterID))
+
+	// Field boundaries are unambiguous
+	a := GetTokenOptions{ClusterName: "ab", Region: "c"}
+	b := GetTokenOptions{ClusterName: "a", Region: "bc"}
+	assert.NotEqual(t, Fingerprint("gcp", a), Fingerprint("gcp", b))
+}
+
+func TestDiskTokenStore_RejectsUnsafeKeys(t *testing.T) {
+	store, err := NewDiskTokenStore(t.TempDir())
+	require.NoError(t, err)
+
+	for _, key := range []string{"", "../escape", "a/b"} {
+		err := store.Put(context.Background(), key, &Token{AccessToken: "token", ExpiresAt: time.Now().Add(time.Hour)})
+		assert.Error(t, err, "key %q", key)
+	}
+}
+
+func TestDiskTokenStore_FilePermissions(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewDiskTokenStore(dir)
+	require.NoError(t, err)
+
+	key := Fingerprint("aws", GetTokenOptions{ClusterName: "my-cluster"})
+	require.NoError(t, store.Put(context.Background(), key, &Token{AccessToken: "token", ExpiresAt: time.Now().Add(time.Hour)}))
+
+	info, err := os.Stat(filepath.Join(dir, key+".json"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
+
+func TestDiskTokenStore_RestrictsExistingDir(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("POSIX permission bits are not enforced on Windows")
+	}
+
+	dir := filepath.Join(t.TempDir(), "cache")
+	require.NoError(t, os.Mkdir(dir, 0755))
+
+	_, err := NewDiskTokenStore(dir)
+	require.NoError(t, err)
+
+	info, err := os.Stat(dir)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0700), info.Mode().Perm())
+}
+
+// fakeRefresher records the current token it was given and returns the configured token
+type fakeRefresher struct {
+	calls   int
+	current *Token
+	token   *Token
+	err     error
+}
+
+func (f *fakeRefresher) RefreshToken(ctx context.Context, opts GetTokenOptions, currentToken *Token) (*Token, error) {
+	f.calls++
+	f.current = currentToken
+	if f.err != nil {
+		return nil, f.err
+	}
+	if currentToken != nil && currentToken.ExpiresIn() > 2*time.Minute {
+		return currentToken, nil
+	}
+	return f.token, nil
+}
+
+func TestRefreshIfNeeded(t *testing.T) {
+	now := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	fresh := &Token{AccessToken: "fresh", ExpiresAt: now.Add(time.Hour)}
+
+	tests := []struct {
+		name          string
+		current       *Token
+		validateErr   error
+		wantGenerated bool
+	}{
+		{name: "no current token", wantGenerated: true},
+		{name: "current token is kept", current: &Token{AccessToken: "current", ExpiresAt: now.Add(10 * time.Minute)}},
+		{name: "current token within the threshold", current: &Token{AccessToken: "current", ExpiresAt: now.Add(5 * time.Minute)}, wantGenerated: true},
+		{name: "current token rejected by the provider", current: &Token{AccessToken: "current", ExpiresAt: now.Add(10 * time.Minute)}, validateErr: fmt.Errorf("bad token"), wantGenerated: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			generated := 0
+			p := &MockProvider{
+				GetTokenFunc: func(ctx context.Context, opts GetTokenOptions) (*Token, error) {
+					generated++
+					return fresh, nil
+				},
+				ValidateTokenFunc: func(token *Token) error { return tt.validateErr },
+			}
+
+			token, err := RefreshIfNeeded(context.Background(), p, GetTokenOptions{ClusterName: "my-cluster"}, tt.current, ClockFunc(func() time.Time { return now }), 5*time.Minute)
+			require.NoError(t, err)
+			if tt.wantGenerated {
+				assert.Same(t, fresh, token)
+				assert.Equal(t, 1, generated)
+				return
+			}
+			assert.Same(t, tt.current, token)
+			assert.Zero(t, generated)
+		})
+	}
+}
+
+func TestStoreRefresher_Scope(t *testing.T) {
+	ctx := context.Background()
+	opts := GetTokenOptions{ClusterName: "my-cluster"}
+	store := NewMemoryTokenStore()
+
+	_, err := NewStoreRefresher("aws", &fakeRefresher{token: &Token{AccessToken: "profile-a", ExpiresAt: time.Now().Add(time.Hour)}}, store, logger.Nop()).
+		WithScope("a").RefreshToken(ctx, opts)
+	require.NoError(t, err)
+
+	refresher := &fakeRefresher{token: &Token{AccessToken: "profile-b", ExpiresAt: time.Now().Add(time.Hour)}}
+	token, err := NewStoreRefresher("aws", refresher, store, logger.Nop()).WithScope("b").RefreshToken(ctx, opts)
+	require.NoError(t, err)
+	assert.Equal(t, "profile-b", token.AccessToken)
+	assert.Nil(t, refresher.current, "tokens of another scope are not reused")
+}
+
+func TestStoreRefresher(t *testing.T) {
+	ctx := context.Background()
+	opts := GetTokenOptions{ClusterName: "my-cluster", Region: "us-east-1"}
+	key := Fingerprint("aws", opts)
+
+	t.Run("writes refreshed token through to the store", func(t *testing.T) {
+		store := NewMemoryTokenStore()
+		fresh := &Token{AccessToken: "fresh", ExpiresAt: time.Now().Add(time.Hour), TokenType: "Bearer"}
+		refresher := &fakeRefresher{token: fresh}
+
+		token, err := NewStoreRefresher("aws", refresher, store, logger.Nop()).RefreshToken(ctx, opts)
+		require.NoError(t, err)
+		assert.Equal(t, "fresh", token.AccessToken)
+		assert.Nil(t, refresher.current)
+
+		stored, err := store.Get(ctx, key)
+		require.NoError(t, err)
+		require.NotNil(t, stored)
+		assert.Equal(t, "fresh", stored.AccessToken)
+	})
+
+	t.Run("reads stored token and passes it to the provider", func(t *testing.T) {
+		store := NewMemoryTokenStore()
+		cached := &Token{AccessToken: "cached", ExpiresAt: time.Now().Add(time.Hour), TokenType: "Bearer"}
+		require.NoError(t, store.Put(ctx, key, cached))
+		refresher := &fakeRefresher{token: &Token{AccessToken: "fresh", ExpiresAt: time.Now().Add(time.Hour)}}
+
+		token, err := NewStoreRefresher("aws", refresher, store, logger.Nop()).RefreshToken(ctx, opts)
+		require.NoError(t, err)
+		assert.Equal(t, "cached", token.AccessToken)
+		require.NotNil(t, refresher.current)
+		assert.Equal(t, "cached", refresher.current.AccessToken)
+	})
+
+	t.Run("refresh error leaves store untouched", func(t *testing.T) {
+		store := NewMemoryTokenStore()
+		refresher := &fakeRefresher{err: fmt.Errorf("boom")}
+
+		_, err := NewStoreRefresher("aws", refresher, store, logger.Nop()).RefreshToken(ctx, opts)
+		assert.Error(t, err)
+
+		stored, err := store.Get(ctx, key)
+		require.NoError(t, err)
+		assert.Nil(t, stored)
+	})
+}
+
+// fakeClock advances by step every time it is read
+type fakeClock struct {
+	now  time.Time
+	step time.Duration
+}
+
+func (c *fakeClock) Now() time.Time {
+	now := c.now
+	c.now = c.now.Add(c.step)
+	return now
+}
+
+// requestObservation is one call to RecordTokenRequestDuration
+type requestObservation struct {
+	provider string
+	outcome  string
+	duration time.Duration
+}
+
+type fakeObserver struct {
+	observations []requestObservation
+}
+
+func (o *fakeObserver) RecordTokenRequestDuration(provider, cacheOutcome string, duration time.Duration) {
+	o.observations = append(o.observations, requestObservation{provider, cacheOutcome, duration})
+}
+
+// unreadableStore fails every read
+type unreadableStore struct {
+	TokenStore
+}
+
+func (s unreadableStore) Get(ctx context.Context, key string) (*Token, error) {
+	return nil, fmt.Errorf("permission denied")
+}
+
+func TestStoreRefresher_CacheOutcome(t *testing.T) {
+	ctx := context.Background()
+	opts := GetTokenOptions{ClusterName: "my-cluster", Region: "us-east-1"}
+	key := Fingerprint("aws", opts)
+	fresh := &Token{AccessToken: "fresh", ExpiresAt: time.Now().Add(time.Hour), TokenType: "Bearer"}
+
+	tests := []struct {
+		name        string
+		stored      *Token
+		unreadable  bool
+		wantOutcome CacheOutcome
+		wantToken   string
+	}{
+		{
+			name:        "nothing stored",
+			wantOutcome: CacheMiss,
+			wantToken:   "fresh",
+		},
+		{
+			name:        "valid token stored",
+			stored:      &Token{AccessToken: "cached", ExpiresAt: time.Now().Add(time.Hour), TokenType: "Bearer"},
+			wantOutcome: CacheHit,
+			wantToken:   "cached",
+		},
+		{
+			name:        "token about to expire",
+			stored:      &Token{AccessToken: "stale", ExpiresAt: time.Now().Add(90 * time.Second), TokenType: "Bearer"},
+			wantOutcome: CacheStaleRefresh,
+			wantToken:   "fresh",
+		},
+		{
+			name:        "store unreadable",
+			unreadable:  true,
+			wantOutcome: CacheBypass,
+			wantToken:   "fresh",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			disk, err := NewDiskTokenStore(t.TempDir())
+			require.NoError(t, err)
+			if tt.stored != nil {
+				require.NoError(t, disk.Put(ctx, key, tt.stored))
+			}
+			var store TokenStore = disk
+			if tt.unreadable {
+				store = unreadableStore{disk}
+			}
+
+			observer := &fakeObserver{}
+			refresher := NewStoreRefresher("aws", &fakeRefresher{token: fresh}, store, logger.Nop()).
+				WithRequestObserver(observer)
+			refresher.clock = &fakeClock{now: time.Unix(1700000000, 0), step: 250 * time.Millisecond}
+
+			token, outcome, err := refresher.Refresh(ctx, opts)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantOutcome, outcome)
+			assert.Equal(t, tt.wantToken, token.AccessToken)
+			assert.Equal(t, []requestObservation{
+				{provider: "aws", outcome: string(tt.wantOutcome), duration: 250 * time.Millisecond},
+			}, observer.observations)
+		})
+	}
+
+	t.Run("failed requests are not observed", func(t *testing.T) {
+		observer := &fakeObserver{}
+		_, outcome, err := NewStoreRefresher("aws", &fakeRefresher{err: fmt.Errorf("boom")}, NewMemoryTokenStore(), logger.Nop()).
+			WithRequestObserver(observer).
+			Refresh(ctx, opts)
+		assert.Error(t, err)
+		assert.Equal(t, CacheMiss, outcome)
+		assert.Empty(t, observer.observations)
+	})
+}
@@ -0,0 +1,56 @@
+package provider
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/tracing"
+)
+
+// StartSpan starts the span of a provider operation, named "<provider>.<operation>"
+// (for example aws.GenerateToken), with the provider, cluster and region attributes and
+// the allowed caller baggage (see tracing.BaggageKeys).
+// A nil tracing provider starts a span that is not recorded.
+func StartSpan(ctx context.Context, tp *tracing.Provider, name ProviderName, operation, cluster, region string) (context.Context, trace.Span) {
+	ctx, span := tp.StartSpan(ctx, name.String()+"."+operation)
+
+	attrs := []attribute.KeyValue{
+		attribute.String("provider", name.String()),
+		attribute.String("cluster", cluster),
+	}
+	if region != "" {
+		attrs = append(attrs, attribute.String("region", region))
+	}
+	attrs = append(attrs, tp.BaggageAttributes(ctx)...)
+	tracing.SetAttributes(ctx, attrs...)
+
+	return ctx, span
+}
+
+// EndSpan records err on the span started by StartSpan when it is non-nil and ends the span
+func EndSpan(ctx context.Context, span trace.Span, err error) {
+	if err != nil {
+		tracing.RecordError(ctx, err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// LoggerWithBaggage returns log with the allowed caller baggage of ctx as fields, so
+// result log entries can be matched to the caller's trace. With a nil tracing provider
+// it returns log unchanged.
+func LoggerWithBaggage(ctx context.Context, tp *tracing.Provider, log logger.Logger) logger.Logger {
+	members := tp.Baggage(ctx)
+	if len(members) == 0 {
+		return log
+	}
+	fields := make([]logger.Field, 0, len(members))
+	for _, member := range members {
+		fields = append(fields, logger.String(member.Key(), member.Value()))
+	}
+	return log.With(fields...)
+}
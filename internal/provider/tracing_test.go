@@ -0,0 +1,123 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/tracing"
+)
+
+func TestStartSpan(t *testing.T) {
+	tests := []struct {
+		name       string
+		region     string
+		err        error
+		wantAttrs  []attribute.KeyValue
+		wantStatus codes.Code
+	}{
+		{
+			name:   "success",
+			region: "us-east-1",
+			wantAttrs: []attribute.KeyValue{
+				attribute.String("provider", "aws"),
+				attribute.String("cluster", "my-cluster"),
+				attribute.String("region", "us-east-1"),
+			},
+			wantStatus: codes.Unset,
+		},
+		{
+			name: "failure without region",
+			err:  errors.New(errors.ErrTokenGenerationFailed, "failed to presign request"),
+			wantAttrs: []attribute.KeyValue{
+				attribute.String("provider", "aws"),
+				attribute.String("cluster", "my-cluster"),
+			},
+			wantStatus: codes.Error,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := tracetest.NewSpanRecorder()
+			tp := tracing.NewProviderFromTracerProvider(
+				sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)),
+				tracing.DefaultConfig(),
+			)
+
+			ctx, span := StartSpan(context.Background(), tp, ProviderAWS, "GenerateToken", "my-cluster", tt.region)
+			EndSpan(ctx, span, tt.err)
+
+			spans := recorder.Ended()
+			require.Len(t, spans, 1)
+			assert.Equal(t, "aws.GenerateToken", spans[0].Name())
+			assert.ElementsMatch(t, tt.wantAttrs, spans[0].Attributes())
+			assert.Equal(t, tt.wantStatus, spans[0].Status().Code)
+			if tt.err != nil {
+				require.Len(t, spans[0].Events(), 1)
+				assert.Equal(t, "exception", spans[0].Events()[0].Name)
+				assert.Equal(t, tt.err.Error(), spans[0].Status().Description)
+			} else {
+				assert.Empty(t, spans[0].Events())
+			}
+		})
+	}
+}
+
+func TestStartSpan_NilProvider(t *testing.T) {
+	ctx, span := StartSpan(context.Background(), nil, ProviderGCP, "GetClusterInfo", "my-cluster", "")
+	assert.False(t, span.IsRecording())
+	EndSpan(ctx, span, assert.AnError)
+}
+
+// fieldLogger records the fields added with With
+type fieldLogger struct {
+	logger.Logger
+	fields []logger.Field
+}
+
+func (l *fieldLogger) With(fields ...logger.Field) logger.Logger {
+	return &fieldLogger{Logger: l.Logger, fields: append(append([]logger.Field{}, l.fields...), fields...)}
+}
+
+func TestStartSpan_Baggage(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := tracing.NewProviderFromTracerProvider(
+		sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)),
+		tracing.DefaultConfig(),
+	)
+	bag, err := baggage.Parse("hyperfleet.cluster=spoke-1,hyperfleet.request_id=req-42,team=payments")
+	require.NoError(t, err)
+	ctx := baggage.ContextWithBaggage(context.Background(), bag)
+
+	spanCtx, span := StartSpan(ctx, tp, ProviderGCP, "GetClusterInfo", "my-cluster", "")
+	EndSpan(spanCtx, span, nil)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	assert.ElementsMatch(t, []attribute.KeyValue{
+		attribute.String("provider", "gcp"),
+		attribute.String("cluster", "my-cluster"),
+		attribute.String("hyperfleet.cluster", "spoke-1"),
+		attribute.String("hyperfleet.request_id", "req-42"),
+	}, spans[0].Attributes)
+
+	log := &fieldLogger{Logger: logger.Nop()}
+	withBaggage := LoggerWithBaggage(ctx, tp, log).(*fieldLogger)
+	assert.Equal(t, []logger.Field{
+		logger.String("hyperfleet.cluster", "spoke-1"),
+		logger.String("hyperfleet.request_id", "req-42"),
+	}, withBaggage.fields)
+
+	// Without tracing the baggage is left out
+	assert.Same(t, log, LoggerWithBaggage(ctx, nil, log))
+}
@@ -0,0 +1,27 @@
+package provider
+
+import (
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+)
+
+// OptionsValidator checks token options against the naming rules of a provider.
+// It must not call any cloud API or load credentials.
+type OptionsValidator func(opts GetTokenOptions) error
+
+// Validate checks the options against the rules of the named provider, so typos in
+// cluster names and other identifiers are caught before any API call. Providers that
+// registered no validator only require a cluster name.
+func (o GetTokenOptions) Validate(providerName string) error {
+	if o.ClusterName == "" {
+		return errors.New(
+			errors.ErrInvalidArgument,
+			"cluster name is required",
+		).WithField("provider", providerName)
+	}
+
+	registration, _ := registrationOf(providerName)
+	if registration.ValidateOptions == nil {
+		return nil
+	}
+	return registration.ValidateOptions(o)
+}
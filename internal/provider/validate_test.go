@@ -0,0 +1,68 @@
+package provider_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+	_ "github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider/aws"
+	_ "github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider/azure"
+	_ "github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider/gcp"
+	_ "github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider/oci"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+)
+
+func TestGetTokenOptions_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider string
+		opts     provider.GetTokenOptions
+		wantErr  string
+	}{
+		// GKE: lowercase letters, digits and hyphens, up to 40 characters
+		{name: "gke name", provider: "gcp", opts: provider.GetTokenOptions{ClusterName: "prod-cluster-1", ProjectID: "my-project", Region: "us-central1-a"}},
+		{name: "gke maximum length", provider: "gcp", opts: provider.GetTokenOptions{ClusterName: "c" + strings.Repeat("a", 39)}},
+		{name: "gke too long", provider: "gcp", opts: provider.GetTokenOptions{ClusterName: "c" + strings.Repeat("a", 40)}, wantErr: "invalid GKE cluster name"},
+		{name: "gke uppercase", provider: "gcp", opts: provider.GetTokenOptions{ClusterName: "Prod"}, wantErr: "invalid GKE cluster name"},
+		{name: "gke trailing hyphen", provider: "gcp", opts: provider.GetTokenOptions{ClusterName: "prod-"}, wantErr: "invalid GKE cluster name"},
+		{name: "gke project", provider: "gcp", opts: provider.GetTokenOptions{ClusterName: "prod", ProjectID: "My_Project"}, wantErr: "invalid GCP project ID"},
+		{name: "gke location", provider: "gcp", opts: provider.GetTokenOptions{ClusterName: "prod", Region: "uscentral"}, wantErr: "invalid GKE location"},
+
+		// EKS: letters, digits, hyphens and underscores, up to 100 characters
+		{name: "eks name", provider: "aws", opts: provider.GetTokenOptions{ClusterName: "Prod_Cluster-1", Region: "us-east-1"}},
+		{name: "eks maximum length", provider: "aws", opts: provider.GetTokenOptions{ClusterName: strings.Repeat("a", 100)}},
+		{name: "eks ARN", provider: "aws", opts: provider.GetTokenOptions{ClusterName: "arn:aws:eks:us-east-1:123456789012:cluster/prod"}},
+		{name: "eks too long", provider: "aws", opts: provider.GetTokenOptions{ClusterName: strings.Repeat("a", 101)}, wantErr: "invalid EKS cluster name"},
+		{name: "eks dot", provider: "aws", opts: provider.GetTokenOptions{ClusterName: "prod.cluster"}, wantErr: "invalid EKS cluster name"},
+		{name: "eks leading hyphen", provider: "aws", opts: provider.GetTokenOptions{ClusterName: "-prod"}, wantErr: "invalid EKS cluster name"},
+		{name: "eks region", provider: "aws", opts: provider.GetTokenOptions{ClusterName: "prod", Region: "us-east"}, wantErr: "invalid AWS region"},
+
+		// AKS: letters, digits, underscores and hyphens, up to 63 characters
+		{name: "aks name", provider: "azure", opts: provider.GetTokenOptions{ClusterName: "Prod_Cluster-1", ResourceGroup: "rg.prod"}},
+		{name: "aks maximum length", provider: "azure", opts: provider.GetTokenOptions{ClusterName: strings.Repeat("a", 63)}},
+		{name: "aks too long", provider: "azure", opts: provider.GetTokenOptions{ClusterName: strings.Repeat("a", 64)}, wantErr: "invalid AKS cluster name"},
+		{name: "aks trailing underscore", provider: "azure", opts: provider.GetTokenOptions{ClusterName: "prod_"}, wantErr: "invalid AKS cluster name"},
+		{name: "aks resource group", provider: "azure", opts: provider.GetTokenOptions{ClusterName: "prod", ResourceGroup: "rg."}, wantErr: "invalid Azure resource group"},
+
+		// Providers without a validator only require a name
+		{name: "oci cluster ID", provider: "oci", opts: provider.GetTokenOptions{ClusterName: "ocid1.cluster.oc1.iad.aaaa"}},
+		{name: "missing name", provider: "oci", wantErr: "cluster name is required"},
+		{name: "missing name with validator", provider: "aws", wantErr: "cluster name is required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.opts.Validate(tt.provider)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, errors.ErrInvalidArgument))
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
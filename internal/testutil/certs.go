@@ -0,0 +1,54 @@
+package testutil
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/credentials"
+)
+
+// SelfSignedCertificatePEM returns a self-signed RSA certificate that expires at notAfter,
+// followed by its PKCS#8 private key, in PEM
+func SelfSignedCertificatePEM(t *testing.T, notAfter time.Time) []byte {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "hyperfleet-test-sp"},
+		NotBefore:    notAfter.Add(-365 * 24 * time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	return append(data, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})...)
+}
+
+// CreateCertificateAzureCredentials creates Azure credentials of a certificate-based
+// service principal, with a certificate valid for a year and no client secret
+func CreateCertificateAzureCredentials(t *testing.T) *credentials.AzureCredentials {
+	t.Helper()
+
+	creds := CreateValidAzureCredentials()
+	creds.ClientSecret = ""
+	creds.ClientCertificatePath = "/etc/azure/sp.pem"
+	creds.ClientCertificate = string(SelfSignedCertificatePEM(t, time.Now().Add(365*24*time.Hour)))
+	return creds
+}
@@ -0,0 +1,39 @@
+package testutil
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+)
+
+// NewDebugLogger creates a JSON logger at debug level that writes to the returned buffer
+func NewDebugLogger(t *testing.T) (logger.Logger, *bytes.Buffer) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	log, err := logger.New(logger.Config{
+		Level:  logger.DebugLevel,
+		Format: logger.JSONFormat,
+		Output: &buf,
+	})
+	if err != nil {
+		t.Fatalf("failed to create debug logger: %v", err)
+	}
+	return log, &buf
+}
+
+// AssertNotLogged fails the test when any of the sensitive values appears in output
+func AssertNotLogged(t *testing.T, output string, values ...string) {
+	t.Helper()
+
+	if output == "" {
+		t.Errorf("no log output was captured")
+	}
+	for _, value := range values {
+		if value != "" && strings.Contains(output, value) {
+			t.Errorf("log output contains sensitive value %q:\n%s", value, output)
+		}
+	}
+}
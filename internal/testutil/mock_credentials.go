@@ -17,6 +17,12 @@ type MockCredLoader struct {
 	AWSErr     error
 	AzureCreds *credentials.AzureCredentials
 	AzureErr   error
+	OCICreds   *credentials.OCICredentials
+	OCIErr     error
+	DOCreds    *credentials.DigitalOceanCredentials
+	DOErr      error
+	OIDCCreds  *credentials.OIDCCredentials
+	OIDCErr    error
 }
 
 // NewMockCredLoader creates a new mock credential loader
@@ -60,6 +66,42 @@ func (m *MockCredLoader) WithAzureError(err error) *MockCredLoader {
 	return m
 }
 
+// WithOCICreds configures the mock to return specific OCI credentials
+func (m *MockCredLoader) WithOCICreds(creds *credentials.OCICredentials) *MockCredLoader {
+	m.OCICreds = creds
+	return m
+}
+
+// WithOCIError configures the mock to return an error for OCI credentials
+func (m *MockCredLoader) WithOCIError(err error) *MockCredLoader {
+	m.OCIErr = err
+	return m
+}
+
+// WithDigitalOceanCreds configures the mock to return specific DigitalOcean credentials
+func (m *MockCredLoader) WithDigitalOceanCreds(creds *credentials.DigitalOceanCredentials) *MockCredLoader {
+	m.DOCreds = creds
+	return m
+}
+
+// WithDigitalOceanError configures the mock to return an error for DigitalOcean credentials
+func (m *MockCredLoader) WithDigitalOceanError(err error) *MockCredLoader {
+	m.DOErr = err
+	return m
+}
+
+// WithOIDCCreds configures the mock to return specific OIDC client credentials
+func (m *MockCredLoader) WithOIDCCreds(creds *credentials.OIDCCredentials) *MockCredLoader {
+	m.OIDCCreds = creds
+	return m
+}
+
+// WithOIDCError configures the mock to return an error for OIDC client credentials
+func (m *MockCredLoader) WithOIDCError(err error) *MockCredLoader {
+	m.OIDCErr = err
+	return m
+}
+
 // LoadGCP implements credentials.Loader interface
 func (m *MockCredLoader) LoadGCP(ctx context.Context, path string) (*credentials.GCPCredentials, error) {
 	if m.GCPErr != nil {
@@ -84,6 +126,30 @@ func (m *MockCredLoader) LoadAzure(ctx context.Context, opts credentials.AzureCr
 	return m.AzureCreds, nil
 }
 
+// LoadOCI implements credentials.Loader interface
+func (m *MockCredLoader) LoadOCI(ctx context.Context, opts credentials.OCICredentialOptions) (*credentials.OCICredentials, error) {
+	if m.OCIErr != nil {
+		return nil, m.OCIErr
+	}
+	return m.OCICreds, nil
+}
+
+// LoadDigitalOcean implements credentials.Loader interface
+func (m *MockCredLoader) LoadDigitalOcean(ctx context.Context, opts credentials.DigitalOceanCredentialOptions) (*credentials.DigitalOceanCredentials, error) {
+	if m.DOErr != nil {
+		return nil, m.DOErr
+	}
+	return m.DOCreds, nil
+}
+
+// LoadOIDC implements credentials.Loader interface
+func (m *MockCredLoader) LoadOIDC(ctx context.Context, opts credentials.OIDCCredentialOptions) (*credentials.OIDCCredentials, error) {
+	if m.OIDCErr != nil {
+		return nil, m.OIDCErr
+	}
+	return m.OIDCCreds, nil
+}
+
 // --- GCP Credential Helpers ---
 
 // CreateValidGCPCredentials creates valid-looking (but fake) GCP credentials for testing
@@ -128,6 +194,8 @@ func CreateValidAWSCredentials() *credentials.AWSCredentials {
 func CreateValidAWSCredentialsWithSessionToken() *credentials.AWSCredentials {
 	creds := CreateValidAWSCredentials()
 	creds.SessionToken = "FwoGZXIvYXdzEBYaDH...TestSessionToken"
+	// A known expiration keeps the AWS session credential check offline
+	creds.Expiration = time.Now().Add(time.Hour)
 	return creds
 }
 
@@ -176,7 +244,8 @@ func CreateInvalidAzureCredentials() *credentials.AzureCredentials {
 
 // --- Time Mock ---
 
-// MockTime is a mock time provider for testing time-dependent logic
+// MockTime is a mock time provider for testing time-dependent logic.
+// It implements provider.Clock.
 type MockTime struct {
 	CurrentTime time.Time
 }
@@ -190,3 +259,8 @@ func NewMockTime(now time.Time) *MockTime {
 func (m *MockTime) Now() time.Time {
 	return m.CurrentTime
 }
+
+// Advance moves the mocked current time forward by d
+func (m *MockTime) Advance(d time.Duration) {
+	m.CurrentTime = m.CurrentTime.Add(d)
+}
@@ -0,0 +1,21 @@
+package testutil
+
+import (
+	"path/filepath"
+	"runtime"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/credentials"
+)
+
+// FixtureDir returns the directory of the shared credential fixtures in testdata/credentials
+func FixtureDir() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Join(filepath.Dir(file), "testdata", "credentials")
+}
+
+// NewReplayLoader creates a loader that serves the shared credential fixtures.
+// The fixtures are scrubbed recordings of the Create*Credentials helpers, so the
+// values differ from those helpers but have the same shape.
+func NewReplayLoader() *credentials.ReplayLoader {
+	return credentials.NewReplayLoader(FixtureDir())
+}
@@ -0,0 +1,51 @@
+package testutil
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+)
+
+// RecordingTransport is an http.RoundTripper that answers every request with Handler
+// and records the hosts it was sent to. Tests install it in an http.Client to check
+// that a cloud SDK sends its requests through the configured client, without network access.
+type RecordingTransport struct {
+	// Handler answers the requests; nil answers 404 Not Found
+	Handler http.Handler
+
+	mu    sync.Mutex
+	hosts []string
+}
+
+// NewRecordingClient returns an HTTP client backed by a RecordingTransport that
+// answers with handler
+func NewRecordingClient(handler http.Handler) (*http.Client, *RecordingTransport) {
+	transport := &RecordingTransport{Handler: handler}
+	return &http.Client{Transport: transport}, transport
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *RecordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	t.hosts = append(t.hosts, req.URL.Host)
+	t.mu.Unlock()
+
+	handler := t.Handler
+	if handler == nil {
+		handler = http.NotFoundHandler()
+	}
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	resp := recorder.Result()
+	resp.Request = req
+	return resp, nil
+}
+
+// Hosts returns the hosts of the requests sent so far
+func (t *RecordingTransport) Hosts() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return append([]string(nil), t.hosts...)
+}
@@ -0,0 +1,94 @@
+package throttle
+
+import (
+	"time"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+)
+
+// State is the state of a circuit breaker. The values are the ones exported by the
+// breaker_state gauge.
+type State int
+
+const (
+	// StateClosed lets every request through
+	StateClosed State = 0
+
+	// StateOpen rejects every request until the open timeout has passed
+	StateOpen State = 1
+
+	// StateHalfOpen lets one probe request through; its result closes or reopens the breaker
+	StateHalfOpen State = 2
+)
+
+// String returns the state name
+func (s State) String() string {
+	switch s {
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// breaker is a consecutive failure circuit breaker. It is not safe for concurrent
+// use; Provider serializes access.
+type breaker struct {
+	state    State
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// allow reports whether a request may be made at now, moving an open breaker to
+// half-open once timeout has passed. A rejected request may retry after the returned wait.
+func (b *breaker) allow(now time.Time, timeout time.Duration) (bool, time.Duration) {
+	if b.state == StateOpen {
+		if wait := b.openedAt.Add(timeout).Sub(now); wait > 0 {
+			return false, wait
+		}
+		b.state = StateHalfOpen
+	}
+
+	if b.state == StateHalfOpen {
+		if b.probing {
+			// The probe's result is expected within a request timeout; retrying sooner
+			// than the open timeout is pointless if it fails
+			return false, timeout
+		}
+		b.probing = true
+	}
+	return true, 0
+}
+
+// release gives up a probe admitted by allow that was never made
+func (b *breaker) release() {
+	b.probing = false
+}
+
+// record feeds the result of a request made at now to the breaker. Only errors that
+// suggest the cloud endpoint is failing count; others, such as invalid arguments,
+// neither count nor reset the failure run.
+func (b *breaker) record(err error, now time.Time, threshold int) {
+	probe := b.state == StateHalfOpen && b.probing
+	b.probing = false
+
+	switch {
+	case err == nil:
+		b.state = StateClosed
+		b.failures = 0
+	case countsAsFailure(err):
+		b.failures++
+		if probe || b.failures >= threshold {
+			b.state = StateOpen
+			b.openedAt = now
+		}
+	}
+}
+
+// countsAsFailure reports whether err counts towards opening the breaker
+func countsAsFailure(err error) bool {
+	return errors.Is(err, errors.ErrTokenGenerationFailed) || errors.Is(err, errors.ErrNetworkTimeout)
+}
@@ -0,0 +1,31 @@
+package throttle
+
+import (
+	"math"
+	"time"
+)
+
+// bucket is a token bucket holding up to burst tokens and refilled at rate tokens
+// per second. It is not safe for concurrent use; Provider serializes access.
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// take removes a token at now. When the bucket is empty it returns false and how
+// long until a token is available.
+func (b *bucket) take(now time.Time, rate float64, burst int) (bool, time.Duration) {
+	if b.last.IsZero() {
+		b.tokens = float64(burst)
+	} else if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = math.Min(float64(burst), b.tokens+elapsed.Seconds()*rate)
+	}
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	wait := time.Duration((1 - b.tokens) / rate * float64(time.Second))
+	return false, wait
+}
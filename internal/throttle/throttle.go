@@ -0,0 +1,229 @@
+// Package throttle protects cloud token endpoints from clients that request tokens in
+// a loop. It wraps a provider.Provider with a token bucket rate limiter and a circuit
+// breaker per cluster, so one misbehaving caller is throttled before STS or Entra ID
+// throttle every caller sharing the credentials.
+package throttle
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+)
+
+const (
+	// DefaultBurst is how many requests may be made at once when Config.Burst is unset
+	DefaultBurst = 1
+
+	// DefaultOpenTimeout is how long an open breaker rejects requests before it lets a
+	// probe through
+	DefaultOpenTimeout = 30 * time.Second
+
+	// retryAfterField is the error field holding the Retry-After hint in seconds
+	retryAfterField = provider.RetryAfterSecondsField
+)
+
+// Reasons a request is throttled, as recorded by Observer.RecordThrottledRequest
+const (
+	ReasonRateLimit   = "rate_limit"
+	ReasonCircuitOpen = "circuit_open"
+)
+
+// Observer records breaker state changes and throttled requests. *metrics.Metrics
+// implements it.
+type Observer interface {
+	SetBreakerState(provider, cluster string, state int)
+	RecordThrottledRequest(provider, reason string)
+}
+
+// Config configures the rate limiter and circuit breaker of a Provider
+type Config struct {
+	// RequestsPerSecond is the sustained token request rate allowed per cluster
+	// (0: no rate limit)
+	RequestsPerSecond float64
+
+	// Burst is how many requests may be made at once above that rate (default: DefaultBurst)
+	Burst int
+
+	// FailureThreshold is how many token requests in a row must fail with
+	// ERR_TOKEN_GENERATION_FAILED or ERR_NETWORK_TIMEOUT to open the breaker
+	// (0: no circuit breaker)
+	FailureThreshold int
+
+	// OpenTimeout is how long the breaker stays open before a probe request is let
+	// through (default: DefaultOpenTimeout)
+	OpenTimeout time.Duration
+
+	// Clock tells the time buckets refill and breakers reopen by (default: provider.SystemClock)
+	Clock provider.Clock
+
+	// Observer records breaker states and throttled requests (default: none)
+	Observer Observer
+}
+
+// Enabled reports whether config limits anything
+func (c Config) Enabled() bool {
+	return c.RequestsPerSecond > 0 || c.FailureThreshold > 0
+}
+
+// Provider is a provider.Provider whose token requests are rate limited and circuit
+// broken per cluster. Requests it rejects fail with ERR_RATE_LIMIT_EXCEEDED, which
+// maps to HTTP 429, and carry a RetryAfter hint.
+type Provider struct {
+	provider.Provider
+	config Config
+
+	mu     sync.Mutex
+	guards map[string]*guard
+}
+
+// guard is the rate limiter and circuit breaker of one cluster
+type guard struct {
+	bucket  bucket
+	breaker breaker
+}
+
+// Wrap returns p with the rate limit and circuit breaker of config
+func Wrap(p provider.Provider, config Config) *Provider {
+	if config.Burst <= 0 {
+		config.Burst = DefaultBurst
+	}
+	if config.OpenTimeout <= 0 {
+		config.OpenTimeout = DefaultOpenTimeout
+	}
+	if config.Clock == nil {
+		config.Clock = provider.SystemClock
+	}
+	return &Provider{
+		Provider: p,
+		config:   config,
+		guards:   make(map[string]*guard),
+	}
+}
+
+// GetToken generates a token unless the cluster's rate limit is exhausted or its
+// breaker is open
+func (p *Provider) GetToken(ctx context.Context, opts provider.GetTokenOptions) (*provider.Token, error) {
+	if err := p.admit(opts.ClusterName); err != nil {
+		return nil, err
+	}
+	token, err := p.Provider.GetToken(ctx, opts)
+	p.record(opts.ClusterName, err)
+	return token, err
+}
+
+// RefreshToken returns currentToken while it is valid without using the rate limit,
+// and otherwise generates a new token through GetToken
+func (p *Provider) RefreshToken(ctx context.Context, opts provider.GetTokenOptions, currentToken *provider.Token) (*provider.Token, error) {
+	return provider.RefreshIfNeeded(ctx, p, opts, currentToken, p.config.Clock, provider.RefreshThresholdOf(p.Name()))
+}
+
+// BreakerState returns the state of the breaker of cluster
+func (p *Provider) BreakerState(cluster string) State {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	g, ok := p.guards[cluster]
+	if !ok {
+		return StateClosed
+	}
+	return g.breaker.state
+}
+
+// admit reserves a request for cluster, or returns the error rejecting it
+func (p *Provider) admit(cluster string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.config.Clock.Now()
+	g := p.guard(cluster)
+
+	if p.config.FailureThreshold > 0 {
+		before := g.breaker.state
+		allowed, wait := g.breaker.allow(now, p.config.OpenTimeout)
+		p.observeState(cluster, before, g.breaker.state)
+		if !allowed {
+			return p.reject(cluster, ReasonCircuitOpen, wait)
+		}
+	}
+
+	if p.config.RequestsPerSecond > 0 {
+		if allowed, wait := g.bucket.take(now, p.config.RequestsPerSecond, p.config.Burst); !allowed {
+			// A probe that never ran must not keep the half-open breaker waiting for it
+			g.breaker.release()
+			return p.reject(cluster, ReasonRateLimit, wait)
+		}
+	}
+	return nil
+}
+
+// record feeds the result of a token request for cluster to its breaker
+func (p *Provider) record(cluster string, err error) {
+	if p.config.FailureThreshold <= 0 {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	g := p.guard(cluster)
+	before := g.breaker.state
+	g.breaker.record(err, p.config.Clock.Now(), p.config.FailureThreshold)
+	p.observeState(cluster, before, g.breaker.state)
+}
+
+// guard returns the guard of cluster, creating it on first use. p.mu must be held.
+func (p *Provider) guard(cluster string) *guard {
+	g, ok := p.guards[cluster]
+	if !ok {
+		g = &guard{}
+		p.guards[cluster] = g
+		if p.config.Observer != nil && p.config.FailureThreshold > 0 {
+			p.config.Observer.SetBreakerState(p.Name(), cluster, int(StateClosed))
+		}
+	}
+	return g
+}
+
+// observeState reports a breaker state change of cluster to the observer
+func (p *Provider) observeState(cluster string, before, after State) {
+	if p.config.Observer != nil && before != after {
+		p.config.Observer.SetBreakerState(p.Name(), cluster, int(after))
+	}
+}
+
+// reject returns the error of a throttled request, retryable after wait
+func (p *Provider) reject(cluster, reason string, wait time.Duration) error {
+	if p.config.Observer != nil {
+		p.config.Observer.RecordThrottledRequest(p.Name(), reason)
+	}
+
+	title := "token request rate limit exceeded"
+	if reason == ReasonCircuitOpen {
+		title = "token requests suspended after repeated failures"
+	}
+	return errors.New(errors.ErrRateLimitExceeded, title).WithFields(map[string]interface{}{
+		"provider":      p.Name(),
+		"cluster":       cluster,
+		"reason":        reason,
+		retryAfterField: int(math.Ceil(wait.Seconds())),
+	})
+}
+
+// RetryAfter returns how long the caller should wait before retrying a request
+// rejected by a Provider, for use as an HTTP Retry-After header. The bool is false
+// for other errors.
+func RetryAfter(err error) (time.Duration, bool) {
+	var e *errors.Error
+	if !errors.As(err, &e) || e.Code != errors.ErrRateLimitExceeded {
+		return 0, false
+	}
+	seconds, ok := e.Fields[retryAfterField].(int)
+	if !ok {
+		return 0, false
+	}
+	return time.Duration(seconds) * time.Second, true
+}
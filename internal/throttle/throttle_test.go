@@ -0,0 +1,272 @@
+package throttle
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+)
+
+// fakeClock is a clock that only moves when advanced
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+// fakeObserver records breaker states and throttled requests
+type fakeObserver struct {
+	states    map[string]State
+	throttled map[string]int
+}
+
+func newFakeObserver() *fakeObserver {
+	return &fakeObserver{states: make(map[string]State), throttled: make(map[string]int)}
+}
+
+func (o *fakeObserver) SetBreakerState(provider, cluster string, state int) {
+	o.states[provider+"/"+cluster] = State(state)
+}
+
+func (o *fakeObserver) RecordThrottledRequest(provider, reason string) {
+	o.throttled[provider+"/"+reason]++
+}
+
+// scriptedProvider returns the errors of results in turn, then tokens
+type scriptedProvider struct {
+	provider.MockProvider
+	results []error
+	calls   int
+}
+
+func newScriptedProvider(clock provider.Clock, results ...error) *scriptedProvider {
+	p := &scriptedProvider{results: results}
+	p.NameValue = "aws"
+	p.GetTokenFunc = func(ctx context.Context, opts provider.GetTokenOptions) (*provider.Token, error) {
+		p.calls++
+		if len(p.results) > 0 {
+			err := p.results[0]
+			p.results = p.results[1:]
+			if err != nil {
+				return nil, err
+			}
+		}
+		return &provider.Token{AccessToken: "token", ExpiresAt: clock.Now().Add(15 * time.Minute), TokenType: "Bearer"}, nil
+	}
+	return p
+}
+
+func generationFailed() error {
+	return errors.New(errors.ErrTokenGenerationFailed, "failed to presign GetCallerIdentity request")
+}
+
+func getToken(p *Provider, cluster string) error {
+	_, err := p.GetToken(context.Background(), provider.GetTokenOptions{ClusterName: cluster})
+	return err
+}
+
+func TestProvider_RateLimit(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)}
+	observer := newFakeObserver()
+	inner := newScriptedProvider(clock)
+	p := Wrap(inner, Config{RequestsPerSecond: 2, Burst: 3, Clock: clock, Observer: observer})
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, getToken(p, "eks-prod"), "request %d is within the burst", i)
+	}
+
+	err := getToken(p, "eks-prod")
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, errors.ErrRateLimitExceeded))
+	assert.Equal(t, 429, errors.GetStatus(err))
+	retryAfter, ok := RetryAfter(err)
+	require.True(t, ok)
+	assert.Equal(t, time.Second, retryAfter, "half a second rounds up to one")
+	assert.Equal(t, 1, observer.throttled["aws/"+ReasonRateLimit])
+
+	// Other clusters have buckets of their own
+	require.NoError(t, getToken(p, "eks-dev"))
+
+	clock.advance(500 * time.Millisecond)
+	require.NoError(t, getToken(p, "eks-prod"), "one token refilled")
+	require.Error(t, getToken(p, "eks-prod"))
+
+	clock.advance(10 * time.Second)
+	for i := 0; i < 3; i++ {
+		require.NoError(t, getToken(p, "eks-prod"), "the bucket refills up to the burst only")
+	}
+	require.Error(t, getToken(p, "eks-prod"))
+
+	assert.Equal(t, 8, inner.calls, "rejected requests never reach the provider")
+}
+
+func TestProvider_CircuitBreaker(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)}
+	observer := newFakeObserver()
+	timeout := errors.New(errors.ErrNetworkTimeout, "get token timed out")
+	inner := newScriptedProvider(clock, generationFailed(), timeout, generationFailed(), nil, generationFailed())
+	p := Wrap(inner, Config{FailureThreshold: 3, OpenTimeout: time.Minute, Clock: clock, Observer: observer})
+
+	for i := 0; i < 3; i++ {
+		require.Error(t, getToken(p, "eks-prod"))
+	}
+	assert.Equal(t, StateOpen, p.BreakerState("eks-prod"))
+	assert.Equal(t, StateOpen, observer.states["aws/eks-prod"])
+
+	err := getToken(p, "eks-prod")
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, errors.ErrRateLimitExceeded))
+	retryAfter, ok := RetryAfter(err)
+	require.True(t, ok)
+	assert.Equal(t, time.Minute, retryAfter)
+	assert.Equal(t, 1, observer.throttled["aws/"+ReasonCircuitOpen])
+	assert.Equal(t, 3, inner.calls)
+
+	// Other clusters are unaffected
+	require.NoError(t, getToken(p, "eks-dev"))
+	assert.Equal(t, StateClosed, p.BreakerState("eks-dev"))
+
+	// A failed probe reopens the breaker for another timeout
+	clock.advance(time.Minute)
+	require.Error(t, getToken(p, "eks-prod"))
+	assert.Equal(t, 5, inner.calls)
+	assert.Equal(t, StateOpen, p.BreakerState("eks-prod"))
+	clock.advance(30 * time.Second)
+	err = getToken(p, "eks-prod")
+	retryAfter, _ = RetryAfter(err)
+	assert.Equal(t, 30*time.Second, retryAfter)
+
+	// A successful probe closes it
+	clock.advance(30 * time.Second)
+	require.NoError(t, getToken(p, "eks-prod"))
+	assert.Equal(t, StateClosed, p.BreakerState("eks-prod"))
+	assert.Equal(t, StateClosed, observer.states["aws/eks-prod"])
+	require.NoError(t, getToken(p, "eks-prod"))
+}
+
+func TestProvider_CircuitBreakerIgnoresOtherErrors(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)}
+	invalid := errors.New(errors.ErrInvalidArgument, "cluster name is required")
+	inner := newScriptedProvider(clock, generationFailed(), generationFailed(), invalid, invalid, generationFailed())
+	p := Wrap(inner, Config{FailureThreshold: 3, Clock: clock})
+
+	for i := 0; i < 4; i++ {
+		require.Error(t, getToken(p, "eks-prod"))
+		assert.Equal(t, StateClosed, p.BreakerState("eks-prod"), "request %d", i)
+	}
+	require.Error(t, getToken(p, "eks-prod"))
+	assert.Equal(t, StateOpen, p.BreakerState("eks-prod"), "invalid arguments do not reset the failure run")
+}
+
+func TestProvider_SuccessResetsFailures(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)}
+	inner := newScriptedProvider(clock, generationFailed(), generationFailed(), nil, generationFailed(), generationFailed())
+	p := Wrap(inner, Config{FailureThreshold: 3, Clock: clock})
+
+	for i := 0; i < 5; i++ {
+		_ = getToken(p, "eks-prod")
+	}
+	assert.Equal(t, StateClosed, p.BreakerState("eks-prod"))
+}
+
+func TestProvider_HalfOpenAllowsOneProbe(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)}
+	p := Wrap(newScriptedProvider(clock), Config{FailureThreshold: 1, OpenTimeout: time.Minute, Clock: clock})
+
+	p.record("eks-prod", generationFailed())
+	clock.advance(time.Minute)
+
+	require.NoError(t, p.admit("eks-prod"), "the first request is the probe")
+	assert.Equal(t, StateHalfOpen, p.BreakerState("eks-prod"))
+	err := p.admit("eks-prod")
+	require.Error(t, err, "concurrent requests wait for the probe")
+	assert.True(t, errors.Is(err, errors.ErrRateLimitExceeded))
+
+	// A probe that ends without a verdict frees the slot
+	p.record("eks-prod", errors.New(errors.ErrInvalidArgument, "cluster name is required"))
+	require.NoError(t, p.admit("eks-prod"))
+}
+
+func TestProvider_RateLimitedProbeIsReleased(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)}
+	inner := newScriptedProvider(clock, generationFailed())
+	p := Wrap(inner, Config{RequestsPerSecond: 1, FailureThreshold: 1, OpenTimeout: 100 * time.Millisecond, Clock: clock})
+
+	require.Error(t, getToken(p, "eks-prod"))
+	assert.Equal(t, StateOpen, p.BreakerState("eks-prod"))
+
+	// The breaker admits a probe before the bucket has refilled
+	clock.advance(100 * time.Millisecond)
+	err := getToken(p, "eks-prod")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "rate limit exceeded")
+	assert.Equal(t, StateHalfOpen, p.BreakerState("eks-prod"))
+
+	clock.advance(time.Second)
+	require.NoError(t, getToken(p, "eks-prod"), "the unused probe slot was released")
+	assert.Equal(t, StateClosed, p.BreakerState("eks-prod"))
+	assert.Equal(t, 2, inner.calls)
+}
+
+func TestProvider_RefreshTokenReusesValidTokens(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)}
+	inner := newScriptedProvider(clock)
+	inner.ValidateTokenFunc = func(token *provider.Token) error { return nil }
+	p := Wrap(inner, Config{RequestsPerSecond: 1, Clock: clock})
+	opts := provider.GetTokenOptions{ClusterName: "eks-prod"}
+
+	token, err := p.RefreshToken(context.Background(), opts, nil)
+	require.NoError(t, err)
+
+	for i := 0; i < 5; i++ {
+		reused, err := p.RefreshToken(context.Background(), opts, token)
+		require.NoError(t, err, "valid tokens do not use the rate limit")
+		assert.Same(t, token, reused)
+	}
+	assert.Equal(t, 1, inner.calls)
+
+	clock.advance(14 * time.Minute)
+	_, err = p.RefreshToken(context.Background(), opts, token)
+	require.NoError(t, err, "the bucket refilled")
+	assert.Equal(t, 2, inner.calls)
+}
+
+func TestProvider_PassesThrough(t *testing.T) {
+	inner := newScriptedProvider(provider.SystemClock)
+	validated := false
+	inner.ValidateCredentialsFunc = func(ctx context.Context) error {
+		validated = true
+		return nil
+	}
+	p := Wrap(inner, Config{})
+
+	assert.Equal(t, "aws", p.Name())
+	require.NoError(t, p.ValidateCredentials(context.Background()))
+	assert.True(t, validated)
+	for i := 0; i < 10; i++ {
+		require.NoError(t, getToken(p, "eks-prod"), "an empty config limits nothing")
+	}
+	assert.False(t, Config{}.Enabled())
+	assert.True(t, Config{FailureThreshold: 1}.Enabled())
+}
+
+func TestRetryAfter(t *testing.T) {
+	_, ok := RetryAfter(generationFailed())
+	assert.False(t, ok)
+	_, ok = RetryAfter(fmt.Errorf("plain"))
+	assert.False(t, ok)
+	_, ok = RetryAfter(errors.New(errors.ErrRateLimitExceeded, "OIDC token request rejected: slow_down"))
+	assert.False(t, ok, "429s from the cloud carry no hint")
+}
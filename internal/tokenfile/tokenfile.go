@@ -0,0 +1,259 @@
+// Package tokenfile keeps a bearer token file fresh for workloads that can only read
+// a token from disk, like a projected service account token volume.
+package tokenfile
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/filelock"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+)
+
+const (
+	// DefaultMaxFailures is how many refreshes in a row may fail before Run gives up
+	DefaultMaxFailures = 5
+
+	// DefaultRetryInterval is the wait before retrying a failed refresh
+	DefaultRetryInterval = 10 * time.Second
+
+	// maxRetryInterval bounds the backoff between refreshes failing in a row
+	maxRetryInterval = 2 * time.Minute
+
+	// maxWait bounds each sleep so that a wall clock change is noticed within it
+	maxWait = 30 * time.Second
+
+	// fileMode keeps the token readable by its owner only
+	fileMode os.FileMode = 0o600
+)
+
+// Generator returns a new token
+type Generator func(ctx context.Context) (*provider.Token, error)
+
+// Config configures a Refresher
+type Config struct {
+	// Path is the token file. Its directory must exist.
+	Path string
+
+	// Interval is the time between refreshes. Zero refreshes only when the token is
+	// within Threshold of expiry.
+	Interval time.Duration
+
+	// Threshold is the remaining lifetime at which a token is refreshed, usually
+	// provider.RefreshThresholdOf the provider; provider.ClockSkew is added to it
+	Threshold time.Duration
+
+	// MaxFailures is how many refreshes in a row may fail before Run returns an error
+	// (default: DefaultMaxFailures)
+	MaxFailures int
+
+	// RetryInterval is the wait before retrying a failed refresh, doubled for each
+	// further failure in a row (default: DefaultRetryInterval)
+	RetryInterval time.Duration
+
+	// Clock tells the time token expiry is compared with (default: provider.SystemClock)
+	Clock provider.Clock
+
+	// Logger reports refreshes and failures (default: no logging)
+	Logger logger.Logger
+}
+
+// Refresher writes a token to a file and rewrites it before it expires
+type Refresher struct {
+	config   Config
+	generate Generator
+
+	// after and jitter are replaced in tests
+	after  func(time.Duration) <-chan time.Time
+	jitter func(max time.Duration) time.Duration
+
+	mu      sync.Mutex
+	token   *provider.Token
+	lastErr error
+}
+
+// New creates a Refresher that writes tokens from generate to config.Path
+func New(config Config, generate Generator) *Refresher {
+	if config.MaxFailures <= 0 {
+		config.MaxFailures = DefaultMaxFailures
+	}
+	if config.RetryInterval <= 0 {
+		config.RetryInterval = DefaultRetryInterval
+	}
+	if config.Clock == nil {
+		config.Clock = provider.SystemClock
+	}
+	if config.Logger == nil {
+		config.Logger = logger.Nop()
+	}
+
+	return &Refresher{
+		config:   config,
+		generate: generate,
+		after:    time.After,
+		jitter:   randomJitter,
+	}
+}
+
+// Run refreshes the token file until ctx is done, which returns nil, or until
+// MaxFailures refreshes in a row fail, which returns the last error.
+//
+// The next refresh is due when the wall clock reaches its scheduled time or when the
+// scheduled wait has been slept, whichever comes first. A clock stepped forward
+// therefore refreshes early, and a clock stepped back cannot delay a refresh.
+func (r *Refresher) Run(ctx context.Context) error {
+	var (
+		next     time.Time
+		budget   time.Duration
+		failures int
+	)
+
+	for {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		now := r.config.Clock.Now()
+		if !now.Before(next) || budget <= 0 {
+			token, err := r.refresh(ctx)
+			switch {
+			case err != nil && ctx.Err() != nil:
+				return nil
+			case err != nil:
+				failures++
+				r.config.Logger.Warn("Failed to refresh token file",
+					logger.String("file", r.config.Path),
+					logger.Int("consecutive_failures", failures),
+					logger.Error(err),
+				)
+				if failures >= r.config.MaxFailures {
+					return errors.Wrap(
+						errors.ErrTokenGenerationFailed,
+						err,
+						fmt.Sprintf("token refresh failed %d times in a row", failures),
+					).WithField("file", r.config.Path)
+				}
+				next = now.Add(r.retryDelay(now, failures, err))
+			default:
+				failures = 0
+				next = r.nextRefresh(now, token)
+				r.config.Logger.Info("Token file refreshed", append([]logger.Field{
+					logger.String("file", r.config.Path),
+					logger.String("expires_at", token.ExpiresAt.Format(time.RFC3339)),
+					logger.String("next_refresh", next.Format(time.RFC3339)),
+				}, provider.TokenLogFields(token)...)...)
+			}
+			budget = next.Sub(now)
+		}
+
+		wait := next.Sub(r.config.Clock.Now())
+		if wait > budget {
+			wait = budget
+		}
+		if wait > maxWait {
+			wait = maxWait
+		}
+		if wait < 0 {
+			wait = 0
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-r.after(wait):
+			budget -= wait
+		}
+	}
+}
+
+// refresh generates a token and atomically replaces the token file with it
+func (r *Refresher) refresh(ctx context.Context) (*provider.Token, error) {
+	token, err := r.generate(ctx)
+	if err == nil {
+		err = filelock.WriteAtomic(r.config.Path, []byte(token.AccessToken), fileMode)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lastErr = err
+	if err != nil {
+		return nil, err
+	}
+	r.token = token
+	return token, nil
+}
+
+// retryDelay returns the wait before retrying after the failures-th refresh in a row
+// failed with err at now: RetryInterval doubled for each earlier failure, up to
+// maxRetryInterval, plus a random jitter of up to half of it. A rate limited request
+// is retried no sooner than its Retry-After.
+func (r *Refresher) retryDelay(now time.Time, failures int, err error) time.Duration {
+	delay := r.config.RetryInterval
+	for i := 1; i < failures && delay < maxRetryInterval; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryInterval && r.config.RetryInterval < maxRetryInterval {
+		delay = maxRetryInterval
+	}
+	delay += r.jitter(delay / 2)
+
+	if retryAfter, ok := provider.RetryAfter(err, now); ok && retryAfter > delay {
+		delay = retryAfter
+	}
+	return delay
+}
+
+// nextRefresh returns when the token written at now is next refreshed: Threshold plus
+// provider.ClockSkew before it expires, or earlier by Interval. The time is moved
+// earlier by a random jitter so that pods started together do not refresh in step.
+func (r *Refresher) nextRefresh(now time.Time, token *provider.Token) time.Time {
+	threshold := r.config.Threshold
+	latest := token.ExpiresAt.Add(-threshold - provider.ClockSkew() - r.jitter(threshold/2))
+
+	next := latest
+	if r.config.Interval > 0 {
+		if byInterval := now.Add(r.config.Interval - r.jitter(r.config.Interval/10)); byInterval.Before(latest) {
+			next = byInterval
+		}
+	}
+	if next.Before(now) {
+		// Tokens shorter-lived than the threshold are refreshed as soon as they are written
+		next = now.Add(r.config.RetryInterval)
+	}
+	return next
+}
+
+// Check is a health check that fails until a token has been written and once the
+// written token has expired. A failed refresh is reported only when the token it
+// should have replaced has expired.
+func (r *Refresher) Check(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch {
+	case r.token == nil && r.lastErr != nil:
+		return fmt.Errorf("token file not written: %w", r.lastErr)
+	case r.token == nil:
+		return fmt.Errorf("token file not written yet")
+	case r.token.IsExpiredWith(r.config.Clock) && r.lastErr != nil:
+		return fmt.Errorf("token in file expired at %s: %w", r.token.ExpiresAt.Format(time.RFC3339), r.lastErr)
+	case r.token.IsExpiredWith(r.config.Clock):
+		return fmt.Errorf("token in file expired at %s", r.token.ExpiresAt.Format(time.RFC3339))
+	default:
+		return nil
+	}
+}
+
+// randomJitter returns a random duration in [0, max)
+func randomJitter(max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(max)))
+}
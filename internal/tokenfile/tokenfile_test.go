@@ -0,0 +1,310 @@
+package tokenfile
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/testutil"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+)
+
+var start = time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+// fakeGenerator issues tokens valid for lifetime, failing the calls listed in fail,
+// and cancels the run after stopAfter calls
+type fakeGenerator struct {
+	clock     *testutil.MockTime
+	lifetime  time.Duration
+	fail      map[int]bool
+	failErr   error
+	stopAfter int
+	cancel    context.CancelFunc
+	calls     []time.Duration
+}
+
+func (g *fakeGenerator) generate(ctx context.Context) (*provider.Token, error) {
+	g.calls = append(g.calls, g.clock.Now().Sub(start))
+	n := len(g.calls)
+	if n >= g.stopAfter {
+		g.cancel()
+	}
+	if g.fail[n] && g.failErr != nil {
+		return nil, g.failErr
+	}
+	if g.fail[n] {
+		return nil, fmt.Errorf("call %d failed", n)
+	}
+	return &provider.Token{
+		AccessToken: fmt.Sprintf("token-%d", n),
+		ExpiresAt:   g.clock.Now().Add(g.lifetime),
+	}, nil
+}
+
+// newTestRefresher returns a Refresher whose sleeps advance clock instantly. step, when
+// set, is called before each sleep advances the clock, so tests can change the clock.
+// The clock skew tolerance is zero so that tokens are refreshed at the threshold.
+func newTestRefresher(t *testing.T, config Config, gen *fakeGenerator, step func(sleep int)) (*Refresher, context.Context) {
+	require.NoError(t, provider.SetClockSkew(0))
+	t.Cleanup(func() { require.NoError(t, provider.SetClockSkew(provider.DefaultClockSkew)) })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	gen.cancel = cancel
+
+	config.Clock = gen.clock
+	if config.Path == "" {
+		config.Path = filepath.Join(t.TempDir(), "token")
+	}
+	r := New(config, gen.generate)
+	sleeps := 0
+	r.after = func(d time.Duration) <-chan time.Time {
+		sleeps++
+		if step != nil {
+			step(sleeps)
+		}
+		gen.clock.Advance(d)
+		ch := make(chan time.Time, 1)
+		ch <- gen.clock.Now()
+		return ch
+	}
+	r.jitter = func(max time.Duration) time.Duration { return 0 }
+	return r, ctx
+}
+
+func TestRun_WritesTokenFile(t *testing.T) {
+	gen := &fakeGenerator{clock: testutil.NewMockTime(start), lifetime: time.Hour, stopAfter: 2}
+	path := filepath.Join(t.TempDir(), "token")
+	r, ctx := newTestRefresher(t, Config{Path: path, Threshold: 5 * time.Minute}, gen, nil)
+
+	require.NoError(t, r.Run(ctx))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "token-2", string(data))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "no temp files are left behind")
+}
+
+func TestRun_Schedule(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   Config
+		lifetime time.Duration
+		jitter   func(max time.Duration) time.Duration
+		expected []time.Duration
+	}{
+		{
+			name:     "auto refreshes at the threshold",
+			config:   Config{Threshold: 5 * time.Minute},
+			lifetime: time.Hour,
+			expected: []time.Duration{0, 55 * time.Minute, 110 * time.Minute},
+		},
+		{
+			name:     "jitter moves refreshes earlier",
+			config:   Config{Threshold: 4 * time.Minute},
+			lifetime: time.Hour,
+			jitter:   func(max time.Duration) time.Duration { return max },
+			expected: []time.Duration{0, 54 * time.Minute, 108 * time.Minute},
+		},
+		{
+			name:     "interval shorter than the token lifetime",
+			config:   Config{Threshold: 5 * time.Minute, Interval: 10 * time.Minute},
+			lifetime: time.Hour,
+			expected: []time.Duration{0, 10 * time.Minute, 20 * time.Minute},
+		},
+		{
+			name:     "interval longer than the token lifetime",
+			config:   Config{Threshold: 2 * time.Minute, Interval: time.Hour},
+			lifetime: 15 * time.Minute,
+			expected: []time.Duration{0, 13 * time.Minute, 26 * time.Minute},
+		},
+		{
+			name:     "token shorter-lived than the threshold",
+			config:   Config{Threshold: 5 * time.Minute, RetryInterval: time.Minute},
+			lifetime: 4 * time.Minute,
+			expected: []time.Duration{0, time.Minute, 2 * time.Minute},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen := &fakeGenerator{clock: testutil.NewMockTime(start), lifetime: tt.lifetime, stopAfter: len(tt.expected)}
+			r, ctx := newTestRefresher(t, tt.config, gen, nil)
+			if tt.jitter != nil {
+				r.jitter = tt.jitter
+			}
+
+			require.NoError(t, r.Run(ctx))
+			assert.Equal(t, tt.expected, gen.calls)
+		})
+	}
+}
+
+func TestRun_ClockSkew(t *testing.T) {
+	gen := &fakeGenerator{clock: testutil.NewMockTime(start), lifetime: time.Hour, stopAfter: 3}
+	r, ctx := newTestRefresher(t, Config{Threshold: 5 * time.Minute}, gen, nil)
+	require.NoError(t, provider.SetClockSkew(2*time.Minute))
+
+	require.NoError(t, r.Run(ctx))
+	assert.Equal(t, []time.Duration{0, 53 * time.Minute, 106 * time.Minute}, gen.calls,
+		"tokens are refreshed the clock skew before the threshold")
+}
+
+func TestRun_ClockSteppedForward(t *testing.T) {
+	clock := testutil.NewMockTime(start)
+	gen := &fakeGenerator{clock: clock, lifetime: time.Hour, stopAfter: 2}
+	r, ctx := newTestRefresher(t, Config{Threshold: 5 * time.Minute}, gen, func(sleep int) {
+		if sleep == 1 {
+			clock.Advance(time.Hour)
+		}
+	})
+
+	require.NoError(t, r.Run(ctx))
+	// The first 30s sleep already sees the clock past the scheduled refresh
+	assert.Equal(t, []time.Duration{0, time.Hour + maxWait}, gen.calls)
+}
+
+func TestRun_ClockSteppedBack(t *testing.T) {
+	clock := testutil.NewMockTime(start)
+	gen := &fakeGenerator{clock: clock, lifetime: time.Hour, stopAfter: 2}
+	r, ctx := newTestRefresher(t, Config{Threshold: 5 * time.Minute}, gen, func(sleep int) {
+		if sleep == 1 {
+			clock.Advance(-time.Hour)
+		}
+	})
+
+	require.NoError(t, r.Run(ctx))
+	// 55 minutes were slept, so the refresh is not delayed by the hour the clock lost
+	assert.Equal(t, []time.Duration{0, -5 * time.Minute}, gen.calls)
+}
+
+func TestRun_ConsecutiveFailures(t *testing.T) {
+	gen := &fakeGenerator{
+		clock:     testutil.NewMockTime(start),
+		lifetime:  time.Hour,
+		fail:      map[int]bool{1: true, 3: true, 4: true, 5: true},
+		stopAfter: 100,
+	}
+	path := filepath.Join(t.TempDir(), "token")
+	r, ctx := newTestRefresher(t, Config{Path: path, Threshold: 5 * time.Minute, MaxFailures: 3, RetryInterval: 10 * time.Second}, gen, nil)
+
+	err := r.Run(ctx)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, errors.ErrTokenGenerationFailed))
+	assert.Contains(t, err.Error(), "token refresh failed 3 times in a row")
+
+	// A success resets the count, and failures in a row back off exponentially
+	assert.Equal(t, []time.Duration{
+		0,
+		10 * time.Second,
+		10*time.Second + 55*time.Minute,
+		10*time.Second + 55*time.Minute + 10*time.Second,
+		10*time.Second + 55*time.Minute + 30*time.Second,
+	}, gen.calls)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "token-2", string(data), "a failed refresh leaves the last token in place")
+}
+
+func TestRetryDelay(t *testing.T) {
+	r := New(Config{RetryInterval: 10 * time.Second}, nil)
+	r.jitter = func(max time.Duration) time.Duration { return 0 }
+	failed := fmt.Errorf("refresh failed")
+
+	// The backoff doubles up to maxRetryInterval
+	var delays []time.Duration
+	for failures := 1; failures <= 6; failures++ {
+		delays = append(delays, r.retryDelay(start, failures, failed))
+	}
+	assert.Equal(t, []time.Duration{
+		10 * time.Second, 20 * time.Second, 40 * time.Second, 80 * time.Second, 2 * time.Minute, 2 * time.Minute,
+	}, delays)
+
+	// The jitter adds up to half of the backoff
+	r.jitter = func(max time.Duration) time.Duration { return max }
+	assert.Equal(t, 30*time.Second, r.retryDelay(start, 2, failed))
+}
+
+func TestRun_RetryAfter(t *testing.T) {
+	tests := []struct {
+		name       string
+		retryAfter string
+		wantDelay  time.Duration
+	}{
+		{name: "seconds", retryAfter: "2", wantDelay: 2 * time.Second},
+		{name: "HTTP-date", retryAfter: start.Add(90 * time.Second).Format(http.TimeFormat), wantDelay: 90 * time.Second},
+		{name: "shorter than the backoff", retryAfter: "0", wantDelay: 100 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen := &fakeGenerator{
+				clock:     testutil.NewMockTime(start),
+				lifetime:  time.Hour,
+				fail:      map[int]bool{1: true},
+				failErr:   errors.New(errors.ErrRateLimitExceeded, "rate limited").WithField(provider.RetryAfterField, tt.retryAfter),
+				stopAfter: 2,
+			}
+			r, ctx := newTestRefresher(t, Config{Threshold: 5 * time.Minute, RetryInterval: 100 * time.Millisecond}, gen, nil)
+
+			require.NoError(t, r.Run(ctx))
+			require.Len(t, gen.calls, 2)
+			assert.Equal(t, tt.wantDelay, gen.calls[1]-gen.calls[0])
+		})
+	}
+}
+
+func TestRun_StopsWhenCanceled(t *testing.T) {
+	gen := &fakeGenerator{clock: testutil.NewMockTime(start), lifetime: time.Hour, stopAfter: 1}
+	r, ctx := newTestRefresher(t, Config{Threshold: 5 * time.Minute}, gen, nil)
+
+	require.NoError(t, r.Run(ctx))
+	assert.Len(t, gen.calls, 1)
+}
+
+func TestCheck(t *testing.T) {
+	clock := testutil.NewMockTime(start)
+	gen := &fakeGenerator{clock: clock, lifetime: time.Hour, fail: map[int]bool{1: true, 3: true}, stopAfter: 100}
+	r, ctx := newTestRefresher(t, Config{Threshold: 5 * time.Minute}, gen, nil)
+
+	err := r.Check(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not written yet")
+
+	_, err = r.refresh(ctx)
+	require.Error(t, err)
+	err = r.Check(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "call 1 failed")
+
+	_, err = r.refresh(ctx)
+	require.NoError(t, err)
+	assert.NoError(t, r.Check(ctx))
+
+	// A failed refresh is tolerated while the written token is valid
+	_, err = r.refresh(ctx)
+	require.Error(t, err)
+	assert.NoError(t, r.Check(ctx))
+
+	clock.Advance(2 * time.Hour)
+	err = r.Check(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "expired")
+	assert.Contains(t, err.Error(), "call 3 failed")
+}
@@ -52,6 +52,9 @@ const (
 	ErrInvalidFormat    ErrorCode = "ERR_INVALID_FORMAT"
 	ErrMissingRequired  ErrorCode = "ERR_MISSING_REQUIRED"
 
+	// File errors
+	ErrFileLocked ErrorCode = "ERR_FILE_LOCKED"
+
 	// Exec plugin errors
 	ErrExecPluginFailed       ErrorCode = "ERR_EXEC_PLUGIN_FAILED"
 	ErrExecPluginInvalidOutput ErrorCode = "ERR_EXEC_PLUGIN_INVALID_OUTPUT"
@@ -283,6 +286,14 @@ var errorInfoMap = map[ErrorCode]ErrorInfo{
 		Title:  "Rate Limit Exceeded",
 	},
 
+	// File errors (409)
+	ErrFileLocked: {
+		Code:   ErrFileLocked,
+		Type:   "https://hyperfleet.io/errors/file-locked",
+		Status: 409,
+		Title:  "File Locked",
+	},
+
 	// Exec plugin errors (500)
 	ErrExecPluginFailed: {
 		Code:   ErrExecPluginFailed,
@@ -142,6 +142,26 @@ func As(err error, target **Error) bool {
 	return false
 }
 
+// RequestIDField is the field that carries the ID of the invocation an error came from
+const RequestIDField = "request_id"
+
+// WithRequestID records the request ID id in the fields of the application Error of
+// err. An error without one is wrapped in an ErrUnknown Error with the same message, so
+// that every reported error carries the ID. A nil err or empty id returns err as is.
+func WithRequestID(err error, id string) error {
+	if err == nil || id == "" {
+		return err
+	}
+	var appErr *Error
+	if As(err, &appErr) {
+		appErr.WithField(RequestIDField, id)
+		return err
+	}
+	wrapped := New(ErrUnknown, err.Error()).WithField(RequestIDField, id)
+	wrapped.Cause = err
+	return wrapped
+}
+
 // GetCode extracts the error code from an error
 func GetCode(err error) ErrorCode {
 	var appErr *Error
@@ -1,7 +1,9 @@
 package errors
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -275,3 +277,34 @@ func TestErrorMarshalJSON(t *testing.T) {
 	assert.Contains(t, jsonStr, "root cause")
 	assert.Contains(t, jsonStr, "gcp")
 }
+
+func TestWithRequestID(t *testing.T) {
+	t.Run("application error", func(t *testing.T) {
+		appErr := New(ErrClusterNotFound, "cluster not found")
+		err := WithRequestID(fmt.Errorf("failed to get cluster info: %w", appErr), "0123abcd")
+
+		assert.Equal(t, "failed to get cluster info: cluster not found", err.Error())
+		assert.Equal(t, "0123abcd", appErr.Fields[RequestIDField])
+		assert.True(t, Is(err, ErrClusterNotFound))
+	})
+
+	t.Run("plain error", func(t *testing.T) {
+		cause := context.DeadlineExceeded
+		err := WithRequestID(fmt.Errorf("unknown flag: --bogus: %w", cause), "0123abcd")
+
+		assert.Equal(t, "unknown flag: --bogus: context deadline exceeded", err.Error())
+		assert.Equal(t, ErrUnknown, GetCode(err))
+		assert.True(t, errors.Is(err, cause), "the original error is kept in the chain")
+
+		var appErr *Error
+		require.True(t, As(err, &appErr))
+		assert.Equal(t, "0123abcd", appErr.Fields[RequestIDField])
+		assert.Empty(t, appErr.Detail)
+	})
+
+	t.Run("no ID or error", func(t *testing.T) {
+		plain := errors.New("failed")
+		assert.Same(t, plain, WithRequestID(plain, ""))
+		assert.NoError(t, WithRequestID(nil, "0123abcd"))
+	})
+}
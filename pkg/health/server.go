@@ -3,23 +3,36 @@ package health
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
+	"sort"
 	"sync"
 	"time"
 
 	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"golang.org/x/sync/errgroup"
 )
 
-// Server provides health check endpoints
+// maxConcurrentChecks bounds how many readiness checks run at once
+const maxConcurrentChecks = 8
+
+// Server provides health check and metrics endpoints on zero, one or two listeners
 type Server struct {
-	addr      string
-	server    *http.Server
-	logger    logger.Logger
-	checks    map[string]Check
-	mu        sync.RWMutex
-	startTime time.Time
+	healthAddr    string
+	metricsAddr   string
+	healthServer  *http.Server
+	metricsServer *http.Server
+	healthLn      net.Listener
+	metricsLn     net.Listener
+	endpoints     []string
+	logger        logger.Logger
+	checks        map[string]Check
+	mu            sync.RWMutex
+	startTime     time.Time
 }
 
 // Check represents a health check function
@@ -27,8 +40,31 @@ type Check func(ctx context.Context) error
 
 // Config holds server configuration
 type Config struct {
-	// Address to listen on (e.g., ":8080")
-	Address string
+	// HealthAddress to serve health probes on (e.g., ":8081")
+	HealthAddress string
+
+	// MetricsAddress to serve metrics on (e.g., ":9090").
+	// When empty or equal to HealthAddress, metrics share the health listener.
+	MetricsAddress string
+
+	// HealthDisabled turns off the health probe endpoints
+	HealthDisabled bool
+
+	// MetricsDisabled turns off the metrics endpoint
+	MetricsDisabled bool
+
+	// LivenessPath for the liveness probe (default: /healthz)
+	LivenessPath string
+
+	// ReadinessPath for the readiness probe (default: /readyz)
+	ReadinessPath string
+
+	// MetricsPath for the metrics endpoint (default: /metrics)
+	MetricsPath string
+
+	// MetricsGatherer to expose (default: prometheus.DefaultGatherer).
+	// Pass the registry given to metrics.NewMetrics to serve the same metrics.
+	MetricsGatherer prometheus.Gatherer
 
 	// ReadTimeout for HTTP requests
 	ReadTimeout time.Duration
@@ -43,9 +79,12 @@ type Config struct {
 // DefaultConfig returns default health server configuration
 func DefaultConfig() Config {
 	return Config{
-		Address:      ":8080",
-		ReadTimeout:  5 * time.Second,
-		WriteTimeout: 10 * time.Second,
+		HealthAddress: ":8080",
+		LivenessPath:  "/healthz",
+		ReadinessPath: "/readyz",
+		MetricsPath:   "/metrics",
+		ReadTimeout:   5 * time.Second,
+		WriteTimeout:  10 * time.Second,
 	}
 }
 
@@ -54,27 +93,71 @@ func NewServer(config Config) *Server {
 	if config.Logger == nil {
 		config.Logger = logger.Nop()
 	}
+	if config.LivenessPath == "" {
+		config.LivenessPath = "/healthz"
+	}
+	if config.ReadinessPath == "" {
+		config.ReadinessPath = "/readyz"
+	}
+	if config.MetricsPath == "" {
+		config.MetricsPath = "/metrics"
+	}
+	if config.MetricsGatherer == nil {
+		config.MetricsGatherer = prometheus.DefaultGatherer
+	}
 
 	s := &Server{
-		addr:      config.Address,
 		logger:    config.Logger,
 		checks:    make(map[string]Check),
 		startTime: time.Now(),
 	}
 
-	// Create HTTP server
-	mux := http.NewServeMux()
-	mux.HandleFunc("/healthz", s.handleLiveness)
-	mux.HandleFunc("/readyz", s.handleReadiness)
-	mux.HandleFunc("/livez", s.handleLiveness) // Alias for /healthz
-	mux.Handle("/metrics", promhttp.Handler())
-	mux.HandleFunc("/", s.handleRoot)
+	var healthMux *http.ServeMux
+	if !config.HealthDisabled {
+		healthMux = http.NewServeMux()
+		healthMux.HandleFunc(config.LivenessPath, s.handleLiveness)
+		healthMux.HandleFunc(config.ReadinessPath, s.handleReadiness)
+		s.endpoints = append(s.endpoints, config.LivenessPath, config.ReadinessPath)
+		if config.LivenessPath != "/livez" && config.ReadinessPath != "/livez" {
+			healthMux.HandleFunc("/livez", s.handleLiveness) // Alias for the liveness path
+			s.endpoints = append(s.endpoints, "/livez")
+		}
+		if config.LivenessPath != "/" && config.ReadinessPath != "/" {
+			healthMux.HandleFunc("/", s.handleRoot)
+		}
+		s.healthAddr = config.HealthAddress
+	}
+
+	if !config.MetricsDisabled {
+		metricsHandler := promhttp.HandlerFor(config.MetricsGatherer, promhttp.HandlerOpts{})
+		if config.MetricsAddress == "" || config.MetricsAddress == config.HealthAddress {
+			// Single listener: serve metrics next to the health probes
+			if healthMux == nil {
+				healthMux = http.NewServeMux()
+				s.healthAddr = config.HealthAddress
+			}
+			healthMux.Handle(config.MetricsPath, metricsHandler)
+			s.endpoints = append(s.endpoints, config.MetricsPath)
+		} else {
+			metricsMux := http.NewServeMux()
+			metricsMux.Handle(config.MetricsPath, metricsHandler)
+			s.metricsAddr = config.MetricsAddress
+			s.metricsServer = &http.Server{
+				Addr:         config.MetricsAddress,
+				Handler:      metricsMux,
+				ReadTimeout:  config.ReadTimeout,
+				WriteTimeout: config.WriteTimeout,
+			}
+		}
+	}
 
-	s.server = &http.Server{
-		Addr:         config.Address,
-		Handler:      mux,
-		ReadTimeout:  config.ReadTimeout,
-		WriteTimeout: config.WriteTimeout,
+	if healthMux != nil {
+		s.healthServer = &http.Server{
+			Addr:         s.healthAddr,
+			Handler:      healthMux,
+			ReadTimeout:  config.ReadTimeout,
+			WriteTimeout: config.WriteTimeout,
+		}
 	}
 
 	return s
@@ -90,27 +173,87 @@ func (s *Server) RegisterCheck(name string, check Check) {
 	)
 }
 
-// Start starts the health check server
+// Start binds the configured listeners and serves them in the background.
+// It returns an error if any listener cannot be bound.
 func (s *Server) Start() error {
-	s.logger.Info("Starting health server",
-		logger.String("address", s.addr),
-	)
+	if s.healthServer == nil && s.metricsServer == nil {
+		s.logger.Info("Health and metrics servers are disabled")
+		return nil
+	}
 
-	go func() {
-		if err := s.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			s.logger.Error("Health server error",
-				logger.String("error", err.Error()),
-			)
+	if s.healthServer != nil {
+		ln, err := net.Listen("tcp", s.healthServer.Addr)
+		if err != nil {
+			return fmt.Errorf("failed to listen on health address %s: %w", s.healthServer.Addr, err)
 		}
-	}()
+		s.healthLn = ln
+	}
+
+	if s.metricsServer != nil {
+		ln, err := net.Listen("tcp", s.metricsServer.Addr)
+		if err != nil {
+			if s.healthLn != nil {
+				s.healthLn.Close()
+				s.healthLn = nil
+			}
+			return fmt.Errorf("failed to listen on metrics address %s: %w", s.metricsServer.Addr, err)
+		}
+		s.metricsLn = ln
+	}
+
+	if s.healthServer != nil {
+		s.logger.Info("Starting health server",
+			logger.String("address", s.healthLn.Addr().String()),
+		)
+		go s.serve("health", s.healthServer, s.healthLn)
+	}
+
+	if s.metricsServer != nil {
+		s.logger.Info("Starting metrics server",
+			logger.String("address", s.metricsLn.Addr().String()),
+		)
+		go s.serve("metrics", s.metricsServer, s.metricsLn)
+	}
 
 	return nil
 }
 
-// Stop gracefully shuts down the health check server
+// HealthAddr returns the address the health listener is bound to, resolving a port 0
+// in HealthAddress. It is empty before Start or when health probes are disabled.
+func (s *Server) HealthAddr() string {
+	if s.healthLn == nil {
+		return ""
+	}
+	return s.healthLn.Addr().String()
+}
+
+// serve runs an HTTP server on a bound listener until it is shut down
+func (s *Server) serve(name string, server *http.Server, ln net.Listener) {
+	if err := server.Serve(ln); err != nil && err != http.ErrServerClosed {
+		s.logger.Error("Server error",
+			logger.String("server", name),
+			logger.String("error", err.Error()),
+		)
+	}
+}
+
+// Stop gracefully shuts down all listeners
 func (s *Server) Stop(ctx context.Context) error {
 	s.logger.Info("Stopping health server")
-	return s.server.Shutdown(ctx)
+
+	var errs []error
+	if s.healthServer != nil {
+		if err := s.healthServer.Shutdown(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("health server: %w", err))
+		}
+	}
+	if s.metricsServer != nil {
+		if err := s.metricsServer.Shutdown(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("metrics server: %w", err))
+		}
+	}
+
+	return errors.Join(errs...)
 }
 
 // handleRoot provides basic information
@@ -126,7 +269,7 @@ func (s *Server) handleRoot(w http.ResponseWriter, r *http.Request) {
 		"service":   "hyperfleet-cloud-provider",
 		"status":    "running",
 		"uptime":    uptime.String(),
-		"endpoints": []string{"/healthz", "/readyz", "/livez", "/metrics"},
+		"endpoints": s.endpoints,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -177,23 +320,7 @@ func (s *Server) handleReadiness(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Run all health checks
-	results := make(map[string]string)
-	allHealthy := true
-
-	for name, check := range checks {
-		if err := check(ctx); err != nil {
-			results[name] = fmt.Sprintf("failed: %s", err.Error())
-			allHealthy = false
-			s.logger.Warn("Health check failed",
-				logger.String("check", name),
-				logger.String("error", err.Error()),
-			)
-		} else {
-			results[name] = "ok"
-		}
-	}
-
+	results, allHealthy := s.runChecks(ctx, checks)
 	status := "ok"
 	statusCode := http.StatusOK
 	if !allHealthy {
@@ -211,6 +338,67 @@ func (s *Server) handleReadiness(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(response)
 }
 
+// runChecks runs the checks concurrently, at most maxConcurrentChecks at a time, and
+// returns their results by name. A check still running when ctx expires is reported as
+// failed with the context error.
+func (s *Server) runChecks(ctx context.Context, checks map[string]Check) (map[string]string, bool) {
+	names := make([]string, 0, len(checks))
+	for name := range checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var mu sync.Mutex
+	errs := make(map[string]error, len(names))
+
+	var g errgroup.Group
+	g.SetLimit(maxConcurrentChecks)
+	done := make(chan struct{})
+	go func() {
+		for _, name := range names {
+			check := checks[name]
+			g.Go(func() error {
+				err := check(ctx)
+				mu.Lock()
+				errs[name] = err
+				mu.Unlock()
+				return nil
+			})
+		}
+		g.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	// Results are gathered in name order so logs are stable across requests
+	results := make(map[string]string, len(names))
+	allHealthy := true
+	for _, name := range names {
+		err, finished := errs[name]
+		if !finished {
+			err = ctx.Err()
+		}
+		if err != nil {
+			results[name] = fmt.Sprintf("failed: %s", err.Error())
+			allHealthy = false
+			s.logger.Warn("Health check failed",
+				logger.String("check", name),
+				logger.String("error", err.Error()),
+			)
+		} else {
+			results[name] = "ok"
+		}
+	}
+	return results, allHealthy
+}
+
 // HealthResponse represents the health check response
 type HealthResponse struct {
 	Status string            `json:"status"`
@@ -4,11 +4,14 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -21,14 +24,15 @@ func TestNewServer(t *testing.T) {
 
 	server := NewServer(config)
 	require.NotNil(t, server)
-	assert.Equal(t, config.Address, server.addr)
-	assert.NotNil(t, server.server)
+	assert.Equal(t, config.HealthAddress, server.healthAddr)
+	assert.NotNil(t, server.healthServer)
+	assert.Nil(t, server.metricsServer)
 	assert.NotNil(t, server.checks)
 }
 
 func TestServerStartStop(t *testing.T) {
 	config := DefaultConfig()
-	config.Address = ":18080" // Use different port to avoid conflicts
+	config.HealthAddress = ":18080" // Use different port to avoid conflicts
 	config.Logger = logger.Nop()
 
 	server := NewServer(config)
@@ -242,7 +246,8 @@ func TestHealthResponse_JSON(t *testing.T) {
 func TestDefaultConfig(t *testing.T) {
 	config := DefaultConfig()
 
-	assert.Equal(t, ":8080", config.Address)
+	assert.Equal(t, ":8080", config.HealthAddress)
+	assert.Empty(t, config.MetricsAddress)
 	assert.Equal(t, 5*time.Second, config.ReadTimeout)
 	assert.Equal(t, 10*time.Second, config.WriteTimeout)
 }
@@ -253,15 +258,19 @@ func TestServer_ConcurrentChecks(t *testing.T) {
 
 	server := NewServer(config)
 
-	// Register slow checks
+	// Register slow checks, one of them failing
 	slowCheck := func(ctx context.Context) error {
-		time.Sleep(10 * time.Millisecond)
+		time.Sleep(50 * time.Millisecond)
 		return nil
 	}
 
-	for i := 0; i < 10; i++ {
+	for i := 0; i < maxConcurrentChecks; i++ {
 		server.RegisterCheck(fmt.Sprintf("check%d", i), slowCheck)
 	}
+	server.RegisterCheck("failing", func(ctx context.Context) error {
+		time.Sleep(50 * time.Millisecond)
+		return fmt.Errorf("backend unavailable")
+	})
 
 	req := httptest.NewRequest(http.MethodGet, "/readyz", nil)
 	w := httptest.NewRecorder()
@@ -270,9 +279,65 @@ func TestServer_ConcurrentChecks(t *testing.T) {
 	server.handleReadiness(w, req)
 	duration := time.Since(start)
 
-	// All checks should run serially, so total time should be ~100ms
-	assert.Less(t, duration, 200*time.Millisecond)
-	assert.Equal(t, http.StatusOK, w.Code)
+	// Checks run concurrently: nine 50ms checks with a limit of eight take two rounds,
+	// well under the 450ms they would take serially
+	assert.Less(t, duration, 250*time.Millisecond)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	var response HealthResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Len(t, response.Checks, maxConcurrentChecks+1)
+	assert.Equal(t, "failed: backend unavailable", response.Checks["failing"])
+	assert.Equal(t, "ok", response.Checks["check0"])
+}
+
+func TestServer_ConcurrentChecksDeterministicOutput(t *testing.T) {
+	config := DefaultConfig()
+	config.Logger = logger.Nop()
+
+	server := NewServer(config)
+	for i := 0; i < 20; i++ {
+		delay := time.Duration(20-i) * time.Millisecond
+		server.RegisterCheck(fmt.Sprintf("check%02d", i), func(ctx context.Context) error {
+			time.Sleep(delay)
+			return nil
+		})
+	}
+
+	var bodies []string
+	for i := 0; i < 3; i++ {
+		w := httptest.NewRecorder()
+		server.handleReadiness(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		bodies = append(bodies, w.Body.String())
+	}
+	assert.Equal(t, bodies[0], bodies[1])
+	assert.Equal(t, bodies[0], bodies[2])
+}
+
+func TestServer_CheckIgnoringContextDoesNotBlockProbe(t *testing.T) {
+	config := DefaultConfig()
+	config.Logger = logger.Nop()
+
+	server := NewServer(config)
+	release := make(chan struct{})
+	defer close(release)
+	server.RegisterCheck("stuck", func(ctx context.Context) error {
+		<-release
+		return nil
+	})
+	server.RegisterCheck("fast", func(ctx context.Context) error { return nil })
+
+	w := httptest.NewRecorder()
+	start := time.Now()
+	server.handleReadiness(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	assert.Less(t, time.Since(start), 6*time.Second)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	var response HealthResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Equal(t, "failed: context deadline exceeded", response.Checks["stuck"])
+	assert.Equal(t, "ok", response.Checks["fast"])
 }
 
 func TestServer_CheckTimeout(t *testing.T) {
@@ -335,7 +400,7 @@ func TestMetricsEndpoint(t *testing.T) {
 	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
 	w := httptest.NewRecorder()
 
-	server.server.Handler.ServeHTTP(w, req)
+	server.healthServer.Handler.ServeHTTP(w, req)
 
 	assert.Equal(t, http.StatusOK, w.Code)
 	assert.Contains(t, w.Header().Get("Content-Type"), "text/plain")
@@ -369,3 +434,154 @@ func TestRootEndpointIncludesMetrics(t *testing.T) {
 
 	assert.Contains(t, endpointStrs, "/metrics")
 }
+
+// getURL fetches url and returns the status code and body
+func getURL(t *testing.T, url string) (int, string) {
+	t.Helper()
+	resp, err := http.Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.StatusCode, string(body)
+}
+
+func TestServer_SplitListeners(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	counter := prometheus.NewCounter(prometheus.CounterOpts{Name: "split_listener_test_total", Help: "test"})
+	registry.MustRegister(counter)
+	counter.Inc()
+
+	config := DefaultConfig()
+	config.HealthAddress = "127.0.0.1:0"
+	config.MetricsAddress = "localhost:0"
+	config.MetricsGatherer = registry
+	config.Logger = logger.Nop()
+
+	server := NewServer(config)
+	require.NotNil(t, server.healthServer)
+	require.NotNil(t, server.metricsServer)
+	require.NoError(t, server.Start())
+
+	healthURL := "http://" + server.HealthAddr()
+	metricsURL := "http://" + server.metricsLn.Addr().String()
+	assert.NotEqual(t, "127.0.0.1:0", server.HealthAddr(), "the bound port is reported")
+
+	code, _ := getURL(t, healthURL+"/healthz")
+	assert.Equal(t, http.StatusOK, code)
+
+	code, _ = getURL(t, healthURL+"/metrics")
+	assert.Equal(t, http.StatusNotFound, code, "metrics must not be served on the health listener")
+
+	code, body := getURL(t, metricsURL+"/metrics")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Contains(t, body, "split_listener_test_total 1")
+
+	code, _ = getURL(t, metricsURL+"/healthz")
+	assert.Equal(t, http.StatusNotFound, code, "health probes must not be served on the metrics listener")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, server.Stop(ctx))
+
+	_, err := http.Get(healthURL + "/healthz")
+	assert.Error(t, err)
+	_, err = http.Get(metricsURL + "/metrics")
+	assert.Error(t, err)
+}
+
+func TestServer_SingleListener(t *testing.T) {
+	config := DefaultConfig()
+	config.HealthAddress = "127.0.0.1:0"
+	config.LivenessPath = "/live"
+	config.MetricsPath = "/custom-metrics"
+	config.MetricsGatherer = prometheus.NewRegistry()
+	config.Logger = logger.Nop()
+
+	server := NewServer(config)
+	require.NotNil(t, server.healthServer)
+	assert.Nil(t, server.metricsServer)
+
+	require.NoError(t, server.Start())
+	baseURL := "http://" + server.healthLn.Addr().String()
+
+	code, _ := getURL(t, baseURL+"/live")
+	assert.Equal(t, http.StatusOK, code)
+
+	code, _ = getURL(t, baseURL+"/readyz")
+	assert.Equal(t, http.StatusOK, code)
+
+	code, _ = getURL(t, baseURL+"/custom-metrics")
+	assert.Equal(t, http.StatusOK, code)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, server.Stop(ctx))
+}
+
+func TestServer_DisabledListeners(t *testing.T) {
+	t.Run("metrics only", func(t *testing.T) {
+		config := DefaultConfig()
+		config.HealthAddress = "127.0.0.1:0"
+		config.HealthDisabled = true
+		config.MetricsGatherer = prometheus.NewRegistry()
+		config.Logger = logger.Nop()
+
+		server := NewServer(config)
+		require.NoError(t, server.Start())
+		baseURL := "http://" + server.healthLn.Addr().String()
+
+		code, _ := getURL(t, baseURL+"/metrics")
+		assert.Equal(t, http.StatusOK, code)
+		code, _ = getURL(t, baseURL+"/healthz")
+		assert.Equal(t, http.StatusNotFound, code)
+
+		require.NoError(t, server.Stop(context.Background()))
+	})
+
+	t.Run("health only", func(t *testing.T) {
+		config := DefaultConfig()
+		config.HealthAddress = "127.0.0.1:0"
+		config.MetricsAddress = ":9090"
+		config.MetricsDisabled = true
+		config.Logger = logger.Nop()
+
+		server := NewServer(config)
+		assert.Nil(t, server.metricsServer)
+		require.NoError(t, server.Start())
+		baseURL := "http://" + server.healthLn.Addr().String()
+
+		code, _ := getURL(t, baseURL+"/metrics")
+		assert.Equal(t, http.StatusNotFound, code)
+
+		require.NoError(t, server.Stop(context.Background()))
+	})
+
+	t.Run("both disabled", func(t *testing.T) {
+		config := DefaultConfig()
+		config.HealthDisabled = true
+		config.MetricsDisabled = true
+		config.Logger = logger.Nop()
+
+		server := NewServer(config)
+		assert.Nil(t, server.healthServer)
+		assert.Nil(t, server.metricsServer)
+		require.NoError(t, server.Start())
+		require.NoError(t, server.Stop(context.Background()))
+	})
+}
+
+func TestServer_StartBindError(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+
+	config := DefaultConfig()
+	config.HealthAddress = "127.0.0.1:0"
+	config.MetricsAddress = ln.Addr().String()
+	config.Logger = logger.Nop()
+
+	server := NewServer(config)
+	assert.Error(t, server.Start())
+	assert.Nil(t, server.healthLn, "health listener must be released when metrics fails to bind")
+}
@@ -0,0 +1,81 @@
+package hyperfleet_test
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/hyperfleet"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+)
+
+func ExampleNewGCP() {
+	prov, err := hyperfleet.NewGCP(&hyperfleet.Config{
+		ProjectID:       "my-project",
+		CredentialsFile: "/var/run/secrets/gcp/sa.json",
+	}, logger.NewDefault())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	token, err := prov.GetToken(context.Background(), hyperfleet.GetTokenOptions{
+		ClusterName: "my-cluster",
+		ProjectID:   "my-project",
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("token expires in", token.ExpiresIn().Round(time.Minute))
+}
+
+func ExampleNewAWS() {
+	prov, err := hyperfleet.NewAWS(&hyperfleet.Config{Region: "us-east-1"}, nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	info, err := prov.GetClusterInfo(context.Background(), hyperfleet.ClusterInfoOptions{
+		ClusterName: "my-cluster",
+		Region:      "us-east-1",
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(info.Endpoint)
+}
+
+func ExampleNew() {
+	// New selects the provider by name, for example from a cluster record
+	prov, err := hyperfleet.New("azure", &hyperfleet.Config{
+		TenantID:       "00000000-0000-0000-0000-000000000000",
+		SubscriptionID: "00000000-0000-0000-0000-000000000000",
+	}, nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := prov.ValidateCredentials(context.Background()); err != nil {
+		if errors.Is(err, errors.ErrCredentialNotFound) {
+			log.Fatal("no Azure credentials configured")
+		}
+		log.Fatal(err)
+	}
+}
+
+func ExampleNew_unsupported() {
+	_, err := hyperfleet.New("ibm", nil, nil)
+	fmt.Println(errors.Is(err, errors.ErrProviderNotSupported))
+	// Output: true
+}
+
+func ExampleToken_IsExpired() {
+	token := &hyperfleet.Token{
+		AccessToken: "k8s-aws-v1.example",
+		ExpiresAt:   time.Now().Add(-time.Minute),
+		TokenType:   "Bearer",
+	}
+	fmt.Println(token.IsExpired())
+	// Output: true
+}
@@ -0,0 +1,278 @@
+// Package hyperfleet is the public Go API for generating Kubernetes authentication
+// tokens and looking up cluster connection details on GKE, EKS, AKS, OKE and DOKS, and
+// for getting tokens from a generic OIDC issuer. Other services embed it instead of running the hyperfleet-credential-provider binary.
+//
+// Stability: the exported identifiers of this package follow semantic versioning.
+// Fields may be added to the option, config and result structs in minor releases,
+// so construct them with field names. Nothing is removed or changed incompatibly
+// before the next major version. The package only exposes types defined here and in
+// the public pkg/errors, pkg/logger and pkg/tracing packages, never types under internal/.
+//
+// Errors returned by providers are *errors.Error values from pkg/errors; use
+// errors.Is with an errors.ErrorCode to classify them.
+package hyperfleet
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/metrics"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/tracing"
+)
+
+// Provider names accepted by New
+const (
+	GCP          = "gcp"
+	AWS          = "aws"
+	Azure        = "azure"
+	OCI          = "oci"
+	DigitalOcean = "digitalocean"
+	OIDC         = "oidc"
+)
+
+// Provider generates Kubernetes authentication tokens and looks up clusters
+// for a specific cloud platform. It is safe for concurrent use.
+type Provider interface {
+	// GetToken generates a short-lived authentication token
+	GetToken(ctx context.Context, opts GetTokenOptions) (*Token, error)
+
+	// GetClusterInfo returns the API server endpoint and CA certificate of a cluster
+	GetClusterInfo(ctx context.Context, opts ClusterInfoOptions) (*ClusterInfo, error)
+
+	// ValidateCredentials verifies that credentials are valid
+	ValidateCredentials(ctx context.Context) error
+
+	// Name returns the provider name (gcp, aws, azure, oci, digitalocean, oidc)
+	Name() string
+}
+
+// Config configures a provider. Each provider reads the fields that apply to it
+// and ignores the rest.
+type Config struct {
+	// Region is the cloud region or location
+	Region string
+
+	// ProjectID is the GCP project ID (GCP only)
+	ProjectID string
+
+	// AccountID is the AWS account ID (AWS only, optional)
+	AccountID string
+
+	// AWSProfile is the profile read from the AWS shared files; empty uses AWS_PROFILE (AWS only)
+	AWSProfile string
+
+	// SubscriptionID is the Azure subscription ID (Azure only)
+	SubscriptionID string
+
+	// TenantID is the Azure tenant ID (Azure only)
+	TenantID string
+
+	// AzureCloud is the Azure cloud: public, usgovernment or china (Azure only, default: public)
+	AzureCloud string
+
+	// TenancyID is the OCI tenancy OCID (OCI only, optional)
+	TenancyID string
+
+	// UserID is the OCI user OCID (OCI only, optional)
+	UserID string
+
+	// CompartmentID is the OCI compartment searched for clusters by name (OCI only, optional)
+	CompartmentID string
+
+	// CredentialsFile is the path to the credentials file; empty uses the environment
+	CredentialsFile string
+
+	// CredentialsDir is a directory of GCP service account keys (GCP only)
+	CredentialsDir string
+
+	// GCPUseADC is the application default credentials mode: auto, true or false (GCP only)
+	GCPUseADC string
+
+	// OIDCIssuerURL is the issuer whose discovery document names the token endpoint (OIDC only)
+	OIDCIssuerURL string
+
+	// OIDCClientID is the OAuth client ID (OIDC only)
+	OIDCClientID string
+
+	// OIDCPrivateKeyFile is a PEM private key that signs private_key_jwt client
+	// assertions instead of a client secret (OIDC only, optional)
+	OIDCPrivateKeyFile string
+
+	// OIDCScopes are the requested scopes; nil requests openid (OIDC only)
+	OIDCScopes []string
+
+	// OIDCSubjectTokenFile holds a token, such as a Kubernetes service account token,
+	// exchanged for the access token with RFC 8693 token exchange (OIDC only, optional)
+	OIDCSubjectTokenFile string
+
+	// OIDCSubjectTokenType is the RFC 8693 type of the subject token (OIDC only, optional)
+	OIDCSubjectTokenType string
+
+	// OIDCUseIDToken returns the ID token instead of the access token (OIDC only)
+	OIDCUseIDToken bool
+
+	// TokenDuration is the token lifetime; zero uses the provider default
+	TokenDuration time.Duration
+
+	// StrictPermissions rejects credentials files readable by group or others
+	StrictPermissions bool
+
+	// CredentialsSHA256 is a comma-separated list of SHA-256 digests, one of which every
+	// credentials file read must have (GCP, AWS and Azure only). Credentials that do not
+	// come from a file then fail to load. Empty skips the check.
+	CredentialsSHA256 string
+
+	// SkipCredentialCheck skips the pre-flight expiry check of session credentials (AWS only)
+	SkipCredentialCheck bool
+
+	// AWSFallbacks are credential sources tried in order when the configured AWS
+	// credentials fail, in --aws-fallback format such as profile=backup,region=us-west-2
+	// (AWS only)
+	AWSFallbacks []string
+
+	// AWSSuggestRegion looks for a cluster that is not found in its region in a few
+	// likely regions, to report a region mismatch (AWS only)
+	AWSSuggestRegion bool
+
+	// AzurePreferSecret authenticates with the client secret when the Azure credentials
+	// have both a secret and a client certificate (Azure only)
+	AzurePreferSecret bool
+
+	// STSEndpoint overrides the regional STS endpoint that tokens presign requests to,
+	// e.g. an interface VPC endpoint (AWS only)
+	STSEndpoint string
+
+	// GKEEndpoint overrides the Container API endpoint of cluster lookups (GCP only)
+	GKEEndpoint string
+
+	// Tracing records spans for token generation and cluster lookups; nil disables tracing
+	Tracing *tracing.Provider
+
+	// HTTPClient carries the cloud SDK requests, e.g. through a proxy; nil keeps the
+	// SDK default transports
+	HTTPClient *http.Client
+
+	// WatchCredentials reloads the GCP, AWS and Azure credentials files when they change
+	// on disk, e.g. when Vault Agent rotates them (long-running modes). The files are
+	// watched until the provider is closed through io.Closer.
+	WatchCredentials bool
+
+	// Metrics records credential reloads; nil disables them
+	Metrics *metrics.Metrics
+}
+
+// GetTokenOptions contains parameters for token generation
+type GetTokenOptions struct {
+	// ClusterName is the Kubernetes cluster name
+	ClusterName string
+
+	// Region is the cloud region
+	Region string
+
+	// ProjectID is the GCP project ID (GCP only)
+	ProjectID string
+
+	// AccountID is the AWS account ID (AWS only, optional)
+	AccountID string
+
+	// ClusterID is the cluster ID the token is bound to when it differs from
+	// ClusterName (AWS only, optional)
+	ClusterID string
+
+	// SubscriptionID is the Azure subscription ID (Azure only)
+	SubscriptionID string
+
+	// TenantID is the Azure tenant ID (Azure only)
+	TenantID string
+
+	// ResourceGroup is the Azure resource group (Azure only, optional)
+	ResourceGroup string
+
+	// CompartmentID is the compartment searched for a cluster given by name (OCI only, optional)
+	CompartmentID string
+
+	// Audience binds the token to an audience other than the cluster default
+	// (GCP, AWS, Azure and OIDC only, optional)
+	Audience string
+}
+
+// ClusterInfoOptions identifies the cluster to describe
+type ClusterInfoOptions struct {
+	// ClusterName is the Kubernetes cluster name
+	ClusterName string
+
+	// Region is the cloud region or location (GCP, AWS and OCI)
+	Region string
+
+	// ResourceGroup is the Azure resource group (Azure only)
+	ResourceGroup string
+
+	// EndpointAccess picks the API server endpoint of clusters that have both a public
+	// and a private one: "private", "public", or empty for the public endpoint when
+	// the cluster has one (GCP, AWS and Azure only)
+	EndpointAccess string
+}
+
+// Token is a Kubernetes authentication token
+type Token struct {
+	// AccessToken is the bearer token for authentication
+	AccessToken string
+
+	// ExpiresAt is when the token expires
+	ExpiresAt time.Time
+
+	// TokenType is the token type (usually "Bearer")
+	TokenType string
+
+	// Provider is the name of the provider that issued the token
+	Provider string
+
+	// ClusterName is the cluster the token was generated for
+	ClusterName string
+
+	// Identity names the principal that minted the token: a service account email, a
+	// partially redacted AWS access key ID or an Azure client ID. It is safe to log.
+	Identity string
+
+	// Audience is the audience the token is bound to, when the provider binds one
+	Audience string
+
+	// Scopes are the OAuth2 scopes the token was requested with, when the provider
+	// requests any
+	Scopes []string
+}
+
+// IsExpired returns true if the token has expired
+func (t *Token) IsExpired() bool {
+	return time.Now().After(t.ExpiresAt)
+}
+
+// ExpiresIn returns the duration until the token expires
+func (t *Token) ExpiresIn() time.Duration {
+	return time.Until(t.ExpiresAt)
+}
+
+// ClusterInfo describes how to connect to a cluster
+type ClusterInfo struct {
+	// Endpoint is the cluster API server URL (with https://)
+	Endpoint string
+
+	// CertificateAuthority is the base64-encoded cluster CA certificate
+	CertificateAuthority string
+
+	// Version is the Kubernetes version
+	Version string
+
+	// Location is the cluster location (GCP and Azure)
+	Location string
+
+	// Region is the cluster region (AWS, OCI and DigitalOcean)
+	Region string
+
+	// ARN is the cluster ARN (AWS only)
+	ARN string
+
+	// ResourceID is the cluster resource ID (Azure), OCID (OCI) or UUID (DigitalOcean)
+	ResourceID string
+}
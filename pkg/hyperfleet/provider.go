@@ -0,0 +1,244 @@
+package hyperfleet
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/metrics"
+
+	// Register all providers with the internal registry
+	_ "github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider/aws"
+	_ "github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider/azure"
+	_ "github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider/digitalocean"
+	_ "github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider/gcp"
+	_ "github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider/oci"
+	_ "github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider/oidc"
+)
+
+// New creates the named provider (gcp, aws, azure, oci, digitalocean or oidc).
+// A nil config uses the provider defaults and a nil log discards log output.
+// The returned Provider implements io.Closer; close it when done with it to stop
+// watching the credentials files of Config.WatchCredentials.
+func New(name string, config *Config, log logger.Logger) (Provider, error) {
+	if config == nil {
+		config = &Config{}
+	}
+	if log == nil {
+		log = logger.Nop()
+	}
+
+	inner, err := provider.New(name, config.internal(), log)
+	if err != nil {
+		return nil, err
+	}
+	return &wrapper{inner: inner, log: log, metrics: config.Metrics}, nil
+}
+
+// NewGCP creates a Google Cloud (GKE) provider
+func NewGCP(config *Config, log logger.Logger) (Provider, error) {
+	return New(GCP, config, log)
+}
+
+// NewAWS creates an Amazon Web Services (EKS) provider
+func NewAWS(config *Config, log logger.Logger) (Provider, error) {
+	return New(AWS, config, log)
+}
+
+// NewAzure creates a Microsoft Azure (AKS) provider
+func NewAzure(config *Config, log logger.Logger) (Provider, error) {
+	return New(Azure, config, log)
+}
+
+// NewOCI creates an Oracle Cloud (OKE) provider
+func NewOCI(config *Config, log logger.Logger) (Provider, error) {
+	return New(OCI, config, log)
+}
+
+// NewDigitalOcean creates a DigitalOcean (DOKS) provider
+func NewDigitalOcean(config *Config, log logger.Logger) (Provider, error) {
+	return New(DigitalOcean, config, log)
+}
+
+// NewOIDC creates a generic OpenID Connect provider, which gets tokens from the
+// token endpoint of Config.OIDCIssuerURL. It does not support cluster lookup.
+func NewOIDC(config *Config, log logger.Logger) (Provider, error) {
+	return New(OIDC, config, log)
+}
+
+// Providers returns the names accepted by New, sorted
+func Providers() []string {
+	return provider.Registered()
+}
+
+// SupportsAudience reports whether the named provider accepts GetTokenOptions.Audience
+func SupportsAudience(name string) bool {
+	return provider.CapabilitiesOf(name).BoundAudience
+}
+
+// SetClockSkew sets how far the local clock may be off from the cloud clocks; tokens
+// are treated as needing a refresh this much earlier. The default is one minute. A skew
+// that reaches the gap between a provider's default token duration and refresh
+// threshold fails with ErrConfigInvalid.
+func SetClockSkew(skew time.Duration) error {
+	return provider.SetClockSkew(skew)
+}
+
+// wrapper adapts an internal provider to the public Provider interface
+type wrapper struct {
+	inner   provider.Provider
+	log     logger.Logger
+	metrics *metrics.Metrics
+}
+
+func wrap(inner provider.Provider) Provider {
+	return &wrapper{inner: inner, log: logger.Nop()}
+}
+
+// GetToken implements Provider
+func (w *wrapper) GetToken(ctx context.Context, opts GetTokenOptions) (*Token, error) {
+	if opts.Audience != "" {
+		if err := provider.CheckAudienceSupported(w.inner.Name()); err != nil {
+			return nil, err
+		}
+	}
+
+	token, err := w.inner.GetToken(ctx, opts.internal())
+	if err != nil {
+		return nil, err
+	}
+	return newToken(token), nil
+}
+
+// GetClusterInfo implements Provider
+func (w *wrapper) GetClusterInfo(ctx context.Context, opts ClusterInfoOptions) (*ClusterInfo, error) {
+	describer, ok := w.inner.(provider.ClusterDescriber)
+	if !ok {
+		return nil, errors.New(
+			errors.ErrProviderNotSupported,
+			fmt.Sprintf("provider %s does not support cluster lookup", w.inner.Name()),
+		).WithField("provider", w.inner.Name())
+	}
+
+	if opts.EndpointAccess != "" {
+		if err := provider.CheckEndpointAccessSupported(w.inner.Name()); err != nil {
+			return nil, err
+		}
+	}
+
+	info, err := describer.DescribeCluster(ctx, provider.ClusterInfoOptions{
+		ClusterName:    opts.ClusterName,
+		Region:         opts.Region,
+		ResourceGroup:  opts.ResourceGroup,
+		EndpointAccess: opts.EndpointAccess,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ClusterInfo{
+		Endpoint:             info.Endpoint,
+		CertificateAuthority: info.CertificateAuthority,
+		Version:              info.Version,
+		Location:             info.Location,
+		Region:               info.Region,
+		ARN:                  info.ARN,
+		ResourceID:           info.ResourceID,
+	}, nil
+}
+
+// ValidateCredentials implements Provider
+func (w *wrapper) ValidateCredentials(ctx context.Context) error {
+	return w.inner.ValidateCredentials(ctx)
+}
+
+// Name implements Provider
+func (w *wrapper) Name() string {
+	return w.inner.Name()
+}
+
+// Close implements io.Closer
+func (w *wrapper) Close() error {
+	return provider.Close(w.inner)
+}
+
+func (c *Config) internal() *provider.Config {
+	return &provider.Config{
+		Region:               c.Region,
+		ProjectID:            c.ProjectID,
+		AccountID:            c.AccountID,
+		AWSProfile:           c.AWSProfile,
+		SubscriptionID:       c.SubscriptionID,
+		TenantID:             c.TenantID,
+		AzureCloud:           c.AzureCloud,
+		TenancyID:            c.TenancyID,
+		UserID:               c.UserID,
+		CompartmentID:        c.CompartmentID,
+		CredentialsFile:      c.CredentialsFile,
+		CredentialsDir:       c.CredentialsDir,
+		GCPUseADC:            c.GCPUseADC,
+		OIDCIssuerURL:        c.OIDCIssuerURL,
+		OIDCClientID:         c.OIDCClientID,
+		OIDCPrivateKeyFile:   c.OIDCPrivateKeyFile,
+		OIDCScopes:           c.OIDCScopes,
+		OIDCSubjectTokenFile: c.OIDCSubjectTokenFile,
+		OIDCSubjectTokenType: c.OIDCSubjectTokenType,
+		OIDCUseIDToken:       c.OIDCUseIDToken,
+		TokenDuration:        c.TokenDuration,
+		StrictPermissions:    c.StrictPermissions,
+		CredentialsSHA256:    c.CredentialsSHA256,
+		SkipCredentialCheck:  c.SkipCredentialCheck,
+		AWSFallbacks:         c.AWSFallbacks,
+		AWSSuggestRegion:     c.AWSSuggestRegion,
+		AzurePreferSecret:    c.AzurePreferSecret,
+		STSEndpoint:          c.STSEndpoint,
+		GKEEndpoint:          c.GKEEndpoint,
+		Tracing:              c.Tracing,
+		HTTPClient:           c.HTTPClient,
+		WatchCredentials:     c.WatchCredentials,
+		Metrics:              c.Metrics,
+	}
+}
+
+func newToken(t *provider.Token) *Token {
+	return &Token{
+		AccessToken: t.AccessToken,
+		ExpiresAt:   t.ExpiresAt,
+		TokenType:   t.TokenType,
+		Provider:    t.Provider,
+		ClusterName: t.ClusterName,
+		Identity:    t.Identity,
+		Audience:    t.Audience,
+		Scopes:      t.Scopes,
+	}
+}
+
+func (t *Token) internal() *provider.Token {
+	return &provider.Token{
+		AccessToken: t.AccessToken,
+		ExpiresAt:   t.ExpiresAt,
+		TokenType:   t.TokenType,
+		Provider:    t.Provider,
+		ClusterName: t.ClusterName,
+		Identity:    t.Identity,
+		Audience:    t.Audience,
+		Scopes:      t.Scopes,
+	}
+}
+
+func (o GetTokenOptions) internal() provider.GetTokenOptions {
+	return provider.GetTokenOptions{
+		ClusterName:    o.ClusterName,
+		Region:         o.Region,
+		ProjectID:      o.ProjectID,
+		AccountID:      o.AccountID,
+		ClusterID:      o.ClusterID,
+		SubscriptionID: o.SubscriptionID,
+		TenantID:       o.TenantID,
+		ResourceGroup:  o.ResourceGroup,
+		CompartmentID:  o.CompartmentID,
+		Audience:       o.Audience,
+	}
+}
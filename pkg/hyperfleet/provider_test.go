@@ -0,0 +1,283 @@
+package hyperfleet
+
+import (
+	"context"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+)
+
+func TestConstructors(t *testing.T) {
+	tests := []struct {
+		name        string
+		newProvider func(*Config, logger.Logger) (Provider, error)
+		config      *Config
+		wantName    string
+	}{
+		{
+			name:        "gcp",
+			newProvider: NewGCP,
+			config:      &Config{ProjectID: "my-project", GCPUseADC: "false", CredentialsFile: "/nonexistent/sa.json"},
+			wantName:    GCP,
+		},
+		{
+			name:        "aws",
+			newProvider: NewAWS,
+			config:      &Config{Region: "us-east-1"},
+			wantName:    AWS,
+		},
+		{
+			name:        "azure",
+			newProvider: NewAzure,
+			config:      &Config{TenantID: "tenant", SubscriptionID: "subscription"},
+			wantName:    Azure,
+		},
+		{
+			name:        "oci",
+			newProvider: NewOCI,
+			config:      &Config{Region: "us-ashburn-1"},
+			wantName:    OCI,
+		},
+		{
+			name:        "digitalocean with nil config and logger",
+			newProvider: NewDigitalOcean,
+			wantName:    DigitalOcean,
+		},
+		{
+			name:        "oidc",
+			newProvider: NewOIDC,
+			config:      &Config{OIDCIssuerURL: "https://issuer.example.com", OIDCClientID: "client"},
+			wantName:    OIDC,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prov, err := tt.newProvider(tt.config, nil)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantName, prov.Name())
+		})
+	}
+}
+
+func TestNew_Errors(t *testing.T) {
+	_, err := New("nonexistent", nil, logger.Nop())
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, errors.ErrProviderNotSupported))
+
+	_, err = NewGCP(&Config{ProjectID: "my-project", GCPUseADC: "sometimes"}, logger.Nop())
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, errors.ErrInvalidArgument))
+}
+
+func TestClose(t *testing.T) {
+	for _, watch := range []bool{true, false} {
+		prov, err := NewAWS(&Config{Region: "us-east-1", WatchCredentials: watch}, logger.Nop())
+		require.NoError(t, err)
+
+		closer, ok := prov.(io.Closer)
+		require.True(t, ok, "providers created by New implement io.Closer")
+		assert.NoError(t, closer.Close())
+	}
+
+	// Providers without credentials files have nothing to close
+	prov, err := NewDigitalOcean(nil, nil)
+	require.NoError(t, err)
+	assert.NoError(t, prov.(io.Closer).Close())
+}
+
+func TestProviders(t *testing.T) {
+	assert.Equal(t, []string{AWS, Azure, DigitalOcean, GCP, OCI, OIDC}, Providers())
+	assert.True(t, SupportsAudience(GCP))
+	assert.False(t, SupportsAudience(DigitalOcean))
+	assert.True(t, SupportsAudience(OIDC))
+}
+
+func TestWrapper_GetToken(t *testing.T) {
+	expiresAt := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	var got provider.GetTokenOptions
+	prov := wrap(&provider.MockProvider{
+		GetTokenFunc: func(ctx context.Context, opts provider.GetTokenOptions) (*provider.Token, error) {
+			got = opts
+			return &provider.Token{
+				AccessToken: "token",
+				ExpiresAt:   expiresAt,
+				TokenType:   "Bearer",
+				Provider:    "gcp",
+				ClusterName: "cluster",
+				Identity:    "d***@project.iam.gserviceaccount.com",
+				Scopes:      []string{"https://www.googleapis.com/auth/cloud-platform"},
+			}, nil
+		},
+	})
+
+	token, err := prov.GetToken(context.Background(), GetTokenOptions{
+		ClusterName:    "cluster",
+		Region:         "region",
+		ProjectID:      "project",
+		AccountID:      "account",
+		SubscriptionID: "subscription",
+		TenantID:       "tenant",
+		ResourceGroup:  "group",
+		CompartmentID:  "compartment",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, &Token{
+		AccessToken: "token",
+		ExpiresAt:   expiresAt,
+		TokenType:   "Bearer",
+		Provider:    "gcp",
+		ClusterName: "cluster",
+		Identity:    "d***@project.iam.gserviceaccount.com",
+		Scopes:      []string{"https://www.googleapis.com/auth/cloud-platform"},
+	}, token)
+	assert.Equal(t, provider.GetTokenOptions{
+		ClusterName:    "cluster",
+		Region:         "region",
+		ProjectID:      "project",
+		AccountID:      "account",
+		SubscriptionID: "subscription",
+		TenantID:       "tenant",
+		ResourceGroup:  "group",
+		CompartmentID:  "compartment",
+	}, got)
+}
+
+func TestWrapper_GetTokenRejectsUnsupportedAudience(t *testing.T) {
+	called := false
+	prov := wrap(&provider.MockProvider{
+		GetTokenFunc: func(ctx context.Context, opts provider.GetTokenOptions) (*provider.Token, error) {
+			called = true
+			return &provider.Token{}, nil
+		},
+	})
+
+	_, err := prov.GetToken(context.Background(), GetTokenOptions{ClusterName: "cluster", Audience: "sts.example.com"})
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, errors.ErrInvalidArgument))
+	assert.False(t, called)
+}
+
+func TestWrapper_GetClusterInfo(t *testing.T) {
+	prov := wrap(&describingProvider{info: &provider.ClusterInfo{
+		Endpoint:             "https://1.2.3.4",
+		CertificateAuthority: "Y2E=",
+		Version:              "1.30",
+		Region:               "us-east-1",
+		ARN:                  "arn:aws:eks:us-east-1:123456789012:cluster/cluster",
+	}})
+
+	info, err := prov.GetClusterInfo(context.Background(), ClusterInfoOptions{ClusterName: "cluster", Region: "us-east-1"})
+	require.NoError(t, err)
+	assert.Equal(t, &ClusterInfo{
+		Endpoint:             "https://1.2.3.4",
+		CertificateAuthority: "Y2E=",
+		Version:              "1.30",
+		Region:               "us-east-1",
+		ARN:                  "arn:aws:eks:us-east-1:123456789012:cluster/cluster",
+	}, info)
+
+	// Providers without cluster lookup report it rather than panicking
+	_, err = wrap(&provider.MockProvider{}).GetClusterInfo(context.Background(), ClusterInfoOptions{ClusterName: "cluster"})
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, errors.ErrProviderNotSupported))
+}
+
+// TestMirroredTypes fails when a field is added to an internal type without
+// adding it to its public mirror
+func TestMirroredTypes(t *testing.T) {
+	pairs := []struct {
+		public, internal interface{}
+	}{
+		{Config{}, provider.Config{}},
+		{GetTokenOptions{}, provider.GetTokenOptions{}},
+		{ClusterInfoOptions{}, provider.ClusterInfoOptions{}},
+		{ClusterInfo{}, provider.ClusterInfo{}},
+		{Token{}, provider.Token{}},
+	}
+
+	for _, pair := range pairs {
+		public, internal := reflect.TypeOf(pair.public), reflect.TypeOf(pair.internal)
+		t.Run(public.Name(), func(t *testing.T) {
+			require.Equal(t, internal.NumField(), public.NumField())
+			for i := 0; i < public.NumField(); i++ {
+				assert.Equal(t, internal.Field(i).Name, public.Field(i).Name)
+				assert.Equal(t, internal.Field(i).Type, public.Field(i).Type)
+			}
+		})
+	}
+}
+
+// TestPublicSurface_NoInternalTypes checks that callers never need an internal import
+func TestPublicSurface_NoInternalTypes(t *testing.T) {
+	types := []reflect.Type{
+		reflect.TypeOf((*Provider)(nil)).Elem(),
+		reflect.TypeOf(Config{}),
+		reflect.TypeOf(GetTokenOptions{}),
+		reflect.TypeOf(ClusterInfoOptions{}),
+		reflect.TypeOf(ClusterInfo{}),
+		reflect.TypeOf(&Token{}),
+		reflect.TypeOf(New),
+		reflect.TypeOf(NewGCP),
+		reflect.TypeOf(WithTokenStore),
+		reflect.TypeOf(NewDiskTokenStore),
+	}
+
+	var check func(t *testing.T, typ reflect.Type, seen map[reflect.Type]bool)
+	check = func(t *testing.T, typ reflect.Type, seen map[reflect.Type]bool) {
+		if seen[typ] {
+			return
+		}
+		seen[typ] = true
+		assert.NotContains(t, typ.PkgPath(), "/internal/", "%s leaks an internal type", typ)
+
+		switch typ.Kind() {
+		case reflect.Ptr, reflect.Slice:
+			check(t, typ.Elem(), seen)
+		case reflect.Struct:
+			for i := 0; i < typ.NumField(); i++ {
+				if typ.Field(i).IsExported() {
+					check(t, typ.Field(i).Type, seen)
+				}
+			}
+		case reflect.Func:
+			for i := 0; i < typ.NumIn(); i++ {
+				check(t, typ.In(i), seen)
+			}
+			for i := 0; i < typ.NumOut(); i++ {
+				check(t, typ.Out(i), seen)
+			}
+		case reflect.Interface:
+			if strings.HasPrefix(typ.PkgPath(), "github.com/openshift-hyperfleet/") {
+				for i := 0; i < typ.NumMethod(); i++ {
+					check(t, typ.Method(i).Type, seen)
+				}
+			}
+		}
+	}
+
+	seen := make(map[reflect.Type]bool)
+	for _, typ := range types {
+		check(t, typ, seen)
+	}
+}
+
+// describingProvider is a mock provider that also supports cluster lookup
+type describingProvider struct {
+	provider.MockProvider
+	info *provider.ClusterInfo
+}
+
+func (p *describingProvider) DescribeCluster(ctx context.Context, opts provider.ClusterInfoOptions) (*provider.ClusterInfo, error) {
+	return p.info, nil
+}
@@ -0,0 +1,123 @@
+package hyperfleet
+
+import (
+	"context"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+)
+
+// TokenStore persists tokens between GetToken calls, keyed by a fingerprint of the
+// provider name and token options. Implementations must be safe for concurrent use.
+type TokenStore interface {
+	// Get returns the stored token for key, or nil if there is none
+	Get(ctx context.Context, key string) (*Token, error)
+
+	// Put stores token under key, replacing any previous token
+	Put(ctx context.Context, key string, token *Token) error
+
+	// Delete removes the token stored under key; deleting a missing key is not an error
+	Delete(ctx context.Context, key string) error
+}
+
+// NewMemoryTokenStore creates an empty in-process token store
+func NewMemoryTokenStore() TokenStore {
+	return &publicStore{store: provider.NewMemoryTokenStore()}
+}
+
+// NewDiskTokenStore creates a token store that keeps one 0600 JSON file per token in
+// dir, creating dir with mode 0700 if needed
+func NewDiskTokenStore(dir string) (TokenStore, error) {
+	store, err := provider.NewDiskTokenStore(dir)
+	if err != nil {
+		return nil, err
+	}
+	return &publicStore{store: store}, nil
+}
+
+// WithTokenStore returns p with a GetToken that returns the token stored for the
+// options while p accepts it and it is outside the provider's refresh threshold, and
+// otherwise generates a token and stores it. Store failures are logged and never fail
+// GetToken. With Config.Metrics set, every token returned is recorded in the token
+// request duration histogram by cache outcome. p must have been created by New.
+func WithTokenStore(p Provider, store TokenStore) (Provider, error) {
+	w, ok := p.(*wrapper)
+	if !ok || store == nil {
+		return nil, errors.New(
+			errors.ErrInvalidArgument,
+			"WithTokenStore needs a provider created by New and a non-nil store",
+		)
+	}
+
+	tokens := provider.NewStoreRefresher(w.inner.Name(), w.inner, &internalStore{store: store}, w.log)
+	if w.metrics != nil {
+		tokens.WithRequestObserver(w.metrics)
+	}
+	return &storeWrapper{wrapper: w, tokens: tokens}, nil
+}
+
+// storeWrapper is a wrapper whose tokens are read through and written through a store
+type storeWrapper struct {
+	*wrapper
+	tokens *provider.StoreRefresher
+}
+
+// GetToken implements Provider
+func (w *storeWrapper) GetToken(ctx context.Context, opts GetTokenOptions) (*Token, error) {
+	if opts.Audience != "" {
+		if err := provider.CheckAudienceSupported(w.inner.Name()); err != nil {
+			return nil, err
+		}
+	}
+
+	token, err := w.tokens.RefreshToken(ctx, opts.internal())
+	if err != nil {
+		return nil, err
+	}
+	return newToken(token), nil
+}
+
+// publicStore adapts an internal token store to TokenStore
+type publicStore struct {
+	store provider.TokenStore
+}
+
+func (s *publicStore) Get(ctx context.Context, key string) (*Token, error) {
+	token, err := s.store.Get(ctx, key)
+	if err != nil || token == nil {
+		return nil, err
+	}
+	return newToken(token), nil
+}
+
+func (s *publicStore) Put(ctx context.Context, key string, token *Token) error {
+	if token == nil {
+		return s.store.Put(ctx, key, nil)
+	}
+	return s.store.Put(ctx, key, token.internal())
+}
+
+func (s *publicStore) Delete(ctx context.Context, key string) error {
+	return s.store.Delete(ctx, key)
+}
+
+// internalStore adapts a TokenStore to the internal token store interface
+type internalStore struct {
+	store TokenStore
+}
+
+func (s *internalStore) Get(ctx context.Context, key string) (*provider.Token, error) {
+	token, err := s.store.Get(ctx, key)
+	if err != nil || token == nil {
+		return nil, err
+	}
+	return token.internal(), nil
+}
+
+func (s *internalStore) Put(ctx context.Context, key string, token *provider.Token) error {
+	return s.store.Put(ctx, key, newToken(token))
+}
+
+func (s *internalStore) Delete(ctx context.Context, key string) error {
+	return s.store.Delete(ctx, key)
+}
@@ -0,0 +1,65 @@
+package hyperfleet
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+)
+
+func TestWithTokenStore(t *testing.T) {
+	disk, err := NewDiskTokenStore(filepath.Join(t.TempDir(), "tokens"))
+	require.NoError(t, err)
+
+	for name, store := range map[string]TokenStore{"memory": NewMemoryTokenStore(), "disk": disk} {
+		t.Run(name, func(t *testing.T) {
+			generated := 0
+			prov, err := WithTokenStore(wrap(&provider.MockProvider{
+				GetTokenFunc: func(ctx context.Context, opts provider.GetTokenOptions) (*provider.Token, error) {
+					generated++
+					return &provider.Token{
+						AccessToken: "token-" + opts.ClusterName,
+						ExpiresAt:   time.Now().Add(time.Hour),
+						TokenType:   "Bearer",
+						ClusterName: opts.ClusterName,
+					}, nil
+				},
+			}), store)
+			require.NoError(t, err)
+
+			first, err := prov.GetToken(context.Background(), GetTokenOptions{ClusterName: "cluster"})
+			require.NoError(t, err)
+			second, err := prov.GetToken(context.Background(), GetTokenOptions{ClusterName: "cluster"})
+			require.NoError(t, err)
+			assert.Equal(t, first.AccessToken, second.AccessToken)
+			assert.Equal(t, "cluster", second.ClusterName)
+			assert.Equal(t, 1, generated, "the stored token is reused")
+
+			other, err := prov.GetToken(context.Background(), GetTokenOptions{ClusterName: "other"})
+			require.NoError(t, err)
+			assert.Equal(t, "token-other", other.AccessToken)
+			assert.Equal(t, 2, generated)
+		})
+	}
+}
+
+func TestWithTokenStore_InvalidArguments(t *testing.T) {
+	_, err := WithTokenStore(wrap(&provider.MockProvider{}), nil)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, errors.ErrInvalidArgument))
+
+	_, err = WithTokenStore(foreignProvider{}, NewMemoryTokenStore())
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, errors.ErrInvalidArgument))
+}
+
+// foreignProvider implements Provider without New
+type foreignProvider struct {
+	Provider
+}
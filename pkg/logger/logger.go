@@ -2,6 +2,7 @@ package logger
 
 import (
 	"context"
+	"time"
 )
 
 // Logger defines the interface for structured logging
@@ -103,6 +104,29 @@ type Config struct {
 	Level  Level
 	Format Format
 	Output interface{} // io.Writer, defaults to os.Stderr
+
+	// Sampling drops repeated messages; nil logs every message
+	Sampling *SamplingConfig
+
+	// DisableCaller omits the file:line of the logging call
+	DisableCaller bool
+
+	// DisableStacktrace omits the stack trace logged with error messages
+	DisableStacktrace bool
+}
+
+// SamplingConfig samples messages with the same level and text: within each Tick the
+// first Initial are logged, then every Thereafter-th. Fields are not compared, so
+// "Token generated" lines of different clusters are sampled together.
+type SamplingConfig struct {
+	Initial    int
+	Thereafter int
+	Tick       time.Duration
+}
+
+// DefaultSampling logs the first 100 identical messages of each second, then every 100th
+func DefaultSampling() *SamplingConfig {
+	return &SamplingConfig{Initial: 100, Thereafter: 100, Tick: time.Second}
 }
 
 // DefaultConfig returns the default logger configuration
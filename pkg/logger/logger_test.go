@@ -1,7 +1,11 @@
 package logger
 
 import (
+	"bytes"
+	"encoding/json"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -173,3 +177,106 @@ func TestMustNew(t *testing.T) {
 		})
 	})
 }
+
+// logLines decodes the JSON lines written to buf
+func logLines(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+
+	var lines []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var entry map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		lines = append(lines, entry)
+	}
+	return lines
+}
+
+func TestSampling(t *testing.T) {
+	var buf bytes.Buffer
+	log := MustNew(Config{
+		Level:    InfoLevel,
+		Format:   JSONFormat,
+		Output:   &buf,
+		Sampling: &SamplingConfig{Initial: 3, Thereafter: 5, Tick: time.Hour},
+	})
+
+	for i := 0; i < 20; i++ {
+		log.Info("Token generated", Int("i", i))
+	}
+	log.Info("Cluster described")
+
+	var generated []float64
+	described := 0
+	for _, entry := range logLines(t, &buf) {
+		switch entry["msg"] {
+		case "Token generated":
+			generated = append(generated, entry["i"].(float64))
+		case "Cluster described":
+			described++
+		}
+	}
+	// The first 3, then every 5th of the rest: the 8th, 13th and 18th
+	assert.Equal(t, []float64{0, 1, 2, 7, 12, 17}, generated)
+	assert.Equal(t, 1, described, "other messages are sampled separately")
+}
+
+func TestWith_BoundFields(t *testing.T) {
+	var buf bytes.Buffer
+	log := MustNew(Config{Level: InfoLevel, Format: JSONFormat, Output: &buf})
+
+	child := log.With(String("provider", "gcp")).With(String("cluster", "my-cluster"))
+	child.Info("Token generated", Int("expires_in", 3600))
+	log.Info("Unbound")
+
+	lines := logLines(t, &buf)
+	require.Len(t, lines, 2)
+	assert.Equal(t, "gcp", lines[0]["provider"])
+	assert.Equal(t, "my-cluster", lines[0]["cluster"])
+	assert.Equal(t, float64(3600), lines[0]["expires_in"])
+	assert.NotContains(t, lines[1], "provider", "the parent logger is not changed")
+}
+
+func TestCallerAndStacktrace(t *testing.T) {
+	tests := []struct {
+		name           string
+		config         Config
+		wantCaller     bool
+		wantStacktrace bool
+	}{
+		{
+			name:           "default",
+			config:         Config{},
+			wantCaller:     true,
+			wantStacktrace: true,
+		},
+		{
+			name:   "disabled",
+			config: Config{DisableCaller: true, DisableStacktrace: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			tt.config.Output = &buf
+			log := MustNew(tt.config)
+			log.Error("Token generation failed")
+
+			lines := logLines(t, &buf)
+			require.Len(t, lines, 1)
+			if tt.wantCaller {
+				assert.Contains(t, lines[0]["caller"], "logger/logger_test.go:", "the caller is the logging call, not the wrapper")
+			} else {
+				assert.NotContains(t, lines[0], "caller")
+			}
+			if tt.wantStacktrace {
+				assert.Contains(t, lines[0], "stacktrace")
+			} else {
+				assert.NotContains(t, lines[0], "stacktrace")
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package logger
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"strings"
+)
+
+// mask replaces the hidden part of a redacted value
+const mask = "***"
+
+// keptQueryParams are the presigned URL parameters PresignedURL keeps: when the URL
+// was signed and how long it is valid
+var keptQueryParams = []string{"X-Amz-Date", "X-Amz-Expires", "Expires"}
+
+// presignedParams are the presigned URL parameters PresignedURLParams logs as is, by
+// field key
+var presignedParams = []struct{ param, key string }{
+	{"Action", "action"},
+	{"Version", "version"},
+	{"X-Amz-Expires", "expires"},
+	{"X-Amz-SignedHeaders", "signed_headers"},
+}
+
+// Secret creates a field that identifies value by a short SHA-256 digest without
+// revealing it, so that two log lines can be matched up
+func Secret(key, value string) Field {
+	return Field{Key: key, Value: RedactSecret(value)}
+}
+
+// Email creates a field with the local part of an email address masked
+func Email(key, value string) Field {
+	return Field{Key: key, Value: RedactEmail(value)}
+}
+
+// AccessKeyID creates a field with all but the first 4 characters of an access key
+// ID masked, which keeps the key type prefix (AKIA for long-lived, ASIA for session keys)
+func AccessKeyID(key, value string) Field {
+	return Field{Key: key, Value: RedactAccessKeyID(value)}
+}
+
+// ID creates a field with all but the last 4 characters of an identifier masked,
+// for tenant, client and user IDs
+func ID(key, value string) Field {
+	return Field{Key: key, Value: RedactID(value)}
+}
+
+// PresignedURL creates a field with only the host and expiry parameters of a
+// presigned URL; the credential, signature and security token are dropped
+func PresignedURL(key, value string) Field {
+	return Field{Key: key, Value: RedactPresignedURL(value)}
+}
+
+// PresignedURLParams creates fields for the query parameters of a presigned URL that
+// explain a rejected request: the action, API version, expiry and signed headers as is,
+// the credential scope with the access key ID masked as by AccessKeyID, and the
+// signature masked. A value that does not parse as an absolute URL yields no fields.
+func PresignedURLParams(value string) []Field {
+	u, err := url.Parse(value)
+	if err != nil || u.Host == "" {
+		return nil
+	}
+
+	query := u.Query()
+	var fields []Field
+	for _, p := range presignedParams {
+		if v := query.Get(p.param); v != "" {
+			fields = append(fields, Field{Key: p.key, Value: v})
+		}
+	}
+	if credential := query.Get("X-Amz-Credential"); credential != "" {
+		// The scope after the access key ID is date/region/service/aws4_request
+		accessKeyID, scope, _ := strings.Cut(credential, "/")
+		fields = append(fields, Field{Key: "credential", Value: RedactAccessKeyID(accessKeyID) + "/" + scope})
+	}
+	if query.Get("X-Amz-Signature") != "" {
+		fields = append(fields, Field{Key: "signature", Value: mask})
+	}
+	return fields
+}
+
+// RedactSecret returns "sha256:" and the first 8 hex digits of the digest of value
+func RedactSecret(value string) string {
+	if value == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(value))
+	return "sha256:" + hex.EncodeToString(sum[:4])
+}
+
+// RedactEmail masks the local part of an email address, e.g.
+// deployer@my-project.iam.gserviceaccount.com becomes d***@my-project.iam.gserviceaccount.com.
+// A value without a domain is masked entirely.
+func RedactEmail(value string) string {
+	if value == "" {
+		return ""
+	}
+	local, domain, ok := strings.Cut(value, "@")
+	if !ok || local == "" {
+		return mask
+	}
+	return local[:1] + mask + "@" + domain
+}
+
+// RedactAccessKeyID keeps the first 4 characters of an access key ID
+func RedactAccessKeyID(value string) string {
+	if value == "" {
+		return ""
+	}
+	if len(value) <= 8 {
+		return mask
+	}
+	return value[:4] + mask
+}
+
+// RedactID keeps the last 4 characters of an identifier
+func RedactID(value string) string {
+	if value == "" {
+		return ""
+	}
+	if len(value) <= 8 {
+		return mask
+	}
+	return mask + value[len(value)-4:]
+}
+
+// RedactPresignedURL keeps the scheme, host and expiry parameters of a presigned URL.
+// A value that does not parse as an absolute URL is masked entirely.
+func RedactPresignedURL(value string) string {
+	if value == "" {
+		return ""
+	}
+	u, err := url.Parse(value)
+	if err != nil || u.Host == "" {
+		return mask
+	}
+
+	query := u.Query()
+	kept := url.Values{}
+	for _, name := range keptQueryParams {
+		if v := query.Get(name); v != "" {
+			kept.Set(name, v)
+		}
+	}
+
+	redacted := url.URL{Scheme: u.Scheme, Host: u.Host, Path: u.Path, RawQuery: kept.Encode()}
+	return redacted.String()
+}
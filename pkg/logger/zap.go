@@ -4,6 +4,7 @@ import (
 	"context"
 	"io"
 	"os"
+	"time"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -61,13 +62,26 @@ func NewZapLogger(config Config) (Logger, error) {
 		zapcore.AddSync(writer),
 		level,
 	)
+	if s := config.Sampling; s != nil {
+		tick := s.Tick
+		if tick <= 0 {
+			tick = time.Second
+		}
+		core = zapcore.NewSamplerWithOptions(core, tick, s.Initial, s.Thereafter)
+	}
 
 	// Build logger
-	logger := zap.New(core,
-		zap.AddCaller(),
-		zap.AddCallerSkip(1), // Skip wrapper functions
-		zap.AddStacktrace(zapcore.ErrorLevel),
-	)
+	var options []zap.Option
+	if !config.DisableCaller {
+		options = append(options,
+			zap.AddCaller(),
+			zap.AddCallerSkip(1), // Skip wrapper functions
+		)
+	}
+	if !config.DisableStacktrace {
+		options = append(options, zap.AddStacktrace(zapcore.ErrorLevel))
+	}
+	logger := zap.New(core, options...)
 
 	return &zapLogger{logger: logger}, nil
 }
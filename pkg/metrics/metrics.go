@@ -7,19 +7,33 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promauto"
 )
 
-// Metrics holds all Prometheus metrics for the cloud provider
+// Metrics holds all Prometheus metrics for the cloud provider. A nil *Metrics records
+// nothing, for commands run with metrics disabled.
 type Metrics struct {
 	// Token generation metrics
 	TokenRequestsTotal       *prometheus.CounterVec
 	TokenGenerationDuration  *prometheus.HistogramVec
 	TokenGenerationErrors    *prometheus.CounterVec
+	TokenRequestDuration     *prometheus.HistogramVec
+	TokenFallbackTotal       *prometheus.CounterVec
+	TokenExpirySeconds       *prometheus.GaugeVec
+	InflightTokenRequests    *prometheus.GaugeVec
+	LastSuccessfulToken      *prometheus.GaugeVec
 
 	// Credential validation metrics
 	CredentialValidationErrors *prometheus.CounterVec
+	CredentialReloads          *prometheus.CounterVec
 
 	// Health check metrics
 	HealthCheckDuration *prometheus.HistogramVec
 	HealthCheckErrors   *prometheus.CounterVec
+
+	// Throttling metrics
+	BreakerState           *prometheus.GaugeVec
+	ThrottledRequestsTotal *prometheus.CounterVec
+
+	// clusterLabel reports whether the token gauges have a cluster label
+	clusterLabel bool
 }
 
 // Config holds configuration for metrics
@@ -32,6 +46,10 @@ type Config struct {
 
 	// Registry to use (default: prometheus.DefaultRegisterer)
 	Registry prometheus.Registerer
+
+	// DisableClusterLabel drops the cluster label of the token expiry and last success
+	// gauges, which then hold the latest token of each provider, for large fleets
+	DisableClusterLabel bool
 }
 
 // DefaultConfig returns default metrics configuration
@@ -54,7 +72,14 @@ func NewMetrics(config Config) *Metrics {
 
 	factory := promauto.With(config.Registry)
 
+	tokenLabels := []string{"provider", "cluster"}
+	if config.DisableClusterLabel {
+		tokenLabels = []string{"provider"}
+	}
+
 	return &Metrics{
+		clusterLabel: !config.DisableClusterLabel,
+
 		TokenRequestsTotal: factory.NewCounterVec(
 			prometheus.CounterOpts{
 				Namespace: config.Namespace,
@@ -86,6 +111,58 @@ func NewMetrics(config Config) *Metrics {
 			[]string{"provider", "error_type"},
 		),
 
+		// No cluster label: the cardinality stays bounded by providers times outcomes
+		TokenRequestDuration: factory.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: config.Namespace,
+				Subsystem: config.Subsystem,
+				Name:      "token_request_duration_seconds",
+				Help:      "End-to-end token request duration in seconds, by token cache outcome",
+				Buckets:   []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+			},
+			[]string{"provider", "cache_outcome"},
+		),
+
+		TokenFallbackTotal: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: config.Namespace,
+				Subsystem: config.Subsystem,
+				Name:      "token_fallback_total",
+				Help:      "Total number of tokens generated with a fallback credential source after the primary failed",
+			},
+			[]string{"provider", "entry"},
+		),
+
+		TokenExpirySeconds: factory.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: config.Namespace,
+				Subsystem: config.Subsystem,
+				Name:      "token_expiry_seconds",
+				Help:      "Seconds until the last generated token expires, measured when it was generated",
+			},
+			tokenLabels,
+		),
+
+		InflightTokenRequests: factory.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: config.Namespace,
+				Subsystem: config.Subsystem,
+				Name:      "inflight_token_requests",
+				Help:      "Number of token generations in progress",
+			},
+			[]string{"provider"},
+		),
+
+		LastSuccessfulToken: factory.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: config.Namespace,
+				Subsystem: config.Subsystem,
+				Name:      "last_successful_token_timestamp",
+				Help:      "Unix time of the last successful token generation",
+			},
+			tokenLabels,
+		),
+
 		CredentialValidationErrors: factory.NewCounterVec(
 			prometheus.CounterOpts{
 				Namespace: config.Namespace,
@@ -116,39 +193,168 @@ func NewMetrics(config Config) *Metrics {
 			},
 			[]string{"check_name"},
 		),
+
+		BreakerState: factory.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: config.Namespace,
+				Subsystem: config.Subsystem,
+				Name:      "breaker_state",
+				Help:      "Token request circuit breaker state per cluster (0 closed, 1 open, 2 half-open)",
+			},
+			[]string{"provider", "cluster"},
+		),
+
+		ThrottledRequestsTotal: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: config.Namespace,
+				Subsystem: config.Subsystem,
+				Name:      "throttled_requests_total",
+				Help:      "Total number of token requests rejected by the rate limiter or an open circuit breaker",
+			},
+			[]string{"provider", "reason"},
+		),
+
+		CredentialReloads: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: config.Namespace,
+				Subsystem: config.Subsystem,
+				Name:      "credential_reloads_total",
+				Help:      "Total number of reloads of rotated credentials files by outcome",
+			},
+			[]string{"provider", "status"},
+		),
 	}
 }
 
 // RecordTokenRequest records a token generation request
 func (m *Metrics) RecordTokenRequest(provider, status string) {
+	if m == nil {
+		return
+	}
 	m.TokenRequestsTotal.WithLabelValues(provider, status).Inc()
 }
 
 // RecordTokenGenerationDuration records the duration of token generation
 func (m *Metrics) RecordTokenGenerationDuration(provider string, duration time.Duration) {
+	if m == nil {
+		return
+	}
 	m.TokenGenerationDuration.WithLabelValues(provider).Observe(duration.Seconds())
 }
 
 // RecordTokenGenerationError records a token generation error
 func (m *Metrics) RecordTokenGenerationError(provider, errorType string) {
+	if m == nil {
+		return
+	}
 	m.TokenGenerationErrors.WithLabelValues(provider, errorType).Inc()
 }
 
+// RecordTokenRequestDuration records the end-to-end duration of a token request and
+// whether it was served from the token cache
+func (m *Metrics) RecordTokenRequestDuration(provider, cacheOutcome string, duration time.Duration) {
+	if m == nil {
+		return
+	}
+	m.TokenRequestDuration.WithLabelValues(provider, cacheOutcome).Observe(duration.Seconds())
+}
+
+// RecordTokenFallback records a token generated with the fallback credential source entry
+func (m *Metrics) RecordTokenFallback(provider, entry string) {
+	if m == nil {
+		return
+	}
+	m.TokenFallbackTotal.WithLabelValues(provider, entry).Inc()
+}
+
+// SetTokenExpiry records the lifetime left to a token just generated for cluster
+func (m *Metrics) SetTokenExpiry(provider, cluster string, expiresIn time.Duration) {
+	if m == nil {
+		return
+	}
+	m.TokenExpirySeconds.WithLabelValues(m.tokenLabels(provider, cluster)...).Set(expiresIn.Seconds())
+}
+
+// IncInflight records the start of a token generation
+func (m *Metrics) IncInflight(provider string) {
+	if m == nil {
+		return
+	}
+	m.InflightTokenRequests.WithLabelValues(provider).Inc()
+}
+
+// DecInflight records the end of a token generation started with IncInflight
+func (m *Metrics) DecInflight(provider string) {
+	if m == nil {
+		return
+	}
+	m.InflightTokenRequests.WithLabelValues(provider).Dec()
+}
+
+// SetLastSuccess records the time of the last token generated for cluster
+func (m *Metrics) SetLastSuccess(provider, cluster string, at time.Time) {
+	if m == nil {
+		return
+	}
+	m.LastSuccessfulToken.WithLabelValues(m.tokenLabels(provider, cluster)...).Set(float64(at.Unix()))
+}
+
+// tokenLabels returns the label values of the token gauges
+func (m *Metrics) tokenLabels(provider, cluster string) []string {
+	if !m.clusterLabel {
+		return []string{provider}
+	}
+	return []string{provider, cluster}
+}
+
 // RecordCredentialValidationError records a credential validation error
 func (m *Metrics) RecordCredentialValidationError(provider string) {
+	if m == nil {
+		return
+	}
 	m.CredentialValidationErrors.WithLabelValues(provider).Inc()
 }
 
+// RecordCredentialReload records a reload of a rotated credentials file
+func (m *Metrics) RecordCredentialReload(provider, status string) {
+	if m == nil {
+		return
+	}
+	m.CredentialReloads.WithLabelValues(provider, status).Inc()
+}
+
 // RecordHealthCheckDuration records the duration of a health check
 func (m *Metrics) RecordHealthCheckDuration(checkName string, duration time.Duration) {
+	if m == nil {
+		return
+	}
 	m.HealthCheckDuration.WithLabelValues(checkName).Observe(duration.Seconds())
 }
 
 // RecordHealthCheckError records a health check error
 func (m *Metrics) RecordHealthCheckError(checkName string) {
+	if m == nil {
+		return
+	}
 	m.HealthCheckErrors.WithLabelValues(checkName).Inc()
 }
 
+// SetBreakerState records the circuit breaker state of a cluster
+func (m *Metrics) SetBreakerState(provider, cluster string, state int) {
+	if m == nil {
+		return
+	}
+	m.BreakerState.WithLabelValues(provider, cluster).Set(float64(state))
+}
+
+// RecordThrottledRequest records a token request rejected for reason
+func (m *Metrics) RecordThrottledRequest(provider, reason string) {
+	if m == nil {
+		return
+	}
+	m.ThrottledRequestsTotal.WithLabelValues(provider, reason).Inc()
+}
+
 // Timer is a helper for timing operations
 type Timer struct {
 	start time.Time
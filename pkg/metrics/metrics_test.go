@@ -24,9 +24,13 @@ func TestNewMetrics(t *testing.T) {
 	assert.NotNil(t, m.TokenRequestsTotal)
 	assert.NotNil(t, m.TokenGenerationDuration)
 	assert.NotNil(t, m.TokenGenerationErrors)
+	assert.NotNil(t, m.TokenRequestDuration)
 	assert.NotNil(t, m.CredentialValidationErrors)
+	assert.NotNil(t, m.CredentialReloads)
 	assert.NotNil(t, m.HealthCheckDuration)
 	assert.NotNil(t, m.HealthCheckErrors)
+	assert.NotNil(t, m.BreakerState)
+	assert.NotNil(t, m.ThrottledRequestsTotal)
 }
 
 func TestDefaultConfig(t *testing.T) {
@@ -99,6 +103,44 @@ func TestRecordTokenGenerationDuration(t *testing.T) {
 	assert.True(t, found, "token_generation_duration_seconds metric not found")
 }
 
+func TestRecordTokenRequestDuration(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	config := Config{
+		Namespace: "test",
+		Registry:  registry,
+	}
+
+	m := NewMetrics(config)
+
+	// Record durations
+	m.RecordTokenRequestDuration("aws", "hit", 2*time.Millisecond)
+	m.RecordTokenRequestDuration("aws", "hit", 3*time.Millisecond)
+	m.RecordTokenRequestDuration("aws", "miss", 400*time.Millisecond)
+	m.RecordTokenRequestDuration("gcp", "stale_refresh", 300*time.Millisecond)
+
+	// Verify metrics can be collected
+	metricFamilies, err := registry.Gather()
+	require.NoError(t, err)
+
+	// Find the request duration histogram
+	found := false
+	for _, mf := range metricFamilies {
+		if mf.GetName() != "test_token_request_duration_seconds" {
+			continue
+		}
+		found = true
+		assert.Equal(t, 3, len(mf.GetMetric())) // aws-hit, aws-miss, gcp-stale_refresh
+		for _, metric := range mf.GetMetric() {
+			labels := make([]string, 0, len(metric.GetLabel()))
+			for _, label := range metric.GetLabel() {
+				labels = append(labels, label.GetName())
+			}
+			assert.ElementsMatch(t, []string{"provider", "cache_outcome"}, labels)
+		}
+	}
+	assert.True(t, found, "token_request_duration_seconds metric not found")
+}
+
 func TestRecordTokenGenerationError(t *testing.T) {
 	registry := prometheus.NewRegistry()
 	config := Config{
@@ -219,6 +261,108 @@ func TestRecordHealthCheckError(t *testing.T) {
 	assert.True(t, found, "health_check_errors_total metric not found")
 }
 
+func TestThrottleMetrics(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	m := NewMetrics(Config{Namespace: "test", Registry: registry})
+
+	m.SetBreakerState("aws", "eks-prod", 1)
+	m.SetBreakerState("aws", "eks-dev", 0)
+	m.RecordThrottledRequest("aws", "rate_limit")
+	m.RecordThrottledRequest("aws", "rate_limit")
+	m.RecordThrottledRequest("aws", "circuit_open")
+
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.BreakerState.WithLabelValues("aws", "eks-prod")))
+	assert.Equal(t, float64(0), testutil.ToFloat64(m.BreakerState.WithLabelValues("aws", "eks-dev")))
+	assert.Equal(t, float64(2), testutil.ToFloat64(m.ThrottledRequestsTotal.WithLabelValues("aws", "rate_limit")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.ThrottledRequestsTotal.WithLabelValues("aws", "circuit_open")))
+}
+
+func TestTokenFallbackMetrics(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	m := NewMetrics(Config{Namespace: "test", Registry: registry})
+
+	m.RecordTokenFallback("aws", "profile=backup")
+	m.RecordTokenFallback("aws", "profile=backup")
+	m.RecordTokenFallback("aws", "region=us-west-2")
+
+	assert.Equal(t, float64(2), testutil.ToFloat64(m.TokenFallbackTotal.WithLabelValues("aws", "profile=backup")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.TokenFallbackTotal.WithLabelValues("aws", "region=us-west-2")))
+}
+
+func TestNilMetrics(t *testing.T) {
+	var m *Metrics
+	assert.NotPanics(t, func() {
+		m.RecordTokenRequest("aws", "success")
+		m.RecordTokenFallback("aws", "profile=backup")
+		m.RecordCredentialValidationError("aws")
+		m.SetBreakerState("aws", "eks-prod", 1)
+		m.RecordThrottledRequest("aws", "rate_limit")
+		m.SetTokenExpiry("aws", "eks-prod", 15*time.Minute)
+		m.IncInflight("aws")
+		m.DecInflight("aws")
+		m.SetLastSuccess("aws", "eks-prod", time.Now())
+	})
+}
+
+func TestTokenGauges(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	m := NewMetrics(Config{Namespace: "test", Registry: registry})
+
+	m.SetTokenExpiry("gcp", "gke-prod", time.Hour)
+	m.SetTokenExpiry("aws", "eks-prod", 14*time.Minute+30*time.Second)
+	m.SetLastSuccess("aws", "eks-prod", time.Unix(1700000000, 0))
+	m.IncInflight("aws")
+	m.IncInflight("aws")
+	m.DecInflight("aws")
+	m.IncInflight("gcp")
+
+	expected := `
+		# HELP test_token_expiry_seconds Seconds until the last generated token expires, measured when it was generated
+		# TYPE test_token_expiry_seconds gauge
+		test_token_expiry_seconds{cluster="eks-prod",provider="aws"} 870
+		test_token_expiry_seconds{cluster="gke-prod",provider="gcp"} 3600
+	`
+	require.NoError(t, testutil.CollectAndCompare(m.TokenExpirySeconds, strings.NewReader(expected)))
+
+	expected = `
+		# HELP test_inflight_token_requests Number of token generations in progress
+		# TYPE test_inflight_token_requests gauge
+		test_inflight_token_requests{provider="aws"} 1
+		test_inflight_token_requests{provider="gcp"} 1
+	`
+	require.NoError(t, testutil.CollectAndCompare(m.InflightTokenRequests, strings.NewReader(expected)))
+
+	expected = `
+		# HELP test_last_successful_token_timestamp Unix time of the last successful token generation
+		# TYPE test_last_successful_token_timestamp gauge
+		test_last_successful_token_timestamp{cluster="eks-prod",provider="aws"} 1.7e+09
+	`
+	require.NoError(t, testutil.CollectAndCompare(m.LastSuccessfulToken, strings.NewReader(expected)))
+}
+
+func TestTokenGauges_DisableClusterLabel(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	m := NewMetrics(Config{Namespace: "test", Registry: registry, DisableClusterLabel: true})
+
+	m.SetTokenExpiry("aws", "eks-prod", time.Hour)
+	m.SetTokenExpiry("aws", "eks-dev", 10*time.Minute)
+	m.SetLastSuccess("aws", "eks-dev", time.Unix(1700000000, 0))
+
+	expected := `
+		# HELP test_token_expiry_seconds Seconds until the last generated token expires, measured when it was generated
+		# TYPE test_token_expiry_seconds gauge
+		test_token_expiry_seconds{provider="aws"} 600
+	`
+	require.NoError(t, testutil.CollectAndCompare(m.TokenExpirySeconds, strings.NewReader(expected)))
+
+	expected = `
+		# HELP test_last_successful_token_timestamp Unix time of the last successful token generation
+		# TYPE test_last_successful_token_timestamp gauge
+		test_last_successful_token_timestamp{provider="aws"} 1.7e+09
+	`
+	require.NoError(t, testutil.CollectAndCompare(m.LastSuccessfulToken, strings.NewReader(expected)))
+}
+
 func TestTimer(t *testing.T) {
 	timer := NewTimer()
 	require.NotNil(t, timer)
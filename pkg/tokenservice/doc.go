@@ -0,0 +1,6 @@
+// Package tokenservice is the gRPC TokenService served by serve --grpc-address: the
+// generated client and server of tokenservice.proto, and the mapping of pkg/errors
+// codes to gRPC status codes.
+package tokenservice
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative tokenservice.proto
@@ -0,0 +1,129 @@
+package tokenservice
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+)
+
+// ErrorDomain is the domain of the ErrorInfo detail of TokenService errors
+const ErrorDomain = "hyperfleet.io"
+
+// Status converts err to a gRPC status. The status code follows the pkg/errors code of
+// err, and an ErrorInfo detail carries that code as its reason and the non-sensitive
+// fields of the error as its metadata. A rate limit error with a Retry-After hint also
+// carries a RetryInfo detail.
+func Status(err error) *status.Status {
+	if err == nil {
+		return status.New(codes.OK, "")
+	}
+	if _, ok := status.FromError(err); ok {
+		return status.Convert(err)
+	}
+	if stderrors.Is(err, context.Canceled) && !errors.As(err, new(*errors.Error)) {
+		return status.New(codes.Canceled, err.Error())
+	}
+
+	code := errors.GetCode(err)
+	if stderrors.Is(err, context.DeadlineExceeded) && code == errors.ErrUnknown {
+		code = errors.ErrNetworkTimeout
+	}
+
+	info := &errdetails.ErrorInfo{
+		Reason: string(code),
+		Domain: ErrorDomain,
+	}
+	var appErr *errors.Error
+	if errors.As(err, &appErr) {
+		for key, value := range appErr.Redact().Fields {
+			if info.Metadata == nil {
+				info.Metadata = make(map[string]string)
+			}
+			info.Metadata[key] = fmt.Sprint(value)
+		}
+	}
+
+	details := []protoadapt.MessageV1{info}
+	if wait, ok := provider.RetryAfter(err, time.Now()); ok {
+		details = append(details, &errdetails.RetryInfo{RetryDelay: durationpb.New(wait)})
+	}
+
+	st := status.New(grpcCode(code), err.Error())
+	if withDetails, detailsErr := st.WithDetails(details...); detailsErr == nil {
+		return withDetails
+	}
+	return st
+}
+
+// RetryDelay returns how long to wait before retrying the call that failed with err,
+// from its RetryInfo detail. The bool is false when err carries no RetryInfo.
+func RetryDelay(err error) (time.Duration, bool) {
+	st, ok := status.FromError(err)
+	if !ok {
+		return 0, false
+	}
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.RetryInfo); ok && info.GetRetryDelay() != nil {
+			return info.GetRetryDelay().AsDuration(), true
+		}
+	}
+	return 0, false
+}
+
+// ErrorCode returns the pkg/errors code carried by a TokenService error, or ErrUnknown
+// when err has no ErrorInfo detail of ErrorDomain
+func ErrorCode(err error) errors.ErrorCode {
+	st, ok := status.FromError(err)
+	if !ok {
+		return errors.ErrUnknown
+	}
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok && info.GetDomain() == ErrorDomain {
+			return errors.ErrorCode(info.GetReason())
+		}
+	}
+	return errors.ErrUnknown
+}
+
+// grpcCode maps a pkg/errors code to the gRPC status code of the same category
+func grpcCode(code errors.ErrorCode) codes.Code {
+	switch code {
+	case errors.ErrUnknown:
+		return codes.Unknown
+	case errors.ErrNetworkTimeout:
+		return codes.DeadlineExceeded
+	case errors.ErrAlreadyExists:
+		return codes.AlreadyExists
+	case errors.ErrFileLocked:
+		return codes.Aborted
+	case errors.ErrProviderNotSupported:
+		return codes.Unimplemented
+	}
+
+	switch errors.GetErrorInfo(code).Status {
+	case 400:
+		return codes.InvalidArgument
+	case 401:
+		return codes.Unauthenticated
+	case 403:
+		return codes.PermissionDenied
+	case 404:
+		return codes.NotFound
+	case 429:
+		return codes.ResourceExhausted
+	case 503:
+		return codes.Unavailable
+	default:
+		return codes.Internal
+	}
+}
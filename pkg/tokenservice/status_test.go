@@ -0,0 +1,93 @@
+package tokenservice
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
+)
+
+func TestStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		wantCode codes.Code
+		wantErr  errors.ErrorCode
+	}{
+		{name: "invalid argument", err: errors.New(errors.ErrInvalidArgument, "bad"), wantCode: codes.InvalidArgument, wantErr: errors.ErrInvalidArgument},
+		{name: "missing required", err: errors.New(errors.ErrMissingRequired, "bad"), wantCode: codes.InvalidArgument, wantErr: errors.ErrMissingRequired},
+		{name: "invalid credentials", err: errors.New(errors.ErrCredentialInvalid, "revoked"), wantCode: codes.Unauthenticated, wantErr: errors.ErrCredentialInvalid},
+		{name: "permission denied", err: errors.New(errors.ErrPermissionDenied, "denied"), wantCode: codes.PermissionDenied, wantErr: errors.ErrPermissionDenied},
+		{name: "not found", err: errors.New(errors.ErrClusterNotFound, "missing"), wantCode: codes.NotFound, wantErr: errors.ErrClusterNotFound},
+		{name: "unreachable", err: errors.New(errors.ErrClusterUnreachable, "down"), wantCode: codes.Unavailable, wantErr: errors.ErrClusterUnreachable},
+		{name: "timeout", err: errors.New(errors.ErrNetworkTimeout, "slow"), wantCode: codes.DeadlineExceeded, wantErr: errors.ErrNetworkTimeout},
+		{name: "rate limited", err: errors.New(errors.ErrRateLimitExceeded, "slow down"), wantCode: codes.ResourceExhausted, wantErr: errors.ErrRateLimitExceeded},
+		{name: "generation failed", err: errors.New(errors.ErrTokenGenerationFailed, "failed"), wantCode: codes.Internal, wantErr: errors.ErrTokenGenerationFailed},
+		{name: "wrapped", err: fmt.Errorf("get token: %w", errors.New(errors.ErrCredentialExpired, "expired")), wantCode: codes.Unauthenticated, wantErr: errors.ErrCredentialExpired},
+		{name: "plain error", err: fmt.Errorf("boom"), wantCode: codes.Unknown, wantErr: errors.ErrUnknown},
+		{name: "deadline exceeded", err: fmt.Errorf("call: %w", context.DeadlineExceeded), wantCode: codes.DeadlineExceeded, wantErr: errors.ErrNetworkTimeout},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Status(tt.err).Err()
+			assert.Equal(t, tt.wantCode, status.Code(err))
+			assert.Equal(t, tt.wantErr, ErrorCode(err))
+			assert.Equal(t, tt.err.Error(), status.Convert(err).Message())
+		})
+	}
+}
+
+func TestStatus_Canceled(t *testing.T) {
+	st := Status(context.Canceled)
+	assert.Equal(t, codes.Canceled, st.Code())
+	assert.Empty(t, st.Details())
+}
+
+func TestStatus_Metadata(t *testing.T) {
+	err := errors.New(errors.ErrRateLimitExceeded, "rate limited").WithFields(map[string]interface{}{
+		"retry_after_seconds": 3,
+		"token":               "secret",
+	})
+
+	st := Status(err)
+	require.Len(t, st.Details(), 2)
+	info, ok := st.Details()[0].(*errdetails.ErrorInfo)
+	require.True(t, ok)
+	assert.Equal(t, "ERR_RATE_LIMIT_EXCEEDED", info.GetReason())
+	assert.Equal(t, ErrorDomain, info.GetDomain())
+	assert.Equal(t, map[string]string{"retry_after_seconds": "3"}, info.GetMetadata(), "sensitive fields are dropped")
+}
+
+func TestStatus_RetryInfo(t *testing.T) {
+	throttled := errors.New(errors.ErrRateLimitExceeded, "rate limited").WithField("retry_after_seconds", 3)
+	wait, ok := RetryDelay(Status(throttled).Err())
+	require.True(t, ok)
+	assert.Equal(t, 3*time.Second, wait)
+
+	// The Retry-After header of a cloud API response
+	cloud := errors.New(errors.ErrRateLimitExceeded, "sts throttled").WithField("retry_after", "7")
+	wait, ok = RetryDelay(Status(cloud).Err())
+	require.True(t, ok)
+	assert.Equal(t, 7*time.Second, wait)
+
+	_, ok = RetryDelay(Status(errors.New(errors.ErrRateLimitExceeded, "no hint")).Err())
+	assert.False(t, ok)
+	_, ok = RetryDelay(Status(errors.New(errors.ErrCredentialInvalid, "revoked")).Err())
+	assert.False(t, ok)
+}
+
+func TestStatus_PassesThroughStatusErrors(t *testing.T) {
+	err := status.Error(codes.Unavailable, "connection refused")
+	st := Status(err)
+	assert.Equal(t, codes.Unavailable, st.Code())
+	assert.Equal(t, errors.ErrUnknown, ErrorCode(st.Err()))
+}
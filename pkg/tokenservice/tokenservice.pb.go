@@ -0,0 +1,697 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        v5.29.3
+// source: tokenservice.proto
+
+package tokenservice
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// GetTokenRequest mirrors the token options of get-token. Fields left empty default
+// to the flags the server was started with.
+type GetTokenRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Kubernetes cluster name
+	ClusterName string `protobuf:"bytes,1,opt,name=cluster_name,json=clusterName,proto3" json:"cluster_name,omitempty"`
+	// Cloud region
+	Region string `protobuf:"bytes,2,opt,name=region,proto3" json:"region,omitempty"`
+	// GCP project ID (GCP only)
+	ProjectId string `protobuf:"bytes,3,opt,name=project_id,json=projectId,proto3" json:"project_id,omitempty"`
+	// AWS account ID (AWS only)
+	AccountId string `protobuf:"bytes,4,opt,name=account_id,json=accountId,proto3" json:"account_id,omitempty"`
+	// Cluster ID the token is bound to when it differs from cluster_name (AWS only)
+	ClusterId string `protobuf:"bytes,5,opt,name=cluster_id,json=clusterId,proto3" json:"cluster_id,omitempty"`
+	// Azure subscription ID (Azure only)
+	SubscriptionId string `protobuf:"bytes,6,opt,name=subscription_id,json=subscriptionId,proto3" json:"subscription_id,omitempty"`
+	// Azure tenant ID (Azure only)
+	TenantId string `protobuf:"bytes,7,opt,name=tenant_id,json=tenantId,proto3" json:"tenant_id,omitempty"`
+	// Azure resource group (Azure only)
+	ResourceGroup string `protobuf:"bytes,8,opt,name=resource_group,json=resourceGroup,proto3" json:"resource_group,omitempty"`
+	// Compartment searched for a cluster given by name (OCI only)
+	CompartmentId string `protobuf:"bytes,9,opt,name=compartment_id,json=compartmentId,proto3" json:"compartment_id,omitempty"`
+	// Audience the token is bound to instead of the cluster default
+	Audience      string `protobuf:"bytes,10,opt,name=audience,proto3" json:"audience,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetTokenRequest) Reset() {
+	*x = GetTokenRequest{}
+	mi := &file_tokenservice_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetTokenRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetTokenRequest) ProtoMessage() {}
+
+func (x *GetTokenRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_tokenservice_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetTokenRequest.ProtoReflect.Descriptor instead.
+func (*GetTokenRequest) Descriptor() ([]byte, []int) {
+	return file_tokenservice_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *GetTokenRequest) GetClusterName() string {
+	if x != nil {
+		return x.ClusterName
+	}
+	return ""
+}
+
+func (x *GetTokenRequest) GetRegion() string {
+	if x != nil {
+		return x.Region
+	}
+	return ""
+}
+
+func (x *GetTokenRequest) GetProjectId() string {
+	if x != nil {
+		return x.ProjectId
+	}
+	return ""
+}
+
+func (x *GetTokenRequest) GetAccountId() string {
+	if x != nil {
+		return x.AccountId
+	}
+	return ""
+}
+
+func (x *GetTokenRequest) GetClusterId() string {
+	if x != nil {
+		return x.ClusterId
+	}
+	return ""
+}
+
+func (x *GetTokenRequest) GetSubscriptionId() string {
+	if x != nil {
+		return x.SubscriptionId
+	}
+	return ""
+}
+
+func (x *GetTokenRequest) GetTenantId() string {
+	if x != nil {
+		return x.TenantId
+	}
+	return ""
+}
+
+func (x *GetTokenRequest) GetResourceGroup() string {
+	if x != nil {
+		return x.ResourceGroup
+	}
+	return ""
+}
+
+func (x *GetTokenRequest) GetCompartmentId() string {
+	if x != nil {
+		return x.CompartmentId
+	}
+	return ""
+}
+
+func (x *GetTokenRequest) GetAudience() string {
+	if x != nil {
+		return x.Audience
+	}
+	return ""
+}
+
+// Token is a Kubernetes bearer token and how it was issued
+type Token struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Bearer token for authentication
+	AccessToken string `protobuf:"bytes,1,opt,name=access_token,json=accessToken,proto3" json:"access_token,omitempty"`
+	// When the token expires
+	ExpiresAt *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	// Token type, usually "Bearer"
+	TokenType string `protobuf:"bytes,3,opt,name=token_type,json=tokenType,proto3" json:"token_type,omitempty"`
+	// Name of the provider that issued the token
+	Provider string `protobuf:"bytes,4,opt,name=provider,proto3" json:"provider,omitempty"`
+	// Cluster the token was generated for
+	ClusterName string `protobuf:"bytes,5,opt,name=cluster_name,json=clusterName,proto3" json:"cluster_name,omitempty"`
+	// Principal that minted the token, safe to log
+	Identity string `protobuf:"bytes,6,opt,name=identity,proto3" json:"identity,omitempty"`
+	// Audience the token is bound to, when the provider binds one
+	Audience string `protobuf:"bytes,7,opt,name=audience,proto3" json:"audience,omitempty"`
+	// OAuth2 scopes the token was requested with
+	Scopes        []string `protobuf:"bytes,8,rep,name=scopes,proto3" json:"scopes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Token) Reset() {
+	*x = Token{}
+	mi := &file_tokenservice_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Token) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Token) ProtoMessage() {}
+
+func (x *Token) ProtoReflect() protoreflect.Message {
+	mi := &file_tokenservice_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Token.ProtoReflect.Descriptor instead.
+func (*Token) Descriptor() ([]byte, []int) {
+	return file_tokenservice_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Token) GetAccessToken() string {
+	if x != nil {
+		return x.AccessToken
+	}
+	return ""
+}
+
+func (x *Token) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+func (x *Token) GetTokenType() string {
+	if x != nil {
+		return x.TokenType
+	}
+	return ""
+}
+
+func (x *Token) GetProvider() string {
+	if x != nil {
+		return x.Provider
+	}
+	return ""
+}
+
+func (x *Token) GetClusterName() string {
+	if x != nil {
+		return x.ClusterName
+	}
+	return ""
+}
+
+func (x *Token) GetIdentity() string {
+	if x != nil {
+		return x.Identity
+	}
+	return ""
+}
+
+func (x *Token) GetAudience() string {
+	if x != nil {
+		return x.Audience
+	}
+	return ""
+}
+
+func (x *Token) GetScopes() []string {
+	if x != nil {
+		return x.Scopes
+	}
+	return nil
+}
+
+// ExecCredential is a token formatted for a kubectl exec credential plugin
+type ExecCredential struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// ExecCredential JSON
+	Json string `protobuf:"bytes,1,opt,name=json,proto3" json:"json,omitempty"`
+	// When the token expires
+	ExpiresAt     *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExecCredential) Reset() {
+	*x = ExecCredential{}
+	mi := &file_tokenservice_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExecCredential) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExecCredential) ProtoMessage() {}
+
+func (x *ExecCredential) ProtoReflect() protoreflect.Message {
+	mi := &file_tokenservice_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExecCredential.ProtoReflect.Descriptor instead.
+func (*ExecCredential) Descriptor() ([]byte, []int) {
+	return file_tokenservice_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ExecCredential) GetJson() string {
+	if x != nil {
+		return x.Json
+	}
+	return ""
+}
+
+func (x *ExecCredential) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+// GetClusterInfoRequest identifies the cluster to describe. Fields left empty
+// default to the flags the server was started with.
+type GetClusterInfoRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Kubernetes cluster name
+	ClusterName string `protobuf:"bytes,1,opt,name=cluster_name,json=clusterName,proto3" json:"cluster_name,omitempty"`
+	// Cloud region or location (GCP, AWS and OCI)
+	Region string `protobuf:"bytes,2,opt,name=region,proto3" json:"region,omitempty"`
+	// Azure resource group (Azure only)
+	ResourceGroup string `protobuf:"bytes,3,opt,name=resource_group,json=resourceGroup,proto3" json:"resource_group,omitempty"`
+	// API server endpoint of clusters that have both: private or public
+	EndpointAccess string `protobuf:"bytes,4,opt,name=endpoint_access,json=endpointAccess,proto3" json:"endpoint_access,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *GetClusterInfoRequest) Reset() {
+	*x = GetClusterInfoRequest{}
+	mi := &file_tokenservice_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetClusterInfoRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetClusterInfoRequest) ProtoMessage() {}
+
+func (x *GetClusterInfoRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_tokenservice_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetClusterInfoRequest.ProtoReflect.Descriptor instead.
+func (*GetClusterInfoRequest) Descriptor() ([]byte, []int) {
+	return file_tokenservice_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetClusterInfoRequest) GetClusterName() string {
+	if x != nil {
+		return x.ClusterName
+	}
+	return ""
+}
+
+func (x *GetClusterInfoRequest) GetRegion() string {
+	if x != nil {
+		return x.Region
+	}
+	return ""
+}
+
+func (x *GetClusterInfoRequest) GetResourceGroup() string {
+	if x != nil {
+		return x.ResourceGroup
+	}
+	return ""
+}
+
+func (x *GetClusterInfoRequest) GetEndpointAccess() string {
+	if x != nil {
+		return x.EndpointAccess
+	}
+	return ""
+}
+
+// ClusterInfo is the provider-independent description of a cluster
+type ClusterInfo struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Cluster API server URL
+	Endpoint string `protobuf:"bytes,1,opt,name=endpoint,proto3" json:"endpoint,omitempty"`
+	// Base64-encoded cluster CA certificate
+	CertificateAuthority string `protobuf:"bytes,2,opt,name=certificate_authority,json=certificateAuthority,proto3" json:"certificate_authority,omitempty"`
+	// Kubernetes version
+	Version string `protobuf:"bytes,3,opt,name=version,proto3" json:"version,omitempty"`
+	// Cluster location (GCP and Azure)
+	Location string `protobuf:"bytes,4,opt,name=location,proto3" json:"location,omitempty"`
+	// Cluster region (AWS, OCI and DigitalOcean)
+	Region string `protobuf:"bytes,5,opt,name=region,proto3" json:"region,omitempty"`
+	// Cluster ARN (AWS only)
+	Arn string `protobuf:"bytes,6,opt,name=arn,proto3" json:"arn,omitempty"`
+	// Cluster resource ID (Azure), OCID (OCI) or UUID (DigitalOcean)
+	ResourceId    string `protobuf:"bytes,7,opt,name=resource_id,json=resourceId,proto3" json:"resource_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ClusterInfo) Reset() {
+	*x = ClusterInfo{}
+	mi := &file_tokenservice_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ClusterInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClusterInfo) ProtoMessage() {}
+
+func (x *ClusterInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_tokenservice_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClusterInfo.ProtoReflect.Descriptor instead.
+func (*ClusterInfo) Descriptor() ([]byte, []int) {
+	return file_tokenservice_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ClusterInfo) GetEndpoint() string {
+	if x != nil {
+		return x.Endpoint
+	}
+	return ""
+}
+
+func (x *ClusterInfo) GetCertificateAuthority() string {
+	if x != nil {
+		return x.CertificateAuthority
+	}
+	return ""
+}
+
+func (x *ClusterInfo) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *ClusterInfo) GetLocation() string {
+	if x != nil {
+		return x.Location
+	}
+	return ""
+}
+
+func (x *ClusterInfo) GetRegion() string {
+	if x != nil {
+		return x.Region
+	}
+	return ""
+}
+
+func (x *ClusterInfo) GetArn() string {
+	if x != nil {
+		return x.Arn
+	}
+	return ""
+}
+
+func (x *ClusterInfo) GetResourceId() string {
+	if x != nil {
+		return x.ResourceId
+	}
+	return ""
+}
+
+// ValidateCredentialsRequest takes no parameters: the server validates the
+// credentials it was started with
+type ValidateCredentialsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ValidateCredentialsRequest) Reset() {
+	*x = ValidateCredentialsRequest{}
+	mi := &file_tokenservice_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ValidateCredentialsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ValidateCredentialsRequest) ProtoMessage() {}
+
+func (x *ValidateCredentialsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_tokenservice_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ValidateCredentialsRequest.ProtoReflect.Descriptor instead.
+func (*ValidateCredentialsRequest) Descriptor() ([]byte, []int) {
+	return file_tokenservice_proto_rawDescGZIP(), []int{5}
+}
+
+// ValidateCredentialsResponse reports credentials that are valid; invalid ones
+// fail the call
+type ValidateCredentialsResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Name of the provider whose credentials were validated
+	Provider      string `protobuf:"bytes,1,opt,name=provider,proto3" json:"provider,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ValidateCredentialsResponse) Reset() {
+	*x = ValidateCredentialsResponse{}
+	mi := &file_tokenservice_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ValidateCredentialsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ValidateCredentialsResponse) ProtoMessage() {}
+
+func (x *ValidateCredentialsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_tokenservice_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ValidateCredentialsResponse.ProtoReflect.Descriptor instead.
+func (*ValidateCredentialsResponse) Descriptor() ([]byte, []int) {
+	return file_tokenservice_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ValidateCredentialsResponse) GetProvider() string {
+	if x != nil {
+		return x.Provider
+	}
+	return ""
+}
+
+var File_tokenservice_proto protoreflect.FileDescriptor
+
+const file_tokenservice_proto_rawDesc = "" +
+	"\n" +
+	"\x12tokenservice.proto\x12 hyperfleet.credentialprovider.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\xd9\x02\n" +
+	"\x0fGetTokenRequest\x12!\n" +
+	"\fcluster_name\x18\x01 \x01(\tR\vclusterName\x12\x16\n" +
+	"\x06region\x18\x02 \x01(\tR\x06region\x12\x1d\n" +
+	"\n" +
+	"project_id\x18\x03 \x01(\tR\tprojectId\x12\x1d\n" +
+	"\n" +
+	"account_id\x18\x04 \x01(\tR\taccountId\x12\x1d\n" +
+	"\n" +
+	"cluster_id\x18\x05 \x01(\tR\tclusterId\x12'\n" +
+	"\x0fsubscription_id\x18\x06 \x01(\tR\x0esubscriptionId\x12\x1b\n" +
+	"\ttenant_id\x18\a \x01(\tR\btenantId\x12%\n" +
+	"\x0eresource_group\x18\b \x01(\tR\rresourceGroup\x12%\n" +
+	"\x0ecompartment_id\x18\t \x01(\tR\rcompartmentId\x12\x1a\n" +
+	"\baudience\x18\n" +
+	" \x01(\tR\baudience\"\x93\x02\n" +
+	"\x05Token\x12!\n" +
+	"\faccess_token\x18\x01 \x01(\tR\vaccessToken\x129\n" +
+	"\n" +
+	"expires_at\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\x12\x1d\n" +
+	"\n" +
+	"token_type\x18\x03 \x01(\tR\ttokenType\x12\x1a\n" +
+	"\bprovider\x18\x04 \x01(\tR\bprovider\x12!\n" +
+	"\fcluster_name\x18\x05 \x01(\tR\vclusterName\x12\x1a\n" +
+	"\bidentity\x18\x06 \x01(\tR\bidentity\x12\x1a\n" +
+	"\baudience\x18\a \x01(\tR\baudience\x12\x16\n" +
+	"\x06scopes\x18\b \x03(\tR\x06scopes\"_\n" +
+	"\x0eExecCredential\x12\x12\n" +
+	"\x04json\x18\x01 \x01(\tR\x04json\x129\n" +
+	"\n" +
+	"expires_at\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\"\xa2\x01\n" +
+	"\x15GetClusterInfoRequest\x12!\n" +
+	"\fcluster_name\x18\x01 \x01(\tR\vclusterName\x12\x16\n" +
+	"\x06region\x18\x02 \x01(\tR\x06region\x12%\n" +
+	"\x0eresource_group\x18\x03 \x01(\tR\rresourceGroup\x12'\n" +
+	"\x0fendpoint_access\x18\x04 \x01(\tR\x0eendpointAccess\"\xdf\x01\n" +
+	"\vClusterInfo\x12\x1a\n" +
+	"\bendpoint\x18\x01 \x01(\tR\bendpoint\x123\n" +
+	"\x15certificate_authority\x18\x02 \x01(\tR\x14certificateAuthority\x12\x18\n" +
+	"\aversion\x18\x03 \x01(\tR\aversion\x12\x1a\n" +
+	"\blocation\x18\x04 \x01(\tR\blocation\x12\x16\n" +
+	"\x06region\x18\x05 \x01(\tR\x06region\x12\x10\n" +
+	"\x03arn\x18\x06 \x01(\tR\x03arn\x12\x1f\n" +
+	"\vresource_id\x18\a \x01(\tR\n" +
+	"resourceId\"\x1c\n" +
+	"\x1aValidateCredentialsRequest\"9\n" +
+	"\x1bValidateCredentialsResponse\x12\x1a\n" +
+	"\bprovider\x18\x01 \x01(\tR\bprovider2\xff\x03\n" +
+	"\fTokenService\x12f\n" +
+	"\bGetToken\x121.hyperfleet.credentialprovider.v1.GetTokenRequest\x1a'.hyperfleet.credentialprovider.v1.Token\x12x\n" +
+	"\x11GetExecCredential\x121.hyperfleet.credentialprovider.v1.GetTokenRequest\x1a0.hyperfleet.credentialprovider.v1.ExecCredential\x12x\n" +
+	"\x0eGetClusterInfo\x127.hyperfleet.credentialprovider.v1.GetClusterInfoRequest\x1a-.hyperfleet.credentialprovider.v1.ClusterInfo\x12\x92\x01\n" +
+	"\x13ValidateCredentials\x12<.hyperfleet.credentialprovider.v1.ValidateCredentialsRequest\x1a=.hyperfleet.credentialprovider.v1.ValidateCredentialsResponseBQZOgithub.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/tokenserviceb\x06proto3"
+
+var (
+	file_tokenservice_proto_rawDescOnce sync.Once
+	file_tokenservice_proto_rawDescData []byte
+)
+
+func file_tokenservice_proto_rawDescGZIP() []byte {
+	file_tokenservice_proto_rawDescOnce.Do(func() {
+		file_tokenservice_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_tokenservice_proto_rawDesc), len(file_tokenservice_proto_rawDesc)))
+	})
+	return file_tokenservice_proto_rawDescData
+}
+
+var file_tokenservice_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_tokenservice_proto_goTypes = []any{
+	(*GetTokenRequest)(nil),             // 0: hyperfleet.credentialprovider.v1.GetTokenRequest
+	(*Token)(nil),                       // 1: hyperfleet.credentialprovider.v1.Token
+	(*ExecCredential)(nil),              // 2: hyperfleet.credentialprovider.v1.ExecCredential
+	(*GetClusterInfoRequest)(nil),       // 3: hyperfleet.credentialprovider.v1.GetClusterInfoRequest
+	(*ClusterInfo)(nil),                 // 4: hyperfleet.credentialprovider.v1.ClusterInfo
+	(*ValidateCredentialsRequest)(nil),  // 5: hyperfleet.credentialprovider.v1.ValidateCredentialsRequest
+	(*ValidateCredentialsResponse)(nil), // 6: hyperfleet.credentialprovider.v1.ValidateCredentialsResponse
+	(*timestamppb.Timestamp)(nil),       // 7: google.protobuf.Timestamp
+}
+var file_tokenservice_proto_depIdxs = []int32{
+	7, // 0: hyperfleet.credentialprovider.v1.Token.expires_at:type_name -> google.protobuf.Timestamp
+	7, // 1: hyperfleet.credentialprovider.v1.ExecCredential.expires_at:type_name -> google.protobuf.Timestamp
+	0, // 2: hyperfleet.credentialprovider.v1.TokenService.GetToken:input_type -> hyperfleet.credentialprovider.v1.GetTokenRequest
+	0, // 3: hyperfleet.credentialprovider.v1.TokenService.GetExecCredential:input_type -> hyperfleet.credentialprovider.v1.GetTokenRequest
+	3, // 4: hyperfleet.credentialprovider.v1.TokenService.GetClusterInfo:input_type -> hyperfleet.credentialprovider.v1.GetClusterInfoRequest
+	5, // 5: hyperfleet.credentialprovider.v1.TokenService.ValidateCredentials:input_type -> hyperfleet.credentialprovider.v1.ValidateCredentialsRequest
+	1, // 6: hyperfleet.credentialprovider.v1.TokenService.GetToken:output_type -> hyperfleet.credentialprovider.v1.Token
+	2, // 7: hyperfleet.credentialprovider.v1.TokenService.GetExecCredential:output_type -> hyperfleet.credentialprovider.v1.ExecCredential
+	4, // 8: hyperfleet.credentialprovider.v1.TokenService.GetClusterInfo:output_type -> hyperfleet.credentialprovider.v1.ClusterInfo
+	6, // 9: hyperfleet.credentialprovider.v1.TokenService.ValidateCredentials:output_type -> hyperfleet.credentialprovider.v1.ValidateCredentialsResponse
+	6, // [6:10] is the sub-list for method output_type
+	2, // [2:6] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
+}
+
+func init() { file_tokenservice_proto_init() }
+func file_tokenservice_proto_init() {
+	if File_tokenservice_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_tokenservice_proto_rawDesc), len(file_tokenservice_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   7,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_tokenservice_proto_goTypes,
+		DependencyIndexes: file_tokenservice_proto_depIdxs,
+		MessageInfos:      file_tokenservice_proto_msgTypes,
+	}.Build()
+	File_tokenservice_proto = out.File
+	file_tokenservice_proto_goTypes = nil
+	file_tokenservice_proto_depIdxs = nil
+}
@@ -0,0 +1,74 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+)
+
+// BaggageKeys are the caller baggage members recorded on spans and log entries and
+// forwarded to cloud APIs. Hub controllers set them to correlate a reconcile with the
+// credential requests it makes; other members are ignored.
+var BaggageKeys = []string{
+	"hyperfleet.cluster",
+	"hyperfleet.fleet",
+	"hyperfleet.request_id",
+}
+
+// baggageHeader is the W3C baggage header
+const baggageHeader = "baggage"
+
+// Baggage returns the allowed baggage members of ctx, in BaggageKeys order. A nil
+// provider means tracing is disabled and returns nil.
+func (p *Provider) Baggage(ctx context.Context) []baggage.Member {
+	if p == nil {
+		return nil
+	}
+	bag := baggage.FromContext(ctx)
+	var members []baggage.Member
+	for _, key := range BaggageKeys {
+		if member := bag.Member(key); member.Key() != "" {
+			members = append(members, member)
+		}
+	}
+	return members
+}
+
+// BaggageAttributes returns the allowed baggage members of ctx as span attributes
+func (p *Provider) BaggageAttributes(ctx context.Context) []attribute.KeyValue {
+	members := p.Baggage(ctx)
+	attrs := make([]attribute.KeyValue, 0, len(members))
+	for _, member := range members {
+		attrs = append(attrs, attribute.String(member.Key(), member.Value()))
+	}
+	return attrs
+}
+
+// InjectBaggage sets the W3C baggage header of an outgoing request to the allowed
+// baggage members of ctx. It leaves the header unset when there are none.
+func (p *Provider) InjectBaggage(ctx context.Context, header http.Header) {
+	if value := p.BaggageHeader(ctx); value != "" {
+		header.Set(baggageHeader, value)
+	}
+}
+
+// BaggageHeader returns the W3C baggage header value carrying the allowed baggage
+// members of ctx in BaggageKeys order, or "" when there are none
+func (p *Provider) BaggageHeader(ctx context.Context) string {
+	members := p.Baggage(ctx)
+	if len(members) == 0 {
+		return ""
+	}
+	if _, err := baggage.New(members...); err != nil {
+		return ""
+	}
+	// Baggage.String does not keep the member order, so join the members directly
+	parts := make([]string, len(members))
+	for i, member := range members {
+		parts[i] = member.String()
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,82 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// contextWithBaggage returns a context carrying the given W3C baggage header value
+func contextWithBaggage(t *testing.T, header string) context.Context {
+	t.Helper()
+	bag, err := baggage.Parse(header)
+	require.NoError(t, err)
+	return baggage.ContextWithBaggage(context.Background(), bag)
+}
+
+func TestProvider_BaggageAttributes(t *testing.T) {
+	tests := []struct {
+		name      string
+		baggage   string
+		wantAttrs []attribute.KeyValue
+		wantValue string
+	}{
+		{
+			name:    "allowed keys in allowlist order",
+			baggage: "hyperfleet.request_id=req-42,hyperfleet.cluster=spoke-1,hyperfleet.fleet=eu",
+			wantAttrs: []attribute.KeyValue{
+				attribute.String("hyperfleet.cluster", "spoke-1"),
+				attribute.String("hyperfleet.fleet", "eu"),
+				attribute.String("hyperfleet.request_id", "req-42"),
+			},
+			wantValue: "hyperfleet.cluster=spoke-1,hyperfleet.fleet=eu,hyperfleet.request_id=req-42",
+		},
+		{
+			name:      "keys outside the allowlist are ignored",
+			baggage:   "hyperfleet.cluster=spoke-1,user.id=alice,hyperfleet.secret=s3cr3t",
+			wantAttrs: []attribute.KeyValue{attribute.String("hyperfleet.cluster", "spoke-1")},
+			wantValue: "hyperfleet.cluster=spoke-1",
+		},
+		{
+			name:      "no allowed keys",
+			baggage:   "tenant=acme",
+			wantAttrs: []attribute.KeyValue{},
+		},
+	}
+
+	provider := NewProviderFromTracerProvider(sdktrace.NewTracerProvider(), DefaultConfig())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := contextWithBaggage(t, tt.baggage)
+
+			assert.Equal(t, tt.wantAttrs, provider.BaggageAttributes(ctx))
+			assert.Equal(t, tt.wantValue, provider.BaggageHeader(ctx))
+
+			header := http.Header{}
+			provider.InjectBaggage(ctx, header)
+			if tt.wantValue == "" {
+				assert.NotContains(t, header, "Baggage")
+			} else {
+				assert.Equal(t, tt.wantValue, header.Get("baggage"))
+			}
+		})
+	}
+}
+
+func TestProvider_BaggageDisabled(t *testing.T) {
+	var provider *Provider
+	ctx := contextWithBaggage(t, "hyperfleet.cluster=spoke-1")
+
+	assert.Empty(t, provider.Baggage(ctx))
+	assert.Empty(t, provider.BaggageAttributes(ctx))
+
+	header := http.Header{}
+	provider.InjectBaggage(ctx, header)
+	assert.Empty(t, header)
+}
@@ -12,6 +12,7 @@ import (
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
 	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
 )
 
 // Config holds tracing configuration
@@ -122,9 +123,19 @@ func NewProvider(ctx context.Context, config Config) (*Provider, error) {
 	}, nil
 }
 
+// NewProviderFromTracerProvider wraps an existing tracer provider, for example one
+// that records spans in memory in tests. The global tracer provider is left unchanged.
+func NewProviderFromTracerProvider(tp *sdktrace.TracerProvider, config Config) *Provider {
+	return &Provider{
+		tp:     tp,
+		tracer: tp.Tracer(config.ServiceName),
+		config: config,
+	}
+}
+
 // Shutdown shuts down the tracer provider
 func (p *Provider) Shutdown(ctx context.Context) error {
-	if p.tp != nil {
+	if p != nil && p.tp != nil {
 		return p.tp.Shutdown(ctx)
 	}
 	return nil
@@ -135,8 +146,12 @@ func (p *Provider) Tracer() trace.Tracer {
 	return p.tracer
 }
 
-// StartSpan starts a new span with the given name
+// StartSpan starts a new span with the given name. A nil provider starts spans that
+// are not recorded, so callers need not check whether tracing is configured.
 func (p *Provider) StartSpan(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	if p == nil {
+		return noop.NewTracerProvider().Tracer("").Start(ctx, name, opts...)
+	}
 	return p.tracer.Start(ctx, name, opts...)
 }
 
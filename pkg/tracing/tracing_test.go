@@ -9,6 +9,8 @@ import (
 	"github.com/stretchr/testify/require"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
 func TestDefaultConfig(t *testing.T) {
@@ -333,3 +335,35 @@ func TestProvider_ConfigValidation(t *testing.T) {
 		})
 	}
 }
+
+func TestProvider_NilStartsNonRecordingSpans(t *testing.T) {
+	var provider *Provider
+
+	ctx, span := provider.StartSpan(context.Background(), "untraced-operation")
+	require.NotNil(t, ctx)
+	assert.False(t, span.IsRecording())
+	span.End()
+
+	assert.NoError(t, provider.Shutdown(context.Background()))
+}
+
+func TestNewProviderFromTracerProvider(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := NewProviderFromTracerProvider(
+		sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)),
+		DefaultConfig(),
+	)
+	defer provider.Shutdown(context.Background())
+
+	spanCtx, span := provider.StartSpan(context.Background(), "recorded-operation")
+	SetAttributes(spanCtx, attribute.String("key", "value"))
+	RecordError(spanCtx, assert.AnError)
+	span.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "recorded-operation", spans[0].Name())
+	assert.Contains(t, spans[0].Attributes(), attribute.String("key", "value"))
+	require.Len(t, spans[0].Events(), 1)
+	assert.Equal(t, "exception", spans[0].Events()[0].Name)
+}
@@ -5,7 +5,13 @@ package integration
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -14,6 +20,7 @@ import (
 
 	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider"
 	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider/gcp"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/errors"
 	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
 )
 
@@ -196,14 +203,62 @@ func TestGCPInvalidCredentials(t *testing.T) {
 		assert.Error(t, err, "Should fail when trying to use invalid credentials")
 	})
 
+	t.Run("RevokedKey", func(t *testing.T) {
+		// A well-formed key for a service account Google does not know passes the
+		// offline checks; only the token fetch can reject it
+		credentialsFile := writeUnknownServiceAccountKey(t, "fake-project")
+		config := &gcp.Config{
+			ProjectID:       "fake-project",
+			CredentialsFile: credentialsFile,
+			TokenDuration:   1 * time.Hour,
+			Scopes:          gcp.DefaultScopes(),
+			UseADC:          gcp.ADCModeNever,
+		}
+
+		p, err := gcp.NewProvider(config, log)
+		require.NoError(t, err)
+
+		err = p.ValidateCredentials(context.Background())
+		require.Error(t, err, "Validation should fail when the token endpoint rejects the key")
+		assert.True(t, errors.Is(err, errors.ErrCredentialInvalid), "expected %s, got %v", errors.ErrCredentialInvalid, err)
+	})
+
 	t.Run("MissingProjectID", func(t *testing.T) {
 		config := &gcp.Config{
 			ProjectID:     "", // Missing
 			TokenDuration: 1 * time.Hour,
 			Scopes:        gcp.DefaultScopes(),
+			UseADC:        gcp.ADCModeNever,
 		}
 
 		_, err := gcp.NewProvider(config, log)
 		assert.Error(t, err, "Should fail with missing project ID")
 	})
 }
+
+// writeUnknownServiceAccountKey writes a well-formed service account key, signed with a
+// freshly generated RSA key, for a service account that does not exist
+func writeUnknownServiceAccountKey(t *testing.T, projectID string) string {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	data, err := json.Marshal(map[string]string{
+		"type":           "service_account",
+		"project_id":     projectID,
+		"private_key_id": "0123456789abcdef0123456789abcdef01234567",
+		"private_key":    string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		"client_email":   "revoked-key@" + projectID + ".iam.gserviceaccount.com",
+		"client_id":      "100000000000000000000",
+		"auth_uri":       "https://accounts.google.com/o/oauth2/auth",
+		"token_uri":      "https://oauth2.googleapis.com/token",
+	})
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "revoked-sa.json")
+	require.NoError(t, os.WriteFile(path, data, 0600))
+	return path
+}
@@ -0,0 +1,95 @@
+//go:build integration
+// +build integration
+
+package integration
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider/aws"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider/azure"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/internal/provider/gcp"
+	"github.com/openshift-hyperfleet/hyperfleet-credential-provider/pkg/logger"
+)
+
+// TestAWSWhoAmI calls STS GetCallerIdentity with real credentials
+// Requires:
+//   - AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY or AWS_CREDENTIALS_FILE
+//   - AWS_TEST_REGION: AWS region (e.g., us-east-1)
+func TestAWSWhoAmI(t *testing.T) {
+	region := os.Getenv("AWS_TEST_REGION")
+	if region == "" {
+		t.Skip("Skipping AWS whoami test: AWS_TEST_REGION not set")
+	}
+	if (os.Getenv("AWS_ACCESS_KEY_ID") == "" || os.Getenv("AWS_SECRET_ACCESS_KEY") == "") && os.Getenv("AWS_CREDENTIALS_FILE") == "" {
+		t.Skip("Skipping AWS whoami test: no AWS credentials found (AWS_ACCESS_KEY_ID/AWS_SECRET_ACCESS_KEY or AWS_CREDENTIALS_FILE)")
+	}
+
+	p, err := aws.NewProvider(&aws.Config{Region: region}, logger.Nop())
+	require.NoError(t, err, "Failed to create AWS provider")
+
+	identity, err := p.WhoAmI(context.Background())
+	require.NoError(t, err, "Failed to get caller identity")
+	assert.Len(t, identity.Account, 12, "Account should be a 12-digit account ID")
+	assert.Contains(t, identity.ARN, ":"+identity.Account+":")
+	assert.NotEmpty(t, identity.Type)
+	assert.NotEmpty(t, identity.UserID)
+
+	t.Logf("AWS identity: type=%s arn=%s", identity.Type, identity.ARN)
+}
+
+// TestGCPWhoAmI reads the service account of a real credentials file
+// Requires:
+//   - GOOGLE_APPLICATION_CREDENTIALS: service account key file
+//   - GCP_TEST_PROJECT_ID: GCP project ID
+func TestGCPWhoAmI(t *testing.T) {
+	projectID := os.Getenv("GCP_TEST_PROJECT_ID")
+	credentialsFile := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS")
+	if projectID == "" || credentialsFile == "" {
+		t.Skip("Skipping GCP whoami test: missing required environment variables (GCP_TEST_PROJECT_ID, GOOGLE_APPLICATION_CREDENTIALS)")
+	}
+
+	p, err := gcp.NewProvider(&gcp.Config{
+		ProjectID:       projectID,
+		CredentialsFile: credentialsFile,
+		Scopes:          gcp.DefaultScopes(),
+	}, logger.Nop())
+	require.NoError(t, err, "Failed to create GCP provider")
+
+	identity, err := p.WhoAmI(context.Background())
+	require.NoError(t, err, "Failed to get identity")
+	assert.Equal(t, "service_account", identity.Type)
+	assert.Contains(t, identity.Email, "@")
+
+	t.Logf("GCP identity: email=%s", identity.Email)
+}
+
+// TestAzureWhoAmI reads the service principal from a real Azure AD token
+// Requires:
+//   - AZURE_CLIENT_ID and AZURE_CLIENT_SECRET or AZURE_CREDENTIALS_FILE
+//   - AZURE_TENANT_ID: Azure tenant ID
+func TestAzureWhoAmI(t *testing.T) {
+	tenantID := os.Getenv("AZURE_TENANT_ID")
+	if tenantID == "" {
+		t.Skip("Skipping Azure whoami test: AZURE_TENANT_ID not set")
+	}
+	if (os.Getenv("AZURE_CLIENT_ID") == "" || os.Getenv("AZURE_CLIENT_SECRET") == "") && os.Getenv("AZURE_CREDENTIALS_FILE") == "" {
+		t.Skip("Skipping Azure whoami test: no Azure credentials found (AZURE_CLIENT_ID/AZURE_CLIENT_SECRET or AZURE_CREDENTIALS_FILE)")
+	}
+
+	p, err := azure.NewProvider(&azure.Config{TenantID: tenantID}, logger.Nop())
+	require.NoError(t, err, "Failed to create Azure provider")
+
+	identity, err := p.WhoAmI(context.Background())
+	require.NoError(t, err, "Failed to get identity")
+	assert.Equal(t, tenantID, identity.TenantID)
+	assert.NotEmpty(t, identity.AppID)
+	assert.NotEmpty(t, identity.ObjectID)
+
+	t.Logf("Azure identity: appId=%s objectId=%s", identity.AppID, identity.ObjectID)
+}